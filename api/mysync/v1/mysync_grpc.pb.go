@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: mysync/v1/mysync.proto
+
+// Management API of mysync agent
+
+package mysyncv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MySync_GetClusterState_FullMethodName   = "/mysync.v1.MySync/GetClusterState"
+	MySync_StreamEvents_FullMethodName      = "/mysync.v1.MySync/StreamEvents"
+	MySync_RequestSwitchover_FullMethodName = "/mysync.v1.MySync/RequestSwitchover"
+	MySync_SetMaintenance_FullMethodName    = "/mysync.v1.MySync/SetMaintenance"
+)
+
+// MySyncClient is the client API for MySync service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MySyncClient interface {
+	// GetClusterState returns cluster state as seen in DCS
+	GetClusterState(ctx context.Context, in *GetClusterStateRequest, opts ...grpc.CallOption) (*ClusterState, error)
+	// StreamEvents sends recorded cluster events as they happen
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// RequestSwitchover schedules manual switchover, it is performed by the manager
+	RequestSwitchover(ctx context.Context, in *RequestSwitchoverRequest, opts ...grpc.CallOption) (*Switchover, error)
+	// SetMaintenance enables or disables maintenance mode
+	SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*Maintenance, error)
+}
+
+type mySyncClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMySyncClient(cc grpc.ClientConnInterface) MySyncClient {
+	return &mySyncClient{cc}
+}
+
+func (c *mySyncClient) GetClusterState(ctx context.Context, in *GetClusterStateRequest, opts ...grpc.CallOption) (*ClusterState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterState)
+	err := c.cc.Invoke(ctx, MySync_GetClusterState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mySyncClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MySync_ServiceDesc.Streams[0], MySync_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySync_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *mySyncClient) RequestSwitchover(ctx context.Context, in *RequestSwitchoverRequest, opts ...grpc.CallOption) (*Switchover, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Switchover)
+	err := c.cc.Invoke(ctx, MySync_RequestSwitchover_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mySyncClient) SetMaintenance(ctx context.Context, in *SetMaintenanceRequest, opts ...grpc.CallOption) (*Maintenance, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Maintenance)
+	err := c.cc.Invoke(ctx, MySync_SetMaintenance_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MySyncServer is the server API for MySync service.
+// All implementations must embed UnimplementedMySyncServer
+// for forward compatibility.
+type MySyncServer interface {
+	// GetClusterState returns cluster state as seen in DCS
+	GetClusterState(context.Context, *GetClusterStateRequest) (*ClusterState, error)
+	// StreamEvents sends recorded cluster events as they happen
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// RequestSwitchover schedules manual switchover, it is performed by the manager
+	RequestSwitchover(context.Context, *RequestSwitchoverRequest) (*Switchover, error)
+	// SetMaintenance enables or disables maintenance mode
+	SetMaintenance(context.Context, *SetMaintenanceRequest) (*Maintenance, error)
+	mustEmbedUnimplementedMySyncServer()
+}
+
+// UnimplementedMySyncServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMySyncServer struct{}
+
+func (UnimplementedMySyncServer) GetClusterState(context.Context, *GetClusterStateRequest) (*ClusterState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterState not implemented")
+}
+func (UnimplementedMySyncServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedMySyncServer) RequestSwitchover(context.Context, *RequestSwitchoverRequest) (*Switchover, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestSwitchover not implemented")
+}
+func (UnimplementedMySyncServer) SetMaintenance(context.Context, *SetMaintenanceRequest) (*Maintenance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMaintenance not implemented")
+}
+func (UnimplementedMySyncServer) mustEmbedUnimplementedMySyncServer() {}
+func (UnimplementedMySyncServer) testEmbeddedByValue()                {}
+
+// UnsafeMySyncServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MySyncServer will
+// result in compilation errors.
+type UnsafeMySyncServer interface {
+	mustEmbedUnimplementedMySyncServer()
+}
+
+func RegisterMySyncServer(s grpc.ServiceRegistrar, srv MySyncServer) {
+	// If the following call pancis, it indicates UnimplementedMySyncServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MySync_ServiceDesc, srv)
+}
+
+func _MySync_GetClusterState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClusterStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MySyncServer).GetClusterState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MySync_GetClusterState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MySyncServer).GetClusterState(ctx, req.(*GetClusterStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MySync_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MySyncServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MySync_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+func _MySync_RequestSwitchover_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestSwitchoverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MySyncServer).RequestSwitchover(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MySync_RequestSwitchover_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MySyncServer).RequestSwitchover(ctx, req.(*RequestSwitchoverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MySync_SetMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMaintenanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MySyncServer).SetMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MySync_SetMaintenance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MySyncServer).SetMaintenance(ctx, req.(*SetMaintenanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MySync_ServiceDesc is the grpc.ServiceDesc for MySync service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MySync_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mysync.v1.MySync",
+	HandlerType: (*MySyncServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetClusterState",
+			Handler:    _MySync_GetClusterState_Handler,
+		},
+		{
+			MethodName: "RequestSwitchover",
+			Handler:    _MySync_RequestSwitchover_Handler,
+		},
+		{
+			MethodName: "SetMaintenance",
+			Handler:    _MySync_SetMaintenance_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _MySync_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mysync/v1/mysync.proto",
+}
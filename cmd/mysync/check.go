@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var checkCmd = &cobra.Command{
+	Use:     "check",
+	GroupID: "observe",
+	Short:   "Validate that host is ready to be managed by mysync",
+	Long:    "Checks config, DCS access, MySQL credentials and grants, semisync plugins, server_id uniqueness and clocks",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Printf("[FAIL] config: %v\n", err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliCheck())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
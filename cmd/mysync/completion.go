@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+// completeHosts completes cluster host names known to DCS
+func completeHosts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	app, err := app.NewApp(configFile, "Fatal", true)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return app.CompleteHosts(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	_ = switchCmd.RegisterFlagCompletionFunc("to", completeHosts)
+	_ = switchCmd.RegisterFlagCompletionFunc("from", completeHosts)
+	_ = hostAddCmd.RegisterFlagCompletionFunc("stream-from", completeHosts)
+	hostRemoveCmd.ValidArgsFunction = completeHosts
+	hostResetupCmd.ValidArgsFunction = completeHosts
+	hostConfigCmd.ValidArgsFunction = completeHosts
+	promoteCmd.ValidArgsFunction = completeHosts
+	logsCmd.ValidArgsFunction = completeHosts
+	_ = hostResetupCmd.RegisterFlagCompletionFunc("donor", completeHosts)
+}
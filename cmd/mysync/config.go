@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+	"github.com/yandex/mysync/internal/config"
+)
+
+var secretsKeyFile string
+
+var configCmd = &cobra.Command{
+	Use:     "config",
+	GroupID: "observe",
+	Short:   "Validate and print mysync config",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate config file",
+	Long:  "Checks types, required fields, duration formats and mutually exclusive options of config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		_, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", configFile)
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print effective config including defaults",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliConfigShow(format))
+	},
+}
+
+var configReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reload config of running mysync",
+	Long:  "Sends SIGHUP to running mysync, which applies timeouts, lag thresholds, log level and other parameters that are safe to change at runtime",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliReload())
+	},
+}
+
+var configGenKeyCmd = &cobra.Command{
+	Use:   "gen-key",
+	Short: "Generate key for config secrets encryption",
+	Long:  "Prints new random key, which should be saved to secrets_key_file or to KMS used by secrets_key_command",
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := config.GenerateSecretsKey()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(key)
+	},
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt secret for config file",
+	Long:  "Reads secret from stdin and prints encrypted value, which may be used in config instead of plaintext password",
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := config.ReadSecretsKeyFile(secretsKeyFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Printf("failed to read secret: %v\n", err)
+			os.Exit(1)
+		}
+		encrypted, err := config.EncryptSecret(key, strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(encrypted)
+	},
+}
+
+func init() {
+	configEncryptCmd.Flags().StringVar(&secretsKeyFile, "key-file", "", "file with key generated by `mysync config gen-key`")
+	_ = configEncryptCmd.MarkFlagRequired("key-file")
+	configCmd.AddCommand(configGenKeyCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configReloadCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var eventsFollow bool
+var eventsInterval time.Duration
+
+var eventsCmd = &cobra.Command{
+	Use:     "events",
+	GroupID: "observe",
+	Short:   "Print cluster events, optionally streaming new ones",
+	Long:    "With --follow streams recorded events, master changes and host health transitions from DCS until interrupted",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliEvents(eventsFollow, eventsInterval, format))
+	},
+}
+
+func init() {
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "stream new events")
+	eventsCmd.Flags().DurationVar(&eventsInterval, "interval", time.Second, "how often to poll DCS for new events")
+	rootCmd.AddCommand(eventsCmd)
+}
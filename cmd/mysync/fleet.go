@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var fleetClusters []string
+var fleetSelector string
+var fleetParallel int
+var fleetWait time.Duration
+var fleetDuration time.Duration
+
+var fleetCmd = &cobra.Command{
+	Use:     "fleet",
+	GroupID: "operations",
+	Short:   "Apply operation to many clusters at once",
+	Long: ("Runs operation on clusters of config files given by --clusters, which match --selector,\n" +
+		"and prints consolidated report. Selector is comma-separated list of label=pattern and label!=pattern\n" +
+		"terms matched against labels of cluster config, \"namespace\" label is zookeeper namespace of cluster,\n" +
+		"e.g. --selector 'env=prod,namespace=/mysync/eu-*'."),
+}
+
+var fleetMaintCmd = &cobra.Command{
+	Use:     "maintenance",
+	Aliases: []string{"maint", "mnt"},
+	Short:   "Enables or disables maintenance mode on selected clusters",
+}
+
+var fleetMaintOnCmd = &cobra.Command{
+	Use:     "on",
+	Aliases: []string{"enable"},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(app.RunFleet(fleetClusters, fleetSelector, fleetParallel, logLevel, format, app.FleetEnableMaintenance(fleetWait, fleetDuration)))
+	},
+}
+
+var fleetMaintOffCmd = &cobra.Command{
+	Use:     "off",
+	Aliases: []string{"disable"},
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(app.RunFleet(fleetClusters, fleetSelector, fleetParallel, logLevel, format, app.FleetDisableMaintenance(fleetWait)))
+	},
+}
+
+var fleetVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Report mysync and MySQL versions and config hash of hosts of selected clusters",
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(app.RunFleet(fleetClusters, fleetSelector, fleetParallel, logLevel, format, app.FleetVersions()))
+	},
+}
+
+var fleetConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage config of selected clusters",
+}
+
+var fleetConfigPushCmd = &cobra.Command{
+	Use:   "push key=value...",
+	Short: "Set config overrides on all hosts of selected clusters",
+	Long:  "Values are YAML, overrides are stored in DCS as with `mysync host config --set` and applied by `mysync config reload` or restart of mysync.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(app.RunFleet(fleetClusters, fleetSelector, fleetParallel, logLevel, format, app.FleetPushConfig(args)))
+	},
+}
+
+func init() {
+	fleetCmd.PersistentFlags().StringSliceVar(&fleetClusters, "clusters", nil, "config files of clusters, e.g. /etc/mysync.d/*.yaml")
+	_ = fleetCmd.MarkPersistentFlagRequired("clusters")
+	fleetCmd.PersistentFlags().StringVarP(&fleetSelector, "selector", "l", "", "labels of clusters to apply operation to, e.g. env=prod,namespace=/mysync/eu-*")
+	fleetCmd.PersistentFlags().IntVarP(&fleetParallel, "parallel", "p", 10, "how many clusters are processed at once")
+	fleetMaintCmd.PersistentFlags().DurationVarP(&fleetWait, "wait", "w", 30*time.Second, "how long to wait for maintenance activation, 0s to return immediately")
+	fleetMaintOnCmd.Flags().DurationVar(&fleetDuration, "duration", 0, "leave maintenance automatically after given time, e.g. 2h")
+	fleetMaintCmd.AddCommand(fleetMaintOnCmd)
+	fleetMaintCmd.AddCommand(fleetMaintOffCmd)
+	fleetConfigCmd.AddCommand(fleetConfigPushCmd)
+	fleetCmd.AddCommand(fleetMaintCmd)
+	fleetCmd.AddCommand(fleetVersionCmd)
+	fleetCmd.AddCommand(fleetConfigCmd)
+	rootCmd.AddCommand(fleetCmd)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var historySince time.Duration
+
+var historyCmd = &cobra.Command{
+	Use:     "history",
+	GroupID: "observe",
+	Short:   "Print history of failovers, switchovers, repairs and maintenances",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHistory(historySince, format))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().DurationVar(&historySince, "since", 0, "show only events happened within given duration, e.g. 12h")
+}
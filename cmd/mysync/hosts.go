@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -12,11 +13,20 @@ import (
 
 var streamFrom string
 var priority int64
+var semiSync bool
 var dryRun bool
 var skipMySQLCheck bool
+var relay bool
+var resetupDonor string
+var resetupMethod string
+var overridesSet []string
+var overridesUnset []string
+var quarantineTimeout time.Duration
+var drainWait time.Duration
 
 var hostCmd = &cobra.Command{
 	Use:     "host",
+	GroupID: "operations",
 	Aliases: []string{"hosts"},
 	Short:   "manage hosts in cluster",
 	Run: func(cmd *cobra.Command, args []string) {
@@ -25,7 +35,7 @@ var hostCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliHostList())
+		exitCli(app, app.CliHostList(format))
 	},
 }
 
@@ -41,17 +51,20 @@ var hostAddCmd = &cobra.Command{
 		}
 
 		var priorityVal *int64
+		var semiSyncVal *bool
 		var streamFromVar *string
 		cmd.Flags().Visit(func(f *pflag.Flag) {
 			switch f.Name {
 			case "priority":
 				priorityVal = &priority
+			case "semi-sync":
+				semiSyncVal = &semiSync
 			case "stream-from":
 				streamFromVar = &streamFrom
 			}
 		})
 
-		os.Exit(app.CliHostAdd(args[0], streamFromVar, priorityVal, dryRun, skipMySQLCheck))
+		exitCli(app, app.CliHostAdd(args[0], streamFromVar, priorityVal, semiSyncVal, relay, dryRun, skipMySQLCheck))
 	},
 }
 
@@ -65,13 +78,99 @@ var hostRemoveCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliHostRemove(args[0]))
+		exitCli(app, app.CliHostRemove(args[0]))
+	},
+}
+
+var hostResetupCmd = &cobra.Command{
+	Use:   "resetup",
+	Short: "request rebuild of replica from donor",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostResetup(args[0], resetupDonor, resetupMethod))
+	},
+}
+
+var hostConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "show or change config overrides of host stored in DCS",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostConfig(args[0], overridesSet, overridesUnset))
+	},
+}
+
+var hostQuarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "exclude host from automated actions, keeping its status collected",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostQuarantine(args[0], quarantineTimeout))
+	},
+}
+
+var hostUnquarantineCmd = &cobra.Command{
+	Use:   "unquarantine",
+	Short: "return quarantined host under control of mysync",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostUnquarantine(args[0]))
+	},
+}
+
+var hostDrainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "take replica out of read traffic before maintenance, keeping replication running",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostDrain(args[0], drainWait))
+	},
+}
+
+var hostUndrainCmd = &cobra.Command{
+	Use:   "undrain",
+	Short: "return drained replica to read traffic",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliHostUndrain(args[0]))
 	},
 }
 
 func init() {
 	hostAddCmd.Flags().StringVar(&streamFrom, "stream-from", "", "host to stream from")
 	hostAddCmd.Flags().Int64Var(&priority, "priority", 0, "host priority")
+	hostAddCmd.Flags().BoolVar(&semiSync, "semi-sync", true, "host may acknowledge commits as semi-sync replica, otherwise it replicates asynchronously and is never promoted")
+	hostAddCmd.Flags().BoolVar(&relay, "relay", false, "host only relays replication to cascade replicas (binlog server or blackhole replica)")
 	hostAddCmd.Flags().BoolVar(&dryRun, "dry-run", false, "tests suggested changes."+
 		" Exits codes:"+
 		" 0 - when no changes detected,"+
@@ -79,6 +178,18 @@ func init() {
 		" 2 - when changes detected and some changes will be performed during usual run")
 	hostAddCmd.Flags().BoolVar(&skipMySQLCheck, "skip-mysql-check", false, "skip mysql availability check")
 	hostCmd.AddCommand(hostAddCmd)
+	hostResetupCmd.Flags().StringVar(&resetupDonor, "donor", "", "host to copy data from, selected by resetup_donor_policy if empty")
+	hostResetupCmd.Flags().StringVar(&resetupMethod, "method", "file", "resetup method: clone, xtrabackup, script or file (leave resetup to external tooling)")
 	hostCmd.AddCommand(hostRemoveCmd)
+	hostCmd.AddCommand(hostResetupCmd)
+	hostConfigCmd.Flags().StringArrayVar(&overridesSet, "set", nil, "override config key for host, e.g. --set offline_mode_enable_lag=1m")
+	hostConfigCmd.Flags().StringArrayVar(&overridesUnset, "unset", nil, "remove override of config key")
+	hostCmd.AddCommand(hostConfigCmd)
+	hostQuarantineCmd.Flags().DurationVar(&quarantineTimeout, "timeout", 0, "quarantine duration, quarantine_timeout from config by default")
+	hostCmd.AddCommand(hostQuarantineCmd)
+	hostCmd.AddCommand(hostUnquarantineCmd)
+	hostDrainCmd.Flags().DurationVarP(&drainWait, "wait", "w", 5*time.Minute, "how long wait for host to be drained, 0s to return immediately")
+	hostCmd.AddCommand(hostDrainCmd)
+	hostCmd.AddCommand(hostUndrainCmd)
 	rootCmd.AddCommand(hostCmd)
 }
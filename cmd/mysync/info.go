@@ -9,19 +9,26 @@ import (
 	"github.com/yandex/mysync/internal/app"
 )
 
+var infoCandidates bool
+
 var infoCmd = &cobra.Command{
-	Use:   "info",
-	Short: "Print information from DCS",
+	Use:     "info",
+	GroupID: "observe",
+	Short:   "Print information from DCS",
 	Run: func(cmd *cobra.Command, args []string) {
 		app, err := app.NewApp(configFile, logLevel, true)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliInfo(short))
+		if infoCandidates {
+			exitCli(app, app.CliCandidates(format))
+		}
+		exitCli(app, app.CliInfo(short, format))
 	},
 }
 
 func init() {
+	infoCmd.Flags().BoolVar(&infoCandidates, "candidates", false, "print promotion readiness of replicas and the host which would be promoted now")
 	rootCmd.AddCommand(infoCmd)
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var logsFollow bool
+var logsLines int
+
+var logsCmd = &cobra.Command{
+	Use:     "logs <host|manager>",
+	GroupID: "observe",
+	Short:   "Print mysync log of cluster host",
+	Long:    "Fetches recent mysync log from agent API of given host. Pass \"manager\" to get logs of current manager",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliLogs(args[0], logsLines, logsFollow))
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "stream new log lines")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 100, "number of recent lines to print")
+	rootCmd.AddCommand(logsCmd)
+}
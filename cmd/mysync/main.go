@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -12,25 +13,65 @@ import (
 var configFile string
 var logLevel string
 var short bool
+var format string
+var remote string
+var token string
+var reason string
+var clusters []string
 
 var rootCmd = &cobra.Command{
 	Use:   "mysync",
 	Short: "Mysync is MySQL HA cluster coordination tool",
 	Long:  `Running without additional arguments will start mysync agent for current node.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		app.SetOperatorReason(reason)
+		if remote == "" || cmd.Name() == "completion" || strings.HasPrefix(cmd.Name(), "__") {
+			return
+		}
+		os.Exit(app.RunRemoteCLI(remote, token, configFile, os.Args[1:]))
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(clusters) > 0 {
+			files, err := app.ExpandClusterConfigs(clusters)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			os.Exit(app.RunClusters(files, logLevel))
+		}
 		app, err := app.NewApp(configFile, logLevel, false)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.Run())
+		rc := app.Run()
+		if app.RestartRequested() {
+			rc = app.Reexec()
+		}
+		os.Exit(rc)
 	},
 }
 
 func init() {
+	rootCmd.Version = app.AgentVersion()
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "/etc/mysync.yaml", "config file")
 	rootCmd.PersistentFlags().StringVarP(&logLevel, "loglevel", "l", "Warn", "logging level (Trace|Debug|Info|Warn|Error|Fatal)")
 	rootCmd.PersistentFlags().BoolVarP(&short, "short", "s", false, "short output")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "", "machine-readable output format (json|yaml)")
+	rootCmd.PersistentFlags().StringVar(&remote, "remote", os.Getenv("MYSYNC_REMOTE"), "run command via API of mysync agent, e.g. https://db1:9443, api TLS certificates are taken from --config if it exists")
+	rootCmd.PersistentFlags().StringVar(&token, "token", os.Getenv("MYSYNC_API_TOKEN"), "API token for --remote")
+	rootCmd.PersistentFlags().StringVar(&reason, "reason", "", "reason of switchover, maintenance or promotion recorded to event history")
+	rootCmd.Flags().StringSliceVar(&clusters, "clusters", nil, "config files of clusters managed by this process, e.g. /etc/mysync.d/*.yaml")
+	rootCmd.AddGroup(
+		&cobra.Group{ID: "observe", Title: "Cluster state commands:"},
+		&cobra.Group{ID: "operations", Title: "Cluster management commands:"},
+	)
+}
+
+// exitCli revokes credentials issued for command and exits with its code
+func exitCli(a *app.App, rc int) {
+	a.Close()
+	os.Exit(rc)
 }
 
 func main() {
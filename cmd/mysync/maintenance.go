@@ -11,9 +11,11 @@ import (
 )
 
 var maintWait time.Duration
+var maintDuration time.Duration
 
 var maintCmd = &cobra.Command{
 	Use:     "maintenance",
+	GroupID: "operations",
 	Aliases: []string{"maint", "mnt"},
 	Short:   "Enables or disables maintenance mode",
 	Long: ("When maintenance is enabled MySync manager will not perform any actions.\n" +
@@ -29,7 +31,7 @@ var maintOnCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliEnableMaintenance(maintWait))
+		exitCli(app, app.CliEnableMaintenance(maintWait, maintDuration))
 	},
 }
 
@@ -42,7 +44,7 @@ var maintOffCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliDisableMaintenance(maintWait))
+		exitCli(app, app.CliDisableMaintenance(maintWait))
 	},
 }
 
@@ -54,7 +56,7 @@ var maintGetCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliGetMaintenance())
+		exitCli(app, app.CliGetMaintenance())
 	},
 }
 
@@ -63,5 +65,6 @@ func init() {
 	maintCmd.AddCommand(maintOnCmd)
 	maintCmd.AddCommand(maintOffCmd)
 	maintCmd.AddCommand(maintGetCmd)
+	maintOnCmd.Flags().DurationVar(&maintDuration, "duration", 0, "leave maintenance automatically after given time, e.g. 2h; sets new expiry if maintenance is already on")
 	maintCmd.PersistentFlags().DurationVarP(&maintWait, "wait", "w", 30*time.Second, "how long to wait for maintenance activation, 0s to return immediately")
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var handoffTo string
+var handoffWait time.Duration
+
+var managerCmd = &cobra.Command{
+	Use:     "manager",
+	GroupID: "operations",
+	Short:   "Manage mysync manager role",
+}
+
+var managerHandoffCmd = &cobra.Command{
+	Use:   "handoff",
+	Short: "Release manager lock, so another agent takes over immediately",
+	Long:  "Manager finishes its current cycle, passes its state through DCS and releases the lock. Useful for rolling upgrades of mysync.",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliManagerHandoff(handoffTo, handoffWait))
+	},
+}
+
+var managerRestartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart local mysync with the current binary keeping manager role",
+	Long:  "Running mysync finishes its current cycle, reserves manager lock for itself and re-executes its binary, so upgrade does not cause re-election.",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliRestart())
+	},
+}
+
+func init() {
+	managerHandoffCmd.Flags().StringVar(&handoffTo, "to", "", "host which should become manager (any agent if empty)")
+	managerHandoffCmd.Flags().DurationVarP(&handoffWait, "wait", "w", time.Minute, "how long wait for new manager, 0s to return immediately")
+	managerCmd.AddCommand(managerHandoffCmd)
+	managerCmd.AddCommand(managerRestartCmd)
+	rootCmd.AddCommand(managerCmd)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var promoteForce bool
+var promoteFenced bool
+var promoteConfirm string
+var promoteWait time.Duration
+
+var promoteCmd = &cobra.Command{
+	Use:     "promote <host>",
+	GroupID: "operations",
+	Short:   "Force host to become master, accepting data loss",
+	Long: "Disaster recovery only: promotes host bypassing all candidate checks and discards transactions not replicated to it. " +
+		"Requires --force and confirmation by typing host name (or --confirm <host>). Old master is made read-only first, " +
+		"if it is unreachable promotion is aborted unless --fenced is given. Use `mysync switch` for regular switchover.",
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliPromote(args[0], promoteConfirm, promoteForce, promoteFenced, promoteWait))
+	},
+}
+
+func init() {
+	promoteCmd.Flags().BoolVar(&promoteForce, "force", false, "accept loss of transactions not replicated to host")
+	promoteCmd.Flags().BoolVar(&promoteFenced, "fenced", false, "old master is known to be down or isolated, promote even if it can't be made read-only")
+	promoteCmd.Flags().StringVar(&promoteConfirm, "confirm", "", "host name confirming promotion without interactive prompt")
+	promoteCmd.Flags().DurationVarP(&promoteWait, "wait", "w", 30*time.Second, "how long to wait for mysync to enter maintenance before promotion")
+	rootCmd.AddCommand(promoteCmd)
+}
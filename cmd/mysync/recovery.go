@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var recoveryCmd = &cobra.Command{
+	Use:     "recovery",
+	GroupID: "operations",
+	Short:   "Record and show cluster-wide GTID positions for point-in-time recovery",
+	Long: ("Recovery marks are GTID positions of the whole cluster stored in DCS. Backup tooling saves mark id along with backup,\n" +
+		"hosts restored from backups are validated against recorded marks before rejoin, if recovery_marks.validate_rejoin is set."),
+}
+
+var recoveryMarkCmd = &cobra.Command{
+	Use:   "mark",
+	Short: "Record current GTID positions of cluster hosts and print the mark",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliRecoveryMark(format))
+	},
+}
+
+var recoveryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print recorded marks and the newest mark reached by each host",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliRecoveryStatus(format))
+	},
+}
+
+var recoveryRestoredCmd = &cobra.Command{
+	Use:   "restored <host>",
+	Short: "Put host restored from backup on recovery, so it rejoins only after validation",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliRecoveryRestored(args[0]))
+	},
+}
+
+func init() {
+	recoveryCmd.AddCommand(recoveryMarkCmd)
+	recoveryCmd.AddCommand(recoveryStatusCmd)
+	recoveryCmd.AddCommand(recoveryRestoredCmd)
+	rootCmd.AddCommand(recoveryCmd)
+}
@@ -10,15 +10,16 @@ import (
 )
 
 var stateCmd = &cobra.Command{
-	Use:   "state",
-	Short: "Print cluster nodes state by querying databases",
+	Use:     "state",
+	GroupID: "observe",
+	Short:   "Print cluster nodes state by querying databases",
 	Run: func(cmd *cobra.Command, args []string) {
 		app, err := app.NewApp(configFile, logLevel, true)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliState(short))
+		exitCli(app, app.CliState(short, format))
 	},
 }
 
@@ -13,18 +13,25 @@ import (
 var switchTo string
 var switchFrom string
 var switchWait time.Duration
+var switchAbort bool
+var switchForce bool
+var switchDryRun bool
 
 var switchCmd = &cobra.Command{
-	Use:   "switch",
-	Short: "Move the master to (from) specified host",
-	Long:  "If master is already on (not on) specified host it will be ignored",
+	Use:     "switch",
+	GroupID: "operations",
+	Short:   "Move the master to (from) specified host",
+	Long:    "If master is already on (not on) specified host it will be ignored",
 	Run: func(cmd *cobra.Command, args []string) {
 		app, err := app.NewApp(configFile, logLevel, true)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		os.Exit(app.CliSwitch(switchFrom, switchTo, switchWait))
+		if switchAbort {
+			exitCli(app, app.CliAbort())
+		}
+		exitCli(app, app.CliSwitch(switchFrom, switchTo, switchWait, switchForce, switchDryRun, format))
 	},
 }
 
@@ -33,4 +40,7 @@ func init() {
 	switchCmd.Flags().StringVar(&switchFrom, "from", "", "switch master from specific (or current master if empty) host")
 	switchCmd.Flags().StringVar(&switchTo, "to", "", "switch master to specific (or most up-to-date if empty) host")
 	switchCmd.Flags().DurationVarP(&switchWait, "wait", "w", 5*time.Minute, "how long wait for switchover to complete, 0s to return immediately")
+	switchCmd.Flags().BoolVar(&switchAbort, "abort", false, "abort pending or running switchover")
+	switchCmd.Flags().BoolVar(&switchForce, "force", false, "do not wait for running backups and online schema changes")
+	switchCmd.Flags().BoolVar(&switchDryRun, "dry-run", false, "print switchover plan without performing it")
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var topInterval time.Duration
+
+var topCmd = &cobra.Command{
+	Use:     "top",
+	GroupID: "observe",
+	Short:   "Show live-refreshing cluster dashboard",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliTop(topInterval))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().DurationVarP(&topInterval, "interval", "i", 2*time.Second, "refresh interval")
+}
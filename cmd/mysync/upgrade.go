@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yandex/mysync/internal/app"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:     "upgrade",
+	GroupID: "operations",
+	Short:   "Show or declare rolling upgrade of MySQL",
+	Long:    "During rolling upgrade hosts run different MySQL versions on purpose: promotion may go to newer version, but never back to older one.",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliUpgrade(format))
+	},
+}
+
+var upgradeOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Start rolling upgrade",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliUpgradeOn())
+	},
+}
+
+var upgradeOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Finish rolling upgrade",
+	Run: func(cmd *cobra.Command, args []string) {
+		app, err := app.NewApp(configFile, logLevel, true)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		exitCli(app, app.CliUpgradeOff())
+	},
+}
+
+func init() {
+	upgradeCmd.AddCommand(upgradeOnCmd)
+	upgradeCmd.AddCommand(upgradeOffCmd)
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// antiAffinityTarget returns active host, other than master, which agent is alive and may take
+// manager lock, or empty string if there is none
+func antiAffinityTarget(master string, activeNodes []string, clusterStateDcs map[string]*NodeState, now time.Time, staleTimeout time.Duration) string {
+	hosts := make([]string, 0, len(activeNodes))
+	for _, host := range activeNodes {
+		if host != master {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		state := clusterStateDcs[host]
+		if state == nil || !state.PingOk || now.Sub(state.CheckAt) > staleTimeout {
+			continue
+		}
+		return host
+	}
+	return ""
+}
+
+// checkManagerAntiAffinity is called by manager on healthy cluster. If manager runs on master host,
+// it hands manager lock off to agent on another active host
+func (app *App) checkManagerAntiAffinity(master string, activeNodes []string, clusterStateDcs map[string]*NodeState) {
+	host := app.cfg().Hostname
+	if app.cfg().ManagerAntiAffinity == config.ManagerAntiAffinityOff || master != host {
+		return
+	}
+	now := time.Now()
+	to := antiAffinityTarget(master, activeNodes, clusterStateDcs, now, app.cfg().HealthStaleTimeout)
+	if to == "" {
+		app.logger.Warnf("anti-affinity: manager runs on master %s, no other agent may take the lock", host)
+		return
+	}
+	handoff := &ManagerHandoff{
+		From:        host,
+		To:          to,
+		InitiatedBy: host,
+		InitiatedAt: now,
+		ExpiresAt:   now.Add(app.cfg().ManagerHandoffTimeout),
+	}
+	err := app.dcs.Create(pathManagerHandoff, handoff)
+	if err == dcs.ErrExists {
+		return
+	}
+	if err != nil {
+		app.logger.Errorf("anti-affinity: failed to create manager handoff: %v", err)
+		return
+	}
+	app.logger.Infof("anti-affinity: manager runs on master, requested %s", handoff)
+}
+
+// antiAffinityAllowsLock checks that candidate may take manager lock under manager_anti_affinity policy.
+// Agent on master never takes it in "require" mode and takes it only if no other agent is alive in "prefer" mode.
+// Single-node cluster is exempt, as there is no other agent to be manager
+func (app *App) antiAffinityAllowsLock() bool {
+	if app.cfg().ManagerAntiAffinity == config.ManagerAntiAffinityOff {
+		return true
+	}
+	if len(app.cluster.HANodeHosts()) == 1 {
+		return true
+	}
+	var master string
+	err := app.dcs.Get(pathMasterNode, &master)
+	if err != nil {
+		if err != dcs.ErrNotFound {
+			app.logger.Errorf("anti-affinity: failed to get current master: %v", err)
+		}
+		return true
+	}
+	if master != app.cfg().Hostname {
+		return true
+	}
+	if app.cfg().ManagerAntiAffinity == config.ManagerAntiAffinityRequire {
+		return false
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		app.logger.Errorf("anti-affinity: failed to get active nodes: %v", err)
+		return true
+	}
+	clusterStateDcs, err := app.getClusterStateFromDcs()
+	if err != nil {
+		app.logger.Errorf("anti-affinity: failed to get cluster state from dcs: %v", err)
+		return true
+	}
+	return antiAffinityTarget(master, activeNodes, clusterStateDcs, time.Now(), app.cfg().HealthStaleTimeout) == ""
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestAntiAffinityTarget(t *testing.T) {
+	now := time.Now()
+	active := []string{"mysql3", "mysql1", "mysql2"}
+	states := map[string]*NodeState{
+		"mysql1": {PingOk: true, CheckAt: now},
+		"mysql2": {PingOk: false, CheckAt: now},
+		"mysql3": {PingOk: true, CheckAt: now},
+	}
+	require.Equal(t, "mysql3", antiAffinityTarget("mysql1", active, states, now, time.Minute))
+	require.Equal(t, "mysql1", antiAffinityTarget("mysql2", active, states, now, time.Minute))
+
+	states["mysql3"].CheckAt = now.Add(-time.Hour)
+	require.Equal(t, "", antiAffinityTarget("mysql1", active, states, now, time.Minute))
+	require.Equal(t, "", antiAffinityTarget("mysql1", []string{"mysql1"}, states, now, time.Minute))
+}
+
+func TestAntiAffinityAllowsLock(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().ManagerAntiAffinity = config.ManagerAntiAffinityRequire
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql1"), mysql.NodeConfiguration{}))
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql1"))
+	newCluster := func() {
+		cluster, err := mysql.NewCluster(app.config, app.logger, app.dcs)
+		require.NoError(t, err)
+		t.Cleanup(cluster.Close)
+		require.NoError(t, cluster.UpdateHostsInfo())
+		app.cluster = cluster
+	}
+
+	// nobody else may be manager of single-node cluster
+	newCluster()
+	require.True(t, app.antiAffinityAllowsLock())
+
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql2"), mysql.NodeConfiguration{}))
+	newCluster()
+	require.False(t, app.antiAffinityAllowsLock())
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/util"
+)
+
+const (
+	apiCliPath          = "/v1/cli"
+	apiExitCodeTrailer  = "X-Mysync-Exit-Code"
+	apiShutdownTimeout  = 5 * time.Second
+	apiRequestBodyLimit = 64 * 1024
+)
+
+// commands which may be run via agent API. CLI runs there without stdin, so interactive ones
+// (abort, switch --abort) are excluded and promote requires --confirm
+var apiCommands = []string{
+	"info", "state", "switch", "maintenance", "maint", "mnt",
+	"host", "hosts", "history", "events", "check", "promote", "config", "logs", "manager",
+	"upgrade",
+}
+
+// apiCliRequest is a CLI invocation forwarded by `mysync --remote`
+type apiCliRequest struct {
+	Args []string `json:"args"`
+}
+
+// apiServer serves agent API until ctx is done
+func (app *App) apiServer(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiCliPath, app.apiAuth(app.handleAPICli))
+	mux.HandleFunc(apiLogsPath, app.apiAuth(app.handleAPILogs))
+	mux.HandleFunc(apiLivenessPath, app.apiAuth(app.handleAPILiveness))
+	app.registerHealthHandlers(mux)
+	server := &http.Server{
+		Addr:              app.cfg().APIListen,
+		Handler:           mux,
+		ReadHeaderTimeout: app.cfg().DBTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	app.logger.Infof("api: listening on %s", app.cfg().APIListen)
+	var err error
+	if app.agentTLS != nil {
+		server.TLSConfig = app.agentTLS.serverConfig(app.cfg().APITLSRequireClientCert)
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		app.logger.Errorf("api: server failed: %v", err)
+	}
+}
+
+// apiAuth checks client certificate or bearer token and passes matched token to handler
+func (app *App) apiAuth(handler func(w http.ResponseWriter, r *http.Request, client config.APITokenConfig)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if t, ok := matchClientCert(app.cfg().APITokens, r.TLS); ok {
+			handler(w, r, t)
+			return
+		}
+		if t, ok := matchToken(app.cfg().APITokens, r.Header.Get("Authorization")); ok {
+			handler(w, r, t)
+			return
+		}
+		app.logger.Warnf("api: unauthorized request from %s", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+// matchToken returns token entry matching bearer token from authorization header
+func matchToken(tokens []config.APITokenConfig, authorization string) (config.APITokenConfig, bool) {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	for _, t := range tokens {
+		if t.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			return t, true
+		}
+	}
+	return config.APITokenConfig{}, false
+}
+
+// sanitizeAPIArgs checks that forwarded args run allowed command and don't override agent config
+func sanitizeAPIArgs(args []string) ([]string, error) {
+	if len(args) == 0 || !util.ContainsString(apiCommands, args[0]) {
+		return nil, fmt.Errorf("command is not allowed via api, expected one of %v", apiCommands)
+	}
+	confirmed := false
+	for _, arg := range args {
+		name := strings.SplitN(arg, "=", 2)[0]
+		if name == "--config" || name == "--remote" || name == "-c" {
+			return nil, fmt.Errorf("flag %s is not allowed via api", name)
+		}
+		// short form with value attached, e.g. -c/etc/mysync.yaml
+		if strings.HasPrefix(arg, "-c") && !strings.HasPrefix(arg, "--") {
+			return nil, fmt.Errorf("flag -c is not allowed via api")
+		}
+		if name == "--abort" {
+			return nil, fmt.Errorf("switchover abort asks for confirmation, it is not allowed via api")
+		}
+		confirmed = confirmed || name == "--confirm"
+	}
+	if args[0] == "promote" && !confirmed {
+		return nil, fmt.Errorf("promote via api requires --confirm <host>, as confirmation can't be typed")
+	}
+	return args, nil
+}
+
+func (app *App) handleAPICli(w http.ResponseWriter, r *http.Request, client config.APITokenConfig) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request apiCliRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, apiRequestBodyLimit)).Decode(&request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("malformed request: %v", err), http.StatusBadRequest)
+		return
+	}
+	args, err := sanitizeAPIArgs(request.Args)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if required := requiredAPIRole(args); !apiRoleAllows(client.Role, required) {
+		app.logger.Warnf("api: %s from %s is not allowed to run %v, %s role is required", client.Name, r.RemoteAddr, args, required)
+		http.Error(w, fmt.Sprintf("%s role is required to run %s", required, args[0]), http.StatusForbidden)
+		return
+	}
+	app.logger.Infof("api: %s from %s runs %v", client.Name, r.RemoteAddr, args)
+
+	executable, err := os.Executable()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cmd := exec.CommandContext(r.Context(), executable, append(args, "--config", app.configFile)...)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", apiClientEnv, client.Name),
+		fmt.Sprintf("%s=%s", apiClientSignatureEnv, signAPIClient(app.cfg(), client.Name)))
+	w.Header().Set("Trailer", apiExitCodeTrailer)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	output := &flushWriter{w: w}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err = cmd.Run()
+	ret := 0
+	if cmd.ProcessState != nil {
+		ret = cmd.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+	} else if err != nil {
+		fmt.Fprintln(output, err)
+		ret = 1
+	}
+	w.Header().Set(apiExitCodeTrailer, strconv.Itoa(ret))
+}
+
+// flushWriter streams command output to client as soon as it is produced
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+// stripRemoteArgs removes flags which make sense only for local CLI
+func stripRemoteArgs(args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		name := strings.SplitN(args[i], "=", 2)[0]
+		switch name {
+		case "--remote", "--token", "--config", "-c":
+			if !strings.Contains(args[i], "=") {
+				i++
+			}
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
+
+// remoteHTTPClient returns client for agent API, which uses certificates of local agent config if there is one
+func remoteHTTPClient(configFile string) (*http.Client, error) {
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		return http.DefaultClient, nil
+	}
+	cfg, err := config.ReadFromFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	return newAgentTLS(cfg).httpClient()
+}
+
+// RunRemoteCLI forwards CLI invocation to agent API and returns its exit code
+func RunRemoteCLI(url, token, configFile string, args []string) int {
+	client, err := remoteHTTPClient(configFile)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	body, err := json.Marshal(apiCliRequest{Args: stripRemoteArgs(args)})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(url, "/")+apiCliPath, bytes.NewReader(body))
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, err = io.Copy(os.Stdout, resp.Body)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 1
+	}
+	ret, err := strconv.Atoi(resp.Trailer.Get(apiExitCodeTrailer))
+	if err != nil {
+		fmt.Println("agent did not report exit code")
+		return 1
+	}
+	return ret
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripRemoteArgs(t *testing.T) {
+	args := stripRemoteArgs([]string{"--remote", "https://db1:9443", "switch", "--to=db2", "--token=secret", "-c", "/etc/mysync.yaml", "-w", "5m"})
+	require.Equal(t, []string{"switch", "--to=db2", "-w", "5m"}, args)
+}
+
+func TestSanitizeAPIArgs(t *testing.T) {
+	_, err := sanitizeAPIArgs([]string{"info", "-s"})
+	require.NoError(t, err)
+	_, err = sanitizeAPIArgs([]string{"top"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs(nil)
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"info", "--config=/tmp/evil.yaml"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"info", "-c/tmp/evil.yaml"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"info", "-c", "/tmp/evil.yaml"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"switch", "--to", "mysql2", "--reason", "planned"})
+	require.NoError(t, err)
+	// interactive commands can't be run without stdin
+	_, err = sanitizeAPIArgs([]string{"abort"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"switch", "--abort"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"promote", "mysql2", "--force"})
+	require.Error(t, err)
+	_, err = sanitizeAPIArgs([]string{"promote", "mysql2", "--force", "--confirm", "mysql2"})
+	require.NoError(t, err)
+	_, err = sanitizeAPIArgs([]string{"promote", "mysql2", "--force", "--confirm=mysql2"})
+	require.NoError(t, err)
+}
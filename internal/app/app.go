@@ -10,25 +10,29 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	mysql_driver "github.com/go-sql-driver/mysql"
 	"github.com/gofrs/flock"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/yandex/mysync/internal/config"
 	"github.com/yandex/mysync/internal/dcs"
 	"github.com/yandex/mysync/internal/log"
 	"github.com/yandex/mysync/internal/mysql"
 	"github.com/yandex/mysync/internal/mysql/gtids"
+	"github.com/yandex/mysync/internal/statsd"
 	"github.com/yandex/mysync/internal/util"
 )
 
 // App is main application structure
 type App struct {
 	state               appState
+	configFile          string
 	logger              *log.Logger
-	config              *config.Config
+	config              *config.Holder
 	dcs                 dcs.DCS
 	cluster             *mysql.Cluster
 	filelock            *flock.Flock
@@ -38,21 +42,49 @@ type App struct {
 	daemonState         *DaemonState
 	daemonMutex         sync.Mutex
 	replRepairState     map[string]*ReplicationRepairState
+	repairBudgets       map[string]*repairBudget
+	externalSourceLost  map[string]time.Time
+	lagHistory          lagHistory
+	semiSyncStall       semiSyncStall
 	externalReplication mysql.IExternalReplication
 	switchHelper        mysql.ISwitchHelper
 	lostQuorumTime      time.Time
+	replicaFailedAt     time.Time
+	holdsResetupSlot    bool
+	resetupRunning      atomic.Bool
+	liveness            agentLiveness
+	livenessSeen        livenessSeen
+	notifications       chan HistoryEvent
+	statsd              *statsd.Client
+	stateTimes          stateDurations
+	masterViewTimes     stateDurations
+	clusterView         clusterViewCache
+	throttle            throttleCache
+	localView           localView
+	faults              faultInjector
+	polling             adaptivePolling
+	agentTLS            *agentTLS
+	restarting          bool
+	savedState          *LocalState
+	failureDetector     phiAccrual
+	policy              policy
+	restrictedFailure   time.Time
+	probeTableReady     bool
+	loadShedding        *LoadShedding
+	lastRecoveryMark    time.Time
+	tracerProvider      trace.TracerProvider
 }
 
 // NewApp returns new App. Suddenly.
 func NewApp(configFile, logLevel string, interactive bool) (*App, error) {
-	config, err := config.ReadFromFile(configFile)
+	cfg, err := config.ReadFromFile(configFile)
 	if err != nil {
 		return nil, err
 	}
 	logPath := ""
 	if !interactive {
-		logLevel = config.LogLevel
-		logPath = config.Log
+		logLevel = cfg.LogLevel
+		logPath = cfg.Log
 	}
 	logger, err := log.Open(logPath, logLevel)
 	if err != nil {
@@ -61,33 +93,42 @@ func NewApp(configFile, logLevel string, interactive bool) (*App, error) {
 	if logPath != "" {
 		logger.ReOpenOnSignal(syscall.SIGUSR2)
 	}
-	externalReplication, err := mysql.NewExternalReplication(config.ExternalReplicationType, logger)
+	externalReplication, err := mysql.NewExternalReplication(cfg.ExternalReplicationType, logger)
 	if err != nil {
 		return nil, err
 	}
-	switchHelper := mysql.NewSwitchHelper(config)
+	switchHelper := mysql.NewSwitchHelper(cfg)
 	app := &App{
 		state:               stateFirstRun,
-		config:              config,
+		configFile:          configFile,
+		config:              config.NewHolder(cfg),
 		logger:              logger,
 		nodeFailedAt:        make(map[string]time.Time),
 		streamFromFailedAt:  make(map[string]time.Time),
 		replRepairState:     make(map[string]*ReplicationRepairState),
+		repairBudgets:       make(map[string]*repairBudget),
+		externalSourceLost:  make(map[string]time.Time),
 		slaveReadPositions:  make(map[string]string),
 		externalReplication: externalReplication,
 		switchHelper:        switchHelper,
+		agentTLS:            newAgentTLS(cfg),
 	}
 	return app, nil
 }
 
+// cfg returns current config, it is replaced as a whole on update
+func (app *App) cfg() *config.Config {
+	return app.config.Get()
+}
+
 func (app *App) lockFile() error {
-	app.filelock = flock.New(app.config.Lockfile)
+	app.filelock = flock.New(app.cfg().Lockfile)
 	if locked, err := app.filelock.TryLock(); !locked {
 		msg := "Possibly another instance is running."
 		if err != nil {
 			msg = err.Error()
 		}
-		return fmt.Errorf("failed to acquire lock on %s: %s", app.config.Lockfile, msg)
+		return fmt.Errorf("failed to acquire lock on %s: %s", app.cfg().Lockfile, msg)
 	}
 	return nil
 }
@@ -110,7 +151,7 @@ func (app *App) baseContext() context.Context {
 func (app *App) connectDCS() error {
 	var err error
 	// TODO: support other DCS systems
-	app.dcs, err = dcs.NewZookeeper(app.baseContext(), &app.config.Zookeeper, app.logger)
+	app.dcs, err = dcs.NewZookeeper(app.baseContext(), &app.cfg().Zookeeper, app.logger)
 	if err != nil {
 		return fmt.Errorf("failed to connect to zkDCS: %s", err.Error())
 	}
@@ -123,42 +164,54 @@ func (app *App) newDBCluster() error {
 	if err != nil {
 		return fmt.Errorf("failed to create database cluster %s", err.Error())
 	}
+	if app.cfg().DevMode {
+		app.cluster.SetQueryDelay(app.faults.queryDelay)
+	}
 	return nil
 }
 
 func (app *App) writeEmergeFile(msg string) {
-	err := os.WriteFile(app.config.Emergefile, []byte(msg), 0644)
+	if app.cfg().ObserveOnly {
+		app.logger.Infof("observe-only: would write emerge file: %s", msg)
+		return
+	}
+	err := os.WriteFile(app.cfg().Emergefile, []byte(msg), 0644)
 	if err != nil {
 		app.logger.Errorf("failed to write emerge file: %v", err)
 	}
 }
 
 func (app *App) writeResetupFile(msg string) {
-	err := os.WriteFile(app.config.Resetupfile, []byte(msg), 0644)
+	app.recordEvent(HistoryEvent{Type: EventResetupRequired, Host: app.cfg().Hostname, Cause: msg})
+	if app.cfg().ObserveOnly {
+		app.logger.Infof("observe-only: would write resetup file: %s", msg)
+		return
+	}
+	err := os.WriteFile(app.cfg().Resetupfile, []byte(msg), 0644)
 	if err != nil {
 		app.logger.Errorf("failed to write resetup file: %v", err)
 	}
 }
 
 func (app *App) doesResetupFileExist() bool {
-	_, err := os.Stat(app.config.Resetupfile)
+	_, err := os.Stat(app.cfg().Resetupfile)
 	return err == nil
 }
 
 func (app *App) writeMaintenanceFile() {
-	err := os.WriteFile(app.config.Maintenancefile, []byte(""), 0644)
+	err := os.WriteFile(app.cfg().Maintenancefile, []byte(""), 0644)
 	if err != nil {
 		app.logger.Errorf("failed to write maintenance file: %v", err)
 	}
 }
 
 func (app *App) doesMaintenanceFileExist() bool {
-	_, err := os.Stat(app.config.Maintenancefile)
+	_, err := os.Stat(app.cfg().Maintenancefile)
 	return err == nil
 }
 
 func (app *App) removeMaintenanceFile() {
-	err := os.Remove(app.config.Maintenancefile)
+	err := os.Remove(app.cfg().Maintenancefile)
 	if err != nil && !os.IsNotExist(err) {
 		app.logger.Errorf("failed to remove maintenance file: %v", err)
 	}
@@ -166,27 +219,58 @@ func (app *App) removeMaintenanceFile() {
 
 // separate goroutine performing health checks
 func (app *App) healthChecker(ctx context.Context) {
-	ticker := time.NewTicker(app.config.HealthCheckInterval)
+	interval := app.cfg().HealthCheckInterval
+	ticker := time.NewTicker(interval)
 	var oldBinLogPos string
+	var oldState *NodeState
+	var oldApplyState *NodeState
+	var lastProbe *ReadOnlyProbe
 	for {
 		select {
 		case <-ticker.C:
 			hc := app.getLocalNodeState()
+			app.injectNodeFaults(hc)
+			app.liveness.checkedHealth(time.Now(), hc.PingOk)
+			hc.AgentState = app.getAgentState(time.Now())
+			hc.ClockOffset = app.getClockOffset()
+			hc.ReadOnlyProbe = app.probeReadOnly(hc, lastProbe, time.Now())
+			lastProbe = hc.ReadOnlyProbe
+			oldApplyState = hc.UpdateApplyRate(oldApplyState)
+			app.emitNodeMetrics(hc, app.liveness.currentState())
 			oldBinLogPos = hc.UpdateBinlogStatus(oldBinLogPos)
+			oldState = hc.UpdateDiskGrowthRate(oldState)
 			app.logger.Infof("healthcheck: %v", hc)
-			err := app.dcs.SetEphemeral(dcs.JoinPath(pathHealthPrefix, app.config.Hostname), hc)
+			err := app.dcs.SetEphemeral(dcs.JoinPath(pathHealthPrefix, app.cfg().Hostname), hc)
 			if err != nil {
 				app.logger.Errorf("healthcheck: failed to set status to dcs: %s", err)
 			}
+			app.enforceEpoch(hc)
+			app.saveLocalState(hc)
+			app.refreshMembership()
+			app.refreshThrottleState()
+			now := time.Now()
+			app.polling.observe(pollHealth, nodeUnstable(hc), now)
+			app.resetTicker(ticker, &interval, app.polling.interval(app.cfg().AdaptivePolling, app.cfg().HealthCheckInterval, now), "healthcheck")
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// refreshMembership reads cluster hosts from dcs on every agent whatever its state is,
+// so hosts added or removed by `mysync host add/remove` are picked up without restart
+func (app *App) refreshMembership() {
+	if !app.dcs.IsConnected() {
+		return
+	}
+	if err := app.cluster.UpdateHostsInfo(); err != nil {
+		app.logger.Errorf("healthcheck: failed to update hosts info: %v", err)
+	}
+}
+
 // separate gorutine performing info file management
 func (app *App) stateFileHandler(ctx context.Context) {
-	ticker := time.NewTicker(app.config.InfoFileHandlerInterval)
+	ticker := time.NewTicker(app.cfg().InfoFileHandlerInterval)
 	for {
 		select {
 		case <-ticker.C:
@@ -194,19 +278,24 @@ func (app *App) stateFileHandler(ctx context.Context) {
 			tree, err := app.dcs.GetTree("")
 			if err != nil {
 				app.logger.Errorf("stateFileHandler: failed to get current zk tree: %v", err)
-				_ = os.Remove(app.config.InfoFile)
+				_ = os.Remove(app.cfg().InfoFile)
 				continue
 			}
+			if view, err := clusterViewFromTree(tree); err != nil {
+				app.logger.Errorf("stateFileHandler: failed to get cluster view: %v", err)
+			} else {
+				app.localView.setCluster(view)
+			}
 			data, err := json.Marshal(tree)
 			if err != nil {
 				app.logger.Errorf("stateFileHandler: failed to marshal zk node data: %v", err)
-				_ = os.Remove(app.config.InfoFile)
+				_ = os.Remove(app.cfg().InfoFile)
 				continue
 			}
-			err = os.WriteFile(app.config.InfoFile, data, 0666)
+			err = os.WriteFile(app.cfg().InfoFile, data, 0666)
 			if err != nil {
 				app.logger.Errorf("stateFileHandler: failed to write info file %v", err)
-				_ = os.Remove(app.config.InfoFile)
+				_ = os.Remove(app.cfg().InfoFile)
 				continue
 			}
 
@@ -218,7 +307,7 @@ func (app *App) stateFileHandler(ctx context.Context) {
 
 // checks if update of external CA file required
 func (app *App) externalCAFileChecker(ctx context.Context) {
-	ticker := time.NewTicker(app.config.ExternalCAFileCheckInterval)
+	ticker := time.NewTicker(app.cfg().ExternalCAFileCheckInterval)
 	for {
 		select {
 		case <-ticker.C:
@@ -245,7 +334,7 @@ func (app *App) externalCAFileChecker(ctx context.Context) {
 }
 
 func (app *App) replMonWriter(ctx context.Context) {
-	ticker := time.NewTicker(app.config.ReplMonWriteInterval)
+	ticker := time.NewTicker(app.cfg().ReplMonWriteInterval)
 	for {
 		select {
 		case <-ticker.C:
@@ -253,29 +342,29 @@ func (app *App) replMonWriter(ctx context.Context) {
 			sstatus, err := localNode.GetReplicaStatus()
 			if err != nil {
 				app.logger.Errorf("repl mon writer: got error %v while checking replica status", err)
-				time.Sleep(app.config.ReplMonErrorWaitInterval)
+				time.Sleep(app.cfg().ReplMonErrorWaitInterval)
 				continue
 			}
 			if sstatus != nil {
 				app.logger.Infof("repl mon writer: host is replica")
-				time.Sleep(app.config.ReplMonSlaveWaitInterval)
+				time.Sleep(app.cfg().ReplMonSlaveWaitInterval)
 				continue
 			}
 			readOnly, _, err := localNode.IsReadOnly()
 			if err != nil {
 				app.logger.Errorf("repl mon writer: got error %v while checking read only status", err)
-				time.Sleep(app.config.ReplMonErrorWaitInterval)
+				time.Sleep(app.cfg().ReplMonErrorWaitInterval)
 				continue
 			}
 			if readOnly {
 				app.logger.Infof("repl mon writer: host is read only")
-				time.Sleep(app.config.ReplMonSlaveWaitInterval)
+				time.Sleep(app.cfg().ReplMonSlaveWaitInterval)
 				continue
 			}
-			err = localNode.UpdateReplMonTable(app.config.ReplMonSchemeName, app.config.ReplMonTableName)
+			err = localNode.UpdateReplMonTable(app.cfg().ReplMonSchemeName, app.cfg().ReplMonTableName)
 			if err != nil {
 				if mysql.IsErrorTableDoesNotExists(err) {
-					err = localNode.CreateReplMonTable(app.config.ReplMonSchemeName, app.config.ReplMonTableName)
+					err = localNode.CreateReplMonTable(app.cfg().ReplMonSchemeName, app.cfg().ReplMonTableName)
 					if err != nil {
 						app.logger.Errorf("repl mon writer: got error %v while creating repl mon table", err)
 					}
@@ -298,13 +387,16 @@ func (app *App) SetResetupStatus() {
 
 // separated gorutine for resetuping local mysql
 func (app *App) recoveryChecker(ctx context.Context) {
-	ticker := time.NewTicker(app.config.RecoveryCheckInterval)
+	ticker := time.NewTicker(app.cfg().RecoveryCheckInterval)
 	for {
 		select {
 		case <-ticker.C:
 			app.checkRecovery()
 			app.checkCrashRecovery()
+			app.checkReplicaResetup()
+			app.checkResetupRequest()
 			app.SetResetupStatus()
+			app.releaseResetupSlot()
 		case <-ctx.Done():
 			return
 		}
@@ -312,7 +404,7 @@ func (app *App) recoveryChecker(ctx context.Context) {
 }
 
 func (app *App) checkRecovery() {
-	if !app.IsRecoveryNeeded(app.config.Hostname) {
+	if !app.IsRecoveryNeeded(app.cfg().Hostname) {
 		return
 	}
 	if app.doesResetupFileExist() {
@@ -377,16 +469,29 @@ func (app *App) checkRecovery() {
 			return
 		}
 
+		err = app.validateRecoveryLineage(localNode.Host(), gtids.ParseGtidSet(sstatus.GetExecutedGtidSet()))
+		if errors.Is(err, errNoRecoveryMarkReached) {
+			app.logger.Errorf("recovery: %v, need RESETUP", err)
+			app.writeResetupFile(err.Error())
+			return
+		}
+		if err != nil {
+			app.logger.Errorf("recovery: %v, waiting for it...", err)
+			return
+		}
+
 		app.logger.Infof("recovery: local node %s is not ahead of master, recovery finished", localNode.Host())
-		err = app.ClearRecovery(app.config.Hostname)
+		err = app.ClearRecovery(app.cfg().Hostname)
 		if err != nil {
 			app.logger.Errorf("recovery: failed to clear recovery flag in zk: %v", err)
+			return
 		}
+		app.recordEvent(HistoryEvent{Type: EventRejoin, Host: localNode.Host(), Message: fmt.Sprintf("recovery finished, replicating from %s", master)})
 	}
 }
 
 func (app *App) checkCrashRecovery() {
-	if !app.config.ResetupCrashedHosts {
+	if !app.cfg().ResetupCrashedHosts {
 		return
 	}
 	if app.doesResetupFileExist() {
@@ -424,7 +529,7 @@ func (app *App) checkCrashRecovery() {
 func (app *App) checkHAReplicasRunning(local *mysql.Node) bool {
 	checker := func(host string) error {
 		node := app.cluster.Get(host)
-		status, err := node.ReplicaStatusWithTimeout(app.config.DBLostCheckTimeout, app.config.ReplicationChannel)
+		status, err := node.ReplicaStatusWithTimeout(app.cfg().DBLostCheckTimeout, app.cfg().ReplicationChannel)
 		if err != nil {
 			return err
 		}
@@ -437,7 +542,7 @@ func (app *App) checkHAReplicasRunning(local *mysql.Node) bool {
 		if status.GetMasterHost() != local.Host() {
 			return fmt.Errorf("replication on host %s doesn't streaming from master %s", host, local.Host())
 		}
-		if !app.config.SemiSync {
+		if !app.cfg().SemiSync {
 			return nil // count all replicas in async-only schema
 		}
 		ssstatus, err := node.SemiSyncStatus()
@@ -464,7 +569,7 @@ func (app *App) checkHAReplicasRunning(local *mysql.Node) bool {
 
 	app.logger.Infof("mysync HA Replicas check: live replicas %d, number of hosts %d ", availableReplicas, len(app.cluster.HANodeHosts()))
 
-	if app.config.SemiSync {
+	if app.cfg().SemiSync {
 		status, err := local.SemiSyncStatus()
 		if err != nil {
 			app.logger.Errorf("failed to get semisync status: %v", err)
@@ -478,14 +583,18 @@ func (app *App) checkHAReplicasRunning(local *mysql.Node) bool {
 }
 
 func (app *App) stateFirstRun() appState {
-	if !app.dcs.WaitConnected(app.config.DcsWaitTimeout) {
+	if !app.dcs.WaitConnected(app.cfg().DcsWaitTimeout) {
 		if app.doesMaintenanceFileExist() {
 			return stateMaintenance
 		}
+		app.enforceSavedState()
 		return stateFirstRun
 	}
 	app.dcs.Initialize()
-	if app.AcquireLock(pathManagerLock) {
+	// rebooted old master should not stay writable until first healthcheck
+	app.enforceEpoch(app.getLocalNodeState())
+	app.checkSavedState()
+	if app.managerLockAllowed() && app.AcquireLock(pathManagerLock) {
 		return stateManager
 	}
 	return stateCandidate
@@ -500,7 +609,7 @@ func (app *App) stateLost() appState {
 		return stateLost
 	}
 
-	if app.config.DisableSetReadonlyOnLost {
+	if app.cfg().DisableSetReadonlyOnLost {
 		app.logger.Infof("mysync have lost connection to ZK. MySQL HA cluster is not reachable. However switching to RO is prohibited by mysync configuration. Do nothing")
 		return stateLost
 	}
@@ -515,7 +624,7 @@ func (app *App) stateLost() appState {
 	app.logger.Infof("mysync have lost connection to ZK. MySQL HA cluster is not reachable. Switching to RO")
 	var err error
 	if localNodeState.IsMaster {
-		err = node.SetReadOnlyWithForce(app.config.ExcludeUsers, true)
+		err = node.SetReadOnlyWithForce(app.cfg().ExcludeUsers, true)
 
 		merr, ok := err.(*mysql_driver.MySQLError)
 		if !(errors.Is(err, context.DeadlineExceeded) || ok && merr.Number == 1205) { // Error 1205: Lock wait timeout exceeded; try restarting transaction
@@ -535,7 +644,7 @@ func (app *App) stateLost() appState {
 				app.logger.Errorf("node %s: %v", node.Host(), err)
 				return stateLost
 			}
-			err = node.SetReadOnlyWithForce(app.config.ExcludeUsers, true)
+			err = node.SetReadOnlyWithForce(app.cfg().ExcludeUsers, true)
 			if err != nil {
 				app.logger.Errorf("failed to set master %s read-only: %v", node.Host(), err)
 				return stateLost
@@ -564,10 +673,13 @@ func (app *App) stateMaintenance() appState {
 	if err != nil && err != dcs.ErrNotFound {
 		return stateMaintenance
 	}
-	if err == dcs.ErrNotFound || maintenance.ShouldLeave {
+	if err == dcs.ErrNotFound || maintenance.ShouldLeave || maintenance.IsExpired() {
 		if app.AcquireLock(pathManagerLock) {
+			if maintenance != nil && !maintenance.ShouldLeave && maintenance.IsExpired() {
+				app.logger.Warnf("maintenance: expired at %s, resuming normal operation", *maintenance.ExpiresAt)
+			}
 			app.logger.Info("leaving maintenance")
-			err := app.leaveMaintenance()
+			err := app.leaveMaintenance(maintenance)
 			if err != nil {
 				app.logger.Errorf("maintenance: failed to leave: %v", err)
 				return stateMaintenance
@@ -590,6 +702,9 @@ func (app *App) stateManager() appState {
 	if !app.AcquireLock(pathManagerLock) {
 		return stateCandidate
 	}
+	if app.checkManagerHandoff() {
+		return stateCandidate
+	}
 
 	err := app.cluster.UpdateHostsInfo()
 	if err != nil {
@@ -607,8 +722,10 @@ func (app *App) stateManager() appState {
 		app.logger.Errorf("failed to get cluster state from DCS: %s", err)
 		return stateManager
 	}
+	app.clusterView.update(clusterState, clusterStateDcs)
+	app.recordLagHistory(clusterState, time.Now())
 
-	if app.config.ManagerSwitchover {
+	if app.cfg().ManagerSwitchover {
 		managerSeeMaster, err := app.checkMasterVisible(clusterState, clusterStateDcs)
 		if err == nil && !managerSeeMaster {
 			if state, ok := app.checkQuorum(clusterState, clusterStateDcs); !ok {
@@ -637,6 +754,18 @@ func (app *App) stateManager() appState {
 	app.logger.Infof("master: %s", master)
 	app.logger.Infof("cs: %v", clusterState)
 	app.logger.Infof("dcs cs: %v", clusterStateDcs)
+	if err := app.checkClockSkew(activeNodes, clusterStateDcs); err != nil {
+		app.logger.Warnf("clock: %v, lag and event times may be wrong", err)
+	}
+	if app.statsd != nil {
+		app.emitLagMetrics()
+		candidates, err := app.getCandidatesReadiness(master, activeNodes, clusterStateDcs)
+		if err == nil {
+			app.emitCandidateMetrics(candidates)
+		} else {
+			app.logger.Warnf("readiness: %v", err)
+		}
+	}
 
 	// check if we are in maintenance
 	maintenance, err := app.GetMaintenance()
@@ -658,8 +787,26 @@ func (app *App) stateManager() appState {
 
 	// check if switchover required or in progress
 	switchover := new(Switchover)
-	if err := app.dcs.Get(pathCurrentSwitch, switchover); err == nil {
-		err = app.approveSwitchover(switchover, activeNodes, clusterState)
+	err = app.dcs.Get(pathCurrentSwitch, switchover)
+	app.polling.observe(pollSwitchover, err == nil, time.Now())
+	if err == nil {
+		if err = app.checkSwitchoverDeadline(switchover); err != nil {
+			app.logger.Errorf("aborting switchover: %s", err)
+			err = app.FinishSwitchover(switchover, err)
+			if err != nil {
+				app.logger.Errorf("failed to abort switchover: %s", err)
+			}
+			return stateManager
+		}
+		if err = app.checkBackupsBeforeSwitchover(switchover, master); err != nil {
+			app.logger.Warnf("deferring switchover: %s", err)
+			return stateManager
+		}
+		if err = app.checkOnlineDDLBeforeSwitchover(switchover, master); err != nil {
+			app.logger.Warnf("deferring switchover: %s", err)
+			return stateManager
+		}
+		err = app.approveSwitchover(switchover, activeNodes, clusterState, clusterStateDcs)
 		if err != nil {
 			app.logger.Errorf("cannot perform switchover: %s", err)
 			err = app.FinishSwitchover(switchover, err)
@@ -674,9 +821,13 @@ func (app *App) stateManager() appState {
 			app.logger.Errorf("failed to start switchover: %s", err)
 			return stateManager
 		}
+		app.masterViewTimes.enter(masterViewSwitchover, time.Now())
 		err = app.performSwitchover(clusterState, activeNodes, switchover, master)
 		if app.dcs.Get(pathCurrentSwitch, new(Switchover)) == dcs.ErrNotFound {
 			app.logger.Errorf("switchover was aborted")
+			if err != nil {
+				app.restoreMasterAfterAbort()
+			}
 		} else {
 			if err != nil {
 				err = app.FailSwitchover(switchover, err)
@@ -699,16 +850,27 @@ func (app *App) stateManager() appState {
 		return stateManager
 	}
 
+	// heartbeats of master are its health checks, timestamped by its agent
+	observedAt := clusterStateDcs[master].CheckAt
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	app.failureDetector.heartbeat(master, clusterState[master].PingOk && clusterStateDcs[master].PingOk,
+		observedAt, app.cfg().HealthCheckInterval, app.cfg().PhiWindowSize)
+
 	// perform failover if needed
 	if !clusterStateDcs[master].PingOk || clusterStateDcs[master].IsFileSystemReadonly {
 		app.logger.Errorf("MASTER FAILURE")
+		app.masterViewTimes.enter(masterViewDead, time.Now())
 		if app.nodeFailedAt[master].IsZero() {
 			app.nodeFailedAt[master] = time.Now()
 		}
 		err = app.approveFailover(clusterState, clusterStateDcs, activeNodes, master)
 		if err == nil {
 			app.logger.Infof("failover approved")
-			err = app.IssueFailover(master)
+			cause := diagnoseMasterFailure(master, clusterState, clusterStateDcs, app.nodeFailedAt[master])
+			app.logger.Infof("failover cause: %s %v", cause.Kind, cause.Evidence)
+			err = app.IssueFailover(master, cause)
 			if err != nil {
 				app.logger.Error(err.Error())
 			}
@@ -721,22 +883,52 @@ func (app *App) stateManager() appState {
 	}
 	if !clusterState[master].PingOk {
 		app.logger.Errorf("MASTER SUSPICIOUS, do not perform any kind of repair")
+		app.masterViewTimes.enter(masterViewSuspicious, time.Now())
 		return stateManager
 	}
+	app.masterViewTimes.enter(masterViewHealthy, time.Now())
+
+	app.removeExpiredQuarantines()
+
+	// detect master blocked waiting for semi-sync ACKs
+	app.checkSemiSyncStall(clusterState, activeNodes, master)
+
+	// protect writes of master, which may be lost while replication is degraded
+	app.checkLoadShedding(clusterState, activeNodes, master)
+
+	// keep manager off the master host, if requested
+	app.checkManagerAntiAffinity(master, activeNodes, clusterStateDcs)
+
+	// let bulk writers know whether replicas keep up
+	app.publishThrottleState(clusterState, activeNodes, master)
+
+	// record cluster-wide position for point-in-time recovery
+	app.recordScheduledRecoveryMark(master)
 
 	// set hosts online or offline depending on replication lag
-	app.repairOfflineMode(clusterState, master)
+	app.repairOfflineMode(clusterState, clusterStateDcs, master)
+
+	// keep drained hosts out of read traffic
+	app.repairDrainedHosts(clusterState, master)
 
 	// analyze and repair cluster
 	app.repairCluster(clusterState, clusterStateDcs, master)
 
+	// switch away from master which disk is going to be full soon
+	app.checkDiskExhaustion(clusterStateDcs, master)
+
+	// switch away from master which storage is going to fail
+	app.checkStorageHealth(clusterStateDcs, master)
+
 	// perform after-crash failover if needed
-	if app.config.ResetupCrashedHosts && countHANodes(clusterState) > 1 && clusterStateDcs[master].DaemonState != nil && clusterStateDcs[master].DaemonState.CrashRecovery {
+	if app.cfg().ResetupCrashedHosts && countHANodes(clusterState) > 1 && clusterStateDcs[master].DaemonState != nil && clusterStateDcs[master].DaemonState.CrashRecovery {
 		app.logger.Errorf("MASTER FAILURE (CRASH RECOVERY)")
 		err = app.approveFailover(clusterState, clusterStateDcs, activeNodes, master)
 		if err == nil {
 			app.logger.Infof("failover approved")
-			err = app.IssueFailover(master)
+			cause := diagnoseMasterFailure(master, clusterState, clusterStateDcs, app.nodeFailedAt[master])
+			app.logger.Infof("failover cause: %s %v", cause.Kind, cause.Evidence)
+			err = app.IssueFailover(master, cause)
 			if err != nil {
 				app.logger.Error(err.Error())
 			}
@@ -752,7 +944,7 @@ func (app *App) stateManager() appState {
 		app.logger.Errorf("failed to update active nodes in dcs: %v", err)
 	}
 
-	if app.config.ReplMon {
+	if app.cfg().ReplMon {
 		err = app.updateReplMonTS(master)
 		if err != nil {
 			app.logger.Errorf("failed to update repl_mon timestamp: %v", err)
@@ -812,7 +1004,7 @@ func (app *App) checkQuorum(clusterStateFromDB, clusterStateDcs map[string]*Node
 		}
 	}
 
-	managerElectionDelayAfterQuorumLoss := app.config.ManagerElectionDelayAfterQuorumLoss
+	managerElectionDelayAfterQuorumLoss := app.cfg().ManagerElectionDelayAfterQuorumLoss
 
 	if workingHANodesCount > 0 && visibleHAHostsCount <= (workingHANodesCount-1)/2 {
 		app.logger.Infof("manager lost quorum (%d/%d visible HAHosts)", visibleHAHostsCount, workingHANodesCount)
@@ -846,8 +1038,8 @@ func (app *App) AcquireLock(path string) bool {
 		return app.dcs.AcquireLock(path)
 	}
 
-	managerElectionDelayAfterQuorumLoss := app.config.ManagerElectionDelayAfterQuorumLoss
-	managerLockAcquireDelayAfterQuorumLoss := app.config.ManagerLockAcquireDelayAfterQuorumLoss
+	managerElectionDelayAfterQuorumLoss := app.cfg().ManagerElectionDelayAfterQuorumLoss
+	managerLockAcquireDelayAfterQuorumLoss := app.cfg().ManagerLockAcquireDelayAfterQuorumLoss
 
 	lostQuorumDuration := time.Since(app.lostQuorumTime)
 	if lostQuorumDuration < managerElectionDelayAfterQuorumLoss {
@@ -863,7 +1055,7 @@ func (app *App) AcquireLock(path string) bool {
 		)
 		return false
 		// Manager start to try to AcquireLock
-	} else if lostQuorumDuration > app.config.ManagerElectionDelayAfterQuorumLoss+app.config.ManagerLockAcquireDelayAfterQuorumLoss {
+	} else if lostQuorumDuration > app.cfg().ManagerElectionDelayAfterQuorumLoss+app.cfg().ManagerLockAcquireDelayAfterQuorumLoss {
 		app.lostQuorumTime = time.Time{}
 		return app.dcs.AcquireLock(path)
 	}
@@ -872,11 +1064,11 @@ func (app *App) AcquireLock(path string) bool {
 }
 
 func (app *App) approveFailover(clusterState, clusterStateDcs map[string]*NodeState, activeNodes []string, master string) error {
-	if !app.config.Failover {
+	if !app.cfg().Failover {
 		return fmt.Errorf("auto_failover is disabled in config")
 	}
 	afterCrashRecovery := false
-	if clusterStateDcs[master].DaemonState != nil && clusterStateDcs[master].DaemonState.CrashRecovery && app.config.ResetupCrashedHosts {
+	if clusterStateDcs[master].DaemonState != nil && clusterStateDcs[master].DaemonState.CrashRecovery && app.cfg().ResetupCrashedHosts {
 		afterCrashRecovery = true
 	}
 	if afterCrashRecovery {
@@ -887,11 +1079,11 @@ func (app *App) approveFailover(clusterState, clusterStateDcs map[string]*NodeSt
 		if countRunningHASlaves(clusterState) == countHANodes(clusterState)-1 {
 			return fmt.Errorf("all replicas are alive and running replication, seems zk problems")
 		}
-		if app.config.FailoverDelay > 0 {
-			failingTime := time.Since(app.nodeFailedAt[master])
-			if failingTime < app.config.FailoverDelay {
-				return fmt.Errorf("failover delay is not yet elapsed: remaining %v", app.config.FailoverDelay-failingTime)
-			}
+		if err := app.checkMasterDead(master, time.Now()); err != nil {
+			return err
+		}
+		if err := app.checkLivenessQuorum(master); err != nil {
+			return fmt.Errorf("master liveness is not confirmed by quorum: %v", err)
 		}
 	}
 
@@ -913,11 +1105,14 @@ func (app *App) approveFailover(clusterState, clusterStateDcs map[string]*NodeSt
 			return fmt.Errorf("another switchover in progress. this should never happen")
 		}
 		timeAfterLastSwitchover := time.Since(lastSwitchover.Result.FinishedAt)
-		if timeAfterLastSwitchover < app.config.FailoverCooldown && lastSwitchover.Cause == CauseAuto {
-			return fmt.Errorf("not enough time from last failover %s (cooldown %s)", lastSwitchover.Result.FinishedAt, app.config.FailoverCooldown)
+		if timeAfterLastSwitchover < app.cfg().FailoverCooldown && lastSwitchover.Cause == CauseAuto {
+			return fmt.Errorf("not enough time from last failover %s (cooldown %s)", lastSwitchover.Result.FinishedAt, app.cfg().FailoverCooldown)
 		}
 	}
-	return nil
+	if err := app.checkFailoverWindow(master, time.Now()); err != nil {
+		return err
+	}
+	return app.policyApproveFailover(clusterState, clusterStateDcs, activeNodes, master)
 }
 
 func (app *App) stateCandidate() appState {
@@ -938,16 +1133,20 @@ func (app *App) stateCandidate() appState {
 	if maintenance != nil && maintenance.MySyncPaused {
 		return stateMaintenance
 	}
-	if app.AcquireLock(pathManagerLock) {
+	if app.managerLockAllowed() && app.antiAffinityAllowsLock() && app.AcquireLock(pathManagerLock) {
 		return stateManager
 	}
 	return stateCandidate
 }
 
 func (app *App) emulateError(pos string) bool {
-	if !app.config.DevMode {
+	if !app.cfg().DevMode {
 		return false
 	}
+	if app.faults.active(faultErrorPrefix+pos) != nil {
+		app.logger.Debugf("devmode: error injected: %s", pos)
+		return true
+	}
 	ee := util.GetEnvVariable("MYSYNC_EMULATE_ERROR", "")
 	for _, emulatedError := range strings.Split(ee, ",") {
 		if pos == emulatedError {
@@ -958,14 +1157,40 @@ func (app *App) emulateError(pos string) bool {
 	return false
 }
 
-func (app *App) approveSwitchover(switchover *Switchover, activeNodes []string, clusterState map[string]*NodeState) error {
+func (app *App) approveSwitchover(switchover *Switchover, activeNodes []string, clusterState, clusterStateDcs map[string]*NodeState) error {
 	if switchover.RunCount > 0 {
 		return nil
 	}
+	if app.cfg().SwitchoverRequireSameProtocol {
+		if err := checkProtocolSkew(activeNodes, clusterStateDcs); err != nil {
+			return err
+		}
+	}
+	// failover should not wait for clocks to be fixed
+	if switchover.Cause == CauseManual {
+		if err := app.checkClockSkew(activeNodes, clusterStateDcs); err != nil {
+			return err
+		}
+	}
 	permissibleSlaves := countAliveHASlavesWithinNodes(activeNodes, clusterState)
 	return app.switchHelper.CheckFailoverQuorum(activeNodes, permissibleSlaves)
 }
 
+// checkSwitchoverDeadline returns error if switchover is running longer than switchover_timeout
+func (app *App) checkSwitchoverDeadline(switchover *Switchover) error {
+	if app.cfg().SwitchoverTimeout == 0 {
+		return nil
+	}
+	// switchover may be deferred (e.g. by running backup or online DDL) before it is started
+	if switchover.StartedAt.IsZero() {
+		return nil
+	}
+	if time.Since(switchover.StartedAt) > app.cfg().SwitchoverTimeout {
+		return fmt.Errorf("switchover was not completed within %v", app.cfg().SwitchoverTimeout)
+	}
+	return nil
+}
+
 /*
 Returns list of hosts that should be active at the moment
 Typically it's master + list of alive, replicating, not split-brained replicas
@@ -977,6 +1202,16 @@ func (app *App) calcActiveNodes(clusterState, clusterStateDcs map[string]*NodeSt
 		app.logger.Errorf("failed to get hosts on recovery: %v", err)
 		return nil, err
 	}
+	quarantined, err := app.getQuarantinedHosts()
+	if err != nil {
+		app.logger.Errorf("failed to get quarantined hosts: %v", err)
+		return nil, err
+	}
+	drained, err := app.getDrainedHosts()
+	if err != nil {
+		app.logger.Errorf("failed to get drained hosts: %v", err)
+		return nil, err
+	}
 	mgtids, err := masterNode.GTIDExecutedParsed()
 	if err != nil {
 		app.logger.Warnf("failed to get master status %v", err)
@@ -999,6 +1234,9 @@ func (app *App) calcActiveNodes(clusterState, clusterStateDcs map[string]*NodeSt
 		if hostsOnRecovery != nil && util.ContainsString(hostsOnRecovery, host) {
 			continue
 		}
+		if quarantined[host] != nil || drained[host] != nil {
+			continue
+		}
 		if !node.PingOk {
 			if node.PingDubious || clusterStateDcs[host].PingOk {
 				// we can't rely on ping and slave status if ping was dubious
@@ -1012,9 +1250,14 @@ func (app *App) calcActiveNodes(clusterState, clusterStateDcs map[string]*NodeSt
 				app.nodeFailedAt[host] = time.Now()
 			}
 			failingTime := time.Since(app.nodeFailedAt[host])
-			if failingTime < app.config.InactivationDelay {
+			if failingTime < app.cfg().InactivationDelay {
+				if util.ContainsString(oldActiveNodes, host) {
+					app.logger.Warnf("calc active nodes: %s is failing: remaining %v", host, app.cfg().InactivationDelay-failingTime)
+					activeNodes = append(activeNodes, host)
+				}
+			} else if err := app.checkLivenessQuorum(host); err != nil {
 				if util.ContainsString(oldActiveNodes, host) {
-					app.logger.Warnf("calc active nodes: %s is failing: remaining %v", host, app.config.InactivationDelay-failingTime)
+					app.logger.Warnf("calc active nodes: %s is failing, but %v, keeping active...", host, err)
 					activeNodes = append(activeNodes, host)
 				}
 			} else {
@@ -1083,7 +1326,7 @@ func (app *App) calcActiveNodesChanges(clusterState map[string]*NodeState, activ
 		for _, host := range becomeActive {
 			slaveState := clusterState[host].SlaveState
 			dataLag := calcLagBytes(masterBinlogs, slaveState.MasterLogFile, slaveState.MasterLogPos)
-			if dataLag > app.config.SemiSyncEnableLag {
+			if dataLag > app.cfg().SemiSyncEnableLag {
 				newBinLogPos := slaveState.GetCurrentBinlogPosition()
 				oldBinLogPos := app.slaveReadPositions[host]
 
@@ -1125,7 +1368,7 @@ func (app *App) updateActiveNodes(clusterState, clusterStateDcs map[string]*Node
 		return err
 	}
 
-	if !app.config.SemiSync {
+	if !app.cfg().SemiSync {
 		// disable semi-sync on hosts
 		for host, state := range clusterState {
 			node := app.cluster.Get(host)
@@ -1140,7 +1383,18 @@ func (app *App) updateActiveNodes(clusterState, clusterStateDcs map[string]*Node
 		return nil
 	}
 
-	becomeActive, becomeInactive, becomeDataLag, err := app.calcActiveNodesChanges(clusterState, activeNodes, oldActiveNodes, master)
+	// async replicas are active, but they are out of semi-sync HA-group
+	semiSyncNodes, err := app.semiSyncEligible(activeNodes)
+	if err != nil {
+		app.logger.Errorf("update active nodes: %v", err)
+		return err
+	}
+	oldSemiSyncNodes, err := app.semiSyncEligible(oldActiveNodes)
+	if err != nil {
+		app.logger.Errorf("update active nodes: %v", err)
+		return err
+	}
+	becomeActive, becomeInactive, becomeDataLag, err := app.calcActiveNodesChanges(clusterState, semiSyncNodes, oldSemiSyncNodes, master)
 	if err != nil {
 		app.logger.Errorf("update active nodes: failed to calc active nodes changes: %v", err)
 		return err
@@ -1150,8 +1404,8 @@ func (app *App) updateActiveNodes(clusterState, clusterStateDcs map[string]*Node
 		oldWaitSlaveCount = masterState.SemiSyncState.WaitSlaveCount
 	}
 	// data lagging replicas should not affect WaitSlaveCount
-	notLaggingActive := filterOut(activeNodes, becomeDataLag)
-	waitSlaveCount := app.switchHelper.GetRequiredWaitSlaveCount(notLaggingActive)
+	notLaggingActive := filterOut(semiSyncNodes, becomeDataLag)
+	waitSlaveCount := app.capWaitSlaveCount(master, app.switchHelper.GetRequiredWaitSlaveCount(notLaggingActive))
 
 	app.logger.Infof("update active nodes: active nodes are: %v, wait_slave_count %d", activeNodes, waitSlaveCount)
 	if len(becomeActive) > 0 {
@@ -1178,7 +1432,16 @@ func (app *App) updateActiveNodes(clusterState, clusterStateDcs map[string]*Node
 			return err
 		}
 	}
+	quarantined, err := app.getQuarantinedHosts()
+	if err != nil {
+		app.logger.Errorf("update active nodes: failed to get quarantined hosts: %v", err)
+		return err
+	}
 	for _, host := range becomeInactive {
+		// quarantined host leaves HA-group, but keeps its settings untouched
+		if quarantined[host] != nil {
+			continue
+		}
 		err = app.disableSemiSyncOnSlave(host, true)
 		if err != nil {
 			app.logger.Warnf("failed to disable semi-sync on slave %s: %v", host, err)
@@ -1306,10 +1569,27 @@ func (app *App) disableSemiSyncIfNonNeeded(node *mysql.Node, state *NodeState) {
 
 // nolint: gocyclo, funlen
 func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNodes []string, switchover *Switchover, oldMaster string) error {
+	tr := app.startSwitchoverTrace(switchover, oldMaster)
+	err := app.performSwitchoverPhases(clusterState, activeNodes, switchover, oldMaster, tr)
+	if err != nil {
+		app.rollbackEpoch()
+	}
+	tr.end(err)
+	return err
+}
+
+func (app *App) performSwitchoverPhases(clusterState map[string]*NodeState, activeNodes []string, switchover *Switchover, oldMaster string, tr *switchoverTrace) error {
+	tr.startPhase("check")
 	if switchover.To != "" {
 		if !util.ContainsString(activeNodes, switchover.To) {
 			return errors.New("switchover: failed: replica is not active, can't switch to it")
 		}
+		if err := app.checkZoneAllowed(switchover.To); err != nil {
+			return fmt.Errorf("switchover: failed: %s", err)
+		}
+		if err := app.checkVersionAllowed(switchover.To, oldMaster); err != nil {
+			return fmt.Errorf("switchover: failed: %s", err)
+		}
 	}
 	// do not perform switchover if we have connection problems with some hosts
 	if dubious := getDubiousHAHosts(clusterState); len(dubious) > 0 {
@@ -1325,6 +1605,8 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 
 	// set read only everywhere (all HA-nodes) and stop replication
 	app.logger.Info("switchover: phase 1: enter read only")
+	tr.startPhase("enter read only")
+	tr.expectLongPhase(app.cfg().SwitchoverDrainTimeout)
 	errs := util.RunParallel(func(host string) error {
 		if !clusterState[host].PingOk {
 			return fmt.Errorf("switchover: failed to ping host %s", host)
@@ -1332,7 +1614,7 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 		node := app.cluster.Get(host)
 		// in case node is a master
 
-		if app.config.ForceSwitchover {
+		if app.cfg().ForceSwitchover {
 			err := node.SetOfflineForce()
 			if err != nil {
 				return fmt.Errorf("failed to set node %s force offline: %v", host, err)
@@ -1349,11 +1631,16 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 		err := node.SetReadOnly(true)
 		if err != nil || app.emulateError("freeze_ro") {
 			app.logger.Infof("switchover: failed to set node %s read-only, trying kill bad queries: %v", host, err)
-			if err := node.SetReadOnlyWithForce(app.config.ExcludeUsers, true); err != nil {
+			if err := node.SetReadOnlyWithForce(app.cfg().ExcludeUsers, true); err != nil {
 				return fmt.Errorf("failed to set node %s read-only: %v", host, err)
 			}
 		}
 		app.logger.Infof("switchover: host %s set read-only", host)
+		if host == oldMaster && switchover.Cause != CauseAuto {
+			if err := app.drainConnections(node); err != nil {
+				return fmt.Errorf("failed to drain node %s: %v", host, err)
+			}
+		}
 		return nil
 	}, activeNodes)
 
@@ -1370,6 +1657,7 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 	}
 
 	app.logger.Info("switchover: phase 2: stop replication")
+	tr.startPhase("stop replication")
 
 	oldMasterNode := app.cluster.Get(oldMaster)
 	if clusterState[oldMaster].PingOk {
@@ -1416,6 +1704,7 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 
 	// collect active host positions
 	app.logger.Info("switchover: phase 3: find most up-to-date host")
+	tr.startPhase("select candidate")
 	positions, err := app.getNodePositions(frozenActiveNodes)
 	if err != nil {
 		return err
@@ -1438,27 +1727,63 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 		return fmt.Errorf("splitbrain detected")
 	}
 	app.logger.Infof("switchover: most up-to-date node is %s with gtidset %s", mostRecent, mostRecentGtidSet)
+	if !util.ContainsString(frozenActiveNodes, oldMaster) {
+		switchover.lost = app.estimateLostTransactions(oldMaster, mostRecentGtidSet)
+		if switchover.lost != nil {
+			app.logger.Warnf("switchover: old master %s reported %d transactions absent on alive hosts: %s", oldMaster, switchover.lost.count, switchover.lost.gtids)
+		}
+	}
 
 	// choose new master
 	var newMaster string
+	var candidates []nodePosition
+	// async replicas are frozen and considered for the most recent host, but they are not promoted
+	eligible, err := app.semiSyncEligible(frozenActiveNodes)
+	if err != nil {
+		return err
+	}
 	if switchover.To != "" {
 		newMaster = switchover.To
+		if !util.ContainsString(eligible, newMaster) {
+			return fmt.Errorf("switchover: %s is async replica, it can't be promoted", newMaster)
+		}
 	} else if switchover.From != "" {
 		positions2 := filterOutNodeFromPositions(positions, switchover.From)
+		positions2 = filterPositionsByHosts(positions2, eligible)
+		positions2, err = app.applyZonePolicy(positions2, switchover.From)
+		if err != nil {
+			return fmt.Errorf("switchover: %s", err)
+		}
+		positions2, err = app.applyVersionPolicy(positions2, oldMaster)
+		if err != nil {
+			return fmt.Errorf("switchover: %s", err)
+		}
+		positions2 = app.deprioritizeHostsOnBackup(positions2)
+		positions2 = app.deprioritizeHostsWithBacklog(positions2, clusterState)
 		// we ignore splitbrain flag as it should be handled during searching most recent host
-		newMaster, err = getMostDesirableNode(app.logger, positions2, app.switchHelper.GetPriorityChoiceMaxLag())
+		candidates = positions2
+		newMaster, err = app.choosePromotionCandidate(app.logger, positions2)
 		if err != nil {
 			return fmt.Errorf("switchover: error while looking for highest priority node: %s", switchover.From)
 		}
-	} else {
+		newMaster = app.policyChooseCandidate(clusterState, activeNodes, oldMaster, positions2, newMaster)
+	} else if util.ContainsString(eligible, mostRecent) {
 		newMaster = mostRecent
+	} else {
+		// most recent host is async replica, new master is chosen among others and catches up from it
+		newMaster, err = app.choosePromotionCandidate(app.logger, filterPositionsByHosts(positions, eligible))
+		if err != nil {
+			return fmt.Errorf("switchover: no host to promote besides async replica %s: %v", mostRecent, err)
+		}
 	}
 	app.logger.Infof("switchover: newMaster is %s", newMaster)
+	tr.setNewMaster(newMaster)
 
 	newMasterNode := app.cluster.Get(newMaster)
 
 	// catch up
 	app.logger.Info("switchover: phase 4: catch up if needed")
+	tr.startPhase("catch up")
 	if newMaster != mostRecent {
 		app.logger.Infof("switchover: new master %s differs from most recent host %s, need to catch up", newMaster, mostRecent)
 		err := app.cluster.Get(mostRecent).SetOnline()
@@ -1472,13 +1797,32 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 	} else {
 		app.logger.Infof("switchover: new master %s is the most recent host, waiting for all binlogs to be applied", newMaster)
 	}
-	caught, err := app.waitForCatchUp(newMasterNode, mostRecentGtidSet, app.config.SlaveCatchUpTimeout, time.Second)
+	catchUpTimeout := app.getCatchUpTimeout(newMasterNode, mostRecentGtidSet)
+	fallback := app.priorityCatchUpFallback(switchover, newMaster, mostRecent, candidates)
+	if fallback {
+		catchUpTimeout = min(catchUpTimeout, app.cfg().PriorityCatchUpTimeout)
+	}
+	tr.expectLongPhase(catchUpTimeout)
+	caught, err := app.waitForCatchUp(newMasterNode, mostRecentGtidSet, catchUpTimeout, time.Second)
 	if err != nil || app.emulateError("catchup_master_status") {
 		return fmt.Errorf("failed to get gtid executed from %s: %s", newMaster, err)
 	}
+	if !caught && fallback {
+		app.logger.Warnf("switchover: %s failed to catch up %s within priority_catch_up_timeout %v, promoting most recent host instead",
+			newMaster, mostRecent, catchUpTimeout)
+		newMaster = mostRecent
+		newMasterNode = app.cluster.Get(newMaster)
+		tr.setNewMaster(newMaster)
+		catchUpTimeout = app.getCatchUpTimeout(newMasterNode, mostRecentGtidSet)
+		tr.expectLongPhase(catchUpTimeout)
+		caught, err = app.waitForCatchUp(newMasterNode, mostRecentGtidSet, catchUpTimeout, time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to get gtid executed from %s: %s", newMaster, err)
+		}
+	}
 	if !caught || app.emulateError("catchup_failed") {
 		return fmt.Errorf("new master %s failed to catch up %s within %s",
-			newMaster, mostRecent, app.config.SlaveCatchUpTimeout)
+			newMaster, mostRecent, catchUpTimeout)
 	}
 	// catching up may take a while so we need to ensure we are still a manager
 	if !app.AcquireLock(pathManagerLock) || app.emulateError("catchup_lost_lock") {
@@ -1495,8 +1839,14 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 		return fmt.Errorf("switchover: failed to ping hosts: %v with dubious errors", dubious)
 	}
 
+	// last chance to abort before topology changes
+	if err := app.checkSwitchoverDeadline(switchover); err != nil {
+		return err
+	}
+
 	// turn slaves to the new master
 	app.logger.Info("switchover: phase 5: turn to the new master")
+	tr.startPhase("repoint replicas")
 	err = app.cluster.Get(newMaster).SetOnline()
 	if err != nil {
 		return fmt.Errorf("got error on setting new master %s online %v", newMaster, err)
@@ -1535,6 +1885,7 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 
 	// promote new master
 	app.logger.Info("switchover: phase 6: promote new master")
+	tr.startPhase("promote")
 	err = newMasterNode.StopSlave()
 	if err != nil || app.emulateError("promote_stop_slave") {
 		return fmt.Errorf("failed to stop slave on new master %s: %s", newMaster, err)
@@ -1545,6 +1896,12 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 	}
 	app.logger.Infof("switchover: new master %s promoted", newMaster)
 
+	// new epoch should be started before new master become writable
+	err = app.bumpEpoch(newMaster)
+	if err != nil || app.emulateError("promote_bump_epoch") {
+		return fmt.Errorf("failed to start new epoch with master %s: %v", newMaster, err)
+	}
+
 	// adjust semi-sync before finishing switchover
 	clusterState = app.getClusterStateFromDB()
 	err = app.updateActiveNodes(clusterState, clusterState, activeNodesWithOldMaster, newMaster)
@@ -1574,12 +1931,19 @@ func (app *App) performSwitchover(clusterState map[string]*NodeState, activeNode
 		app.logger.Errorf("failed to set external replication on new master")
 	}
 
+	// warm up new master before announcing it
+	tr.startPhase("warm up")
+	tr.expectLongPhase(app.cfg().PromotionWarmupTimeout)
+	app.warmupNewMaster(newMaster, newMasterNode, switchover)
+
 	// set new master in dcs
 	err = app.dcs.Set(pathMasterNode, newMaster)
 	if err != nil || app.emulateError("promote_set_to_dcs") {
 		return fmt.Errorf("failed to set new master to dcs: %s", err)
 	}
 
+	switchover.dns = app.updateDNS(newMaster)
+
 	return nil
 }
 
@@ -1618,17 +1982,36 @@ func (app *App) getMasterHost(clusterState map[string]*NodeState) (string, error
 	return masters[0], nil
 }
 
-func (app *App) repairOfflineMode(clusterState map[string]*NodeState, master string) {
+func (app *App) repairOfflineMode(clusterState, clusterStateDcs map[string]*NodeState, master string) {
+	quarantined, err := app.getQuarantinedHosts()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get quarantined hosts: %v", err)
+		return
+	}
+	drained, err := app.getDrainedHosts()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get drained hosts: %v", err)
+		return
+	}
+	loadOffline, err := app.getLoadOfflineHosts()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get replicas offline because of load: %v", err)
+		return
+	}
 	masterNode := app.cluster.Get(master)
+	online := onlineReplicas(clusterState, master)
 	for host, state := range clusterState {
-		if !state.PingOk {
+		if !state.PingOk || quarantined[host] != nil {
+			continue
+		}
+		if drained[host] != nil && host != master {
 			continue
 		}
 		node := app.cluster.Get(host)
 		if host == master {
 			app.repairMasterOfflineMode(host, node, state)
 		} else {
-			app.repairSlaveOfflineMode(host, node, state, masterNode, clusterState[master])
+			app.repairSlaveOfflineMode(host, node, state, app.hostLoad(clusterStateDcs[host]), loadOffline[host], &online, masterNode, clusterState[master])
 		}
 	}
 }
@@ -1647,10 +2030,40 @@ func (app *App) repairMasterOfflineMode(host string, node *mysql.Node, state *No
 	}
 }
 
-func (app *App) repairSlaveOfflineMode(host string, node *mysql.Node, state *NodeState, masterNode *mysql.Node, masterState *NodeState) {
-	if state.SlaveState != nil && state.SlaveState.ReplicationLag != nil {
+// offlineModeLags returns lag thresholds for taking replica out of (and back to) client rotation
+func (app *App) offlineModeLags(state *NodeState) (enableLag, disableLag time.Duration) {
+	enableLag, disableLag = app.cfg().OfflineModeEnableLag, app.cfg().OfflineModeDisableLag
+	if state.IsCascade {
+		if app.cfg().CascadeOfflineModeEnableLag > 0 {
+			enableLag = app.cfg().CascadeOfflineModeEnableLag
+		}
+		if app.cfg().CascadeOfflineModeDisableLag > 0 {
+			disableLag = app.cfg().CascadeOfflineModeDisableLag
+		}
+	}
+	return enableLag, disableLag
+}
+
+// repairSlaveOfflineMode takes replica out of client rotation and back, loadOffline is set
+// if replica was taken offline because of its load, online is count of replicas serving clients
+func (app *App) repairSlaveOfflineMode(host string, node *mysql.Node, state *NodeState, load *LoadState, loadOffline *LoadOffline, online *int, masterNode *mysql.Node, masterState *NodeState) {
+	if loadOffline != nil && !state.IsOffline {
+		// returned online by operator or by lag repair
+		app.clearLoadOffline(host)
+		loadOffline = nil
+	}
+	if lag := app.smoothedLag(host, state); lag != nil {
 		replPermBroken, _ := state.IsReplicationPermanentlyBroken()
-		if state.IsOffline && *state.SlaveState.ReplicationLag <= app.config.OfflineModeDisableLag.Seconds() {
+		enableLag, disableLag := app.offlineModeLags(state)
+		overloaded, relieved := app.loadOfflineDecision(load)
+		held := app.loadOfflineHeld(loadOffline)
+		if state.IsOffline && *lag <= disableLag.Seconds() && !relieved {
+			app.logger.Infof("repair: slave %s stays offline, because its load (%s) is still high", host, load)
+		} else if state.IsOffline && *lag <= disableLag.Seconds() && held {
+			app.logger.Infof("repair: slave %s stays offline for %v after overload (%s) since %s",
+				host, app.cfg().OfflineModeLoadHoldTime, &loadOffline.Load, loadOffline.Since.Format(time.RFC3339))
+		}
+		if state.IsOffline && *lag <= disableLag.Seconds() && relieved && !held {
 			if replPermBroken {
 				app.logger.Infof("repair: replica %s is permanently broken, won't set online", host)
 				return
@@ -1669,6 +2082,10 @@ func (app *App) repairSlaveOfflineMode(host string, node *mysql.Node, state *Nod
 				app.logger.Errorf("repair: should not turn slave to online until get actual resetup status")
 				return
 			}
+			if err := app.policyApproveOffline(host, false, "lag"); err != nil {
+				app.logger.Infof("repair: slave %s stays offline: %v", host, err)
+				return
+			}
 			err = node.SetDefaultReplicationSettings(masterNode)
 			if err != nil {
 				app.logger.Errorf("repair: failed to set default replication settings on slave %s: %s", host, err)
@@ -1676,23 +2093,47 @@ func (app *App) repairSlaveOfflineMode(host string, node *mysql.Node, state *Nod
 			err = node.SetOnline()
 			if err != nil {
 				app.logger.Errorf("repair: failed to set slave %s online: %s", host, err)
+			} else if loadOffline != nil {
+				app.logger.Infof("repair: slave %s set online, because its load (%s) dropped since it went offline (%s)",
+					host, load, &loadOffline.Load)
+				app.clearLoadOffline(host)
 			} else {
 				app.logger.Infof("repair: slave %s set online, because ReplicationLag (%f s) <= OfflineModeDisableLag (%v)",
-					host, *state.SlaveState.ReplicationLag, app.config.OfflineModeDisableLag)
+					host, *lag, disableLag)
 			}
 		}
-		if !state.IsOffline && !masterState.IsReadOnly && *state.SlaveState.ReplicationLag > app.config.OfflineModeEnableLag.Seconds() {
-			err := node.SetOffline()
-			if err != nil {
+		// by default replicas are not taken out of rotation while master is read-only,
+		// as lag can't grow without writes
+		masterAllowsOffline := !masterState.IsReadOnly || app.cfg().OfflineModeIgnoreMasterReadOnly
+		if !state.IsOffline && masterAllowsOffline && *lag > enableLag.Seconds() {
+			// policy veto skips taking replica offline only, broken replication is handled below anyway
+			if err := app.policyApproveOffline(host, true, "lag"); err != nil {
+				app.logger.Infof("repair: slave %s stays online: %v", host, err)
+			} else if err := node.SetOffline(); err != nil {
 				app.logger.Errorf("repair: failed to set slave %s offline: %s", host, err)
 			} else {
 				app.logger.Infof("repair: slave %s set offline, because ReplicationLag (%f s) >= OfflineModeEnableLag (%v)",
-					host, *state.SlaveState.ReplicationLag, app.config.OfflineModeEnableLag)
+					host, *lag, enableLag)
+				*online--
 				err = node.OptimizeReplication()
 				if err != nil {
 					app.logger.Errorf("repair: failed to set optimize replication settings on slave %s: %s", host, err)
 				}
 			}
+		} else if !state.IsOffline && overloaded && *online <= app.cfg().OfflineModeMinOnlineReplicas {
+			app.logger.Warnf("repair: slave %s is overloaded (%s), but stays online: only %d replicas serve clients", host, load, *online)
+		} else if !state.IsOffline && overloaded {
+			if err := app.policyApproveOffline(host, true, "load"); err != nil {
+				app.logger.Infof("repair: slave %s stays online: %v", host, err)
+			} else if err := app.setLoadOffline(host, load); err != nil {
+				app.logger.Errorf("repair: failed to save load of slave %s: %v", host, err)
+			} else if err := node.SetOffline(); err != nil {
+				app.logger.Errorf("repair: failed to set slave %s offline: %s", host, err)
+				app.clearLoadOffline(host)
+			} else {
+				app.logger.Infof("repair: slave %s set offline, because it is overloaded (%s)", host, load)
+				*online--
+			}
 		}
 		// gradual transfer of permanently broken nodes to offline
 		lastShutdownNodeTime, err := app.GetLastShutdownNodeTime()
@@ -1700,8 +2141,12 @@ func (app *App) repairSlaveOfflineMode(host string, node *mysql.Node, state *Nod
 			app.logger.Errorf("repair: failed to get last shutdown node time: %s", err)
 			return
 		}
-		setOfflineIsPossible := time.Since(lastShutdownNodeTime) > app.config.OfflineModeEnableInterval
+		setOfflineIsPossible := time.Since(lastShutdownNodeTime) > app.cfg().OfflineModeEnableInterval
 		if !state.IsOffline && replPermBroken && setOfflineIsPossible {
+			if err := app.policyApproveOffline(host, true, "broken"); err != nil {
+				app.logger.Infof("repair: slave %s stays online: %v", host, err)
+				return
+			}
 			err = app.UpdateLastShutdownNodeTime()
 			if err != nil {
 				app.logger.Errorf("repair: failed to update last shutdown node time: %s", err)
@@ -1730,21 +2175,21 @@ func (app *App) repairReadOnlyOnMaster(masterNode *mysql.Node, masterState *Node
 			continue
 		}
 		if node.IsMaster && masterNode.Host() == host {
-			if node.DiskState.Usage() >= app.config.CriticalDiskUsage {
-				app.logger.Errorf("diskusage: master %s has critical disk usage %0.2f%%", host, node.DiskState.Usage())
+			if node.DiskState.MaxUsage() >= app.cfg().CriticalDiskUsage {
+				app.logger.Errorf("diskusage: master %s has critical disk usage %0.2f%%", host, node.DiskState.MaxUsage())
 				needRo = true
-			} else if node.DiskState.Usage() > app.config.NotCriticalDiskUsage {
-				app.logger.Warnf("diskusage: master %s has grey-zone disk usage %0.2f%%", host, node.DiskState.Usage())
+			} else if node.DiskState.MaxUsage() > app.cfg().NotCriticalDiskUsage {
+				app.logger.Warnf("diskusage: master %s has grey-zone disk usage %0.2f%%", host, node.DiskState.MaxUsage())
 				mayWrite = false
 			}
 		} else {
-			if app.config.SemiSync && node.SemiSyncState != nil && node.SemiSyncState.SlaveEnabled &&
+			if app.cfg().SemiSync && node.SemiSyncState != nil && node.SemiSyncState.SlaveEnabled &&
 				node.SlaveState != nil && node.SlaveState.ReplicationState == mysql.ReplicationRunning {
 				replicasRunning += 1
-				if node.DiskState.Usage() >= app.config.CriticalDiskUsage {
+				if node.DiskState.Usage() >= app.cfg().CriticalDiskUsage {
 					app.logger.Warnf("diskusage: semisync replica %s has critical disk usage %0.2f%%", host, node.DiskState.Usage())
 					replicasLow += 1
-				} else if node.DiskState.Usage() > app.config.NotCriticalDiskUsage {
+				} else if node.DiskState.Usage() > app.cfg().NotCriticalDiskUsage {
 					app.logger.Warnf("diskusage: semisync replica %s has grey-zone disk usage %0.2f%%", host, node.DiskState.Usage())
 				} else {
 					replicasNormal += 1
@@ -1762,12 +2207,12 @@ func (app *App) repairReadOnlyOnMaster(masterNode *mysql.Node, masterState *Node
 		}
 	}
 	if needRo {
-		keepSuperWritable := app.config.KeepSuperWritableOnCriticalDiskUsage
+		keepSuperWritable := app.cfg().KeepSuperWritableOnCriticalDiskUsage
 		if masterState.IsReadOnly && (keepSuperWritable != masterState.IsSuperReadOnly) {
 			app.logger.Infof("diskusage: master is already read-only")
 			return
 		}
-		err := masterNode.SetReadOnlyWithForce(app.config.ExcludeUsers, !keepSuperWritable)
+		err := masterNode.SetReadOnlyWithForce(app.cfg().ExcludeUsers, !keepSuperWritable)
 		if err != nil {
 			app.logger.Errorf("diskusage: failed to set master read-only: %v", err)
 		} else {
@@ -1799,10 +2244,19 @@ func (app *App) repairReadOnlyOnMaster(masterNode *mysql.Node, masterState *Node
 }
 
 func (app *App) repairCluster(clusterState, clusterStateDcs map[string]*NodeState, master string) {
+	quarantined, err := app.getQuarantinedHosts()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get quarantined hosts: %v", err)
+		return
+	}
 	for host, state := range clusterState {
 		if !state.PingOk {
 			continue
 		}
+		if quarantined[host] != nil {
+			app.logger.Infof("repair: %s is quarantined %v, skipping", host, quarantined[host])
+			continue
+		}
 		node := app.cluster.Get(host)
 		if host == master {
 			app.repairMasterNode(node, clusterState, clusterStateDcs)
@@ -1835,41 +2289,21 @@ func (app *App) repairSlaveNode(node *mysql.Node, clusterState map[string]*NodeS
 	state := clusterState[host]
 	// node is real slave or stale master here
 	// state.SlaveState may be nil
-	if !state.IsReadOnly {
+	if replicaWritable(state, app.cfg().EnforceSettings) {
 		err := node.SetReadOnly(true)
 		if err != nil {
 			app.logger.Errorf("repair: failed to set host %s read-only: %s", host, err)
 		} else {
 			app.logger.Infof("repair: slave %s set read-only", host)
+			if !state.IsMaster {
+				app.settingsDriftCorrected(host, "%s on replica, set super_read_only back", describeReadOnlyDrift(state))
+			}
 		}
 	}
 
 	if state.IsMaster {
-		// if we found stale master, we should set it offline and resetup
-		app.logger.Infof("repair: found stale master %s", host)
-		app.logger.Infof("repair: setting stale master %s offline", host)
-		err := app.stopReplicationOnMaster(node)
-		if err != nil {
-			app.logger.Errorf("repair: %s", err)
-		}
-		err = app.externalReplication.Stop(node)
-		if err != nil {
-			app.logger.Errorf("repair: %s", err)
-		}
-		err = app.externalReplication.Reset(node)
-		if err != nil {
-			app.logger.Errorf("repair: %s", err)
-		}
-		app.logger.Infof("repair: turning stale master %s to new master %s", host, master)
-		err = app.performChangeMaster(host, master)
-		if err != nil {
-			app.logger.Errorf("repair: error turning stale master %s to new master: %s", host, err)
-		}
-		app.logger.Infof("repair: mark stale master %s for recovery", host)
-		err = app.SetRecovery(host)
-		if err != nil {
-			app.logger.Errorf("repair: error setting stale master %s for recovery: %s", host, err)
-		}
+		// if we found stale master, we should set it offline and turn to replica or resetup
+		app.rejoinOldMaster(host, node, master)
 		return
 	}
 
@@ -1887,18 +2321,33 @@ func (app *App) repairSlaveNode(node *mysql.Node, clusterState map[string]*NodeS
 
 	if !state.IsCascade {
 		if state.SlaveState != nil && state.SlaveState.MasterHost != master {
-			app.logger.Infof("repair: found stale slave %s, trying to turn it to new replication source %s", host, master)
-			err := app.performChangeMaster(host, master)
-			if err != nil {
-				app.logger.Errorf("repair: %s", err)
+			if app.repairAllowed(host, repairActionChangeMaster) {
+				app.logger.Infof("repair: found stale slave %s, trying to turn it to new replication source %s", host, master)
+				err := app.performChangeMaster(host, master)
+				app.repairAttempted(host, repairActionChangeMaster)
+				if err != nil {
+					app.logger.Errorf("repair: %s", err)
+				} else {
+					app.settingsDriftCorrected(host, "replication source was %s instead of master %s, changed back", state.SlaveState.MasterHost, master)
+				}
 			}
 		} else if state.SlaveState != nil && state.SlaveState.ReplicationState == mysql.ReplicationStopped {
+			app.resetRepairBudget(host, repairActionChangeMaster)
 			// cascade nodes' replication may be stopped during period of changing stream_from host
-			err := node.StartSlave()
-			if err != nil {
-				app.logger.Errorf("repair: failed to start replication on %s: %v", host, err)
-			} else {
-				app.logger.Infof("repair: replication started on %s", host)
+			if app.repairAllowed(host, repairActionStartSlave) {
+				err := node.StartSlave()
+				app.repairAttempted(host, repairActionStartSlave)
+				if err != nil {
+					app.logger.Errorf("repair: failed to start replication on %s: %v", host, err)
+				} else {
+					app.logger.Infof("repair: replication started on %s", host)
+					app.settingsDriftCorrected(host, "replication was stopped, started again")
+				}
+			}
+		} else if state.SlaveState != nil {
+			app.resetRepairBudget(host, repairActionChangeMaster)
+			if state.SlaveState.ReplicationState == mysql.ReplicationRunning {
+				app.resetRepairBudget(host, repairActionStartSlave)
 			}
 		}
 	}
@@ -1908,10 +2357,10 @@ func (app *App) repairSlaveNode(node *mysql.Node, clusterState map[string]*NodeS
 			if result, code := state.IsReplicationPermanentlyBroken(); result {
 				app.logger.Warnf("repair: replication on host %v is permanently broken, error code: %d", host, code)
 			} else {
-				app.TryRepairReplication(node, master, app.config.ReplicationChannel)
+				app.TryRepairReplication(node, master, app.cfg().ReplicationChannel)
 			}
 		} else {
-			app.MarkReplicationRunning(node, app.config.ReplicationChannel)
+			app.MarkReplicationRunning(node, app.cfg().ReplicationChannel)
 		}
 	}
 }
@@ -1948,6 +2397,7 @@ func (app *App) repairCascadeNode(node *mysql.Node, clusterState map[string]*Nod
 	if isReplicationRunning && upstreamCandidate == upstreamMaster {
 		app.logger.Infof("repair: replication from desired stream_from is running. Do nothing.")
 		delete(app.streamFromFailedAt, host)
+		app.resetRepairBudget(host, repairActionStartSlave)
 		return
 	}
 
@@ -1957,7 +2407,11 @@ func (app *App) repairCascadeNode(node *mysql.Node, clusterState map[string]*Nod
 			app.logger.Warnf("repair: replication on host %v is permanently broken, error code: %d", host, code)
 			return
 		}
+		if !app.repairAllowed(host, repairActionStartSlave) {
+			return
+		}
 		err := node.StartSlave()
+		app.repairAttempted(host, repairActionStartSlave)
 		if err != nil {
 			app.logger.Warnf("repair: failed to start slave")
 			return
@@ -2041,8 +2495,11 @@ func (app *App) repairExternalReplication(masterNode *mysql.Node) {
 	}
 
 	if app.externalReplication.IsRunningByUser(masterNode) && !extReplStatus.ReplicationRunning() {
+		if app.failoverExternalSource(masterNode, extReplStatus) {
+			return
+		}
 		// TODO: remove "". Master is not needed for external replication now
-		app.TryRepairReplication(masterNode, "", app.config.ExternalReplicationChannel)
+		app.TryRepairReplication(masterNode, "", app.cfg().ExternalReplicationChannel)
 	}
 }
 
@@ -2062,7 +2519,12 @@ func (app *App) findBestStreamFrom(node *mysql.Node, clusterState map[string]*No
 			return master
 		}
 
-		candidateState := clusterState[streamFrom]
+		candidateState, ok := clusterState[streamFrom]
+		if !ok {
+			// stream_from host was removed from cluster, e.g. dead relay: go upstream
+			loopDetector = append(loopDetector, streamFrom)
+			continue
+		}
 
 		// if cascade node is streaming now from configured host - do nothing
 		if len(loopDetector) == 1 {
@@ -2074,10 +2536,10 @@ func (app *App) findBestStreamFrom(node *mysql.Node, clusterState map[string]*No
 			}
 		}
 
+		lag := app.smoothedLag(streamFrom, candidateState)
 		hasReasonableLag := candidateState.IsMaster || (candidateState.SlaveState != nil &&
 			candidateState.SlaveState.ReplicationState == mysql.ReplicationRunning &&
-			candidateState.SlaveState.ReplicationLag != nil &&
-			*candidateState.SlaveState.ReplicationLag < app.config.StreamFromReasonableLag.Seconds())
+			lag != nil && *lag < app.cfg().StreamFromReasonableLag.Seconds())
 
 		if candidateState.PingOk && !candidateState.IsOffline && hasReasonableLag {
 			return streamFrom // first suitable cascadeNodeState is Ok
@@ -2088,7 +2550,7 @@ func (app *App) findBestStreamFrom(node *mysql.Node, clusterState map[string]*No
 }
 
 func (app *App) enterMaintenance(maintenance *Maintenance, master string) error {
-	if app.config.DisableSemiSyncReplicationOnMaintenance {
+	if app.cfg().DisableSemiSyncReplicationOnMaintenance {
 		node := app.cluster.Get(master)
 		err := node.SemiSyncDisable()
 		if err != nil {
@@ -2100,10 +2562,14 @@ func (app *App) enterMaintenance(maintenance *Maintenance, master string) error
 		}
 	}
 	maintenance.MySyncPaused = true
-	return app.dcs.Set(pathMaintenance, maintenance)
+	err := app.dcs.Set(pathMaintenance, maintenance)
+	if err == nil {
+		app.recordEvent(HistoryEvent{Type: EventMaintenanceOn, Message: fmt.Sprintf("initiated by %s", maintenance.InitiatedBy), Operator: maintenance.Operator})
+	}
+	return err
 }
 
-func (app *App) leaveMaintenance() error {
+func (app *App) leaveMaintenance(maintenance *Maintenance) error {
 	err := app.cluster.UpdateHostsInfo()
 	if err != nil {
 		return err
@@ -2133,7 +2599,15 @@ func (app *App) leaveMaintenance() error {
 	if len(activeNodes) == 0 {
 		return ErrNoActiveNodes
 	}
-	return app.dcs.Delete(pathMaintenance)
+	err = app.dcs.Delete(pathMaintenance)
+	if err == nil {
+		event := HistoryEvent{Type: EventMaintenanceOff, Host: master}
+		if maintenance != nil {
+			event.Operator = maintenance.DisabledBy
+		}
+		app.recordEvent(event)
+	}
+	return err
 }
 
 func (app *App) performChangeMaster(host, master string) error {
@@ -2159,7 +2633,7 @@ func (app *App) performChangeMaster(host, master string) error {
 		return fmt.Errorf("failed to start slave on host %s: %s", host, err)
 	}
 
-	deadline := time.Now().Add(app.config.WaitReplicationStartTimeout)
+	deadline := time.Now().Add(app.cfg().WaitReplicationStartTimeout)
 	var sstatus mysql.ReplicaStatus
 	for time.Now().Before(deadline) {
 		sstatus, err = node.GetReplicaStatus()
@@ -2171,6 +2645,7 @@ func (app *App) performChangeMaster(host, master string) error {
 			break
 		}
 		app.logger.Warnf("changemaster: replication on host %s is not running yet, waiting...", host)
+		app.liveness.tickPhase(time.Now())
 		time.Sleep(time.Second)
 	}
 	if sstatus != nil && sstatus.ReplicationRunning() {
@@ -2189,10 +2664,10 @@ func (app *App) getNodeState(host string) *NodeState {
 		node = app.cluster.Get(host)
 	}
 	nodeState := new(NodeState)
-	nodeState.ShowOnlyGTIDDiff = app.config.ShowOnlyGTIDDiff
+	nodeState.ShowOnlyGTIDDiff = app.cfg().ShowOnlyGTIDDiff
 	err := func() error {
 		nodeState.CheckAt = time.Now()
-		nodeState.CheckBy = app.config.Hostname
+		nodeState.CheckBy = app.cfg().Hostname
 		pingOk, err := node.Ping()
 		nodeState.PingOk = pingOk
 		if err != nil {
@@ -2263,12 +2738,14 @@ func (app *App) getNodeState(host string) *NodeState {
 func (app *App) getLocalNodeState() *NodeState {
 	node := app.cluster.Local()
 	nodeState := app.getNodeState(node.Host())
+	nodeState.Zone = app.cfg().Zone
 
 	diskUsed, diskTotal, err := node.GetDiskUsage()
 	if err == nil {
 		nodeState.DiskState = new(DiskState)
 		nodeState.DiskState.Total = diskTotal
 		nodeState.DiskState.Used = diskUsed
+		app.fillDiskDetails(nodeState.DiskState)
 	} else {
 		app.logger.Errorf("Failed to get disk usage: %v", err)
 	}
@@ -2284,6 +2761,46 @@ func (app *App) getLocalNodeState() *NodeState {
 	} else {
 		app.logger.Errorf("Failed to check file system on readonly: %v", err)
 	}
+	nodeState.StorageDegradation = app.getStorageDegradation()
+	if nodeState.PingOk {
+		settings, err := node.GetReplicationSettings()
+		if err == nil {
+			nodeState.Durability = &DurabilityState{
+				InnodbFlushLogAtTrxCommit: settings.InnodbFlushLogAtTrxCommit,
+				SyncBinlog:                settings.SyncBinlog,
+			}
+		} else {
+			app.logger.Errorf("Failed to get durability settings: %v", err)
+		}
+	}
+	if nodeState.PingOk && app.loadAwareOffline() {
+		nodeState.Load = app.getLocalLoadState(node)
+	}
+	if nodeState.PingOk {
+		version, err := node.RefreshVersion()
+		if err == nil {
+			nodeState.MySQLVersion = version
+		} else {
+			app.logger.Errorf("Failed to get mysql version: %v", err)
+		}
+	}
+	if nodeState.PingOk && nodeState.IsMaster && app.cfg().ExternalReplicationType != util.Disabled {
+		nodeState.ExternalSlaveState = app.getExternalSlaveState(node)
+	}
+	if nodeState.PingOk && app.cfg().BackupAwareSwitchover {
+		nodeState.IsBackupRunning, err = node.IsBackupRunning()
+		if err != nil {
+			app.logger.Errorf("Failed to check running backup: %v", err)
+		}
+	}
+	if nodeState.PingOk && len(app.cfg().HealthChecks) > 0 {
+		failed, critical := app.runHealthChecks()
+		nodeState.FailedHealthChecks = failed
+		if critical {
+			nodeState.PingOk = false
+			nodeState.Error = "critical health check failed"
+		}
+	}
 
 	return nodeState
 }
@@ -2320,12 +2837,9 @@ func (app *App) getLocalDaemonState() (*DaemonState, error) {
 
 func (app *App) getClusterStateFromDB() map[string]*NodeState {
 	hosts := app.cluster.AllNodeHosts()
+	slots := make(chan struct{}, app.cfg().HostStatusConcurrency)
 	getter := func(host string) (*NodeState, error) {
-		if host == app.cluster.Local().Host() {
-			return app.getLocalNodeState(), nil
-		}
-		ns := app.getNodeState(host)
-		return ns, nil
+		return app.getNodeStateWithTimeout(host, slots), nil
 	}
 	clusterState, _ := getNodeStatesInParallel(hosts, getter, app.logger)
 	return clusterState
@@ -2339,7 +2853,7 @@ func (app *App) getClusterStateFromDcs() (map[string]*NodeState, error) {
 		if err != nil && err != dcs.ErrNotFound {
 			return nil, err
 		}
-		nodeState.ShowOnlyGTIDDiff = app.config.ShowOnlyGTIDDiff
+		nodeState.ShowOnlyGTIDDiff = app.cfg().ShowOnlyGTIDDiff
 		return nodeState, nil
 	}
 	return getNodeStatesInParallel(hosts, getter, app.logger)
@@ -2363,6 +2877,7 @@ func (app *App) waitForCatchUp(node *mysql.Node, gtidset gtids.GTIDSet, timeout
 		if app.CheckAsyncSwitchAllowed(node, switchover) {
 			return true, nil
 		}
+		app.liveness.tickPhase(time.Now())
 		time.Sleep(sleep)
 		if time.Now().After(deadline) {
 			break
@@ -2437,7 +2952,7 @@ func (app *App) getNodePositions(activeNodes []string) ([]nodePosition, error) {
 				return fmt.Errorf("failed to get master status on host %s: %s", host, err)
 			}
 		} else {
-			gtidset = gtids.ParseGtidSet(sstatus.GetExecutedGtidSet())
+			gtidset = gtids.ParseGtidSet(sstatus.GetExecutedGtidSet()).Clone()
 			if sstatus.GetRetrievedGtidSet() != "" {
 				// slave may have downloaded but not applied transactions
 				err := gtidset.Update(sstatus.GetRetrievedGtidSet())
@@ -2471,6 +2986,7 @@ Run enters the main application loop
 When Run exits mysync process is over
 */
 func (app *App) Run() int {
+	defer app.Close()
 	ctx := app.baseContext()
 
 	err := app.lockFile()
@@ -2479,9 +2995,25 @@ func (app *App) Run() int {
 		return 1
 	}
 	defer app.unlockFile()
+	app.writePidToLockFile()
+
+	shutdownTracing, err := app.initTracing(ctx)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer shutdownTracing()
+	err = app.initStatsD()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
 
 	app.logger.Infof("MYSYNC START")
-	app.logger.Infof("config failover: %v semisync: %v", app.config.Failover, app.config.SemiSync)
+	app.logger.Infof("config failover: %v semisync: %v", app.cfg().Failover, app.cfg().SemiSync)
+	if app.cfg().ObserveOnly {
+		app.logger.Warn("running in observe-only mode: actions are logged and recorded, but never applied to MySQL and cluster topology in dcs")
+	}
 
 	err = app.connectDCS()
 	if err != nil {
@@ -2489,6 +3021,33 @@ func (app *App) Run() int {
 		return 1
 	}
 	defer app.dcs.Close()
+	if app.cfg().DevMode {
+		app.dcs = &chaosDCS{DCS: app.dcs, faults: &app.faults}
+	}
+	if app.cfg().ObserveOnly {
+		app.dcs = &observeOnlyDCS{DCS: app.dcs, logger: app.logger}
+	}
+
+	err = app.applyDCSHostOverrides(app.cfg())
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	err = app.logger.SetLevel(app.cfg().LogLevel)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+
+	// issued here rather than on config read, CLI commands do not need them
+	var vaultErr error
+	app.config.Update(func(cfg *config.Config) {
+		vaultErr = cfg.IssueVaultDatabaseCredentials()
+	})
+	if vaultErr != nil {
+		app.logger.Error(vaultErr.Error())
+		return 1
+	}
 
 	err = app.newDBCluster()
 	if err != nil {
@@ -2496,16 +3055,56 @@ func (app *App) Run() int {
 		return 1
 	}
 	defer app.cluster.Close()
+	app.loadLocalState()
+	if app.cfg().PolicyFile != "" {
+		if _, err := app.policy.load(app.cfg().PolicyFile); err != nil {
+			app.logger.Errorf("policy: failed to load %s: %v", app.cfg().PolicyFile, err)
+		}
+	}
 
+	if len(app.cfg().Notifiers) > 0 {
+		app.notifications = make(chan HistoryEvent, notifyQueueSize)
+		go app.notifier(ctx)
+	}
 	go app.healthChecker(ctx)
 	go app.recoveryChecker(ctx)
 	go app.stateFileHandler(ctx)
-	if app.config.ExternalReplicationType != util.Disabled {
+	if app.cfg().ExternalReplicationType != util.Disabled {
 		go app.externalCAFileChecker(ctx)
 	}
-	if app.config.ReplMon {
+	if app.cfg().ReplMon {
 		go app.replMonWriter(ctx)
 	}
+	if app.cfg().LivenessQuorum > 0 || app.cfg().ZonePolicy.MinReachableZones > 0 {
+		go app.livenessChecker(ctx)
+	}
+	if app.cfg().APIListen != "" {
+		go app.apiServer(ctx)
+	}
+	if app.cfg().GRPCListen != "" {
+		go app.grpcServer(ctx)
+	}
+	if app.cfg().HealthListen != "" {
+		go app.healthServer(ctx)
+	}
+	if app.cfg().DebugListen != "" {
+		go app.debugServer(ctx)
+	}
+	if app.cfg().MySQLCredentialsLease != nil {
+		go app.vaultCredentialsRenewer(ctx)
+	}
+	if app.cfg().Kubernetes.Enabled {
+		go app.kubernetesSyncer(ctx)
+	}
+	if len(app.cfg().TopologyPublishers) > 0 {
+		go app.topologyPublisher(ctx)
+	}
+	if app.cfg().VIP.Address != "" {
+		go app.vipManager(ctx)
+	}
+	if app.cfg().Discovery.Type != "" {
+		go app.discoveryRegistrar(ctx)
+	}
 
 	handlers := map[appState](func() appState){
 		stateFirstRun:    app.stateFirstRun,
@@ -2515,12 +3114,41 @@ func (app *App) Run() int {
 		stateMaintenance: app.stateMaintenance,
 	}
 
-	ticker := time.NewTicker(app.config.TickInterval)
+	reloadSigs := make(chan os.Signal, 1)
+	signal.Notify(reloadSigs, syscall.SIGHUP)
+	restartSigs := make(chan os.Signal, 1)
+	signal.Notify(restartSigs, restartSignal)
+
+	app.liveness.tickLoop(time.Now(), app.state)
+	go app.systemdWatchdog(ctx)
+	app.notifySystemd("READY=1")
+	defer func() {
+		if app.restarting {
+			app.notifySystemd("RELOADING=1")
+		} else {
+			app.notifySystemd("STOPPING=1")
+		}
+	}()
+	interval := app.cfg().TickInterval
+	ticker := time.NewTicker(interval)
 	for {
 		select {
+		case <-reloadSigs:
+			app.reloadConfig()
+		case <-restartSigs:
+			// state handlers are not interrupted, so restart happens between cycles
+			app.prepareRestart()
+			return 0
 		case <-ticker.C:
 			// run states without sleep while app.state changes
 			for {
+				app.faults.waitUnfrozen(ctx)
+				now := time.Now()
+				app.liveness.tickLoop(now, app.state)
+				app.stateTimes.enter(string(app.state), now)
+				if app.state != stateManager {
+					app.masterViewTimes.enter("", now)
+				}
 				app.logger.Infof("mysync state: %s", app.state)
 				stateHandler := handlers[app.state]
 				if stateHandler == nil {
@@ -2533,6 +3161,7 @@ func (app *App) Run() int {
 				// TODO: update state file ?
 				app.state = nextState
 			}
+			app.resetTicker(ticker, &interval, app.nextLoopInterval(time.Now()), "main loop")
 		case <-ctx.Done():
 			return 0
 		}
@@ -1,8 +1,10 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/yandex/mysync/internal/dcs"
@@ -23,6 +25,24 @@ func (app *App) GetActiveNodes() ([]string, error) {
 	return activeNodes, nil
 }
 
+// getChildrenValues reads values of all children of path with a single tree read instead of one read per child
+func (app *App) getChildrenValues(path string) (map[string]json.RawMessage, error) {
+	tree, err := app.dcs.GetTree(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes, _ := tree.(map[string]interface{})
+	values := make(map[string]json.RawMessage, len(nodes))
+	for name, node := range nodes {
+		data, err := json.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = data
+	}
+	return values, nil
+}
+
 func (app *App) GetClusterCascadeFqdnsFromDcs() ([]string, error) {
 	fqdns, err := app.dcs.GetChildren(dcs.PathCascadeNodesPrefix)
 	if err == dcs.ErrNotFound {
@@ -147,12 +167,19 @@ func (app *App) FinishSwitchover(switchover *Switchover, switchErr error) error
 
 	if switchErr != nil {
 		switchover.Result.Error = switchErr.Error()
+	} else {
+		switchover.Result.WriteDowntime = writeDowntime(switchover)
+		if switchover.lost != nil {
+			switchover.Result.LostTransactions = switchover.lost.gtids
+			switchover.Result.LostTransactionsCount = switchover.lost.count
+		}
 	}
 
 	err := app.dcs.Delete(pathCurrentSwitch)
 	if err != nil {
 		return err
 	}
+	app.recordSwitchoverEvent(switchover)
 	return app.dcs.Set(path, switchover)
 }
 
@@ -169,11 +196,53 @@ func (app *App) FailSwitchover(switchover *Switchover, err error) error {
 
 func (app *App) StartSwitchover(switchover *Switchover) error {
 	app.logger.Infof("switchover: %s => %s starting...", switchover.From, switchover.To)
-	switchover.StartedAt = time.Now()
-	switchover.StartedBy = app.config.Hostname
+	// deadline of switchover counts from the first start, so it is not reset by retries
+	if switchover.StartedAt.IsZero() {
+		switchover.StartedAt = time.Now()
+	}
+	switchover.StartedBy = app.cfg().Hostname
 	return app.dcs.Set(pathCurrentSwitch, switchover)
 }
 
+// restoreMasterAfterAbort makes master from dcs writable again, if aborted switchover had already set it read-only.
+// Master left read-only by low disk space is kept as is
+func (app *App) restoreMasterAfterAbort() {
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil || master == "" {
+		app.logger.Errorf("abort: failed to get master from dcs: %v", err)
+		return
+	}
+	var lowSpace bool
+	err = app.dcs.Get(pathLowSpace, &lowSpace)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("abort: failed to get low space flag from dcs: %v", err)
+		return
+	}
+	if lowSpace {
+		app.logger.Warnf("abort: master %s is kept read-only due to low space", master)
+		return
+	}
+	node := app.cluster.Get(master)
+	if node == nil {
+		app.logger.Errorf("abort: master %s is not in cluster", master)
+		return
+	}
+	readOnly, _, err := node.IsReadOnly()
+	if err != nil {
+		app.logger.Errorf("abort: failed to get read-only status of master %s: %v", master, err)
+		return
+	}
+	if !readOnly {
+		return
+	}
+	err = node.SetWritable()
+	if err != nil {
+		app.logger.Errorf("abort: failed to set master %s writable: %v", master, err)
+		return
+	}
+	app.logger.Infof("abort: master %s set writable after aborted switchover", master)
+}
+
 func (app *App) GetLastSwitchover() Switchover {
 	var lastSwitch, lastRejectedSwitch Switchover
 	err := app.dcs.Get(pathLastSwitch, &lastSwitch)
@@ -192,13 +261,23 @@ func (app *App) GetLastSwitchover() Switchover {
 	return lastSwitch
 }
 
-func (app *App) IssueFailover(master string) error {
+func (app *App) IssueFailover(master string, cause *FailoverCause) error {
 	var switchover Switchover
 	switchover.From = master
-	switchover.InitiatedBy = app.config.Hostname
+	switchover.InitiatedBy = app.cfg().Hostname
 	switchover.InitiatedAt = time.Now()
 	switchover.Cause = CauseAuto
-	return app.dcs.Create(pathCurrentSwitch, switchover)
+	switchover.FailoverCause = cause
+	err := app.dcs.Create(pathCurrentSwitch, switchover)
+	if err == nil {
+		event := HistoryEvent{Type: EventFailoverIssued, Host: master}
+		if cause != nil {
+			event.Cause = cause.Kind
+			event.Message = strings.Join(cause.Evidence, "; ")
+		}
+		app.recordEvent(event)
+	}
+	return err
 }
 
 func (app *App) SetMasterHost(master string) (string, error) {
@@ -206,6 +285,10 @@ func (app *App) SetMasterHost(master string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to set current master to dcs: %s", err)
 	}
+	err = app.bumpEpoch(master)
+	if err != nil {
+		return "", fmt.Errorf("failed to set epoch to dcs: %s", err)
+	}
 	return master, nil
 }
 
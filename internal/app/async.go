@@ -8,21 +8,21 @@ import (
 )
 
 func (app *App) CheckAsyncSwitchAllowed(node *mysql.Node, switchover *Switchover) bool {
-	if app.config.ASync && switchover.Cause == CauseAuto && app.config.AsyncAllowedLag > 0 {
+	if app.cfg().ASync && switchover.Cause == CauseAuto && app.cfg().AsyncAllowedLag > 0 {
 		app.logger.Infof("async mode is active and this is auto switch so we checking new master delay")
 		ts, err := app.GetReplMonTS()
 		if err != nil {
 			app.logger.Errorf("failed to get mdb repl mon ts: %v", err)
 			return false
 		}
-		delay, err := node.CalcReplMonTSDelay(app.config.ReplMonSchemeName, app.config.ReplMonTableName, ts)
+		delay, err := node.CalcReplMonTSDelay(app.cfg().ReplMonSchemeName, app.cfg().ReplMonTableName, ts)
 		if err != nil {
 			app.logger.Errorf("failed to calc mdb repl mon ts: %v", err)
 			return false
 		}
-		if time.Duration(delay)*time.Second < app.config.AsyncAllowedLag {
+		if time.Duration(delay)*time.Second < app.cfg().AsyncAllowedLag {
 			app.logger.Infof("async allowed lag is %f seconds and current lag on host %s is %d, so we don't wait for catch up any more",
-				app.config.AsyncAllowedLag.Seconds(), node.Host(), delay)
+				app.cfg().AsyncAllowedLag.Seconds(), node.Host(), delay)
 			return true
 		}
 	}
@@ -31,7 +31,7 @@ func (app *App) CheckAsyncSwitchAllowed(node *mysql.Node, switchover *Switchover
 
 func (app *App) updateReplMonTS(master string) error {
 	masterNode := app.cluster.Get(master)
-	ts, err := masterNode.GetReplMonTS(app.config.ReplMonSchemeName, app.config.ReplMonTableName)
+	ts, err := masterNode.GetReplMonTS(app.cfg().ReplMonSchemeName, app.cfg().ReplMonTableName)
 	if err != nil {
 		return fmt.Errorf("failed to get master repl_mon timestamp: %v", err)
 	}
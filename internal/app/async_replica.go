@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// asyncReplicas returns HA hosts marked as not eligible for semi-sync
+func asyncReplicas(haNodes map[string]mysql.NodeConfiguration) []string {
+	var hosts []string
+	for host, nc := range haNodes {
+		if nc.NoSemiSync {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// getAsyncReplicas returns HA hosts replicating asynchronously
+func (app *App) getAsyncReplicas() ([]string, error) {
+	haNodes, err := app.cluster.GetClusterHAHostsFromDcs()
+	if err != nil {
+		return nil, err
+	}
+	return asyncReplicas(haNodes), nil
+}
+
+// semiSyncEligible filters out async replicas from hosts. In semi-sync mode async replicas stay active,
+// but they never acknowledge commits, so they are not in semi-sync set and can't be promoted without data loss
+func (app *App) semiSyncEligible(hosts []string) ([]string, error) {
+	if !app.cfg().SemiSync {
+		return hosts, nil
+	}
+	async, err := app.getAsyncReplicas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get async replicas: %v", err)
+	}
+	return filterOut(hosts, async), nil
+}
+
+// processSemiSync marks HA host as eligible or not for semi-sync, keeping its other settings
+func (app *App) processSemiSync(semiSync bool, dryRun bool, host string) (changes bool, err error) {
+	var nc mysql.NodeConfiguration
+	err = app.dcs.Get(dcs.JoinPath(pathHANodes, host), &nc)
+	if err != nil {
+		if err != dcs.ErrNotFound {
+			return false, err
+		}
+		fmt.Printf("node %s is not HA node, semi-sync eligibility cannot be set\n", host)
+		return false, fmt.Errorf("node %s is not HA node, semi-sync eligibility cannot be set", host)
+	}
+	if nc.NoSemiSync == !semiSync {
+		if dryRun {
+			fmt.Printf("dry run: node already has semi-sync eligibility %t\n", semiSync)
+		}
+		return false, nil
+	}
+	if dryRun {
+		fmt.Printf("dry run: node semi-sync eligibility can be set to %t\n", semiSync)
+		return true, nil
+	}
+	nc.NoSemiSync = !semiSync
+	err = app.dcs.Set(dcs.JoinPath(pathHANodes, host), nc)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
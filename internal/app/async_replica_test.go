@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestAsyncReplicas(t *testing.T) {
+	haNodes := map[string]mysql.NodeConfiguration{
+		"mysql1": {Priority: 10},
+		"mysql2": {NoSemiSync: true},
+		"mysql3": {},
+	}
+	require.Equal(t, []string{"mysql2"}, asyncReplicas(haNodes))
+}
+
+func TestProcessSemiSync(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	require.NoError(t, app.dcs.Create(pathHANodes, nil))
+	require.NoError(t, app.dcs.Set(dcs.JoinPath(pathHANodes, "mysql2"), mysql.NodeConfiguration{Priority: 5}))
+
+	changes, err := app.processSemiSync(false, true, "mysql2")
+	require.NoError(t, err)
+	require.True(t, changes)
+
+	changes, err = app.processSemiSync(false, false, "mysql2")
+	require.NoError(t, err)
+	require.True(t, changes)
+	var nc mysql.NodeConfiguration
+	require.NoError(t, app.dcs.Get(dcs.JoinPath(pathHANodes, "mysql2"), &nc))
+	require.Equal(t, mysql.NodeConfiguration{Priority: 5, NoSemiSync: true}, nc)
+
+	changes, err = app.processSemiSync(false, false, "mysql2")
+	require.NoError(t, err)
+	require.False(t, changes)
+
+	_, err = app.processSemiSync(false, false, "mysql3")
+	require.Error(t, err)
+}
+
+func TestSemiSyncEligible(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql1"), mysql.NodeConfiguration{}))
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql2"), mysql.NodeConfiguration{NoSemiSync: true}))
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql3"), mysql.NodeConfiguration{}))
+	cluster, err := mysql.NewCluster(app.config, app.logger, app.dcs)
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	app.cluster = cluster
+	active := []string{"mysql1", "mysql2", "mysql3"}
+
+	eligible, err := app.semiSyncEligible(active)
+	require.NoError(t, err)
+	require.Equal(t, active, eligible)
+
+	// async replica stays active, but it is out of semi-sync set
+	app.cfg().SemiSync = true
+	eligible, err = app.semiSyncEligible(active)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mysql1", "mysql3"}, eligible)
+}
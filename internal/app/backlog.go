@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+// applyRateSmoothing is a weight of the latest measurement in apply rate
+const applyRateSmoothing = 0.3
+
+// catchUpEstimateMargin is a safety factor applied to estimated catch up time
+const catchUpEstimateMargin = 1.5
+
+// applyBacklog returns number of transactions retrieved by replica, but not applied yet
+func applyBacklog(executed, retrieved string) int64 {
+	if retrieved == "" {
+		return 0
+	}
+	_, count, err := gtids.MissingTransactions(gtids.ParseGtidSet(executed), gtids.ParseGtidSet(retrieved))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// BacklogString describes relay log usage and apply backlog of replica
+func (ss *SlaveState) BacklogString() string {
+	return fmt.Sprintf("relay_log_space=%d apply_backlog=%d apply_rate=%.1f/s", ss.RelayLogSpace, ss.ApplyBacklog, ss.ApplyRate)
+}
+
+// UpdateApplyRate estimates transactions applied per second by replica since previous health check.
+// Rate is measured only while replica has backlog, otherwise it is limited by incoming writes, not by apply speed.
+func (ns *NodeState) UpdateApplyRate(old *NodeState) (newState *NodeState) {
+	if ns.SlaveState == nil {
+		return nil
+	}
+	if old == nil || old.SlaveState == nil {
+		return ns
+	}
+	ns.SlaveState.ApplyRate = old.SlaveState.ApplyRate
+	elapsed := ns.CheckAt.Sub(old.CheckAt).Seconds()
+	if elapsed <= 0 {
+		return old
+	}
+	if ns.SlaveState.ApplyBacklog == 0 && old.SlaveState.ApplyBacklog == 0 {
+		return ns
+	}
+	applied := gtids.CountTransactions(gtids.ParseGtidSet(ns.SlaveState.ExecutedGtidSet)) -
+		gtids.CountTransactions(gtids.ParseGtidSet(old.SlaveState.ExecutedGtidSet))
+	rate := float64(max(applied, 0)) / elapsed
+	ns.SlaveState.ApplyRate = applyRateSmoothing*rate + (1-applyRateSmoothing)*old.SlaveState.ApplyRate
+	return ns
+}
+
+// filterPositionsByBacklog removes candidates which apply backlog exceeds maxBacklog,
+// unless there are no other candidates
+func filterPositionsByBacklog(positions []nodePosition, clusterState map[string]*NodeState, maxBacklog int64) []nodePosition {
+	if maxBacklog == 0 {
+		return positions
+	}
+	var filtered []nodePosition
+	for _, pos := range positions {
+		state := clusterState[pos.host]
+		if state == nil || state.SlaveState == nil || state.SlaveState.ApplyBacklog <= maxBacklog {
+			filtered = append(filtered, pos)
+		}
+	}
+	if len(filtered) == 0 {
+		return positions
+	}
+	return filtered
+}
+
+// deprioritizeHostsWithBacklog removes candidates having more than max_apply_backlog unapplied transactions,
+// unless there are no other candidates
+func (app *App) deprioritizeHostsWithBacklog(positions []nodePosition, clusterState map[string]*NodeState) []nodePosition {
+	filtered := filterPositionsByBacklog(positions, clusterState, app.cfg().MaxApplyBacklog)
+	if len(filtered) != len(positions) {
+		app.logger.Infof("switchover: hosts with apply backlog over %d are deprioritized, candidates: %v", app.cfg().MaxApplyBacklog, positionHosts(filtered))
+	}
+	return filtered
+}
+
+// catchUpTimeout returns how long new master may catch up: base timeout, extended up to limit
+// when applying backlog at measured rate is expected to take longer
+func catchUpTimeout(base, limit time.Duration, backlog int64, rate float64) time.Duration {
+	if limit <= base || backlog <= 0 || rate <= 0 {
+		return base
+	}
+	estimate := time.Duration(float64(backlog) / rate * catchUpEstimateMargin * float64(time.Second))
+	return min(max(base, estimate), limit)
+}
+
+// getCatchUpTimeout estimates time new master needs to apply transactions of most recent host
+func (app *App) getCatchUpTimeout(node *mysql.Node, target gtids.GTIDSet) time.Duration {
+	base := app.cfg().SlaveCatchUpTimeout
+	if app.cfg().SlaveCatchUpTimeoutMax <= base {
+		return base
+	}
+	executed, err := node.GTIDExecutedParsed()
+	if err != nil {
+		app.logger.Warnf("switchover: failed to get gtid executed from %s: %v", node.Host(), err)
+		return base
+	}
+	_, backlog, err := gtids.MissingTransactions(executed, target)
+	if err != nil {
+		app.logger.Warnf("switchover: failed to estimate backlog of %s: %v", node.Host(), err)
+		return base
+	}
+	var state NodeState
+	err = app.dcs.Get(dcs.JoinPath(pathHealthPrefix, node.Host()), &state)
+	if err != nil || state.SlaveState == nil {
+		return base
+	}
+	timeout := catchUpTimeout(base, app.cfg().SlaveCatchUpTimeoutMax, backlog, state.SlaveState.ApplyRate)
+	app.logger.Infof("switchover: %s has %d transactions to apply at %.1f/s, catch up timeout is %v",
+		node.Host(), backlog, state.SlaveState.ApplyRate, timeout)
+	return timeout
+}
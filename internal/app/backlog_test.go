@@ -0,0 +1,52 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const backlogUUID = "00000000-0000-0000-0000-000000000001"
+
+func TestApplyBacklog(t *testing.T) {
+	require.Equal(t, int64(0), applyBacklog(backlogUUID+":1-100", ""))
+	require.Equal(t, int64(0), applyBacklog(backlogUUID+":1-100", backlogUUID+":1-100"))
+	require.Equal(t, int64(50), applyBacklog(backlogUUID+":1-100", backlogUUID+":1-150"))
+}
+
+func TestUpdateApplyRate(t *testing.T) {
+	now := time.Now()
+	old := &NodeState{CheckAt: now, SlaveState: &SlaveState{ExecutedGtidSet: backlogUUID + ":1-100", ApplyBacklog: 500}}
+	ns := &NodeState{CheckAt: now.Add(10 * time.Second), SlaveState: &SlaveState{ExecutedGtidSet: backlogUUID + ":1-300", ApplyBacklog: 300}}
+	require.Equal(t, ns, ns.UpdateApplyRate(old))
+	require.InDelta(t, applyRateSmoothing*20, ns.SlaveState.ApplyRate, 0.0001)
+
+	// without backlog apply rate is limited by writes on master, previous estimation is kept
+	idle := &NodeState{CheckAt: now.Add(20 * time.Second), SlaveState: &SlaveState{ExecutedGtidSet: backlogUUID + ":1-301"}}
+	ns.SlaveState.ApplyBacklog = 0
+	require.Equal(t, idle, idle.UpdateApplyRate(ns))
+	require.Equal(t, ns.SlaveState.ApplyRate, idle.SlaveState.ApplyRate)
+}
+
+func TestBacklogCandidates(t *testing.T) {
+	positions := []nodePosition{{host: "mysql2"}, {host: "mysql3"}}
+	clusterState := map[string]*NodeState{
+		"mysql2": {SlaveState: &SlaveState{ApplyBacklog: 100000}},
+		"mysql3": {SlaveState: &SlaveState{ApplyBacklog: 10}},
+	}
+	require.Equal(t, positions, filterPositionsByBacklog(positions, clusterState, 0))
+	require.Equal(t, []string{"mysql3"}, positionHosts(filterPositionsByBacklog(positions, clusterState, 1000)))
+	// there are no other candidates
+	require.Equal(t, positions, filterPositionsByBacklog(positions, clusterState, 5))
+}
+
+func TestCatchUpTimeout(t *testing.T) {
+	base := 30 * time.Minute
+	require.Equal(t, base, catchUpTimeout(base, 0, 1000000, 10))
+	require.Equal(t, base, catchUpTimeout(base, 2*time.Hour, 1000000, 0))
+	require.Equal(t, base, catchUpTimeout(base, 2*time.Hour, 1000, 10))
+	// 36000 transactions at 10/s with margin
+	require.Equal(t, 90*time.Minute, catchUpTimeout(base, 2*time.Hour, 36000, 10))
+	require.Equal(t, 2*time.Hour, catchUpTimeout(base, 2*time.Hour, 1000000, 10))
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// getHostsOnBackup returns hosts among given ones, where backup is running:
+// either backup tooling set advisory flag in dcs or mysync agent detected backup lock
+func (app *App) getHostsOnBackup(hosts []string) ([]string, error) {
+	flagged, err := app.dcs.GetChildren(pathBackups)
+	if err != nil && err != dcs.ErrNotFound {
+		return nil, err
+	}
+	var result []string
+	for _, host := range hosts {
+		if util.ContainsString(flagged, host) {
+			result = append(result, host)
+			continue
+		}
+		nodeState := new(NodeState)
+		err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, host), nodeState)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if nodeState.IsBackupRunning {
+			result = append(result, host)
+		}
+	}
+	return result, nil
+}
+
+// checkBackupsBeforeSwitchover returns error if planned switchover should be deferred
+// because backup is running on master or on switchover target
+func (app *App) checkBackupsBeforeSwitchover(switchover *Switchover, master string) error {
+	if !app.cfg().BackupAwareSwitchover || switchover.Cause == CauseAuto || switchover.Force {
+		return nil
+	}
+	hosts := []string{master}
+	if switchover.To != "" {
+		hosts = append(hosts, switchover.To)
+	}
+	onBackup, err := app.getHostsOnBackup(hosts)
+	if err != nil {
+		return fmt.Errorf("failed to check running backups: %v", err)
+	}
+	if len(onBackup) > 0 {
+		return fmt.Errorf("backup is running on %v", onBackup)
+	}
+	return nil
+}
+
+// deprioritizeHostsOnBackup removes candidates running backup, unless there are no other candidates
+func (app *App) deprioritizeHostsOnBackup(positions []nodePosition) []nodePosition {
+	if !app.cfg().BackupAwareSwitchover {
+		return positions
+	}
+	onBackup, err := app.getHostsOnBackup(positionHosts(positions))
+	if err != nil {
+		app.logger.Warnf("switchover: failed to check running backups: %v", err)
+		return positions
+	}
+	var filtered []nodePosition
+	for _, pos := range positions {
+		if !util.ContainsString(onBackup, pos.host) {
+			filtered = append(filtered, pos)
+		}
+	}
+	if len(filtered) == 0 {
+		return positions
+	}
+	if len(filtered) != len(positions) {
+		app.logger.Infof("switchover: hosts %v are running backup, candidates: %v", onBackup, positionHosts(filtered))
+	}
+	return filtered
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// Faults which may be injected in DevMode via debug endpoint
+const (
+	// FaultDCSDisconnect makes DCS look disconnected, all operations fail
+	FaultDCSDisconnect = "dcs_disconnect"
+	// FaultMySQLDelay delays every query to MySQL (to Host only, if set)
+	FaultMySQLDelay = "mysql_delay"
+	// FaultFreezeLoop blocks main loop of agent
+	FaultFreezeLoop = "freeze_loop"
+	// FaultDiskFull reports data disk of local MySQL as full
+	FaultDiskFull = "disk_full"
+	// faultErrorPrefix enables emulated error at given position, e.g. error:freeze_ro
+	faultErrorPrefix = "error:"
+
+	debugFaultsPath = "/debug/faults"
+)
+
+var errChaosDCSDisconnected = errors.New("chaos: dcs connection dropped")
+
+// Fault is injected failure, active until expiration or removal
+type Fault struct {
+	Name  string        `json:"name"`
+	Host  string        `json:"host,omitempty"`
+	Delay time.Duration `json:"delay,omitempty"`
+	Until time.Time     `json:"until,omitempty"`
+}
+
+func validateFault(fault *Fault) error {
+	switch {
+	case fault.Name == FaultDCSDisconnect, fault.Name == FaultFreezeLoop, fault.Name == FaultDiskFull:
+	case fault.Name == FaultMySQLDelay:
+		if fault.Delay <= 0 {
+			return fmt.Errorf("%s requires positive delay", fault.Name)
+		}
+	case strings.HasPrefix(fault.Name, faultErrorPrefix) && len(fault.Name) > len(faultErrorPrefix):
+	default:
+		return fmt.Errorf("unknown fault %q", fault.Name)
+	}
+	return nil
+}
+
+// faultInjector keeps faults injected into running agent
+type faultInjector struct {
+	mu     sync.Mutex
+	faults map[string]*Fault
+}
+
+func (fi *faultInjector) inject(fault Fault) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.faults == nil {
+		fi.faults = make(map[string]*Fault)
+	}
+	fi.faults[fault.Name] = &fault
+}
+
+// clear removes fault by name or all faults if name is empty
+func (fi *faultInjector) clear(name string) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if name == "" {
+		fi.faults = nil
+		return
+	}
+	delete(fi.faults, name)
+}
+
+// active returns fault if it is injected and not expired
+func (fi *faultInjector) active(name string) *Fault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	fault, ok := fi.faults[name]
+	if !ok {
+		return nil
+	}
+	if !fault.Until.IsZero() && time.Now().After(fault.Until) {
+		delete(fi.faults, name)
+		return nil
+	}
+	result := *fault
+	return &result
+}
+
+func (fi *faultInjector) list() []Fault {
+	fi.mu.Lock()
+	names := make([]string, 0, len(fi.faults))
+	for name := range fi.faults {
+		names = append(names, name)
+	}
+	fi.mu.Unlock()
+	sort.Strings(names)
+	faults := make([]Fault, 0, len(names))
+	for _, name := range names {
+		if fault := fi.active(name); fault != nil {
+			faults = append(faults, *fault)
+		}
+	}
+	return faults
+}
+
+// queryDelay returns delay of queries to host
+func (fi *faultInjector) queryDelay(host string) time.Duration {
+	fault := fi.active(FaultMySQLDelay)
+	if fault == nil || (fault.Host != "" && fault.Host != host) {
+		return 0
+	}
+	return fault.Delay
+}
+
+// waitUnfrozen blocks while main loop is frozen
+func (fi *faultInjector) waitUnfrozen(ctx context.Context) {
+	for fi.active(FaultFreezeLoop) != nil {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// injectNodeFaults alters local node state according to injected faults
+func (app *App) injectNodeFaults(hc *NodeState) {
+	if app.faults.active(FaultDiskFull) != nil && hc.DiskState != nil {
+		hc.DiskState.Used = hc.DiskState.Total
+	}
+}
+
+func (app *App) handleDebugFaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var fault struct {
+			Fault
+			Duration time.Duration `json:"duration"`
+		}
+		err := json.NewDecoder(io.LimitReader(r.Body, apiRequestBodyLimit)).Decode(&fault)
+		if err == nil {
+			err = validateFault(&fault.Fault)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fault.Duration > 0 {
+			fault.Until = time.Now().Add(fault.Duration)
+		}
+		app.logger.Warnf("chaos: injecting %s (host %q, delay %v, until %v)", fault.Name, fault.Host, fault.Delay, fault.Until)
+		app.faults.inject(fault.Fault)
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		app.logger.Warnf("chaos: clearing fault %q", name)
+		app.faults.clear(name)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(app.faults.list())
+}
+
+// chaosDCS fails DCS operations while dcs_disconnect fault is injected
+type chaosDCS struct {
+	dcs.DCS
+	faults *faultInjector
+}
+
+func (c *chaosDCS) disconnected() bool {
+	return c.faults.active(FaultDCSDisconnect) != nil
+}
+
+func (c *chaosDCS) IsConnected() bool {
+	return !c.disconnected() && c.DCS.IsConnected()
+}
+
+func (c *chaosDCS) WaitConnected(timeout time.Duration) bool {
+	if c.disconnected() {
+		return false
+	}
+	return c.DCS.WaitConnected(timeout)
+}
+
+func (c *chaosDCS) AcquireLock(path string) bool {
+	return !c.disconnected() && c.DCS.AcquireLock(path)
+}
+
+func (c *chaosDCS) Create(path string, value interface{}) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.Create(path, value)
+}
+
+func (c *chaosDCS) CreateEphemeral(path string, value interface{}) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.CreateEphemeral(path, value)
+}
+
+func (c *chaosDCS) Set(path string, value interface{}) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.Set(path, value)
+}
+
+func (c *chaosDCS) SetEphemeral(path string, value interface{}) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.SetEphemeral(path, value)
+}
+
+func (c *chaosDCS) Get(path string, dest interface{}) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.Get(path, dest)
+}
+
+func (c *chaosDCS) Delete(path string) error {
+	if c.disconnected() {
+		return errChaosDCSDisconnected
+	}
+	return c.DCS.Delete(path)
+}
+
+func (c *chaosDCS) GetTree(path string) (interface{}, error) {
+	if c.disconnected() {
+		return nil, errChaosDCSDisconnected
+	}
+	return c.DCS.GetTree(path)
+}
+
+func (c *chaosDCS) GetChildren(path string) ([]string, error) {
+	if c.disconnected() {
+		return nil, errChaosDCSDisconnected
+	}
+	return c.DCS.GetChildren(path)
+}
+
+func (c *chaosDCS) SessionInfo() dcs.SessionInfo {
+	info := c.DCS.SessionInfo()
+	if c.disconnected() {
+		info.Connected = false
+		info.State = FaultDCSDisconnect
+	}
+	return info
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+func TestFaultInjector(t *testing.T) {
+	var fi faultInjector
+	require.Nil(t, fi.active(FaultFreezeLoop))
+
+	fi.inject(Fault{Name: FaultMySQLDelay, Host: "h1", Delay: time.Second})
+	require.Equal(t, time.Second, fi.queryDelay("h1"))
+	require.Equal(t, time.Duration(0), fi.queryDelay("h2"))
+
+	fi.inject(Fault{Name: FaultFreezeLoop, Until: time.Now().Add(-time.Second)})
+	require.Nil(t, fi.active(FaultFreezeLoop))
+	require.Len(t, fi.list(), 1)
+
+	fi.clear("")
+	require.Empty(t, fi.list())
+
+	require.Error(t, validateFault(&Fault{Name: "power_off"}))
+	require.Error(t, validateFault(&Fault{Name: FaultMySQLDelay}))
+	require.Error(t, validateFault(&Fault{Name: faultErrorPrefix}))
+	require.NoError(t, validateFault(&Fault{Name: faultErrorPrefix + "freeze_ro"}))
+}
+
+func TestHandleDebugFaults(t *testing.T) {
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	app := &App{logger: logger, config: config.NewHolder(&config.Config{DevMode: true})}
+
+	body := `{"name": "error:freeze_ro", "duration": 60000000000}`
+	rec := httptest.NewRecorder()
+	app.handleDebugFaults(rec, httptest.NewRequest(http.MethodPost, debugFaultsPath, strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var faults []Fault
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &faults))
+	require.Len(t, faults, 1)
+	require.True(t, app.emulateError("freeze_ro"))
+	require.False(t, app.emulateError("catchup_failed"))
+
+	rec = httptest.NewRecorder()
+	app.handleDebugFaults(rec, httptest.NewRequest(http.MethodPost, debugFaultsPath, strings.NewReader(`{"name": "unknown"}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	app.handleDebugFaults(rec, httptest.NewRequest(http.MethodDelete, debugFaultsPath+"?name=error:freeze_ro", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, app.emulateError("freeze_ro"))
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// maxClockSkew is clock difference between hosts, which is considered sane
+const maxClockSkew = time.Second
+
+// privileges mysync user should have, either of alternatives is enough
+var requiredPrivileges = [][]string{
+	{"REPLICATION CLIENT"},
+	{"REPLICATION SLAVE"},
+	{"PROCESS"},
+	{"RELOAD"},
+	{"SUPER", "SYSTEM_VARIABLES_ADMIN"},
+}
+
+type checkResult struct {
+	name   string
+	err    error
+	detail string
+}
+
+// CliCheck validates that node is ready to be managed by mysync
+func (app *App) CliCheck() int {
+	var results []checkResult
+	add := func(name string, detail string, err error) {
+		results = append(results, checkResult{name: name, detail: detail, err: err})
+	}
+	add("config", fmt.Sprintf("hostname %s", app.cfg().Hostname), nil)
+	defer func() {
+		failed := 0
+		for _, res := range results {
+			if res.err != nil {
+				failed++
+				fmt.Printf("[FAIL] %s: %v\n", res.name, res.err)
+			} else {
+				fmt.Printf("[PASS] %s: %s\n", res.name, res.detail)
+			}
+		}
+		if failed > 0 {
+			fmt.Printf("%d of %d checks failed\n", failed, len(results))
+		}
+	}()
+
+	err := app.connectDCS()
+	if err != nil {
+		add("dcs", "", fmt.Errorf("failed to connect: %v", err))
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+	add("dcs", "connected", nil)
+	add("dcs write", "test node written and removed", app.checkDCSWritable())
+
+	err = app.newDBCluster()
+	if err != nil {
+		add("mysql", "", err)
+		return 1
+	}
+	defer app.cluster.Close()
+	if err := app.cluster.UpdateHostsInfo(); err != nil {
+		add("cluster hosts", "", err)
+		return 1
+	}
+	add("cluster hosts", strings.Join(app.cluster.AllNodeHosts(), ", "), nil)
+
+	local := app.cluster.Local()
+	ok, err := local.Ping()
+	if err == nil && !ok {
+		err = fmt.Errorf("ping is not ok")
+	}
+	if err != nil {
+		add("mysql credentials", "", err)
+		return 1
+	}
+	user, _ := app.cfg().MySQLCredentials()
+	add("mysql credentials", fmt.Sprintf("connected to %s as %s", local.Host(), user), nil)
+	add("mysql grants", "all required privileges granted", app.checkGrants())
+	if app.cfg().SemiSync {
+		add("semisync plugins", "installed and active", app.checkSemiSyncPlugins())
+	}
+	add("server_id", "unique across cluster", app.checkServerIDs())
+	add("clocks", fmt.Sprintf("skew is less than %v", maxClockSkew), app.checkClocks())
+
+	for _, res := range results {
+		if res.err != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkDCSWritable writes and removes test node in cluster namespace
+func (app *App) checkDCSWritable() error {
+	path := dcs.JoinPath(pathDCSCheck, app.cfg().Hostname)
+	err := app.dcs.Create(pathDCSCheck, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	err = app.dcs.SetEphemeral(path, time.Now())
+	if err != nil {
+		return err
+	}
+	err = app.dcs.Delete(path)
+	if err != nil {
+		return err
+	}
+	// parent is removed too, unless checks on other hosts are using it
+	if children, err := app.dcs.GetChildren(pathDCSCheck); err == nil && len(children) == 0 {
+		_ = app.dcs.Delete(pathDCSCheck)
+	}
+	return nil
+}
+
+func (app *App) checkGrants() error {
+	grants, err := app.cluster.Local().GetGrants()
+	if err != nil {
+		return err
+	}
+	all := strings.ToUpper(strings.Join(grants, "\n"))
+	if strings.Contains(all, "ALL PRIVILEGES ON *.*") {
+		return nil
+	}
+	var missing []string
+	for _, alternatives := range requiredPrivileges {
+		found := false
+		for _, privilege := range alternatives {
+			if strings.Contains(all, privilege) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, strings.Join(alternatives, " or "))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing privileges: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (app *App) checkSemiSyncPlugins() error {
+	plugins, err := app.cluster.Local().GetActivePlugins()
+	if err != nil {
+		return err
+	}
+	for _, pair := range [][]string{
+		{"rpl_semi_sync_master", "rpl_semi_sync_source"},
+		{"rpl_semi_sync_slave", "rpl_semi_sync_replica"},
+	} {
+		if !util.ContainsString(plugins, pair[0]) && !util.ContainsString(plugins, pair[1]) {
+			return fmt.Errorf("plugin %s (%s) is not active", pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+func (app *App) checkServerIDs() error {
+	owners := make(map[int64]string)
+	for _, host := range app.cluster.AllNodeHosts() {
+		id, err := app.cluster.Get(host).GetServerID()
+		if err != nil {
+			return fmt.Errorf("failed to get server_id of %s: %v", host, err)
+		}
+		if owner, ok := owners[id]; ok {
+			return fmt.Errorf("server_id %d is used by both %s and %s", id, owner, host)
+		}
+		owners[id] = host
+	}
+	return nil
+}
+
+func (app *App) checkClocks() error {
+	for _, host := range app.cluster.AllNodeHosts() {
+		skew, err := measureClockOffset(app.cluster.Get(host))
+		if err != nil {
+			return fmt.Errorf("failed to get time of %s: %v", host, err)
+		}
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > maxClockSkew {
+			return fmt.Errorf("clock of %s differs from local by %v", host, skew)
+		}
+	}
+	return nil
+}
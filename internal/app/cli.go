@@ -3,6 +3,8 @@ package app
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
@@ -13,11 +15,51 @@ import (
 
 	"github.com/yandex/mysync/internal/dcs"
 	"github.com/yandex/mysync/internal/mysql"
-	"github.com/yandex/mysync/internal/util"
 )
 
+const (
+	// FormatJSON is machine-readable JSON CLI output
+	FormatJSON = "json"
+	// FormatYAML is machine-readable YAML CLI output
+	FormatYAML = "yaml"
+
+	// cliOutputVersion should be incremented on incompatible changes of machine-readable output
+	cliOutputVersion = 1
+)
+
+// cliOutput is envelope of machine-readable CLI output
+type cliOutput struct {
+	Version int         `json:"version" yaml:"version"`
+	Data    interface{} `json:"data" yaml:"data"`
+}
+
+// printCliOutput prints data in given format.
+// Without format data is printed as yaml for humans, without envelope
+func (app *App) printCliOutput(tree interface{}, format string) int {
+	var data []byte
+	var err error
+	switch format {
+	case "":
+		data, err = yaml.Marshal(tree)
+	case FormatYAML:
+		data, err = yaml.Marshal(cliOutput{Version: cliOutputVersion, Data: tree})
+	case FormatJSON:
+		data, err = json.MarshalIndent(cliOutput{Version: cliOutputVersion, Data: tree}, "", "  ")
+		data = append(data, '\n')
+	default:
+		app.logger.Errorf("unknown output format %s, expected %s or %s", format, FormatJSON, FormatYAML)
+		return 1
+	}
+	if err != nil {
+		app.logger.Errorf("failed to marshal output: %v", err)
+		return 1
+	}
+	fmt.Print(string(data))
+	return 0
+}
+
 // CliInfo is CLI command printing information from DCS to the stdout
-func (app *App) CliInfo(short bool) int {
+func (app *App) CliInfo(short bool, format string) int {
 	err := app.connectDCS()
 	if err != nil {
 		app.logger.Error(err.Error())
@@ -73,16 +115,116 @@ func (app *App) CliInfo(short bool) int {
 			data[pathRecovery] = nodesOnRecovery
 		}
 
+		quarantined, err := app.getQuarantinedHosts()
+		if err != nil {
+			app.logger.Errorf("failed to get quarantined hosts: %v", err)
+			return 1
+		}
+		if len(quarantined) > 0 {
+			quarantine := make(map[string]string)
+			for host, q := range quarantined {
+				quarantine[host] = q.String()
+			}
+			data[pathQuarantine] = quarantine
+		}
+
+		drained, err := app.getDrainedHosts()
+		if err != nil {
+			app.logger.Errorf("failed to get drained hosts: %v", err)
+			return 1
+		}
+		if len(drained) > 0 {
+			drain := make(map[string]string)
+			for host, d := range drained {
+				drain[host] = d.String()
+			}
+			data[pathDrain] = drain
+		}
+
+		upgrade, err := app.getRollingUpgrade()
+		if err != nil {
+			app.logger.Errorf("failed to get rolling upgrade: %v", err)
+			return 1
+		}
+		if upgrade != nil {
+			data[pathRollingUpgrade] = upgrade.String()
+		}
+
+		var throttle ThrottleState
+		err = app.dcs.Get(pathThrottle, &throttle)
+		if err == nil {
+			data[pathThrottle] = throttle.String()
+		} else if err != dcs.ErrNotFound {
+			app.logger.Errorf("failed to get throttle state: %v", err)
+			return 1
+		}
+
+		shedding, err := app.getLoadShedding()
+		if err != nil {
+			app.logger.Errorf("failed to get load shedding state: %v", err)
+			return 1
+		}
+		if shedding != nil {
+			data[pathLoadShedding] = shedding.String()
+		}
+
+		window, err := app.cfg().ActiveFailoverWindow(time.Now())
+		if err != nil {
+			app.logger.Errorf("failed to get failover window: %v", err)
+			return 1
+		}
+		if window != nil {
+			data["failover_window"] = fmt.Sprintf("%s: %s", window.Name, window.Mode)
+		}
+
 		clusterState, err := app.getClusterStateFromDcs()
 		if err != nil {
 			app.logger.Errorf("failed to get cluster state: %v", err)
 			return 1
 		}
 		health := make(map[string]interface{})
+		agentStates := make(map[string]interface{})
+		loads := make(map[string]interface{})
+		clockOffsets := make(map[string]interface{})
+		backlogs := make(map[string]interface{})
+		probes := make(map[string]interface{})
 		for host, state := range clusterState {
 			health[host] = state.String()
+			if state.AgentState != nil {
+				agentStates[host] = state.AgentState.String()
+			}
+			if state.Load != nil {
+				loads[host] = state.Load.String()
+			}
+			if state.ClockOffset != nil {
+				clockOffsets[host] = state.ClockOffset.String()
+			}
+			if state.SlaveState != nil {
+				backlogs[host] = state.SlaveState.BacklogString()
+			}
+			if state.ReadOnlyProbe != nil {
+				probes[host] = state.ReadOnlyProbe.String()
+			}
 		}
 		data[pathHealthPrefix] = health
+		if len(agentStates) > 0 {
+			data["agent_state"] = agentStates
+		}
+		if len(loads) > 0 {
+			data["load"] = loads
+		}
+		if len(clockOffsets) > 0 {
+			data["clock_offset"] = clockOffsets
+		}
+		if len(backlogs) > 0 {
+			data["apply_backlog"] = backlogs
+		}
+		if len(probes) > 0 {
+			data["read_only_probe"] = probes
+		}
+		if skew := versionSkew(clusterState); skew != nil {
+			data["version_skew"] = skew
+		}
 
 		for _, path := range []string{pathLastSwitch, pathCurrentSwitch, pathLastRejectedSwitch} {
 			var switchover Switchover
@@ -95,6 +237,20 @@ func (app *App) CliInfo(short bool) int {
 			}
 		}
 
+		resetupRequests := make(map[string]interface{})
+		for _, host := range app.getKnownHosts() {
+			request, err := app.getResetupRequest(host)
+			if err == nil {
+				resetupRequests[host] = request.String()
+			} else if err != dcs.ErrNotFound {
+				app.logger.Errorf("failed to get resetup request of %s: %v", host, err)
+				return 1
+			}
+		}
+		if len(resetupRequests) > 0 {
+			data[pathResetupRequests] = resetupRequests
+		}
+
 		var maintenance Maintenance
 		err = app.dcs.Get(pathMaintenance, &maintenance)
 		if err == nil {
@@ -127,17 +283,11 @@ func (app *App) CliInfo(short bool) int {
 			return 1
 		}
 	}
-	data, err := yaml.Marshal(tree)
-	if err != nil {
-		app.logger.Errorf("failed to marshal yaml: %v", err)
-		return 1
-	}
-	fmt.Print(string(data))
-	return 0
+	return app.printCliOutput(tree, format)
 }
 
 // CliState print state of the cluster to the stdout
-func (app *App) CliState(short bool) int {
+func (app *App) CliState(short bool, format string) int {
 	err := app.connectDCS()
 	if err != nil {
 		app.logger.Error(err.Error())
@@ -168,27 +318,13 @@ func (app *App) CliState(short bool) int {
 	} else {
 		tree = clusterState
 	}
-	data, err := yaml.Marshal(tree)
-	if err != nil {
-		app.logger.Errorf("failed to marshal yaml: %v", err)
-		return 1
-	}
-	fmt.Print(string(data))
-	return 0
+	return app.printCliOutput(tree, format)
 }
 
 // CliSwitch performs manual switch-over of the master node
 // nolint: gocyclo, funlen
-func (app *App) CliSwitch(switchFrom, switchTo string, waitTimeout time.Duration) int {
+func (app *App) CliSwitch(switchFrom, switchTo string, waitTimeout time.Duration, force, dryRun bool, format string) int {
 	ctx := app.baseContext()
-	if switchFrom == "" && switchTo == "" {
-		app.logger.Errorf("Either --from or --to should be set")
-		return 1
-	}
-	if switchFrom != "" && switchTo != "" {
-		app.logger.Errorf("Option --from and --to can't be used in the same time")
-		return 1
-	}
 	err := app.connectDCS()
 	if err != nil {
 		app.logger.Error(err.Error())
@@ -208,85 +344,18 @@ func (app *App) CliSwitch(switchFrom, switchTo string, waitTimeout time.Duration
 		return 1
 	}
 
-	if len(app.cluster.HANodeHosts()) == 1 {
-		app.logger.Info("switchover has not sense on single HA-node cluster")
+	request, err := app.validateSwitchover(switchFrom, switchTo)
+	if errors.Is(err, errSwitchoverNotNeeded) {
+		app.logger.Info(err.Error())
 		fmt.Println("switchover done")
 		return 0
 	}
-
-	var fromHost, toHost string
-
-	var currentMaster string
-	if err := app.dcs.Get(pathMasterNode, &currentMaster); err != nil {
-		app.logger.Errorf("failed to get current master: %v", err)
-		return 1
-	}
-	activeNodes, err := app.GetActiveNodes()
 	if err != nil {
 		app.logger.Error(err.Error())
 		return 1
 	}
-
-	if switchTo != "" {
-		// switch to particular host
-		desired := util.SelectNodes(app.cluster.HANodeHosts(), switchTo)
-		if len(desired) == 0 {
-			app.logger.Errorf("no HA-nodes matching '%s'", switchTo)
-			return 1
-		}
-		if len(desired) > 1 {
-			app.logger.Errorf("two or more nodes matching '%s'", switchTo)
-			return 1
-		}
-		toHost = desired[0]
-		if toHost == currentMaster {
-			app.logger.Infof("master is already on %s, skipping...", toHost)
-			fmt.Println("switchover done")
-			return 0
-		}
-		if !util.ContainsString(activeNodes, toHost) {
-			app.logger.Errorf("%s is not active, can't switch to it", toHost)
-			return 1
-		}
-	} else {
-		// switch away from specified host(s)
-		notDesired := util.SelectNodes(app.cluster.HANodeHosts(), switchFrom)
-		if len(notDesired) == 0 {
-			app.logger.Errorf("no HA-nodes matches '%s', check --from param", switchFrom)
-			return 1
-		}
-		if !util.ContainsString(notDesired, currentMaster) {
-			app.logger.Infof("master is already not on %v, skipping...", notDesired)
-			fmt.Println("switchover done")
-			return 0
-		}
-		var candidates []string
-		for _, node := range activeNodes {
-			if !util.ContainsString(notDesired, node) {
-				candidates = append(candidates, node)
-			}
-		}
-		if len(candidates) == 0 {
-			app.logger.Errorf("there are no active nodes, not matching '%s'", switchFrom)
-			return 1
-		}
-		if len(notDesired) == 1 {
-			fromHost = notDesired[0]
-		} else {
-			// there are multiple hosts matching --from pattern
-			// to avoid switching from one to another, use switch to behavior
-			positions, err := app.getNodePositions(candidates)
-			if err != nil {
-				app.logger.Error(err.Error())
-				return 1
-			}
-			toHost, err = getMostDesirableNode(app.logger, positions, app.switchHelper.GetPriorityChoiceMaxLag())
-			if err != nil {
-				app.logger.Error(err.Error())
-				return 1
-			}
-		}
-	}
+	fromHost, toHost := request.From, request.To
+	currentMaster, activeNodes := request.Master, request.ActiveNodes
 
 	var switchover Switchover
 	err = app.dcs.Get(pathCurrentSwitch, &switchover)
@@ -299,11 +368,24 @@ func (app *App) CliSwitch(switchFrom, switchTo string, waitTimeout time.Duration
 		return 2
 	}
 
+	if dryRun {
+		plan := app.planSwitchover(fromHost, toHost, currentMaster, activeNodes, force)
+		if ret := app.printCliOutput(plan, format); ret != 0 {
+			return ret
+		}
+		if len(plan.Problems) > 0 {
+			return 1
+		}
+		return 0
+	}
+
 	switchover.From = fromHost
 	switchover.To = toHost
-	switchover.InitiatedBy = app.config.Hostname
+	switchover.InitiatedBy = app.cfg().Hostname
 	switchover.InitiatedAt = time.Now()
 	switchover.Cause = CauseManual
+	switchover.Force = force
+	switchover.Operator = app.currentOperator()
 
 	err = app.dcs.Create(pathCurrentSwitch, switchover)
 	if err == dcs.ErrExists {
@@ -349,7 +431,7 @@ func (app *App) CliSwitch(switchFrom, switchTo string, waitTimeout time.Duration
 }
 
 // CliEnableMaintenance enables maintenance mode
-func (app *App) CliEnableMaintenance(waitTimeout time.Duration) int {
+func (app *App) CliEnableMaintenance(waitTimeout, duration time.Duration) int {
 	ctx := app.baseContext()
 	err := app.connectDCS()
 	if err != nil {
@@ -359,44 +441,77 @@ func (app *App) CliEnableMaintenance(waitTimeout time.Duration) int {
 	defer app.dcs.Close()
 	app.dcs.Initialize()
 
+	result, err := app.enableMaintenance(ctx, waitTimeout, duration)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	fmt.Println(result)
+	return 0
+}
+
+// enableMaintenance creates maintenance node and waits for mysync to pause, returning resulting status
+func (app *App) enableMaintenance(ctx context.Context, waitTimeout, duration time.Duration) (string, error) {
 	maintenance := &Maintenance{
-		InitiatedBy: app.config.Hostname,
+		InitiatedBy: app.cfg().Hostname,
 		InitiatedAt: time.Now(),
+		Operator:    app.currentOperator(),
+	}
+	if duration > 0 {
+		maintenance.expireAfter(duration)
+	}
+	err := app.dcs.Create(pathMaintenance, maintenance)
+	if err == dcs.ErrExists && duration > 0 {
+		err = app.extendMaintenance(duration)
 	}
-	err = app.dcs.Create(pathMaintenance, maintenance)
 	if err != nil && err != dcs.ErrExists {
-		app.logger.Error(err.Error())
-		return 1
+		return "", err
+	}
+	if waitTimeout <= 0 {
+		return "maintenance scheduled", nil
 	}
 	// wait for mysync to pause
-	if waitTimeout > 0 {
-		waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
-		defer cancel()
-		ticker := time.NewTicker(time.Second)
-	Out:
-		for {
-			select {
-			case <-ticker.C:
-				err = app.dcs.Get(pathMaintenance, maintenance)
-				if err != nil {
-					app.logger.Error(err.Error())
-				}
-				if maintenance.MySyncPaused {
-					break Out
-				}
-			case <-waitCtx.Done():
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+Out:
+	for {
+		select {
+		case <-ticker.C:
+			err = app.dcs.Get(pathMaintenance, maintenance)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+			if maintenance.MySyncPaused {
 				break Out
 			}
+		case <-waitCtx.Done():
+			break Out
 		}
-		if !maintenance.MySyncPaused {
-			app.logger.Error("could not wait for mysync to enter maintenance")
-			return 1
-		}
-		fmt.Println("maintenance enabled")
-	} else {
-		fmt.Println("maintenance scheduled")
 	}
-	return 0
+	if !maintenance.MySyncPaused {
+		return "", fmt.Errorf("could not wait for mysync to enter maintenance")
+	}
+	return "maintenance enabled", nil
+}
+
+// extendMaintenance sets new expiry of already enabled maintenance
+func (app *App) extendMaintenance(duration time.Duration) error {
+	maintenance, err := app.GetMaintenance()
+	if err != nil {
+		return fmt.Errorf("failed to get maintenance: %v", err)
+	}
+	if maintenance.ShouldLeave {
+		return fmt.Errorf("maintenance is being disabled, can't change its duration")
+	}
+	maintenance.expireAfter(duration)
+	err = app.dcs.Set(pathMaintenance, maintenance)
+	if err != nil {
+		return err
+	}
+	app.logger.Infof("maintenance is already enabled, it expires at %s now", maintenance.ExpiresAt.Format(time.RFC3339))
+	return nil
 }
 
 // CliDisableMaintenance disables maintenance mode
@@ -410,50 +525,57 @@ func (app *App) CliDisableMaintenance(waitTimeout time.Duration) int {
 	defer app.dcs.Close()
 	app.dcs.Initialize()
 
+	result, err := app.disableMaintenance(ctx, waitTimeout)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	fmt.Println(result)
+	return 0
+}
+
+// disableMaintenance asks mysync to leave maintenance and waits for it, returning resulting status
+func (app *App) disableMaintenance(ctx context.Context, waitTimeout time.Duration) (string, error) {
 	maintenance := &Maintenance{}
-	err = app.dcs.Get(pathMaintenance, maintenance)
+	err := app.dcs.Get(pathMaintenance, maintenance)
 	if err == dcs.ErrNotFound {
-		fmt.Println("maintenance disabled")
-		return 0
+		return "maintenance disabled", nil
 	} else if err != nil {
-		app.logger.Error(err.Error())
-		return 1
+		return "", err
 	}
 	maintenance.ShouldLeave = true
+	maintenance.DisabledBy = app.currentOperator()
 	err = app.dcs.Set(pathMaintenance, maintenance)
 	if err != nil {
-		app.logger.Error(err.Error())
-		return 1
-	}
-	if waitTimeout > 0 {
-		waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
-		defer cancel()
-		ticker := time.NewTicker(time.Second)
-	Out:
-		for {
-			select {
-			case <-ticker.C:
-				err = app.dcs.Get(pathMaintenance, maintenance)
-				if err == dcs.ErrNotFound {
-					maintenance = nil
-					break Out
-				}
-				if err != nil {
-					app.logger.Error(err.Error())
-				}
-			case <-waitCtx.Done():
+		return "", err
+	}
+	if waitTimeout <= 0 {
+		return "maintenance disable scheduled", nil
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+Out:
+	for {
+		select {
+		case <-ticker.C:
+			err = app.dcs.Get(pathMaintenance, maintenance)
+			if err == dcs.ErrNotFound {
+				maintenance = nil
 				break Out
 			}
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+		case <-waitCtx.Done():
+			break Out
 		}
-		if maintenance != nil {
-			app.logger.Error("could not wait for mysync to leave maintenance")
-			return 1
-		}
-		fmt.Println("maintenance disabled")
-	} else {
-		fmt.Println("maintenance disable scheduled")
 	}
-	return 0
+	if maintenance != nil {
+		return "", fmt.Errorf("could not wait for mysync to leave maintenance")
+	}
+	return "maintenance disabled", nil
 }
 
 // CliGetMaintenance prints on/off depending on current maintenance status
@@ -489,7 +611,8 @@ func (app *App) CliAbort() int {
 	defer app.dcs.Close()
 	app.dcs.Initialize()
 
-	err = app.dcs.Get(pathCurrentSwitch, new(Switchover))
+	switchover := new(Switchover)
+	err = app.dcs.Get(pathCurrentSwitch, switchover)
 	if err == dcs.ErrNotFound {
 		fmt.Println("no active switchover")
 		return 0
@@ -518,12 +641,27 @@ func (app *App) CliAbort() int {
 		return 1
 	}
 
+	// running switchover is finished by manager, which restores master itself
+	if !switchover.StartedAt.IsZero() && switchover.Result != nil {
+		err = app.newDBCluster()
+		if err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+		defer app.cluster.Close()
+		if err = app.cluster.UpdateHostsInfo(); err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+		app.restoreMasterAfterAbort()
+	}
+
 	fmt.Printf("switchover aborted\n")
 	return 0
 }
 
 // CliHostList prints list of managed HA/cascade hosts
-func (app *App) CliHostList() int {
+func (app *App) CliHostList(format string) int {
 	err := app.connectDCS()
 	if err != nil {
 		app.logger.Error(err.Error())
@@ -556,23 +694,39 @@ func (app *App) CliHostList() int {
 	}
 	data[pathCascadeNodesPrefix] = cascadeNodes
 
-	out, err := yaml.Marshal(data)
+	relays, err := app.getRelayHosts()
 	if err != nil {
-		app.logger.Errorf("failed to marshal yaml: %v", err)
+		app.logger.Errorf("failed to get relay hosts: %v", err)
 		return 1
 	}
-	fmt.Print(string(out))
-	return 0
+	if len(relays) > 0 {
+		data["relays"] = relays
+	}
+
+	async, err := app.getAsyncReplicas()
+	if err != nil {
+		app.logger.Errorf("failed to get async replicas: %v", err)
+		return 1
+	}
+	if len(async) > 0 {
+		data["async_replicas"] = async
+	}
+
+	return app.printCliOutput(data, format)
 }
 
 // CliHostAdd add hosts to the list of managed HA/cascade hosts
-func (app *App) CliHostAdd(host string, streamFrom *string, priority *int64, dryRun bool, skipMySQLCheck bool) int {
+func (app *App) CliHostAdd(host string, streamFrom *string, priority *int64, semiSync *bool, relay bool, dryRun bool, skipMySQLCheck bool) int {
 	err := validatePriority(priority)
 	if err != nil {
 		fmt.Println(err.Error())
 		app.logger.Error(err.Error())
 		return 1
 	}
+	if relay && (streamFrom == nil || *streamFrom == "") {
+		app.logger.Error("relay host should stream from another host, set --stream-from")
+		return 1
+	}
 
 	err = app.connectDCS()
 	if err != nil {
@@ -618,7 +772,7 @@ func (app *App) CliHostAdd(host string, streamFrom *string, priority *int64, dry
 	changes := false
 
 	if streamFrom != nil {
-		changesNew, err := app.processReplicationSource(*streamFrom, dryRun, host, skipMySQLCheck)
+		changesNew, err := app.processReplicationSource(*streamFrom, relay, dryRun, host, skipMySQLCheck)
 		if err != nil {
 			return 1
 		}
@@ -643,6 +797,15 @@ func (app *App) CliHostAdd(host string, streamFrom *string, priority *int64, dry
 		changes = changes || changesNew
 	}
 
+	if semiSync != nil {
+		changesNew, err := app.processSemiSync(*semiSync, dryRun, host)
+		if err != nil {
+			return 1
+		}
+
+		changes = changes || changesNew
+	}
+
 	if dryRun {
 		if !changes {
 			fmt.Println("dry run finished: no changes detected")
@@ -693,6 +856,10 @@ func (app *App) CliHostRemove(host string) int {
 	if err != nil && err != dcs.ErrNotFound {
 		return 1
 	}
+	err = app.dcs.Delete(dcs.JoinPath(pathResetupRequests, host))
+	if err != nil && err != dcs.ErrNotFound {
+		return 1
+	}
 	fmt.Println("host has been removed")
 	return 0
 }
@@ -704,7 +871,7 @@ func pingMysql(skipMySQLCheck bool, cluster *mysql.Cluster, host string) (bool,
 	return cluster.PingNode(host)
 }
 
-func (app *App) processReplicationSource(streamFrom string, dryRun bool, host string,
+func (app *App) processReplicationSource(streamFrom string, relay bool, dryRun bool, host string,
 	skipMySQLCheck bool) (changes bool, err error) {
 	if streamFrom == "" {
 		if dryRun {
@@ -755,7 +922,7 @@ func (app *App) processReplicationSource(streamFrom string, dryRun bool, host st
 			if err != nil && err != dcs.ErrNotFound {
 				return false, err
 			}
-			if cns.StreamFrom == streamFrom {
+			if cns.StreamFrom == streamFrom && cns.Relay == relay {
 				fmt.Printf("dry run: node is already streaming from %s\n", streamFrom)
 				return false, nil
 			}
@@ -767,7 +934,7 @@ func (app *App) processReplicationSource(streamFrom string, dryRun bool, host st
 		if err != nil && err != dcs.ErrNotFound {
 			return false, err
 		}
-		err = app.dcs.Set(dcs.JoinPath(pathCascadeNodesPrefix, host), mysql.CascadeNodeConfiguration{StreamFrom: streamFrom})
+		err = app.dcs.Set(dcs.JoinPath(pathCascadeNodesPrefix, host), mysql.CascadeNodeConfiguration{StreamFrom: streamFrom, Relay: relay})
 		if err != nil && err != dcs.ErrExists {
 			return false, err
 		}
@@ -800,10 +967,52 @@ func (app *App) processPriority(priority int64, dryRun bool, host string) (chang
 		return false, nil
 	}
 
-	err = app.dcs.Set(dcs.JoinPath(pathHANodes, host), mysql.NodeConfiguration{Priority: priority})
+	// keep other settings of host
+	var nc mysql.NodeConfiguration
+	err = app.dcs.Get(dcs.JoinPath(pathHANodes, host), &nc)
+	if err != nil && err != dcs.ErrNotFound && err != dcs.ErrMalformed {
+		return false, err
+	}
+	nc.Priority = priority
+	err = app.dcs.Set(dcs.JoinPath(pathHANodes, host), nc)
 	if err != nil && err != dcs.ErrExists {
 		return false, err
 	}
 
 	return true, nil
 }
+
+// CompleteHosts returns HA and cascade hosts from DCS starting with prefix, used for shell completion
+func (app *App) CompleteHosts(prefix string) []string {
+	err := app.connectDCS()
+	if err != nil {
+		return nil
+	}
+	defer app.dcs.Close()
+	var hosts []string
+	for _, host := range app.getKnownHosts() {
+		if strings.HasPrefix(host, prefix) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// getKnownHosts returns sorted HA and cascade hosts registered in dcs
+func (app *App) getKnownHosts() []string {
+	var hosts []string
+	for _, path := range []string{pathHANodes, pathCascadeNodesPrefix} {
+		children, err := app.dcs.GetChildren(path)
+		if err != nil {
+			continue
+		}
+		hosts = append(hosts, children...)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// CliConfigShow prints effective config, including defaults, with secrets hidden
+func (app *App) CliConfigShow(format string) int {
+	return app.printCliOutput(app.cfg().Redacted(), format)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// measureClockOffset returns difference between local clock and clock of MySQL host,
+// positive if local clock is ahead
+func measureClockOffset(node *mysql.Node) (time.Duration, error) {
+	before := time.Now()
+	remote, err := node.GetCurrentTime()
+	if err != nil {
+		return 0, err
+	}
+	// compare with the middle of request to compensate network latency
+	local := before.Add(time.Since(before) / 2)
+	return local.Sub(remote), nil
+}
+
+// getClockOffset returns offset of local clock from clock of master host, which is reference for the cluster,
+// or nil if clock skew is not checked or master is unknown
+func (app *App) getClockOffset() *time.Duration {
+	if app.cfg().ClockSkewThreshold == 0 {
+		return nil
+	}
+	var master string
+	err := app.dcs.Get(pathMasterNode, &master)
+	if err != nil {
+		if err != dcs.ErrNotFound {
+			app.logger.Errorf("clock: failed to get current master: %v", err)
+		}
+		return nil
+	}
+	var offset time.Duration
+	if master != app.cfg().Hostname {
+		node := app.cluster.Get(master)
+		if node == nil {
+			return nil
+		}
+		offset, err = measureClockOffset(node)
+		if err != nil {
+			app.logger.Warnf("clock: failed to get time of master %s: %v", master, err)
+			return nil
+		}
+	}
+	return &offset
+}
+
+// clockSkew returns difference between the most ahead and the most behind clocks of hosts,
+// hosts of unknown clock offset are omitted
+func clockSkew(hosts []string, clusterStateDcs map[string]*NodeState) (skew time.Duration, ahead, behind string) {
+	sorted := append([]string(nil), hosts...)
+	sort.Strings(sorted)
+	var minOffset, maxOffset *time.Duration
+	for _, host := range sorted {
+		state := clusterStateDcs[host]
+		if state == nil || state.ClockOffset == nil {
+			continue
+		}
+		if maxOffset == nil || *state.ClockOffset > *maxOffset {
+			maxOffset, ahead = state.ClockOffset, host
+		}
+		if minOffset == nil || *state.ClockOffset < *minOffset {
+			minOffset, behind = state.ClockOffset, host
+		}
+	}
+	if maxOffset == nil {
+		return 0, "", ""
+	}
+	return *maxOffset - *minOffset, ahead, behind
+}
+
+// checkClockSkew returns error if clocks of hosts differ more than clock_skew_threshold
+func (app *App) checkClockSkew(hosts []string, clusterStateDcs map[string]*NodeState) error {
+	threshold := app.cfg().ClockSkewThreshold
+	if threshold == 0 {
+		return nil
+	}
+	skew, ahead, behind := clockSkew(hosts, clusterStateDcs)
+	if skew > threshold {
+		return fmt.Errorf("clock of %s is ahead of %s by %v, more than clock_skew_threshold %v", ahead, behind, skew, threshold)
+	}
+	return nil
+}
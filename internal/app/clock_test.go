@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	offset := func(d time.Duration) *time.Duration { return &d }
+	states := map[string]*NodeState{
+		"mysql1": {ClockOffset: offset(0)},
+		"mysql2": {ClockOffset: offset(1500 * time.Millisecond)},
+		"mysql3": {ClockOffset: offset(-time.Second)},
+		"mysql4": {},
+	}
+	skew, ahead, behind := clockSkew([]string{"mysql1", "mysql2", "mysql3", "mysql4"}, states)
+	require.Equal(t, 2500*time.Millisecond, skew)
+	require.Equal(t, "mysql2", ahead)
+	require.Equal(t, "mysql3", behind)
+
+	skew, _, _ = clockSkew([]string{"mysql4"}, states)
+	require.Equal(t, time.Duration(0), skew)
+
+	app := newTestApp(t, "mysql1")
+	require.NoError(t, app.checkClockSkew([]string{"mysql1", "mysql2", "mysql3"}, states))
+	app.cfg().ClockSkewThreshold = 2 * time.Second
+	require.Error(t, app.checkClockSkew([]string{"mysql1", "mysql2", "mysql3"}, states))
+	require.NoError(t, app.checkClockSkew([]string{"mysql1", "mysql2"}, states))
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// ExpandClusterConfigs expands glob patterns to sorted list of unique config files
+func ExpandClusterConfigs(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad config pattern %s: %s", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no config files match %s", pattern)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// checkClusterConflicts verifies that clusters managed by one process
+// do not share dcs namespace, local files and listen addresses
+func checkClusterConflicts(configs map[string]*config.Config) error {
+	owners := make(map[string]string)
+	files := make([]string, 0, len(configs))
+	for file := range configs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var problems []string
+	for _, file := range files {
+		cfg := configs[file]
+		zkHosts := append([]string(nil), cfg.Zookeeper.Hosts...)
+		sort.Strings(zkHosts)
+		resources := map[string]string{
+			"zookeeper namespace":   strings.Join(zkHosts, ",") + ":" + cfg.Zookeeper.Namespace,
+			"log":                   cfg.Log,
+			"lockfile":              cfg.Lockfile,
+			"info_file":             cfg.InfoFile,
+			"emergefile":            cfg.Emergefile,
+			"resetupfile":           cfg.Resetupfile,
+			"maintenancefile":       cfg.Maintenancefile,
+			"resetup_progress_file": cfg.ResetupProgressFile,
+			"api_listen":            cfg.APIListen,
+			"grpc_listen":           cfg.GRPCListen,
+			"health_listen":         cfg.HealthListen,
+			"debug_listen":          cfg.DebugListen,
+		}
+		for name, value := range resources {
+			if value == "" {
+				continue
+			}
+			key := name + "\x00" + value
+			if owner, ok := owners[key]; ok {
+				problems = append(problems, fmt.Sprintf("%s of %s is the same as of %s", name, file, owner))
+				continue
+			}
+			owners[key] = file
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("clusters conflict: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// RunClusters runs mysync agent for each config file in one process.
+// Clusters are independent: failure of one of them does not stop others.
+// Returns the worst exit code.
+func RunClusters(configFiles []string, logLevel string) int {
+	apps := make(map[string]*App, len(configFiles))
+	configs := make(map[string]*config.Config, len(configFiles))
+	for _, file := range configFiles {
+		app, err := NewApp(file, logLevel, false)
+		if err != nil {
+			fmt.Printf("%s: %s\n", file, err)
+			closeApps(apps)
+			return 1
+		}
+		apps[file] = app
+		configs[file] = app.cfg()
+	}
+	if err := checkClusterConflicts(configs); err != nil {
+		fmt.Println(err)
+		closeApps(apps)
+		return 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	code := 0
+	for file, app := range apps {
+		wg.Add(1)
+		go func(file string, app *App) {
+			defer wg.Done()
+			rc := app.Run()
+			if rc != 0 {
+				fmt.Printf("%s: mysync exited with code %d\n", file, rc)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if rc > code {
+				code = rc
+			}
+		}(file, app)
+	}
+	wg.Wait()
+	for _, app := range apps {
+		if app.RestartRequested() {
+			return app.Reexec()
+		}
+	}
+	return code
+}
+
+func closeApps(apps map[string]*App) {
+	for _, app := range apps {
+		app.Close()
+	}
+}
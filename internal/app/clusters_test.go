@@ -0,0 +1,56 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestExpandClusterConfigs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yaml", "c.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	files, err := ExpandClusterConfigs([]string{filepath.Join(dir, "*.yaml"), filepath.Join(dir, "a.yaml")})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")}, files)
+
+	_, err = ExpandClusterConfigs([]string{filepath.Join(dir, "*.json")})
+	require.Error(t, err)
+}
+
+func TestCheckClusterConflicts(t *testing.T) {
+	newConfig := func(name string) *config.Config {
+		cfg, err := config.DefaultConfig()
+		require.NoError(t, err)
+		cfg.Zookeeper.Hosts = []string{"zk1:2181", "zk2:2181"}
+		cfg.Zookeeper.Namespace = "/mysync/" + name
+		cfg.Log = "/var/log/mysync/" + name + ".log"
+		cfg.Lockfile = "/var/run/mysync/" + name + ".lock"
+		cfg.InfoFile = "/var/run/mysync/" + name + ".info"
+		cfg.Emergefile = "/var/run/mysync/" + name + ".emerge"
+		cfg.Resetupfile = "/var/run/mysync/" + name + ".resetup"
+		cfg.Maintenancefile = "/var/run/mysync/" + name + ".maintenance"
+		cfg.ResetupProgressFile = "/var/run/mysync/" + name + ".resetup.progress"
+		return &cfg
+	}
+	first, second := newConfig("first"), newConfig("second")
+	configs := map[string]*config.Config{"first.yaml": first, "second.yaml": second}
+	require.NoError(t, checkClusterConflicts(configs))
+
+	second.Zookeeper.Namespace = first.Zookeeper.Namespace
+	second.Zookeeper.Hosts = []string{"zk2:2181", "zk1:2181"}
+	second.APIListen = ":9443"
+	first.APIListen = ":9443"
+	second.Log = first.Log
+	err := checkClusterConflicts(configs)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "zookeeper namespace of second.yaml is the same as of first.yaml")
+	require.Contains(t, err.Error(), "api_listen of second.yaml is the same as of first.yaml")
+	require.Contains(t, err.Error(), "log of second.yaml is the same as of first.yaml")
+	require.NotContains(t, err.Error(), "lockfile")
+}
@@ -67,10 +67,86 @@ const (
 	// structure: pathResetupStatus/hostname -> ResetupStatus
 	pathResetupStatus = "resetup_status"
 
+	// per-host config overrides, applied by mysync on start and reload
+	// structure: pathHostOverrides/hostname -> map of config keys
+	pathHostOverrides = "host_overrides"
+
+	// resetup requested by operator
+	// structure: pathResetupRequests/hostname -> ResetupRequest
+	pathResetupRequests = "resetup_requests"
+
+	// slots limiting number of concurrent resetups, held by hosts on resetup
+	// structure: pathResetupSlots/N -> hostname (ephemeral)
+	pathResetupSlots = "resetup_slots"
+
+	// slots limiting number of concurrent resetups from one donor, held by recipients
+	// structure: pathDonorSlots/donor/N -> hostname (ephemeral)
+	pathDonorSlots = "donor_slots"
+
 	pathLastShutdownNodeTime = "last_shutdown_node_time"
 
+	// manager lock handoff requested by operator
+	// structure: single ManagerHandoff
+	pathManagerHandoff = "manager_handoff"
+
+	// rolling upgrade of MySQL declared by operator
+	// structure: single RollingUpgrade
+	pathRollingUpgrade = "rolling_upgrade"
+
+	// hosts excluded from automated actions by operator
+	// structure: pathQuarantine/hostname -> HostQuarantine
+	pathQuarantine = "quarantine"
+
+	// replicas taken out of read traffic by operator before maintenance
+	// structure: pathDrain/hostname -> HostDrain
+	pathDrain = "drain"
+
+	// replicas taken out of rotation because of their load
+	// structure: pathLoadOffline/hostname -> LoadOffline
+	pathLoadOffline = "load_offline"
+
 	// last known timestamp from repl_mon table
 	pathMasterReplMonTS = "master_repl_mon_ts"
+
+	// topology epoch, incremented on every promotion
+	// structure: single TopologyEpoch
+	pathEpoch = "epoch"
+
+	// liveness observations made by every mysync agent
+	// structure: pathLiveness/hostname -> LivenessObservation
+	pathLiveness = "liveness"
+
+	// running backups, maintained by backup tooling
+	// structure: pathBackups/hostname -> any
+	pathBackups = "backups"
+
+	// running online schema changes, maintained by migration tooling
+	// structure: pathOnlineDDL/migration -> any
+	pathOnlineDDL = "online_ddl"
+
+	// nodes written by `mysync check` to validate access to dcs, removed right after
+	// structure: pathDCSCheck/hostname -> time
+	pathDCSCheck = "dcs_check"
+
+	// bounded history of mysync actions, node per event, so concurrent writers do not lose events
+	// structure: pathEventHistory/time-hostname -> HistoryEvent, names sort in order of events
+	pathEventHistory = "event_history"
+
+	// lock of virtual IP holder, released only after VIP is removed from the host
+	// structure: dcs.LockOwner
+	pathVIPLock = "vip"
+
+	// lag of replicas against throttle_lag, published for bulk writers
+	// structure: ThrottleState
+	pathThrottle = "throttle"
+
+	// actions applied on master while replication is degraded, reverted after recovery
+	// structure: LoadShedding
+	pathLoadShedding = "load_shedding"
+
+	// cluster-wide GTID positions for point-in-time recovery
+	// structure: pathRecoveryMarks/id -> RecoveryMark
+	pathRecoveryMarks = "recovery_marks"
 )
 
 var (
@@ -81,23 +157,34 @@ var (
 
 // NodeState contains status check performed by some mysync process
 type NodeState struct {
-	CheckBy              string         `json:"check_by"`
-	CheckAt              time.Time      `json:"check_at"`
-	PingOk               bool           `json:"ping_ok"`
-	PingDubious          bool           `json:"ping_dubious"`
-	IsMaster             bool           `json:"is_master"`
-	IsReadOnly           bool           `json:"is_readonly"`
-	IsSuperReadOnly      bool           `json:"is_super_readonly"`
-	IsOffline            bool           `json:"is_offline"`
-	IsCascade            bool           `json:"is_cascade"`
-	IsFileSystemReadonly bool           `json:"is_file_system_readonly"`
-	IsLoadingBinlog      bool           `json:"is_loading_binlog"`
-	Error                string         `json:"error"`
-	DiskState            *DiskState     `json:"disk_state"`
-	DaemonState          *DaemonState   `json:"daemon_state"`
-	MasterState          *MasterState   `json:"master_state"`
-	SlaveState           *SlaveState    `json:"slave_state"`
-	SemiSyncState        *SemiSyncState `json:"semi_sync_state"`
+	CheckBy              string            `json:"check_by"`
+	CheckAt              time.Time         `json:"check_at"`
+	PingOk               bool              `json:"ping_ok"`
+	PingDubious          bool              `json:"ping_dubious"`
+	IsMaster             bool              `json:"is_master"`
+	IsReadOnly           bool              `json:"is_readonly"`
+	IsSuperReadOnly      bool              `json:"is_super_readonly"`
+	IsOffline            bool              `json:"is_offline"`
+	IsCascade            bool              `json:"is_cascade"`
+	IsFileSystemReadonly bool              `json:"is_file_system_readonly"`
+	IsLoadingBinlog      bool              `json:"is_loading_binlog"`
+	Zone                 string            `json:"zone,omitempty"`
+	FailedHealthChecks   map[string]string `json:"failed_health_checks,omitempty"`
+	StorageDegradation   string            `json:"storage_degradation,omitempty"`
+	IsBackupRunning      bool              `json:"is_backup_running,omitempty"`
+	Error                string            `json:"error"`
+	DiskState            *DiskState        `json:"disk_state"`
+	DaemonState          *DaemonState      `json:"daemon_state"`
+	AgentState           *AgentState       `json:"agent_state,omitempty"`
+	MasterState          *MasterState      `json:"master_state"`
+	SlaveState           *SlaveState       `json:"slave_state"`
+	SemiSyncState        *SemiSyncState    `json:"semi_sync_state"`
+	Durability           *DurabilityState  `json:"durability,omitempty"`
+	Load                 *LoadState        `json:"load,omitempty"`
+	ClockOffset          *time.Duration    `json:"clock_offset,omitempty"`
+	ExternalSlaveState   *SlaveState       `json:"external_slave_state,omitempty"`
+	MySQLVersion         *mysql.Version    `json:"mysql_version,omitempty"`
+	ReadOnlyProbe        *ReadOnlyProbe    `json:"read_only_probe,omitempty"`
 
 	ShowOnlyGTIDDiff bool
 }
@@ -159,6 +246,26 @@ func (ns *NodeState) UpdateBinlogStatus(oldBinloPos string) (newBinlogPos string
 	return
 }
 
+// diskGrowthSmoothing is a weight of the latest measurement in disk growth rate
+const diskGrowthSmoothing = 0.2
+
+// UpdateDiskGrowthRate estimates disk growth rate using previous health check
+func (ns *NodeState) UpdateDiskGrowthRate(old *NodeState) (newState *NodeState) {
+	if ns.DiskState == nil {
+		return old
+	}
+	if old == nil || old.DiskState == nil {
+		return ns
+	}
+	elapsed := ns.CheckAt.Sub(old.CheckAt).Seconds()
+	if elapsed <= 0 {
+		return old
+	}
+	rate := (float64(ns.DiskState.Used) - float64(old.DiskState.Used)) / elapsed
+	ns.DiskState.GrowthRate = diskGrowthSmoothing*rate + (1-diskGrowthSmoothing)*old.DiskState.GrowthRate
+	return ns
+}
+
 func (ns *NodeState) String() string {
 	ping := "ok"
 	if !ns.PingOk {
@@ -230,10 +337,54 @@ func (ns *NodeState) String() string {
 		ping, repl, sync, ns.IsReadOnly, ns.IsOffline, lag, du, cr, gtid)
 }
 
+// LivenessObservation contains results of probing cluster hosts by some mysync process
+type LivenessObservation struct {
+	CheckAt time.Time       `json:"check_at"`
+	Alive   map[string]bool `json:"alive"`
+	// SeenAt is when local agent saw observation change, it is measured by local clock,
+	// so freshness of observations does not depend on clock skew between hosts
+	SeenAt time.Time `json:"-"`
+}
+
+// HistoryEvent describes significant action performed by mysync
+type HistoryEvent struct {
+	Time       time.Time     `json:"time" yaml:"time"`
+	Type       string        `json:"type" yaml:"type"`
+	Host       string        `json:"host,omitempty" yaml:"host,omitempty"`
+	Cause      string        `json:"cause,omitempty" yaml:"cause,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Message    string        `json:"message" yaml:"message"`
+	RecordedBy string        `json:"recorded_by" yaml:"recorded_by"`
+
+	// failover SLI, set for finished switchovers
+	WriteDowntime    time.Duration `json:"write_downtime,omitempty" yaml:"write_downtime,omitempty"`
+	LostTransactions int64         `json:"lost_transactions,omitempty" yaml:"lost_transactions,omitempty"`
+
+	// person who initiated manual operation
+	Operator *Operator `json:"operator,omitempty" yaml:"operator,omitempty"`
+	// event was not applied to MySQL, as mysync was running in observe-only mode
+	ObserveOnly bool `json:"observe_only,omitempty" yaml:"observe_only,omitempty"`
+	// update of writer DNS record done on promotion
+	DNS *DNSUpdate `json:"dns,omitempty" yaml:"dns,omitempty"`
+}
+
+// TopologyEpoch is a monotonically increasing cluster term stamped on every promotion
+type TopologyEpoch struct {
+	Epoch     int64     `json:"epoch"`
+	Master    string    `json:"master"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
 // DiskState contains information about disk space on the node
 type DiskState struct {
-	Used  uint64
-	Total uint64
+	Used        uint64
+	Total       uint64
+	InodesUsed  uint64
+	InodesTotal uint64
+	// bytes per second
+	GrowthRate float64
+	// additional volumes (binlogs, tmpdir, ...) by path
+	Volumes map[string]*DiskState `json:",omitempty"`
 }
 
 func (ds DiskState) Usage() float64 {
@@ -246,12 +397,75 @@ func (ds DiskState) Usage() float64 {
 	return 100.0 * float64(ds.Used) / float64(ds.Total)
 }
 
+func (ds DiskState) InodesUsage() float64 {
+	if ds.InodesTotal == 0 {
+		return 0
+	}
+	if ds.InodesUsed > ds.InodesTotal {
+		return 100
+	}
+	return 100.0 * float64(ds.InodesUsed) / float64(ds.InodesTotal)
+}
+
+// MaxUsage returns the worst of space and inodes usage among all volumes
+func (ds DiskState) MaxUsage() float64 {
+	usage := math.Max(ds.Usage(), ds.InodesUsage())
+	for _, volume := range ds.Volumes {
+		if volume != nil {
+			usage = math.Max(usage, volume.MaxUsage())
+		}
+	}
+	return usage
+}
+
+// TimeToFull returns predicted time until disk is full, or 0 if disk usage is not growing
+func (ds DiskState) TimeToFull() time.Duration {
+	if ds.GrowthRate <= 0 || ds.Total == 0 {
+		return 0
+	}
+	if ds.Used >= ds.Total {
+		return time.Nanosecond
+	}
+	seconds := float64(ds.Total-ds.Used) / ds.GrowthRate
+	if seconds > math.MaxInt64/float64(time.Second) {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
 type DaemonState struct {
 	StartTime     time.Time `json:"start_time"`
 	RecoveryTime  time.Time `json:"recovery_time"`
 	CrashRecovery bool      `json:"crash_recovery"`
 }
 
+// AgentState describes how long mysync agent spent in each state of its state machine
+type AgentState struct {
+	State          string                   `json:"state"`
+	StateSince     time.Time                `json:"state_since"`
+	StateDurations map[string]time.Duration `json:"state_durations"`
+	// manager's view of master health, empty on other hosts
+	MasterView          string                   `json:"master_view,omitempty"`
+	MasterViewSince     time.Time                `json:"master_view_since,omitempty"`
+	MasterViewDurations map[string]time.Duration `json:"master_view_durations,omitempty"`
+	// mysync version and effective config of agent, to detect skew during rolling upgrades
+	Version         string `json:"version,omitempty"`
+	ProtocolVersion int    `json:"protocol_version,omitempty"`
+	ConfigHash      string `json:"config_hash,omitempty"`
+}
+
+func (as *AgentState) String() string {
+	now := time.Now()
+	result := fmt.Sprintf("%s for %s (%s)", as.State, now.Sub(as.StateSince).Round(time.Second), formatDurations(as.StateDurations))
+	if as.MasterView != "" {
+		result += fmt.Sprintf(", master %s for %s", as.MasterView, now.Sub(as.MasterViewSince).Round(time.Second))
+	}
+	if len(as.MasterViewDurations) > 0 {
+		result += fmt.Sprintf(" (%s)", formatDurations(as.MasterViewDurations))
+	}
+	return result
+}
+
 // MasterState contains master specific info
 type MasterState struct {
 	ExecutedGtidSet string `json:"executed_gtid_set"`
@@ -269,6 +483,11 @@ type SlaveState struct {
 	MasterLogPos     int64    `json:"master_log_pos"`
 	LastIOErrno      int      `json:"last_io_errno"`
 	LastSQLErrno     int      `json:"last_sql_errno"`
+	RelayLogSpace    int64    `json:"relay_log_space"`
+	// transactions retrieved but not applied yet
+	ApplyBacklog int64 `json:"apply_backlog"`
+	// transactions applied per second, measured by agent of replica
+	ApplyRate float64 `json:"apply_rate"`
 }
 
 func (ss *SlaveState) FromReplicaStatus(replStatus mysql.ReplicaStatus) {
@@ -280,6 +499,37 @@ func (ss *SlaveState) FromReplicaStatus(replStatus mysql.ReplicaStatus) {
 	ss.MasterLogPos = replStatus.GetReadMasterLogPos()
 	ss.LastIOErrno = replStatus.GetLastIOErrno()
 	ss.LastSQLErrno = replStatus.GetLastSQLErrno()
+	ss.RelayLogSpace = replStatus.GetRelayLogSpace()
+	ss.ApplyBacklog = applyBacklog(ss.ExecutedGtidSet, ss.RetrievedGtidSet)
+}
+
+// DurabilityState contains settings making committed transactions survive crash of host
+type DurabilityState struct {
+	InnodbFlushLogAtTrxCommit int `json:"innodb_flush_log_at_trx_commit"`
+	SyncBinlog                int `json:"sync_binlog"`
+}
+
+// IsDurable checks that transactions are flushed on commit
+func (ds *DurabilityState) IsDurable() bool {
+	return ds.InnodbFlushLogAtTrxCommit == 1 && ds.SyncBinlog == 1
+}
+
+// LoadState contains load signals of host, considered in offline_mode decisions
+type LoadState struct {
+	ThreadsRunning int `json:"threads_running"`
+	// 1-minute load average per CPU
+	CPULoad float64 `json:"cpu_load"`
+}
+
+func (ls *LoadState) String() string {
+	return fmt.Sprintf("threads_running=%d cpu_load=%.2f", ls.ThreadsRunning, ls.CPULoad)
+}
+
+// LoadOffline is saved when replica is taken out of rotation because of its load
+type LoadOffline struct {
+	Since time.Time `json:"since"`
+	// load measured before replica went offline
+	Load LoadState `json:"load"`
 }
 
 // SemiSyncState contains semi sync host settings
@@ -296,19 +546,56 @@ const (
 	CauseWorker = "worker"
 	// CauseAuto  means failover was started automatically by failure detection process
 	CauseAuto = "auto"
+	// CauseProactive means switchover was started by mysync to get ahead of predicted master failure
+	CauseProactive = "proactive"
+	// CauseForced means master was promoted by `mysync promote --force`, bypassing all checks
+	CauseForced = "forced"
+)
+
+const (
+	// FailureAgentUnreachable means mysync on master does not report its health to dcs
+	FailureAgentUnreachable = "agent_unreachable"
+	// FailureMySQLDown means mysync on master reports that MySQL is not available
+	FailureMySQLDown = "mysql_down"
+	// FailureFilesystemReadonly means master filesystem was remounted read-only
+	FailureFilesystemReadonly = "filesystem_readonly"
+	// FailureCrashRecovery means MySQL on master is recovering after crash
+	FailureCrashRecovery = "crash_recovery"
+	// FailureDiskExhaustion means master disk is predicted to be full soon
+	FailureDiskExhaustion = "disk_exhaustion"
+	// FailureStorageDegraded means master storage reports errors and is expected to fail
+	FailureStorageDegraded = "storage_degraded"
+	// FailureSemiSyncStall means master commits are blocked waiting for semi sync ACKs
+	FailureSemiSyncStall = "semisync_stall"
+	// FailureUnknown means failure could not be classified
+	FailureUnknown = "unknown"
 )
 
+// FailoverCause contains structured reason of automatic failover
+type FailoverCause struct {
+	Kind     string    `json:"kind"`
+	FailedAt time.Time `json:"failed_at"`
+	Evidence []string  `json:"evidence,omitempty"`
+}
+
 // Switchover contains info about currently running or scheduled switchover/failover process
 type Switchover struct {
-	From        string            `json:"from"`
-	To          string            `json:"to"`
-	Cause       string            `json:"cause"`
-	InitiatedBy string            `json:"initiated_by"`
-	InitiatedAt time.Time         `json:"initiated_at"`
-	StartedBy   string            `json:"started_by"`
-	StartedAt   time.Time         `json:"started_at"`
-	Result      *SwitchoverResult `json:"result"`
-	RunCount    int               `json:"run_count,omitempty"`
+	From          string            `json:"from"`
+	To            string            `json:"to"`
+	Cause         string            `json:"cause"`
+	InitiatedBy   string            `json:"initiated_by"`
+	InitiatedAt   time.Time         `json:"initiated_at"`
+	StartedBy     string            `json:"started_by"`
+	StartedAt     time.Time         `json:"started_at"`
+	Result        *SwitchoverResult `json:"result"`
+	RunCount      int               `json:"run_count,omitempty"`
+	FailoverCause *FailoverCause    `json:"failover_cause,omitempty"`
+	Force         bool              `json:"force,omitempty"`
+	Operator      *Operator         `json:"operator,omitempty"`
+
+	// estimated while switchover is performed, saved to result on finish
+	lost *lostTransactions
+	dns  *DNSUpdate
 }
 
 func (sw *Switchover) String() string {
@@ -332,7 +619,7 @@ func (sw *Switchover) String() string {
 	if sw.To != "" {
 		swTo = sw.To
 	}
-	return fmt.Sprintf("<%s %s=>%s %s by %s at %s>", state, swFrom, swTo, sw.Cause, sw.InitiatedBy, sw.InitiatedAt)
+	return fmt.Sprintf("<%s %s=>%s %s by %s at %s>", state, swFrom, swTo, sw.Cause, initiator(sw.Operator, sw.InitiatedBy), sw.InitiatedAt)
 }
 
 // SwitchoverResult contains results of finished/failed switchover
@@ -340,14 +627,203 @@ type SwitchoverResult struct {
 	Ok         bool      `json:"ok"`
 	Error      string    `json:"error"`
 	FinishedAt time.Time `json:"finished_at"`
+	// write unavailability and transactions lost by successful switchover
+	WriteDowntime         time.Duration `json:"write_downtime,omitempty"`
+	LostTransactions      string        `json:"lost_transactions,omitempty"`
+	LostTransactionsCount int64         `json:"lost_transactions_count,omitempty"`
+}
+
+const (
+	// ResetupMethodClone rebuilds replica with CLONE INSTANCE from donor
+	ResetupMethodClone = "clone"
+	// ResetupMethodXtrabackup rebuilds replica with xtrabackup via resetup command
+	ResetupMethodXtrabackup = "xtrabackup"
+	// ResetupMethodScript rebuilds replica with resetup command
+	ResetupMethodScript = "script"
+	// ResetupMethodFile leaves resetup to external tooling watching resetup file
+	ResetupMethodFile = "file"
+)
+
+const (
+	ResetupRequestPending = "pending"
+	ResetupRequestRunning = "running"
+	ResetupRequestDone    = "done"
+	ResetupRequestFailed  = "failed"
+)
+
+// ResetupRequest is a request to rebuild replica, made by `mysync host resetup`
+type ResetupRequest struct {
+	Donor       string    `json:"donor,omitempty"`
+	Method      string    `json:"method"`
+	InitiatedBy string    `json:"initiated_by"`
+	InitiatedAt time.Time `json:"initiated_at"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// published periodically while resetup is running
+	Progress *ResetupProgress `json:"progress,omitempty"`
+}
+
+// ResetupProgress is the state of data copy of running resetup
+type ResetupProgress struct {
+	Phase       string     `json:"phase"`
+	BytesCopied int64      `json:"bytes_copied"`
+	BytesTotal  int64      `json:"bytes_total,omitempty"`
+	Donor       string     `json:"donor,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	ETA         *time.Time `json:"eta,omitempty"`
+}
+
+func (p *ResetupProgress) String() string {
+	const gib = float64(1 << 30)
+	s := fmt.Sprintf("%s %.1f", p.Phase, float64(p.BytesCopied)/gib)
+	if p.BytesTotal > 0 {
+		s += fmt.Sprintf("/%.1f GiB (%.1f%%)", float64(p.BytesTotal)/gib, float64(p.BytesCopied)/float64(p.BytesTotal)*100)
+	} else {
+		s += " GiB"
+	}
+	if p.ETA != nil {
+		s += fmt.Sprintf(", ETA %s", p.ETA.Format(time.RFC3339))
+	}
+	return s
+}
+
+func (r *ResetupRequest) String() string {
+	s := fmt.Sprintf("<%s via %s", r.Status, r.Method)
+	if r.Donor != "" {
+		s += fmt.Sprintf(" from %s", r.Donor)
+	}
+	s += fmt.Sprintf(" by %s at %s", r.InitiatedBy, r.InitiatedAt)
+	if r.Error != "" {
+		s += fmt.Sprintf(": %s", r.Error)
+	}
+	if r.Status == ResetupRequestRunning && r.Progress != nil {
+		s += fmt.Sprintf(": %s", r.Progress)
+	}
+	return s + ">"
+}
+
+// ManagerHandoff is a request to pass manager lock to another agent, made by `mysync manager handoff`
+type ManagerHandoff struct {
+	From        string    `json:"from"`
+	To          string    `json:"to,omitempty"`
+	InitiatedBy string    `json:"initiated_by"`
+	InitiatedAt time.Time `json:"initiated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Operator    *Operator `json:"operator,omitempty"`
+	// set by the former manager when lock is released
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+	// state of the former manager taken by the next one
+	NodeFailedAt       map[string]time.Time `json:"node_failed_at,omitempty"`
+	StreamFromFailedAt map[string]time.Time `json:"stream_from_failed_at,omitempty"`
+}
+
+// IsExpired returns true if handoff was not completed in time, so any agent may take the lock
+func (h *ManagerHandoff) IsExpired(now time.Time) bool {
+	return now.After(h.ExpiresAt)
+}
+
+// allowsLock returns true if host may take manager lock while handoff is in progress
+func (h *ManagerHandoff) allowsLock(host string, now time.Time) bool {
+	if h.IsExpired(now) {
+		return true
+	}
+	if h.To != "" {
+		return host == h.To
+	}
+	return host != h.From
+}
+
+func (h *ManagerHandoff) String() string {
+	s := fmt.Sprintf("<from %s", h.From)
+	if h.To != "" {
+		s += fmt.Sprintf(" to %s", h.To)
+	}
+	s += fmt.Sprintf(" by %s at %s", h.InitiatedBy, h.InitiatedAt)
+	if h.ReleasedAt != nil {
+		s += ", released"
+	}
+	return s + ">"
+}
+
+// HostQuarantine is made by `mysync host quarantine`: mysync keeps monitoring the host,
+// but neither repairs it nor considers it as a candidate for promotion
+type HostQuarantine struct {
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Operator  *Operator `json:"operator,omitempty"`
+}
+
+// IsExpired returns true if quarantine timeout passed
+func (q *HostQuarantine) IsExpired(now time.Time) bool {
+	return now.After(q.ExpiresAt)
+}
+
+func (q *HostQuarantine) String() string {
+	s := fmt.Sprintf("<since %s until %s", q.CreatedAt.Format(time.RFC3339), q.ExpiresAt.Format(time.RFC3339))
+	if q.Operator != nil {
+		s += fmt.Sprintf(" by %s", q.Operator)
+	}
+	return s + ">"
+}
+
+// HostDrain is made by `mysync host drain`: replica is kept offline with replication running,
+// and becomes safe to take down once its client connections are finished
+type HostDrain struct {
+	StartedAt   time.Time  `json:"started_at"`
+	Operator    *Operator  `json:"operator,omitempty"`
+	Connections int        `json:"connections"`
+	DrainedAt   *time.Time `json:"drained_at,omitempty"`
+}
+
+func (d *HostDrain) String() string {
+	s := fmt.Sprintf("<since %s", d.StartedAt.Format(time.RFC3339))
+	if d.Operator != nil {
+		s += fmt.Sprintf(" by %s", d.Operator)
+	}
+	if d.DrainedAt != nil {
+		s += fmt.Sprintf(", drained at %s", d.DrainedAt.Format(time.RFC3339))
+	} else {
+		s += fmt.Sprintf(", %d connections left", d.Connections)
+	}
+	return s + ">"
+}
+
+// RollingUpgrade is made by `mysync upgrade on`: hosts run different MySQL versions on purpose,
+// so promotion may only move cluster forward to newer version
+type RollingUpgrade struct {
+	StartedAt time.Time `json:"started_at"`
+	Operator  *Operator `json:"operator,omitempty"`
+}
+
+func (u *RollingUpgrade) String() string {
+	s := fmt.Sprintf("<since %s", u.StartedAt.Format(time.RFC3339))
+	if u.Operator != nil {
+		s += fmt.Sprintf(" by %s", u.Operator)
+	}
+	return s + ">"
 }
 
 // Maintenance struct presence means that cluster under manual control
 type Maintenance struct {
-	InitiatedBy  string    `json:"initiated_by"`
-	InitiatedAt  time.Time `json:"initiated_at"`
-	MySyncPaused bool      `json:"mysync_paused"`
-	ShouldLeave  bool      `json:"should_leave"`
+	InitiatedBy  string     `json:"initiated_by"`
+	InitiatedAt  time.Time  `json:"initiated_at"`
+	MySyncPaused bool       `json:"mysync_paused"`
+	ShouldLeave  bool       `json:"should_leave"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Operator     *Operator  `json:"operator,omitempty"`
+	DisabledBy   *Operator  `json:"disabled_by,omitempty"`
+}
+
+// IsExpired returns true if maintenance was enabled for limited time, which is over
+func (m *Maintenance) IsExpired() bool {
+	return m.ExpiresAt != nil && time.Now().After(*m.ExpiresAt)
+}
+
+// expireAfter limits maintenance by duration from now
+func (m *Maintenance) expireAfter(duration time.Duration) {
+	expiresAt := time.Now().Add(duration)
+	m.ExpiresAt = &expiresAt
 }
 
 func (m *Maintenance) String() string {
@@ -358,5 +834,12 @@ func (m *Maintenance) String() string {
 	if m.ShouldLeave {
 		ms = "leaving"
 	}
-	return fmt.Sprintf("<%s by %s at %s>", ms, m.InitiatedBy, m.InitiatedAt)
+	by := initiator(m.Operator, m.InitiatedBy)
+	if m.Operator != nil && m.Operator.Reason != "" {
+		by += fmt.Sprintf(" (%s)", m.Operator.Reason)
+	}
+	if m.ExpiresAt != nil {
+		return fmt.Sprintf("<%s by %s at %s until %s>", ms, by, m.InitiatedAt, *m.ExpiresAt)
+	}
+	return fmt.Sprintf("<%s by %s at %s>", ms, by, m.InitiatedAt)
 }
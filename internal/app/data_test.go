@@ -1,8 +1,10 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -81,3 +83,34 @@ func TestUpdateBinlogWithoutChanges(t *testing.T) {
 	require.Equal(t, "test_master_log_file0000000000000000002", newBinlogPos)
 	require.Equal(t, false, ns.IsLoadingBinlog)
 }
+
+func TestDiskStatePrediction(t *testing.T) {
+	now := time.Now()
+	old := &NodeState{CheckAt: now, DiskState: &DiskState{Used: 100, Total: 1000}}
+	ns := &NodeState{CheckAt: now.Add(10 * time.Second), DiskState: &DiskState{Used: 200, Total: 1000}}
+
+	require.Equal(t, ns, ns.UpdateDiskGrowthRate(old))
+	require.InDelta(t, diskGrowthSmoothing*10, ns.DiskState.GrowthRate, 0.0001)
+	require.Equal(t, time.Duration(800/ns.DiskState.GrowthRate*float64(time.Second)), ns.DiskState.TimeToFull())
+
+	// disk usage is not growing
+	require.Equal(t, time.Duration(0), old.DiskState.TimeToFull())
+
+	ds := DiskState{Used: 10, Total: 100, InodesUsed: 50, InodesTotal: 100, Volumes: map[string]*DiskState{
+		"/binlogs": {Used: 90, Total: 100},
+	}}
+	require.Equal(t, 90.0, ds.MaxUsage())
+}
+
+func TestMaintenanceExpiry(t *testing.T) {
+	maintenance := &Maintenance{InitiatedBy: "mysql1"}
+	data, err := json.Marshal(maintenance)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "expires_at")
+	require.False(t, maintenance.IsExpired())
+
+	maintenance.expireAfter(-time.Second)
+	require.True(t, maintenance.IsExpired())
+	maintenance.expireAfter(time.Hour)
+	require.False(t, maintenance.IsExpired())
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+const debugStatePath = "/debug/state"
+
+// clusterViewCache keeps the last cluster view of manager for debugging
+type clusterViewCache struct {
+	mu              sync.Mutex
+	updatedAt       time.Time
+	clusterState    map[string]*NodeState
+	clusterStateDcs map[string]*NodeState
+}
+
+func (c *clusterViewCache) update(clusterState, clusterStateDcs map[string]*NodeState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updatedAt = time.Now()
+	c.clusterState = clusterState
+	c.clusterStateDcs = clusterStateDcs
+}
+
+// debugClusterView is the last cluster view of manager
+type debugClusterView struct {
+	UpdatedAt time.Time             `json:"updated_at"`
+	DB        map[string]*NodeState `json:"db"`
+	DCS       map[string]*NodeState `json:"dcs"`
+}
+
+// debugState is a dump of agent internals served by debug endpoint
+type debugState struct {
+	Time        time.Time        `json:"time"`
+	Hostname    string           `json:"hostname"`
+	Goroutines  int              `json:"goroutines"`
+	Health      agentHealth      `json:"health"`
+	AgentState  *AgentState      `json:"agent_state"`
+	DCS         *dcs.SessionInfo `json:"dcs,omitempty"`
+	ClusterView debugClusterView `json:"cluster_view"`
+}
+
+func (app *App) getDebugState(now time.Time) *debugState {
+	state := &debugState{
+		Time:       now,
+		Hostname:   app.cfg().Hostname,
+		Goroutines: runtime.NumGoroutine(),
+		Health:     app.checkAgentHealth(now, true),
+		AgentState: app.getAgentState(now),
+	}
+	if app.dcs != nil {
+		session := app.dcs.SessionInfo()
+		state.DCS = &session
+	}
+	app.clusterView.mu.Lock()
+	state.ClusterView = debugClusterView{
+		UpdatedAt: app.clusterView.updatedAt,
+		DB:        app.clusterView.clusterState,
+		DCS:       app.clusterView.clusterStateDcs,
+	}
+	app.clusterView.mu.Unlock()
+	return state
+}
+
+func (app *App) handleDebugState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(app.getDebugState(time.Now()))
+}
+
+// debugServer serves pprof and state dump on debug_listen until ctx is done.
+// In DevMode it also allows to inject faults
+func (app *App) debugServer(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc(debugStatePath, app.handleDebugState)
+	if app.cfg().DevMode {
+		mux.HandleFunc(debugFaultsPath, app.handleDebugFaults)
+	}
+	server := &http.Server{
+		Addr:              app.cfg().DebugListen,
+		Handler:           mux,
+		ReadHeaderTimeout: app.cfg().DBTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	app.logger.Infof("debug: listening on %s", app.cfg().DebugListen)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		app.logger.Errorf("debug: server failed: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestDebugState(t *testing.T) {
+	app := &App{config: config.NewHolder(&config.Config{Hostname: "h1", HealthStaleTimeout: time.Minute})}
+	now := time.Now()
+	app.liveness.tickLoop(now, stateManager)
+	app.stateTimes.enter(stateManager, now.Add(-time.Minute))
+	app.clusterView.update(map[string]*NodeState{"h1": {PingOk: true}}, map[string]*NodeState{"h1": {PingOk: false}})
+
+	state := app.getDebugState(now)
+	require.Equal(t, "h1", state.Hostname)
+	require.Greater(t, state.Goroutines, 0)
+	require.Nil(t, state.DCS)
+	require.Equal(t, stateManager, state.AgentState.State)
+	require.True(t, state.ClusterView.DB["h1"].PingOk)
+	require.False(t, state.ClusterView.DCS["h1"].PingOk)
+
+	_, err := json.Marshal(state)
+	require.NoError(t, err)
+}
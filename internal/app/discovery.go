@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/discovery"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// discoveryRegistration describes local host as seen by mysync: its role and whether it is active.
+// It returns false if role is unknown, as dcs is not available
+func (app *App) discoveryRegistration(now time.Time) (discovery.Registration, bool) {
+	cfg := app.cfg().Discovery
+	reg := discovery.Registration{
+		Host:    app.cfg().Hostname,
+		Address: cfg.Address,
+		Port:    app.cfg().MySQL.Port,
+		Role:    discovery.RoleReplica,
+	}
+	if reg.Address == "" {
+		reg.Address = app.cfg().Hostname
+	}
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil || master == "" {
+		return reg, false
+	}
+	if master == app.cfg().Hostname {
+		reg.Role = discovery.RolePrimary
+	}
+	// primary stays registered during maintenance, clients should keep reaching it
+	_, err = app.GetMaintenance()
+	if err == nil {
+		reg.Maintenance = true
+	} else if err != dcs.ErrNotFound {
+		return reg, false
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		return reg, false
+	}
+
+	var problems []string
+	if !util.ContainsString(activeNodes, app.cfg().Hostname) {
+		problems = append(problems, "host is not active")
+	}
+	problems = append(problems, app.checkAgentHealth(now, true).Problems...)
+	reg.Healthy = len(problems) == 0
+	reg.Note = strings.Join(problems, "; ")
+	return reg, true
+}
+
+// discoveryRegistrar keeps role of local host registered in service catalog until ctx is done
+func (app *App) discoveryRegistrar(ctx context.Context) {
+	cfg := app.cfg().Discovery
+	registrar, err := discovery.New(cfg)
+	if err != nil {
+		app.logger.Errorf("discovery: %v", err)
+		return
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	var last discovery.Registration
+	for {
+		reg, ok := app.discoveryRegistration(time.Now())
+		if ok && app.cfg().ObserveOnly {
+			if reg.Role != last.Role || reg.Healthy != last.Healthy {
+				app.logger.Infof("observe-only: would register %s as %s, healthy: %v", reg.Host, reg.Role, reg.Healthy)
+				last = reg
+			}
+		} else if ok {
+			reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			err := registrar.Register(reqCtx, reg)
+			cancel()
+			if err != nil {
+				app.logger.Errorf("discovery: failed to register %s: %v", reg.Host, err)
+			} else if reg.Role != last.Role || reg.Healthy != last.Healthy {
+				app.logger.Infof("discovery: %s registered as %s, healthy: %v", reg.Host, reg.Role, reg.Healthy)
+				last = reg
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if app.cfg().ObserveOnly {
+				return
+			}
+			// registration expires anyway, but stopped agent should disappear from catalog at once
+			reqCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+			if err := registrar.Deregister(reqCtx, app.cfg().Hostname); err != nil {
+				app.logger.Warnf("discovery: failed to deregister %s: %v", app.cfg().Hostname, err)
+			}
+			cancel()
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/discovery"
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestDiscoveryRegistration(t *testing.T) {
+	dcs := mysynctest.NewMemDCS("mysql1")
+	defer dcs.Close()
+	app := &App{dcs: dcs, config: config.NewHolder(&config.Config{Hostname: "mysql1", HealthStaleTimeout: time.Minute})}
+	app.cfg().MySQL.Port = 3306
+	now := time.Now()
+	app.liveness.tickLoop(now, stateCandidate)
+	app.liveness.checkedHealth(now, true)
+
+	_, ok := app.discoveryRegistration(now)
+	require.False(t, ok)
+
+	require.NoError(t, dcs.Set(pathMasterNode, "mysql2"))
+	require.NoError(t, dcs.Set(pathActiveNodes, []string{"mysql2"}))
+	reg, ok := app.discoveryRegistration(now)
+	require.True(t, ok)
+	require.Equal(t, discovery.Registration{Host: "mysql1", Address: "mysql1", Port: 3306, Role: discovery.RoleReplica,
+		Note: "host is not active"}, reg)
+
+	require.NoError(t, dcs.Set(pathActiveNodes, []string{"mysql1", "mysql2"}))
+	require.NoError(t, dcs.Set(pathMasterNode, "mysql1"))
+	reg, _ = app.discoveryRegistration(now)
+	require.Equal(t, discovery.RolePrimary, reg.Role)
+	require.True(t, reg.Healthy)
+
+	require.NoError(t, dcs.Set(pathMaintenance, &Maintenance{}))
+	reg, _ = app.discoveryRegistration(now)
+	require.Equal(t, discovery.RolePrimary, reg.Role)
+	require.True(t, reg.Maintenance)
+	require.True(t, reg.Healthy)
+}
+
+func TestDiscoveryRegistrarObserveOnly(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, "mysql1")
+	defer app.dcs.Close()
+	app.cfg().ObserveOnly = true
+	app.cfg().Discovery = config.DiscoveryConfig{
+		Type:     config.DiscoveryConsul,
+		Endpoint: server.URL,
+		Interval: 10 * time.Millisecond,
+		Timeout:  time.Second,
+	}
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, app.dcs.Set(pathActiveNodes, []string{"mysql1"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	app.discoveryRegistrar(ctx)
+	require.Equal(t, int32(0), requests.Load())
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// fillDiskDetails adds inodes usage and usage of additional volumes to local disk state
+func (app *App) fillDiskDetails(ds *DiskState) {
+	if app.cfg().TestDiskUsageFile != "" {
+		return
+	}
+	node := app.cluster.Local()
+	_, _, inodesUsed, inodesTotal, err := node.GetFsUsage(app.cfg().MySQL.DataDir)
+	if err != nil {
+		app.logger.Errorf("Failed to get inodes usage: %v", err)
+	} else {
+		ds.InodesUsed = inodesUsed
+		ds.InodesTotal = inodesTotal
+	}
+	for _, path := range app.cfg().DiskExtraPaths {
+		used, total, inodesUsed, inodesTotal, err := node.GetFsUsage(path)
+		if err != nil {
+			app.logger.Errorf("Failed to get disk usage of %s: %v", path, err)
+			continue
+		}
+		if ds.Volumes == nil {
+			ds.Volumes = make(map[string]*DiskState)
+		}
+		ds.Volumes[path] = &DiskState{
+			Used:        used,
+			Total:       total,
+			InodesUsed:  inodesUsed,
+			InodesTotal: inodesTotal,
+		}
+	}
+}
+
+// checkDiskExhaustion warns about master disk which is going to be full soon
+// and issues switchover from it if it is allowed
+func (app *App) checkDiskExhaustion(clusterStateDcs map[string]*NodeState, master string) {
+	if app.cfg().DiskExhaustionHorizon == 0 {
+		return
+	}
+	state := clusterStateDcs[master]
+	if state == nil || state.DiskState == nil {
+		return
+	}
+	timeToFull := state.DiskState.TimeToFull()
+	if timeToFull == 0 || timeToFull > app.cfg().DiskExhaustionHorizon {
+		return
+	}
+	app.logger.Warnf("diskusage: master %s disk is predicted to be full in %v (usage %0.2f%%, growth %0.0f bytes/s)",
+		master, timeToFull, state.DiskState.Usage(), state.DiskState.GrowthRate)
+	if !app.cfg().SwitchoverOnDiskExhaustion {
+		return
+	}
+	cause := &FailoverCause{
+		Kind:     FailureDiskExhaustion,
+		FailedAt: state.CheckAt,
+		Evidence: []string{fmt.Sprintf("disk of %s is predicted to be full in %v", master, timeToFull)},
+	}
+	err := app.issueProactiveSwitchover(master, cause)
+	if err != nil {
+		app.logger.Errorf("diskusage: failed to issue switchover from %s: %v", master, err)
+	}
+}
+
+// issueProactiveSwitchover issues switchover from master,
+// which is expected to fail soon, unless it was done recently
+func (app *App) issueProactiveSwitchover(master string, cause *FailoverCause) error {
+	lastSwitchover := app.GetLastSwitchover()
+	if lastSwitchover.Result != nil && time.Since(lastSwitchover.Result.FinishedAt) < app.cfg().FailoverCooldown {
+		app.logger.Infof("switchover from %s is not issued, last switchover was less than %v ago", master, app.cfg().FailoverCooldown)
+		return nil
+	}
+	var switchover Switchover
+	switchover.From = master
+	switchover.InitiatedBy = app.cfg().Hostname
+	switchover.InitiatedAt = time.Now()
+	switchover.Cause = CauseProactive
+	switchover.FailoverCause = cause
+	err := app.dcs.Create(pathCurrentSwitch, switchover)
+	if err == dcs.ErrExists {
+		return nil
+	}
+	if err == nil {
+		app.logger.Infof("switchover from %s issued: %s", master, cause.Kind)
+	}
+	return err
+}
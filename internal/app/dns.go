@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/dnsupdate"
+)
+
+const dnsPropagationCheckInterval = time.Second
+
+// DNSUpdate is a result of pointing writer DNS record to the new master
+type DNSUpdate struct {
+	Record          string        `json:"record" yaml:"record"`
+	Type            string        `json:"type" yaml:"type"`
+	Values          []string      `json:"values" yaml:"values"`
+	TTL             int           `json:"ttl" yaml:"ttl"`
+	Error           string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Propagated      bool          `json:"propagated,omitempty" yaml:"propagated,omitempty"`
+	PropagationTime time.Duration `json:"propagation_time,omitempty" yaml:"propagation_time,omitempty"`
+}
+
+func (u *DNSUpdate) String() string {
+	s := fmt.Sprintf("dns %s %s %s ttl %d", u.Record, u.Type, strings.Join(u.Values, ","), u.TTL)
+	if u.Error != "" {
+		return s + " failed: " + u.Error
+	}
+	if u.Propagated {
+		s += fmt.Sprintf(", propagated in %v", u.PropagationTime.Round(time.Millisecond))
+	}
+	return s
+}
+
+// dnsValues returns values of writer record pointing to host
+func dnsValues(ctx context.Context, recordType, host string) ([]string, error) {
+	if recordType == "CNAME" {
+		return []string{host}, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, addr := range addrs {
+		if (addr.IP.To4() != nil) == (recordType == "A") {
+			values = append(values, addr.IP.String())
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("host %s has no %s addresses", host, recordType)
+	}
+	return values, nil
+}
+
+// updateDNS points writer record to the new master and waits until check server returns it.
+// Failure is reported in result, as promotion itself is already done at this point
+func (app *App) updateDNS(master string) *DNSUpdate {
+	cfg := app.cfg().DNS
+	if cfg.Provider == "" {
+		return nil
+	}
+	update := &DNSUpdate{Record: cfg.Record, Type: cfg.RecordType, TTL: cfg.TTL}
+	err := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		defer cancel()
+		values, err := dnsValues(ctx, cfg.RecordType, master)
+		if err != nil {
+			return err
+		}
+		update.Values = values
+		if app.cfg().ObserveOnly {
+			app.logger.Infof("observe-only: would update %s", update)
+			return nil
+		}
+		provider, err := dnsupdate.New(cfg)
+		if err != nil {
+			return err
+		}
+		return provider.Update(ctx, cfg.Record, cfg.RecordType, values, cfg.TTL)
+	}()
+	if err != nil {
+		update.Error = err.Error()
+		app.logger.Errorf("dns: failed to point %s to %s: %v", cfg.Record, master, err)
+		return update
+	}
+	if cfg.CheckServer != "" && !app.cfg().ObserveOnly {
+		app.waitDNSPropagation(update)
+	}
+	app.logger.Infof("dns: %s", update)
+	return update
+}
+
+func (app *App) waitDNSPropagation(update *DNSUpdate) {
+	cfg := app.cfg().DNS
+	expected := dnsupdate.Normalize(update.Type, update.Values)
+	start := time.Now()
+	deadline := start.Add(cfg.PropagationTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		values, err := dnsupdate.Lookup(ctx, cfg.CheckServer, update.Record, update.Type)
+		cancel()
+		if err == nil && slices.Equal(values, expected) {
+			update.Propagated = true
+			update.PropagationTime = time.Since(start)
+			return
+		}
+		if time.Now().After(deadline) {
+			app.logger.Warnf("dns: %s is not propagated to %s after %v: got %v, %v", update.Record, cfg.CheckServer, cfg.PropagationTimeout, values, err)
+			return
+		}
+		app.liveness.tickPhase(time.Now())
+		time.Sleep(dnsPropagationCheckInterval)
+	}
+}
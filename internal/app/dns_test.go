@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+func TestUpdateDNS(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	app := &App{logger: logger, config: config.NewHolder(&config.Config{DNS: config.DNSConfig{
+		Provider:   config.DNSProviderPowerDNS,
+		Record:     "writer.example.com",
+		RecordType: "CNAME",
+		TTL:        30,
+		Endpoint:   server.URL,
+		Zone:       "example.com",
+		Secret:     "secret",
+		Timeout:    time.Second,
+	}})}
+
+	update := app.updateDNS("mysql2.example.com")
+	require.Equal(t, &DNSUpdate{Record: "writer.example.com", Type: "CNAME", Values: []string{"mysql2.example.com"}, TTL: 30}, update)
+	require.Equal(t, "dns writer.example.com CNAME mysql2.example.com ttl 30", update.String())
+
+	status = http.StatusUnprocessableEntity
+	update = app.updateDNS("mysql2.example.com")
+	require.NotEmpty(t, update.Error)
+
+	app.cfg().DNS.Provider = ""
+	require.Nil(t, app.updateDNS("mysql2.example.com"))
+}
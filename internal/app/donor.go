@@ -0,0 +1,155 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// donorCandidate is a host, which may give its data to replica on resetup
+type donorCandidate struct {
+	host      string
+	zone      string
+	isMaster  bool
+	lag       float64
+	donations int
+}
+
+// donorCandidates filters hosts by resetup_donor_policy and sorts them from the most preferred one
+func donorCandidates(host, zone string, clusterState map[string]*NodeState, master string, excluded []string, donations map[string]int, policy config.DonorPolicyConfig) []donorCandidate {
+	var candidates []donorCandidate
+	for h, state := range clusterState {
+		if h == host || state == nil || !state.PingOk || state.IsBackupRunning || util.ContainsString(excluded, h) {
+			continue
+		}
+		if policy.MaxConcurrent > 0 && donations[h] >= policy.MaxConcurrent {
+			continue
+		}
+		candidate := donorCandidate{host: h, zone: state.Zone, isMaster: h == master, donations: donations[h]}
+		if candidate.isMaster {
+			if !policy.AllowMaster {
+				continue
+			}
+		} else {
+			if state.SlaveState == nil || state.SlaveState.ReplicationLag == nil {
+				continue
+			}
+			candidate.lag = *state.SlaveState.ReplicationLag
+			if policy.MaxLag > 0 && candidate.lag > policy.MaxLag.Seconds() {
+				continue
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if policy.PreferSameZone && zone != "" && (a.zone == zone) != (b.zone == zone) {
+			return a.zone == zone
+		}
+		if a.isMaster != b.isMaster {
+			return !a.isMaster
+		}
+		if a.donations != b.donations {
+			return a.donations < b.donations
+		}
+		if a.lag != b.lag {
+			return a.lag < b.lag
+		}
+		return a.host < b.host
+	})
+	return candidates
+}
+
+// getResetupDonations returns number of running resetups per donor and hosts being rebuilt now
+func (app *App) getResetupDonations() (map[string]int, []string, error) {
+	donations := make(map[string]int)
+	var recipients []string
+	hosts, err := app.dcs.GetChildren(pathResetupRequests)
+	if err == dcs.ErrNotFound {
+		return donations, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, host := range hosts {
+		request, err := app.getResetupRequest(host)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if request.Status != ResetupRequestRunning {
+			continue
+		}
+		recipients = append(recipients, host)
+		if request.Donor != "" {
+			donations[request.Donor]++
+		}
+	}
+	return donations, recipients, nil
+}
+
+// selectResetupDonor chooses donor for resetup of host by resetup_donor_policy
+func (app *App) selectResetupDonor(host string) (string, error) {
+	policy := app.cfg().ResetupDonorPolicy
+	clusterState, err := app.getClusterStateFromDcs()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cluster state: %v", err)
+	}
+	var master string
+	err = app.dcs.Get(pathMasterNode, &master)
+	if err != nil && err != dcs.ErrNotFound {
+		return "", fmt.Errorf("failed to get current master: %v", err)
+	}
+	donations, recipients, err := app.getResetupDonations()
+	if err != nil {
+		return "", fmt.Errorf("failed to get running resetups: %v", err)
+	}
+	relays, err := app.getRelayHosts()
+	if err != nil {
+		return "", fmt.Errorf("failed to get relay hosts: %v", err)
+	}
+	excluded := append(relays, recipients...)
+
+	// candidates, whose load can't be checked, are never used as donors
+	var unknownLoad []string
+	for _, candidate := range donorCandidates(host, app.cfg().Zone, clusterState, master, excluded, donations, policy) {
+		if policy.MaxThreadsRunning > 0 {
+			node := app.cluster.Get(candidate.host)
+			if node == nil {
+				app.logger.Warnf("resetup: donor candidate %s is not connected, its load is unknown", candidate.host)
+				unknownLoad = append(unknownLoad, candidate.host)
+				continue
+			}
+			threads, err := node.GetThreadsRunning()
+			if err != nil {
+				app.logger.Warnf("resetup: failed to get load of donor candidate %s: %v", candidate.host, err)
+				unknownLoad = append(unknownLoad, candidate.host)
+				continue
+			}
+			if threads > policy.MaxThreadsRunning {
+				app.logger.Infof("resetup: donor candidate %s is loaded with %d running threads, skipping", candidate.host, threads)
+				continue
+			}
+		}
+		if policy.MaxConcurrent > 0 {
+			acquired, err := app.acquireSlot(dcs.JoinPath(pathDonorSlots, candidate.host), policy.MaxConcurrent)
+			if err != nil {
+				app.logger.Warnf("resetup: failed to acquire slot of donor candidate %s: %v", candidate.host, err)
+				continue
+			}
+			if !acquired {
+				continue
+			}
+		}
+		return candidate.host, nil
+	}
+	if len(unknownLoad) > 0 {
+		return "", fmt.Errorf("no host matches resetup_donor_policy, load of %v is unknown", unknownLoad)
+	}
+	return "", fmt.Errorf("no host matches resetup_donor_policy")
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func donorState(zone string, lag float64) *NodeState {
+	return &NodeState{PingOk: true, Zone: zone, SlaveState: &SlaveState{ReplicationLag: &lag}}
+}
+
+func donorHosts(candidates []donorCandidate) []string {
+	var hosts []string
+	for _, c := range candidates {
+		hosts = append(hosts, c.host)
+	}
+	return hosts
+}
+
+func TestDonorCandidates(t *testing.T) {
+	policy := config.DonorPolicyConfig{PreferSameZone: true, MaxLag: time.Minute, MaxConcurrent: 1}
+	clusterState := map[string]*NodeState{
+		"mysql1": {PingOk: true, IsMaster: true, Zone: "vla"},
+		"mysql2": donorState("sas", 0),
+		"mysql3": donorState("vla", 5),
+		"mysql4": donorState("vla", 120),
+		"mysql5": donorState("vla", 0),
+		"mysql6": donorState("vla", 0),
+		"relay1": donorState("vla", 0),
+	}
+	clusterState["mysql6"].PingOk = false
+
+	candidates := donorCandidates("mysql5", "vla", clusterState, "mysql1", []string{"relay1"}, nil, policy)
+	require.Equal(t, []string{"mysql3", "mysql2"}, donorHosts(candidates))
+
+	// busy donor is skipped
+	candidates = donorCandidates("mysql5", "vla", clusterState, "mysql1", []string{"relay1"}, map[string]int{"mysql3": 1}, policy)
+	require.Equal(t, []string{"mysql2"}, donorHosts(candidates))
+
+	// master is the last resort
+	policy.AllowMaster = true
+	policy.PreferSameZone = false
+	candidates = donorCandidates("mysql5", "vla", clusterState, "mysql1", []string{"relay1"}, nil, policy)
+	require.Equal(t, []string{"mysql2", "mysql3", "mysql1"}, donorHosts(candidates))
+}
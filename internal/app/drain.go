@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// drainConnections waits until open transactions on read-only node are finished.
+// Replication and mysync own connections are not taken into account.
+// Remaining transactions are killed after timeout if switchover_drain_kill is set.
+func (app *App) drainConnections(node *mysql.Node) error {
+	if app.cfg().SwitchoverDrainTimeout == 0 {
+		return nil
+	}
+	user, _ := app.cfg().MySQLCredentials()
+	excludeUsers := append([]string{user, app.cfg().MySQL.ReplicationUser}, app.cfg().ExcludeUsers...)
+	deadline := time.Now().Add(app.cfg().SwitchoverDrainTimeout)
+	for {
+		ids, err := node.GetTransactionProcessIDs(excludeUsers)
+		if err != nil {
+			return fmt.Errorf("failed to get open transactions on %s: %v", node.Host(), err)
+		}
+		if len(ids) == 0 {
+			app.logger.Infof("switchover: host %s drained", node.Host())
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if !app.cfg().SwitchoverDrainKill {
+				app.logger.Warnf("switchover: %d transactions are still open on %s after drain timeout", len(ids), node.Host())
+				return nil
+			}
+			app.logger.Warnf("switchover: killing %d transactions still open on %s after drain timeout", len(ids), node.Host())
+			return node.KillProcesses(ids)
+		}
+		app.logger.Infof("switchover: waiting for %d open transactions on %s to finish", len(ids), node.Host())
+		app.liveness.tickPhase(time.Now())
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,27 @@
+package app
+
+import "fmt"
+
+// replicaWritable checks that replica accepts writes it should not: read_only is always required,
+// super_read_only is enforced only in enforce_settings mode
+func replicaWritable(state *NodeState, enforce bool) bool {
+	return !state.IsReadOnly || (enforce && !state.IsSuperReadOnly)
+}
+
+// describeReadOnlyDrift explains what was changed out-of-band on writable replica
+func describeReadOnlyDrift(state *NodeState) string {
+	if !state.IsReadOnly {
+		return "read_only was turned off"
+	}
+	return "super_read_only was turned off"
+}
+
+// settingsDriftCorrected records audit event about out-of-band change of host settings reverted by repair
+func (app *App) settingsDriftCorrected(host, format string, args ...interface{}) {
+	if !app.cfg().EnforceSettings {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	app.logger.Warnf("repair: drift of %s corrected: %s", host, message)
+	app.recordEvent(HistoryEvent{Type: EventDriftCorrected, Host: host, Message: message})
+}
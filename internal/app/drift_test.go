@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaWritable(t *testing.T) {
+	require.False(t, replicaWritable(&NodeState{IsReadOnly: true, IsSuperReadOnly: true}, true))
+	require.True(t, replicaWritable(&NodeState{IsReadOnly: false}, false))
+	require.False(t, replicaWritable(&NodeState{IsReadOnly: true}, false))
+	require.True(t, replicaWritable(&NodeState{IsReadOnly: true}, true))
+	require.Equal(t, "super_read_only was turned off", describeReadOnlyDrift(&NodeState{IsReadOnly: true}))
+}
+
+func TestSettingsDriftCorrected(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	app.settingsDriftCorrected("mysql2", "replication was stopped, started again")
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	app.cfg().EnforceSettings = true
+	app.settingsDriftCorrected("mysql2", "replication source was %s instead of master %s, changed back", "mysql3", "mysql1")
+	history, err = app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, EventDriftCorrected, history[0].Type)
+	require.Equal(t, "mysql2", history[0].Host)
+	require.Equal(t, "replication source was mysql3 instead of master mysql1, changed back", history[0].Message)
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// GetEpoch returns current topology epoch or nil if it was never set
+func (app *App) GetEpoch() (*TopologyEpoch, error) {
+	epoch := new(TopologyEpoch)
+	err := app.dcs.Get(pathEpoch, epoch)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return epoch, nil
+}
+
+// bumpEpoch starts new topology epoch with given master
+func (app *App) bumpEpoch(master string) error {
+	epoch, err := app.GetEpoch()
+	if err != nil {
+		return err
+	}
+	if epoch == nil {
+		epoch = new(TopologyEpoch)
+	} else if epoch.Master == master {
+		return nil
+	}
+	epoch.Epoch++
+	epoch.Master = master
+	epoch.ChangedAt = time.Now()
+	app.logger.Infof("epoch: starting epoch %d with master %s", epoch.Epoch, master)
+	return app.dcs.Set(pathEpoch, epoch)
+}
+
+// rollbackEpoch starts new epoch with master from dcs, if failed switchover has already started epoch with new master.
+// Otherwise agent of actual master would keep demoting it
+func (app *App) rollbackEpoch() {
+	epoch, err := app.GetEpoch()
+	if err != nil {
+		app.logger.Errorf("epoch: failed to get epoch from dcs: %v", err)
+		return
+	}
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil || master == "" {
+		app.logger.Errorf("epoch: failed to get master from dcs: %v", err)
+		return
+	}
+	if epoch == nil || epoch.Master == master {
+		return
+	}
+	app.logger.Warnf("epoch: switchover to %s failed, returning to master %s", epoch.Master, master)
+	err = app.bumpEpoch(master)
+	if err != nil {
+		app.logger.Errorf("epoch: failed to start new epoch with master %s: %v", master, err)
+	}
+}
+
+// enforceEpoch demotes local node to read-only if it is writable master
+// while newer epoch says that master is somewhere else
+func (app *App) enforceEpoch(localState *NodeState) {
+	if !localState.PingOk || !localState.IsMaster || localState.IsReadOnly {
+		return
+	}
+	epoch, err := app.GetEpoch()
+	if err != nil {
+		app.logger.Errorf("epoch: failed to get epoch from dcs: %v", err)
+		return
+	}
+	if epoch == nil || epoch.Master == app.cfg().Hostname {
+		return
+	}
+	// during maintenance topology is under manual control
+	maintenance, err := app.GetMaintenance()
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("epoch: failed to get maintenance from dcs: %v", err)
+		return
+	}
+	if maintenance != nil {
+		return
+	}
+	app.logger.Errorf("epoch: local node is writable, but master of epoch %d is %s, setting read-only", epoch.Epoch, epoch.Master)
+	err = app.cluster.Local().SetReadOnly(true)
+	if err != nil {
+		app.logger.Errorf("epoch: failed to set local node read-only: %v", err)
+	}
+}
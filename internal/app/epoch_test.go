@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackEpoch(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, app.bumpEpoch("mysql1"))
+
+	// switchover failed after new epoch was started
+	require.NoError(t, app.bumpEpoch("mysql2"))
+	app.rollbackEpoch()
+	epoch, err := app.GetEpoch()
+	require.NoError(t, err)
+	require.Equal(t, "mysql1", epoch.Master)
+	require.Equal(t, int64(3), epoch.Epoch)
+
+	// nothing to roll back
+	app.rollbackEpoch()
+	epoch, err = app.GetEpoch()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), epoch.Epoch)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// EventHealthChanged is derived by `mysync events --follow` from host health in dcs
+	EventHealthChanged = "health_changed"
+	// EventMasterChanged is derived by `mysync events --follow` from current master in dcs
+	EventMasterChanged = "master_changed"
+)
+
+func healthStatus(state *NodeState) string {
+	if state == nil {
+		return "unknown"
+	}
+	if !state.PingOk {
+		return "dead"
+	}
+	return "alive"
+}
+
+// diffClusterEvents returns events describing transition between two observations of cluster
+func diffClusterEvents(prevMaster, master string, prevHealth, health map[string]*NodeState, now time.Time) []HistoryEvent {
+	var events []HistoryEvent
+	if master != prevMaster {
+		events = append(events, HistoryEvent{
+			Time:    now,
+			Type:    EventMasterChanged,
+			Host:    master,
+			Message: fmt.Sprintf("master changed from %q to %q", prevMaster, master),
+		})
+	}
+	hosts := make([]string, 0, len(health))
+	for host := range health {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		prev, next := healthStatus(prevHealth[host]), healthStatus(health[host])
+		if prev == next {
+			continue
+		}
+		events = append(events, HistoryEvent{
+			Time:    now,
+			Type:    EventHealthChanged,
+			Host:    host,
+			Message: fmt.Sprintf("%s => %s", prev, next),
+		})
+	}
+	return events
+}
+
+// CliEvents prints recorded events and, if follow is set, streams new ones until interrupted
+func (app *App) CliEvents(follow bool, interval time.Duration, format string) int {
+	if !follow {
+		return app.CliHistory(0, format)
+	}
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.newDBCluster()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.cluster.Close()
+
+	var lastSeen time.Time
+	history, err := app.GetEventHistory()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	for _, event := range history {
+		if event.Time.After(lastSeen) {
+			lastSeen = event.Time
+		}
+	}
+	master, _ := app.GetMasterHostFromDcs()
+	health, _ := app.getClusterStateFromDcs()
+
+	ctx := app.baseContext()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+		var events []HistoryEvent
+		history, err := app.GetEventHistory()
+		if err != nil {
+			app.logger.Errorf("failed to get events: %v", err)
+			continue
+		}
+		for _, event := range history {
+			if event.Time.After(lastSeen) {
+				events = append(events, event)
+				lastSeen = event.Time
+			}
+		}
+		newMaster, err := app.GetMasterHostFromDcs()
+		if err != nil {
+			newMaster = master
+		}
+		if err := app.cluster.UpdateHostsInfo(); err != nil {
+			app.logger.Errorf("failed to update hosts: %v", err)
+		}
+		newHealth, err := app.getClusterStateFromDcs()
+		if err != nil {
+			newHealth = health
+		}
+		events = append(events, diffClusterEvents(master, newMaster, health, newHealth, time.Now())...)
+		master, health = newMaster, newHealth
+		for _, event := range events {
+			if app.printEvent(event, format) != 0 {
+				return 1
+			}
+		}
+	}
+}
+
+func (app *App) printEvent(event HistoryEvent, format string) int {
+	switch format {
+	case FormatJSON:
+		data, err := json.Marshal(cliOutput{Version: cliOutputVersion, Data: event})
+		if err != nil {
+			app.logger.Errorf("failed to marshal event: %v", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	case "":
+		fmt.Print(formatEvents([]HistoryEvent{event}))
+	default:
+		fmt.Println("---")
+		return app.printCliOutput(event, format)
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffClusterEvents(t *testing.T) {
+	now := time.Now()
+	prevHealth := map[string]*NodeState{
+		"h1": {PingOk: true},
+		"h2": {PingOk: true},
+	}
+	health := map[string]*NodeState{
+		"h1": {PingOk: false},
+		"h2": {PingOk: true},
+		"h3": {PingOk: true},
+	}
+
+	events := diffClusterEvents("h1", "h2", prevHealth, health, now)
+	require.Len(t, events, 3)
+	require.Equal(t, EventMasterChanged, events[0].Type)
+	require.Equal(t, "h2", events[0].Host)
+	require.Equal(t, EventHealthChanged, events[1].Type)
+	require.Equal(t, "h1", events[1].Host)
+	require.Equal(t, "alive => dead", events[1].Message)
+	require.Equal(t, "h3", events[2].Host)
+	require.Equal(t, "unknown => alive", events[2].Message)
+
+	require.Empty(t, diffClusterEvents("h2", "h2", health, health, now))
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// getExternalSlaveState returns state of external channel on master, or nil if there is no such channel
+func (app *App) getExternalSlaveState(node *mysql.Node) *SlaveState {
+	status, err := app.externalReplication.GetReplicaStatus(node)
+	if err != nil {
+		if !mysql.IsErrorChannelDoesNotExists(err) {
+			app.logger.Errorf("failed to get external replica status: %v", err)
+		}
+		return nil
+	}
+	if status == nil {
+		return nil
+	}
+	state := new(SlaveState)
+	state.FromReplicaStatus(status)
+	if lag := status.GetReplicationLag(); lag.Valid {
+		state.ReplicationLag = &lag.Float64
+	}
+	return state
+}
+
+// failoverExternalSource repoints external channel of master to writable host among
+// external_replication_sources, when its current source is unreachable for external_replication_failover_timeout.
+// It returns true if channel was repointed.
+func (app *App) failoverExternalSource(masterNode *mysql.Node, status mysql.ReplicaStatus) bool {
+	host := masterNode.Host()
+	if len(app.cfg().ExternalReplicationSources) == 0 || status.ReplicationIORunning() {
+		delete(app.externalSourceLost, host)
+		return false
+	}
+	lostAt, ok := app.externalSourceLost[host]
+	if !ok {
+		app.externalSourceLost[host] = time.Now()
+		return false
+	}
+	if time.Since(lostAt) < app.cfg().ExternalReplicationFailoverTimeout {
+		return false
+	}
+
+	source, err := app.externalReplication.FindWritableSource(masterNode, app.cfg().ExternalReplicationSources, app.cfg().DBTimeout)
+	if err != nil {
+		app.logger.Errorf("repair (external): failed to find new source for %s: %v", host, err)
+		return false
+	}
+	oldSource := status.GetMasterHost()
+	if source == "" || source == oldSource {
+		app.logger.Warnf("repair (external): source %s of %s is lost, but there is no other writable source among %v", oldSource, host, app.cfg().ExternalReplicationSources)
+		return false
+	}
+	app.logger.Infof("repair (external): repointing external replication of %s from %s to %s", host, oldSource, source)
+	err = app.externalReplication.Repoint(masterNode, source)
+	if err != nil {
+		app.logger.Errorf("repair (external): failed to repoint external replication of %s to %s: %v", host, source, err)
+		return false
+	}
+	delete(app.externalSourceLost, host)
+	app.recordEvent(HistoryEvent{
+		Type:    EventExternalSource,
+		Host:    host,
+		Message: fmt.Sprintf("external replication source changed from %s to %s", oldSource, source),
+	})
+	return true
+}
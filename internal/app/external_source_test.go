@@ -0,0 +1,59 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+type fakeExternalReplication struct {
+	mysql.UnimplementedExternalReplication
+	writable  string
+	repointed string
+}
+
+func (f *fakeExternalReplication) FindWritableSource(*mysql.Node, []string, time.Duration) (string, error) {
+	return f.writable, nil
+}
+
+func (f *fakeExternalReplication) Repoint(_ *mysql.Node, host string) error {
+	f.repointed = host
+	return nil
+}
+
+func TestFailoverExternalSource(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().ExternalReplicationSources = []string{"remote1", "remote2"}
+	app.cfg().ExternalReplicationFailoverTimeout = time.Millisecond
+	ext := &fakeExternalReplication{writable: "remote2"}
+	app.externalReplication = ext
+	app.externalSourceLost = make(map[string]time.Time)
+	node, err := mysql.NewNode(app.config, app.logger, "mysql1")
+	require.NoError(t, err)
+
+	running := &mysql.SlaveStatusStruct{MasterHost: "remote1", SlaveIORunning: "Yes", SlaveSQLRunning: "No"}
+	require.False(t, app.failoverExternalSource(node, running))
+	require.Empty(t, app.externalSourceLost)
+
+	// source is lost, but timeout is not passed yet
+	lost := &mysql.SlaveStatusStruct{MasterHost: "remote1", SlaveIORunning: "Connecting", SlaveSQLRunning: "Yes"}
+	require.False(t, app.failoverExternalSource(node, lost))
+	time.Sleep(2 * time.Millisecond)
+	require.True(t, app.failoverExternalSource(node, lost))
+	require.Equal(t, "remote2", ext.repointed)
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, EventExternalSource, history[0].Type)
+
+	// current source is the only writable one
+	ext.repointed = ""
+	lost.MasterHost = "remote2"
+	require.False(t, app.failoverExternalSource(node, lost))
+	time.Sleep(2 * time.Millisecond)
+	require.False(t, app.failoverExternalSource(node, lost))
+	require.Empty(t, ext.repointed)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// diagnoseMasterFailure classifies master failure and collects evidence supporting it
+func diagnoseMasterFailure(master string, clusterState, clusterStateDcs map[string]*NodeState, failedAt time.Time) *FailoverCause {
+	cause := &FailoverCause{FailedAt: failedAt}
+	dbState := clusterState[master]
+	dcsState := clusterStateDcs[master]
+	if dcsState == nil {
+		dcsState = new(NodeState)
+	}
+	if cause.FailedAt.IsZero() {
+		cause.FailedAt = dcsState.CheckAt
+	}
+
+	switch {
+	case dcsState.CheckAt.IsZero():
+		cause.Kind = FailureAgentUnreachable
+		cause.Evidence = append(cause.Evidence, fmt.Sprintf("no health report of %s in dcs", master))
+	case dcsState.IsFileSystemReadonly:
+		cause.Kind = FailureFilesystemReadonly
+		cause.Evidence = append(cause.Evidence, fmt.Sprintf("%s reported read-only filesystem at %s", master, dcsState.CheckAt.Format(time.RFC3339)))
+	case !dcsState.PingOk:
+		cause.Kind = FailureMySQLDown
+		cause.Evidence = append(cause.Evidence, fmt.Sprintf("%s reported failed ping at %s: %s", master, dcsState.CheckAt.Format(time.RFC3339), dcsState.Error))
+	case dcsState.DaemonState != nil && dcsState.DaemonState.CrashRecovery:
+		cause.Kind = FailureCrashRecovery
+		cause.Evidence = append(cause.Evidence, fmt.Sprintf("%s reported crash recovery at %s", master, dcsState.CheckAt.Format(time.RFC3339)))
+	default:
+		cause.Kind = FailureUnknown
+	}
+
+	if dbState != nil {
+		if dbState.PingOk {
+			cause.Evidence = append(cause.Evidence, fmt.Sprintf("manager %s can connect to %s", dbState.CheckBy, master))
+		} else {
+			cause.Evidence = append(cause.Evidence, fmt.Sprintf("manager %s failed to connect to %s (dubious: %t): %s", dbState.CheckBy, master, dbState.PingDubious, dbState.Error))
+		}
+	}
+	return cause
+}
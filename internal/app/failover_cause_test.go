@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseMasterFailure(t *testing.T) {
+	now := time.Now()
+	clusterState := map[string]*NodeState{
+		"A": {CheckBy: "B", PingOk: false},
+	}
+
+	cause := diagnoseMasterFailure("A", clusterState, map[string]*NodeState{"A": {}}, now)
+	require.Equal(t, FailureAgentUnreachable, cause.Kind)
+	require.Equal(t, now, cause.FailedAt)
+	require.Len(t, cause.Evidence, 2)
+
+	cause = diagnoseMasterFailure("A", clusterState, map[string]*NodeState{"A": {CheckAt: now, PingOk: false}}, now)
+	require.Equal(t, FailureMySQLDown, cause.Kind)
+
+	cause = diagnoseMasterFailure("A", clusterState, map[string]*NodeState{"A": {CheckAt: now, PingOk: true, IsFileSystemReadonly: true}}, now)
+	require.Equal(t, FailureFilesystemReadonly, cause.Kind)
+
+	// failure time is taken from health report when master was not seen failing
+	dcsState := map[string]*NodeState{"A": {CheckAt: now, PingOk: true, DaemonState: &DaemonState{CrashRecovery: true}}}
+	cause = diagnoseMasterFailure("A", map[string]*NodeState{"A": {PingOk: true}}, dcsState, time.Time{})
+	require.Equal(t, FailureCrashRecovery, cause.Kind)
+	require.Equal(t, now, cause.FailedAt)
+}
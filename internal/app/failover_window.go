@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// checkFailoverWindow returns error, when automatic failover of master is restricted by active failover window.
+// In alert mode restriction is recorded as event once per master failure.
+func (app *App) checkFailoverWindow(master string, now time.Time) error {
+	window, err := app.cfg().ActiveFailoverWindow(now)
+	if err != nil {
+		app.logger.Errorf("failover window: %v", err)
+		return nil
+	}
+	if window == nil || window.Mode == config.FailoverWindowAllow {
+		return nil
+	}
+	failedAt := app.nodeFailedAt[master]
+	if failedAt.IsZero() {
+		failedAt = now
+	}
+	if window.HardDownAfter > 0 && now.Sub(failedAt) >= window.HardDownAfter {
+		app.logger.Warnf("failover window %q: master %s is down since %s, longer than hard_down_after %s, failover is not restricted",
+			window.Name, master, failedAt.Format(time.RFC3339), window.HardDownAfter)
+		return nil
+	}
+	err = fmt.Errorf("automatic failover is restricted by failover window %q (%s)", window.Name, window.Mode)
+	if window.Mode == config.FailoverWindowAlert && !app.restrictedFailure.Equal(failedAt) {
+		app.restrictedFailure = failedAt
+		app.recordEvent(HistoryEvent{
+			Type:    EventFailoverBlocked,
+			Host:    master,
+			Message: fmt.Sprintf("master failure detected, but %v", err),
+		})
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestCheckFailoverWindow(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().FailoverWindows = []config.FailoverWindowConfig{
+		{Name: "backup", Schedule: "0 1 * * *", Duration: 3 * time.Hour, Timezone: "UTC", Mode: config.FailoverWindowAlert, HardDownAfter: 10 * time.Minute},
+	}
+	app.nodeFailedAt = make(map[string]time.Time)
+	outside := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	inside := time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC)
+
+	app.nodeFailedAt["mysql1"] = outside.Add(-time.Minute)
+	require.NoError(t, app.checkFailoverWindow("mysql1", outside))
+
+	app.nodeFailedAt["mysql1"] = inside.Add(-time.Minute)
+	require.Error(t, app.checkFailoverWindow("mysql1", inside))
+	require.Error(t, app.checkFailoverWindow("mysql1", inside.Add(time.Minute)))
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, EventFailoverBlocked, history[0].Type)
+
+	// master is hard down
+	require.NoError(t, app.checkFailoverWindow("mysql1", inside.Add(10*time.Minute)))
+
+	app.cfg().FailoverWindows[0].Mode = config.FailoverWindowPause
+	app.nodeFailedAt["mysql1"] = inside
+	require.Error(t, app.checkFailoverWindow("mysql1", inside))
+	history, err = app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// phiAccrual is adaptive failure detector of master. Instead of fixed delay it tells how suspicious
+// silence of master is, given history of intervals between its heartbeats, so jittery network
+// raises threshold by itself and stable one lets detect failure sooner.
+// Heartbeat is health check made by agent of master, timestamped by that agent, when manager sees
+// master alive too. So stalled manager (GC pause, slow dcs) does not make master look silent.
+type phiAccrual struct {
+	master    string
+	last      time.Time
+	intervals []time.Duration
+}
+
+// heartbeat records view of master observed at given time,
+// expected is interval of heartbeats used until history is collected
+func (d *phiAccrual) heartbeat(master string, alive bool, observedAt time.Time, expected time.Duration, windowSize int) {
+	if d.master != master {
+		*d = phiAccrual{
+			master:    master,
+			last:      observedAt,
+			intervals: []time.Duration{expected - expected/4, expected + expected/4},
+		}
+	}
+	if !alive {
+		return
+	}
+	// the same observation may be seen by several manager ticks
+	interval := observedAt.Sub(d.last)
+	if interval <= 0 {
+		return
+	}
+	d.intervals = append(d.intervals, interval)
+	if len(d.intervals) > windowSize {
+		d.intervals = d.intervals[len(d.intervals)-windowSize:]
+	}
+	d.last = observedAt
+}
+
+// phi returns suspicion level of master: phi of 1 means 10% chance that next heartbeat is still coming,
+// phi of 2 means 1% and so on. Intervals are assumed to be normally distributed.
+func (d *phiAccrual) phi(master string, now time.Time, minStdDeviation time.Duration) float64 {
+	if d.master != master || len(d.intervals) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, interval := range d.intervals {
+		sum += float64(interval)
+	}
+	mean := sum / float64(len(d.intervals))
+	var variance float64
+	for _, interval := range d.intervals {
+		variance += (float64(interval) - mean) * (float64(interval) - mean)
+	}
+	stdDeviation := math.Max(math.Sqrt(variance/float64(len(d.intervals))), float64(minStdDeviation))
+
+	// logistic approximation of normal cumulative distribution
+	y := (float64(now.Sub(d.last)) - mean) / stdDeviation
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if y > 0 {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// checkMasterDead returns error while master is not considered dead by configured failure detector.
+// failover_delay is applied by any detector, so phi accrual may only delay failover further
+func (app *App) checkMasterDead(master string, now time.Time) error {
+	if app.cfg().FailoverDelay > 0 {
+		failingTime := now.Sub(app.nodeFailedAt[master])
+		if failingTime < app.cfg().FailoverDelay {
+			return fmt.Errorf("failover delay is not yet elapsed: remaining %v", app.cfg().FailoverDelay-failingTime)
+		}
+	}
+	if app.cfg().FailureDetector == config.FailureDetectorPhiAccrual {
+		phi := app.failureDetector.phi(master, now, app.cfg().PhiMinStdDeviation)
+		if phi < app.cfg().PhiThreshold {
+			return fmt.Errorf("suspicion level of master is %.2f, while phi_threshold is %.2f", phi, app.cfg().PhiThreshold)
+		}
+		app.logger.Infof("failure detector: suspicion level of master %s is %.2f", master, phi)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestPhiAccrual(t *testing.T) {
+	var stable, jittery phiAccrual
+	now := time.Now()
+	for i := 0; i < 50; i++ {
+		now = now.Add(time.Second)
+		stable.heartbeat("mysql1", true, now, time.Second, 100)
+		jittery.heartbeat("mysql1", true, now.Add(time.Duration(i%2)*1500*time.Millisecond), time.Second, 100)
+	}
+	require.Len(t, stable.intervals, 51)
+
+	require.Less(t, stable.phi("mysql1", now.Add(time.Second), 100*time.Millisecond), 1.0)
+	// stable master is suspected sooner than jittery one
+	silence := now.Add(3 * time.Second)
+	require.Greater(t, stable.phi("mysql1", silence, 100*time.Millisecond), 8.0)
+	require.Less(t, jittery.phi("mysql1", silence, 100*time.Millisecond), 8.0)
+	// suspicion grows with silence
+	require.Greater(t, jittery.phi("mysql1", now.Add(10*time.Second), 100*time.Millisecond), 8.0)
+
+	// failed checks are not heartbeats
+	stable.heartbeat("mysql1", false, now.Add(time.Second), time.Second, 100)
+	require.Equal(t, now, stable.last)
+	// the same observation seen again is not a heartbeat
+	stable.heartbeat("mysql1", true, now, time.Second, 100)
+	require.Len(t, stable.intervals, 51)
+
+	// history is kept for current master only and within window
+	stable.heartbeat("mysql2", true, now, time.Second, 3)
+	require.Equal(t, 0.0, stable.phi("mysql1", now, time.Millisecond))
+	require.Len(t, stable.intervals, 2)
+	stable.heartbeat("mysql2", true, now.Add(time.Second), time.Second, 3)
+	stable.heartbeat("mysql2", true, now.Add(2*time.Second), time.Second, 3)
+	require.Len(t, stable.intervals, 3)
+}
+
+func TestCheckMasterDead(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.nodeFailedAt = make(map[string]time.Time)
+	now := time.Now()
+
+	app.nodeFailedAt["mysql1"] = now.Add(-10 * time.Second)
+	require.Error(t, app.checkMasterDead("mysql1", now))
+	require.NoError(t, app.checkMasterDead("mysql1", now.Add(time.Minute)))
+
+	app.cfg().FailureDetector = config.FailureDetectorPhiAccrual
+	for i := 0; i < 10; i++ {
+		app.failureDetector.heartbeat("mysql1", true, now.Add(time.Duration(i)*app.cfg().TickInterval), app.cfg().TickInterval, app.cfg().PhiWindowSize)
+	}
+	last := now.Add(9 * app.cfg().TickInterval)
+	require.Error(t, app.checkMasterDead("mysql1", last.Add(app.cfg().TickInterval)))
+	require.NoError(t, app.checkMasterDead("mysql1", last.Add(3*app.cfg().TickInterval)))
+
+	// failover_delay is a floor for phi accrual
+	app.nodeFailedAt["mysql1"] = last.Add(2 * app.cfg().TickInterval)
+	require.Error(t, app.checkMasterDead("mysql1", last.Add(3*app.cfg().TickInterval)))
+}
@@ -0,0 +1,310 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/log"
+)
+
+// selectorNamespace is selector key matching zookeeper namespace of cluster instead of its label
+const selectorNamespace = "namespace"
+
+// FleetOperation is applied by `mysync fleet` to each selected cluster with connected dcs.
+// Result should be either string or map of strings, e.g. host -> status
+type FleetOperation func(ctx context.Context, app *App) (interface{}, error)
+
+// selectorTerm matches label (or namespace) against glob pattern
+type selectorTerm struct {
+	key     string
+	pattern string
+	negate  bool
+}
+
+// clusterSelector is conjunction of terms, e.g. "env=prod,team!=billing,namespace=/mysync/eu-*"
+type clusterSelector []selectorTerm
+
+func parseClusterSelector(expr string) (clusterSelector, error) {
+	var selector clusterSelector
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var term selectorTerm
+		if i := strings.Index(part, "!="); i > 0 {
+			term = selectorTerm{key: part[:i], pattern: part[i+2:], negate: true}
+		} else if i := strings.Index(part, "="); i > 0 {
+			term = selectorTerm{key: part[:i], pattern: part[i+1:]}
+		} else {
+			return nil, fmt.Errorf("malformed selector term %q, expected key=pattern or key!=pattern", part)
+		}
+		term.key, term.pattern = strings.TrimSpace(term.key), strings.TrimSpace(term.pattern)
+		if _, err := path.Match(term.pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern in selector term %q: %v", part, err)
+		}
+		selector = append(selector, term)
+	}
+	return selector, nil
+}
+
+// matches checks all terms, missing label matches only negated terms
+func (s clusterSelector) matches(namespace string, labels map[string]string) bool {
+	for _, term := range s {
+		value, ok := labels[term.key]
+		if term.key == selectorNamespace {
+			value, ok = namespace, true
+		}
+		matched := false
+		if ok {
+			matched, _ = path.Match(term.pattern, value)
+		}
+		if matched == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// fleetResult is outcome of operation on one cluster
+type fleetResult struct {
+	Cluster   string      `json:"cluster" yaml:"cluster"`
+	Namespace string      `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Ok        bool        `json:"ok" yaml:"ok"`
+	Result    interface{} `json:"result,omitempty" yaml:"result,omitempty"`
+	Error     string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RunFleet applies operation to clusters of config files matching patterns and selector,
+// at most parallel clusters at once, and prints consolidated report.
+// Returns 1 if operation failed on any of clusters.
+func RunFleet(patterns []string, selector string, parallel int, logLevel, format string, op FleetOperation) int {
+	files, err := ExpandClusterConfigs(patterns)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	sel, err := parseClusterSelector(selector)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var results []*fleetResult
+	var apps []*App
+	for _, file := range files {
+		app, err := NewApp(file, logLevel, true)
+		if err != nil {
+			// cluster may match selector, so broken config is reported rather than skipped
+			results = append(results, &fleetResult{Cluster: file, Error: err.Error()})
+			continue
+		}
+		if !sel.matches(app.cfg().Zookeeper.Namespace, app.cfg().Labels) {
+			app.Close()
+			continue
+		}
+		apps = append(apps, app)
+	}
+	if len(apps) == 0 && len(results) == 0 {
+		fmt.Printf("no clusters match selector %q\n", selector)
+		return 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, parallel)
+	for _, app := range apps {
+		wg.Add(1)
+		go func(app *App) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result := app.runFleetOperation(ctx, op)
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, result)
+		}(app)
+	}
+	wg.Wait()
+	for _, app := range apps {
+		app.Close()
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+	return printFleetReport(results, format)
+}
+
+func (app *App) runFleetOperation(ctx context.Context, op FleetOperation) *fleetResult {
+	result := &fleetResult{Cluster: app.configFile, Namespace: app.cfg().Zookeeper.Namespace}
+	if ctx.Err() != nil {
+		result.Error = "interrupted"
+		return result
+	}
+	err := app.connectDCS()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+	result.Result, err = op(ctx, app)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Ok = true
+	return result
+}
+
+func printFleetReport(results []*fleetResult, format string) int {
+	failed := 0
+	for _, result := range results {
+		if !result.Ok {
+			failed++
+		}
+	}
+	rc := 0
+	if failed > 0 {
+		rc = 1
+	}
+	if format != "" {
+		logger, err := log.Open("", "Error")
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		app := &App{logger: logger}
+		if app.printCliOutput(results, format) != 0 {
+			return 1
+		}
+		return rc
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLUSTER\tNAMESPACE\tSTATUS\tRESULT")
+	for _, result := range results {
+		status, text := "ok", formatFleetResult(result.Result)
+		if !result.Ok {
+			status, text = "failed", result.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Cluster, result.Namespace, status, text)
+	}
+	_ = tw.Flush()
+	fmt.Printf("%d clusters, %d failed\n", len(results), failed)
+	return rc
+}
+
+func formatFleetResult(result interface{}) string {
+	switch v := result.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("%s: %s", key, v[key]))
+		}
+		return strings.Join(parts, "; ")
+	}
+	data, _ := yaml.Marshal(result)
+	return strings.TrimSpace(string(data))
+}
+
+// FleetEnableMaintenance enables maintenance on cluster
+func FleetEnableMaintenance(waitTimeout, duration time.Duration) FleetOperation {
+	return func(ctx context.Context, app *App) (interface{}, error) {
+		return app.enableMaintenance(ctx, waitTimeout, duration)
+	}
+}
+
+// FleetDisableMaintenance disables maintenance on cluster
+func FleetDisableMaintenance(waitTimeout time.Duration) FleetOperation {
+	return func(ctx context.Context, app *App) (interface{}, error) {
+		return app.disableMaintenance(ctx, waitTimeout)
+	}
+}
+
+// FleetVersions reports mysync and MySQL versions and config hash published by agents of cluster
+func FleetVersions() FleetOperation {
+	return func(_ context.Context, app *App) (interface{}, error) {
+		return app.fleetVersions(), nil
+	}
+}
+
+func (app *App) fleetVersions() map[string]string {
+	versions := make(map[string]string)
+	for _, host := range app.getKnownHosts() {
+		nodeState := new(NodeState)
+		err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, host), nodeState)
+		if err != nil {
+			versions[host] = "unknown"
+			continue
+		}
+		var parts []string
+		if nodeState.AgentState != nil && nodeState.AgentState.Version != "" {
+			parts = append(parts, "mysync "+nodeState.AgentState.Version)
+		}
+		if nodeState.MySQLVersion != nil {
+			parts = append(parts, "mysql "+nodeState.MySQLVersion.String())
+		}
+		if nodeState.AgentState != nil && nodeState.AgentState.ConfigHash != "" {
+			parts = append(parts, "config "+nodeState.AgentState.ConfigHash)
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "unknown")
+		}
+		versions[host] = strings.Join(parts, ", ")
+	}
+	return versions
+}
+
+// FleetPushConfig sets config overrides (key=value) on all hosts of cluster,
+// agents apply them on `mysync config reload` or restart
+func FleetPushConfig(set []string) FleetOperation {
+	return func(_ context.Context, app *App) (interface{}, error) {
+		return app.pushConfigOverrides(set)
+	}
+}
+
+func (app *App) pushConfigOverrides(set []string) (string, error) {
+	hosts := app.getKnownHosts()
+	if len(hosts) == 0 {
+		return "", fmt.Errorf("no hosts in cluster")
+	}
+	for _, host := range hosts {
+		overrides, err := app.getHostOverrides(host)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", host, err)
+		}
+		err = setOverrides(overrides, set)
+		if err != nil {
+			return "", err
+		}
+		err = app.saveHostOverrides(host, overrides)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", host, err)
+		}
+	}
+	return fmt.Sprintf("overrides saved on %d hosts, reload mysync to apply", len(hosts)), nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestClusterSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "billing"}
+	for expr, expected := range map[string]bool{
+		"":                              true,
+		"env=prod":                      true,
+		"env=prod, team=bill*":          true,
+		"env=test":                      false,
+		"team!=billing":                 false,
+		"owner!=dba":                    true,
+		"owner=*":                       false,
+		"namespace=/mysync/eu-*":        true,
+		"env=prod,namespace=/mysync/us": false,
+	} {
+		selector, err := parseClusterSelector(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, expected, selector.matches("/mysync/eu-1", labels), expr)
+	}
+	_, err := parseClusterSelector("env")
+	require.Error(t, err)
+	_, err = parseClusterSelector("env=[")
+	require.Error(t, err)
+}
+
+func TestFleetOperations(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	require.NoError(t, app.dcs.Create(pathHANodes, nil))
+	for _, host := range []string{"mysql1", "mysql2"} {
+		require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, host), mysql.NodeConfiguration{}))
+	}
+	require.NoError(t, app.dcs.Create(pathHealthPrefix, nil))
+	require.NoError(t, app.dcs.Set(dcs.JoinPath(pathHealthPrefix, "mysql1"), &NodeState{
+		AgentState:   &AgentState{Version: "1.2.3", ConfigHash: "abc"},
+		MySQLVersion: &mysql.Version{MajorVersion: 8, MinorVersion: 0, PatchVersion: 36},
+	}))
+	require.Equal(t, map[string]string{
+		"mysql1": "mysync 1.2.3, mysql 8.0.36, config abc",
+		"mysql2": "unknown",
+	}, app.fleetVersions())
+
+	_, err := app.pushConfigOverrides([]string{"offline_mode_enable_lag=10m"})
+	require.NoError(t, err)
+	_, err = app.pushConfigOverrides([]string{"no_such_key=1"})
+	require.Error(t, err)
+	// valid yaml, but agent would refuse to start with it
+	_, err = app.pushConfigOverrides([]string{"critical_disk_usage=200"})
+	require.Error(t, err)
+	for _, host := range []string{"mysql1", "mysql2"} {
+		overrides, err := app.getHostOverrides(host)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"offline_mode_enable_lag": "10m"}, overrides)
+	}
+}
@@ -0,0 +1,390 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	mysyncv1 "github.com/yandex/mysync/api/mysync/v1"
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// grpcEventsPollInterval is how often event history is checked for new events
+const grpcEventsPollInterval = time.Second
+
+// roles required to call gRPC methods
+var grpcMethodRoles = map[string]string{
+	mysyncv1.MySync_GetClusterState_FullMethodName:   config.APIRoleViewer,
+	mysyncv1.MySync_StreamEvents_FullMethodName:      config.APIRoleViewer,
+	mysyncv1.MySync_RequestSwitchover_FullMethodName: config.APIRoleOperator,
+	mysyncv1.MySync_SetMaintenance_FullMethodName:    config.APIRoleOperator,
+}
+
+type grpcClientKey struct{}
+
+// grpcService implements management API on top of dcs of the running agent
+type grpcService struct {
+	mysyncv1.UnimplementedMySyncServer
+	app *App
+}
+
+// grpcServer serves gRPC management API until ctx is done
+func (app *App) grpcServer(ctx context.Context) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(app.grpcUnaryAuth),
+		grpc.StreamInterceptor(app.grpcStreamAuth),
+	}
+	if app.agentTLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(app.agentTLS.serverConfig(app.cfg().APITLSRequireClientCert))))
+	}
+	server := grpc.NewServer(opts...)
+	mysyncv1.RegisterMySyncServer(server, &grpcService{app: app})
+	listener, err := net.Listen("tcp", app.cfg().GRPCListen)
+	if err != nil {
+		app.logger.Errorf("grpc: failed to listen on %s: %v", app.cfg().GRPCListen, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(apiShutdownTimeout):
+			server.Stop()
+		}
+	}()
+	app.logger.Infof("grpc: listening on %s", app.cfg().GRPCListen)
+	err = server.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		app.logger.Errorf("grpc: server failed: %v", err)
+	}
+}
+
+// grpcAuthorize authenticates caller the same way as agent API and checks its role
+func (app *App) grpcAuthorize(ctx context.Context, method string) (context.Context, error) {
+	client, ok := config.APITokenConfig{}, false
+	if p, found := peer.FromContext(ctx); found {
+		if info, isTLS := p.AuthInfo.(credentials.TLSInfo); isTLS {
+			client, ok = matchClientCert(app.cfg().APITokens, &info.State)
+		}
+	}
+	if !ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, authorization := range md.Get("authorization") {
+			if client, ok = matchToken(app.cfg().APITokens, authorization); ok {
+				break
+			}
+		}
+	}
+	if !ok {
+		app.logger.Warnf("grpc: unauthorized call of %s", method)
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	required, known := grpcMethodRoles[method]
+	if !known {
+		required = config.APIRoleAdmin
+	}
+	if !apiRoleAllows(client.Role, required) {
+		app.logger.Warnf("grpc: %s is not allowed to call %s, %s role is required", client.Name, method, required)
+		return nil, status.Errorf(codes.PermissionDenied, "%s role is required", required)
+	}
+	return context.WithValue(ctx, grpcClientKey{}, client), nil
+}
+
+func (app *App) grpcUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := app.grpcAuthorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// grpcAuthorizedStream passes context with authorized client to stream handler
+type grpcAuthorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcAuthorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (app *App) grpcStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := app.grpcAuthorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpcAuthorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+func grpcOperator(ctx context.Context, reason string) *Operator {
+	client, _ := ctx.Value(grpcClientKey{}).(config.APITokenConfig)
+	return &Operator{APIClient: client.Name, Reason: reason}
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampPtrOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestampOrNil(*t)
+}
+
+func operatorToProto(op *Operator) *mysyncv1.Operator {
+	if op == nil {
+		return nil
+	}
+	return &mysyncv1.Operator{User: op.User, ApiClient: op.APIClient, Reason: op.Reason}
+}
+
+func hostStateToProto(host string, ns *NodeState) *mysyncv1.HostState {
+	hs := &mysyncv1.HostState{
+		Host:       host,
+		CheckAt:    timestampOrNil(ns.CheckAt),
+		CheckBy:    ns.CheckBy,
+		PingOk:     ns.PingOk,
+		IsMaster:   ns.IsMaster,
+		IsReadOnly: ns.IsReadOnly,
+		IsOffline:  ns.IsOffline,
+		IsCascade:  ns.IsCascade,
+		Zone:       ns.Zone,
+		Error:      ns.Error,
+	}
+	if ns.MasterState != nil {
+		hs.GtidExecuted = ns.MasterState.ExecutedGtidSet
+	}
+	if ns.SlaveState != nil {
+		hs.GtidExecuted = ns.SlaveState.ExecutedGtidSet
+		hs.ReplicationState = ns.SlaveState.ReplicationState
+		hs.ReplicationSource = ns.SlaveState.MasterHost
+		hs.ReplicationLagSeconds = ns.SlaveState.ReplicationLag
+	}
+	return hs
+}
+
+func switchoverToProto(sw *Switchover) *mysyncv1.Switchover {
+	if sw == nil {
+		return nil
+	}
+	res := &mysyncv1.Switchover{
+		From:        sw.From,
+		To:          sw.To,
+		Cause:       sw.Cause,
+		InitiatedBy: sw.InitiatedBy,
+		InitiatedAt: timestampOrNil(sw.InitiatedAt),
+		StartedAt:   timestampOrNil(sw.StartedAt),
+		Operator:    operatorToProto(sw.Operator),
+	}
+	if sw.Result != nil {
+		ok := sw.Result.Ok
+		res.Ok = &ok
+		res.Error = sw.Result.Error
+		res.FinishedAt = timestampOrNil(sw.Result.FinishedAt)
+	}
+	return res
+}
+
+func maintenanceToProto(m *Maintenance) *mysyncv1.Maintenance {
+	if m == nil {
+		return &mysyncv1.Maintenance{}
+	}
+	return &mysyncv1.Maintenance{
+		Enabled:      true,
+		InitiatedBy:  m.InitiatedBy,
+		InitiatedAt:  timestampOrNil(m.InitiatedAt),
+		MysyncPaused: m.MySyncPaused,
+		ShouldLeave:  m.ShouldLeave,
+		ExpiresAt:    timestampPtrOrNil(m.ExpiresAt),
+		Operator:     operatorToProto(m.Operator),
+	}
+}
+
+func eventToProto(event HistoryEvent) *mysyncv1.Event {
+	res := &mysyncv1.Event{
+		Time:       timestampOrNil(event.Time),
+		Type:       event.Type,
+		Host:       event.Host,
+		Cause:      event.Cause,
+		Message:    event.Message,
+		RecordedBy: event.RecordedBy,
+		Operator:   operatorToProto(event.Operator),
+	}
+	if event.Duration > 0 {
+		res.Duration = durationpb.New(event.Duration)
+	}
+	return res
+}
+
+func (s *grpcService) GetClusterState(ctx context.Context, _ *mysyncv1.GetClusterStateRequest) (*mysyncv1.ClusterState, error) {
+	app := s.app
+	res := new(mysyncv1.ClusterState)
+	var err error
+	if res.Master, err = app.GetMasterHostFromDcs(); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	var manager dcs.LockOwner
+	if err = app.dcs.Get(pathManagerLock, &manager); err != nil && err != dcs.ErrNotFound {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	res.Manager = manager.Hostname
+	if res.ActiveNodes, err = app.GetActiveNodes(); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	clusterState, err := app.getClusterStateFromDcs()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	hosts := make([]string, 0, len(clusterState))
+	for host := range clusterState {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		res.Hosts = append(res.Hosts, hostStateToProto(host, clusterState[host]))
+	}
+	maintenance, err := app.GetMaintenance()
+	if err != nil && err != dcs.ErrNotFound {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	res.Maintenance = maintenanceToProto(maintenance)
+	var switchover Switchover
+	err = app.dcs.Get(pathCurrentSwitch, &switchover)
+	if err == nil {
+		res.Switchover = switchoverToProto(&switchover)
+	} else if err != dcs.ErrNotFound {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	lastSwitchover := app.GetLastSwitchover()
+	if !lastSwitchover.InitiatedAt.IsZero() {
+		res.LastSwitchover = switchoverToProto(&lastSwitchover)
+	}
+	return res, nil
+}
+
+func (s *grpcService) StreamEvents(req *mysyncv1.StreamEventsRequest, stream mysyncv1.MySync_StreamEventsServer) error {
+	app := s.app
+	if app.cfg().EventHistorySize == 0 {
+		return status.Error(codes.FailedPrecondition, "event history is disabled")
+	}
+	since := time.Now()
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	ticker := time.NewTicker(grpcEventsPollInterval)
+	defer ticker.Stop()
+	for {
+		history, err := app.GetEventHistory()
+		if err != nil {
+			app.logger.Warnf("grpc: failed to get events: %v", err)
+		}
+		for _, event := range history {
+			if !event.Time.After(since) {
+				continue
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+			since = event.Time
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *grpcService) RequestSwitchover(ctx context.Context, req *mysyncv1.RequestSwitchoverRequest) (*mysyncv1.Switchover, error) {
+	app := s.app
+	request, err := app.validateSwitchover(req.From, req.To)
+	switch {
+	case errors.Is(err, errSwitchoverInvalid):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errSwitchoverRejected), errors.Is(err, errSwitchoverNotNeeded):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	switchover := &Switchover{
+		From:        request.From,
+		To:          request.To,
+		Cause:       CauseManual,
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: time.Now(),
+		Operator:    grpcOperator(ctx, req.Reason),
+	}
+	err = app.dcs.Create(pathCurrentSwitch, switchover)
+	if err == dcs.ErrExists {
+		return nil, status.Error(codes.AlreadyExists, "another switchover in progress")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	app.logger.Infof("grpc: switchover %s => %s requested by %s", switchover.From, switchover.To, switchover.Operator)
+	return switchoverToProto(switchover), nil
+}
+
+func (s *grpcService) SetMaintenance(ctx context.Context, req *mysyncv1.SetMaintenanceRequest) (*mysyncv1.Maintenance, error) {
+	app := s.app
+	maintenance, err := app.GetMaintenance()
+	if err != nil && err != dcs.ErrNotFound {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if !req.Enabled {
+		if maintenance == nil {
+			return maintenanceToProto(nil), nil
+		}
+		maintenance.ShouldLeave = true
+		maintenance.DisabledBy = grpcOperator(ctx, req.Reason)
+		if err = app.dcs.Set(pathMaintenance, maintenance); err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		app.logger.Infof("grpc: maintenance disabling requested by %s", maintenance.DisabledBy)
+		return maintenanceToProto(maintenance), nil
+	}
+	if maintenance != nil {
+		if req.Duration != nil && req.Duration.AsDuration() > 0 {
+			if err = app.extendMaintenance(req.Duration.AsDuration()); err != nil {
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			}
+			maintenance.expireAfter(req.Duration.AsDuration())
+		}
+		return maintenanceToProto(maintenance), nil
+	}
+	maintenance = &Maintenance{
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: time.Now(),
+		Operator:    grpcOperator(ctx, req.Reason),
+	}
+	if req.Duration != nil && req.Duration.AsDuration() > 0 {
+		maintenance.expireAfter(req.Duration.AsDuration())
+	}
+	err = app.dcs.Create(pathMaintenance, maintenance)
+	if err != nil && err != dcs.ErrExists {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	app.logger.Infof("grpc: maintenance requested by %s", maintenance.Operator)
+	return maintenanceToProto(maintenance), nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	mysyncv1 "github.com/yandex/mysync/api/mysync/v1"
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestGRPCService(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().EventHistorySize = 10
+	app.cfg().APITokens = []config.APITokenConfig{
+		{Name: "ui", Token: "viewer-token", Role: config.APIRoleViewer},
+		{Name: "control-plane", Token: "operator-token", Role: config.APIRoleOperator},
+	}
+	store := app.dcs
+	defer store.Close()
+	for _, host := range []string{"mysql1", "mysql2"} {
+		require.NoError(t, store.Create(dcs.JoinPath(pathHANodes, host), mysql.NodeConfiguration{}))
+	}
+	require.NoError(t, store.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, store.Set(pathActiveNodes, []string{"mysql1", "mysql2"}))
+	require.NoError(t, store.Set(dcs.JoinPath(pathHealthPrefix, "mysql2"), NodeState{PingOk: true, SlaveState: &SlaveState{MasterHost: "mysql1", ReplicationState: "running"}}))
+	cluster, err := mysql.NewCluster(app.config, app.logger, store)
+	require.NoError(t, err)
+	defer cluster.Close()
+	require.NoError(t, cluster.UpdateHostsInfo())
+	app.cluster = cluster
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(app.grpcUnaryAuth), grpc.StreamInterceptor(app.grpcStreamAuth))
+	mysyncv1.RegisterMySyncServer(server, &grpcService{app: app})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := mysyncv1.NewMySyncClient(conn)
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	_, err = client.GetClusterState(context.Background(), &mysyncv1.GetClusterStateRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	state, err := client.GetClusterState(withToken("viewer-token"), &mysyncv1.GetClusterStateRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "mysql1", state.Master)
+	require.Equal(t, []string{"mysql1", "mysql2"}, state.ActiveNodes)
+	require.Len(t, state.Hosts, 2)
+	require.Equal(t, "mysql1", state.Hosts[1].ReplicationSource)
+	require.False(t, state.Maintenance.Enabled)
+
+	_, err = client.RequestSwitchover(withToken("viewer-token"), &mysyncv1.RequestSwitchoverRequest{To: "mysql2"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.RequestSwitchover(withToken("operator-token"), &mysyncv1.RequestSwitchoverRequest{To: "mysql1"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.RequestSwitchover(withToken("operator-token"), &mysyncv1.RequestSwitchoverRequest{From: "mysql1", To: "mysql2"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.RequestSwitchover(withToken("operator-token"), &mysyncv1.RequestSwitchoverRequest{To: "mysql3"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	sw, err := client.RequestSwitchover(withToken("operator-token"), &mysyncv1.RequestSwitchoverRequest{To: "mysql2", Reason: "kernel update"})
+	require.NoError(t, err)
+	require.Equal(t, "control-plane", sw.Operator.ApiClient)
+	var switchover Switchover
+	require.NoError(t, store.Get(pathCurrentSwitch, &switchover))
+	require.Equal(t, &Operator{APIClient: "control-plane", Reason: "kernel update"}, switchover.Operator)
+	_, err = client.RequestSwitchover(withToken("operator-token"), &mysyncv1.RequestSwitchoverRequest{From: "mysql1"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	maintenance, err := client.SetMaintenance(withToken("operator-token"), &mysyncv1.SetMaintenanceRequest{Enabled: true})
+	require.NoError(t, err)
+	require.True(t, maintenance.Enabled)
+	maintenance, err = client.SetMaintenance(withToken("operator-token"), &mysyncv1.SetMaintenanceRequest{Enabled: false})
+	require.NoError(t, err)
+	require.True(t, maintenance.ShouldLeave)
+
+	ctx, cancel := context.WithTimeout(withToken("viewer-token"), 5*time.Second)
+	defer cancel()
+	since := time.Now()
+	stream, err := client.StreamEvents(ctx, &mysyncv1.StreamEventsRequest{})
+	require.NoError(t, err)
+	app.recordEvent(HistoryEvent{Time: since.Add(-time.Hour), Type: EventRepair, Message: "old"})
+	app.recordEvent(HistoryEvent{Time: since.Add(time.Second), Type: EventSwitchover, Message: "new"})
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "new", event.Message)
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+func (app *App) getManagerHandoff() (*ManagerHandoff, error) {
+	handoff := new(ManagerHandoff)
+	err := app.dcs.Get(pathManagerHandoff, handoff)
+	if err != nil {
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// managerLockAllowed checks that pending handoff does not reserve manager lock for another agent
+func (app *App) managerLockAllowed() bool {
+	handoff, err := app.getManagerHandoff()
+	if err == dcs.ErrNotFound {
+		return true
+	}
+	if err != nil {
+		app.logger.Errorf("handoff: failed to get manager handoff: %v", err)
+		return true
+	}
+	return handoff.allowsLock(app.cfg().Hostname, time.Now())
+}
+
+// checkManagerHandoff is called by manager between cycles. It takes state passed by the former
+// manager or, if handoff from this host was requested, passes the state and releases the lock.
+// Returns true if the lock was released
+func (app *App) checkManagerHandoff() bool {
+	handoff, err := app.getManagerHandoff()
+	if err == dcs.ErrNotFound {
+		return false
+	}
+	if err != nil {
+		app.logger.Errorf("handoff: failed to get manager handoff: %v", err)
+		return false
+	}
+	host := app.cfg().Hostname
+	switch {
+	case handoff.ReleasedAt != nil && (handoff.From != host || handoff.To == host):
+		// handoff to this agent or restart of it
+		app.takeManagerHandoff(handoff)
+		return false
+	case handoff.From != host || handoff.IsExpired(time.Now()):
+		app.logger.Warnf("handoff: removing stale manager handoff %s", handoff)
+		app.removeManagerHandoff()
+		return false
+	case handoff.ReleasedAt != nil:
+		return false
+	}
+
+	err = app.dcs.Get(pathCurrentSwitch, new(Switchover))
+	if err == nil {
+		app.logger.Infof("handoff: postponed until switchover is finished")
+		return false
+	}
+	if err != dcs.ErrNotFound {
+		app.logger.Errorf("handoff: failed to get current switchover: %v", err)
+		return false
+	}
+
+	now := time.Now()
+	handoff.ReleasedAt = &now
+	handoff.NodeFailedAt = app.nodeFailedAt
+	handoff.StreamFromFailedAt = app.streamFromFailedAt
+	err = app.dcs.Set(pathManagerHandoff, handoff)
+	if err != nil {
+		app.logger.Errorf("handoff: failed to save manager state: %v", err)
+		return false
+	}
+	app.dcs.ReleaseLock(pathManagerLock)
+	app.logger.Infof("handoff: manager lock released %s", handoff)
+	to := handoff.To
+	if to == "" {
+		to = "any agent"
+	}
+	app.recordEvent(HistoryEvent{
+		Type:     EventManagerHandoff,
+		Host:     host,
+		Message:  fmt.Sprintf("manager lock passed from %s to %s", host, to),
+		Operator: handoff.Operator,
+	})
+	return true
+}
+
+// takeManagerHandoff restores state passed by the former manager
+func (app *App) takeManagerHandoff(handoff *ManagerHandoff) {
+	for host, t := range handoff.NodeFailedAt {
+		if _, ok := app.nodeFailedAt[host]; !ok {
+			app.nodeFailedAt[host] = t
+		}
+	}
+	for host, t := range handoff.StreamFromFailedAt {
+		if _, ok := app.streamFromFailedAt[host]; !ok {
+			app.streamFromFailedAt[host] = t
+		}
+	}
+	app.removeManagerHandoff()
+	app.logger.Infof("handoff: took manager lock from %s in %v", handoff.From, time.Since(*handoff.ReleasedAt))
+}
+
+func (app *App) removeManagerHandoff() {
+	err := app.dcs.Delete(pathManagerHandoff)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("handoff: failed to remove manager handoff: %v", err)
+	}
+}
+
+// CliManagerHandoff asks current manager to release the lock to another agent
+func (app *App) CliManagerHandoff(to string, waitTimeout time.Duration) int {
+	ctx := app.baseContext()
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	var manager dcs.LockOwner
+	err = app.dcs.Get(pathManagerLock, &manager)
+	if err == dcs.ErrNotFound {
+		app.logger.Error("there is no manager now")
+		return 1
+	}
+	if err != nil {
+		app.logger.Errorf("failed to get manager: %v", err)
+		return 1
+	}
+	if to == manager.Hostname {
+		app.logger.Errorf("%s is already manager", to)
+		return 1
+	}
+	if to != "" {
+		activeNodes, err := app.GetActiveNodes()
+		if err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+		if !util.ContainsString(activeNodes, to) {
+			app.logger.Errorf("%s is not active, it can't become manager", to)
+			return 1
+		}
+	}
+
+	now := time.Now()
+	handoff := &ManagerHandoff{
+		From:        manager.Hostname,
+		To:          to,
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: now,
+		ExpiresAt:   now.Add(app.cfg().ManagerHandoffTimeout),
+		Operator:    app.currentOperator(),
+	}
+	err = app.dcs.Create(pathManagerHandoff, handoff)
+	if err == dcs.ErrExists {
+		existing, err := app.getManagerHandoff()
+		if err == nil && !existing.IsExpired(now) {
+			app.logger.Errorf("another manager handoff is in progress: %s", existing)
+			return 2
+		}
+		err = app.dcs.Set(pathManagerHandoff, handoff)
+		if err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+	} else if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	if waitTimeout == 0 {
+		fmt.Println("manager handoff scheduled")
+		return 0
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			var owner dcs.LockOwner
+			err = app.dcs.Get(pathManagerLock, &owner)
+			if err != nil && err != dcs.ErrNotFound {
+				app.logger.Errorf("failed to get manager: %v", err)
+				continue
+			}
+			if owner.Hostname != "" && owner.Hostname != manager.Hostname {
+				fmt.Printf("manager moved from %s to %s\n", manager.Hostname, owner.Hostname)
+				return 0
+			}
+		case <-waitCtx.Done():
+			app.logger.Errorf("manager was not changed within %v", waitTimeout)
+			return 1
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestManagerHandoffAllowsLock(t *testing.T) {
+	now := time.Now()
+	handoff := &ManagerHandoff{From: "mysql1", ExpiresAt: now.Add(time.Minute)}
+	require.False(t, handoff.allowsLock("mysql1", now))
+	require.True(t, handoff.allowsLock("mysql2", now))
+
+	handoff.To = "mysql3"
+	require.False(t, handoff.allowsLock("mysql2", now))
+	require.True(t, handoff.allowsLock("mysql3", now))
+
+	// nobody took the lock in time
+	require.True(t, handoff.allowsLock("mysql1", now.Add(2*time.Minute)))
+}
+
+func TestManagerHandoff(t *testing.T) {
+	store := mysynctest.NewMemStore()
+	newApp := func(host string) *App {
+		app := newTestApp(t, host)
+		app.cfg().EventHistorySize = 0
+		app.dcs = store.Session(host)
+		app.nodeFailedAt = make(map[string]time.Time)
+		app.streamFromFailedAt = make(map[string]time.Time)
+		return app
+	}
+	manager, candidate, other := newApp("mysql1"), newApp("mysql2"), newApp("mysql3")
+	require.True(t, manager.dcs.AcquireLock(pathManagerLock))
+	failedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	manager.nodeFailedAt["mysql3"] = failedAt
+
+	// nothing requested
+	require.False(t, manager.checkManagerHandoff())
+
+	now := time.Now()
+	require.NoError(t, manager.dcs.Set(pathManagerHandoff, &ManagerHandoff{
+		From: "mysql1", To: "mysql2", InitiatedAt: now, ExpiresAt: now.Add(time.Minute),
+	}))
+	require.True(t, manager.checkManagerHandoff())
+
+	// lock is reserved for target
+	require.False(t, manager.managerLockAllowed())
+	require.False(t, other.managerLockAllowed())
+	require.True(t, candidate.managerLockAllowed())
+	require.True(t, candidate.dcs.AcquireLock(pathManagerLock))
+
+	require.False(t, candidate.checkManagerHandoff())
+	require.True(t, failedAt.Equal(candidate.nodeFailedAt["mysql3"]))
+	_, err := candidate.getManagerHandoff()
+	require.Error(t, err)
+	require.True(t, manager.managerLockAllowed())
+}
+
+func TestManagerHandoffStale(t *testing.T) {
+	app := newTestApp(t, "mysql2")
+	require.True(t, app.dcs.AcquireLock(pathManagerLock))
+
+	// handoff requested from agent, which is not manager anymore
+	now := time.Now()
+	require.NoError(t, app.dcs.Set(pathManagerHandoff, &ManagerHandoff{From: "mysql1", ExpiresAt: now.Add(time.Minute)}))
+	require.False(t, app.checkManagerHandoff())
+	_, err := app.getManagerHandoff()
+	require.Error(t, err)
+}
+
+func TestManagerRestart(t *testing.T) {
+	store := mysynctest.NewMemStore()
+	old := newTestApp(t, "mysql1")
+	old.dcs = store.Session("mysql1")
+	old.state = stateManager
+	old.nodeFailedAt = map[string]time.Time{"mysql2": time.Now().Truncate(time.Second)}
+	old.streamFromFailedAt = make(map[string]time.Time)
+	require.True(t, old.dcs.AcquireLock(pathManagerLock))
+	old.prepareRestart()
+	require.True(t, old.RestartRequested())
+	old.dcs.Close()
+
+	other := newTestApp(t, "mysql2")
+	other.dcs = store.Session("mysql2")
+	require.False(t, other.managerLockAllowed())
+
+	restarted := newTestApp(t, "mysql1")
+	restarted.dcs = store.Session("mysql1")
+	restarted.nodeFailedAt = make(map[string]time.Time)
+	restarted.streamFromFailedAt = make(map[string]time.Time)
+	require.True(t, restarted.managerLockAllowed())
+	require.True(t, restarted.dcs.AcquireLock(pathManagerLock))
+	require.False(t, restarted.checkManagerHandoff())
+	require.True(t, old.nodeFailedAt["mysql2"].Equal(restarted.nodeFailedAt["mysql2"]))
+	require.True(t, other.managerLockAllowed())
+}
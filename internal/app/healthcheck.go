@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// runHealthChecks runs user-defined SQL health checks on local node
+// and returns errors of failed checks by check name
+func (app *App) runHealthChecks() (failed map[string]string, critical bool) {
+	node := app.cluster.Local()
+	for _, check := range app.cfg().HealthChecks {
+		timeout := check.Timeout
+		if timeout == 0 {
+			timeout = app.cfg().DBTimeout
+		}
+		value, err := node.QueryValue(check.Query, timeout)
+		if err = checkHealthCheckResult(check, value, err); err == nil {
+			continue
+		}
+		app.logger.Warnf("health check %s failed: %v", check.Name, err)
+		if failed == nil {
+			failed = make(map[string]string)
+		}
+		failed[check.Name] = err.Error()
+		critical = critical || check.Critical
+	}
+	return failed, critical
+}
+
+func checkHealthCheckResult(check config.HealthCheckConfig, value string, err error) error {
+	if err != nil {
+		return err
+	}
+	if check.Expected != "" && strings.TrimSpace(value) != check.Expected {
+		return fmt.Errorf("got %q, expected %q", value, check.Expected)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestCheckHealthCheckResult(t *testing.T) {
+	check := config.HealthCheckConfig{Name: "heartbeat", Query: "SELECT 1", Expected: "1"}
+	require.NoError(t, checkHealthCheckResult(check, "1", nil))
+	require.Error(t, checkHealthCheckResult(check, "0", nil))
+	require.Error(t, checkHealthCheckResult(check, "1", errors.New("timeout")))
+
+	// without expected value any result is ok
+	check.Expected = ""
+	require.NoError(t, checkHealthCheckResult(check, "0", nil))
+}
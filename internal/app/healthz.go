@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// agentLiveness tracks progress of agent goroutines for health endpoints
+type agentLiveness struct {
+	loopTick    atomic.Int64
+	state       atomic.Value
+	phase       atomic.Value
+	phaseUntil  atomic.Int64
+	healthCheck atomic.Int64
+	mysqlAlive  atomic.Bool
+}
+
+func (l *agentLiveness) tickLoop(now time.Time, state appState) {
+	l.loopTick.Store(now.UnixNano())
+	l.state.Store(state)
+	l.phase.Store("")
+	l.phaseUntil.Store(0)
+}
+
+// enterPhase marks main loop as busy with long phase (e.g. of switchover),
+// which is expected to take up to budget, main loop is not reported stuck meanwhile
+func (l *agentLiveness) enterPhase(name string, now time.Time, budget time.Duration) {
+	l.loopTick.Store(now.UnixNano())
+	l.phase.Store(name)
+	l.phaseUntil.Store(now.Add(budget).UnixNano())
+}
+
+// tickPhase reports progress of waiting inside long phase, so main loop is known
+// to be alive even when waiting exceeds health_stale_timeout
+func (l *agentLiveness) tickPhase(now time.Time) {
+	l.loopTick.Store(now.UnixNano())
+}
+
+// currentPhase returns long phase main loop is busy with, if any
+func (l *agentLiveness) currentPhase() (string, time.Time) {
+	phase, _ := l.phase.Load().(string)
+	return phase, time.Unix(0, l.phaseUntil.Load())
+}
+
+func (l *agentLiveness) checkedHealth(now time.Time, mysqlAlive bool) {
+	l.healthCheck.Store(now.UnixNano())
+	l.mysqlAlive.Store(mysqlAlive)
+}
+
+// currentState returns state of main loop, it is safe to call from other goroutines
+func (l *agentLiveness) currentState() appState {
+	state, _ := l.state.Load().(appState)
+	return state
+}
+
+// agentHealth is a response of health endpoints
+type agentHealth struct {
+	Ok              bool      `json:"ok"`
+	State           appState  `json:"state"`
+	Phase           string    `json:"phase,omitempty"`
+	LastLoopTick    time.Time `json:"last_loop_tick"`
+	LastHealthCheck time.Time `json:"last_health_check"`
+	DCSConnected    bool      `json:"dcs_connected"`
+	MySQLAlive      bool      `json:"mysql_alive"`
+	Problems        []string  `json:"problems,omitempty"`
+}
+
+// checkAgentHealth reports whether agent is alive, and, if ready is set, whether it can manage cluster
+func (app *App) checkAgentHealth(now time.Time, ready bool) agentHealth {
+	health := agentHealth{
+		LastLoopTick:    time.Unix(0, app.liveness.loopTick.Load()),
+		LastHealthCheck: time.Unix(0, app.liveness.healthCheck.Load()),
+		DCSConnected:    app.dcs != nil && app.dcs.IsConnected(),
+		MySQLAlive:      app.liveness.mysqlAlive.Load(),
+	}
+	health.State = app.liveness.currentState()
+	timeout := app.cfg().HealthStaleTimeout
+	phase, phaseUntil := app.liveness.currentPhase()
+	health.Phase = phase
+	if now.Sub(health.LastLoopTick) > timeout && now.After(phaseUntil.Add(timeout)) {
+		if phase != "" {
+			health.Problems = append(health.Problems, fmt.Sprintf("main loop is stuck in %s", phase))
+		} else {
+			health.Problems = append(health.Problems, "main loop is stuck")
+		}
+	}
+	if ready {
+		if now.Sub(health.LastHealthCheck) > timeout {
+			health.Problems = append(health.Problems, "health check is stuck")
+		}
+		if !health.DCSConnected {
+			health.Problems = append(health.Problems, "dcs is not connected")
+		}
+		if !health.MySQLAlive {
+			health.Problems = append(health.Problems, "mysql is not reachable")
+		}
+	}
+	health.Ok = len(health.Problems) == 0
+	return health
+}
+
+func (app *App) healthHandler(ready bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := app.checkAgentHealth(time.Now(), ready)
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(health)
+	}
+}
+
+// registerHealthHandlers adds unauthenticated health and throttle endpoints to mux
+func (app *App) registerHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc(healthzPath, app.healthHandler(false))
+	mux.HandleFunc(readyzPath, app.healthHandler(true))
+	mux.HandleFunc(throttlePath, app.handleThrottle)
+}
+
+// healthServer serves health endpoints on dedicated address until ctx is done
+func (app *App) healthServer(ctx context.Context) {
+	mux := http.NewServeMux()
+	app.registerHealthHandlers(mux)
+	server := &http.Server{
+		Addr:              app.cfg().HealthListen,
+		Handler:           mux,
+		ReadHeaderTimeout: app.cfg().DBTimeout,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	app.logger.Infof("health: listening on %s", app.cfg().HealthListen)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		app.logger.Errorf("health: server failed: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestCheckAgentHealth(t *testing.T) {
+	app := &App{config: config.NewHolder(&config.Config{HealthStaleTimeout: time.Minute})}
+	now := time.Now()
+
+	health := app.checkAgentHealth(now, false)
+	require.False(t, health.Ok)
+	require.Equal(t, []string{"main loop is stuck"}, health.Problems)
+
+	app.liveness.tickLoop(now.Add(-10*time.Second), stateManager)
+	health = app.checkAgentHealth(now, false)
+	require.True(t, health.Ok)
+	require.Equal(t, appState(stateManager), health.State)
+
+	// long phase keeps main loop alive within its budget
+	app.liveness.enterPhase("switchover: catch up", now.Add(-2*time.Minute), 5*time.Minute)
+	health = app.checkAgentHealth(now, false)
+	require.True(t, health.Ok)
+	require.Equal(t, "switchover: catch up", health.Phase)
+	health = app.checkAgentHealth(now.Add(5*time.Minute), false)
+	require.Equal(t, []string{"main loop is stuck in switchover: catch up"}, health.Problems)
+	// waiting inside phase reports progress beyond its budget
+	app.liveness.tickPhase(now.Add(5 * time.Minute))
+	health = app.checkAgentHealth(now.Add(5*time.Minute), false)
+	require.True(t, health.Ok)
+	app.liveness.tickLoop(now.Add(-10*time.Second), stateManager)
+
+	app.liveness.checkedHealth(now, true)
+	health = app.checkAgentHealth(now, true)
+	require.False(t, health.Ok)
+	require.Equal(t, []string{"dcs is not connected"}, health.Problems)
+
+	app.liveness.checkedHealth(now.Add(-2*time.Minute), false)
+	health = app.checkAgentHealth(now, true)
+	require.Equal(t, []string{"health check is stuck", "dcs is not connected", "mysql is not reachable"}, health.Problems)
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+const (
+	EventSwitchover      = "switchover"
+	EventFailover        = "failover"
+	EventFailoverIssued  = "failover_issued"
+	EventRepair          = "repair"
+	EventMaintenanceOn   = "maintenance_on"
+	EventMaintenanceOff  = "maintenance_off"
+	EventResetupRequired = "resetup_required"
+	EventForcedPromotion = "forced_promotion"
+	EventManagerHandoff  = "manager_handoff"
+	EventRepairGivenUp   = "repair_given_up"
+	EventQuarantineOn    = "quarantine_on"
+	EventQuarantineOff   = "quarantine_off"
+	EventExternalSource  = "external_source_changed"
+	EventUpgradeOn       = "rolling_upgrade_on"
+	EventUpgradeOff      = "rolling_upgrade_off"
+	EventDriftCorrected  = "drift_corrected"
+	EventDrainOn         = "drain_on"
+	EventDrainOff        = "drain_off"
+	EventDrained         = "drained"
+	EventWarmup          = "warmup"
+	EventSemiSyncStall   = "semisync_stall"
+	EventRejoin          = "old_master_rejoin"
+	EventWorldChanged    = "changed_while_down"
+	EventFailoverBlocked = "failover_restricted"
+	EventReadOnlyBypass  = "read_only_bypassed"
+	EventLoadShedding    = "load_shedding"
+)
+
+// eventNodeFormat makes names of event nodes sort in order of events
+const eventNodeFormat = "20060102T150405.000000000Z"
+
+// maxEventNameAttempts limits retries of event node creation, when name is taken by event recorded at the same time
+const maxEventNameAttempts = 5
+
+// recordEvent appends event to the bounded history in dcs and notifies about it.
+// Failure to record event is logged but never interrupts the caller
+func (app *App) recordEvent(event HistoryEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.RecordedBy == "" {
+		event.RecordedBy = app.cfg().Hostname
+	}
+	event.ObserveOnly = event.ObserveOnly || app.cfg().ObserveOnly
+	app.notify(event)
+	app.emitEventMetrics(event)
+	if app.cfg().EventHistorySize == 0 {
+		return
+	}
+	err := app.saveEvent(event)
+	if err != nil {
+		app.logger.Errorf("history: failed to save event to dcs: %v", err)
+	}
+}
+
+// saveEvent stores event in its own node, creation of node never overwrites events of other writers.
+// The oldest events above event_history_size are removed
+func (app *App) saveEvent(event HistoryEvent) error {
+	err := app.dcs.Create(pathEventHistory, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s", event.Time.UTC().Format(eventNodeFormat), app.cfg().Hostname)
+	for i := 1; ; i++ {
+		err = app.dcs.Create(dcs.JoinPath(pathEventHistory, name), event)
+		if err != dcs.ErrExists || i == maxEventNameAttempts {
+			break
+		}
+		name = fmt.Sprintf("%s-%s-%d", event.Time.UTC().Format(eventNodeFormat), app.cfg().Hostname, i)
+	}
+	if err != nil {
+		return err
+	}
+	names, err := app.dcs.GetChildren(pathEventHistory)
+	if err != nil {
+		return err
+	}
+	for _, name := range eventsAboveSize(names, app.cfg().EventHistorySize) {
+		err = app.dcs.Delete(dcs.JoinPath(pathEventHistory, name))
+		if err != nil && err != dcs.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventsAboveSize returns names of the oldest events, which do not fit into history of size
+func eventsAboveSize(names []string, size int) []string {
+	if len(names) <= size {
+		return nil
+	}
+	sort.Strings(names)
+	return names[:len(names)-size]
+}
+
+// GetEventHistory returns recorded events, the oldest first
+func (app *App) GetEventHistory() ([]HistoryEvent, error) {
+	values, err := app.getChildrenValues(pathEventHistory)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	history := make([]HistoryEvent, 0, len(names))
+	for _, name := range names {
+		var event HistoryEvent
+		if err = json.Unmarshal(values[name], &event); err != nil {
+			app.logger.Warnf("history: malformed event %s: %v", name, err)
+			continue
+		}
+		history = append(history, event)
+	}
+	return history, nil
+}
+
+func (app *App) recordSwitchoverEvent(switchover *Switchover) {
+	event := HistoryEvent{Type: EventSwitchover, Host: switchover.From, Message: switchover.String(), Operator: switchover.Operator}
+	if switchover.Cause == CauseAuto {
+		event.Type = EventFailover
+	}
+	if switchover.FailoverCause != nil {
+		event.Cause = switchover.FailoverCause.Kind
+	}
+	if switchover.Result != nil {
+		if !switchover.StartedAt.IsZero() {
+			event.Duration = switchover.Result.FinishedAt.Sub(switchover.StartedAt)
+		}
+		if switchover.Result.Error != "" {
+			event.Message += ": " + switchover.Result.Error
+		}
+		event.WriteDowntime = switchover.Result.WriteDowntime
+		event.LostTransactions = switchover.Result.LostTransactionsCount
+		if switchover.Result.LostTransactions != "" {
+			event.Message += ", lost transactions: " + switchover.Result.LostTransactions
+		}
+	}
+	if switchover.dns != nil {
+		event.DNS = switchover.dns
+		event.Message += ", " + switchover.dns.String()
+	}
+	app.recordEvent(event)
+}
+
+// CliHistory prints recorded events
+func (app *App) CliHistory(since time.Duration, format string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	history, err := app.GetEventHistory()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	events := make([]HistoryEvent, 0, len(history))
+	for _, event := range history {
+		if since == 0 || time.Since(event.Time) <= since {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	if format != "" {
+		return app.printCliOutput(events, format)
+	}
+	fmt.Print(formatEvents(events))
+	return 0
+}
+
+func formatEvents(events []HistoryEvent) string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, event := range events {
+		duration := ""
+		if event.Duration > 0 {
+			duration = event.Duration.Round(time.Millisecond).String()
+		}
+		eventType := event.Type
+		if event.ObserveOnly {
+			eventType += " (observe-only)"
+		}
+		message := event.Message
+		if event.Operator != nil {
+			message += fmt.Sprintf(" [by %s]", event.Operator)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", event.Time.Format(time.RFC3339), eventType, event.Host, event.Cause, duration, message)
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
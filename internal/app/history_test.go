@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestEventsAboveSize(t *testing.T) {
+	require.Nil(t, eventsAboveSize([]string{"b", "a"}, 3))
+	require.Equal(t, []string{"a", "b"}, eventsAboveSize([]string{"d", "b", "c", "a"}, 2))
+}
+
+func TestRecordEventConcurrently(t *testing.T) {
+	store := mysynctest.NewMemStore()
+	var wg sync.WaitGroup
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		host := fmt.Sprintf("mysql%d", i)
+		app := newTestApp(t, host)
+		app.cfg().EventHistorySize = 8
+		app.dcs = store.Session(host)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// events of the same time from the same host are kept too
+			app.recordEvent(HistoryEvent{Type: EventRepair, Host: app.cfg().Hostname, Time: now.Add(time.Duration(i) * time.Second)})
+			app.recordEvent(HistoryEvent{Type: EventRepair, Host: app.cfg().Hostname, Time: now.Add(time.Duration(i) * time.Second)})
+		}(i)
+	}
+	wg.Wait()
+
+	app := newTestApp(t, "mysql1")
+	app.dcs = store.Session("mysql1")
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 8)
+	// the oldest events are dropped
+	require.Equal(t, "mysql1", history[0].Host)
+	require.Equal(t, "mysql4", history[7].Host)
+}
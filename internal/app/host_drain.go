@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// getDrainedHosts returns replicas being drained or already drained by operator
+func (app *App) getDrainedHosts() (map[string]*HostDrain, error) {
+	hosts, err := app.dcs.GetChildren(pathDrain)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	drained := make(map[string]*HostDrain)
+	for _, host := range hosts {
+		drain := new(HostDrain)
+		err = app.dcs.Get(dcs.JoinPath(pathDrain, host), drain)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		drained[host] = drain
+	}
+	return drained, nil
+}
+
+// drainExcludeUsers returns users whose connections do not prevent host from being taken down
+func (app *App) drainExcludeUsers() []string {
+	user, _ := app.cfg().MySQLCredentials()
+	return append([]string{user, app.cfg().MySQL.ReplicationUser, "system user", "event_scheduler"}, app.cfg().ExcludeUsers...)
+}
+
+// updateDrainProgress applies observed state of host to drain and returns true if it was changed.
+// Host is drained when it is offline and has no client connections.
+func updateDrainProgress(drain *HostDrain, offline bool, connections int, now time.Time) bool {
+	changed := drain.Connections != connections
+	drain.Connections = connections
+	isDrained := offline && connections == 0
+	if isDrained && drain.DrainedAt == nil {
+		drain.DrainedAt = &now
+		changed = true
+	} else if !isDrained && drain.DrainedAt != nil {
+		drain.DrainedAt = nil
+		changed = true
+	}
+	return changed
+}
+
+// repairDrainedHosts keeps drained replicas offline and tracks their client connections,
+// replication on them is repaired as usual
+func (app *App) repairDrainedHosts(clusterState map[string]*NodeState, master string) {
+	drained, err := app.getDrainedHosts()
+	if err != nil {
+		app.logger.Errorf("drain: failed to get drained hosts: %v", err)
+		return
+	}
+	for host, drain := range drained {
+		state := clusterState[host]
+		if host == master {
+			app.logger.Warnf("drain: %s is master and can't be drained, switch over first", host)
+			continue
+		}
+		if state == nil || !state.PingOk {
+			continue
+		}
+		node := app.cluster.Get(host)
+		if !state.IsOffline {
+			err = node.SetOffline()
+			if err != nil {
+				app.logger.Errorf("drain: failed to set %s offline: %v", host, err)
+			} else {
+				app.logger.Infof("drain: %s set offline", host)
+			}
+			continue
+		}
+		ids, err := node.GetClientProcessIDs(app.drainExcludeUsers())
+		if err != nil {
+			app.logger.Errorf("drain: failed to get client connections of %s: %v", host, err)
+			continue
+		}
+		wasDrained := drain.DrainedAt != nil
+		if !updateDrainProgress(drain, state.IsOffline, len(ids), time.Now()) {
+			continue
+		}
+		err = app.dcs.Set(dcs.JoinPath(pathDrain, host), drain)
+		if err != nil {
+			app.logger.Errorf("drain: failed to update drain of %s: %v", host, err)
+			continue
+		}
+		if drain.DrainedAt != nil && !wasDrained {
+			app.logger.Infof("drain: %s is drained and safe to take down", host)
+			app.recordEvent(HistoryEvent{Type: EventDrained, Host: host, Message: "no client connections left, safe to take down"})
+		}
+	}
+}
+
+// CliHostDrain takes replica out of read traffic and reports when it is safe to take down
+func (app *App) CliHostDrain(host string, waitTimeout time.Duration) int {
+	ctx := app.baseContext()
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	if !util.ContainsString(app.getKnownHosts(), host) {
+		app.logger.Errorf("host %s is not in cluster", host)
+		return 1
+	}
+	var master string
+	err = app.dcs.Get(pathMasterNode, &master)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("failed to get current master: %v", err)
+		return 1
+	}
+	if host == master {
+		app.logger.Errorf("%s is master and can't be drained, switch over first", host)
+		return 1
+	}
+
+	err = app.dcs.Create(pathDrain, nil)
+	if err != nil && err != dcs.ErrExists {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	drain := &HostDrain{StartedAt: time.Now(), Operator: app.currentOperator(), Connections: -1}
+	err = app.dcs.Create(dcs.JoinPath(pathDrain, host), drain)
+	if err == nil {
+		app.recordEvent(HistoryEvent{Type: EventDrainOn, Host: host, Message: "drain started", Operator: drain.Operator})
+	} else if err != dcs.ErrExists {
+		app.logger.Error(err.Error())
+		return 1
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		err = app.dcs.Get(dcs.JoinPath(pathDrain, host), drain)
+		if err != nil {
+			app.logger.Errorf("failed to get drain of %s: %v", host, err)
+			return 1
+		}
+		if drain.DrainedAt != nil {
+			fmt.Printf("%s is drained and safe to take down\n", host)
+			return 0
+		}
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if drain.Connections < 0 {
+				fmt.Printf("%s is being drained\n", host)
+			} else {
+				fmt.Printf("%s is being drained, %d connections left\n", host, drain.Connections)
+			}
+			if waitTimeout == 0 {
+				return 0
+			}
+			return 2
+		}
+	}
+}
+
+// CliHostUndrain returns drained replica to read traffic, once its replication lag allows it
+func (app *App) CliHostUndrain(host string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.dcs.Delete(dcs.JoinPath(pathDrain, host))
+	if err == dcs.ErrNotFound {
+		app.logger.Errorf("host %s is not drained", host)
+		return 1
+	}
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	app.recordEvent(HistoryEvent{Type: EventDrainOff, Host: host, Message: "drain removed", Operator: app.currentOperator()})
+	fmt.Printf("%s undrained\n", host)
+	return 0
+}
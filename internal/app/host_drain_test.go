@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+func TestUpdateDrainProgress(t *testing.T) {
+	now := time.Now()
+	drain := &HostDrain{StartedAt: now, Connections: -1}
+
+	require.True(t, updateDrainProgress(drain, false, 0, now))
+	require.Nil(t, drain.DrainedAt)
+
+	require.True(t, updateDrainProgress(drain, true, 3, now))
+	require.Nil(t, drain.DrainedAt)
+	require.False(t, updateDrainProgress(drain, true, 3, now))
+
+	require.True(t, updateDrainProgress(drain, true, 0, now))
+	require.NotNil(t, drain.DrainedAt)
+	require.False(t, updateDrainProgress(drain, true, 0, now.Add(time.Second)))
+	require.Equal(t, now, *drain.DrainedAt)
+
+	// host set online by someone is not safe to take down anymore
+	require.True(t, updateDrainProgress(drain, false, 0, now))
+	require.Nil(t, drain.DrainedAt)
+}
+
+func TestGetDrainedHosts(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	drained, err := app.getDrainedHosts()
+	require.NoError(t, err)
+	require.Empty(t, drained)
+
+	require.NoError(t, app.dcs.Create(pathDrain, nil))
+	require.NoError(t, app.dcs.Set(dcs.JoinPath(pathDrain, "mysql2"), &HostDrain{StartedAt: time.Now(), Connections: 2}))
+	drained, err = app.getDrainedHosts()
+	require.NoError(t, err)
+	require.Len(t, drained, 1)
+	require.Equal(t, 2, drained["mysql2"].Connections)
+	require.Contains(t, drained["mysql2"].String(), "2 connections left")
+}
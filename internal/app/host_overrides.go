@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+func (app *App) getHostOverrides(host string) (map[string]interface{}, error) {
+	overrides := make(map[string]interface{})
+	err := app.dcs.Get(dcs.JoinPath(pathHostOverrides, host), &overrides)
+	if err == dcs.ErrNotFound {
+		return overrides, nil
+	}
+	return overrides, err
+}
+
+// applyDCSHostOverrides applies overrides for local host stored in dcs on top of config file
+func (app *App) applyDCSHostOverrides(cfg *config.Config) error {
+	overrides, err := app.getHostOverrides(cfg.Hostname)
+	if err != nil {
+		return fmt.Errorf("failed to get host overrides from dcs: %v", err)
+	}
+	err = cfg.ApplyOverrides(overrides)
+	if err != nil {
+		return fmt.Errorf("invalid host overrides in dcs: %v", err)
+	}
+	if len(overrides) > 0 {
+		err = cfg.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid host overrides in dcs: %v", err)
+		}
+	}
+	return nil
+}
+
+// CliHostConfig prints and modifies config overrides of host stored in dcs
+func (app *App) CliHostConfig(host string, set, unset []string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	overrides, err := app.getHostOverrides(host)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	err = setOverrides(overrides, set)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	for _, key := range unset {
+		delete(overrides, key)
+	}
+	if len(set) > 0 || len(unset) > 0 {
+		err = app.saveHostOverrides(host, overrides)
+		if err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+		fmt.Printf("overrides of %s saved, run `mysync config reload` on it or restart mysync to apply\n", host)
+	}
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("%s: %v\n", key, overrides[key])
+	}
+	return 0
+}
+
+// setOverrides parses key=value pairs with yaml values into overrides
+func setOverrides(overrides map[string]interface{}, set []string) error {
+	for _, kv := range set {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("malformed override %q, expected key=value", kv)
+		}
+		var value interface{}
+		err := yaml.Unmarshal([]byte(parts[1]), &value)
+		if err != nil {
+			return fmt.Errorf("malformed value of %s: %v", parts[0], err)
+		}
+		overrides[parts[0]] = value
+	}
+	return nil
+}
+
+// saveHostOverrides validates overrides and stores them in dcs
+func (app *App) saveHostOverrides(host string, overrides map[string]interface{}) error {
+	// check overrides before saving, so agent does not fail to start on them
+	_, err := app.hostConfig(host, overrides)
+	if err != nil {
+		return fmt.Errorf("invalid overrides: %v", err)
+	}
+	err = app.dcs.Create(pathHostOverrides, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	return app.dcs.Set(dcs.JoinPath(pathHostOverrides, host), overrides)
+}
+
+// hostConfig returns validated config of host with overrides applied. It is built
+// from local config, as cluster-wide settings are the same on all hosts
+func (app *App) hostConfig(host string, overrides map[string]interface{}) (*config.Config, error) {
+	cfg := *app.cfg()
+	cfg.Hostname = host
+	err := cfg.ApplyOverrides(cfg.HostOverrides[host])
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.ApplyOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,296 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// roles of pod reflected in kubernetes role label
+const (
+	podRolePrimary = "primary"
+	podRoleReplica = "replica"
+)
+
+const (
+	mergePatchType          = "application/merge-patch+json"
+	strategicMergePatchType = "application/strategic-merge-patch+json"
+)
+
+// kubeClient is a minimal client of kubernetes API using service account of the pod
+type kubeClient struct {
+	cfg  config.KubernetesConfig
+	http *http.Client
+}
+
+func newKubeClient(cfg config.KubernetesConfig, timeout time.Duration) (*kubeClient, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA: %s", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+	}
+	return &kubeClient{
+		cfg: cfg,
+		http: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+func (c *kubeClient) request(ctx context.Context, method, path, contentType string, body interface{}) ([]byte, int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.cfg.APIServer, "/")+path, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	// bound service account tokens are rotated by kubelet, so token is read on each request
+	if c.cfg.TokenFile != "" {
+		token, err := os.ReadFile(c.cfg.TokenFile)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read kubernetes token: %s", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		return data, resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, resp.StatusCode, nil
+}
+
+func (c *kubeClient) podPath() string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", c.cfg.Namespace, c.cfg.PodName)
+}
+
+func (c *kubeClient) setPodLabel(ctx context.Context, label, value string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{label: value},
+		},
+	}
+	_, _, err := c.request(ctx, http.MethodPatch, c.podPath(), mergePatchType, patch)
+	return err
+}
+
+// setPodCondition updates condition of pod status, conditions are merged by type
+func (c *kubeClient) setPodCondition(ctx context.Context, condition string, ok bool, message string) error {
+	status := "False"
+	if ok {
+		status = "True"
+	}
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []map[string]string{{
+				"type":               condition,
+				"status":             status,
+				"message":            message,
+				"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+			}},
+		},
+	}
+	_, _, err := c.request(ctx, http.MethodPatch, c.podPath()+"/status", strategicMergePatchType, patch)
+	return err
+}
+
+func (c *kubeClient) podIP(ctx context.Context) (string, error) {
+	if c.cfg.PodIP != "" {
+		return c.cfg.PodIP, nil
+	}
+	data, _, err := c.request(ctx, http.MethodGet, c.podPath(), "", nil)
+	if err != nil {
+		return "", err
+	}
+	var pod struct {
+		Status struct {
+			PodIP string `json:"podIP"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(data, &pod); err != nil {
+		return "", err
+	}
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("pod %s has no IP yet", c.cfg.PodName)
+	}
+	return pod.Status.PodIP, nil
+}
+
+// setPrimaryEndpoints points endpoints to own pod, creating them if needed
+func (c *kubeClient) setPrimaryEndpoints(ctx context.Context, ip string, port int) error {
+	endpoints := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Endpoints",
+		"metadata": map[string]interface{}{
+			"name":      c.cfg.PrimaryEndpoints,
+			"namespace": c.cfg.Namespace,
+		},
+		"subsets": []map[string]interface{}{{
+			"addresses": []map[string]interface{}{{
+				"ip": ip,
+				"targetRef": map[string]string{
+					"kind":      "Pod",
+					"name":      c.cfg.PodName,
+					"namespace": c.cfg.Namespace,
+				},
+			}},
+			"ports": []map[string]interface{}{{"name": "mysql", "port": port, "protocol": "TCP"}},
+		}},
+	}
+	collection := fmt.Sprintf("/api/v1/namespaces/%s/endpoints", c.cfg.Namespace)
+	_, status, err := c.request(ctx, http.MethodPatch, collection+"/"+c.cfg.PrimaryEndpoints, mergePatchType, endpoints)
+	if status == http.StatusNotFound {
+		_, _, err = c.request(ctx, http.MethodPost, collection, "application/json", endpoints)
+	}
+	return err
+}
+
+// setConfigMapData replaces given keys of ConfigMap data, creating ConfigMap if needed
+func (c *kubeClient) setConfigMapData(ctx context.Context, name string, data map[string]string) error {
+	configMap := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": c.cfg.Namespace,
+		},
+		"data": data,
+	}
+	collection := fmt.Sprintf("/api/v1/namespaces/%s/configmaps", c.cfg.Namespace)
+	_, status, err := c.request(ctx, http.MethodPatch, collection+"/"+name, mergePatchType, configMap)
+	if status == http.StatusNotFound {
+		_, _, err = c.request(ctx, http.MethodPost, collection, "application/json", configMap)
+	}
+	return err
+}
+
+// kubernetesState is what was last successfully published to kubernetes
+type kubernetesState struct {
+	role      string
+	ready     *bool
+	primaryIP string
+}
+
+// podRole returns role of local node according to dcs.
+// Role is not known while dcs is unavailable, mysync itself protects former master in this case
+func (app *App) podRole() (string, bool) {
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Warnf("kubernetes: %v", err)
+		return "", false
+	}
+	if master == "" {
+		return "", false
+	}
+	if master == app.cfg().Hostname {
+		return podRolePrimary, true
+	}
+	return podRoleReplica, true
+}
+
+// kubernetesSyncer publishes role and readiness of local node to its pod until ctx is done
+func (app *App) kubernetesSyncer(ctx context.Context) {
+	client, err := newKubeClient(app.cfg().Kubernetes, app.cfg().DBTimeout)
+	if err != nil {
+		app.logger.Errorf("kubernetes: %v", err)
+		return
+	}
+	var state kubernetesState
+	ticker := time.NewTicker(app.cfg().Kubernetes.SyncInterval)
+	defer ticker.Stop()
+	for {
+		app.kubernetesSync(ctx, client, &state)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (app *App) kubernetesSync(ctx context.Context, client *kubeClient, state *kubernetesState) {
+	cfg := app.cfg().Kubernetes
+	if app.cfg().ObserveOnly {
+		if role, ok := app.podRole(); ok && role != state.role {
+			app.logger.Infof("observe-only: would label pod %s as %s", cfg.PodName, role)
+			state.role = role
+		}
+		return
+	}
+	if cfg.ReadinessGate != "" {
+		health := app.checkAgentHealth(time.Now(), true)
+		if state.ready == nil || *state.ready != health.Ok {
+			err := client.setPodCondition(ctx, cfg.ReadinessGate, health.Ok, strings.Join(health.Problems, "; "))
+			if err != nil {
+				app.logger.Errorf("kubernetes: failed to update readiness gate: %v", err)
+			} else {
+				state.ready = &health.Ok
+			}
+		}
+	}
+
+	role, ok := app.podRole()
+	if !ok {
+		return
+	}
+	if cfg.RoleLabel != "" && role != state.role {
+		if err := client.setPodLabel(ctx, cfg.RoleLabel, role); err != nil {
+			app.logger.Errorf("kubernetes: failed to set pod role: %v", err)
+		} else {
+			app.logger.Infof("kubernetes: pod %s labeled as %s", cfg.PodName, role)
+			state.role = role
+		}
+	}
+	if cfg.PrimaryEndpoints == "" || role != podRolePrimary {
+		state.primaryIP = ""
+		return
+	}
+	ip, err := client.podIP(ctx)
+	if err != nil {
+		app.logger.Errorf("kubernetes: failed to get pod IP: %v", err)
+		return
+	}
+	if ip == state.primaryIP {
+		return
+	}
+	if err := client.setPrimaryEndpoints(ctx, ip, app.cfg().MySQL.Port); err != nil {
+		app.logger.Errorf("kubernetes: failed to update endpoints %s: %v", cfg.PrimaryEndpoints, err)
+		return
+	}
+	app.logger.Infof("kubernetes: endpoints %s point to %s", cfg.PrimaryEndpoints, ip)
+	state.primaryIP = ip
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestKubernetesSync(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		requests[r.Method+" "+r.URL.Path] = body
+		if r.URL.Path == "/api/v1/namespaces/db/endpoints/mysql-primary" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	cfg := config.KubernetesConfig{
+		Enabled:          true,
+		Namespace:        "db",
+		PodName:          "mysql-0",
+		PodIP:            "10.0.0.1",
+		RoleLabel:        "mysync/role",
+		PrimaryEndpoints: "mysql-primary",
+		APIServer:        server.URL,
+	}
+	app := newTestApp(t, "mysql-0")
+	app.cfg().Kubernetes = cfg
+	app.cfg().MySQL.Port = 3306
+	dcs := app.dcs
+	defer dcs.Close()
+	client, err := newKubeClient(cfg, time.Second)
+	require.NoError(t, err)
+
+	var state kubernetesState
+	// no master yet
+	app.kubernetesSync(context.Background(), client, &state)
+	require.Empty(t, requests)
+
+	require.NoError(t, dcs.Set(pathMasterNode, "mysql-0"))
+	app.kubernetesSync(context.Background(), client, &state)
+	labels := requests["PATCH /api/v1/namespaces/db/pods/mysql-0"]["metadata"].(map[string]interface{})["labels"]
+	require.Equal(t, map[string]interface{}{"mysync/role": "primary"}, labels)
+	require.Contains(t, requests, "POST /api/v1/namespaces/db/endpoints")
+	require.Equal(t, podRolePrimary, state.role)
+	require.Equal(t, "10.0.0.1", state.primaryIP)
+
+	requests = map[string]map[string]interface{}{}
+	require.NoError(t, dcs.Set(pathMasterNode, "mysql-1"))
+	app.kubernetesSync(context.Background(), client, &state)
+	labels = requests["PATCH /api/v1/namespaces/db/pods/mysql-0"]["metadata"].(map[string]interface{})["labels"]
+	require.Equal(t, map[string]interface{}{"mysync/role": "replica"}, labels)
+	require.NotContains(t, requests, "POST /api/v1/namespaces/db/endpoints")
+}
+
+func TestKubernetesSyncObserveOnly(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	cfg := config.KubernetesConfig{
+		Enabled:          true,
+		Namespace:        "db",
+		PodName:          "mysql-0",
+		PodIP:            "10.0.0.1",
+		RoleLabel:        "mysync/role",
+		ReadinessGate:    "mysync/ready",
+		PrimaryEndpoints: "mysql-primary",
+		APIServer:        server.URL,
+	}
+	app := newTestApp(t, "mysql-0")
+	app.cfg().Kubernetes = cfg
+	app.cfg().ObserveOnly = true
+	defer app.dcs.Close()
+	client, err := newKubeClient(cfg, time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql-0"))
+	var state kubernetesState
+	app.kubernetesSync(context.Background(), client, &state)
+	require.Equal(t, int32(0), requests.Load())
+	require.Equal(t, podRolePrimary, state.role)
+}
+
+func TestKubernetesHostname(t *testing.T) {
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	cfg.Kubernetes.Enabled = true
+	cfg.Kubernetes.Namespace = "db"
+	cfg.Kubernetes.PodName = "mysql-0"
+	cfg.Kubernetes.Service = "mysql"
+	cfg.SetDynamicDefaults()
+	require.Equal(t, "mysql-0.mysql.db.svc.cluster.local", cfg.Hostname)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type lagSample struct {
+	at  time.Time
+	lag float64
+}
+
+// lagHistory keeps replication lag samples of hosts observed by manager,
+// so decisions are not made on single reading flapping around threshold
+type lagHistory map[string][]lagSample
+
+// add stores lag sample of host and forgets samples older than window
+func (h lagHistory) add(host string, lag float64, now time.Time, window time.Duration) {
+	samples := append(h[host], lagSample{at: now, lag: lag})
+	i := 0
+	for i < len(samples) && now.Sub(samples[i].at) > window {
+		i++
+	}
+	h[host] = samples[i:]
+}
+
+// quantile returns q-quantile of lag samples of host by nearest-rank method
+func (h lagHistory) quantile(host string, q float64) (float64, bool) {
+	samples := h[host]
+	if len(samples) == 0 {
+		return 0, false
+	}
+	lags := make([]float64, len(samples))
+	for i, sample := range samples {
+		lags[i] = sample.lag
+	}
+	sort.Float64s(lags)
+	rank := int(math.Ceil(q*float64(len(lags)))) - 1
+	return lags[max(rank, 0)], true
+}
+
+// recordLagHistory stores current lag of replicas, hosts of unknown lag lose their history
+func (app *App) recordLagHistory(clusterState map[string]*NodeState, now time.Time) {
+	if app.lagHistory == nil {
+		app.lagHistory = make(lagHistory)
+	}
+	for host := range app.lagHistory {
+		if _, ok := clusterState[host]; !ok {
+			delete(app.lagHistory, host)
+		}
+	}
+	for host, state := range clusterState {
+		if state == nil || state.SlaveState == nil || state.SlaveState.ReplicationLag == nil {
+			delete(app.lagHistory, host)
+			continue
+		}
+		app.lagHistory.add(host, *state.SlaveState.ReplicationLag, now, app.cfg().LagHistoryWindow)
+	}
+}
+
+// smoothedLag returns lag_quantile of host lag over lag_history_window,
+// or current lag if smoothing is off or history is not collected
+func (app *App) smoothedLag(host string, state *NodeState) *float64 {
+	if state == nil || state.SlaveState == nil || state.SlaveState.ReplicationLag == nil {
+		return nil
+	}
+	if app.cfg().LagQuantile == 0 {
+		return state.SlaveState.ReplicationLag
+	}
+	lag, ok := app.lagHistory.quantile(host, app.cfg().LagQuantile)
+	if !ok {
+		return state.SlaveState.ReplicationLag
+	}
+	return &lag
+}
+
+// withSmoothedLag returns copy of host state with lag replaced by smoothed one
+func (app *App) withSmoothedLag(host string, state *NodeState) *NodeState {
+	lag := app.smoothedLag(host, state)
+	if lag == nil || lag == state.SlaveState.ReplicationLag {
+		return state
+	}
+	smoothed := *state
+	slaveState := *state.SlaveState
+	slaveState.ReplicationLag = lag
+	smoothed.SlaveState = &slaveState
+	return &smoothed
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLagHistoryQuantile(t *testing.T) {
+	h := make(lagHistory)
+	now := time.Now()
+	_, ok := h.quantile("mysql2", 0.95)
+	require.False(t, ok)
+
+	for i := 1; i <= 20; i++ {
+		h.add("mysql2", float64(i), now.Add(time.Duration(i)*time.Second), time.Minute)
+	}
+	lag, ok := h.quantile("mysql2", 0.95)
+	require.True(t, ok)
+	require.Equal(t, 19.0, lag)
+	lag, _ = h.quantile("mysql2", 0.5)
+	require.Equal(t, 10.0, lag)
+
+	// old samples expire
+	h.add("mysql2", 0, now.Add(71*time.Second), time.Minute)
+	require.Len(t, h["mysql2"], 11)
+	lag, _ = h.quantile("mysql2", 1)
+	require.Equal(t, 20.0, lag)
+}
+
+func TestSmoothedLag(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	state := func(lag float64) map[string]*NodeState {
+		return map[string]*NodeState{
+			"mysql1": {IsMaster: true},
+			"mysql2": {SlaveState: &SlaveState{ReplicationLag: &lag}},
+		}
+	}
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		app.recordLagHistory(state(100), now.Add(time.Duration(i)*time.Second))
+	}
+	current := state(1)
+	app.recordLagHistory(current, now.Add(10*time.Second))
+
+	// single good reading is trusted without smoothing
+	require.Equal(t, 1.0, *app.smoothedLag("mysql2", current["mysql2"]))
+
+	app.cfg().LagQuantile = 0.95
+	require.Equal(t, 100.0, *app.smoothedLag("mysql2", current["mysql2"]))
+	smoothed := app.withSmoothedLag("mysql2", current["mysql2"])
+	require.Equal(t, 100.0, *smoothed.SlaveState.ReplicationLag)
+	require.Equal(t, 1.0, *current["mysql2"].SlaveState.ReplicationLag)
+	require.Nil(t, app.smoothedLag("mysql1", current["mysql1"]))
+
+	// host of unknown lag loses its history
+	app.recordLagHistory(map[string]*NodeState{"mysql2": {}}, now.Add(11*time.Second))
+	require.Empty(t, app.lagHistory["mysql2"])
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// separate goroutine publishing local view of cluster hosts liveness
+func (app *App) livenessChecker(ctx context.Context) {
+	ticker := time.NewTicker(app.cfg().LivenessCheckInterval)
+	for {
+		select {
+		case <-ticker.C:
+			observation := app.observeLiveness()
+			err := app.dcs.SetEphemeral(dcs.JoinPath(pathLiveness, app.cfg().Hostname), observation)
+			if err != nil {
+				app.logger.Errorf("liveness: failed to set observation to dcs: %s", err)
+			}
+			// observations of other agents are followed all the time,
+			// so their freshness is known when failover needs them
+			_, err = app.getLivenessObservations()
+			if err != nil {
+				app.logger.Warnf("liveness: failed to get observations from dcs: %s", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// livenessSeen remembers when observations of other agents were seen changing.
+// CheckAt is set by clock of observer, so it can't be compared with local time
+type livenessSeen struct {
+	mu       sync.Mutex
+	observed map[string]livenessSeenAt
+}
+
+type livenessSeenAt struct {
+	checkAt time.Time
+	seenAt  time.Time
+}
+
+// update sets SeenAt of observation. Observation seen for the first time gets zero SeenAt
+// and stays stale until it changes, as it is unknown how long ago it was made
+func (s *livenessSeen) update(observer string, observation *LivenessObservation, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.observed == nil {
+		s.observed = make(map[string]livenessSeenAt)
+	}
+	last, ok := s.observed[observer]
+	if !ok {
+		last = livenessSeenAt{checkAt: observation.CheckAt}
+	} else if !last.checkAt.Equal(observation.CheckAt) {
+		last = livenessSeenAt{checkAt: observation.CheckAt, seenAt: now}
+	}
+	s.observed[observer] = last
+	observation.SeenAt = last.seenAt
+}
+
+func (app *App) observeLiveness() *LivenessObservation {
+	observation := &LivenessObservation{
+		CheckAt: time.Now(),
+		Alive:   make(map[string]bool),
+	}
+	var mu sync.Mutex
+	util.RunParallel(func(host string) error {
+		node := app.cluster.Get(host)
+		if node == nil {
+			return nil
+		}
+		ok, err := node.Ping()
+		mu.Lock()
+		observation.Alive[host] = ok && err == nil
+		mu.Unlock()
+		return err
+	}, app.cluster.AllNodeHosts())
+	return observation
+}
+
+// countLivenessVotes returns number of fresh observations from other agents
+// reporting host as dead and as alive. Opinions of host itself and of manager (self) do not count,
+// so flapping network of manager is not able to trigger failover alone
+func countLivenessVotes(observations map[string]*LivenessObservation, host, self string, maxAge time.Duration) (dead, alive int) {
+	for observer, observation := range observations {
+		if observer == host || observer == self || observation == nil {
+			continue
+		}
+		if time.Since(observation.SeenAt) > maxAge {
+			continue
+		}
+		isAlive, ok := observation.Alive[host]
+		if !ok {
+			continue
+		}
+		if isAlive {
+			alive++
+		} else {
+			dead++
+		}
+	}
+	return dead, alive
+}
+
+func (app *App) getLivenessObservations() (map[string]*LivenessObservation, error) {
+	observers, err := app.dcs.GetChildren(pathLiveness)
+	if err == dcs.ErrNotFound {
+		return map[string]*LivenessObservation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	observations := make(map[string]*LivenessObservation)
+	for _, observer := range observers {
+		observation := new(LivenessObservation)
+		err = app.dcs.Get(dcs.JoinPath(pathLiveness, observer), observation)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		app.livenessSeen.update(observer, observation, time.Now())
+		observations[observer] = observation
+	}
+	return observations, nil
+}
+
+// checkLivenessQuorum returns error unless enough other agents agree that host is dead
+func (app *App) checkLivenessQuorum(host string) error {
+	if app.cfg().LivenessQuorum == 0 {
+		return nil
+	}
+	observations, err := app.getLivenessObservations()
+	if err != nil {
+		return fmt.Errorf("failed to get liveness observations: %v", err)
+	}
+	maxAge := 3 * app.cfg().LivenessCheckInterval
+	dead, alive := countLivenessVotes(observations, host, app.cfg().Hostname, maxAge)
+	if dead < app.cfg().LivenessQuorum && app.cfg().APITLSCAFile != "" {
+		app.crossCheckLiveness(observations, host, maxAge)
+		dead, alive = countLivenessVotes(observations, host, app.cfg().Hostname, maxAge)
+	}
+	if dead < app.cfg().LivenessQuorum {
+		return fmt.Errorf("only %d agents see %s dead (%d see it alive), while %d is required", dead, host, alive, app.cfg().LivenessQuorum)
+	}
+	app.logger.Infof("liveness: %d agents see %s dead (%d see it alive)", dead, host, alive)
+	return nil
+}
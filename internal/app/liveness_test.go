@@ -0,0 +1,71 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountLivenessVotes(t *testing.T) {
+	now := time.Now()
+	observations := map[string]*LivenessObservation{
+		"A": {SeenAt: now, Alive: map[string]bool{"A": true, "B": false, "C": true}},
+		"B": {SeenAt: now, Alive: map[string]bool{"A": true, "B": true, "C": true}},
+		"C": {SeenAt: now, Alive: map[string]bool{"A": false, "B": false, "C": true}},
+		// stale observation should be ignored
+		"D": {SeenAt: now.Add(-time.Hour), Alive: map[string]bool{"A": false, "B": false, "C": false}},
+	}
+
+	// host's own opinion does not count
+	dead, alive := countLivenessVotes(observations, "B", "", time.Minute)
+	require.Equal(t, 2, dead)
+	require.Equal(t, 0, alive)
+
+	dead, alive = countLivenessVotes(observations, "A", "", time.Minute)
+	require.Equal(t, 1, dead)
+	require.Equal(t, 1, alive)
+
+	dead, alive = countLivenessVotes(observations, "C", "", time.Minute)
+	require.Equal(t, 0, dead)
+	require.Equal(t, 2, alive)
+
+	dead, alive = countLivenessVotes(observations, "unknown", "", time.Minute)
+	require.Equal(t, 0, dead)
+	require.Equal(t, 0, alive)
+
+	// manager's own opinion does not count either
+	dead, alive = countLivenessVotes(observations, "B", "C", time.Minute)
+	require.Equal(t, 1, dead)
+	require.Equal(t, 0, alive)
+}
+
+func TestLivenessSeenIgnoresClockSkew(t *testing.T) {
+	now := time.Now()
+	var seen livenessSeen
+	// clock of A is an hour ahead, clock of B is an hour behind
+	ahead := &LivenessObservation{CheckAt: now.Add(time.Hour), Alive: map[string]bool{"C": false}}
+	behind := &LivenessObservation{CheckAt: now.Add(-time.Hour), Alive: map[string]bool{"C": false}}
+	seen.update("A", ahead, now)
+	seen.update("B", behind, now)
+	observations := map[string]*LivenessObservation{"A": ahead, "B": behind}
+
+	// age of observations seen for the first time is unknown
+	dead, _ := countLivenessVotes(observations, "C", "", time.Minute)
+	require.Equal(t, 0, dead)
+
+	// both agents keep updating their observations
+	ahead = &LivenessObservation{CheckAt: now.Add(time.Hour + time.Second), Alive: map[string]bool{"C": false}}
+	behind = &LivenessObservation{CheckAt: now.Add(-time.Hour + time.Second), Alive: map[string]bool{"C": false}}
+	seen.update("A", ahead, now)
+	seen.update("B", behind, now)
+	observations = map[string]*LivenessObservation{"A": ahead, "B": behind}
+	dead, _ = countLivenessVotes(observations, "C", "", time.Minute)
+	require.Equal(t, 2, dead)
+
+	// A stopped updating its observation, which still looks recent by its clock
+	later := now.Add(2 * time.Minute)
+	ahead = &LivenessObservation{CheckAt: now.Add(time.Hour + time.Second), Alive: map[string]bool{"C": false}}
+	seen.update("A", ahead, later)
+	require.Equal(t, now, ahead.SeenAt)
+}
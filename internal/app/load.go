@@ -0,0 +1,128 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// loadAwareOffline checks that host load is considered in offline_mode decisions
+func (app *App) loadAwareOffline() bool {
+	return app.cfg().OfflineModeEnableThreadsRunning > 0 || app.cfg().OfflineModeEnableCPULoad > 0
+}
+
+// getLocalLoadState collects load signals of local host
+func (app *App) getLocalLoadState(node *mysql.Node) *LoadState {
+	load := new(LoadState)
+	threads, err := node.GetThreadsRunning()
+	if err != nil {
+		app.logger.Errorf("load: failed to get threads running: %v", err)
+		return nil
+	}
+	load.ThreadsRunning = threads
+	if app.cfg().OfflineModeEnableCPULoad > 0 {
+		load.CPULoad, err = util.GetCPULoad()
+		if err != nil {
+			app.logger.Errorf("load: failed to get cpu load: %v", err)
+			return nil
+		}
+	}
+	return load
+}
+
+// hostLoad returns load published by agent of host, if it is fresh enough to act on
+func (app *App) hostLoad(state *NodeState) *LoadState {
+	if !app.loadAwareOffline() || state == nil || state.Load == nil {
+		return nil
+	}
+	if time.Since(state.CheckAt) > app.cfg().HealthStaleTimeout {
+		return nil
+	}
+	return state.Load
+}
+
+// loadOfflineDecision returns whether replica is overloaded and should be taken out of rotation,
+// and whether its load allows to return it back. Disable thresholds default to half of enable ones,
+// so load dropped after clients left does not return replica right back.
+// Unknown load neither takes replica offline nor keeps it there.
+func (app *App) loadOfflineDecision(load *LoadState) (overloaded, relieved bool) {
+	if load == nil {
+		return false, true
+	}
+	relieved = true
+	if enable := app.cfg().OfflineModeEnableThreadsRunning; enable > 0 {
+		disable := app.cfg().OfflineModeDisableThreadsRunning
+		if disable == 0 {
+			disable = enable / 2
+		}
+		overloaded = overloaded || load.ThreadsRunning >= enable
+		relieved = relieved && load.ThreadsRunning < disable
+	}
+	if enable := app.cfg().OfflineModeEnableCPULoad; enable > 0 {
+		disable := app.cfg().OfflineModeDisableCPULoad
+		if disable == 0 {
+			disable = enable / 2
+		}
+		overloaded = overloaded || load.CPULoad >= enable
+		relieved = relieved && load.CPULoad < disable
+	}
+	return overloaded, relieved
+}
+
+// getLoadOfflineHosts returns replicas taken out of rotation because of their load
+func (app *App) getLoadOfflineHosts() (map[string]*LoadOffline, error) {
+	values, err := app.getChildrenValues(pathLoadOffline)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]*LoadOffline)
+	for host, value := range values {
+		offline := new(LoadOffline)
+		if err = json.Unmarshal(value, offline); err != nil {
+			return nil, fmt.Errorf("malformed load offline of %s: %v", host, err)
+		}
+		hosts[host] = offline
+	}
+	return hosts, nil
+}
+
+// setLoadOffline saves load of replica measured before it is taken out of rotation
+func (app *App) setLoadOffline(host string, load *LoadState) error {
+	err := app.dcs.Create(pathLoadOffline, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	return app.dcs.Set(dcs.JoinPath(pathLoadOffline, host), &LoadOffline{Since: time.Now(), Load: *load})
+}
+
+func (app *App) clearLoadOffline(host string) {
+	err := app.dcs.Delete(dcs.JoinPath(pathLoadOffline, host))
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("repair: failed to clear load offline of %s: %v", host, err)
+	}
+}
+
+// loadOfflineHeld checks that replica taken offline because of load has not been offline
+// for offline_mode_load_hold_time yet: load drops as soon as clients leave replica,
+// so it is not a reason to return them right away
+func (app *App) loadOfflineHeld(offline *LoadOffline) bool {
+	return offline != nil && time.Since(offline.Since) < app.cfg().OfflineModeLoadHoldTime
+}
+
+// onlineReplicas counts replicas serving clients
+func onlineReplicas(clusterState map[string]*NodeState, master string) int {
+	count := 0
+	for host, state := range clusterState {
+		if host != master && state.PingOk && !state.IsOffline {
+			count++
+		}
+	}
+	return count
+}
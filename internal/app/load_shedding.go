@@ -0,0 +1,221 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// LoadShedding describes actions applied on master by load_shedding.
+// It is kept in dcs, so manager elected later reverts actions applied by the previous one
+type LoadShedding struct {
+	Master string    `json:"master"`
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	// since when replication is healthy again, zero while it is degraded
+	ClearSince time.Time `json:"clear_since,omitempty"`
+	// max_user_connections of accounts before shedding, restored after it
+	UserLimits map[string]int `json:"user_limits,omitempty"`
+}
+
+func (ls *LoadShedding) String() string {
+	result := fmt.Sprintf("master %s since %s: %s", ls.Master, ls.Since.Format(time.RFC3339), ls.Reason)
+	if !ls.ClearSince.IsZero() {
+		result += fmt.Sprintf(", recovering since %s", ls.ClearSince.Format(time.RFC3339))
+	}
+	return result
+}
+
+// loadSheddingReason tells why writes of master are at risk of loss, or returns empty string.
+// Replicas of unknown lag are not considered keeping up.
+func loadSheddingReason(clusterState map[string]*NodeState, activeNodes []string, master string, lag time.Duration, semiSync bool, waitSlaveCount int) string {
+	var reasons []string
+	if masterState := clusterState[master]; semiSync && masterState != nil && masterState.SemiSyncState != nil {
+		ss := masterState.SemiSyncState
+		if !ss.MasterEnabled {
+			reasons = append(reasons, "semi-sync is disabled on master")
+		} else if ss.WaitSlaveCount < waitSlaveCount {
+			reasons = append(reasons, fmt.Sprintf("master waits for %d semi-sync replicas instead of %d", ss.WaitSlaveCount, waitSlaveCount))
+		}
+	}
+	if lag > 0 {
+		keepsUp := false
+		for _, host := range activeNodes {
+			state := clusterState[host]
+			if host == master || state == nil || state.SlaveState == nil || state.SlaveState.ReplicationLag == nil {
+				continue
+			}
+			if *state.SlaveState.ReplicationLag <= lag.Seconds() {
+				keepsUp = true
+				break
+			}
+		}
+		if !keepsUp {
+			reasons = append(reasons, fmt.Sprintf("no active replica lags less than %v", lag))
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func (app *App) getLoadShedding() (*LoadShedding, error) {
+	shedding := new(LoadShedding)
+	err := app.dcs.Get(pathLoadShedding, shedding)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return shedding, nil
+}
+
+// userLimiter changes max_user_connections of accounts on master
+type userLimiter interface {
+	GetMaxUserConnections(user, host string) (int, error)
+	SetMaxUserConnections(user, host string, limit int) error
+}
+
+// limitUsers lowers max_user_connections of load_shedding users on master, remembering previous limits.
+// Accounts already limited stricter are left as is. Previous limits are saved to dcs before any of them
+// is changed, so limits are restored even if manager dies in between
+func (app *App) limitUsers(node userLimiter, shedding *LoadShedding) error {
+	if len(app.cfg().LoadShedding.Users) == 0 {
+		return nil
+	}
+	limit := app.cfg().LoadShedding.MaxUserConnections
+	if shedding.UserLimits == nil {
+		shedding.UserLimits = make(map[string]int)
+	}
+	var accounts []config.Account
+	for _, user := range app.cfg().LoadShedding.Users {
+		account := config.ParseAccount(user)
+		if _, ok := shedding.UserLimits[account.String()]; !ok {
+			current, err := node.GetMaxUserConnections(account.User, account.Host)
+			if err != nil {
+				app.logger.Errorf("load shedding: failed to get max_user_connections of %s on %s: %v", account, shedding.Master, err)
+				continue
+			}
+			if current != 0 && current <= limit {
+				continue
+			}
+			shedding.UserLimits[account.String()] = current
+		}
+		accounts = append(accounts, account)
+	}
+	err := app.dcs.Set(pathLoadShedding, shedding)
+	if err != nil {
+		return fmt.Errorf("failed to save limits of users: %v", err)
+	}
+	for _, account := range accounts {
+		err := node.SetMaxUserConnections(account.User, account.Host, limit)
+		if err != nil {
+			app.logger.Errorf("load shedding: failed to limit %s on %s: %v", account, shedding.Master, err)
+		}
+	}
+	return nil
+}
+
+// restoreUsers restores max_user_connections saved by limitUsers, accounts failed to restore are kept for retry
+func (app *App) restoreUsers(node userLimiter, master string, shedding *LoadShedding) error {
+	if len(shedding.UserLimits) == 0 {
+		return nil
+	}
+	accounts := make([]string, 0, len(shedding.UserLimits))
+	for account := range shedding.UserLimits {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	var failed []string
+	for _, key := range accounts {
+		account := config.ParseAccount(key)
+		err := node.SetMaxUserConnections(account.User, account.Host, shedding.UserLimits[key])
+		if err != nil {
+			app.logger.Errorf("load shedding: failed to restore max_user_connections of %s on %s: %v", account, master, err)
+			failed = append(failed, key)
+			continue
+		}
+		delete(shedding.UserLimits, key)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restore %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// checkLoadShedding applies load_shedding actions on master while its writes are at risk
+// and reverts them after replication stays healthy for load_shedding.recover_after.
+// Limits of users are changed by replicated statements, so they follow master after switchover.
+func (app *App) checkLoadShedding(clusterState map[string]*NodeState, activeNodes []string, master string) {
+	cfg := app.cfg().LoadShedding
+	shedding, err := app.getLoadShedding()
+	if err != nil {
+		app.logger.Errorf("load shedding: failed to get state: %v", err)
+		return
+	}
+	reason := ""
+	if cfg.Enabled() {
+		reason = loadSheddingReason(clusterState, activeNodes, master, cfg.Lag, cfg.SemiSync && app.cfg().SemiSync, app.cfg().RplSemiSyncMasterWaitForSlaveCount)
+	}
+	limitUsers := func(shedding *LoadShedding) {
+		if len(cfg.Users) == 0 {
+			return
+		}
+		if err := app.limitUsers(app.cluster.Get(master), shedding); err != nil {
+			app.logger.Errorf("load shedding: %v", err)
+		}
+	}
+	now := time.Now()
+	switch {
+	case reason != "" && shedding == nil:
+		app.logger.Errorf("load shedding: writes of master %s are at risk: %s", master, reason)
+		shedding = &LoadShedding{Master: master, Reason: reason, Since: now}
+		limitUsers(shedding)
+		app.recordEvent(HistoryEvent{Type: EventLoadShedding, Host: master, Message: "started: " + reason})
+	case reason != "":
+		if shedding.Master == master && shedding.Reason == reason && shedding.ClearSince.IsZero() {
+			app.loadShedding = shedding
+			return
+		}
+		if shedding.Master != master {
+			shedding.Master = master
+			limitUsers(shedding)
+		}
+		shedding.Reason = reason
+		shedding.ClearSince = time.Time{}
+	case shedding == nil:
+		app.loadShedding = nil
+		return
+	case shedding.ClearSince.IsZero():
+		app.logger.Infof("load shedding: replication of master %s recovered, reverting after %v", master, cfg.RecoverAfter)
+		shedding.ClearSince = now
+	case now.Sub(shedding.ClearSince) < cfg.RecoverAfter:
+		app.loadShedding = shedding
+		return
+	default:
+		if len(shedding.UserLimits) > 0 {
+			err = app.restoreUsers(app.cluster.Get(master), master, shedding)
+		}
+		if err != nil {
+			app.logger.Errorf("load shedding: %v", err)
+			break
+		}
+		err = app.dcs.Delete(pathLoadShedding)
+		if err != nil {
+			app.logger.Errorf("load shedding: failed to delete state: %v", err)
+			break
+		}
+		app.recordEvent(HistoryEvent{Type: EventLoadShedding, Host: master,
+			Message: fmt.Sprintf("stopped after %v", now.Sub(shedding.Since).Round(time.Second))})
+		app.loadShedding = nil
+		return
+	}
+	err = app.dcs.Set(pathLoadShedding, shedding)
+	if err != nil {
+		app.logger.Errorf("load shedding: failed to save state: %v", err)
+	}
+	app.loadShedding = shedding
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSheddingReason(t *testing.T) {
+	lag := func(l float64) *NodeState { return &NodeState{SlaveState: &SlaveState{ReplicationLag: &l}} }
+	clusterState := map[string]*NodeState{
+		"mysql1": {SemiSyncState: &SemiSyncState{MasterEnabled: true, WaitSlaveCount: 1}},
+		"mysql2": lag(3),
+		"mysql3": lag(40),
+		"mysql4": {SlaveState: &SlaveState{}},
+	}
+	active := []string{"mysql1", "mysql2", "mysql3", "mysql4"}
+	require.Equal(t, "", loadSheddingReason(clusterState, active, "mysql1", 10*time.Second, true, 1))
+	require.Equal(t, "master waits for 1 semi-sync replicas instead of 2",
+		loadSheddingReason(clusterState, active, "mysql1", 10*time.Second, true, 2))
+	require.Equal(t, "no active replica lags less than 10s",
+		loadSheddingReason(clusterState, []string{"mysql1", "mysql3", "mysql4"}, "mysql1", 10*time.Second, true, 1))
+
+	clusterState["mysql1"].SemiSyncState.MasterEnabled = false
+	require.Equal(t, "semi-sync is disabled on master, no active replica lags less than 10s",
+		loadSheddingReason(clusterState, []string{"mysql1"}, "mysql1", 10*time.Second, true, 1))
+	require.Equal(t, "", loadSheddingReason(clusterState, active, "mysql1", 0, false, 1))
+}
+
+func TestCheckLoadShedding(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().LoadShedding.Lag = 10 * time.Second
+	app.cfg().LoadShedding.Throttle = true
+	app.cfg().LoadShedding.RecoverAfter = 0
+	replicaLag := 30.0
+	clusterState := map[string]*NodeState{"mysql1": {}, "mysql2": {SlaveState: &SlaveState{ReplicationLag: &replicaLag}}}
+	active := []string{"mysql1", "mysql2"}
+	throttled := func() bool {
+		app.publishThrottleState(clusterState, active, "mysql1")
+		var ts ThrottleState
+		require.NoError(t, app.dcs.Get(pathThrottle, &ts))
+		return ts.Throttled
+	}
+
+	app.checkLoadShedding(clusterState, active, "mysql1")
+	require.NotNil(t, app.loadShedding)
+	require.True(t, throttled())
+	shedding, err := app.getLoadShedding()
+	require.NoError(t, err)
+	require.Equal(t, "mysql1", shedding.Master)
+
+	// flag is lowered only after recovery is confirmed on the next tick
+	replicaLag = 1
+	app.checkLoadShedding(clusterState, active, "mysql1")
+	require.True(t, throttled())
+	app.checkLoadShedding(clusterState, active, "mysql1")
+	require.Nil(t, app.loadShedding)
+	require.False(t, throttled())
+	shedding, err = app.getLoadShedding()
+	require.NoError(t, err)
+	require.Nil(t, shedding)
+}
+
+// fakeUserLimiter keeps max_user_connections of accounts and checks they are saved to dcs before change
+type fakeUserLimiter struct {
+	t      *testing.T
+	app    *App
+	limits map[string]int
+	fail   bool
+}
+
+func (f *fakeUserLimiter) GetMaxUserConnections(user, host string) (int, error) {
+	return f.limits[user+"@"+host], nil
+}
+
+func (f *fakeUserLimiter) SetMaxUserConnections(user, host string, limit int) error {
+	if f.fail {
+		return fmt.Errorf("connection refused")
+	}
+	shedding, err := f.app.getLoadShedding()
+	require.NoError(f.t, err)
+	require.NotNil(f.t, shedding)
+	require.Contains(f.t, shedding.UserLimits, user+"@"+host)
+	f.limits[user+"@"+host] = limit
+	return nil
+}
+
+func TestLimitAndRestoreUsers(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().LoadShedding.Users = []string{"etl", "batch@10.0.0.%", "report"}
+	app.cfg().LoadShedding.MaxUserConnections = 5
+	node := &fakeUserLimiter{t: t, app: app, limits: map[string]int{"etl@%": 0, "batch@10.0.0.%": 100, "report@%": 2}}
+
+	shedding := &LoadShedding{Master: "mysql1"}
+	require.NoError(t, app.limitUsers(node, shedding))
+	// account limited stricter is left as is
+	require.Equal(t, map[string]int{"etl@%": 0, "batch@10.0.0.%": 100}, shedding.UserLimits)
+	require.Equal(t, map[string]int{"etl@%": 5, "batch@10.0.0.%": 5, "report@%": 2}, node.limits)
+
+	// failed accounts are kept for retry
+	node.fail = true
+	require.Error(t, app.restoreUsers(node, "mysql1", shedding))
+	require.Len(t, shedding.UserLimits, 2)
+
+	node.fail = false
+	require.NoError(t, app.restoreUsers(node, "mysql1", shedding))
+	require.Empty(t, shedding.UserLimits)
+	require.Equal(t, map[string]int{"etl@%": 0, "batch@10.0.0.%": 100, "report@%": 2}, node.limits)
+}
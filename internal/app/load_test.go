@@ -0,0 +1,79 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOfflineDecision(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	overloaded, relieved := app.loadOfflineDecision(&LoadState{ThreadsRunning: 1000, CPULoad: 10})
+	require.False(t, overloaded)
+	require.True(t, relieved)
+
+	app.cfg().OfflineModeEnableThreadsRunning = 100
+	app.cfg().OfflineModeDisableThreadsRunning = 50
+	app.cfg().OfflineModeEnableCPULoad = 0.9
+
+	overloaded, relieved = app.loadOfflineDecision(&LoadState{ThreadsRunning: 100, CPULoad: 0.1})
+	require.True(t, overloaded)
+	require.False(t, relieved)
+
+	overloaded, relieved = app.loadOfflineDecision(&LoadState{ThreadsRunning: 70, CPULoad: 0.1})
+	require.False(t, overloaded)
+	require.False(t, relieved)
+
+	overloaded, relieved = app.loadOfflineDecision(&LoadState{ThreadsRunning: 10, CPULoad: 0.95})
+	require.True(t, overloaded)
+	require.False(t, relieved)
+
+	// disable threshold defaults to half of enable one
+	overloaded, relieved = app.loadOfflineDecision(&LoadState{ThreadsRunning: 10, CPULoad: 0.5})
+	require.False(t, overloaded)
+	require.False(t, relieved)
+
+	overloaded, relieved = app.loadOfflineDecision(&LoadState{ThreadsRunning: 10, CPULoad: 0.4})
+	require.False(t, overloaded)
+	require.True(t, relieved)
+
+	overloaded, relieved = app.loadOfflineDecision(nil)
+	require.False(t, overloaded)
+	require.True(t, relieved)
+}
+
+func TestLoadOffline(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	hosts, err := app.getLoadOfflineHosts()
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+
+	require.NoError(t, app.setLoadOffline("mysql2", &LoadState{ThreadsRunning: 200}))
+	hosts, err = app.getLoadOfflineHosts()
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	require.Equal(t, 200, hosts["mysql2"].Load.ThreadsRunning)
+	require.True(t, app.loadOfflineHeld(hosts["mysql2"]))
+
+	hosts["mysql2"].Since = time.Now().Add(-app.cfg().OfflineModeLoadHoldTime)
+	require.False(t, app.loadOfflineHeld(hosts["mysql2"]))
+	require.False(t, app.loadOfflineHeld(nil))
+
+	app.clearLoadOffline("mysql2")
+	hosts, err = app.getLoadOfflineHosts()
+	require.NoError(t, err)
+	require.Empty(t, hosts)
+}
+
+func TestOnlineReplicas(t *testing.T) {
+	clusterState := map[string]*NodeState{
+		"mysql1": {PingOk: true},
+		"mysql2": {PingOk: true},
+		"mysql3": {PingOk: true, IsOffline: true},
+		"mysql4": {PingOk: false},
+	}
+	require.Equal(t, 1, onlineReplicas(clusterState, "mysql1"))
+}
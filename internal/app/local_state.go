@@ -0,0 +1,259 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+// LocalState is the last cluster view of agent, it is kept in local file to survive restarts
+type LocalState struct {
+	SavedAt      time.Time `json:"saved_at"`
+	Master       string    `json:"master"`
+	ActiveNodes  []string  `json:"active_nodes"`
+	Epoch        int64     `json:"epoch"`
+	IsMaster     bool      `json:"is_master"`
+	GTIDExecuted string    `json:"gtid_executed"`
+}
+
+// localStateGTIDRefresh is how often growing gtid_executed alone makes local state to be saved
+const localStateGTIDRefresh = time.Minute
+
+// sameView checks that states differ at most in gtid_executed
+func (ls *LocalState) sameView(other *LocalState) bool {
+	return ls.Master == other.Master && slices.Equal(ls.ActiveNodes, other.ActiveNodes) &&
+		ls.Epoch == other.Epoch && ls.IsMaster == other.IsMaster
+}
+
+// localView keeps cluster view of agent in memory: cluster part comes from dcs tree
+// read for info file, so health checks do not read dcs to save it
+type localView struct {
+	mu      sync.Mutex
+	cluster *LocalState
+	// last written state, used by health checker only
+	saved *LocalState
+}
+
+func (v *localView) setCluster(cluster *LocalState) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cluster = cluster
+}
+
+func (v *localView) getCluster() *LocalState {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.cluster
+}
+
+// clusterViewFromTree extracts master, active nodes and epoch from dcs tree
+func clusterViewFromTree(tree interface{}) (*LocalState, error) {
+	nodes, _ := tree.(map[string]interface{})
+	decode := func(path string, value interface{}) error {
+		node, ok := nodes[path]
+		if !ok || node == nil {
+			return nil
+		}
+		data, err := json.Marshal(node)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, value); err != nil {
+			return fmt.Errorf("malformed %s: %v", path, err)
+		}
+		return nil
+	}
+	view := new(LocalState)
+	var epoch TopologyEpoch
+	for path, value := range map[string]interface{}{pathMasterNode: &view.Master, pathActiveNodes: &view.ActiveNodes, pathEpoch: &epoch} {
+		if err := decode(path, value); err != nil {
+			return nil, err
+		}
+	}
+	view.Epoch = epoch.Epoch
+	return view, nil
+}
+
+func readLocalState(path string) (*LocalState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := new(LocalState)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("malformed %s: %s", path, err)
+	}
+	return state, nil
+}
+
+// writeLocalState replaces file atomically, so crash never leaves it half-written
+func writeLocalState(path string, state *LocalState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func executedGTIDs(ns *NodeState) string {
+	if ns.MasterState != nil {
+		return ns.MasterState.ExecutedGtidSet
+	}
+	if ns.SlaveState != nil {
+		return ns.SlaveState.ExecutedGtidSet
+	}
+	return ""
+}
+
+// loadLocalState reads state saved by previous run, before health checks overwrite it
+func (app *App) loadLocalState() {
+	if app.cfg().LocalStateFile == "" {
+		return
+	}
+	state, err := readLocalState(app.cfg().LocalStateFile)
+	if err != nil {
+		app.logger.Errorf("local state: failed to read: %v", err)
+		return
+	}
+	if state != nil {
+		app.logger.Infof("local state: saved at %s, master %s, epoch %d, local node is master: %t",
+			state.SavedAt.Format(time.RFC3339), state.Master, state.Epoch, state.IsMaster)
+	}
+	app.savedState = state
+}
+
+// saveLocalState is called by health checker, cluster view is saved only while dcs is reachable.
+// File is written when view changes, or when gtid_executed grows, but not more often than localStateGTIDRefresh
+func (app *App) saveLocalState(hc *NodeState) {
+	if app.cfg().LocalStateFile == "" || !hc.PingOk || !app.dcs.IsConnected() {
+		return
+	}
+	cluster := app.localView.getCluster()
+	if cluster == nil {
+		return
+	}
+	state := &LocalState{
+		SavedAt:      time.Now(),
+		Master:       cluster.Master,
+		ActiveNodes:  cluster.ActiveNodes,
+		Epoch:        cluster.Epoch,
+		IsMaster:     hc.IsMaster,
+		GTIDExecuted: executedGTIDs(hc),
+	}
+	saved := app.localView.saved
+	if saved != nil && saved.sameView(state) &&
+		(saved.GTIDExecuted == state.GTIDExecuted || state.SavedAt.Sub(saved.SavedAt) < localStateGTIDRefresh) {
+		return
+	}
+	err := writeLocalState(app.cfg().LocalStateFile, state)
+	if err != nil {
+		app.logger.Errorf("local state: failed to save: %v", err)
+		return
+	}
+	app.localView.saved = state
+}
+
+// enforceSavedState is used while dcs is unreachable at start: writable local node,
+// which was not master in the last known view, is set read-only without waiting for dcs
+func (app *App) enforceSavedState() {
+	saved := app.savedState
+	if saved == nil || saved.Master == "" || saved.Master == app.cfg().Hostname {
+		return
+	}
+	node := app.cluster.Local()
+	readOnly, _, err := node.IsReadOnly()
+	if err != nil || readOnly {
+		return
+	}
+	app.logger.Errorf("local state: local node is writable, but saved master is %s, setting read-only", saved.Master)
+	err = node.SetReadOnly(true)
+	if err != nil {
+		app.logger.Errorf("local state: failed to set local node read-only: %v", err)
+	}
+}
+
+// worldChanges compares saved view with current one and describes what happened while agent was down
+func worldChanges(saved *LocalState, master string, epoch int64, local *NodeState) ([]string, error) {
+	var changes []string
+	if saved.Master != "" && master != "" && saved.Master != master {
+		changes = append(changes, fmt.Sprintf("master changed from %s to %s", saved.Master, master))
+	}
+	if epoch > saved.Epoch {
+		changes = append(changes, fmt.Sprintf("epoch advanced from %d to %d", saved.Epoch, epoch))
+	}
+	if !local.PingOk {
+		return changes, nil
+	}
+	if saved.IsMaster != local.IsMaster {
+		changes = append(changes, fmt.Sprintf("local node role changed, is master: %t", local.IsMaster))
+	}
+	if saved.GTIDExecuted != "" {
+		lost, count, err := gtids.MissingTransactions(gtids.ParseGtidSet(executedGTIDs(local)), gtids.ParseGtidSet(saved.GTIDExecuted))
+		if err != nil {
+			return changes, err
+		}
+		if count > 0 {
+			changes = append(changes, fmt.Sprintf("local node lost %d transactions: %s", count, lost))
+		}
+	}
+	return changes, nil
+}
+
+// checkSavedState reports changes of cluster, which happened while agent was down
+func (app *App) checkSavedState() {
+	saved := app.savedState
+	if saved == nil {
+		return
+	}
+	app.savedState = nil
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Errorf("local state: %v", err)
+		return
+	}
+	var epoch int64
+	if e, err := app.GetEpoch(); err != nil {
+		app.logger.Errorf("local state: failed to get epoch: %v", err)
+		return
+	} else if e != nil {
+		epoch = e.Epoch
+	}
+	changes, err := worldChanges(saved, master, epoch, app.getNodeState(app.cfg().Hostname))
+	if err != nil {
+		app.logger.Errorf("local state: failed to compare gtids: %v", err)
+	}
+	for _, change := range changes {
+		app.logger.Warnf("local state: %s since %s", change, saved.SavedAt.Format(time.RFC3339))
+	}
+	if len(changes) > 0 {
+		app.recordEvent(HistoryEvent{
+			Type:    EventWorldChanged,
+			Host:    app.cfg().Hostname,
+			Message: fmt.Sprintf("changed while agent was down since %s: %v", saved.SavedAt.Format(time.RFC3339), changes),
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysync.state")
+	state, err := readLocalState(path)
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	saved := &LocalState{
+		SavedAt:      time.Now().Truncate(time.Second),
+		Master:       "mysql1",
+		ActiveNodes:  []string{"mysql1", "mysql2"},
+		Epoch:        3,
+		GTIDExecuted: "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-100",
+	}
+	require.NoError(t, writeLocalState(path, saved))
+	state, err = readLocalState(path)
+	require.NoError(t, err)
+	require.True(t, saved.SavedAt.Equal(state.SavedAt))
+	state.SavedAt = saved.SavedAt
+	require.Equal(t, saved, state)
+}
+
+func TestWorldChanges(t *testing.T) {
+	saved := &LocalState{
+		Master:       "mysql1",
+		Epoch:        3,
+		IsMaster:     true,
+		GTIDExecuted: "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-100",
+	}
+	local := &NodeState{PingOk: true, IsMaster: true, MasterState: &MasterState{ExecutedGtidSet: "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-120"}}
+	changes, err := worldChanges(saved, "mysql1", 3, local)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	local = &NodeState{PingOk: true, SlaveState: &SlaveState{ExecutedGtidSet: "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-90"}}
+	changes, err = worldChanges(saved, "mysql2", 4, local)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"master changed from mysql1 to mysql2",
+		"epoch advanced from 3 to 4",
+		"local node role changed, is master: false",
+		"local node lost 10 transactions: 6dbc0b04-4b09-43dc-86cc-9af852ded919:91-100",
+	}, changes)
+
+	// MySQL is down, only cluster view is compared
+	changes, err = worldChanges(saved, "mysql2", 4, &NodeState{})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+}
+
+func TestClusterViewFromTree(t *testing.T) {
+	view, err := clusterViewFromTree(map[string]interface{}{
+		pathMasterNode:   "mysql1",
+		pathActiveNodes:  []interface{}{"mysql1", "mysql2"},
+		pathEpoch:        map[string]interface{}{"epoch": 3, "master": "mysql1"},
+		pathHealthPrefix: map[string]interface{}{"mysql1": map[string]interface{}{"ping_ok": true}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &LocalState{Master: "mysql1", ActiveNodes: []string{"mysql1", "mysql2"}, Epoch: 3}, view)
+
+	view, err = clusterViewFromTree(nil)
+	require.NoError(t, err)
+	require.Equal(t, &LocalState{}, view)
+
+	_, err = clusterViewFromTree(map[string]interface{}{pathActiveNodes: "mysql1"})
+	require.Error(t, err)
+}
+
+func TestSaveLocalState(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().LocalStateFile = filepath.Join(t.TempDir(), "mysync.state")
+	hc := &NodeState{PingOk: true, IsMaster: true, MasterState: &MasterState{ExecutedGtidSet: "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-100"}}
+
+	// cluster view is not known yet
+	app.saveLocalState(hc)
+	state, err := readLocalState(app.cfg().LocalStateFile)
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	app.localView.setCluster(&LocalState{Master: "mysql1", ActiveNodes: []string{"mysql1"}, Epoch: 1})
+	app.saveLocalState(hc)
+	state, err = readLocalState(app.cfg().LocalStateFile)
+	require.NoError(t, err)
+	require.Equal(t, "mysql1", state.Master)
+	savedAt := state.SavedAt
+
+	// growing gtid_executed alone does not rewrite file right away
+	hc.MasterState.ExecutedGtidSet = "6dbc0b04-4b09-43dc-86cc-9af852ded919:1-120"
+	app.saveLocalState(hc)
+	state, err = readLocalState(app.cfg().LocalStateFile)
+	require.NoError(t, err)
+	require.True(t, savedAt.Equal(state.SavedAt))
+
+	app.localView.setCluster(&LocalState{Master: "mysql1", ActiveNodes: []string{"mysql1", "mysql2"}, Epoch: 1})
+	app.saveLocalState(hc)
+	state, err = readLocalState(app.cfg().LocalStateFile)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mysql1", "mysql2"}, state.ActiveNodes)
+	require.Equal(t, hc.MasterState.ExecutedGtidSet, state.GTIDExecuted)
+}
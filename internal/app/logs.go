@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+const (
+	apiLogsPath         = "/v1/logs"
+	apiLogsDefaultLines = 100
+	apiLogsPollInterval = 500 * time.Millisecond
+	// hostManager may be passed instead of host name to get logs of current manager
+	hostManager = "manager"
+)
+
+// tailLines returns offset of the last n lines in file
+func tailLines(f *os.File, n int) (int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := stat.Size()
+	if n == 0 {
+		return size, nil
+	}
+	const chunk = 64 * 1024
+	buf := make([]byte, chunk)
+	offset := size
+	lines := 0
+	for offset > 0 {
+		readSize := int64(chunk)
+		if offset < readSize {
+			readSize = offset
+		}
+		offset -= readSize
+		_, err := f.ReadAt(buf[:readSize], offset)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := readSize - 1; i >= 0; i-- {
+			// skip trailing newline of the last line
+			if buf[i] != '\n' || offset+i == size-1 {
+				continue
+			}
+			lines++
+			if lines == n {
+				return offset + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// handleAPILogs serves tail of local mysync log, following it if requested
+func (app *App) handleAPILogs(w http.ResponseWriter, r *http.Request, client config.APITokenConfig) {
+	lines := apiLogsDefaultLines
+	if l := r.URL.Query().Get("lines"); l != "" {
+		var err error
+		lines, err = strconv.Atoi(l)
+		if err != nil || lines < 0 {
+			http.Error(w, "lines should be non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+	follow := r.URL.Query().Get("follow") == "true"
+	if app.cfg().Log == "" {
+		http.Error(w, "mysync logs to stderr", http.StatusNotFound)
+		return
+	}
+	f, err := os.Open(app.cfg().Log)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	app.logger.Debugf("api: %s from %s reads logs", client.Name, r.RemoteAddr)
+
+	offset, err := tailLines(f, lines)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	output := &flushWriter{w: w}
+	for {
+		n, err := copyFrom(output, f, offset)
+		offset += n
+		if err != nil || !follow {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(apiLogsPollInterval):
+		}
+		// log was rotated, start from the beginning of the new file
+		stat, err := os.Stat(app.cfg().Log)
+		if err == nil && stat.Size() < offset {
+			newFile, err := os.Open(app.cfg().Log)
+			if err == nil {
+				_ = f.Close()
+				f, offset = newFile, 0
+			}
+		}
+	}
+}
+
+func copyFrom(w io.Writer, f *os.File, offset int64) (int64, error) {
+	return io.Copy(w, io.NewSectionReader(f, offset, 1<<62))
+}
+
+// agentAPIURL returns API address of mysync on given host, assuming all agents listen on the same port
+func (app *App) agentAPIURL(host string) (string, error) {
+	if app.cfg().APIListen == "" {
+		return "", fmt.Errorf("api_listen is not configured")
+	}
+	_, port, err := net.SplitHostPort(app.cfg().APIListen)
+	if err != nil {
+		return "", fmt.Errorf("malformed api_listen: %v", err)
+	}
+	scheme := "http"
+	if app.cfg().APITLSCertFile != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, port)), nil
+}
+
+// CliLogs prints recent mysync log of another host, served by its agent API
+func (app *App) CliLogs(host string, lines int, follow bool) int {
+	if len(app.cfg().APITokens) == 0 && app.cfg().APITLSCAFile == "" {
+		app.logger.Error("neither api_tokens nor api_tls_ca_file are configured")
+		return 1
+	}
+	if host == hostManager {
+		err := app.connectDCS()
+		if err != nil {
+			app.logger.Error(err.Error())
+			return 1
+		}
+		var manager dcs.LockOwner
+		err = app.dcs.Get(pathManagerLock, &manager)
+		app.dcs.Close()
+		if err != nil {
+			app.logger.Errorf("failed to get current manager: %v", err)
+			return 1
+		}
+		host = manager.Hostname
+	}
+	apiURL, err := app.agentAPIURL(host)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	query := url.Values{}
+	query.Set("lines", strconv.Itoa(lines))
+	query.Set("follow", strconv.FormatBool(follow))
+	ctx := app.baseContext()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+apiLogsPath+"?"+query.Encode(), nil)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	resp, err := app.agentAPIRequest(req)
+	if err != nil {
+		app.logger.Errorf("failed to get logs from %s: %v", host, err)
+		return 1
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		app.logger.Errorf("failed to get logs from %s: %s: %s", host, resp.Status, body)
+		return 1
+	}
+	_, err = io.Copy(os.Stdout, resp.Body)
+	if err != nil && ctx.Err() == nil {
+		app.logger.Errorf("failed to read logs from %s: %v", host, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysync.log")
+	content := "one\ntwo\nthree\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	offset, err := tailLines(f, 2)
+	require.NoError(t, err)
+	require.Equal(t, "two\nthree\n", content[offset:])
+
+	offset, err = tailLines(f, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), offset)
+
+	offset, err = tailLines(f, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), offset)
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/util"
+)
+
+const apiLivenessPath = "/v1/liveness"
+
+// agentTLS provides agent API certificates, reloading them when files change,
+// so certificates may be rotated without restart
+type agentTLS struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu      sync.Mutex
+	modTime time.Time
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+func newAgentTLS(cfg *config.Config) *agentTLS {
+	if cfg.APITLSCertFile == "" {
+		return nil
+	}
+	return &agentTLS{certFile: cfg.APITLSCertFile, keyFile: cfg.APITLSKeyFile, caFile: cfg.APITLSCAFile}
+}
+
+// load returns current certificate and CA pool, pool is nil when mTLS is not configured
+func (t *agentTLS) load() (*tls.Certificate, *x509.CertPool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var modTime time.Time
+	for _, file := range []string{t.certFile, t.keyFile, t.caFile} {
+		if file == "" {
+			continue
+		}
+		stat, err := os.Stat(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if stat.ModTime().After(modTime) {
+			modTime = stat.ModTime()
+		}
+	}
+	if t.cert != nil && modTime.Equal(t.modTime) {
+		return t.cert, t.pool, nil
+	}
+	cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pool *x509.CertPool
+	if t.caFile != "" {
+		pem, err := os.ReadFile(t.caFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("failed to parse CA certificates from %s", t.caFile)
+		}
+	}
+	t.cert, t.pool, t.modTime = &cert, pool, modTime
+	return t.cert, t.pool, nil
+}
+
+// serverConfig verifies client certificates if CA is configured, requiring them if requireClientCert is set
+func (t *agentTLS) serverConfig(requireClientCert bool) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool, err := t.load()
+			if err != nil {
+				return nil, err
+			}
+			clientAuth := tls.NoClientCert
+			if pool != nil {
+				clientAuth = tls.VerifyClientCertIfGiven
+				if requireClientCert {
+					clientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   clientAuth,
+			}, nil
+		},
+	}
+}
+
+// agentHTTPClient returns client for API of other agents, presenting own certificate if mTLS is configured
+func (app *App) agentHTTPClient() (*http.Client, error) {
+	if app.cfg().APITLSCAFile == "" {
+		return http.DefaultClient, nil
+	}
+	return app.agentTLS.httpClient()
+}
+
+// httpClient returns client presenting agent certificate and trusting agent CA, if mTLS is configured
+func (t *agentTLS) httpClient() (*http.Client, error) {
+	if t == nil || t.caFile == "" {
+		return http.DefaultClient, nil
+	}
+	cert, pool, err := t.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api certificates: %v", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*cert},
+		RootCAs:      pool,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// agentAPIRequest authorizes request to another agent with certificate or the first configured token
+func (app *App) agentAPIRequest(req *http.Request) (*http.Response, error) {
+	client, err := app.agentHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range app.cfg().APITokens {
+		if t.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+t.Token)
+			break
+		}
+	}
+	return client.Do(req)
+}
+
+// matchClientCert returns token entry matching verified client certificate, if any
+func matchClientCert(tokens []config.APITokenConfig, state *tls.ConnectionState) (config.APITokenConfig, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return config.APITokenConfig{}, false
+	}
+	cn := state.VerifiedChains[0][0].Subject.CommonName
+	for _, t := range tokens {
+		if t.CertCN == "" {
+			continue
+		}
+		if ok, _ := path.Match(t.CertCN, cn); ok {
+			return t, true
+		}
+	}
+	return config.APITokenConfig{}, false
+}
+
+func (app *App) handleAPILiveness(w http.ResponseWriter, r *http.Request, client config.APITokenConfig) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(app.observeLiveness())
+}
+
+// fetchLiveness asks agent on host to probe cluster hosts
+func (app *App) fetchLiveness(host string) (*LivenessObservation, error) {
+	apiURL, err := app.agentAPIURL(host)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(app.baseContext(), app.cfg().DBTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+apiLivenessPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := app.agentAPIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	observation := new(LivenessObservation)
+	err = json.NewDecoder(resp.Body).Decode(observation)
+	// observation is made right now by request
+	observation.SeenAt = time.Now()
+	return observation, err
+}
+
+// crossCheckLiveness asks agents, whose observations in dcs are missing or stale, to probe hosts directly via API
+func (app *App) crossCheckLiveness(observations map[string]*LivenessObservation, host string, maxAge time.Duration) {
+	var observers []string
+	for _, observer := range app.cluster.AllNodeHosts() {
+		if observer == host || observer == app.cfg().Hostname {
+			continue
+		}
+		if observation := observations[observer]; observation != nil && time.Since(observation.SeenAt) <= maxAge {
+			continue
+		}
+		observers = append(observers, observer)
+	}
+	var mu sync.Mutex
+	util.RunParallel(func(observer string) error {
+		observation, err := app.fetchLiveness(observer)
+		if err != nil {
+			app.logger.Warnf("liveness: failed to get observation from %s via api: %v", observer, err)
+			return err
+		}
+		mu.Lock()
+		observations[observer] = observation
+		mu.Unlock()
+		return nil
+	}, observers)
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mysync test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue writes certificate with given common name and its key to dir
+func (ca *testCA) issue(t *testing.T, dir, cn string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, cn+".crt"), filepath.Join(dir, cn+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func (ca *testCA) write(t *testing.T, dir string) string {
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600))
+	return caFile
+}
+
+func TestAgentMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := ca.write(t, dir)
+	serverCert, serverKey := ca.issue(t, dir, "db1.example.net")
+	clientCert, clientKey := ca.issue(t, dir, "db2.example.net")
+
+	tokens := []config.APITokenConfig{{Name: "agents", CertCN: "*.example.net", Role: config.APIRoleViewer}}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, ok := matchClientCert(tokens, r.TLS)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(client.Name))
+	}))
+	server.TLS = newAgentTLS(&config.Config{APITLSCertFile: serverCert, APITLSKeyFile: serverKey, APITLSCAFile: caFile}).serverConfig(true)
+	server.StartTLS()
+	defer server.Close()
+
+	app := &App{
+		config:   config.NewHolder(&config.Config{APITLSCAFile: caFile}),
+		agentTLS: newAgentTLS(&config.Config{APITLSCertFile: clientCert, APITLSKeyFile: clientKey, APITLSCAFile: caFile}),
+	}
+	client, err := app.agentHTTPClient()
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// remote CLI presents certificate from local agent config
+	remote, err := newAgentTLS(&config.Config{APITLSCertFile: clientCert, APITLSKeyFile: clientKey, APITLSCAFile: caFile}).httpClient()
+	require.NoError(t, err)
+	resp, err = remote.Get(server.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	remote, err = remoteHTTPClient(filepath.Join(dir, "mysync.yaml"))
+	require.NoError(t, err)
+	require.Same(t, http.DefaultClient, remote)
+
+	// client without certificate is rejected during handshake
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+	_, err = anonymous.Get(server.URL)
+	require.Error(t, err)
+}
+
+func TestAgentTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	caFile := ca.write(t, dir)
+	certFile, keyFile := ca.issue(t, dir, "db1")
+	agentTLS := newAgentTLS(&config.Config{APITLSCertFile: certFile, APITLSKeyFile: keyFile, APITLSCAFile: caFile})
+	first, _, err := agentTLS.load()
+	require.NoError(t, err)
+	same, _, err := agentTLS.load()
+	require.NoError(t, err)
+	require.Same(t, first, same)
+
+	rotatedCert, rotatedKey := ca.issue(t, dir, "db1-rotated")
+	for _, pair := range [][2]string{{rotatedCert, certFile}, {rotatedKey, keyFile}} {
+		require.NoError(t, os.Rename(pair[0], pair[1]))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(pair[1], future, future))
+	}
+	rotated, _, err := agentTLS.load()
+	require.NoError(t, err)
+	require.NotEqual(t, first.Certificate[0], rotated.Certificate[0])
+}
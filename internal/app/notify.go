@@ -0,0 +1,182 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/util"
+)
+
+const (
+	notifyQueueSize      = 100
+	notifyDefaultTimeout = 10 * time.Second
+	pagerDutyEventsURL   = "https://events.pagerduty.com/v2/enqueue"
+)
+
+// webhookPayload is a body of generic webhook notification
+type webhookPayload struct {
+	Cluster string       `json:"cluster"`
+	Event   HistoryEvent `json:"event"`
+}
+
+// pagerDutyPayload is a trigger event of PagerDuty Events API v2
+type pagerDutyPayload struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	Payload     struct {
+		Summary       string       `json:"summary"`
+		Source        string       `json:"source"`
+		Severity      string       `json:"severity"`
+		Component     string       `json:"component"`
+		Group         string       `json:"group"`
+		CustomDetails HistoryEvent `json:"custom_details"`
+	} `json:"payload"`
+}
+
+func eventSummary(cluster string, event HistoryEvent) string {
+	summary := fmt.Sprintf("[%s] %s", cluster, event.Type)
+	if event.Host != "" {
+		summary += " " + event.Host
+	}
+	if event.Cause != "" {
+		summary += fmt.Sprintf(" (%s)", event.Cause)
+	}
+	if event.Message != "" {
+		summary += ": " + event.Message
+	}
+	if event.Operator != nil {
+		summary += fmt.Sprintf(" [by %s]", event.Operator)
+	}
+	return summary
+}
+
+func eventSeverity(event HistoryEvent) string {
+	switch event.Type {
+	case EventFailover, EventFailoverIssued, EventForcedPromotion:
+		return "critical"
+	case EventResetupRequired, EventRepair:
+		return "warning"
+	}
+	return "info"
+}
+
+// notificationBody renders event according to notifier type
+func notificationBody(notifier config.NotifierConfig, cluster string, event HistoryEvent) ([]byte, error) {
+	switch notifier.Type {
+	case config.NotifierSlack:
+		return json.Marshal(map[string]string{"text": eventSummary(cluster, event)})
+	case config.NotifierPagerDuty:
+		payload := pagerDutyPayload{RoutingKey: notifier.RoutingKey, EventAction: "trigger"}
+		payload.Payload.Summary = eventSummary(cluster, event)
+		payload.Payload.Source = event.Host
+		if payload.Payload.Source == "" {
+			payload.Payload.Source = event.RecordedBy
+		}
+		payload.Payload.Severity = eventSeverity(event)
+		payload.Payload.Component = "mysync"
+		payload.Payload.Group = cluster
+		payload.Payload.CustomDetails = event
+		return json.Marshal(payload)
+	default:
+		return json.Marshal(webhookPayload{Cluster: cluster, Event: event})
+	}
+}
+
+// notify sends event to configured notifiers. Running agent delivers notifications
+// in background, so slow endpoints do not delay failover, CLI delivers them immediately
+func (app *App) notify(event HistoryEvent) {
+	if len(app.cfg().Notifiers) == 0 {
+		return
+	}
+	if app.notifications == nil {
+		app.deliverNotifications(context.Background(), event)
+		return
+	}
+	select {
+	case app.notifications <- event:
+	default:
+		app.logger.Warnf("notify: queue is full, dropping %s event", event.Type)
+	}
+}
+
+// notifier delivers queued notifications until ctx is done
+func (app *App) notifier(ctx context.Context) {
+	for {
+		select {
+		case event := <-app.notifications:
+			app.deliverNotifications(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (app *App) deliverNotifications(ctx context.Context, event HistoryEvent) {
+	for _, notifier := range app.cfg().Notifiers {
+		if len(notifier.Events) > 0 && !util.ContainsString(notifier.Events, event.Type) {
+			continue
+		}
+		err := app.deliverNotification(ctx, notifier, event)
+		if err != nil {
+			app.logger.Errorf("notify: failed to send %s event to %s: %v", event.Type, notifier.Name, err)
+		}
+	}
+}
+
+// deliverNotification posts event to notifier, retrying with exponential backoff
+func (app *App) deliverNotification(ctx context.Context, notifier config.NotifierConfig, event HistoryEvent) error {
+	body, err := notificationBody(notifier, app.cfg().Zookeeper.Namespace, event)
+	if err != nil {
+		return err
+	}
+	url := notifier.URL
+	if url == "" && notifier.Type == config.NotifierPagerDuty {
+		url = pagerDutyEventsURL
+	}
+	timeout := notifier.Timeout
+	if timeout == 0 {
+		timeout = notifyDefaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	backoff := app.cfg().NotifyRetryBackoff
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = postNotification(ctx, client, url, body)
+		if err == nil || !retry || attempt >= app.cfg().NotifyRetries {
+			return err
+		}
+		app.logger.Warnf("notify: attempt %d to send %s event to %s failed: %v, retrying in %v", attempt+1, event.Type, notifier.Name, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// postNotification sends body and reports whether failed request may be retried
+func postNotification(ctx context.Context, client *http.Client, url string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(message))
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+func TestNotificationBody(t *testing.T) {
+	event := HistoryEvent{Type: EventFailover, Host: "h1", Cause: "dead_master", Message: "h1 => h2", RecordedBy: "h2"}
+
+	body, err := notificationBody(config.NotifierConfig{Type: config.NotifierSlack}, "/mysync/c1", event)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"text": "[/mysync/c1] failover h1 (dead_master): h1 => h2"}`, string(body))
+
+	body, err = notificationBody(config.NotifierConfig{Type: config.NotifierPagerDuty, RoutingKey: "key"}, "/mysync/c1", event)
+	require.NoError(t, err)
+	var pd pagerDutyPayload
+	require.NoError(t, json.Unmarshal(body, &pd))
+	require.Equal(t, "key", pd.RoutingKey)
+	require.Equal(t, "trigger", pd.EventAction)
+	require.Equal(t, "critical", pd.Payload.Severity)
+	require.Equal(t, "h1", pd.Payload.Source)
+
+	body, err = notificationBody(config.NotifierConfig{Type: config.NotifierWebhook}, "/mysync/c1", event)
+	require.NoError(t, err)
+	var wh webhookPayload
+	require.NoError(t, json.Unmarshal(body, &wh))
+	require.Equal(t, "/mysync/c1", wh.Cluster)
+	require.Equal(t, event.Message, wh.Event.Message)
+}
+
+func TestDeliverNotificationRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	app := &App{logger: logger, config: config.NewHolder(&config.Config{NotifyRetries: 3, NotifyRetryBackoff: time.Millisecond})}
+	notifier := config.NotifierConfig{Name: "hook", Type: config.NotifierWebhook, URL: server.URL}
+	require.NoError(t, app.deliverNotification(context.Background(), notifier, HistoryEvent{Type: EventRepair}))
+	require.Equal(t, int32(3), requests.Load())
+
+	requests.Store(0)
+	app.cfg().NotifyRetries = 1
+	require.Error(t, app.deliverNotification(context.Background(), notifier, HistoryEvent{Type: EventRepair}))
+	require.Equal(t, int32(2), requests.Load())
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/log"
+)
+
+// topologyPaths are dcs nodes describing cluster topology, observe-only agent never changes them
+var topologyPaths = []string{
+	pathMasterNode,
+	pathActiveNodes,
+	pathEpoch,
+	pathHANodes,
+	pathCascadeNodesPrefix,
+	pathCurrentSwitch,
+	pathLastSwitch,
+	pathLastRejectedSwitch,
+}
+
+func isTopologyPath(path string) bool {
+	path = strings.Trim(path, "/")
+	for _, topologyPath := range topologyPaths {
+		if path == topologyPath || strings.HasPrefix(path, topologyPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// observeOnlyDCS logs changes of cluster topology in dcs instead of applying them,
+// so observe-only agent does not interfere with whatever actually manages cluster.
+// Agent own state (health, locks, history) is still written
+type observeOnlyDCS struct {
+	dcs.DCS
+	logger *log.Logger
+}
+
+func (o *observeOnlyDCS) skip(op, path string, value interface{}) bool {
+	if !isTopologyPath(path) {
+		return false
+	}
+	o.logger.Infof("observe-only: would %s %s in dcs: %+v", op, path, value)
+	return true
+}
+
+func (o *observeOnlyDCS) Create(path string, value interface{}) error {
+	if o.skip("create", path, value) {
+		return nil
+	}
+	return o.DCS.Create(path, value)
+}
+
+func (o *observeOnlyDCS) CreateEphemeral(path string, value interface{}) error {
+	if o.skip("create", path, value) {
+		return nil
+	}
+	return o.DCS.CreateEphemeral(path, value)
+}
+
+func (o *observeOnlyDCS) Set(path string, value interface{}) error {
+	if o.skip("set", path, value) {
+		return nil
+	}
+	return o.DCS.Set(path, value)
+}
+
+func (o *observeOnlyDCS) SetEphemeral(path string, value interface{}) error {
+	if o.skip("set", path, value) {
+		return nil
+	}
+	return o.DCS.SetEphemeral(path, value)
+}
+
+func (o *observeOnlyDCS) Delete(path string) error {
+	if o.skip("delete", path, nil) {
+		return nil
+	}
+	return o.DCS.Delete(path)
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/log"
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestObserveOnlyDCS(t *testing.T) {
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	store := mysynctest.NewMemStore()
+	observer := &observeOnlyDCS{DCS: store.Session("mysql1"), logger: logger}
+
+	require.NoError(t, observer.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, observer.Create(dcs.JoinPath(pathHANodes, "mysql1"), nil))
+	require.NoError(t, observer.Set(pathEpoch, &TopologyEpoch{Epoch: 1, Master: "mysql1"}))
+	require.NoError(t, observer.Create(pathCurrentSwitch, &Switchover{To: "mysql1"}))
+	require.NoError(t, observer.SetEphemeral(dcs.JoinPath(pathHealthPrefix, "mysql1"), &NodeState{}))
+
+	other := store.Session("mysql2")
+	var master string
+	require.Equal(t, dcs.ErrNotFound, other.Get(pathMasterNode, &master))
+	require.Equal(t, dcs.ErrNotFound, other.Get(dcs.JoinPath(pathHANodes, "mysql1"), &struct{}{}))
+	require.Equal(t, dcs.ErrNotFound, other.Get(pathEpoch, new(TopologyEpoch)))
+	require.Equal(t, dcs.ErrNotFound, other.Get(pathCurrentSwitch, new(Switchover)))
+	// own state of agent is written
+	require.NoError(t, other.Get(dcs.JoinPath(pathHealthPrefix, "mysql1"), new(NodeState)))
+}
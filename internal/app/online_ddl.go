@@ -0,0 +1,30 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// checkOnlineDDLBeforeSwitchover returns error if planned switchover should be deferred
+// because online schema change (gh-ost, pt-online-schema-change) is in progress on master
+func (app *App) checkOnlineDDLBeforeSwitchover(switchover *Switchover, master string) error {
+	if !app.cfg().OnlineDDLAwareSwitchover || switchover.Cause == CauseAuto || switchover.Force {
+		return nil
+	}
+	migrations, err := app.dcs.GetChildren(pathOnlineDDL)
+	if err != nil && err != dcs.ErrNotFound {
+		return fmt.Errorf("failed to get online schema changes from dcs: %v", err)
+	}
+	if len(migrations) > 0 {
+		return fmt.Errorf("online schema changes are registered in dcs: %v", migrations)
+	}
+	objects, err := app.cluster.Get(master).GetOnlineDDLObjects()
+	if err != nil {
+		return fmt.Errorf("failed to check online schema changes on %s: %v", master, err)
+	}
+	if len(objects) > 0 {
+		return fmt.Errorf("online schema change objects found on %s: %v", master, objects)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// commands run on behalf of api client get its name with signature in environment,
+// signature is made with api secrets from agent config, so local user can't pose as api client
+const (
+	apiClientEnv          = "MYSYNC_API_CLIENT"
+	apiClientSignatureEnv = "MYSYNC_API_CLIENT_SIGNATURE"
+)
+
+// operatorReason is set by CLI --reason flag
+var operatorReason string
+
+// SetOperatorReason sets reason recorded with manual operations run by this process
+func SetOperatorReason(reason string) {
+	operatorReason = reason
+}
+
+// Operator identifies who initiated manual operation
+type Operator struct {
+	// OS user running CLI, the one who invoked sudo if any
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
+	// agent API token name, if CLI was run via --remote
+	APIClient string `json:"api_client,omitempty" yaml:"api_client,omitempty"`
+	Reason    string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func (o *Operator) String() string {
+	who := o.User
+	if o.APIClient != "" {
+		// commands forwarded via api run as agent's OS user, so token is the only identity
+		who = "api token " + o.APIClient
+	}
+	if o.Reason != "" {
+		return fmt.Sprintf("%s (%s)", who, o.Reason)
+	}
+	return who
+}
+
+// currentOperator returns identity of user running this CLI process
+func (app *App) currentOperator() *Operator {
+	op := &Operator{
+		User:   os.Getenv("USER"),
+		Reason: operatorReason,
+	}
+	if u, err := user.Current(); err == nil {
+		op.User = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		op.User = sudoUser
+	}
+	if client := os.Getenv(apiClientEnv); client != "" {
+		signature := signAPIClient(app.cfg(), client)
+		if signature != "" && hmac.Equal([]byte(signature), []byte(os.Getenv(apiClientSignatureEnv))) {
+			op.APIClient = client
+		} else {
+			app.logger.Warnf("ignoring %s=%s: signature does not match", apiClientEnv, client)
+		}
+	}
+	return op
+}
+
+// signAPIClient signs api client name with api tokens and agent key,
+// it returns empty string when there are no secrets to sign with
+func signAPIClient(cfg *config.Config, client string) string {
+	var key []byte
+	for _, t := range cfg.APITokens {
+		key = append(key, t.Token...)
+	}
+	if cfg.APITLSKeyFile != "" {
+		if data, err := os.ReadFile(cfg.APITLSKeyFile); err == nil {
+			key = append(key, data...)
+		}
+	}
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(client))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// initiator formats operator and host operation was initiated from
+func initiator(op *Operator, host string) string {
+	if op == nil {
+		return host
+	}
+	if op.APIClient != "" {
+		return fmt.Sprintf("api token %s@%s", op.APIClient, host)
+	}
+	return fmt.Sprintf("%s@%s", op.User, host)
+}
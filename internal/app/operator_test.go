@@ -0,0 +1,67 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestOperatorString(t *testing.T) {
+	op := &Operator{User: "alice", Reason: "planned kernel update"}
+	require.Equal(t, "alice (planned kernel update)", op.String())
+	require.Equal(t, "alice@db1", initiator(op, "db1"))
+	require.Equal(t, "db1", initiator(nil, "db1"))
+
+	op = &Operator{User: "mysync", APIClient: "deploy"}
+	require.Equal(t, "api token deploy", op.String())
+	require.Equal(t, "api token deploy@db1", initiator(op, "db1"))
+}
+
+func TestCurrentOperator(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	t.Setenv("SUDO_USER", "bob")
+	t.Setenv(apiClientEnv, "")
+	SetOperatorReason("failover drill")
+	defer SetOperatorReason("")
+	require.Equal(t, &Operator{User: "bob", Reason: "failover drill"}, app.currentOperator())
+
+	// api client name is taken only with valid signature
+	app.cfg().APITokens = []config.APITokenConfig{{Name: "deploy", Token: "secret"}}
+	t.Setenv(apiClientEnv, "deploy")
+	t.Setenv(apiClientSignatureEnv, "forged")
+	require.Empty(t, app.currentOperator().APIClient)
+	t.Setenv(apiClientSignatureEnv, signAPIClient(app.cfg(), "deploy"))
+	require.Equal(t, "deploy", app.currentOperator().APIClient)
+
+	// no secrets to check signature with
+	app.cfg().APITokens = nil
+	require.Empty(t, app.currentOperator().APIClient)
+}
+
+func TestFormatEventsWithOperator(t *testing.T) {
+	events := []HistoryEvent{{
+		Time:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Type:     EventMaintenanceOn,
+		Message:  "initiated by db1",
+		Operator: &Operator{User: "alice", Reason: "upgrade"},
+	}}
+	require.Contains(t, formatEvents(events), "initiated by db1 [by alice (upgrade)]")
+}
+
+func TestSwitchoverReasonInHistory(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	operator := &Operator{User: "alice", Reason: "ticket OPS-1234: kernel upgrade"}
+	app.recordSwitchoverEvent(&Switchover{From: "mysql1", To: "mysql2", Cause: CauseManual, InitiatedBy: "mysql1", Operator: operator})
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, operator, history[0].Operator)
+	require.Contains(t, formatEvents(history), "[by alice (ticket OPS-1234: kernel upgrade)]")
+
+	maintenance := &Maintenance{InitiatedBy: "mysql1", MySyncPaused: true, Operator: operator}
+	require.Contains(t, maintenance.String(), "alice@mysql1 (ticket OPS-1234: kernel upgrade)")
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/yandex/mysync/internal/util"
+)
+
+// Hooks of policy_file. Script is written in Starlark and may define any of functions:
+//
+//	choose_candidate(state, candidates, default) -> host to promote, None keeps default
+//	approve_failover(state, master) -> True or None allows failover, False or reason string vetoes it
+//	approve_offline(state, host, offline, reason) -> True or None allows to set host offline (or online), False or reason string vetoes it
+//
+// state is a dict with "master", "active_nodes", "hosts" (manager view of hosts) and "dcs" (agents view of hosts),
+// hosts are the same as in `mysync state`. candidates is a list of dicts with "host", "priority" and "lag".
+// Failing or timed out script is logged and default decision is kept, so broken policy never blocks failover.
+const (
+	policyChooseCandidate = "choose_candidate"
+	policyApproveFailover = "approve_failover"
+	policyApproveOffline  = "approve_offline"
+)
+
+// policy is compiled policy_file, it is recompiled when file changes
+type policy struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	globals starlark.StringDict
+}
+
+func (p *policy) load(path string) (starlark.StringDict, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if path == p.path && info.ModTime().Equal(p.modTime) {
+		return p.globals, nil
+	}
+	thread := &starlark.Thread{Name: path}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	globals.Freeze()
+	p.path, p.modTime, p.globals = path, info.ModTime(), globals
+	return globals, nil
+}
+
+// callPolicy calls hook of policy, None is returned when policy or hook is not defined
+func (app *App) callPolicy(name string, args ...starlark.Value) (starlark.Value, error) {
+	if app.cfg().PolicyFile == "" {
+		return starlark.None, nil
+	}
+	globals, err := app.policy.load(app.cfg().PolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", app.cfg().PolicyFile, err)
+	}
+	fn, ok := globals[name].(starlark.Callable)
+	if !ok {
+		return starlark.None, nil
+	}
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(_ *starlark.Thread, msg string) { app.logger.Infof("policy: %s: %s", name, msg) },
+	}
+	timer := time.AfterFunc(app.cfg().PolicyTimeout, func() { thread.Cancel("policy_timeout exceeded") })
+	defer timer.Stop()
+	return starlark.Call(thread, fn, args, nil)
+}
+
+// approveByPolicy runs approve hook, returning error only when policy vetoes decision explicitly
+func (app *App) approveByPolicy(name string, args ...starlark.Value) error {
+	result, err := app.callPolicy(name, args...)
+	if err != nil {
+		app.logger.Errorf("policy: %s: %v, keeping default decision", name, err)
+		return nil
+	}
+	switch v := result.(type) {
+	case starlark.NoneType:
+		return nil
+	case starlark.Bool:
+		if v {
+			return nil
+		}
+		return fmt.Errorf("vetoed by policy %s", name)
+	case starlark.String:
+		return fmt.Errorf("vetoed by policy %s: %s", name, string(v))
+	}
+	app.logger.Errorf("policy: %s returned %s, while bool, string or None is expected, keeping default decision", name, result.Type())
+	return nil
+}
+
+// policyState converts cluster state to Starlark through its JSON representation
+func policyState(master string, activeNodes []string, clusterState, clusterStateDcs map[string]*NodeState) (starlark.Value, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"master":       master,
+		"active_nodes": activeNodes,
+		"hosts":        clusterState,
+		"dcs":          clusterStateDcs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var state interface{}
+	err = decoder.Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+	return toStarlark(state)
+}
+
+func toStarlark(value interface{}) (starlark.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return starlark.MakeInt64(i), nil
+		}
+		f, err := v.Float64()
+		return starlark.Float(f), err
+	case []interface{}:
+		list := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			sv, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, sv)
+		}
+		return starlark.NewList(list), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			sv, err := toStarlark(v[key])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), sv); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", value)
+}
+
+// policyApproveFailover lets policy veto failover of master
+func (app *App) policyApproveFailover(clusterState, clusterStateDcs map[string]*NodeState, activeNodes []string, master string) error {
+	if app.cfg().PolicyFile == "" {
+		return nil
+	}
+	state, err := policyState(master, activeNodes, clusterState, clusterStateDcs)
+	if err != nil {
+		app.logger.Errorf("policy: %s: %v, keeping default decision", policyApproveFailover, err)
+		return nil
+	}
+	return app.approveByPolicy(policyApproveFailover, state, starlark.String(master))
+}
+
+// policyChooseCandidate lets policy replace new master chosen among candidates
+func (app *App) policyChooseCandidate(clusterState map[string]*NodeState, activeNodes []string, master string, candidates []nodePosition, chosen string) string {
+	if app.cfg().PolicyFile == "" {
+		return chosen
+	}
+	state, err := policyState(master, activeNodes, clusterState, nil)
+	if err != nil {
+		app.logger.Errorf("policy: %s: %v, keeping default decision", policyChooseCandidate, err)
+		return chosen
+	}
+	list := make([]starlark.Value, 0, len(candidates))
+	for _, pos := range candidates {
+		candidate := starlark.NewDict(3)
+		_ = candidate.SetKey(starlark.String("host"), starlark.String(pos.host))
+		_ = candidate.SetKey(starlark.String("priority"), starlark.MakeInt64(pos.priority))
+		_ = candidate.SetKey(starlark.String("lag"), starlark.Float(pos.lag))
+		list = append(list, candidate)
+	}
+	result, err := app.callPolicy(policyChooseCandidate, state, starlark.NewList(list), starlark.String(chosen))
+	if err != nil {
+		app.logger.Errorf("policy: %s: %v, keeping default decision", policyChooseCandidate, err)
+		return chosen
+	}
+	if result == starlark.None {
+		return chosen
+	}
+	host, ok := starlark.AsString(result)
+	if !ok || !util.ContainsString(positionHosts(candidates), host) {
+		app.logger.Errorf("policy: %s returned %s, which is not a candidate, keeping %s", policyChooseCandidate, result, chosen)
+		return chosen
+	}
+	if host != chosen {
+		app.logger.Infof("policy: %s replaced new master %s with %s", policyChooseCandidate, chosen, host)
+	}
+	return host
+}
+
+// lastPolicyState returns state of the last manager tick
+func (app *App) lastPolicyState() (starlark.Value, error) {
+	app.clusterView.mu.Lock()
+	clusterState, clusterStateDcs := app.clusterView.clusterState, app.clusterView.clusterStateDcs
+	app.clusterView.mu.Unlock()
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		return nil, err
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		return nil, err
+	}
+	return policyState(master, activeNodes, clusterState, clusterStateDcs)
+}
+
+// policyApproveOffline lets policy veto setting replica offline or back online
+func (app *App) policyApproveOffline(host string, offline bool, reason string) error {
+	if app.cfg().PolicyFile == "" {
+		return nil
+	}
+	state, err := app.lastPolicyState()
+	if err != nil {
+		app.logger.Errorf("policy: %s: %v, keeping default decision", policyApproveOffline, err)
+		return nil
+	}
+	return app.approveByPolicy(policyApproveOffline, state, starlark.String(host), starlark.Bool(offline), starlark.String(reason))
+}
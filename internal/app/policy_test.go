@@ -0,0 +1,111 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+const testPolicy = `
+def approve_failover(state, master):
+    if state["dcs"][master]["disk_state"]["Used"] > 90:
+        return "disk of %s is full" % master
+    return None
+
+def choose_candidate(state, candidates, default):
+    for candidate in candidates:
+        if candidate["host"] == "mysql3":
+            return "mysql3"
+    return None
+
+def approve_offline(state, host, offline, reason):
+    return reason != "load"
+`
+
+func newPolicyTestApp(t *testing.T, script string) *App {
+	app := newTestApp(t, "mysql1")
+	app.cfg().PolicyFile = filepath.Join(t.TempDir(), "policy.star")
+	require.NoError(t, os.WriteFile(app.cfg().PolicyFile, []byte(script), 0644))
+	return app
+}
+
+func TestPolicyApproveFailover(t *testing.T) {
+	app := newPolicyTestApp(t, testPolicy)
+	clusterState := map[string]*NodeState{"mysql1": {}, "mysql2": {PingOk: true}}
+	clusterStateDcs := map[string]*NodeState{"mysql1": {DiskState: &DiskState{Used: 50, Total: 100}}}
+	require.NoError(t, app.policyApproveFailover(clusterState, clusterStateDcs, []string{"mysql1", "mysql2"}, "mysql1"))
+
+	clusterStateDcs["mysql1"].DiskState.Used = 95
+	err := app.policyApproveFailover(clusterState, clusterStateDcs, []string{"mysql1", "mysql2"}, "mysql1")
+	require.EqualError(t, err, "vetoed by policy approve_failover: disk of mysql1 is full")
+
+	// broken policy keeps default decision
+	delete(clusterStateDcs, "mysql1")
+	require.NoError(t, app.policyApproveFailover(clusterState, clusterStateDcs, []string{"mysql1", "mysql2"}, "mysql1"))
+}
+
+func TestPolicyChooseCandidate(t *testing.T) {
+	app := newPolicyTestApp(t, testPolicy)
+	candidates := []nodePosition{{host: "mysql2", priority: 10}, {host: "mysql3"}}
+	require.Equal(t, "mysql3", app.policyChooseCandidate(nil, nil, "mysql1", candidates, "mysql2"))
+	require.Equal(t, "mysql2", app.policyChooseCandidate(nil, nil, "mysql1", candidates[:1], "mysql2"))
+
+	app = newPolicyTestApp(t, `def choose_candidate(state, candidates, default): return "mysql4"`)
+	require.Equal(t, "mysql2", app.policyChooseCandidate(nil, nil, "mysql1", candidates, "mysql2"))
+}
+
+func TestPolicyTimeout(t *testing.T) {
+	app := newPolicyTestApp(t, `
+def approve_failover(state, master):
+    for i in range(1000000000):
+        pass
+    return False
+`)
+	app.cfg().PolicyTimeout = 100 * time.Millisecond
+	start := time.Now()
+	require.NoError(t, app.policyApproveFailover(nil, nil, nil, "mysql1"))
+	require.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestPolicyReload(t *testing.T) {
+	app := newPolicyTestApp(t, `def approve_failover(state, master): return False`)
+	require.Error(t, app.policyApproveFailover(nil, nil, nil, "mysql1"))
+
+	require.NoError(t, os.WriteFile(app.cfg().PolicyFile, []byte(`def approve_failover(state, master): return True`), 0644))
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(app.cfg().PolicyFile, modTime, modTime))
+	require.NoError(t, app.policyApproveFailover(nil, nil, nil, "mysql1"))
+}
+
+func TestPolicyOfflineVetoKeepsBrokenReplicaHandling(t *testing.T) {
+	app := newPolicyTestApp(t, `
+def approve_offline(state, host, offline, reason):
+    return reason != "lag"
+`)
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, app.dcs.Set(pathActiveNodes, []string{"mysql1", "mysql2"}))
+	require.NoError(t, app.dcs.Create(dcs.JoinPath(pathHANodes, "mysql2"), mysql.NodeConfiguration{}))
+	lastShutdown := time.Now().Add(-time.Hour)
+	require.NoError(t, app.dcs.Set(pathLastShutdownNodeTime, lastShutdown))
+	cluster, err := mysql.NewCluster(app.config, app.logger, app.dcs)
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	require.NoError(t, cluster.UpdateHostsInfo())
+	app.cluster = cluster
+
+	lag := 2 * app.cfg().OfflineModeEnableLag.Seconds()
+	state := &NodeState{PingOk: true, SlaveState: &SlaveState{ReplicationLag: &lag, LastSQLErrno: 1146}}
+	online := 1
+	app.repairSlaveOfflineMode("mysql2", cluster.Get("mysql2"), state, nil, nil, &online, nil, &NodeState{})
+
+	// lag is vetoed, but permanently broken replica is still taken offline
+	shutdown, err := app.GetLastShutdownNodeTime()
+	require.NoError(t, err)
+	require.True(t, shutdown.After(lastShutdown))
+}
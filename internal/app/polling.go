@@ -0,0 +1,112 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// sources of cluster stability reports
+const (
+	pollLoop       = "loop"
+	pollHealth     = "health"
+	pollSwitchover = "switchover"
+)
+
+// adaptivePolling tracks whether cluster is stable according to several sources,
+// polling is fast while any of them reports a problem and slow when all were fine for a while
+type adaptivePolling struct {
+	mu          sync.Mutex
+	unstable    map[string]bool
+	unstableAt  time.Time
+	stableSince time.Time
+}
+
+// observe records stability reported by source
+func (p *adaptivePolling) observe(source string, unstable bool, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unstable == nil {
+		p.unstable = make(map[string]bool)
+		p.stableSince = now
+	}
+	// stable period starts from recovery, not from the last report of problem
+	if unstable || p.unstable[source] {
+		p.unstableAt = now
+	}
+	p.unstable[source] = unstable
+}
+
+// interval returns polling interval replacing base one
+func (p *adaptivePolling) interval(cfg config.AdaptivePollingConfig, base time.Duration, now time.Time) time.Duration {
+	if !cfg.Enabled {
+		return base
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, unstable := range p.unstable {
+		if unstable {
+			return minDuration(cfg.MinInterval, base)
+		}
+	}
+	stableSince := p.stableSince
+	if p.unstableAt.After(stableSince) {
+		stableSince = p.unstableAt
+	}
+	if !stableSince.IsZero() && now.Sub(stableSince) >= cfg.StablePeriod {
+		return maxDuration(cfg.MaxInterval, base)
+	}
+	return base
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// nextLoopInterval reports stability of agent state and returns interval until the next iteration of main loop
+func (app *App) nextLoopInterval(now time.Time) time.Duration {
+	unstable := true
+	switch app.state {
+	case stateCandidate, stateMaintenance:
+		unstable = false
+	case stateManager:
+		view, _, _ := app.masterViewTimes.snapshot(now)
+		unstable = view != masterViewHealthy
+	}
+	if app.state != stateManager {
+		// switchovers are watched by manager only
+		app.polling.observe(pollSwitchover, false, now)
+	}
+	app.polling.observe(pollLoop, unstable, now)
+	return app.polling.interval(app.cfg().AdaptivePolling, app.cfg().TickInterval, now)
+}
+
+// resetTicker applies new interval to ticker, if it has changed
+func (app *App) resetTicker(ticker *time.Ticker, current *time.Duration, interval time.Duration, name string) {
+	if interval == *current {
+		return
+	}
+	app.logger.Infof("polling: %s interval changed from %v to %v", name, *current, interval)
+	ticker.Reset(interval)
+	*current = interval
+}
+
+// nodeUnstable checks that local node needs close watching
+func nodeUnstable(hc *NodeState) bool {
+	if !hc.PingOk || hc.IsFileSystemReadonly {
+		return true
+	}
+	return hc.SlaveState != nil && hc.SlaveState.ReplicationState != mysql.ReplicationRunning
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestAdaptivePolling(t *testing.T) {
+	cfg := config.AdaptivePollingConfig{
+		Enabled:      true,
+		MinInterval:  500 * time.Millisecond,
+		MaxInterval:  15 * time.Second,
+		StablePeriod: time.Minute,
+	}
+	base := 5 * time.Second
+	start := time.Now()
+	var p adaptivePolling
+	require.Equal(t, base, p.interval(cfg, base, start))
+
+	p.observe(pollLoop, false, start)
+	p.observe(pollHealth, false, start)
+	require.Equal(t, base, p.interval(cfg, base, start.Add(30*time.Second)))
+	require.Equal(t, 15*time.Second, p.interval(cfg, base, start.Add(time.Minute)))
+
+	// any unstable source makes polling fast
+	p.observe(pollHealth, true, start.Add(2*time.Minute))
+	require.Equal(t, 500*time.Millisecond, p.interval(cfg, base, start.Add(2*time.Minute)))
+
+	// stable period starts over after recovery
+	p.observe(pollHealth, false, start.Add(3*time.Minute))
+	require.Equal(t, base, p.interval(cfg, base, start.Add(3*time.Minute+30*time.Second)))
+	require.Equal(t, 15*time.Second, p.interval(cfg, base, start.Add(4*time.Minute)))
+
+	cfg.Enabled = false
+	p.observe(pollHealth, true, start.Add(5*time.Minute))
+	require.Equal(t, base, p.interval(cfg, base, start.Add(5*time.Minute)))
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// CliPromote makes host a master bypassing candidate checks, accepting loss of
+// transactions which were not replicated to it. It is intended for disasters,
+// when regular switchover is impossible (e.g. stale state in dcs or no manager).
+// Old master is made read-only first, promotion is aborted if it fails, unless
+// operator confirms with fenced that old master is down or isolated
+func (app *App) CliPromote(host, confirm string, force, fenced bool, waitTimeout time.Duration) int {
+	if !force {
+		app.logger.Errorf("forced promotion may lose data, use `mysync switch --to %s` for regular switchover or add --force", host)
+		return 1
+	}
+	if confirm == "" {
+		fmt.Printf("Promoting %s discards all transactions not replicated to it.\n", host)
+		fmt.Print("Type the host name to continue: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			app.logger.Errorf("failed to read confirmation: %v", err)
+			return 1
+		}
+		confirm = strings.TrimSpace(line)
+	}
+	if confirm != host {
+		app.logger.Errorf("confirmation %q does not match %s, aborting", confirm, host)
+		return 1
+	}
+
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	haNodes, err := app.dcs.GetChildren(pathHANodes)
+	if err != nil {
+		app.logger.Errorf("failed to get ha nodes: %v", err)
+		return 1
+	}
+	if !util.ContainsString(haNodes, host) {
+		app.logger.Errorf("host %s is not HA host", host)
+		return 1
+	}
+
+	err = app.newDBCluster()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.cluster.Close()
+	err = app.cluster.UpdateHostsInfo()
+	if err != nil {
+		app.logger.Errorf("failed to update hosts info: %v", err)
+		return 1
+	}
+	node := app.cluster.Get(host)
+	if node == nil {
+		app.logger.Errorf("host %s is not in cluster", host)
+		return 1
+	}
+
+	maintenanceCreated := app.pauseForPromotion(waitTimeout)
+	promoted := false
+	defer func() {
+		// cluster should not stay frozen after failed promotion
+		if maintenanceCreated && !promoted {
+			err := app.dcs.Delete(pathMaintenance)
+			if err != nil {
+				app.logger.Warnf("promote: failed to remove maintenance: %v, run `mysync maint off`", err)
+			}
+		}
+	}()
+
+	oldMaster, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Warnf("promote: failed to get current master from dcs: %v", err)
+	}
+	lost, demoted := app.demoteForPromotion(oldMaster, host)
+	if !demoted && !fenced {
+		app.logger.Errorf("promote: old master %s was not demoted, promotion may cause split-brain; make sure it is down and add --fenced", oldMaster)
+		return 1
+	}
+
+	err = node.StopSlave()
+	if err != nil {
+		app.logger.Errorf("promote: failed to stop replication on %s: %v", host, err)
+		return 1
+	}
+	err = node.ResetSlaveAll()
+	if err != nil {
+		app.logger.Errorf("promote: failed to reset replication on %s: %v", host, err)
+		return 1
+	}
+	_, err = app.SetMasterHost(host)
+	if err != nil {
+		app.logger.Errorf("promote: %v", err)
+		return 1
+	}
+	err = node.SetWritable()
+	if err != nil {
+		app.logger.Errorf("promote: failed to set %s writable: %v", host, err)
+		return 1
+	}
+	promoted = true
+	err = node.SetOnline()
+	if err != nil {
+		app.logger.Warnf("promote: failed to set %s online: %v", host, err)
+	}
+	dnsUpdate := app.updateDNS(host)
+
+	now := time.Now()
+	switchover := &Switchover{
+		From:        oldMaster,
+		To:          host,
+		Cause:       CauseForced,
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: now,
+		StartedBy:   app.cfg().Hostname,
+		StartedAt:   now,
+		Force:       true,
+		Result:      &SwitchoverResult{Ok: true, FinishedAt: now},
+		Operator:    app.currentOperator(),
+	}
+	err = app.dcs.Set(pathLastSwitch, switchover)
+	if err != nil {
+		app.logger.Warnf("promote: failed to save switchover to dcs: %v", err)
+	}
+	message := fmt.Sprintf("forced promotion of %s by %s", host, initiator(switchover.Operator, app.cfg().Hostname))
+	if lost != "" {
+		message += fmt.Sprintf(", lost transactions: %s", lost)
+	}
+	if dnsUpdate != nil {
+		message += ", " + dnsUpdate.String()
+	}
+	app.recordEvent(HistoryEvent{Type: EventForcedPromotion, Host: host, Cause: oldMaster, Message: message, Operator: switchover.Operator, DNS: dnsUpdate})
+
+	fmt.Printf("%s promoted\n", host)
+	// manager repoints replicas to the new master on leaving maintenance
+	if maintenanceCreated {
+		maintenance := &Maintenance{}
+		err = app.dcs.Get(pathMaintenance, maintenance)
+		if err == nil {
+			maintenance.ShouldLeave = true
+			maintenance.DisabledBy = switchover.Operator
+			err = app.dcs.Set(pathMaintenance, maintenance)
+		}
+		if err != nil {
+			app.logger.Warnf("promote: failed to schedule leaving maintenance: %v, run `mysync maint off`", err)
+		}
+	} else {
+		fmt.Println("maintenance was enabled before promotion, run `mysync maint off` to repoint replicas")
+	}
+	if lost != "" {
+		fmt.Printf("transactions lost: %s\n", lost)
+	}
+	return 0
+}
+
+// pauseForPromotion enters maintenance, so manager won't interfere with promotion.
+// Manager may be absent in disaster, so promotion continues if it does not pause.
+// Returns true if maintenance was enabled by promotion itself
+func (app *App) pauseForPromotion(waitTimeout time.Duration) bool {
+	maintenance := &Maintenance{
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: time.Now(),
+		Operator:    app.currentOperator(),
+	}
+	err := app.dcs.Create(pathMaintenance, maintenance)
+	if err != nil && err != dcs.ErrExists {
+		app.logger.Warnf("promote: failed to enter maintenance: %v", err)
+		return false
+	}
+	created := err == nil
+	ctx, cancel := context.WithTimeout(app.baseContext(), waitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		err = app.dcs.Get(pathMaintenance, maintenance)
+		if err == nil && maintenance.MySyncPaused {
+			return created
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			app.logger.Warnf("promote: mysync did not enter maintenance in %v, continuing", waitTimeout)
+			return created
+		}
+	}
+}
+
+// demoteForPromotion makes old master read-only if it is still reachable
+// and returns transactions, which are absent on the new master, and whether old master is demoted
+func (app *App) demoteForPromotion(oldMaster, newMaster string) (string, bool) {
+	if oldMaster == "" || oldMaster == newMaster {
+		return "", true
+	}
+	oldNode := app.cluster.Get(oldMaster)
+	if oldNode == nil {
+		app.logger.Warnf("promote: old master %s is not in cluster", oldMaster)
+		return "", false
+	}
+	err := oldNode.SetReadOnly(true)
+	if err != nil {
+		app.logger.Warnf("promote: failed to set old master %s read-only: %v", oldMaster, err)
+		return "", false
+	}
+	oldGTID, err := oldNode.GTIDExecutedParsed()
+	if err != nil {
+		return "", true
+	}
+	newGTID, err := app.cluster.Get(newMaster).GTIDExecutedParsed()
+	if err != nil {
+		return "", true
+	}
+	if gtids.IsSlaveBehindOrEqual(oldGTID, newGTID) {
+		return "", true
+	}
+	lost, err := gtids.GTIDDiff(newGTID, oldGTID)
+	if err != nil {
+		return "", true
+	}
+	return lost, true
+}
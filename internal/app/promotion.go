@@ -0,0 +1,37 @@
+package app
+
+import (
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// mostAdvancedPositions returns candidates having all transactions of other candidates,
+// or all candidates if their gtid sets diverged
+func mostAdvancedPositions(positions []nodePosition) []nodePosition {
+	var advanced []nodePosition
+	for _, pos := range positions {
+		if !detectSplitbrain(positions, pos) {
+			advanced = append(advanced, pos)
+		}
+	}
+	if len(advanced) == 0 {
+		return positions
+	}
+	return advanced
+}
+
+// choosePromotionCandidate selects new master among candidates according to promotion_strategy
+func (app *App) choosePromotionCandidate(logger *log.Logger, positions []nodePosition) (string, error) {
+	if app.cfg().PromotionStrategy == config.PromotionStrategyMostAdvanced {
+		positions = mostAdvancedPositions(positions)
+	}
+	return getMostDesirableNode(logger, positions, app.switchHelper.GetPriorityChoiceMaxLag())
+}
+
+// priorityCatchUpFallback checks that new master may be replaced with the most advanced host,
+// if it does not catch up within priority_catch_up_timeout. Most advanced host should be a candidate itself.
+func (app *App) priorityCatchUpFallback(switchover *Switchover, newMaster, mostRecent string, candidates []nodePosition) bool {
+	return switchover.To == "" && newMaster != mostRecent && util.ContainsString(positionHosts(candidates), mostRecent) &&
+		app.cfg().PromotionStrategy == config.PromotionStrategyPriority && app.cfg().PriorityCatchUpTimeout > 0
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+const promotionUUID = "6DBC0B04-4B09-43DC-86CC-9AF852DED919"
+
+func TestPromotionStrategy(t *testing.T) {
+	positions := []nodePosition{
+		{"mysql2", mustGTIDSet(promotionUUID + ":1-100"), 0, 0},
+		{"mysql3", mustGTIDSet(promotionUUID + ":1-90"), 5, 10},
+		{"mysql4", mustGTIDSet(promotionUUID + ":1-100"), 1, 0},
+	}
+	require.Equal(t, []string{"mysql2", "mysql4"}, positionHosts(mostAdvancedPositions(positions)))
+
+	app := newTestApp(t, "mysql1")
+	app.switchHelper = mysql.NewSwitchHelper(app.cfg())
+
+	host, err := app.choosePromotionCandidate(app.logger, positions)
+	require.NoError(t, err)
+	require.Equal(t, "mysql3", host)
+
+	app.cfg().PromotionStrategy = config.PromotionStrategyMostAdvanced
+	host, err = app.choosePromotionCandidate(app.logger, positions)
+	require.NoError(t, err)
+	require.Equal(t, "mysql2", host)
+}
+
+func TestPriorityCatchUpFallback(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	candidates := []nodePosition{{host: "mysql2"}, {host: "mysql3"}}
+	switchover := &Switchover{From: "mysql1"}
+
+	require.False(t, app.priorityCatchUpFallback(switchover, "mysql3", "mysql2", candidates))
+	app.cfg().PriorityCatchUpTimeout = 10 * time.Second
+	require.True(t, app.priorityCatchUpFallback(switchover, "mysql3", "mysql2", candidates))
+	require.False(t, app.priorityCatchUpFallback(switchover, "mysql2", "mysql2", candidates))
+	// most recent host is not a candidate
+	require.False(t, app.priorityCatchUpFallback(switchover, "mysql3", "mysql1", candidates))
+	require.False(t, app.priorityCatchUpFallback(&Switchover{To: "mysql3"}, "mysql3", "mysql2", candidates))
+}
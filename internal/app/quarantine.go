@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// getQuarantinedHosts returns hosts whose quarantine has not expired yet
+func (app *App) getQuarantinedHosts() (map[string]*HostQuarantine, error) {
+	values, err := app.getChildrenValues(pathQuarantine)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	quarantined := make(map[string]*HostQuarantine)
+	for host, value := range values {
+		quarantine := new(HostQuarantine)
+		if err = json.Unmarshal(value, quarantine); err != nil {
+			return nil, fmt.Errorf("malformed quarantine of %s: %v", host, err)
+		}
+		if !quarantine.IsExpired(now) {
+			quarantined[host] = quarantine
+		}
+	}
+	return quarantined, nil
+}
+
+// removeExpiredQuarantines is called by manager, so hosts return under its control
+func (app *App) removeExpiredQuarantines() {
+	values, err := app.getChildrenValues(pathQuarantine)
+	if err != nil {
+		if err != dcs.ErrNotFound {
+			app.logger.Errorf("quarantine: failed to get quarantined hosts: %v", err)
+		}
+		return
+	}
+	now := time.Now()
+	for host, value := range values {
+		quarantine := new(HostQuarantine)
+		if json.Unmarshal(value, quarantine) != nil || !quarantine.IsExpired(now) {
+			continue
+		}
+		err = app.dcs.Delete(dcs.JoinPath(pathQuarantine, host))
+		if err != nil {
+			app.logger.Errorf("quarantine: failed to remove expired quarantine of %s: %v", host, err)
+			continue
+		}
+		app.logger.Infof("quarantine: quarantine of %s expired", host)
+		app.recordEvent(HistoryEvent{Type: EventQuarantineOff, Host: host, Message: "quarantine expired"})
+	}
+}
+
+// CliHostQuarantine excludes host from automated actions of mysync for timeout
+func (app *App) CliHostQuarantine(host string, timeout time.Duration) int {
+	if timeout == 0 {
+		timeout = app.cfg().QuarantineTimeout
+	}
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	if !util.ContainsString(app.getKnownHosts(), host) {
+		app.logger.Errorf("host %s is not in cluster", host)
+		return 1
+	}
+	var master string
+	err = app.dcs.Get(pathMasterNode, &master)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("failed to get current master: %v", err)
+		return 1
+	}
+	if host == master {
+		app.logger.Errorf("%s is master and can't be quarantined, use maintenance instead", host)
+		return 1
+	}
+
+	err = app.dcs.Create(pathQuarantine, nil)
+	if err != nil && err != dcs.ErrExists {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	now := time.Now()
+	quarantine := &HostQuarantine{CreatedAt: now, ExpiresAt: now.Add(timeout), Operator: app.currentOperator()}
+	err = app.dcs.Set(dcs.JoinPath(pathQuarantine, host), quarantine)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	app.recordEvent(HistoryEvent{
+		Type:     EventQuarantineOn,
+		Host:     host,
+		Message:  fmt.Sprintf("quarantined until %s", quarantine.ExpiresAt.Format(time.RFC3339)),
+		Operator: quarantine.Operator,
+	})
+	fmt.Printf("%s quarantined until %s\n", host, quarantine.ExpiresAt.Format(time.RFC3339))
+	return 0
+}
+
+// CliHostUnquarantine returns host under control of mysync
+func (app *App) CliHostUnquarantine(host string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.dcs.Delete(dcs.JoinPath(pathQuarantine, host))
+	if err == dcs.ErrNotFound {
+		app.logger.Errorf("host %s is not quarantined", host)
+		return 1
+	}
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	app.recordEvent(HistoryEvent{Type: EventQuarantineOff, Host: host, Message: "quarantine removed", Operator: app.currentOperator()})
+	fmt.Printf("%s unquarantined\n", host)
+	return 0
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+func TestQuarantineExpiry(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	quarantined, err := app.getQuarantinedHosts()
+	require.NoError(t, err)
+	require.Empty(t, quarantined)
+
+	now := time.Now()
+	require.NoError(t, app.dcs.Create(pathQuarantine, nil))
+	require.NoError(t, app.dcs.Set(dcs.JoinPath(pathQuarantine, "mysql2"), &HostQuarantine{CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, app.dcs.Set(dcs.JoinPath(pathQuarantine, "mysql3"), &HostQuarantine{CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}))
+
+	quarantined, err = app.getQuarantinedHosts()
+	require.NoError(t, err)
+	require.Len(t, quarantined, 1)
+	require.NotNil(t, quarantined["mysql2"])
+
+	app.removeExpiredQuarantines()
+	hosts, err := app.dcs.GetChildren(pathQuarantine)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mysql2"}, hosts)
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, EventQuarantineOff, history[0].Type)
+	require.Equal(t, "mysql3", history[0].Host)
+}
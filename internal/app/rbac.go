@@ -0,0 +1,66 @@
+package app
+
+import (
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/util"
+)
+
+var apiRoleLevels = map[string]int{
+	config.APIRoleViewer:   1,
+	config.APIRoleOperator: 2,
+	config.APIRoleAdmin:    3,
+}
+
+// apiRoleAllows checks that token role is enough for required one, tokens without role are admins
+func apiRoleAllows(role, required string) bool {
+	if role == "" {
+		role = config.APIRoleAdmin
+	}
+	return apiRoleLevels[role] >= apiRoleLevels[required]
+}
+
+// containsAnyString checks that some of args is one of values
+func containsAnyString(args []string, values ...string) bool {
+	for _, arg := range args {
+		if util.ContainsString(values, arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredAPIRole returns role needed to run forwarded CLI command.
+// Subcommands changing cluster are looked up among all args, so flags may precede them
+func requiredAPIRole(args []string) string {
+	switch args[0] {
+	case "info", "state", "history", "events", "check", "logs":
+		return config.APIRoleViewer
+	case "switch":
+		return config.APIRoleOperator
+	case "manager":
+		if containsAnyString(args[1:], "handoff", "restart") {
+			return config.APIRoleOperator
+		}
+		return config.APIRoleViewer
+	case "maintenance", "maint", "mnt", "upgrade":
+		if containsAnyString(args[1:], "on", "enable", "off", "disable") {
+			return config.APIRoleOperator
+		}
+		return config.APIRoleViewer
+	case "config":
+		if containsAnyString(args[1:], "reload") {
+			return config.APIRoleOperator
+		}
+		return config.APIRoleViewer
+	case "host", "hosts":
+		if containsAnyString(args[1:], "add", "remove", "resetup", "config") {
+			return config.APIRoleAdmin
+		}
+		if containsAnyString(args[1:], "quarantine", "unquarantine", "drain", "undrain") {
+			return config.APIRoleOperator
+		}
+		return config.APIRoleViewer
+	}
+	// forced promotion and commands unknown here
+	return config.APIRoleAdmin
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestRequiredAPIRole(t *testing.T) {
+	require.Equal(t, config.APIRoleViewer, requiredAPIRole([]string{"info", "--short"}))
+	require.Equal(t, config.APIRoleViewer, requiredAPIRole([]string{"maintenance", "get"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"maint", "--wait", "5m", "on"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"switch", "--to", "mysql2"}))
+	require.Equal(t, config.APIRoleViewer, requiredAPIRole([]string{"host"}))
+	require.Equal(t, config.APIRoleAdmin, requiredAPIRole([]string{"host", "remove", "mysql3"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"host", "quarantine", "mysql3"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"host", "drain", "mysql3"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"upgrade", "on"}))
+	require.Equal(t, config.APIRoleViewer, requiredAPIRole([]string{"upgrade"}))
+	require.Equal(t, config.APIRoleAdmin, requiredAPIRole([]string{"promote", "mysql2"}))
+	require.Equal(t, config.APIRoleOperator, requiredAPIRole([]string{"config", "reload"}))
+}
+
+func TestAPIRoleAllows(t *testing.T) {
+	require.True(t, apiRoleAllows("", config.APIRoleAdmin))
+	require.True(t, apiRoleAllows(config.APIRoleOperator, config.APIRoleViewer))
+	require.False(t, apiRoleAllows(config.APIRoleOperator, config.APIRoleAdmin))
+	require.False(t, apiRoleAllows(config.APIRoleViewer, config.APIRoleOperator))
+	require.False(t, apiRoleAllows("unknown", config.APIRoleViewer))
+}
@@ -0,0 +1,350 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// Penalties of readiness score, which starts from readinessMaxScore
+const (
+	readinessMaxScore                  = 100
+	readinessLagPenaltyMax             = 30 // 1 point per second of lag
+	readinessGTIDPenaltyMax            = 20 // 1 point per 100 transactions missing
+	readinessGTIDPenaltyStep           = 100
+	readinessBacklogPenaltyMax         = 20 // 1 point per 1000 transactions retrieved but not applied
+	readinessBacklogPenaltyStep        = 1000
+	readinessReplicationStoppedPenalty = 20
+	readinessErrantGTIDPenalty         = 40
+	readinessDurabilityPenalty         = 10
+	readinessZonePenalty               = 10
+)
+
+// CandidateReadiness explains how ready replica is to be promoted if master fails now
+type CandidateReadiness struct {
+	Host        string   `json:"host" yaml:"host"`
+	Score       int      `json:"score" yaml:"score"`
+	Eligible    bool     `json:"eligible" yaml:"eligible"`
+	Selected    bool     `json:"selected" yaml:"selected"`
+	Priority    int64    `json:"priority" yaml:"priority"`
+	Zone        string   `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Lag         *float64 `json:"lag" yaml:"lag"`
+	GTIDBehind  int64    `json:"gtid_behind" yaml:"gtid_behind"`
+	ErrantGTIDs string   `json:"errant_gtids,omitempty" yaml:"errant_gtids,omitempty"`
+	Durable     bool     `json:"durable" yaml:"durable"`
+	Reasons     []string `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+func (cr *CandidateReadiness) String() string {
+	var flags []string
+	if cr.Selected {
+		flags = append(flags, "selected")
+	}
+	if !cr.Eligible {
+		flags = append(flags, "not eligible")
+	}
+	lag := "unknown"
+	if cr.Lag != nil {
+		lag = fmt.Sprintf("%.1fs", *cr.Lag)
+	}
+	s := fmt.Sprintf("score=%d priority=%d lag=%s gtid_behind=%d", cr.Score, cr.Priority, lag, cr.GTIDBehind)
+	if len(flags) > 0 {
+		s = fmt.Sprintf("%s (%s)", s, strings.Join(flags, ", "))
+	}
+	if len(cr.Reasons) > 0 {
+		s += ": " + strings.Join(cr.Reasons, "; ")
+	}
+	return s
+}
+
+func (cr *CandidateReadiness) penalize(points int, format string, args ...interface{}) {
+	cr.Score -= points
+	cr.Reasons = append(cr.Reasons, fmt.Sprintf(format, args...))
+}
+
+func (cr *CandidateReadiness) disqualify(format string, args ...interface{}) {
+	cr.Eligible = false
+	cr.Reasons = append(cr.Reasons, fmt.Sprintf(format, args...))
+}
+
+// scoreCandidate evaluates promotion readiness of replica by its health published to dcs.
+// masterGTIDs should be fetched after replica health, otherwise replica may look ahead of master.
+func scoreCandidate(host string, state *NodeState, active bool, priority int64, masterGTIDs gtids.GTIDSet, masterZone string, policy config.ZonePolicyConfig) CandidateReadiness {
+	cr := CandidateReadiness{Host: host, Score: readinessMaxScore, Eligible: true, Priority: priority}
+	if state == nil {
+		cr.Score = 0
+		cr.disqualify("health is unknown")
+		return cr
+	}
+	cr.Zone = state.Zone
+	if !active {
+		cr.disqualify("not active")
+	}
+	if !state.PingOk {
+		cr.disqualify("not alive")
+	}
+	if state.IsCascade {
+		cr.disqualify("cascade replica")
+	}
+	if util.ContainsString(policy.ForbiddenZones, state.Zone) {
+		cr.disqualify("promotion is forbidden in zone %s", state.Zone)
+	}
+	if broken, code := state.IsReplicationPermanentlyBroken(); broken {
+		cr.disqualify("replication is permanently broken with error %d", code)
+	}
+	if state.SlaveState == nil {
+		cr.Score = 0
+		cr.disqualify("replication status is unknown")
+		return cr
+	}
+
+	cr.Lag = state.SlaveState.ReplicationLag
+	if cr.Lag == nil {
+		cr.penalize(readinessLagPenaltyMax, "lag is unknown")
+	} else if *cr.Lag >= 1 {
+		cr.penalize(min(readinessLagPenaltyMax, int(*cr.Lag)), "lag %.1fs", *cr.Lag)
+	}
+	if backlog := state.SlaveState.ApplyBacklog; backlog >= readinessBacklogPenaltyStep {
+		cr.penalize(min(readinessBacklogPenaltyMax, int(backlog/readinessBacklogPenaltyStep)), "%d transactions not applied", backlog)
+	}
+	if state.SlaveState.ReplicationState != mysql.ReplicationRunning {
+		cr.penalize(readinessReplicationStoppedPenalty, "replication is %s", state.SlaveState.ReplicationState)
+	}
+
+	if masterGTIDs != nil {
+		candidateGTIDs := gtids.ParseGtidSet(state.SlaveState.ExecutedGtidSet).Clone()
+		if state.SlaveState.RetrievedGtidSet != "" {
+			_ = candidateGTIDs.Update(state.SlaveState.RetrievedGtidSet)
+		}
+		if _, behind, err := gtids.MissingTransactions(candidateGTIDs, masterGTIDs); err == nil {
+			cr.GTIDBehind = behind
+			if behind > 0 {
+				cr.penalize(min(readinessGTIDPenaltyMax, int(behind/readinessGTIDPenaltyStep)), "%d transactions behind master", behind)
+			}
+		}
+		if errant, _, err := gtids.MissingTransactions(masterGTIDs, candidateGTIDs); err == nil && errant != "" {
+			cr.ErrantGTIDs = errant
+			cr.penalize(readinessErrantGTIDPenalty, "errant transactions %s", errant)
+		}
+	}
+	if policy.PreferSameZone && masterZone != "" && state.Zone != masterZone {
+		cr.penalize(readinessZonePenalty, "zone differs from master zone %s", masterZone)
+	}
+
+	if state.Durability != nil {
+		cr.Durable = state.Durability.IsDurable()
+		if !cr.Durable {
+			cr.penalize(readinessDurabilityPenalty, "innodb_flush_log_at_trx_commit=%d sync_binlog=%d", state.Durability.InnodbFlushLogAtTrxCommit, state.Durability.SyncBinlog)
+		}
+	}
+	if cr.Score < 0 {
+		cr.Score = 0
+	}
+	return cr
+}
+
+// selectCandidate returns host which would be chosen as new master among eligible candidates,
+// the same way failover does it
+func (app *App) selectCandidate(candidates []CandidateReadiness, clusterState map[string]*NodeState, master, masterZone string) string {
+	var positions []nodePosition
+	zones := map[string]string{master: masterZone}
+	for _, cr := range candidates {
+		if !cr.Eligible {
+			continue
+		}
+		slaveState := clusterState[cr.Host].SlaveState
+		gtidset := gtids.ParseGtidSet(slaveState.ExecutedGtidSet).Clone()
+		if slaveState.RetrievedGtidSet != "" {
+			_ = gtidset.Update(slaveState.RetrievedGtidSet)
+		}
+		lag := float64(99999999)
+		if cr.Lag != nil {
+			lag = *cr.Lag
+		}
+		positions = append(positions, nodePosition{cr.Host, gtidset, lag, cr.Priority})
+		zones[cr.Host] = cr.Zone
+	}
+	positions = filterPositionsByZone(positions, zones, zones[master], app.cfg().ZonePolicy)
+	positions = filterPositionsByBacklog(positions, clusterState, app.cfg().MaxApplyBacklog)
+	if app.cfg().BackupAwareSwitchover {
+		var notOnBackup []nodePosition
+		for _, pos := range positions {
+			if !clusterState[pos.host].IsBackupRunning {
+				notOnBackup = append(notOnBackup, pos)
+			}
+		}
+		if len(notOnBackup) > 0 {
+			positions = notOnBackup
+		}
+	}
+	if len(positions) == 0 {
+		return ""
+	}
+	// it is evaluation, not a switchover: keep selection steps out of the log
+	quiet, err := log.Open("", "Fatal")
+	if err != nil {
+		return ""
+	}
+	host, err := app.choosePromotionCandidate(quiet, positions)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// getCandidatesReadiness scores all HA replicas and marks the one which would be promoted now
+func (app *App) getCandidatesReadiness(master string, activeNodes []string, clusterState map[string]*NodeState) ([]CandidateReadiness, error) {
+	haNodes, err := app.cluster.GetClusterHAHostsFromDcs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ha nodes: %v", err)
+	}
+	var masterZone string
+	var masterGTIDs gtids.GTIDSet
+	if state, ok := clusterState[master]; ok {
+		masterZone = state.Zone
+		if state.MasterState != nil {
+			masterGTIDs = gtids.ParseGtidSet(state.MasterState.ExecutedGtidSet)
+		}
+	}
+	// health of master in dcs may be older than health of replicas
+	if node := app.cluster.Get(master); node != nil {
+		if fresh, err := node.GTIDExecutedParsed(); err == nil {
+			masterGTIDs = fresh
+		} else {
+			app.logger.Warnf("readiness: failed to get gtid executed on master %s: %v", master, err)
+		}
+	}
+	quarantined, err := app.getQuarantinedHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined hosts: %v", err)
+	}
+	drained, err := app.getDrainedHosts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drained hosts: %v", err)
+	}
+	upgrade, err := app.getRollingUpgrade()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rolling upgrade: %v", err)
+	}
+	versions := make(map[string]*mysql.Version)
+	for host, state := range clusterState {
+		if state != nil && state.MySQLVersion != nil {
+			versions[host] = state.MySQLVersion
+		}
+	}
+	var candidates []CandidateReadiness
+	for host, nc := range haNodes {
+		if host == master {
+			continue
+		}
+		cr := scoreCandidate(host, app.withSmoothedLag(host, clusterState[host]), util.ContainsString(activeNodes, host), nc.Priority, masterGTIDs, masterZone, app.cfg().ZonePolicy)
+		if quarantined[host] != nil {
+			cr.disqualify("quarantined")
+		}
+		if drained[host] != nil {
+			cr.disqualify("drained")
+		}
+		if app.cfg().SemiSync && nc.NoSemiSync {
+			cr.disqualify("async replica")
+		}
+		if app.cfg().VersionAwareSwitchover {
+			if conflict := versionConflict(host, versions, master, upgrade != nil); conflict != "" {
+				cr.disqualify("%s", conflict)
+			}
+		}
+		candidates = append(candidates, cr)
+	}
+	selected := app.selectCandidate(candidates, clusterState, master, masterZone)
+	for i := range candidates {
+		candidates[i].Selected = candidates[i].Host == selected
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Selected != candidates[j].Selected {
+			return candidates[i].Selected
+		}
+		if candidates[i].Eligible != candidates[j].Eligible {
+			return candidates[i].Eligible
+		}
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].Host < candidates[j].Host
+	})
+	return candidates, nil
+}
+
+func formatCandidates(candidates []CandidateReadiness) string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tSCORE\tPRIORITY\tLAG\tGTID BEHIND\tDURABLE\tSTATUS\tREASONS")
+	for _, cr := range candidates {
+		lag := "unknown"
+		if cr.Lag != nil {
+			lag = fmt.Sprintf("%.1fs", *cr.Lag)
+		}
+		status := "eligible"
+		if cr.Selected {
+			status = "selected"
+		} else if !cr.Eligible {
+			status = "not eligible"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\t%t\t%s\t%s\n", cr.Host, cr.Score, cr.Priority, lag, cr.GTIDBehind, cr.Durable, status, strings.Join(cr.Reasons, "; "))
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// CliCandidates prints promotion readiness of replicas and the host which would be promoted now
+func (app *App) CliCandidates(format string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.newDBCluster()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.cluster.Close()
+	if err := app.cluster.UpdateHostsInfo(); err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Errorf("failed to get master: %v", err)
+		return 1
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	clusterState, err := app.getClusterStateFromDcs()
+	if err != nil {
+		app.logger.Errorf("failed to get cluster state: %v", err)
+		return 1
+	}
+	candidates, err := app.getCandidatesReadiness(master, activeNodes, clusterState)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	if format != "" {
+		return app.printCliOutput(candidates, format)
+	}
+	fmt.Print(formatCandidates(candidates))
+	return 0
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+const readinessMasterUUID = "6f6a8b4e-0b6c-11ee-8a2e-0242ac120002"
+
+func replicaState(executed string, lag float64) *NodeState {
+	return &NodeState{
+		PingOk:     true,
+		Zone:       "vla",
+		Durability: &DurabilityState{InnodbFlushLogAtTrxCommit: 1, SyncBinlog: 1},
+		SlaveState: &SlaveState{
+			ExecutedGtidSet:  executed,
+			ReplicationLag:   &lag,
+			ReplicationState: mysql.ReplicationRunning,
+		},
+	}
+}
+
+func TestScoreCandidate(t *testing.T) {
+	masterGTIDs := gtids.ParseGtidSet(readinessMasterUUID + ":1-1000")
+	policy := config.ZonePolicyConfig{PreferSameZone: true, ForbiddenZones: []string{"man"}}
+
+	cr := scoreCandidate("mysql2", replicaState(readinessMasterUUID+":1-1000", 0), true, 10, masterGTIDs, "vla", policy)
+	require.True(t, cr.Eligible)
+	require.Equal(t, readinessMaxScore, cr.Score)
+	require.True(t, cr.Durable)
+	require.Empty(t, cr.Reasons)
+
+	state := replicaState(readinessMasterUUID+":1-500,aaaaaaaa-0b6c-11ee-8a2e-0242ac120002:1", 5)
+	state.Zone = "sas"
+	state.Durability.SyncBinlog = 1000
+	cr = scoreCandidate("mysql3", state, true, 0, masterGTIDs, "vla", policy)
+	require.True(t, cr.Eligible)
+	require.Equal(t, int64(500), cr.GTIDBehind)
+	require.Equal(t, "aaaaaaaa-0b6c-11ee-8a2e-0242ac120002:1", cr.ErrantGTIDs)
+	require.Equal(t, readinessMaxScore-5-5-readinessErrantGTIDPenalty-readinessZonePenalty-readinessDurabilityPenalty, cr.Score)
+	require.Len(t, cr.Reasons, 5)
+
+	state = replicaState(readinessMasterUUID+":1-1000", 0)
+	state.Zone = "man"
+	state.IsCascade = true
+	cr = scoreCandidate("mysql4", state, false, 0, masterGTIDs, "vla", policy)
+	require.False(t, cr.Eligible)
+	require.Equal(t, []string{"not active", "cascade replica", "promotion is forbidden in zone man", "zone differs from master zone vla"}, cr.Reasons)
+
+	cr = scoreCandidate("mysql5", nil, true, 0, masterGTIDs, "vla", policy)
+	require.False(t, cr.Eligible)
+	require.Zero(t, cr.Score)
+}
+
+func TestSelectCandidate(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.switchHelper = mysql.NewSwitchHelper(app.cfg())
+	masterGTIDs := gtids.ParseGtidSet(readinessMasterUUID + ":1-1000")
+	clusterState := map[string]*NodeState{
+		"mysql1": {PingOk: true, IsMaster: true, Zone: "vla"},
+		"mysql2": replicaState(readinessMasterUUID+":1-900", 3),
+		"mysql3": replicaState(readinessMasterUUID+":1-1000", 0),
+		"mysql4": replicaState(readinessMasterUUID+":1-1000", 0),
+	}
+	clusterState["mysql4"].PingOk = false
+	var candidates []CandidateReadiness
+	for _, host := range []string{"mysql2", "mysql3", "mysql4"} {
+		candidates = append(candidates, scoreCandidate(host, clusterState[host], true, 0, masterGTIDs, "vla", app.cfg().ZonePolicy))
+	}
+	require.Equal(t, "mysql3", app.selectCandidate(candidates, clusterState, "mysql1", "vla"))
+
+	// priority wins while lag is acceptable
+	candidates[0].Priority = 10
+	require.Equal(t, "mysql2", app.selectCandidate(candidates, clusterState, "mysql1", "vla"))
+}
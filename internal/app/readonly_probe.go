@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReadOnlyProbe is the last result of canary write to read-only node
+type ReadOnlyProbe struct {
+	CheckAt time.Time `json:"check_at"`
+	// write went through, read_only is bypassed
+	Accepted bool `json:"accepted"`
+	// probe was inconclusive
+	Error string `json:"error,omitempty"`
+}
+
+func (p *ReadOnlyProbe) String() string {
+	switch {
+	case p.Accepted:
+		return "write accepted"
+	case p.Error != "":
+		return "inconclusive: " + p.Error
+	}
+	return "write rejected"
+}
+
+// probeReadOnly verifies that local node rejects writes as soon as it becomes read-only
+// and every read_only_probe.interval after that, rather than trusting read_only variable alone.
+// last is the previous result, nil if node was not read-only.
+func (app *App) probeReadOnly(hc *NodeState, last *ReadOnlyProbe, now time.Time) *ReadOnlyProbe {
+	if app.cfg().ReadOnlyProbe.User == "" || !hc.PingOk {
+		return nil
+	}
+	if !hc.IsReadOnly {
+		if hc.IsMaster && !app.probeTableReady {
+			err := app.cluster.Local().CreateReadOnlyProbeTable()
+			if err != nil {
+				app.logger.Errorf("read-only probe: failed to create probe table: %v", err)
+			}
+			app.probeTableReady = err == nil
+		}
+		return nil
+	}
+	if last != nil && now.Sub(last.CheckAt) < app.cfg().ReadOnlyProbe.Interval {
+		return last
+	}
+	accepted, err := app.cluster.Local().ProbeReadOnly()
+	probe := &ReadOnlyProbe{CheckAt: now, Accepted: accepted}
+	if err != nil {
+		probe.Error = err.Error()
+		app.logger.Warnf("read-only probe: %s", probe)
+		return probe
+	}
+	if !accepted {
+		return probe
+	}
+	app.logger.Errorf("read-only probe: local node is read-only, but write of %s went through", app.cfg().ReadOnlyProbe.User)
+	if last == nil || !last.Accepted {
+		app.recordEvent(HistoryEvent{
+			Type:    EventReadOnlyBypass,
+			Host:    app.cfg().Hostname,
+			Message: fmt.Sprintf("read-only node accepted write of %s", app.cfg().ReadOnlyProbe.User),
+		})
+	}
+	return probe
+}
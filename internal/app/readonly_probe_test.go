@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeReadOnly(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	now := time.Now()
+	last := &ReadOnlyProbe{CheckAt: now.Add(-time.Second)}
+	readOnly := &NodeState{PingOk: true, IsReadOnly: true}
+
+	// disabled
+	require.Nil(t, app.probeReadOnly(readOnly, last, now))
+
+	app.cfg().ReadOnlyProbe.User = "probe"
+	require.Nil(t, app.probeReadOnly(&NodeState{PingOk: true}, last, now))
+	require.Nil(t, app.probeReadOnly(&NodeState{IsReadOnly: true}, last, now))
+	// probed recently
+	require.Same(t, last, app.probeReadOnly(readOnly, last, now))
+
+	require.Equal(t, "write rejected", last.String())
+	require.Equal(t, "write accepted", (&ReadOnlyProbe{Accepted: true}).String())
+	require.Equal(t, "inconclusive: access denied", (&ReadOnlyProbe{Error: "access denied"}).String())
+}
@@ -0,0 +1,313 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// recoveryMarkIDFormat is format of mark id, so ids sort in order of marks
+const recoveryMarkIDFormat = "20060102T150405Z"
+
+// recoveryMarkLookback is how long after scheduled time manager still records missed mark
+const recoveryMarkLookback = 5 * time.Minute
+
+// errNoRecoveryMarkReached is returned for host on recovery, which lies off the recorded lineage.
+// Host on recovery does not replicate, so it never reaches any mark by waiting
+var errNoRecoveryMarkReached = errors.New("no recovery mark reached")
+
+// RecoveryMark is cluster-wide GTID position recorded for point-in-time recovery.
+// Backup tooling associates backups with mark id, so backup matches exact position of the whole cluster.
+type RecoveryMark struct {
+	ID        string    `json:"id" yaml:"id"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	Scheduled bool      `json:"scheduled,omitempty" yaml:"scheduled,omitempty"`
+	Operator  *Operator `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Master    string    `json:"master" yaml:"master"`
+	Epoch     int64     `json:"epoch" yaml:"epoch"`
+	// gtid_executed of master, it contains positions of all replicas below
+	GTIDs string `json:"gtids" yaml:"gtids"`
+	// gtid_executed of replicas, read just before master
+	Hosts map[string]string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
+}
+
+// makeRecoveryMark reads positions of cluster hosts. Replicas are read before master,
+// so position of master contains all of them and mark is consistent for the whole cluster
+func (app *App) makeRecoveryMark(id, master string) (*RecoveryMark, error) {
+	mark := &RecoveryMark{ID: id, CreatedAt: time.Now(), Master: master, Hosts: make(map[string]string)}
+	epoch, err := app.GetEpoch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get epoch: %v", err)
+	}
+	if epoch != nil {
+		mark.Epoch = epoch.Epoch
+	}
+	for _, host := range app.cluster.AllNodeHosts() {
+		if host == master {
+			continue
+		}
+		executed, err := app.cluster.Get(host).GTIDExecuted()
+		if err != nil || executed == nil {
+			app.logger.Warnf("recovery mark: failed to get gtid executed of %s: %v", host, err)
+			continue
+		}
+		mark.Hosts[host] = executed.ExecutedGtidSet
+	}
+	executed, err := app.cluster.Get(master).GTIDExecuted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gtid executed of master %s: %v", master, err)
+	}
+	if executed == nil {
+		return nil, fmt.Errorf("master %s has no gtid executed", master)
+	}
+	mark.GTIDs = executed.ExecutedGtidSet
+	return mark, nil
+}
+
+// saveRecoveryMark stores mark and removes the oldest marks beyond recovery_marks.keep.
+// dcs.ErrExists is returned if mark with the same id is already recorded
+func (app *App) saveRecoveryMark(mark *RecoveryMark) error {
+	err := app.dcs.Create(pathRecoveryMarks, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	err = app.dcs.Create(dcs.JoinPath(pathRecoveryMarks, mark.ID), mark)
+	if err != nil {
+		return err
+	}
+	ids, err := app.dcs.GetChildren(pathRecoveryMarks)
+	if err != nil {
+		return err
+	}
+	sort.Strings(ids)
+	for len(ids) > app.cfg().RecoveryMarks.Keep {
+		err = app.dcs.Delete(dcs.JoinPath(pathRecoveryMarks, ids[0]))
+		if err != nil {
+			app.logger.Errorf("recovery mark: failed to delete old mark %s: %v", ids[0], err)
+			break
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// getRecoveryMarks returns recorded marks, the oldest first
+func (app *App) getRecoveryMarks() ([]*RecoveryMark, error) {
+	ids, err := app.dcs.GetChildren(pathRecoveryMarks)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	marks := make([]*RecoveryMark, 0, len(ids))
+	for _, id := range ids {
+		mark := new(RecoveryMark)
+		err = app.dcs.Get(dcs.JoinPath(pathRecoveryMarks, id), mark)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		marks = append(marks, mark)
+	}
+	return marks, nil
+}
+
+// newestReachedMark returns the newest mark, which position is contained in host GTIDs, or nil.
+// Host which reached no mark is restored from backup older than recorded history or from another cluster
+func newestReachedMark(hostGTIDs gtids.GTIDSet, marks []*RecoveryMark) *RecoveryMark {
+	for i := len(marks) - 1; i >= 0; i-- {
+		if marks[i].GTIDs == "" {
+			continue
+		}
+		if gtids.IsSlaveBehindOrEqual(gtids.ParseGtidSet(marks[i].GTIDs), hostGTIDs) {
+			return marks[i]
+		}
+	}
+	return nil
+}
+
+// validateRecoveryLineage checks that host on recovery has reached one of recorded marks.
+// Together with check that host is not ahead of master it means that host lies on the recorded lineage
+func (app *App) validateRecoveryLineage(host string, hostGTIDs gtids.GTIDSet) error {
+	if !app.cfg().RecoveryMarks.ValidateRejoin {
+		return nil
+	}
+	marks, err := app.getRecoveryMarks()
+	if err != nil {
+		return fmt.Errorf("failed to get recovery marks: %v", err)
+	}
+	if len(marks) == 0 {
+		app.logger.Infof("recovery: no recovery marks recorded, lineage of %s is not validated", host)
+		return nil
+	}
+	mark := newestReachedMark(hostGTIDs, marks)
+	if mark == nil {
+		return fmt.Errorf("%w: %s has not reached any of %d recorded recovery marks, the oldest is %s",
+			errNoRecoveryMarkReached, host, len(marks), marks[0].ID)
+	}
+	app.logger.Infof("recovery: %s has reached recovery mark %s", host, mark.ID)
+	return nil
+}
+
+// recordScheduledRecoveryMark is called by manager to record mark at recovery_marks.schedule
+func (app *App) recordScheduledRecoveryMark(master string) {
+	if app.cfg().RecoveryMarks.Schedule == "" {
+		return
+	}
+	schedule, err := util.ParseCron(app.cfg().RecoveryMarks.Schedule)
+	if err != nil {
+		app.logger.Errorf("recovery mark: %v", err)
+		return
+	}
+	fire := schedule.LastFireWithin(time.Now(), recoveryMarkLookback)
+	if fire.IsZero() || !fire.After(app.lastRecoveryMark) {
+		return
+	}
+	mark, err := app.makeRecoveryMark(fire.UTC().Format(recoveryMarkIDFormat), master)
+	if err != nil {
+		app.logger.Errorf("recovery mark: %v", err)
+		return
+	}
+	mark.Scheduled = true
+	err = app.saveRecoveryMark(mark)
+	if err != nil && err != dcs.ErrExists {
+		app.logger.Errorf("recovery mark: failed to save mark %s: %v", mark.ID, err)
+		return
+	}
+	app.lastRecoveryMark = fire
+	if err == nil {
+		app.logger.Infof("recovery mark: recorded scheduled mark %s at %s", mark.ID, mark.GTIDs)
+	}
+}
+
+// CliRecoveryMark records recovery mark and prints it, so backup tooling may save its id along with backup
+func (app *App) CliRecoveryMark(format string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.newDBCluster()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.cluster.Close()
+	err = app.cluster.UpdateHostsInfo()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Errorf("failed to get current master: %v", err)
+		return 1
+	}
+	mark, err := app.makeRecoveryMark(time.Now().UTC().Format(recoveryMarkIDFormat), master)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	mark.Operator = app.currentOperator()
+	err = app.saveRecoveryMark(mark)
+	if err == dcs.ErrExists {
+		app.logger.Errorf("recovery mark %s is already recorded, retry in a second", mark.ID)
+		return 1
+	}
+	if err != nil {
+		app.logger.Errorf("failed to save recovery mark: %v", err)
+		return 1
+	}
+	return app.printCliOutput(mark, format)
+}
+
+// CliRecoveryStatus prints recorded recovery marks and the newest mark reached by each host
+func (app *App) CliRecoveryStatus(format string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	marks, err := app.getRecoveryMarks()
+	if err != nil {
+		app.logger.Errorf("failed to get recovery marks: %v", err)
+		return 1
+	}
+	markList := make([]map[string]interface{}, 0, len(marks))
+	for _, mark := range marks {
+		markList = append(markList, map[string]interface{}{
+			"id":         mark.ID,
+			"created_at": mark.CreatedAt.Format(time.RFC3339),
+			"master":     mark.Master,
+			"epoch":      mark.Epoch,
+			"scheduled":  mark.Scheduled,
+			"gtids":      mark.GTIDs,
+		})
+	}
+	recovery, err := app.GetHostsOnRecovery()
+	if err != nil {
+		app.logger.Errorf("failed to get hosts on recovery: %v", err)
+		return 1
+	}
+	hosts := make(map[string]string)
+	for _, host := range app.getKnownHosts() {
+		nodeState := new(NodeState)
+		err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, host), nodeState)
+		executed := ""
+		if err == nil {
+			executed = executedGTIDs(nodeState)
+		}
+		status := "unknown position"
+		if executed != "" {
+			status = "reached no recorded mark"
+			if mark := newestReachedMark(gtids.ParseGtidSet(executed), marks); mark != nil {
+				status = "reached " + mark.ID
+			}
+		}
+		if util.ContainsString(recovery, host) {
+			status += ", on recovery"
+		}
+		hosts[host] = status
+	}
+	return app.printCliOutput(map[string]interface{}{"marks": markList, "hosts": hosts}, format)
+}
+
+// CliRecoveryRestored puts host restored from backup on recovery,
+// so it rejoins only after checks of recovery, including validation against recorded marks
+func (app *App) CliRecoveryRestored(host string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	if !util.ContainsString(app.getKnownHosts(), host) {
+		app.logger.Errorf("host %s is not in cluster", host)
+		return 1
+	}
+	err = app.SetRecovery(host)
+	if err != nil {
+		app.logger.Errorf("failed to set %s on recovery: %v", host, err)
+		return 1
+	}
+	app.recordEvent(HistoryEvent{Type: EventRejoin, Host: host, Message: "restored from backup, waiting for recovery", Operator: app.currentOperator()})
+	fmt.Printf("%s is on recovery, it rejoins after its position is validated\n", host)
+	return 0
+}
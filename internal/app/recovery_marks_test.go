@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+const recoveryTestUUID = "6dcd2a5b-4ad4-11ee-8a8e-0242ac120002"
+
+func recoveryTestGTIDs(last int) string {
+	return fmt.Sprintf("%s:1-%d", recoveryTestUUID, last)
+}
+
+func TestNewestReachedMark(t *testing.T) {
+	marks := []*RecoveryMark{
+		{ID: "20261015T000000Z", GTIDs: recoveryTestGTIDs(100)},
+		{ID: "20261015T010000Z", GTIDs: recoveryTestGTIDs(200)},
+		{ID: "20261015T020000Z", GTIDs: recoveryTestGTIDs(300)},
+	}
+	require.Equal(t, "20261015T010000Z", newestReachedMark(gtids.ParseGtidSet(recoveryTestGTIDs(250)), marks).ID)
+	require.Equal(t, "20261015T020000Z", newestReachedMark(gtids.ParseGtidSet(recoveryTestGTIDs(300)), marks).ID)
+	require.Nil(t, newestReachedMark(gtids.ParseGtidSet(recoveryTestGTIDs(50)), marks))
+	// host restored from backup of another cluster
+	require.Nil(t, newestReachedMark(gtids.ParseGtidSet("0a8c8a2e-4ad5-11ee-8a8e-0242ac120002:1-1000"), marks))
+}
+
+func TestRecoveryMarks(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().RecoveryMarks.Keep = 2
+
+	// nothing to validate against
+	app.cfg().RecoveryMarks.ValidateRejoin = true
+	require.NoError(t, app.validateRecoveryLineage("mysql2", gtids.ParseGtidSet(recoveryTestGTIDs(10))))
+
+	for i, id := range []string{"20261015T000000Z", "20261015T010000Z", "20261015T020000Z"} {
+		require.NoError(t, app.saveRecoveryMark(&RecoveryMark{ID: id, Master: "mysql1", GTIDs: recoveryTestGTIDs(100 * (i + 1))}))
+	}
+	require.Error(t, app.saveRecoveryMark(&RecoveryMark{ID: "20261015T020000Z"}))
+	marks, err := app.getRecoveryMarks()
+	require.NoError(t, err)
+	require.Len(t, marks, 2)
+	require.Equal(t, "20261015T010000Z", marks[0].ID)
+
+	require.NoError(t, app.validateRecoveryLineage("mysql2", gtids.ParseGtidSet(recoveryTestGTIDs(250))))
+	err = app.validateRecoveryLineage("mysql2", gtids.ParseGtidSet(recoveryTestGTIDs(150)))
+	require.ErrorIs(t, err, errNoRecoveryMarkReached)
+	require.EqualError(t, err, "no recovery mark reached: mysql2 has not reached any of 2 recorded recovery marks, the oldest is 20261015T010000Z")
+
+	app.cfg().RecoveryMarks.ValidateRejoin = false
+	require.NoError(t, app.validateRecoveryLineage("mysql2", gtids.ParseGtidSet(recoveryTestGTIDs(150))))
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+// errantTransactions returns transactions of old master absent on new master
+func errantTransactions(oldMasterGTIDs, masterGTIDs gtids.GTIDSet) (string, error) {
+	errant, _, err := gtids.MissingTransactions(masterGTIDs, oldMasterGTIDs)
+	return errant, err
+}
+
+// rejoinOldMaster turns stale master, which came back after failover, to replica of current master.
+// Old master having transactions absent on current master can't replicate safely, it is flagged for resetup instead.
+func (app *App) rejoinOldMaster(host string, node *mysql.Node, master string) {
+	if request := app.unfinishedResetup(host); request != nil {
+		if request.Status == ResetupRequestFailed {
+			// failed resetup is left for operator, requesting it again would fail the same way
+			app.logger.Errorf("repair: resetup of stale master %s failed: %s, it should be resolved manually", host, request.Error)
+		} else {
+			app.logger.Infof("repair: stale master %s is waiting for resetup", host)
+		}
+		return
+	}
+	app.logger.Infof("repair: found stale master %s", host)
+	app.logger.Infof("repair: setting stale master %s offline", host)
+	err := app.stopReplicationOnMaster(node)
+	if err != nil {
+		app.logger.Errorf("repair: %s", err)
+	}
+	err = app.externalReplication.Stop(node)
+	if err != nil {
+		app.logger.Errorf("repair: %s", err)
+	}
+	err = app.externalReplication.Reset(node)
+	if err != nil {
+		app.logger.Errorf("repair: %s", err)
+	}
+
+	oldGTIDs, err := node.GTIDExecutedParsed()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get gtid executed from stale master %s: %v", host, err)
+		return
+	}
+	masterGTIDs, err := app.cluster.Get(master).GTIDExecutedParsed()
+	if err != nil {
+		app.logger.Errorf("repair: failed to get gtid executed from master %s: %v", master, err)
+		return
+	}
+	errant, err := errantTransactions(oldGTIDs, masterGTIDs)
+	if err != nil {
+		app.logger.Errorf("repair: failed to compare gtids of stale master %s and master %s: %v", host, master, err)
+		return
+	}
+
+	app.logger.Infof("repair: mark stale master %s for recovery", host)
+	err = app.SetRecovery(host)
+	if err != nil {
+		app.logger.Errorf("repair: error setting stale master %s for recovery: %s", host, err)
+	}
+
+	if errant != "" {
+		app.logger.Errorf("repair: stale master %s has transactions absent on master %s: %s, requesting resetup", host, master, errant)
+		request := &ResetupRequest{
+			Method:      ResetupMethodFile,
+			InitiatedBy: app.cfg().Hostname,
+			InitiatedAt: time.Now(),
+			Status:      ResetupRequestPending,
+		}
+		err = app.setResetupRequest(host, request)
+		if err != nil {
+			app.logger.Errorf("repair: failed to request resetup of stale master %s: %v", host, err)
+		}
+		app.recordEvent(HistoryEvent{Type: EventRejoin, Host: host,
+			Message: fmt.Sprintf("unsafe to rejoin %s, errant transactions %s, resetup requested", master, errant)})
+		return
+	}
+
+	app.logger.Infof("repair: turning stale master %s to new master %s", host, master)
+	err = app.performChangeMaster(host, master)
+	if err != nil {
+		app.logger.Errorf("repair: error turning stale master %s to new master: %s", host, err)
+		app.recordEvent(HistoryEvent{Type: EventRejoin, Host: host, Message: fmt.Sprintf("failed to rejoin %s: %v", master, err)})
+		return
+	}
+	app.recordEvent(HistoryEvent{Type: EventRejoin, Host: host, Message: fmt.Sprintf("rejoined as read-only replica of %s", master)})
+}
+
+// unfinishedResetup returns resetup request of host, which is not done: pending, running or failed one
+func (app *App) unfinishedResetup(host string) *ResetupRequest {
+	request, err := app.getResetupRequest(host)
+	if err != nil {
+		if err != dcs.ErrNotFound {
+			app.logger.Errorf("failed to get resetup request of %s: %v", host, err)
+		}
+		return nil
+	}
+	if request.Status == ResetupRequestDone {
+		return nil
+	}
+	return request
+}
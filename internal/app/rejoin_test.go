@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rejoinOldUUID = "6dbc0b04-4b09-43dc-86cc-9af852ded919"
+	rejoinNewUUID = "09978591-5754-4710-bf67-062880abe1b4"
+)
+
+func TestErrantTransactions(t *testing.T) {
+	master := mustGTIDSet(rejoinOldUUID + ":1-100," + rejoinNewUUID + ":1-10")
+
+	errant, err := errantTransactions(mustGTIDSet(rejoinOldUUID+":1-100"), master)
+	require.NoError(t, err)
+	require.Equal(t, "", errant)
+
+	errant, err = errantTransactions(mustGTIDSet(rejoinOldUUID+":1-105"), master)
+	require.NoError(t, err)
+	require.Equal(t, rejoinOldUUID+":101-105", errant)
+}
+
+func TestUnfinishedResetup(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	require.Nil(t, app.unfinishedResetup("mysql2"))
+	request := &ResetupRequest{Method: ResetupMethodFile, Status: ResetupRequestPending}
+	require.NoError(t, app.setResetupRequest("mysql2", request))
+	require.NotNil(t, app.unfinishedResetup("mysql2"))
+	// failed resetup is terminal, it is not requested again
+	request.Status = ResetupRequestFailed
+	require.NoError(t, app.setResetupRequest("mysql2", request))
+	require.Equal(t, ResetupRequestFailed, app.unfinishedResetup("mysql2").Status)
+	request.Status = ResetupRequestDone
+	require.NoError(t, app.setResetupRequest("mysql2", request))
+	require.Nil(t, app.unfinishedResetup("mysql2"))
+}
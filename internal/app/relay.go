@@ -0,0 +1,35 @@
+package app
+
+import (
+	"sort"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// getRelayHosts returns cascade hosts, which fan out replication to other cascade replicas
+func (app *App) getRelayHosts() ([]string, error) {
+	hosts, err := app.dcs.GetChildren(dcs.PathCascadeNodesPrefix)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var relays []string
+	for _, host := range hosts {
+		var cnc mysql.CascadeNodeConfiguration
+		err = app.dcs.Get(dcs.JoinPath(dcs.PathCascadeNodesPrefix, host), &cnc)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cnc.Relay {
+			relays = append(relays, host)
+		}
+	}
+	sort.Strings(relays)
+	return relays, nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestRelayFanOut(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+
+	relays, err := app.getRelayHosts()
+	require.NoError(t, err)
+	require.Empty(t, relays)
+
+	cascadeTopology := map[string]mysql.CascadeNodeConfiguration{
+		"relay1": {StreamFrom: "mysql1", Relay: true},
+		"leaf1":  {StreamFrom: "relay1"},
+	}
+	require.NoError(t, app.dcs.Create(dcs.PathCascadeNodesPrefix, nil))
+	for host, cnc := range cascadeTopology {
+		require.NoError(t, app.dcs.Set(dcs.JoinPath(dcs.PathCascadeNodesPrefix, host), cnc))
+	}
+	relays, err = app.getRelayHosts()
+	require.NoError(t, err)
+	require.Equal(t, []string{"relay1"}, relays)
+
+	lag := 0.0
+	clusterState := map[string]*NodeState{
+		"mysql1": {PingOk: true, IsMaster: true},
+		"relay1": {PingOk: true, IsCascade: true, SlaveState: &SlaveState{MasterHost: "mysql1", ReplicationState: mysql.ReplicationRunning, ReplicationLag: &lag}},
+		"leaf1":  {PingOk: true, IsCascade: true, SlaveState: &SlaveState{MasterHost: "mysql1", ReplicationState: mysql.ReplicationRunning, ReplicationLag: &lag}},
+	}
+	leaf, err := mysql.NewNode(app.config, app.logger, "leaf1")
+	require.NoError(t, err)
+	require.Equal(t, "relay1", app.findBestStreamFrom(leaf, clusterState, "mysql1", cascadeTopology))
+
+	// dead relay is bypassed
+	clusterState["relay1"].PingOk = false
+	require.Equal(t, "mysql1", app.findBestStreamFrom(leaf, clusterState, "mysql1", cascadeTopology))
+
+	// relay removed from cluster
+	delete(clusterState, "relay1")
+	delete(cascadeTopology, "relay1")
+	require.Equal(t, "mysql1", app.findBestStreamFrom(leaf, clusterState, "mysql1", cascadeTopology))
+}
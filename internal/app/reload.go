@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// reloadConfig rereads config file and applies parameters, which may be changed at runtime
+func (app *App) reloadConfig() {
+	newConfig, err := config.ReadFromFile(app.configFile)
+	if err != nil {
+		app.logger.Errorf("reload: keeping current config: %v", err)
+		return
+	}
+	// zookeeper credentials issued by Vault while reading config are applied on restart only,
+	// mysql ones are issued by running agent and rotated by vaultCredentialsRenewer
+	defer func() {
+		err := newConfig.RevokeVaultLeases()
+		if err != nil {
+			app.logger.Warnf("reload: %v", err)
+		}
+	}()
+	err = app.applyDCSHostOverrides(newConfig)
+	if err != nil {
+		app.logger.Errorf("reload: keeping current config: %v", err)
+		return
+	}
+	var applied, ignored []string
+	// goroutines keep reading current config, so changes are applied to its copy
+	app.config.Update(func(cfg *config.Config) {
+		credentialsChanged := reloadMySQLCredentials(cfg, newConfig)
+		applied, ignored = cfg.Reload(newConfig)
+		// running agent matches config file only if all its changes were applied
+		if len(ignored) == 0 {
+			cfg.ClusterHash = newConfig.ClusterHash
+		}
+		if credentialsChanged {
+			applied = append(applied, "mysql credentials")
+		}
+	})
+	if len(ignored) > 0 {
+		app.logger.Warnf("reload: changes of %v require restart", ignored)
+	}
+	if len(applied) == 0 {
+		app.logger.Infof("reload: nothing to apply")
+		return
+	}
+	err = app.logger.SetLevel(app.cfg().LogLevel)
+	if err != nil {
+		app.logger.Errorf("reload: failed to set log level: %v", err)
+	}
+	app.logger.Infof("reload: applied %v", applied)
+}
+
+// reloadMySQLCredentials applies changed MySQL user and passwords to new connections.
+// Credentials leased from Vault are rotated by vaultCredentialsRenewer instead
+func reloadMySQLCredentials(cfg, newConfig *config.Config) bool {
+	if cfg.MySQLCredentialsLease != nil {
+		return false
+	}
+	user, password := cfg.MySQLCredentials()
+	previous := cfg.MySQLPreviousPassword()
+	if newConfig.MySQL.User == user && newConfig.MySQL.Password == password && newConfig.MySQL.PreviousPassword == previous {
+		return false
+	}
+	cfg.SetMySQLPreviousPassword(newConfig.MySQL.PreviousPassword)
+	cfg.SetMySQLCredentials(newConfig.MySQL.User, newConfig.MySQL.Password)
+	return true
+}
+
+// writePidToLockFile makes running agent discoverable by `mysync config reload`
+func (app *App) writePidToLockFile() {
+	err := os.WriteFile(app.cfg().Lockfile, []byte(strconv.Itoa(os.Getpid())), 0644)
+	if err != nil {
+		app.logger.Warnf("failed to write pid to %s: %v", app.cfg().Lockfile, err)
+	}
+}
+
+// signalAgent sends signal to running agent found by its lockfile
+func (app *App) signalAgent(sig syscall.Signal) error {
+	data, err := os.ReadFile(app.cfg().Lockfile)
+	if err != nil {
+		return fmt.Errorf("failed to find running mysync: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("failed to find running mysync: malformed pid in %s", app.cfg().Lockfile)
+	}
+	err = syscall.Kill(pid, sig)
+	if err != nil {
+		return fmt.Errorf("failed to signal mysync (pid %d): %v", pid, err)
+	}
+	return nil
+}
+
+// CliReload asks running agent to reload config
+func (app *App) CliReload() int {
+	err := app.signalAgent(syscall.SIGHUP)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	fmt.Printf("config reload requested, see mysync log for result\n")
+	return 0
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	repairActionStartSlave        = "start_slave"
+	repairActionChangeMaster      = "change_master"
+	repairActionReplicationRepair = "replication_repair"
+)
+
+// repairBudget limits attempts of repair action on host: at most repair_budget_attempts
+// within repair_budget_window with exponentially growing cooldown between them
+type repairBudget struct {
+	attempts    []time.Time
+	cooldown    time.Duration
+	nextAttempt time.Time
+	exhausted   bool
+}
+
+func replicationRepairAction(channel string) string {
+	return fmt.Sprintf("%s on channel %q", repairActionReplicationRepair, channel)
+}
+
+func repairBudgetKey(host, action string) string {
+	return host + "/" + action
+}
+
+// expire forgets attempts made before the window
+func (budget *repairBudget) expire(now time.Time, window time.Duration) {
+	i := 0
+	for i < len(budget.attempts) && now.Sub(budget.attempts[i]) >= window {
+		i++
+	}
+	budget.attempts = budget.attempts[i:]
+}
+
+// repairAllowed checks that repair action on host fits into its budget.
+// Exhausted budget is reported once, so sick host is not hammered and the failure is not masked.
+func (app *App) repairAllowed(host, action string) bool {
+	if app.cfg().RepairBudgetAttempts == 0 {
+		return true
+	}
+	budget, ok := app.repairBudgets[repairBudgetKey(host, action)]
+	if !ok {
+		return true
+	}
+	now := time.Now()
+	budget.expire(now, app.cfg().RepairBudgetWindow)
+	if now.Before(budget.nextAttempt) {
+		return false
+	}
+	if len(budget.attempts) < app.cfg().RepairBudgetAttempts {
+		budget.exhausted = false
+		return true
+	}
+	if !budget.exhausted {
+		budget.exhausted = true
+		message := fmt.Sprintf("%s made %d attempts within %v and gave up until some of them expire", action, len(budget.attempts), app.cfg().RepairBudgetWindow)
+		app.logger.Errorf("repair: host %s: %s", host, message)
+		app.recordEvent(HistoryEvent{Type: EventRepairGivenUp, Host: host, Message: message})
+	}
+	return false
+}
+
+// repairAttempted records attempt of repair action on host and doubles cooldown before the next one
+func (app *App) repairAttempted(host, action string) {
+	key := repairBudgetKey(host, action)
+	budget, ok := app.repairBudgets[key]
+	if !ok {
+		budget = &repairBudget{cooldown: app.cfg().RepairCooldown}
+		app.repairBudgets[key] = budget
+	}
+	now := time.Now()
+	budget.attempts = append(budget.attempts, now)
+	budget.nextAttempt = now.Add(budget.cooldown)
+	budget.cooldown *= 2
+	if budget.cooldown > app.cfg().RepairCooldownMax {
+		budget.cooldown = app.cfg().RepairCooldownMax
+	}
+}
+
+// resetRepairBudget restores budget of repair action on host after the problem is gone
+func (app *App) resetRepairBudget(host, action string) {
+	delete(app.repairBudgets, repairBudgetKey(host, action))
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairBudget(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().RepairBudgetAttempts = 3
+	app.cfg().RepairCooldown = time.Millisecond
+	app.cfg().RepairCooldownMax = 2 * time.Millisecond
+	app.repairBudgets = make(map[string]*repairBudget)
+
+	for i := 0; i < 3; i++ {
+		require.True(t, app.repairAllowed("mysql2", repairActionStartSlave))
+		app.repairAttempted("mysql2", repairActionStartSlave)
+		// cooldown after attempt
+		require.False(t, app.repairAllowed("mysql2", repairActionStartSlave))
+		time.Sleep(3 * time.Millisecond)
+	}
+	require.Equal(t, 2*time.Millisecond, app.repairBudgets[repairBudgetKey("mysql2", repairActionStartSlave)].cooldown)
+
+	// budget is exhausted, event is recorded once
+	require.False(t, app.repairAllowed("mysql2", repairActionStartSlave))
+	require.False(t, app.repairAllowed("mysql2", repairActionStartSlave))
+	history, err := app.GetEventHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, EventRepairGivenUp, history[0].Type)
+	require.Equal(t, "mysql2", history[0].Host)
+
+	// other actions and hosts have their own budgets
+	require.True(t, app.repairAllowed("mysql2", repairActionChangeMaster))
+	require.True(t, app.repairAllowed("mysql3", repairActionStartSlave))
+
+	// attempts expire
+	app.cfg().RepairBudgetWindow = time.Millisecond
+	require.True(t, app.repairAllowed("mysql2", repairActionStartSlave))
+
+	app.resetRepairBudget("mysql2", repairActionStartSlave)
+	require.Empty(t, app.repairBudgets)
+}
@@ -32,8 +32,8 @@ func (app *App) MarkReplicationRunning(node *mysql.Node, channel string) {
 		return
 	}
 
-	if replState.cooldownPassed(app.config.ReplicationRepairCooldown) {
-		status, err := node.ReplicaStatusWithTimeout(app.config.DBTimeout, channel)
+	if replState.cooldownPassed(app.cfg().ReplicationRepairCooldown) {
+		status, err := node.ReplicaStatusWithTimeout(app.cfg().DBTimeout, channel)
 		if err != nil {
 			return
 		}
@@ -43,6 +43,7 @@ func (app *App) MarkReplicationRunning(node *mysql.Node, channel string) {
 
 		if gtids.IsSlaveAhead(newGtidSet, oldGtidSet) {
 			delete(app.replRepairState, key)
+			app.resetRepairBudget(node.Host(), replicationRepairAction(channel))
 		}
 	}
 }
@@ -54,7 +55,7 @@ func (app *App) TryRepairReplication(node *mysql.Node, master string, channel st
 		return
 	}
 
-	if !replState.cooldownPassed(app.config.ReplicationRepairCooldown) {
+	if !replState.cooldownPassed(app.cfg().ReplicationRepairCooldown) || !app.repairAllowed(node.Host(), replicationRepairAction(channel)) {
 		return
 	}
 
@@ -66,16 +67,20 @@ func (app *App) TryRepairReplication(node *mysql.Node, master string, channel st
 
 	algorithm := getRepairAlgorithm(algorithmType)
 	err = algorithm(app, node, master, channel)
+	app.repairAttempted(node.Host(), replicationRepairAction(channel))
+	event := HistoryEvent{Type: EventRepair, Host: node.Host(), Message: fmt.Sprintf("replication repair attempt %d on channel %q", count+1, channel)}
 	if err != nil {
 		app.logger.Errorf("repair error: %v", err)
+		event.Message += fmt.Sprintf(": %v", err)
 	}
+	app.recordEvent(event)
 
 	replState.History[algorithmType] = count + 1
 	replState.LastAttempt = time.Now()
 }
 
 func (app *App) makeReplStateKey(node *mysql.Node, channel string) string {
-	if channel == app.config.ExternalReplicationChannel {
+	if channel == app.cfg().ExternalReplicationChannel {
 		return fmt.Sprintf("%s-%s", node.Host(), channel)
 	}
 	return node.Host()
@@ -83,7 +88,7 @@ func (app *App) makeReplStateKey(node *mysql.Node, channel string) string {
 
 func StartSlaveAlgorithm(app *App, node *mysql.Node, _ string, channel string) error {
 	app.logger.Infof("repair: trying to repair replication using StartSlaveAlgorithm...")
-	if channel == app.config.ExternalReplicationChannel {
+	if channel == app.cfg().ExternalReplicationChannel {
 		return app.externalReplication.Start(node)
 	}
 	return node.StartSlave()
@@ -92,7 +97,7 @@ func StartSlaveAlgorithm(app *App, node *mysql.Node, _ string, channel string) e
 func ResetSlaveAlgorithm(app *App, node *mysql.Node, master string, channel string) error {
 	// TODO we don't want reset slave on external replication
 	// May be we should split algorithms by channel type (ext/int)
-	if channel == app.config.ExternalReplicationChannel {
+	if channel == app.cfg().ExternalReplicationChannel {
 		app.logger.Infof("external repair: don't want to use ResetSlaveAlgorithm, leaving")
 		return nil
 	}
@@ -140,7 +145,7 @@ func (app *App) getSuitableAlgorithmType(state *ReplicationRepairState) (Replica
 	for i := range app.getAlgorithmOrder() {
 		algorithmType := ReplicationRepairAlgorithmType(i)
 		count := state.History[algorithmType]
-		if count < app.config.ReplicationRepairMaxAttempts {
+		if count < app.cfg().ReplicationRepairMaxAttempts {
 			return algorithmType, count, nil
 		}
 	}
@@ -172,7 +177,7 @@ func (app *App) getOrCreateHostRepairState(stateKey, hostname, channel string) (
 }
 
 func (app *App) createRepairState(hostname, channel string) (*ReplicationRepairState, error) {
-	status, err := app.cluster.Get(hostname).ReplicaStatusWithTimeout(app.config.DBTimeout, channel)
+	status, err := app.cluster.Get(hostname).ReplicaStatusWithTimeout(app.cfg().DBTimeout, channel)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +205,7 @@ var aggressiveOrder = []ReplicationRepairAlgorithmType{
 }
 
 func (app *App) getAlgorithmOrder() []ReplicationRepairAlgorithmType {
-	if app.config.ReplicationRepairAggressiveMode {
+	if app.cfg().ReplicationRepairAggressiveMode {
 		return aggressiveOrder
 	} else {
 		return defaultOrder
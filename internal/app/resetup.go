@@ -0,0 +1,137 @@
+package app
+
+import (
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+const (
+	// ReplicaFailureRelayLogCorrupted means relay log can't be read or parsed
+	ReplicaFailureRelayLogCorrupted = "relay_log_corrupted"
+	// ReplicaFailureBinlogPurged means master has already purged binlogs replica needs
+	ReplicaFailureBinlogPurged = "binlog_purged"
+	// ReplicaFailureDataDrift means replica data differs from master
+	ReplicaFailureDataDrift = "data_drift"
+)
+
+// Last_SQL_Errno codes, meaning that relay log is broken
+var relayLogCorruptedSQLErrorCodes = map[int]interface{}{
+	// Relay log read failure
+	1594: nil,
+	// 8.0
+	13121: nil,
+}
+
+// Last_SQL_Errno codes, meaning that replica data differs from master
+var dataDriftSQLErrorCodes = map[int]interface{}{
+	// Can't find record
+	1032: nil,
+	// Duplicate entry
+	1062: nil,
+	// Table doesn't exist
+	1146: nil,
+}
+
+// classifyReplicaFailure returns kind of replication failure, which can't be fixed
+// by restarting replication, or empty string
+func classifyReplicaFailure(lastIOErrno, lastSQLErrno int) string {
+	if _, ok := relayLogCorruptedSQLErrorCodes[lastSQLErrno]; ok {
+		return ReplicaFailureRelayLogCorrupted
+	}
+	if _, ok := dataDriftSQLErrorCodes[lastSQLErrno]; ok {
+		return ReplicaFailureDataDrift
+	}
+	if _, ok := permanentReplicationLostIOErrorCodes[lastIOErrno]; ok {
+		return ReplicaFailureBinlogPurged
+	}
+	return ""
+}
+
+// checkReplicaResetup schedules resetup of local replica, if its replication
+// is broken beyond repair for longer than auto_resetup_delay
+func (app *App) checkReplicaResetup() {
+	if !app.cfg().AutoResetup {
+		return
+	}
+	if app.doesResetupFileExist() {
+		return
+	}
+	localNode := app.cluster.Local()
+	sstatus, err := localNode.GetReplicaStatus()
+	if err != nil {
+		app.logger.Errorf("resetup: host %s failed to get slave status %v", localNode.Host(), err)
+		return
+	}
+	failure := ""
+	if sstatus != nil && sstatus.ReplicationState() == mysql.ReplicationError {
+		failure = classifyReplicaFailure(sstatus.GetLastIOErrno(), sstatus.GetLastSQLErrno())
+	}
+	if failure == "" {
+		app.replicaFailedAt = time.Time{}
+		return
+	}
+	if app.replicaFailedAt.IsZero() {
+		app.replicaFailedAt = time.Now()
+	}
+	failingTime := time.Since(app.replicaFailedAt)
+	if failingTime < app.cfg().AutoResetupDelay {
+		app.logger.Warnf("resetup: replication on %s is broken (%s), resetup in %v", localNode.Host(), failure, app.cfg().AutoResetupDelay-failingTime)
+		return
+	}
+
+	acquired, err := app.acquireSlot(pathResetupSlots, app.cfg().AutoResetupConcurrency)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to acquire resetup slot: %v", err)
+		return
+	}
+	if !acquired {
+		app.logger.Warnf("resetup: replication on %s is broken (%s), but %d hosts are already on resetup", localNode.Host(), failure, app.cfg().AutoResetupConcurrency)
+		return
+	}
+	app.holdsResetupSlot = true
+	app.logger.Errorf("resetup: replication on %s is broken (%s), need RESETUP", localNode.Host(), failure)
+	app.writeResetupFile(failure)
+	app.SetResetupStatus()
+}
+
+// releaseResetupSlot frees slot taken by auto resetup, when resetup is over
+func (app *App) releaseResetupSlot() {
+	if !app.holdsResetupSlot || app.doesResetupFileExist() {
+		return
+	}
+	err := app.releaseSlot(pathResetupSlots)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to release resetup slot: %v", err)
+		return
+	}
+	app.holdsResetupSlot = false
+}
+
+func (app *App) countHostsOnResetup() (int, error) {
+	hosts, err := app.dcs.GetChildren(pathResetupStatus)
+	if err == dcs.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, host := range hosts {
+		if host == app.cfg().Hostname {
+			continue
+		}
+		status, err := app.GetResetupStatus(host)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if status.Status {
+			count++
+		}
+	}
+	return count, nil
+}
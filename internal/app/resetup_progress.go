@@ -0,0 +1,123 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+const cloneStateNotStarted = "Not Started"
+
+// cloneProgress summarizes stages of CLONE INSTANCE, which are reported in execution order,
+// so the last started stage is the current phase
+func cloneProgress(stages []mysql.CloneStage) *ResetupProgress {
+	if len(stages) == 0 {
+		return nil
+	}
+	progress := &ResetupProgress{}
+	for _, stage := range stages {
+		progress.BytesCopied += stage.Data
+		progress.BytesTotal += stage.Estimate
+		if stage.State != cloneStateNotStarted {
+			progress.Phase = stage.Stage
+		}
+	}
+	if progress.Phase == "" {
+		progress.Phase = stages[0].Stage
+	}
+	return progress
+}
+
+// readResetupProgressFile reads progress written by resetup command, e.g.
+// {"phase": "copy", "bytes_copied": 1024, "bytes_total": 4096}
+func readResetupProgressFile(path string) (*ResetupProgress, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	progress := new(ResetupProgress)
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("malformed %s: %s", path, err)
+	}
+	return progress, nil
+}
+
+// estimateResetupEnd extrapolates average copy rate since start
+func estimateResetupEnd(progress *ResetupProgress, now time.Time) *time.Time {
+	elapsed := now.Sub(progress.StartedAt)
+	if progress.BytesTotal <= 0 || progress.BytesCopied <= 0 || elapsed <= 0 {
+		return nil
+	}
+	left := progress.BytesTotal - progress.BytesCopied
+	if left < 0 {
+		left = 0
+	}
+	eta := now.Add(time.Duration(float64(elapsed) * float64(left) / float64(progress.BytesCopied))).Truncate(time.Second)
+	return &eta
+}
+
+func (app *App) getResetupProgress(method string) (*ResetupProgress, error) {
+	if method == ResetupMethodClone {
+		stages, err := app.cluster.Local().GetCloneProgress()
+		if err != nil {
+			return nil, err
+		}
+		return cloneProgress(stages), nil
+	}
+	progress, err := readResetupProgressFile(app.cfg().ResetupProgressFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return progress, err
+}
+
+// reportResetupProgress publishes progress of running resetup to dcs and metrics
+// until returned function is called
+func (app *App) reportResetupProgress(request *ResetupRequest) (stop func()) {
+	host := app.cfg().Hostname
+	started := time.Now()
+	if request.Method != ResetupMethodClone {
+		// progress of previous resetup would be misleading
+		_ = os.Remove(app.cfg().ResetupProgressFile)
+	}
+	report := *request
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(app.cfg().ResetupProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			progress, err := app.getResetupProgress(report.Method)
+			if err != nil {
+				app.logger.Warnf("resetup: failed to get progress: %v", err)
+				progress = nil
+			}
+			if progress != nil {
+				progress.Donor = report.Donor
+				progress.StartedAt = started
+				progress.ETA = estimateResetupEnd(progress, time.Now())
+				report.Progress = progress
+				app.logger.Infof("resetup: progress of %s: %s", host, progress)
+				app.emitResetupMetrics(progress)
+			}
+			// request is published even without progress, as its updated_at is a heartbeat of running resetup
+			if err := app.setResetupRequest(host, &report); err != nil {
+				app.logger.Errorf("resetup: failed to publish progress: %v", err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+		app.emitResetupMetrics(nil)
+	}
+}
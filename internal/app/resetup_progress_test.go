@@ -0,0 +1,75 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestCloneProgress(t *testing.T) {
+	require.Nil(t, cloneProgress(nil))
+
+	progress := cloneProgress([]mysql.CloneStage{
+		{Stage: "DROP DATA", State: "Completed"},
+		{Stage: "FILE COPY", State: "In Progress", Estimate: 1000, Data: 400},
+		{Stage: "PAGE COPY", State: "Not Started", Estimate: 100},
+		{Stage: "REDO COPY", State: "Not Started"},
+	})
+	require.Equal(t, "FILE COPY", progress.Phase)
+	require.Equal(t, int64(400), progress.BytesCopied)
+	require.Equal(t, int64(1100), progress.BytesTotal)
+
+	progress = cloneProgress([]mysql.CloneStage{
+		{Stage: "DROP DATA", State: "Not Started"},
+		{Stage: "FILE COPY", State: "Not Started"},
+	})
+	require.Equal(t, "DROP DATA", progress.Phase)
+}
+
+func TestReadResetupProgressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress")
+	_, err := readResetupProgressFile(path)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"phase": "xtrabackup", "bytes_copied": 1024, "bytes_total": 4096}`), 0o644))
+	progress, err := readResetupProgressFile(path)
+	require.NoError(t, err)
+	require.Equal(t, &ResetupProgress{Phase: "xtrabackup", BytesCopied: 1024, BytesTotal: 4096}, progress)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"phase": `), 0o644))
+	_, err = readResetupProgressFile(path)
+	require.Error(t, err)
+}
+
+func TestEstimateResetupEnd(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	progress := &ResetupProgress{StartedAt: now.Add(-time.Hour), BytesCopied: 1 << 30, BytesTotal: 4 << 30}
+	require.Equal(t, now.Add(3*time.Hour), *estimateResetupEnd(progress, now))
+
+	progress.BytesTotal = 0
+	require.Nil(t, estimateResetupEnd(progress, now))
+
+	progress = &ResetupProgress{StartedAt: now, BytesTotal: 1 << 30}
+	require.Nil(t, estimateResetupEnd(progress, now.Add(time.Minute)))
+}
+
+func TestResetupRequestStringWithProgress(t *testing.T) {
+	eta := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	request := &ResetupRequest{
+		Method:      ResetupMethodClone,
+		Donor:       "db2",
+		InitiatedBy: "db1",
+		InitiatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Status:      ResetupRequestRunning,
+		Progress:    &ResetupProgress{Phase: "FILE COPY", BytesCopied: 1 << 30, BytesTotal: 4 << 30, ETA: &eta},
+	}
+	require.Contains(t, request.String(), ": FILE COPY 1.0/4.0 GiB (25.0%), ETA 2024-01-01T15:00:00Z>")
+
+	request.Status = ResetupRequestDone
+	require.NotContains(t, request.String(), "FILE COPY")
+}
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/util"
+)
+
+var resetupMethods = []string{ResetupMethodClone, ResetupMethodXtrabackup, ResetupMethodScript, ResetupMethodFile}
+
+func (app *App) getResetupRequest(host string) (*ResetupRequest, error) {
+	request := new(ResetupRequest)
+	err := app.dcs.Get(dcs.JoinPath(pathResetupRequests, host), request)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (app *App) setResetupRequest(host string, request *ResetupRequest) error {
+	err := app.dcs.Create(pathResetupRequests, nil)
+	if err != nil && err != dcs.ErrExists {
+		return err
+	}
+	request.UpdatedAt = time.Now()
+	return app.dcs.Set(dcs.JoinPath(pathResetupRequests, host), request)
+}
+
+// checkResetupRequest starts resetup of local replica requested by `mysync host resetup`.
+// Resetup may take hours, so it runs in background and recovery checks go on meanwhile
+func (app *App) checkResetupRequest() {
+	if app.resetupRunning.Load() {
+		return
+	}
+	host := app.cfg().Hostname
+	request, err := app.getResetupRequest(host)
+	if err == dcs.ErrNotFound {
+		return
+	}
+	if err != nil {
+		app.logger.Errorf("resetup: failed to get resetup request: %v", err)
+		return
+	}
+	if request.Status != ResetupRequestPending {
+		return
+	}
+
+	var master string
+	err = app.dcs.Get(pathMasterNode, &master)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("resetup: failed to get current master from dcs: %v", err)
+		return
+	}
+	if master == host {
+		app.failResetupRequest(request, fmt.Errorf("%s is master", host))
+		return
+	}
+	if request.Donor == host {
+		app.failResetupRequest(request, fmt.Errorf("%s can't be donor for itself", host))
+		return
+	}
+	if request.Donor == "" && request.Method != ResetupMethodFile {
+		donor, err := app.selectResetupDonor(host)
+		if err != nil {
+			// request stays pending until some donor is available
+			app.logger.Warnf("resetup: failed to select donor for %s: %v", host, err)
+			return
+		}
+		app.logger.Infof("resetup: donor %s is selected by resetup_donor_policy", donor)
+		request.Donor = donor
+	}
+
+	app.logger.Infof("resetup: rebuilding %s via %s, donor %q, requested by %s", host, request.Method, request.Donor, request.InitiatedBy)
+	request.Status = ResetupRequestRunning
+	err = app.setResetupRequest(host, request)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to update resetup request: %v", err)
+		app.releaseDonorSlot(request.Donor)
+		return
+	}
+	err = app.setResetupStatus(host, true)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to set resetup status: %v", err)
+	}
+
+	app.resetupRunning.Store(true)
+	go app.runResetupRequest(request)
+}
+
+// runResetupRequest performs resetup and reports its result,
+// replication is restored if resetup failed, so replica is left as it was before
+func (app *App) runResetupRequest(request *ResetupRequest) {
+	defer app.resetupRunning.Store(false)
+	host := app.cfg().Hostname
+	stopProgress := app.reportResetupProgress(request)
+	err := app.performResetup(request)
+	stopProgress()
+	app.releaseDonorSlot(request.Donor)
+	if err != nil {
+		app.restoreReplicationAfterResetup(request)
+		app.failResetupRequest(request, err)
+		return
+	}
+	request.Status = ResetupRequestDone
+	err = app.setResetupRequest(host, request)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to update resetup request: %v", err)
+	}
+	app.logger.Infof("resetup: %s was rebuilt via %s", host, request.Method)
+}
+
+func (app *App) performResetup(request *ResetupRequest) error {
+	localNode := app.cluster.Local()
+	cause := fmt.Sprintf("requested by %s via %s", request.InitiatedBy, request.Method)
+	switch request.Method {
+	case ResetupMethodFile:
+		app.writeResetupFile(cause)
+		return nil
+	case ResetupMethodClone:
+		app.recordEvent(HistoryEvent{Type: EventResetupRequired, Host: localNode.Host(), Cause: cause})
+		err := localNode.SetOffline()
+		if err != nil {
+			return fmt.Errorf("failed to set offline: %v", err)
+		}
+		err = localNode.StopSlave()
+		if err != nil {
+			return fmt.Errorf("failed to stop replication: %v", err)
+		}
+		// clone has no zstd, its own network compression is used instead
+		err = localNode.SetCloneThrottling(app.resetupBandwidth(), app.cfg().ResetupCompression != "")
+		if err != nil {
+			return fmt.Errorf("failed to set clone throttling: %v", err)
+		}
+		err = localNode.CloneInstance(request.Donor, app.cfg().ResetupTimeout)
+		if mysql.IsErrorCloneRestartFailed(err) {
+			app.logger.Warnf("resetup: data was cloned, but mysql should be restarted manually")
+			return nil
+		}
+		if mysql.IsErrorConnectionLost(err) {
+			// clone restarts mysqld when data is copied, dropping connection of CLONE INSTANCE
+			return app.waitCloneRestart(localNode)
+		}
+		return err
+	default:
+		app.recordEvent(HistoryEvent{Type: EventResetupRequired, Host: localNode.Host(), Cause: cause})
+		return localNode.RunResetupCommand(request.Method, request.Donor, app.resetupBandwidth(), app.cfg().ResetupCompression, app.cfg().ResetupTimeout)
+	}
+}
+
+// waitCloneRestart waits for mysqld to come back after restart by CLONE INSTANCE
+// and checks that clone has completed
+func (app *App) waitCloneRestart(node *mysql.Node) error {
+	app.logger.Infof("resetup: connection was lost during clone, waiting for mysql to restart")
+	deadline := time.Now().Add(app.cfg().ResetupCloneRestartTimeout)
+	for {
+		status, err := node.GetCloneStatus()
+		if err == nil {
+			if status == nil {
+				return fmt.Errorf("connection was lost during clone, but clone status is empty")
+			}
+			if status.State != mysql.CloneStateCompleted {
+				return fmt.Errorf("clone is %s after restart: %d %s", status.State, status.ErrorNo, status.ErrorMessage)
+			}
+			app.logger.Infof("resetup: mysql restarted after clone completed")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mysql did not come back within %v after clone: %v", app.cfg().ResetupCloneRestartTimeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// restoreReplicationAfterResetup brings replica back to work after failed resetup, best-effort
+func (app *App) restoreReplicationAfterResetup(request *ResetupRequest) {
+	if request.Method == ResetupMethodFile {
+		return
+	}
+	localNode := app.cluster.Local()
+	err := localNode.StartSlave()
+	if err != nil {
+		app.logger.Errorf("resetup: failed to start replication after failed resetup: %v", err)
+		return
+	}
+	err = localNode.SetOnline()
+	if err != nil {
+		app.logger.Errorf("resetup: failed to set online after failed resetup: %v", err)
+	}
+}
+
+// resetupBandwidth returns bandwidth limit of local resetup, cluster-wide limit
+// is split equally between hosts on resetup
+func (app *App) resetupBandwidth() int64 {
+	limit := app.cfg().ResetupBandwidth
+	if app.cfg().ResetupClusterBandwidth == 0 {
+		return limit
+	}
+	others, err := app.countHostsOnResetup()
+	if err != nil {
+		app.logger.Warnf("resetup: failed to count hosts on resetup, assuming none: %v", err)
+	}
+	return splitResetupBandwidth(limit, app.cfg().ResetupClusterBandwidth, others+1)
+}
+
+func splitResetupBandwidth(hostLimit, clusterLimit int64, hosts int) int64 {
+	share := clusterLimit / int64(hosts)
+	if share < 1 {
+		share = 1
+	}
+	if hostLimit == 0 || share < hostLimit {
+		return share
+	}
+	return hostLimit
+}
+
+// releaseDonorSlot frees slot of donor taken by selectResetupDonor, if any
+func (app *App) releaseDonorSlot(donor string) {
+	if donor == "" || app.cfg().ResetupDonorPolicy.MaxConcurrent == 0 {
+		return
+	}
+	err := app.releaseSlot(dcs.JoinPath(pathDonorSlots, donor))
+	if err != nil {
+		app.logger.Errorf("resetup: failed to release slot of donor %s: %v", donor, err)
+	}
+}
+
+func (app *App) failResetupRequest(request *ResetupRequest, err error) {
+	app.logger.Errorf("resetup: failed to rebuild %s: %v", app.cfg().Hostname, err)
+	request.Status = ResetupRequestFailed
+	request.Error = err.Error()
+	err = app.setResetupRequest(app.cfg().Hostname, request)
+	if err != nil {
+		app.logger.Errorf("resetup: failed to update resetup request: %v", err)
+	}
+}
+
+// CliHostResetup requests resetup of replica, which is performed by mysync on that host
+func (app *App) CliHostResetup(host, donor, method string) int {
+	if !util.ContainsString(resetupMethods, method) {
+		app.logger.Errorf("unknown resetup method %q, expected one of %v", method, resetupMethods)
+		return 1
+	}
+	if donor == host {
+		app.logger.Errorf("host %s can't be donor for itself", host)
+		return 1
+	}
+
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	hosts := app.getKnownHosts()
+	for _, h := range []string{host, donor} {
+		if h != "" && !util.ContainsString(hosts, h) {
+			app.logger.Errorf("host %s is not in cluster", h)
+			return 1
+		}
+	}
+	if donor != "" {
+		relays, err := app.getRelayHosts()
+		if err != nil {
+			app.logger.Errorf("failed to get relay hosts: %v", err)
+			return 1
+		}
+		if util.ContainsString(relays, donor) {
+			app.logger.Errorf("host %s is relay and holds no data to copy", donor)
+			return 1
+		}
+	}
+	var master string
+	err = app.dcs.Get(pathMasterNode, &master)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("failed to get current master: %v", err)
+		return 1
+	}
+	if master == host {
+		app.logger.Errorf("host %s is master, switch it over before resetup", host)
+		return 1
+	}
+
+	existing, err := app.getResetupRequest(host)
+	if err != nil && err != dcs.ErrNotFound {
+		app.logger.Errorf("failed to get resetup request: %v", err)
+		return 1
+	}
+	if existing != nil && existing.Status == ResetupRequestRunning && time.Since(existing.UpdatedAt) < app.cfg().ResetupTimeout {
+		app.logger.Errorf("resetup of %s is already running: %s", host, existing)
+		return 1
+	}
+
+	request := &ResetupRequest{
+		Donor:       donor,
+		Method:      method,
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: time.Now(),
+		Status:      ResetupRequestPending,
+	}
+	err = app.setResetupRequest(host, request)
+	if err != nil {
+		app.logger.Errorf("failed to request resetup: %v", err)
+		return 1
+	}
+	fmt.Printf("resetup of %s requested, see progress in `mysync info`\n", host)
+	return 0
+}
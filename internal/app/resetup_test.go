@@ -0,0 +1,27 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyReplicaFailure(t *testing.T) {
+	require.Equal(t, "", classifyReplicaFailure(0, 0))
+	require.Equal(t, "", classifyReplicaFailure(2003, 0))
+	require.Equal(t, ReplicaFailureRelayLogCorrupted, classifyReplicaFailure(0, 1594))
+	require.Equal(t, ReplicaFailureDataDrift, classifyReplicaFailure(0, 1062))
+	require.Equal(t, ReplicaFailureBinlogPurged, classifyReplicaFailure(13114, 0))
+	// sql thread failure is more specific
+	require.Equal(t, ReplicaFailureDataDrift, classifyReplicaFailure(1236, 1032))
+}
+
+func TestSplitResetupBandwidth(t *testing.T) {
+	// only cluster-wide limit
+	require.Equal(t, int64(50), splitResetupBandwidth(0, 100, 2))
+	// host limit is lower than share
+	require.Equal(t, int64(30), splitResetupBandwidth(30, 100, 2))
+	// share is lower than host limit
+	require.Equal(t, int64(25), splitResetupBandwidth(30, 100, 4))
+	require.Equal(t, int64(1), splitResetupBandwidth(0, 1, 3))
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// restartSignal asks running agent to replace itself with the current mysync binary
+const restartSignal = syscall.SIGUSR1
+
+// prepareRestart reserves manager lock for the restarted agent on this host.
+// ZooKeeper session can't be passed to another process, so the lock is released with the session,
+// but other agents don't take it until the restarted one does or manager_handoff_timeout expires
+func (app *App) prepareRestart() {
+	app.restarting = true
+	if app.state != stateManager || !app.dcs.IsConnected() {
+		return
+	}
+	host := app.cfg().Hostname
+	now := time.Now()
+	handoff := &ManagerHandoff{
+		From:               host,
+		To:                 host,
+		InitiatedBy:        host,
+		InitiatedAt:        now,
+		ExpiresAt:          now.Add(app.cfg().ManagerHandoffTimeout),
+		ReleasedAt:         &now,
+		NodeFailedAt:       app.nodeFailedAt,
+		StreamFromFailedAt: app.streamFromFailedAt,
+	}
+	err := app.dcs.Set(pathManagerHandoff, handoff)
+	if err != nil {
+		app.logger.Errorf("restart: failed to reserve manager lock, another agent may become manager: %v", err)
+		return
+	}
+	app.logger.Infof("restart: manager lock reserved until %s", handoff.ExpiresAt.Format(time.RFC3339))
+}
+
+// RestartRequested returns true if Run returned to restart agent
+func (app *App) RestartRequested() bool {
+	return app.restarting
+}
+
+// Reexec replaces process with mysync binary, which may be upgraded since start.
+// It returns only on failure
+func (app *App) Reexec() int {
+	binary, err := os.Executable()
+	if err == nil {
+		app.logger.Infof("restart: executing %s", binary)
+		err = syscall.Exec(binary, os.Args, os.Environ())
+	}
+	app.logger.Errorf("restart: failed to exec mysync: %v", err)
+	return 1
+}
+
+// CliRestart asks running agent to restart keeping manager role
+func (app *App) CliRestart() int {
+	err := app.signalAgent(restartSignal)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	fmt.Printf("restart requested, see mysync log for result\n")
+	return 0
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// semiSyncStall tracks master blocked waiting for semi sync ACKs, as seen by manager
+type semiSyncStall struct {
+	master string
+	// since when sessions of master are waiting for ACK without any commit acknowledged, zero if they are not
+	since time.Time
+	// acknowledged commits of master at last observation
+	yesTx int64
+	// since when master has no waiting sessions, while relaxed
+	clearSince time.Time
+	reported   bool
+	// wait_for_slave_count lowered by mitigation, nil if it is not lowered
+	relaxed *int
+}
+
+// observe applies wait status of master and returns how long master is stalled.
+// Master is stalled while sessions are waiting and no commit is acknowledged:
+// sessions waiting on busy master with ACKs coming are not a stall
+func (s *semiSyncStall) observe(master string, status *mysql.SemiSyncWaitStatus, now time.Time) time.Duration {
+	if s.master != master {
+		*s = semiSyncStall{master: master, yesTx: status.YesTx}
+	}
+	progress := status.YesTx != s.yesTx
+	s.yesTx = status.YesTx
+	if status.WaitSessions == 0 {
+		s.since = time.Time{}
+		s.reported = false
+		if s.relaxed != nil && s.clearSince.IsZero() {
+			s.clearSince = now
+		}
+		return 0
+	}
+	s.clearSince = time.Time{}
+	if s.since.IsZero() || progress {
+		s.since = now
+	}
+	return now.Sub(s.since)
+}
+
+// relaxedWaitCount returns wait_for_slave_count to set on stalled master:
+// connected semi sync replicas, or one less than current count if they are connected but not acknowledging
+func relaxedWaitCount(current, clients int) int {
+	if clients < current {
+		return clients
+	}
+	return max(current-1, 0)
+}
+
+// capWaitSlaveCount keeps wait_for_slave_count of master lowered by stall mitigation
+func (app *App) capWaitSlaveCount(master string, waitSlaveCount int) int {
+	stall := &app.semiSyncStall
+	if stall.master == master && stall.relaxed != nil && waitSlaveCount > *stall.relaxed {
+		return *stall.relaxed
+	}
+	return waitSlaveCount
+}
+
+// checkSemiSyncStall detects master, which sessions are waiting for semi sync ACK longer than
+// semi_sync_stall_timeout, and applies semi_sync_stall_mitigation
+func (app *App) checkSemiSyncStall(clusterState map[string]*NodeState, activeNodes []string, master string) {
+	if !app.cfg().SemiSync || app.cfg().SemiSyncStallTimeout == 0 {
+		return
+	}
+	masterNode := app.cluster.Get(master)
+	status, err := masterNode.SemiSyncWaitStatus()
+	if err != nil {
+		app.logger.Errorf("semisync: failed to get wait status of %s: %v", master, err)
+		return
+	}
+	now := time.Now()
+	stall := &app.semiSyncStall
+	stalled := stall.observe(master, status, now)
+	if stall.relaxed != nil && !stall.clearSince.IsZero() && now.Sub(stall.clearSince) >= app.cfg().SemiSyncStallTimeout {
+		app.logger.Infof("semisync: master %s is not stalled for %v, wait_for_slave_count is not capped anymore", master, now.Sub(stall.clearSince))
+		app.recordEvent(HistoryEvent{Type: EventSemiSyncStall, Host: master, Message: "stall is over, wait count restored"})
+		stall.relaxed = nil
+		stall.clearSince = time.Time{}
+		return
+	}
+	if stalled < app.cfg().SemiSyncStallTimeout {
+		return
+	}
+	message := fmt.Sprintf("%d sessions wait for semi-sync ACK for %v, %d semi-sync replicas connected",
+		status.WaitSessions, stalled.Round(time.Second), status.Clients)
+	app.logger.Errorf("semisync: master %s is stalled: %s", master, message)
+
+	switch app.cfg().SemiSyncStallMitigation {
+	case config.SemiSyncStallReport:
+		if !stall.reported {
+			app.recordEvent(HistoryEvent{Type: EventSemiSyncStall, Host: master, Message: message})
+			stall.reported = true
+		}
+	case config.SemiSyncStallRelax:
+		masterState := clusterState[master]
+		if masterState == nil || masterState.SemiSyncState == nil || !masterState.SemiSyncState.MasterEnabled {
+			return
+		}
+		current := masterState.SemiSyncState.WaitSlaveCount
+		target := relaxedWaitCount(current, status.Clients)
+		err = app.adjustSemiSyncOnMaster(masterNode, masterState, target)
+		if err != nil {
+			app.logger.Errorf("semisync: failed to relax wait count on %s to %d: %v", master, target, err)
+			return
+		}
+		stall.relaxed = &target
+		// give relaxed master time to release waiting sessions
+		stall.since = now
+		app.recordEvent(HistoryEvent{Type: EventSemiSyncStall, Host: master,
+			Message: fmt.Sprintf("%s, wait count relaxed %d => %d", message, current, target)})
+	case config.SemiSyncStallFailover:
+		if countAliveHASlavesWithinNodes(activeNodes, clusterState) == 0 {
+			app.logger.Errorf("semisync: no healthy replica to switch over from stalled master %s", master)
+			return
+		}
+		cause := &FailoverCause{
+			Kind:     FailureSemiSyncStall,
+			FailedAt: stall.since,
+			Evidence: []string{message},
+		}
+		err = app.issueProactiveSwitchover(master, cause)
+		if err != nil {
+			app.logger.Errorf("semisync: failed to issue switchover from %s: %v", master, err)
+		}
+	}
+}
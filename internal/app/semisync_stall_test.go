@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestSemiSyncStallObserve(t *testing.T) {
+	now := time.Now()
+	var stall semiSyncStall
+	waiting := &mysql.SemiSyncWaitStatus{WaitSessions: 5, Clients: 1}
+	clear := &mysql.SemiSyncWaitStatus{Clients: 2}
+
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", waiting, now))
+	require.Equal(t, 10*time.Second, stall.observe("mysql1", waiting, now.Add(10*time.Second)))
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", clear, now.Add(11*time.Second)))
+	require.True(t, stall.clearSince.IsZero())
+
+	// sessions waiting while ACKs come are not stalled
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", waiting, now.Add(11*time.Second)))
+	progressing := &mysql.SemiSyncWaitStatus{WaitSessions: 5, Clients: 1, YesTx: 100}
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", progressing, now.Add(20*time.Second)))
+	require.Equal(t, 5*time.Second, stall.observe("mysql1", progressing, now.Add(25*time.Second)))
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", clear, now.Add(26*time.Second)))
+
+	relaxed := 1
+	stall.relaxed = &relaxed
+	require.Equal(t, time.Duration(0), stall.observe("mysql1", clear, now.Add(27*time.Second)))
+	require.Equal(t, now.Add(27*time.Second), stall.clearSince)
+
+	// new master starts from scratch
+	stall.observe("mysql2", waiting, now.Add(28*time.Second))
+	require.Nil(t, stall.relaxed)
+}
+
+func TestRelaxedWaitCount(t *testing.T) {
+	require.Equal(t, 1, relaxedWaitCount(2, 1))
+	require.Equal(t, 0, relaxedWaitCount(2, 0))
+	require.Equal(t, 1, relaxedWaitCount(2, 2))
+	require.Equal(t, 0, relaxedWaitCount(1, 1))
+}
+
+func TestCapWaitSlaveCount(t *testing.T) {
+	app := &App{}
+	require.Equal(t, 2, app.capWaitSlaveCount("mysql1", 2))
+	relaxed := 1
+	app.semiSyncStall = semiSyncStall{master: "mysql1", relaxed: &relaxed}
+	require.Equal(t, 1, app.capWaitSlaveCount("mysql1", 2))
+	require.Equal(t, 2, app.capWaitSlaveCount("mysql2", 2))
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql/gtids"
+)
+
+// lostTransactions are transactions of old master absent on the new one
+type lostTransactions struct {
+	gtids string
+	count int64
+}
+
+// estimateLostTransactions compares last GTID set reported to dcs by old master with GTID set of the new master.
+// Old master may have committed more after its last report, so it is a lower bound
+func (app *App) estimateLostTransactions(oldMaster string, newMasterGTIDSet gtids.GTIDSet) *lostTransactions {
+	var state NodeState
+	err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, oldMaster), &state)
+	if err != nil || state.MasterState == nil || state.MasterState.ExecutedGtidSet == "" {
+		return nil
+	}
+	missing, count, err := gtids.MissingTransactions(newMasterGTIDSet, gtids.ParseGtidSet(state.MasterState.ExecutedGtidSet))
+	if err != nil {
+		app.logger.Warnf("switchover: failed to estimate lost transactions: %v", err)
+		return nil
+	}
+	if count == 0 {
+		return nil
+	}
+	return &lostTransactions{gtids: missing, count: count}
+}
+
+// writeDowntime estimates how long cluster was unavailable for writes: since master failure for failover
+// and since switchover start for planned switchover, when old master is set read-only
+func writeDowntime(switchover *Switchover) time.Duration {
+	if switchover.Result == nil || !switchover.Result.Ok {
+		return 0
+	}
+	start := switchover.StartedAt
+	if switchover.FailoverCause != nil && !switchover.FailoverCause.FailedAt.IsZero() {
+		start = switchover.FailoverCause.FailedAt
+	}
+	if start.IsZero() {
+		return 0
+	}
+	return switchover.Result.FinishedAt.Sub(start)
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDowntime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	switchover := &Switchover{
+		Cause:     CauseManual,
+		StartedAt: start.Add(10 * time.Second),
+		Result:    &SwitchoverResult{Ok: true, FinishedAt: start.Add(25 * time.Second)},
+	}
+	require.Equal(t, 15*time.Second, writeDowntime(switchover))
+
+	switchover.Cause = CauseAuto
+	switchover.FailoverCause = &FailoverCause{FailedAt: start}
+	require.Equal(t, 25*time.Second, writeDowntime(switchover))
+
+	switchover.Result.Ok = false
+	require.Equal(t, time.Duration(0), writeDowntime(switchover))
+
+	switchover.Result = nil
+	require.Equal(t, time.Duration(0), writeDowntime(switchover))
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+// acquireSlot takes one of limit slots under path, creating ephemeral node named by slot number.
+// Creation of node is atomic, so hosts checking limit at the same time can't exceed it.
+// Slot already held by local host is reused. Slot is freed by releaseSlot or when agent session expires
+func (app *App) acquireSlot(path string, limit int) (bool, error) {
+	held, err := app.heldSlot(path)
+	if err != nil || held != "" {
+		return held != "", err
+	}
+	err = app.createParents(path)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i < limit; i++ {
+		err = app.dcs.CreateEphemeral(dcs.JoinPath(path, strconv.Itoa(i)), app.cfg().Hostname)
+		if err == dcs.ErrExists {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// releaseSlot frees slot under path held by local host, if any
+func (app *App) releaseSlot(path string) error {
+	held, err := app.heldSlot(path)
+	if err != nil || held == "" {
+		return err
+	}
+	err = app.dcs.Delete(held)
+	if err == dcs.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// heldSlot returns path of slot under path held by local host or empty string
+func (app *App) heldSlot(path string) (string, error) {
+	slots, err := app.dcs.GetChildren(path)
+	if err == dcs.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, slot := range slots {
+		var holder string
+		err = app.dcs.Get(dcs.JoinPath(path, slot), &holder)
+		if err == dcs.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if holder == app.cfg().Hostname {
+			return dcs.JoinPath(path, slot), nil
+		}
+	}
+	return "", nil
+}
+
+// createParents creates persistent nodes on the way to path
+func (app *App) createParents(path string) error {
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		err := app.dcs.Create(strings.Join(parts[:i+1], "/"), nil)
+		if err != nil && err != dcs.ErrExists {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestSlots(t *testing.T) {
+	store := mysynctest.NewMemStore()
+	apps := make(map[string]*App)
+	for _, host := range []string{"mysql1", "mysql2", "mysql3"} {
+		apps[host] = newTestApp(t, host)
+		apps[host].dcs = store.Session(host)
+	}
+	path := "donor_slots/mysql0"
+
+	acquired, err := apps["mysql1"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	// slot is reused by its holder
+	acquired, err = apps["mysql1"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	acquired, err = apps["mysql2"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	acquired, err = apps["mysql3"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	require.NoError(t, apps["mysql1"].releaseSlot(path))
+	require.NoError(t, apps["mysql1"].releaseSlot(path))
+	acquired, err = apps["mysql3"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// slot of expired session is freed
+	require.NoError(t, apps["mysql2"].dcs.(*mysynctest.MemDCS).SetConnected(false))
+	acquired, err = apps["mysql1"].acquireSlot(path, 2)
+	require.NoError(t, err)
+	require.True(t, acquired)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// manager's view of master health, tracked along with agent states
+const (
+	masterViewHealthy    = "healthy"
+	masterViewSuspicious = "suspicious"
+	masterViewDead       = "dead"
+	masterViewSwitchover = "switchover"
+)
+
+// stateDurations accumulates time spent in each state since agent start
+type stateDurations struct {
+	mu      sync.Mutex
+	current string
+	since   time.Time
+	total   map[string]time.Duration
+}
+
+// enter switches to state, empty state means that no state is tracked
+func (sd *stateDurations) enter(state string, now time.Time) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if state == sd.current {
+		return
+	}
+	if sd.current != "" {
+		if sd.total == nil {
+			sd.total = make(map[string]time.Duration)
+		}
+		sd.total[sd.current] += now.Sub(sd.since)
+	}
+	sd.current, sd.since = state, now
+}
+
+// snapshot returns current state, time it was entered and cumulative durations including current one
+func (sd *stateDurations) snapshot(now time.Time) (string, time.Time, map[string]time.Duration) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	durations := make(map[string]time.Duration, len(sd.total)+1)
+	for state, d := range sd.total {
+		durations[state] = d
+	}
+	if sd.current != "" {
+		durations[sd.current] += now.Sub(sd.since)
+	}
+	return sd.current, sd.since, durations
+}
+
+// getAgentState describes time spent by agent in its states, it is published with node health
+func (app *App) getAgentState(now time.Time) *AgentState {
+	state := new(AgentState)
+	state.State, state.StateSince, state.StateDurations = app.stateTimes.snapshot(now)
+	state.MasterView, state.MasterViewSince, state.MasterViewDurations = app.masterViewTimes.snapshot(now)
+	state.Version = AgentVersion()
+	state.ProtocolVersion = ProtocolVersion
+	state.ConfigHash = app.cfg().ClusterHash
+	return state
+}
+
+func formatDurations(durations map[string]time.Duration) string {
+	states := make([]string, 0, len(durations))
+	for state := range durations {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	result := ""
+	for i, state := range states {
+		if i > 0 {
+			result += ", "
+		}
+		result += state + " " + durations[state].Round(time.Second).String()
+	}
+	return result
+}
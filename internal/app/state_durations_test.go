@@ -0,0 +1,43 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateDurations(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sd stateDurations
+
+	state, _, durations := sd.snapshot(start)
+	require.Equal(t, "", state)
+	require.Empty(t, durations)
+
+	sd.enter(stateFirstRun, start)
+	sd.enter(stateCandidate, start.Add(time.Second))
+	sd.enter(stateCandidate, start.Add(10*time.Second))
+	sd.enter(stateManager, start.Add(time.Minute))
+	sd.enter(stateCandidate, start.Add(2*time.Minute))
+
+	state, since, durations := sd.snapshot(start.Add(3 * time.Minute))
+	require.Equal(t, stateCandidate, state)
+	require.Equal(t, start.Add(2*time.Minute), since)
+	require.Equal(t, map[string]time.Duration{
+		stateFirstRun:  time.Second,
+		stateCandidate: 2*time.Minute - time.Second,
+		stateManager:   time.Minute,
+	}, durations)
+
+	sd.enter("", start.Add(4*time.Minute))
+	_, _, durations = sd.snapshot(start.Add(time.Hour))
+	require.Equal(t, 3*time.Minute-time.Second, durations[stateCandidate])
+}
+
+func TestFormatDurations(t *testing.T) {
+	require.Equal(t, "dead 30s, healthy 1h0m0s", formatDurations(map[string]time.Duration{
+		masterViewHealthy: time.Hour,
+		masterViewDead:    30 * time.Second,
+	}))
+}
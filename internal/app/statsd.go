@@ -0,0 +1,160 @@
+package app
+
+import (
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/statsd"
+)
+
+func boolGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// initStatsD creates client pushing metrics, if statsd_address is configured
+func (app *App) initStatsD() error {
+	if app.cfg().StatsdAddress == "" {
+		return nil
+	}
+	client, err := statsd.NewClient(app.cfg().StatsdAddress, app.cfg().StatsdPrefix, app.cfg().StatsdTags, app.cfg().StatsdDogStatsD)
+	if err != nil {
+		return err
+	}
+	app.statsd = client
+	app.logger.Infof("statsd: sending metrics to %s", app.cfg().StatsdAddress)
+	return nil
+}
+
+// emitNodeMetrics pushes metrics of local node collected by health check
+func (app *App) emitNodeMetrics(hc *NodeState, state appState) {
+	if app.statsd == nil {
+		return
+	}
+	host := "host:" + app.cfg().Hostname
+	s := app.statsd
+	s.Gauge("agent.is_manager", boolGauge(state == stateManager), host)
+	s.Gauge("agent.in_maintenance", boolGauge(state == stateMaintenance), host)
+	if hc.AgentState != nil {
+		for name, d := range hc.AgentState.StateDurations {
+			s.Gauge("agent.state_seconds."+name, d.Seconds(), host)
+			s.Gauge("agent.in_state."+name, boolGauge(name == hc.AgentState.State), host)
+		}
+		for name, d := range hc.AgentState.MasterViewDurations {
+			s.Gauge("agent.master_view_seconds."+name, d.Seconds(), host)
+			s.Gauge("agent.in_master_view."+name, boolGauge(name == hc.AgentState.MasterView), host)
+		}
+	}
+	s.Gauge("mysql.alive", boolGauge(hc.PingOk), host)
+	if hc.PingOk {
+		s.Gauge("mysql.is_master", boolGauge(hc.IsMaster), host)
+		s.Gauge("mysql.read_only", boolGauge(hc.IsReadOnly), host)
+		s.Gauge("mysql.super_read_only", boolGauge(hc.IsSuperReadOnly), host)
+		s.Gauge("mysql.offline", boolGauge(hc.IsOffline), host)
+		s.Gauge("mysql.backup_running", boolGauge(hc.IsBackupRunning), host)
+		s.Gauge("health_checks.failed", float64(len(hc.FailedHealthChecks)), host)
+	}
+	if hc.SlaveState != nil {
+		s.Gauge("replication.running", boolGauge(hc.SlaveState.ReplicationState == mysql.ReplicationRunning), host)
+		if hc.SlaveState.ReplicationLag != nil {
+			s.Gauge("replication.lag", *hc.SlaveState.ReplicationLag, host)
+		}
+		s.Gauge("replication.relay_log_space", float64(hc.SlaveState.RelayLogSpace), host)
+		s.Gauge("replication.apply_backlog", float64(hc.SlaveState.ApplyBacklog), host)
+		s.Gauge("replication.apply_rate", hc.SlaveState.ApplyRate, host)
+	}
+	if hc.ExternalSlaveState != nil {
+		s.Gauge("external_replication.running", boolGauge(hc.ExternalSlaveState.ReplicationState == mysql.ReplicationRunning), host)
+		if hc.ExternalSlaveState.ReplicationLag != nil {
+			s.Gauge("external_replication.lag", *hc.ExternalSlaveState.ReplicationLag, host)
+		}
+	}
+	if hc.SemiSyncState != nil {
+		s.Gauge("semisync.master_enabled", boolGauge(hc.SemiSyncState.MasterEnabled), host)
+		s.Gauge("semisync.slave_enabled", boolGauge(hc.SemiSyncState.SlaveEnabled), host)
+	}
+	if hc.DiskState != nil && hc.DiskState.Total > 0 {
+		s.Gauge("disk.used_percent", float64(hc.DiskState.Used)/float64(hc.DiskState.Total)*100, host)
+		s.Gauge("disk.growth_rate", hc.DiskState.GrowthRate, host)
+	}
+	if err := s.Flush(); err != nil {
+		app.logger.Warnf("statsd: failed to send metrics: %v", err)
+	}
+}
+
+// emitCandidateMetrics pushes promotion readiness of replicas evaluated by manager
+func (app *App) emitCandidateMetrics(candidates []CandidateReadiness) {
+	s := app.statsd
+	for _, cr := range candidates {
+		host := "host:" + cr.Host
+		s.Gauge("candidate.score", float64(cr.Score), host)
+		s.Gauge("candidate.eligible", boolGauge(cr.Eligible), host)
+		s.Gauge("candidate.selected", boolGauge(cr.Selected), host)
+		s.Gauge("candidate.gtid_behind", float64(cr.GTIDBehind), host)
+	}
+	if err := s.Flush(); err != nil {
+		app.logger.Warnf("statsd: failed to send metrics: %v", err)
+	}
+}
+
+// emitLagMetrics pushes replication lag history of replicas collected by manager
+func (app *App) emitLagMetrics() {
+	s := app.statsd
+	for host, samples := range app.lagHistory {
+		tag := "host:" + host
+		maxLag := 0.0
+		for _, sample := range samples {
+			maxLag = max(maxLag, sample.lag)
+		}
+		s.Gauge("replication.lag_max", maxLag, tag)
+		if app.cfg().LagQuantile > 0 {
+			if lag, ok := app.lagHistory.quantile(host, app.cfg().LagQuantile); ok {
+				s.Gauge("replication.lag_quantile", lag, tag)
+			}
+		}
+	}
+	if err := s.Flush(); err != nil {
+		app.logger.Warnf("statsd: failed to send metrics: %v", err)
+	}
+}
+
+// emitEventMetrics counts cluster events and reports duration of switchovers
+func (app *App) emitEventMetrics(event HistoryEvent) {
+	if app.statsd == nil {
+		return
+	}
+	tag := "type:" + event.Type
+	app.statsd.Count("events", 1, tag)
+	if event.Duration > 0 {
+		app.statsd.Timing("events.duration", float64(event.Duration.Milliseconds()), tag)
+	}
+	if event.WriteDowntime > 0 {
+		app.statsd.Timing("switchover.write_downtime", float64(event.WriteDowntime.Milliseconds()), tag)
+		app.statsd.Gauge("switchover.lost_transactions", float64(event.LostTransactions), tag)
+	}
+	if err := app.statsd.Flush(); err != nil {
+		app.logger.Warnf("statsd: failed to send metrics: %v", err)
+	}
+}
+
+// emitResetupMetrics reports progress of running resetup, nil progress means resetup is over
+func (app *App) emitResetupMetrics(progress *ResetupProgress) {
+	if app.statsd == nil {
+		return
+	}
+	host := "host:" + app.cfg().Hostname
+	s := app.statsd
+	s.Gauge("resetup.running", boolGauge(progress != nil), host)
+	if progress != nil {
+		s.Gauge("resetup.bytes_copied", float64(progress.BytesCopied), host)
+		s.Gauge("resetup.bytes_total", float64(progress.BytesTotal), host)
+		if progress.ETA != nil {
+			s.Gauge("resetup.eta_seconds", time.Until(*progress.ETA).Seconds(), host)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		app.logger.Warnf("statsd: failed to send metrics: %v", err)
+	}
+}
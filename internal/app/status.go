@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// collectWithDeadline runs collect in one of the given slots and waits at most timeout for it,
+// including time spent waiting for a free slot. Slot is released only when collect actually returns,
+// so hosts that black-hole queries cannot make us exceed the concurrency limit
+func collectWithDeadline(slots chan struct{}, timeout time.Duration, collect func() *NodeState) (*NodeState, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+	case <-timer.C:
+		return nil, false
+	}
+	done := make(chan *NodeState, 1)
+	go func() {
+		defer func() { <-slots }()
+		done <- collect()
+	}()
+	select {
+	case state := <-done:
+		return state, true
+	case <-timer.C:
+		return nil, false
+	}
+}
+
+// getNodeStateWithTimeout collects host status within host_status_timeout,
+// host which did not answer in time is considered dead for this cycle
+func (app *App) getNodeStateWithTimeout(host string, slots chan struct{}) *NodeState {
+	timeout := app.cfg().HostStatusTimeout
+	state, ok := collectWithDeadline(slots, timeout, func() *NodeState {
+		if host == app.cluster.Local().Host() {
+			return app.getLocalNodeState()
+		}
+		return app.getNodeState(host)
+	})
+	if ok {
+		return state
+	}
+	app.logger.Warnf("node %s: status collection timed out after %v", host, timeout)
+	return &NodeState{
+		CheckAt:          time.Now(),
+		CheckBy:          app.cfg().Hostname,
+		ShowOnlyGTIDDiff: app.cfg().ShowOnlyGTIDDiff,
+		IsCascade:        app.cluster.IsCascadeHost(host),
+		Error:            fmt.Sprintf("status collection timed out after %v", timeout),
+	}
+}
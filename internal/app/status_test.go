@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectWithDeadline(t *testing.T) {
+	slots := make(chan struct{}, 1)
+
+	state, ok := collectWithDeadline(slots, time.Second, func() *NodeState {
+		return &NodeState{PingOk: true}
+	})
+	require.True(t, ok)
+	require.True(t, state.PingOk)
+
+	release := make(chan struct{})
+	start := time.Now()
+	_, ok = collectWithDeadline(slots, 50*time.Millisecond, func() *NodeState {
+		<-release
+		return &NodeState{}
+	})
+	require.False(t, ok)
+	require.Less(t, time.Since(start), time.Second)
+
+	// hanging collector still occupies the only slot
+	_, ok = collectWithDeadline(slots, 50*time.Millisecond, func() *NodeState {
+		return &NodeState{}
+	})
+	require.False(t, ok)
+
+	close(release)
+	require.Eventually(t, func() bool { return len(slots) == 0 }, time.Second, 10*time.Millisecond)
+	_, ok = collectWithDeadline(slots, time.Second, func() *NodeState {
+		return &NodeState{}
+	})
+	require.True(t, ok)
+}
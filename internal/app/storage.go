@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// getStorageDegradation returns reason of local storage degradation reported
+// by external tooling (SMART, kernel I/O errors) or empty string if storage is healthy
+func (app *App) getStorageDegradation() string {
+	if app.cfg().StorageHealthFile == "" {
+		return ""
+	}
+	data, err := os.ReadFile(app.cfg().StorageHealthFile)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		app.logger.Errorf("Failed to read storage health file: %v", err)
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// checkStorageHealth issues switchover from master with degrading storage
+func (app *App) checkStorageHealth(clusterStateDcs map[string]*NodeState, master string) {
+	state := clusterStateDcs[master]
+	if state == nil || state.StorageDegradation == "" {
+		return
+	}
+	app.logger.Warnf("storage: master %s storage is degrading: %s", master, state.StorageDegradation)
+	if !app.cfg().SwitchoverOnStorageDegradation {
+		return
+	}
+	cause := &FailoverCause{
+		Kind:     FailureStorageDegraded,
+		FailedAt: state.CheckAt,
+		Evidence: []string{fmt.Sprintf("%s reported storage degradation: %s", master, state.StorageDegradation)},
+	}
+	err := app.issueProactiveSwitchover(master, cause)
+	if err != nil {
+		app.logger.Errorf("storage: failed to issue switchover from %s: %v", master, err)
+	}
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+var (
+	// errSwitchoverInvalid is returned for malformed manual switchover request
+	errSwitchoverInvalid = errors.New("invalid switchover request")
+	// errSwitchoverRejected is returned when switchover can't be performed in current topology
+	errSwitchoverRejected = errors.New("switchover rejected")
+	// errSwitchoverNotNeeded is returned when master is already where switchover would move it
+	errSwitchoverNotNeeded = errors.New("switchover is not needed")
+)
+
+// switchoverRequest is manual switchover request resolved against current topology
+type switchoverRequest struct {
+	From        string
+	To          string
+	Master      string
+	ActiveNodes []string
+}
+
+// validateSwitchover resolves --from or --to host pattern of manual switchover and checks that it may be performed.
+// CLI and gRPC API both use it, so they accept and reject the same requests
+func (app *App) validateSwitchover(switchFrom, switchTo string) (*switchoverRequest, error) {
+	if switchFrom == "" && switchTo == "" {
+		return nil, fmt.Errorf("%w: either from or to should be set", errSwitchoverInvalid)
+	}
+	if switchFrom != "" && switchTo != "" {
+		return nil, fmt.Errorf("%w: from and to can't be used at the same time", errSwitchoverInvalid)
+	}
+	haNodes := app.cluster.HANodeHosts()
+	if len(haNodes) == 1 {
+		return nil, fmt.Errorf("%w: switchover has no sense on single HA-node cluster", errSwitchoverNotNeeded)
+	}
+	var master string
+	if err := app.dcs.Get(pathMasterNode, &master); err != nil {
+		return nil, fmt.Errorf("failed to get current master: %v", err)
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		return nil, err
+	}
+	request := &switchoverRequest{Master: master, ActiveNodes: activeNodes}
+
+	if switchTo != "" {
+		// switch to particular host
+		desired := util.SelectNodes(haNodes, switchTo)
+		if util.ContainsString(haNodes, switchTo) {
+			desired = []string{switchTo}
+		}
+		if len(desired) == 0 {
+			return nil, fmt.Errorf("%w: no HA-nodes matching '%s'", errSwitchoverInvalid, switchTo)
+		}
+		if len(desired) > 1 {
+			return nil, fmt.Errorf("%w: two or more nodes matching '%s'", errSwitchoverInvalid, switchTo)
+		}
+		request.To = desired[0]
+		if request.To == master {
+			return nil, fmt.Errorf("%w: master is already on %s", errSwitchoverNotNeeded, request.To)
+		}
+		if !util.ContainsString(activeNodes, request.To) {
+			return nil, fmt.Errorf("%w: %s is not active, can't switch to it", errSwitchoverRejected, request.To)
+		}
+		eligible, err := app.semiSyncEligible([]string{request.To})
+		if err != nil {
+			return nil, err
+		}
+		if len(eligible) == 0 {
+			return nil, fmt.Errorf("%w: %s is async replica, can't switch to it", errSwitchoverRejected, request.To)
+		}
+		if err := app.checkZoneAllowed(request.To); err != nil {
+			return nil, fmt.Errorf("%w: %v", errSwitchoverRejected, err)
+		}
+		if err := app.checkVersionAllowed(request.To, master); err != nil {
+			return nil, fmt.Errorf("%w: %v", errSwitchoverRejected, err)
+		}
+		return request, nil
+	}
+
+	// switch away from specified host(s)
+	notDesired := util.SelectNodes(haNodes, switchFrom)
+	if len(notDesired) == 0 {
+		return nil, fmt.Errorf("%w: no HA-nodes matching '%s'", errSwitchoverInvalid, switchFrom)
+	}
+	if !util.ContainsString(notDesired, master) {
+		return nil, fmt.Errorf("%w: master is already not on %v", errSwitchoverNotNeeded, notDesired)
+	}
+	eligible, err := app.semiSyncEligible(activeNodes)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for _, node := range eligible {
+		if !util.ContainsString(notDesired, node) {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: there are no active nodes, not matching '%s'", errSwitchoverRejected, switchFrom)
+	}
+	if len(notDesired) == 1 {
+		request.From = notDesired[0]
+		return request, nil
+	}
+	// there are multiple hosts matching from pattern
+	// to avoid switching from one to another, use switch to behavior
+	positions, err := app.getNodePositions(candidates)
+	if err != nil {
+		return nil, err
+	}
+	positions, err = app.applyZonePolicy(positions, master)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errSwitchoverRejected, err)
+	}
+	positions, err = app.applyVersionPolicy(positions, master)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errSwitchoverRejected, err)
+	}
+	// choose new master the same way switchover itself does
+	positions = app.deprioritizeHostsOnBackup(positions)
+	request.To, err = app.choosePromotionCandidate(app.logger, positions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errSwitchoverRejected, err)
+	}
+	return request, nil
+}
+
+// SwitchoverPlan describes what switchover would do if it was issued now
+type SwitchoverPlan struct {
+	Master     string          `json:"master" yaml:"master"`
+	From       string          `json:"from,omitempty" yaml:"from,omitempty"`
+	To         string          `json:"to,omitempty" yaml:"to,omitempty"`
+	NewMaster  string          `json:"new_master,omitempty" yaml:"new_master,omitempty"`
+	Force      bool            `json:"force,omitempty" yaml:"force,omitempty"`
+	Candidates []CandidatePlan `json:"candidates" yaml:"candidates"`
+	Problems   []string        `json:"problems,omitempty" yaml:"problems,omitempty"`
+}
+
+// CandidatePlan describes state of the new master candidate
+type CandidatePlan struct {
+	Host     string  `json:"host" yaml:"host"`
+	PingOk   bool    `json:"ping_ok" yaml:"ping_ok"`
+	Lag      float64 `json:"lag" yaml:"lag"`
+	Priority int64   `json:"priority" yaml:"priority"`
+	SemiSync bool    `json:"semi_sync" yaml:"semi_sync"`
+	GTIDSet  string  `json:"gtid_set" yaml:"gtid_set"`
+}
+
+// planSwitchover evaluates switchover preconditions without changing anything in DCS or MySQL
+func (app *App) planSwitchover(fromHost, toHost, master string, activeNodes []string, force bool) *SwitchoverPlan {
+	plan := &SwitchoverPlan{Master: master, From: fromHost, To: toHost, Force: force}
+	problem := func(format string, args ...interface{}) {
+		plan.Problems = append(plan.Problems, fmt.Sprintf(format, args...))
+	}
+
+	maintenance, err := app.GetMaintenance()
+	if err != nil && err != dcs.ErrNotFound {
+		problem("failed to get maintenance: %v", err)
+	}
+	if maintenance != nil {
+		problem("cluster is in maintenance, switchover will wait for it to finish")
+	}
+
+	clusterState := app.getClusterStateFromDB()
+	if state, ok := clusterState[master]; !ok || !state.PingOk {
+		problem("master %s is not available, failover will be performed instead", master)
+	}
+	permissibleSlaves := countAliveHASlavesWithinNodes(activeNodes, clusterState)
+	if err := app.switchHelper.CheckFailoverQuorum(activeNodes, permissibleSlaves); err != nil {
+		problem("%v", err)
+	}
+
+	eligible, err := app.semiSyncEligible(activeNodes)
+	if err != nil {
+		problem("%v", err)
+		eligible = activeNodes
+	}
+	var candidates []string
+	for _, host := range eligible {
+		if host != master && host != fromHost {
+			candidates = append(candidates, host)
+		}
+	}
+	positions, err := app.getNodePositions(candidates)
+	if err != nil {
+		problem("failed to get candidates positions: %v", err)
+	}
+	for _, pos := range positions {
+		candidate := CandidatePlan{Host: pos.host, Lag: pos.lag, Priority: pos.priority}
+		if pos.gtidset != nil {
+			candidate.GTIDSet = pos.gtidset.String()
+		}
+		if state, ok := clusterState[pos.host]; ok {
+			candidate.PingOk = state.PingOk
+			candidate.SemiSync = state.SemiSyncState != nil && state.SemiSyncState.SlaveEnabled
+		}
+		plan.Candidates = append(plan.Candidates, candidate)
+	}
+
+	if toHost != "" {
+		plan.NewMaster = toHost
+		if util.ContainsString(activeNodes, toHost) && !util.ContainsString(eligible, toHost) {
+			problem("%s is async replica, it can't be promoted", toHost)
+		}
+		if err := app.checkZoneAllowed(toHost); err != nil {
+			problem("%v", err)
+		}
+		if err := app.checkVersionAllowed(toHost, master); err != nil {
+			problem("%v", err)
+		}
+	} else if len(positions) > 0 {
+		failedHost := fromHost
+		if failedHost == "" {
+			failedHost = master
+		}
+		positions, err = app.applyZonePolicy(positions, failedHost)
+		if err == nil {
+			positions, err = app.applyVersionPolicy(positions, master)
+		}
+		if err != nil {
+			problem("%v", err)
+		} else {
+			positions = app.deprioritizeHostsOnBackup(positions)
+			plan.NewMaster, err = app.choosePromotionCandidate(app.logger, positions)
+			if err != nil {
+				problem("failed to choose new master: %v", err)
+			}
+		}
+	}
+
+	switchover := &Switchover{From: fromHost, To: toHost, Cause: CauseManual, Force: force}
+	if err := app.checkBackupsBeforeSwitchover(switchover, master); err != nil {
+		problem("switchover will be deferred: %v", err)
+	}
+	if err := app.checkOnlineDDLBeforeSwitchover(switchover, master); err != nil {
+		problem("switchover will be deferred: %v", err)
+	}
+	return plan
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/yandex/mysync/internal/util"
+)
+
+// notifySystemd reports agent state to systemd, it does nothing when agent is not run by systemd
+func (app *App) notifySystemd(state string) {
+	if _, err := util.SdNotify(state); err != nil {
+		app.logger.Warnf("systemd: failed to notify %q: %v", state, err)
+	}
+}
+
+// systemdWatchdog pings systemd watchdog while main loop makes progress,
+// so wedged agent (possibly holding manager lock) is restarted by systemd instead of hanging forever.
+// Long phases of switchover report progress from inside their waits, so they are not mistaken for a hang
+func (app *App) systemdWatchdog(ctx context.Context) {
+	timeout := util.SdWatchdogInterval()
+	if timeout == 0 {
+		return
+	}
+	// stuck main loop is detected after health_stale_timeout, and agent is restarted after watchdog timeout more
+	app.logger.Infof("systemd: watchdog enabled with timeout %v", timeout)
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			health := app.checkAgentHealth(time.Now(), false)
+			if !health.Ok {
+				app.logger.Errorf("systemd: skipping watchdog ping: %v", health.Problems)
+				continue
+			}
+			app.notifySystemd("WATCHDOG=1")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
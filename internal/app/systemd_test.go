@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+func TestSystemdWatchdog(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	app := &App{logger: logger, config: config.NewHolder(&config.Config{HealthStaleTimeout: time.Minute})}
+	app.liveness.tickLoop(time.Now(), stateManager)
+
+	read := func() string {
+		buf := make([]byte, 64)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	app.notifySystemd("READY=1")
+	require.Equal(t, "READY=1", read())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.systemdWatchdog(ctx)
+	require.Equal(t, "WATCHDOG=1", read())
+
+	// stuck main loop is not reported as alive
+	app.liveness.tickLoop(time.Now().Add(-time.Hour), stateManager)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+	for {
+		_, err = conn.Read(make([]byte, 64))
+		if err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+const throttlePath = "/throttle"
+
+// ThrottleState tells bulk writers whether they should slow down, by replication lag seen by manager
+type ThrottleState struct {
+	Throttled bool      `json:"throttled"`
+	MaxLag    float64   `json:"max_lag"`
+	Host      string    `json:"host,omitempty"`
+	Threshold float64   `json:"threshold"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// why writers are throttled regardless of lag, e.g. by load shedding
+	Reason string `json:"reason,omitempty"`
+}
+
+func (ts *ThrottleState) String() string {
+	verdict := "not throttled"
+	if ts.Throttled {
+		verdict = "throttled"
+	}
+	result := fmt.Sprintf("%s: max lag %.1fs on %s, threshold %.1fs, updated at %s",
+		verdict, ts.MaxLag, ts.Host, ts.Threshold, ts.UpdatedAt.Format(time.RFC3339))
+	if ts.Reason != "" {
+		result += ", " + ts.Reason
+	}
+	return result
+}
+
+// throttleState returns the most lagging active replica compared with threshold.
+// Replicas of unknown lag are omitted, as they are dropped from active nodes shortly.
+func throttleState(clusterState map[string]*NodeState, activeNodes []string, master string, threshold time.Duration, now time.Time) *ThrottleState {
+	ts := &ThrottleState{Threshold: threshold.Seconds(), UpdatedAt: now}
+	hosts := append([]string(nil), activeNodes...)
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		state := clusterState[host]
+		if host == master || state == nil || state.SlaveState == nil || state.SlaveState.ReplicationLag == nil {
+			continue
+		}
+		if lag := *state.SlaveState.ReplicationLag; ts.Host == "" || lag > ts.MaxLag {
+			ts.MaxLag, ts.Host = lag, host
+		}
+	}
+	ts.Throttled = ts.MaxLag > ts.Threshold
+	return ts
+}
+
+// publishThrottleState is called by manager to refresh throttle flag in dcs,
+// flag is raised by lag over throttle_lag or by load shedding with throttle action
+func (app *App) publishThrottleState(clusterState map[string]*NodeState, activeNodes []string, master string) {
+	if app.cfg().ThrottleLag == 0 && !app.cfg().LoadShedding.Throttle {
+		return
+	}
+	ts := throttleState(clusterState, activeNodes, master, app.cfg().ThrottleLag, time.Now())
+	if app.cfg().ThrottleLag == 0 {
+		ts.Throttled = false
+	}
+	if app.cfg().LoadShedding.Throttle && app.loadShedding != nil {
+		ts.Throttled = true
+		ts.Reason = "load shedding: " + app.loadShedding.Reason
+	}
+	var old ThrottleState
+	err := app.dcs.Get(pathThrottle, &old)
+	if err == nil && old.Throttled != ts.Throttled {
+		app.logger.Infof("throttle: %s", ts)
+	}
+	err = app.dcs.Set(pathThrottle, ts)
+	if err != nil {
+		app.logger.Errorf("throttle: failed to publish state: %v", err)
+	}
+}
+
+// throttleCache keeps throttle flag read from dcs on health check,
+// so requests of writers polling /throttle do not reach dcs
+type throttleCache struct {
+	mu    sync.Mutex
+	state *ThrottleState
+	err   error
+}
+
+func (c *throttleCache) update(state *ThrottleState, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state, c.err = state, err
+}
+
+func (c *throttleCache) get() (*ThrottleState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil && c.err == nil {
+		return nil, dcs.ErrNotFound
+	}
+	return c.state, c.err
+}
+
+// refreshThrottleState reads throttle flag published by manager into cache served by /throttle
+func (app *App) refreshThrottleState() {
+	if app.cfg().ThrottleLag == 0 && !app.cfg().LoadShedding.Throttle {
+		app.throttle.update(nil, nil)
+		return
+	}
+	ts := new(ThrottleState)
+	err := app.dcs.Get(pathThrottle, ts)
+	if err != nil {
+		ts = nil
+		if err != dcs.ErrNotFound {
+			app.logger.Errorf("throttle: failed to get state: %v", err)
+		}
+	}
+	app.throttle.update(ts, err)
+}
+
+// handleThrottle serves throttle flag: 200 lets writers go on, 429 asks them to slow down,
+// 503 means the flag is unknown or is not refreshed by manager, which writers should treat as throttled.
+// Flag is served from cache refreshed on health check
+func (app *App) handleThrottle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ts, err := app.throttle.get()
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		message := "throttle flag is not published"
+		if err != dcs.ErrNotFound {
+			message = err.Error()
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+		return
+	}
+	switch {
+	case time.Since(ts.UpdatedAt) > app.cfg().HealthStaleTimeout:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case ts.Throttled:
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	_ = json.NewEncoder(w).Encode(ts)
+}
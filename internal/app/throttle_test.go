@@ -0,0 +1,62 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleState(t *testing.T) {
+	lag := func(l float64) *NodeState { return &NodeState{SlaveState: &SlaveState{ReplicationLag: &l}} }
+	clusterState := map[string]*NodeState{
+		"mysql1": {},
+		"mysql2": lag(3),
+		"mysql3": lag(12),
+		"mysql4": {SlaveState: &SlaveState{}},
+		"mysql5": lag(100),
+	}
+	now := time.Now()
+	active := []string{"mysql1", "mysql2", "mysql3", "mysql4"}
+	ts := throttleState(clusterState, active, "mysql1", 10*time.Second, now)
+	require.True(t, ts.Throttled)
+	require.Equal(t, "mysql3", ts.Host)
+	require.Equal(t, 12.0, ts.MaxLag)
+
+	ts = throttleState(clusterState, active, "mysql1", 30*time.Second, now)
+	require.False(t, ts.Throttled)
+}
+
+func TestHandleThrottle(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	app.cfg().ThrottleLag = 10 * time.Second
+	status := func() int {
+		app.refreshThrottleState()
+		rec := httptest.NewRecorder()
+		app.handleThrottle(rec, httptest.NewRequest(http.MethodGet, throttlePath, nil))
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusServiceUnavailable, status())
+
+	clusterState := map[string]*NodeState{"mysql2": {SlaveState: &SlaveState{ReplicationLag: new(float64)}}}
+	app.publishThrottleState(clusterState, []string{"mysql1", "mysql2"}, "mysql1")
+	require.Equal(t, http.StatusOK, status())
+
+	*clusterState["mysql2"].SlaveState.ReplicationLag = 20
+	app.publishThrottleState(clusterState, []string{"mysql1", "mysql2"}, "mysql1")
+	require.Equal(t, http.StatusTooManyRequests, status())
+
+	// manager stopped refreshing the flag
+	require.NoError(t, app.dcs.Set(pathThrottle, &ThrottleState{UpdatedAt: time.Now().Add(-time.Hour)}))
+	require.Equal(t, http.StatusServiceUnavailable, status())
+
+	// flag is served from cache until next health check
+	require.NoError(t, app.dcs.Set(pathThrottle, &ThrottleState{UpdatedAt: time.Now()}))
+	rec := httptest.NewRecorder()
+	app.handleThrottle(rec, httptest.NewRequest(http.MethodGet, throttlePath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, http.StatusOK, status())
+}
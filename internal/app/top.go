@@ -0,0 +1,216 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+const (
+	ansiClearScreen = "\033[H\033[2J"
+	ansiBold        = "\033[1m"
+	ansiReset       = "\033[0m"
+)
+
+// CliTop shows live-refreshing cluster dashboard
+func (app *App) CliTop(interval time.Duration) int {
+	ctx := app.baseContext()
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+	err = app.newDBCluster()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.cluster.Close()
+
+	keys := make(chan byte)
+	restore, err := util.MakeTerminalRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		app.logger.Warnf("key bindings are disabled: %v", err)
+	} else {
+		defer func() { _ = restore() }()
+		go func() {
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				key, err := reader.ReadByte()
+				if err != nil {
+					return
+				}
+				keys <- key
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	status := ""
+	for {
+		fmt.Print(ansiClearScreen + app.renderTop(interval, status))
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		case key := <-keys:
+			switch key {
+			case 'q':
+				return 0
+			case 's':
+				status = app.topConfirm(keys, "issue switchover from current master", app.topSwitchover)
+			case 'm':
+				status = app.topConfirm(keys, "toggle maintenance", app.topToggleMaintenance)
+			}
+		}
+	}
+}
+
+func (app *App) topConfirm(keys chan byte, action string, do func() string) string {
+	fmt.Printf("\n%s? [y/N] ", action)
+	if key := <-keys; key != 'y' && key != 'Y' {
+		return action + ": cancelled"
+	}
+	return do()
+}
+
+func (app *App) topSwitchover() string {
+	var master string
+	if err := app.dcs.Get(pathMasterNode, &master); err != nil {
+		return fmt.Sprintf("failed to get current master: %v", err)
+	}
+	switchover := Switchover{
+		From:        master,
+		InitiatedBy: app.cfg().Hostname,
+		InitiatedAt: time.Now(),
+		Cause:       CauseManual,
+		Operator:    app.currentOperator(),
+	}
+	err := app.dcs.Create(pathCurrentSwitch, switchover)
+	if err == dcs.ErrExists {
+		return "another switchover in progress"
+	}
+	if err != nil {
+		return fmt.Sprintf("failed to issue switchover: %v", err)
+	}
+	return fmt.Sprintf("switchover from %s issued", master)
+}
+
+func (app *App) topToggleMaintenance() string {
+	maintenance := new(Maintenance)
+	err := app.dcs.Get(pathMaintenance, maintenance)
+	if err == dcs.ErrNotFound {
+		maintenance = &Maintenance{
+			InitiatedBy: app.cfg().Hostname,
+			InitiatedAt: time.Now(),
+			Operator:    app.currentOperator(),
+		}
+		if err := app.dcs.Create(pathMaintenance, maintenance); err != nil && err != dcs.ErrExists {
+			return fmt.Sprintf("failed to enable maintenance: %v", err)
+		}
+		return "maintenance scheduled"
+	}
+	if err != nil {
+		return fmt.Sprintf("failed to get maintenance: %v", err)
+	}
+	maintenance.ShouldLeave = true
+	maintenance.DisabledBy = app.currentOperator()
+	if err := app.dcs.Set(pathMaintenance, maintenance); err != nil {
+		return fmt.Sprintf("failed to disable maintenance: %v", err)
+	}
+	return "maintenance disable scheduled"
+}
+
+func (app *App) renderTop(interval time.Duration, status string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%smysync top%s   refresh %v   %s\n\n", ansiBold, ansiReset, interval, time.Now().Format(time.RFC3339))
+
+	if err := app.cluster.UpdateHostsInfo(); err != nil {
+		fmt.Fprintf(&sb, "failed to update hosts info: %v\n", err)
+		return sb.String()
+	}
+	var master string
+	if err := app.dcs.Get(pathMasterNode, &master); err != nil && err != dcs.ErrNotFound {
+		fmt.Fprintf(&sb, "failed to get master: %v\n", err)
+	}
+	var manager dcs.LockOwner
+	if err := app.dcs.Get(pathManagerLock, &manager); err != nil && err != dcs.ErrNotFound {
+		fmt.Fprintf(&sb, "failed to get manager: %v\n", err)
+	}
+	maintenance := "off"
+	var m Maintenance
+	if err := app.dcs.Get(pathMaintenance, &m); err == nil {
+		maintenance = m.String()
+	}
+	fmt.Fprintf(&sb, "manager: %s   master: %s   maintenance: %s\n", manager.Hostname, master, maintenance)
+	var switchover Switchover
+	if err := app.dcs.Get(pathCurrentSwitch, &switchover); err == nil {
+		fmt.Fprintf(&sb, "switchover: %s\n", switchover.String())
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		fmt.Fprintf(&sb, "failed to get active nodes: %v\n", err)
+	}
+	sb.WriteString("\n")
+
+	clusterState, err := app.getClusterStateFromDcs()
+	if err != nil {
+		fmt.Fprintf(&sb, "failed to get cluster state: %v\n", err)
+		return sb.String()
+	}
+	hosts := make([]string, 0, len(clusterState))
+	for host := range clusterState {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tROLE\tACTIVE\tPING\tRO\tOFFLINE\tREPL\tLAG\tSEMISYNC\tZONE\tDISK")
+	for _, host := range hosts {
+		state := clusterState[host]
+		role := "replica"
+		if host == master {
+			role = "master"
+		} else if state.IsCascade {
+			role = "cascade"
+		}
+		repl, lag := "-", "-"
+		if state.SlaveState != nil {
+			repl = state.SlaveState.ReplicationState
+			if state.SlaveState.ReplicationLag != nil {
+				lag = fmt.Sprintf("%.1fs", *state.SlaveState.ReplicationLag)
+			}
+		}
+		semisync := "-"
+		if state.SemiSyncState != nil {
+			if state.SemiSyncState.MasterEnabled {
+				semisync = fmt.Sprintf("master(%d)", state.SemiSyncState.WaitSlaveCount)
+			} else if state.SemiSyncState.SlaveEnabled {
+				semisync = "replica"
+			}
+		}
+		disk := "-"
+		if state.DiskState != nil {
+			disk = fmt.Sprintf("%0.1f%%", state.DiskState.Usage())
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%t\t%t\t%t\t%s\t%s\t%s\t%s\t%s\n", host, role, util.ContainsString(activeNodes, host),
+			state.PingOk, state.IsReadOnly, state.IsOffline, repl, lag, semisync, state.Zone, disk)
+	}
+	_ = tw.Flush()
+
+	sb.WriteString("\n[q] quit   [s] switchover from master   [m] toggle maintenance\n")
+	if status != "" {
+		fmt.Fprintf(&sb, "%s\n", status)
+	}
+	return sb.String()
+}
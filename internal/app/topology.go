@@ -0,0 +1,235 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+const topologyPublishDefaultTimeout = 5 * time.Second
+
+// Topology is current master and replicas, published to application-facing stores
+type Topology struct {
+	Master   string   `json:"master"`
+	Replicas []string `json:"replicas"`
+	Epoch    int64    `json:"epoch"`
+}
+
+func (t *Topology) String() string {
+	return fmt.Sprintf("master %s, replicas %s, epoch %d", t.Master, strings.Join(t.Replicas, ","), t.Epoch)
+}
+
+func (t *Topology) equal(other *Topology) bool {
+	return other != nil && t.Master == other.Master && t.Epoch == other.Epoch && slices.Equal(t.Replicas, other.Replicas)
+}
+
+// renderTopology returns content published to store, JSON unless template is set
+func renderTopology(publisher config.TopologyPublisherConfig, topology *Topology) ([]byte, error) {
+	if publisher.Template == "" {
+		return json.Marshal(topology)
+	}
+	tmpl, err := template.New(publisher.Name).Parse(publisher.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, topology)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// getTopology builds topology from master and active nodes stored in DCS, or returns nil if master is unknown
+func (app *App) getTopology() (*Topology, error) {
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil || master == "" {
+		return nil, err
+	}
+	activeNodes, err := app.GetActiveNodes()
+	if err != nil {
+		return nil, err
+	}
+	topology := &Topology{Master: master, Replicas: []string{}}
+	for _, host := range activeNodes {
+		if host != master {
+			topology.Replicas = append(topology.Replicas, host)
+		}
+	}
+	sort.Strings(topology.Replicas)
+	epoch, err := app.GetEpoch()
+	if err != nil {
+		return nil, err
+	}
+	if epoch != nil {
+		topology.Epoch = epoch.Epoch
+	}
+	return topology, nil
+}
+
+// isManager checks that manager lock is held by local agent
+func (app *App) isManager() bool {
+	var manager dcs.LockOwner
+	err := app.dcs.Get(pathManagerLock, &manager)
+	return err == nil && manager.Hostname == app.cfg().Hostname
+}
+
+// topologyPublisher writes current topology to configured stores whenever it changes.
+// Shared stores are written by manager, local files are written by every agent.
+func (app *App) topologyPublisher(ctx context.Context) {
+	published := make([]*Topology, len(app.cfg().TopologyPublishers))
+	ticker := time.NewTicker(app.cfg().TickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+		app.publishTopologyChanges(ctx, published)
+	}
+}
+
+// publishTopologyChanges publishes current topology to stores, which were given other one.
+// published holds last topology written to each of configured publishers
+func (app *App) publishTopologyChanges(ctx context.Context, published []*Topology) {
+	topology, err := app.getTopology()
+	if err != nil {
+		app.logger.Warnf("topology: %v", err)
+		return
+	}
+	if topology == nil {
+		return
+	}
+	manager := app.isManager()
+	for i, publisher := range app.cfg().TopologyPublishers {
+		if topology.equal(published[i]) || (publisher.Type != config.TopologyPublisherFile && !manager) {
+			continue
+		}
+		if app.cfg().ObserveOnly {
+			app.logger.Infof("observe-only: would publish topology to %s: %s", publisher.Name, topology)
+			published[i] = topology
+			continue
+		}
+		err = app.publishTopology(ctx, publisher, topology)
+		if err != nil {
+			app.logger.Errorf("topology: failed to publish to %s: %v", publisher.Name, err)
+			continue
+		}
+		app.logger.Infof("topology: published to %s: %s", publisher.Name, topology)
+		published[i] = topology
+	}
+}
+
+func (app *App) publishTopology(ctx context.Context, publisher config.TopologyPublisherConfig, topology *Topology) error {
+	content, err := renderTopology(publisher, topology)
+	if err != nil {
+		return err
+	}
+	timeout := publisher.Timeout
+	if timeout == 0 {
+		timeout = topologyPublishDefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	switch publisher.Type {
+	case config.TopologyPublisherEtcd:
+		b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+		body, err := json.Marshal(map[string]string{"key": b64(publisher.Key), "value": b64(string(content))})
+		if err != nil {
+			return err
+		}
+		return putTopology(ctx, http.MethodPost, strings.TrimSuffix(publisher.Endpoint, "/")+"/v3/kv/put", body, nil)
+	case config.TopologyPublisherConsul:
+		headers := map[string]string{}
+		if publisher.Token != "" {
+			headers["X-Consul-Token"] = publisher.Token
+		}
+		url := strings.TrimSuffix(publisher.Endpoint, "/") + "/v1/kv/" + strings.TrimPrefix(publisher.Key, "/")
+		return putTopology(ctx, http.MethodPut, url, content, headers)
+	case config.TopologyPublisherConfigMap:
+		client, err := newKubeClient(app.cfg().Kubernetes, timeout)
+		if err != nil {
+			return err
+		}
+		return client.setConfigMapData(ctx, publisher.Key, map[string]string{
+			"master":   topology.Master,
+			"replicas": strings.Join(topology.Replicas, ","),
+			"topology": string(content),
+		})
+	case config.TopologyPublisherFile:
+		return writeTopologyFile(ctx, publisher, content)
+	}
+	return fmt.Errorf("unknown topology publisher type %q", publisher.Type)
+}
+
+func putTopology(ctx context.Context, method, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, req.URL.Path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// writeTopologyFile atomically replaces file content and runs reload command, if content was changed
+func writeTopologyFile(ctx context.Context, publisher config.TopologyPublisherConfig, content []byte) error {
+	old, err := os.ReadFile(publisher.Key)
+	if err == nil && bytes.Equal(old, content) {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(publisher.Key), filepath.Base(publisher.Key)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmp.Name(), publisher.Key)
+	if err != nil {
+		return err
+	}
+	if publisher.ReloadCommand == "" {
+		return nil
+	}
+	output, err := exec.CommandContext(ctx, "/bin/sh", "-c", publisher.ReloadCommand).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("reload command failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestRenderTopology(t *testing.T) {
+	topology := &Topology{Master: "mysql1", Replicas: []string{"mysql2", "mysql3"}, Epoch: 3}
+
+	content, err := renderTopology(config.TopologyPublisherConfig{}, topology)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"master":"mysql1","replicas":["mysql2","mysql3"],"epoch":3}`, string(content))
+
+	publisher := config.TopologyPublisherConfig{Template: "writer={{.Master}}\n{{range .Replicas}}reader={{.}}\n{{end}}"}
+	content, err = renderTopology(publisher, topology)
+	require.NoError(t, err)
+	require.Equal(t, "writer=mysql1\nreader=mysql2\nreader=mysql3\n", string(content))
+
+	require.True(t, topology.equal(&Topology{Master: "mysql1", Replicas: []string{"mysql2", "mysql3"}, Epoch: 3}))
+	require.False(t, topology.equal(&Topology{Master: "mysql1", Replicas: []string{"mysql2"}, Epoch: 3}))
+	require.False(t, topology.equal(nil))
+}
+
+func TestWriteTopologyFile(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "reloaded")
+	publisher := config.TopologyPublisherConfig{
+		Key:           filepath.Join(dir, "topology.json"),
+		ReloadCommand: "echo x >> " + marker,
+	}
+	require.NoError(t, writeTopologyFile(context.Background(), publisher, []byte("a")))
+	require.NoError(t, writeTopologyFile(context.Background(), publisher, []byte("a")))
+	content, err := os.ReadFile(publisher.Key)
+	require.NoError(t, err)
+	require.Equal(t, "a", string(content))
+	reloads, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	require.Equal(t, "x\n", string(reloads))
+}
+
+func TestPublishTopologyObserveOnly(t *testing.T) {
+	app := newTestApp(t, "mysql1")
+	defer app.dcs.Close()
+	key := filepath.Join(t.TempDir(), "topology.json")
+	app.cfg().TopologyPublishers = []config.TopologyPublisherConfig{{Name: "local", Type: config.TopologyPublisherFile, Key: key}}
+	app.cfg().ObserveOnly = true
+	require.NoError(t, app.dcs.Set(pathMasterNode, "mysql1"))
+	require.NoError(t, app.dcs.Set(pathActiveNodes, []string{"mysql1", "mysql2"}))
+
+	published := make([]*Topology, 1)
+	app.publishTopologyChanges(context.Background(), published)
+	require.NoFileExists(t, key)
+	require.Equal(t, "mysql1", published[0].Master)
+
+	app.cfg().ObserveOnly = false
+	app.publishTopologyChanges(context.Background(), make([]*Topology, 1))
+	require.FileExists(t, key)
+}
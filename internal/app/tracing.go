@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	tracerName             = "github.com/yandex/mysync"
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// initTracing sets up export of spans via OTLP, when otlp_endpoint is configured.
+// Otherwise tracer of app is no-op. Provider is not installed globally, as clusters
+// managed by one process export spans with their own resource. Returned function flushes pending spans
+func (app *App) initTracing(ctx context.Context) (func(), error) {
+	if app.cfg().OTLPEndpoint == "" {
+		return func() {}, nil
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(app.cfg().OTLPEndpoint)}
+	if app.cfg().OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "mysync"),
+			attribute.String("host.name", app.cfg().Hostname),
+		)),
+	)
+	app.tracerProvider = provider
+	app.logger.Infof("tracing: exporting spans to %s", app.cfg().OTLPEndpoint)
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(shutdownCtx); err != nil {
+			app.logger.Warnf("tracing: failed to flush spans: %v", err)
+		}
+	}, nil
+}
+
+// tracer returns tracer of app own provider
+func (app *App) tracer() trace.Tracer {
+	if app.tracerProvider == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return app.tracerProvider.Tracer(tracerName)
+}
+
+// switchoverTrace is a span of switchover with child span for each of its phases
+type switchoverTrace struct {
+	ctx       context.Context
+	tracer    trace.Tracer
+	root      trace.Span
+	phase     trace.Span
+	phaseName string
+	liveness  *agentLiveness
+}
+
+func (app *App) startSwitchoverTrace(switchover *Switchover, oldMaster string) *switchoverTrace {
+	tracer := app.tracer()
+	ctx, root := tracer.Start(context.Background(), "switchover",
+		trace.WithAttributes(
+			attribute.String("mysync.switchover.from", switchover.From),
+			attribute.String("mysync.switchover.to", switchover.To),
+			attribute.String("mysync.switchover.cause", switchover.Cause),
+			attribute.String("mysync.switchover.initiated_by", switchover.InitiatedBy),
+			attribute.String("mysync.old_master", oldMaster),
+		))
+	return &switchoverTrace{ctx: ctx, tracer: tracer, root: root, liveness: &app.liveness}
+}
+
+// startPhase finishes previous phase span and starts the next one,
+// switchover phases keep main loop alive for health endpoints
+func (t *switchoverTrace) startPhase(name string) {
+	t.phaseName = "switchover: " + name
+	t.liveness.enterPhase(t.phaseName, time.Now(), 0)
+	if t.phase != nil {
+		t.phase.End()
+	}
+	_, t.phase = t.tracer.Start(t.ctx, name)
+}
+
+// expectLongPhase tells health endpoints that current phase may take up to budget
+func (t *switchoverTrace) expectLongPhase(budget time.Duration) {
+	t.liveness.enterPhase(t.phaseName, time.Now(), budget)
+}
+
+// setNewMaster annotates switchover with chosen master
+func (t *switchoverTrace) setNewMaster(host string) {
+	t.root.SetAttributes(attribute.String("mysync.new_master", host))
+}
+
+// end finishes switchover span, marking failed phase with error
+func (t *switchoverTrace) end(err error) {
+	if err != nil {
+		span := t.root
+		if t.phase != nil {
+			span = t.phase
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.root.SetStatus(codes.Error, "switchover failed")
+	}
+	if t.phase != nil {
+		t.phase.End()
+	}
+	t.root.End()
+}
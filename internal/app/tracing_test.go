@@ -0,0 +1,32 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSwitchoverTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	app := &App{tracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))}
+	tr := app.startSwitchoverTrace(&Switchover{From: "h1", Cause: CauseManual}, "h1")
+	tr.startPhase("enter read only")
+	tr.startPhase("catch up")
+	tr.setNewMaster("h2")
+	tr.end(errors.New("new master h2 failed to catch up"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	require.Equal(t, "enter read only", spans[0].Name)
+	require.Equal(t, codes.Unset, spans[0].Status.Code)
+	require.Equal(t, "catch up", spans[1].Name)
+	require.Equal(t, codes.Error, spans[1].Status.Code)
+	require.Equal(t, "switchover", spans[2].Name)
+	require.Equal(t, codes.Error, spans[2].Status.Code)
+	require.Equal(t, spans[2].SpanContext.SpanID(), spans[0].Parent.SpanID())
+	require.Equal(t, spans[2].SpanContext.SpanID(), spans[1].Parent.SpanID())
+}
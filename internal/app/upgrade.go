@@ -0,0 +1,210 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// getRollingUpgrade returns rolling upgrade declared by operator or nil
+func (app *App) getRollingUpgrade() (*RollingUpgrade, error) {
+	upgrade := new(RollingUpgrade)
+	err := app.dcs.Get(pathRollingUpgrade, upgrade)
+	if err == dcs.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return upgrade, nil
+}
+
+// getHostVersions returns MySQL versions published by mysync agents in their health nodes
+// hosts of unknown version are omitted
+func (app *App) getHostVersions(hosts []string) map[string]*mysql.Version {
+	versions := make(map[string]*mysql.Version)
+	for _, host := range hosts {
+		nodeState := new(NodeState)
+		err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, host), nodeState)
+		if err != nil {
+			if err != dcs.ErrNotFound {
+				app.logger.Warnf("version: failed to get health of %s: %v", host, err)
+			}
+			continue
+		}
+		if nodeState.MySQLVersion != nil {
+			versions[host] = nodeState.MySQLVersion
+		}
+	}
+	return versions
+}
+
+// versionConflict returns why host can't be promoted, or empty string.
+// Replication from newer source to older replica is not supported, so new master should not run
+// newer series than any of its future replicas. During rolling upgrade it is expected, but
+// new master should not be older than old one, otherwise upgrade rolls back.
+func versionConflict(host string, versions map[string]*mysql.Version, oldMaster string, upgrade bool) string {
+	version := versions[host]
+	if version == nil {
+		return ""
+	}
+	if upgrade {
+		if masterVersion := versions[oldMaster]; masterVersion != nil && version.CompareSeries(masterVersion) < 0 {
+			return fmt.Sprintf("%s runs MySQL %s older than %s on master %s during rolling upgrade", host, version, masterVersion, oldMaster)
+		}
+		return ""
+	}
+	hosts := make([]string, 0, len(versions))
+	for other := range versions {
+		hosts = append(hosts, other)
+	}
+	sort.Strings(hosts)
+	for _, other := range hosts {
+		if other != host && version.CompareSeries(versions[other]) > 0 {
+			return fmt.Sprintf("%s runs MySQL %s newer than %s on %s, which can't replicate from it", host, version, versions[other], other)
+		}
+	}
+	return ""
+}
+
+// filterPositionsByVersion drops candidates conflicting by version with the rest of cluster,
+// during rolling upgrade only candidates of the newest series are kept to move upgrade forward
+func filterPositionsByVersion(positions []nodePosition, versions map[string]*mysql.Version, oldMaster string, upgrade bool) []nodePosition {
+	var allowed []nodePosition
+	var newest *mysql.Version
+	for _, pos := range positions {
+		if versionConflict(pos.host, versions, oldMaster, upgrade) != "" {
+			continue
+		}
+		allowed = append(allowed, pos)
+		if v := versions[pos.host]; v != nil && (newest == nil || v.CompareSeries(newest) > 0) {
+			newest = v
+		}
+	}
+	if !upgrade || newest == nil {
+		return allowed
+	}
+	var newer []nodePosition
+	for _, pos := range allowed {
+		if v := versions[pos.host]; v == nil || v.CompareSeries(newest) == 0 {
+			newer = append(newer, pos)
+		}
+	}
+	return newer
+}
+
+// applyVersionPolicy filters candidate positions by MySQL versions of cluster hosts
+func (app *App) applyVersionPolicy(positions []nodePosition, oldMaster string) ([]nodePosition, error) {
+	if !app.cfg().VersionAwareSwitchover {
+		return positions, nil
+	}
+	upgrade, err := app.getRollingUpgrade()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rolling upgrade: %v", err)
+	}
+	versions := app.getHostVersions(append(app.getKnownHosts(), oldMaster))
+	filtered := filterPositionsByVersion(positions, versions, oldMaster, upgrade != nil)
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no candidates left after applying version policy")
+	}
+	if len(filtered) != len(positions) {
+		app.logger.Infof("version: candidates after applying version policy (rolling upgrade %v): %v", upgrade != nil, positionHosts(filtered))
+	}
+	return filtered, nil
+}
+
+// checkVersionAllowed returns error if host can't be promoted because of its MySQL version
+func (app *App) checkVersionAllowed(host, oldMaster string) error {
+	if !app.cfg().VersionAwareSwitchover {
+		return nil
+	}
+	upgrade, err := app.getRollingUpgrade()
+	if err != nil {
+		return fmt.Errorf("failed to get rolling upgrade: %v", err)
+	}
+	versions := app.getHostVersions(append(app.getKnownHosts(), oldMaster))
+	if conflict := versionConflict(host, versions, oldMaster, upgrade != nil); conflict != "" {
+		return fmt.Errorf("%s", conflict)
+	}
+	return nil
+}
+
+// CliUpgrade shows rolling upgrade state and MySQL versions of hosts
+func (app *App) CliUpgrade(format string) int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	upgrade, err := app.getRollingUpgrade()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	data := make(map[string]interface{})
+	data["rolling_upgrade"] = upgrade != nil
+	if upgrade != nil {
+		data[pathRollingUpgrade] = upgrade.String()
+	}
+	versions := make(map[string]string)
+	for host, version := range app.getHostVersions(app.getKnownHosts()) {
+		versions[host] = version.String()
+	}
+	data["versions"] = versions
+	return app.printCliOutput(data, format)
+}
+
+// CliUpgradeOn declares rolling upgrade of MySQL, so hosts may run different versions
+func (app *App) CliUpgradeOn() int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	upgrade := &RollingUpgrade{StartedAt: time.Now(), Operator: app.currentOperator()}
+	err = app.dcs.Create(pathRollingUpgrade, upgrade)
+	if err == dcs.ErrExists {
+		fmt.Println("rolling upgrade is already on")
+		return 0
+	}
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	app.recordEvent(HistoryEvent{Type: EventUpgradeOn, Message: "rolling upgrade started", Operator: upgrade.Operator})
+	fmt.Println("rolling upgrade is on")
+	return 0
+}
+
+// CliUpgradeOff finishes rolling upgrade, so promotion requires compatible versions again
+func (app *App) CliUpgradeOff() int {
+	err := app.connectDCS()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	defer app.dcs.Close()
+	app.dcs.Initialize()
+
+	err = app.dcs.Delete(pathRollingUpgrade)
+	if err == dcs.ErrNotFound {
+		fmt.Println("rolling upgrade is already off")
+		return 0
+	}
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 1
+	}
+	app.recordEvent(HistoryEvent{Type: EventUpgradeOff, Message: "rolling upgrade finished", Operator: app.currentOperator()})
+	fmt.Println("rolling upgrade is off")
+	return 0
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+func TestFilterPositionsByVersion(t *testing.T) {
+	v80 := &mysql.Version{MajorVersion: 8, MinorVersion: 0, PatchVersion: 36}
+	v80old := &mysql.Version{MajorVersion: 8, MinorVersion: 0, PatchVersion: 20}
+	v84 := &mysql.Version{MajorVersion: 8, MinorVersion: 4, PatchVersion: 2}
+	positions := []nodePosition{{host: "mysql2"}, {host: "mysql3"}, {host: "mysql4"}}
+	versions := map[string]*mysql.Version{"mysql1": v80, "mysql2": v84, "mysql3": v80old}
+
+	// patch versions of one series are compatible, host of unknown version is kept
+	filtered := filterPositionsByVersion(positions, versions, "mysql1", false)
+	require.Equal(t, []string{"mysql3", "mysql4"}, positionHosts(filtered))
+	require.Contains(t, versionConflict("mysql2", versions, "mysql1", false), "newer than 8.0.36 on mysql1")
+
+	// rolling upgrade moves forward only
+	filtered = filterPositionsByVersion(positions, versions, "mysql1", true)
+	require.Equal(t, []string{"mysql2", "mysql4"}, positionHosts(filtered))
+
+	versions["mysql1"] = v84
+	require.Contains(t, versionConflict("mysql3", versions, "mysql1", true), "older than 8.4.2 on master mysql1")
+	require.Equal(t, "", versionConflict("mysql2", versions, "mysql1", true))
+}
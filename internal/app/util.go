@@ -136,6 +136,17 @@ func filterOutNodeFromPositions(positions []nodePosition, hostToFilterOut string
 	return res
 }
 
+// filterPositionsByHosts keeps positions of given hosts only
+func filterPositionsByHosts(positions []nodePosition, hosts []string) []nodePosition {
+	res := []nodePosition{}
+	for _, pos := range positions {
+		if util.ContainsString(hosts, pos.host) {
+			res = append(res, pos)
+		}
+	}
+	return res
+}
+
 func countAliveHASlavesWithinNodes(nodes []string, clusterState map[string]*NodeState) int {
 	cnt := 0
 	for _, hostname := range nodes {
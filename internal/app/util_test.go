@@ -8,11 +8,24 @@ import (
 	"github.com/stretchr/testify/require"
 
 	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/yandex/mysync/internal/config"
 	"github.com/yandex/mysync/internal/log"
 	"github.com/yandex/mysync/internal/mysql"
 	"github.com/yandex/mysync/internal/mysql/gtids"
+	"github.com/yandex/mysync/mysynctest"
 )
 
+// newTestApp returns app of host with default config and its own in-memory dcs,
+// tests of several hosts replace dcs with sessions of shared mysynctest.MemStore
+func newTestApp(t *testing.T, host string) *App {
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	cfg.Hostname = host
+	return &App{config: config.NewHolder(&cfg), logger: logger, dcs: mysynctest.NewMemDCS(host)}
+}
+
 func mustGTIDSet(s string) gomysql.GTIDSet {
 	gtid, err := gomysql.ParseGTIDSet(gomysql.MySQLFlavor, s)
 	if err != nil {
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/internal/vault"
+)
+
+// vaultMinRetryInterval limits rate of requests to Vault on failures
+const vaultMinRetryInterval = 10 * time.Second
+
+// vaultCredentialsRenewer keeps MySQL credentials issued by Vault database secret engine valid.
+// Lease is renewed at half of its duration, new credentials are issued when lease can't be renewed
+// or reaches its max TTL. New credentials are used for new connections only, old connections are
+// recycled by connection pool
+func (app *App) vaultCredentialsRenewer(ctx context.Context) {
+	lease := app.cfg().MySQLCredentialsLease
+	client, err := app.cfg().NewVaultClient()
+	if err != nil {
+		app.logger.Errorf("vault: failed to create client: %v", err)
+		return
+	}
+	retired := make(chan *vault.Lease)
+	go app.vaultLeaseRevoker(ctx, client, retired)
+	for {
+		wait := lease.Duration / 2
+		if wait < vaultMinRetryInterval {
+			wait = vaultMinRetryInterval
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+		if lease.Renewable {
+			duration, err := client.RenewLease(lease)
+			if err == nil && duration >= lease.Duration {
+				app.logger.Debugf("vault: mysql credentials lease renewed for %v", duration)
+				continue
+			}
+			if err != nil {
+				app.logger.Warnf("vault: failed to renew mysql credentials lease: %v", err)
+			}
+		}
+		newLease, err := app.rotateVaultCredentials(client)
+		if err != nil {
+			app.logger.Errorf("vault: failed to rotate mysql credentials: %v", err)
+			continue
+		}
+		if lease.ID != "" {
+			select {
+			case retired <- lease:
+			case <-ctx.Done():
+				return
+			}
+		}
+		lease = newLease
+	}
+}
+
+// vaultLeaseRevoker revokes leases of rotated credentials once connections opened with them
+// are recycled by connection pool, revoking them at once would break open connections
+func (app *App) vaultLeaseRevoker(ctx context.Context, client *vault.Client, retired <-chan *vault.Lease) {
+	var pending []*vault.Lease
+	var revoke <-chan time.Time
+	for {
+		select {
+		case lease := <-retired:
+			pending = append(pending, lease)
+			revoke = time.After(mysql.ConnMaxLifetime(app.cfg()))
+			continue
+		case <-revoke:
+		case <-ctx.Done():
+		}
+		for _, lease := range pending {
+			err := client.RevokeLease(lease)
+			if err != nil {
+				app.logger.Warnf("vault: failed to revoke previous mysql credentials lease: %v", err)
+			}
+		}
+		pending, revoke = nil, nil
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (app *App) rotateVaultCredentials(client *vault.Client) (*vault.Lease, error) {
+	creds, err := config.FetchVaultCredentials(client, app.cfg().Vault.MySQL)
+	if err != nil {
+		return nil, err
+	}
+	lease := creds.Lease
+	if lease == nil {
+		lease = &vault.Lease{}
+	}
+	app.config.Update(func(cfg *config.Config) {
+		cfg.SetMySQLCredentials(creds.Username, creds.Password)
+		cfg.MySQLCredentialsLease = lease
+	})
+	app.logger.Infof("vault: mysql credentials rotated, new user %s", creds.Username)
+	return lease, nil
+}
+
+// Close revokes credentials issued by Vault for this process,
+// CLI commands and stopped agent do not need them anymore
+func (app *App) Close() {
+	err := app.cfg().RevokeVaultLeases()
+	if err != nil {
+		app.logger.Warnf("vault: %v", err)
+	}
+}
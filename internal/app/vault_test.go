@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/vault"
+)
+
+func TestVaultLeaseRevokedAfterConnectionsRecycled(t *testing.T) {
+	var revoked atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/sys/leases/revoke" {
+			revoked.Add(1)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "token")
+	client, err := vault.NewClient(server.URL, "", "", time.Second)
+	require.NoError(t, err)
+
+	app := newTestApp(t, "mysql1")
+	app.cfg().TickInterval = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	retired := make(chan *vault.Lease)
+	go app.vaultLeaseRevoker(ctx, client, retired)
+
+	retired <- &vault.Lease{ID: "database/creds/mysync/1"}
+	// connections opened with previous credentials are still in pool
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(0), revoked.Load())
+	require.Eventually(t, func() bool { return revoked.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Version of mysync, set at build time with -ldflags "-X github.com/yandex/mysync/internal/app.Version=..."
+var Version = ""
+
+// ProtocolVersion is version of data agents exchange via DCS. It should be incremented
+// on changes, which agents of previous versions can't handle during switchover
+const ProtocolVersion = 1
+
+// AgentVersion returns version of running binary
+func AgentVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return setting.Value[:12]
+		}
+	}
+	return "unknown"
+}
+
+func (as *AgentState) versionString() string {
+	return fmt.Sprintf("%s (protocol %d, config %s)", as.Version, as.ProtocolVersion, as.ConfigHash)
+}
+
+// versionSkew returns versions of agents, if they differ in version, protocol or config
+func versionSkew(clusterState map[string]*NodeState) map[string]string {
+	versions := make(map[string]string)
+	distinct := make(map[string]bool)
+	for host, state := range clusterState {
+		if state == nil || state.AgentState == nil || state.AgentState.Version == "" {
+			continue
+		}
+		v := state.AgentState.versionString()
+		versions[host] = v
+		distinct[v] = true
+	}
+	if len(distinct) < 2 {
+		return nil
+	}
+	return versions
+}
+
+// checkProtocolSkew returns error, if active agents use different protocol versions
+func checkProtocolSkew(activeNodes []string, clusterStateDcs map[string]*NodeState) error {
+	protocols := make(map[int][]string)
+	for _, host := range activeNodes {
+		state := clusterStateDcs[host]
+		if state == nil || state.AgentState == nil || state.AgentState.ProtocolVersion == 0 {
+			// agents older than version reporting
+			protocols[0] = append(protocols[0], host)
+			continue
+		}
+		protocols[state.AgentState.ProtocolVersion] = append(protocols[state.AgentState.ProtocolVersion], host)
+	}
+	if len(protocols) < 2 {
+		return nil
+	}
+	groups := make([]string, 0, len(protocols))
+	for protocol, hosts := range protocols {
+		sort.Strings(hosts)
+		groups = append(groups, fmt.Sprintf("protocol %d on %s", protocol, strings.Join(hosts, ", ")))
+	}
+	sort.Strings(groups)
+	return fmt.Errorf("agents of incompatible versions are running: %s", strings.Join(groups, "; "))
+}
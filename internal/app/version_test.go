@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionSkew(t *testing.T) {
+	agent := func(version string, protocol int, hash string) *NodeState {
+		return &NodeState{AgentState: &AgentState{Version: version, ProtocolVersion: protocol, ConfigHash: hash}}
+	}
+	state := map[string]*NodeState{
+		"mysql1": agent("1.1", 1, "abc"),
+		"mysql2": agent("1.1", 1, "abc"),
+		"mysql3": {},
+	}
+	require.Nil(t, versionSkew(state))
+	require.NoError(t, checkProtocolSkew([]string{"mysql1", "mysql2"}, state))
+
+	state["mysql2"] = agent("1.2", 1, "abc")
+	require.Equal(t, map[string]string{
+		"mysql1": "1.1 (protocol 1, config abc)",
+		"mysql2": "1.2 (protocol 1, config abc)",
+	}, versionSkew(state))
+	require.NoError(t, checkProtocolSkew([]string{"mysql1", "mysql2"}, state))
+
+	state["mysql2"] = agent("2.0", 2, "abc")
+	require.EqualError(t, checkProtocolSkew([]string{"mysql1", "mysql2", "mysql3"}, state),
+		"agents of incompatible versions are running: protocol 0 on mysql3; protocol 1 on mysql1; protocol 2 on mysql2")
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// vipState is state of virtual IP on the local host
+type vipState struct {
+	held   bool
+	locked bool
+	// VIP possibly left by previous run is removed once on start
+	cleaned        bool
+	disconnectedAt time.Time
+}
+
+// vipManager keeps virtual IP on the current master. Master acquires VIP lock in dcs, checks that
+// no other host answers for the address and adds it. Other hosts remove VIP and only then release the lock,
+// so the new master does not take the address until the old one released it or lost its dcs session
+func (app *App) vipManager(ctx context.Context) {
+	var state vipState
+	ticker := time.NewTicker(app.cfg().VIP.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			app.syncVIP(&state)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (app *App) syncVIP(state *vipState) {
+	cfg := app.cfg().VIP
+	local := app.cluster.Local()
+	if !state.cleaned {
+		_ = local.DeleteVIP(cfg.Address, cfg.Interface, cfg.CommandTimeout)
+		state.cleaned = true
+	}
+	release := func(reason string) {
+		if state.held {
+			err := local.DeleteVIP(cfg.Address, cfg.Interface, cfg.CommandTimeout)
+			if err != nil {
+				app.logger.Errorf("vip: failed to remove %s: %v", cfg.Address, err)
+				return
+			}
+			app.logger.Infof("vip: %s removed: %s", cfg.Address, reason)
+			state.held = false
+		}
+		if state.locked {
+			app.dcs.ReleaseLock(pathVIPLock)
+			state.locked = false
+		}
+	}
+
+	// lock is ephemeral, so the new master may get it as soon as our dcs session expires
+	if !app.dcs.IsConnected() {
+		if state.disconnectedAt.IsZero() {
+			state.disconnectedAt = time.Now()
+		}
+		if time.Since(state.disconnectedAt) >= app.cfg().Zookeeper.SessionTimeout {
+			release("dcs is not connected")
+		}
+		return
+	}
+	state.disconnectedAt = time.Time{}
+	master, err := app.GetMasterHostFromDcs()
+	if err != nil {
+		app.logger.Warnf("vip: %v", err)
+		return
+	}
+	if master != app.cfg().Hostname {
+		release("host is not master")
+		return
+	}
+	if !app.dcs.AcquireLock(pathVIPLock) {
+		state.locked = false
+		app.logger.Warnf("vip: waiting for previous holder to release %s", cfg.Address)
+		return
+	}
+	state.locked = true
+	if state.held {
+		return
+	}
+	free, err := local.IsVIPFree(cfg.Address, cfg.Interface, cfg.CommandTimeout)
+	if err != nil || !free {
+		app.logger.Errorf("vip: %s is still used by another host (%v), not taking it", cfg.Address, err)
+		return
+	}
+	err = local.AddVIP(cfg.Address, cfg.Interface, cfg.CommandTimeout)
+	if err != nil {
+		app.logger.Errorf("vip: failed to add %s: %v", cfg.Address, err)
+		return
+	}
+	state.held = true
+	app.logger.Infof("vip: %s added to %s", cfg.Address, app.cfg().Hostname)
+}
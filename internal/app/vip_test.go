@@ -0,0 +1,77 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestSyncVIP(t *testing.T) {
+	dir := t.TempDir()
+	actions := filepath.Join(dir, "actions")
+	busy := filepath.Join(dir, "busy")
+	app := newTestApp(t, "mysql1")
+	app.cfg().VIP.Address = "10.0.0.10/24"
+	app.cfg().VIP.Interface = "eth0"
+	app.cfg().Commands = map[string]string{
+		"vip_add":   `echo "add $MYSYNC_VIP_ADDRESS $MYSYNC_VIP_INTERFACE" >> ` + actions,
+		"vip_del":   `echo del >> ` + actions,
+		"vip_check": `test ! -e ` + busy,
+	}
+	store := mysynctest.NewMemStore()
+	dcs1 := store.Session("mysql1")
+	dcs2 := store.Session("mysql2")
+	app.dcs = dcs1
+	cluster, err := mysql.NewCluster(app.config, app.logger, dcs1)
+	require.NoError(t, err)
+	defer cluster.Close()
+	app.cluster = cluster
+	readActions := func() []string {
+		data, _ := os.ReadFile(actions)
+		_ = os.Remove(actions)
+		return strings.Fields(strings.ReplaceAll(string(data), " ", "_"))
+	}
+
+	var state vipState
+	require.NoError(t, dcs1.Set(pathMasterNode, "mysql2"))
+	app.syncVIP(&state)
+	require.Equal(t, []string{"del"}, readActions())
+	require.False(t, state.held)
+
+	// previous holder did not release the lock yet
+	require.True(t, dcs2.AcquireLock(pathVIPLock))
+	require.NoError(t, dcs1.Set(pathMasterNode, "mysql1"))
+	app.syncVIP(&state)
+	require.Empty(t, readActions())
+
+	// address still answers from another host
+	dcs2.ReleaseLock(pathVIPLock)
+	require.NoError(t, os.WriteFile(busy, nil, 0o644))
+	app.syncVIP(&state)
+	require.Empty(t, readActions())
+	require.False(t, state.held)
+
+	require.NoError(t, os.Remove(busy))
+	app.syncVIP(&state)
+	require.Equal(t, []string{"add_10.0.0.10_eth0"}, readActions())
+	require.True(t, state.held)
+	require.False(t, dcs2.AcquireLock(pathVIPLock))
+
+	require.NoError(t, dcs1.Set(pathMasterNode, "mysql2"))
+	app.syncVIP(&state)
+	require.Equal(t, []string{"del"}, readActions())
+	require.True(t, dcs2.AcquireLock(pathVIPLock))
+
+	dcs1.SetConnected(false)
+	state.held = true
+	app.cfg().Zookeeper.SessionTimeout = time.Hour
+	app.syncVIP(&state)
+	require.Empty(t, readActions())
+}
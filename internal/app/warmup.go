@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+// bufferPoolLoaded checks Innodb_buffer_pool_load_status, reporting whether load is over.
+// Status left by previous load (e.g. on startup) is reported until new load is picked up,
+// so load is not over while status is the same as before it was started
+func bufferPoolLoaded(before, status string) (done bool, err error) {
+	switch {
+	case status == before:
+		return false, nil
+	case strings.Contains(status, "completed"):
+		return true, nil
+	case strings.Contains(status, "aborted"), strings.Contains(status, "Cannot"), strings.Contains(status, "Error"):
+		return true, fmt.Errorf("%s", status)
+	}
+	return false, nil
+}
+
+// warmupEvent logs warm-up progress and records it to event history
+func (app *App) warmupEvent(host, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	app.logger.Infof("warm-up: %s: %s", host, message)
+	app.recordEvent(HistoryEvent{Type: EventWarmup, Host: host, Message: message})
+}
+
+// warmupNewMaster prepares promoted master to full load before it is announced:
+// raises max_connections, preloads buffer pool and runs warm-up queries within promotion_warmup_timeout,
+// but not longer than switchover_timeout allows. Warm-up is best effort, its failures do not fail switchover.
+func (app *App) warmupNewMaster(host string, node *mysql.Node, switchover *Switchover) {
+	budget := app.cfg().PromotionWarmupTimeout
+	if budget == 0 {
+		return
+	}
+	if app.cfg().SwitchoverTimeout > 0 && !switchover.StartedAt.IsZero() {
+		left := time.Until(switchover.StartedAt.Add(app.cfg().SwitchoverTimeout))
+		if left <= 0 {
+			app.warmupEvent(host, "skipped, switchover_timeout is exhausted")
+			return
+		}
+		budget = min(budget, left)
+	}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+	app.warmupEvent(host, "started with budget of %v", budget)
+
+	if target := app.cfg().PromotionWarmupMaxConnections; target > 0 {
+		current, err := node.GetMaxConnections()
+		if err != nil {
+			app.logger.Warnf("warm-up: failed to get max_connections on %s: %v", host, err)
+		} else if current < target {
+			err = node.SetMaxConnections(target)
+			if err != nil {
+				app.logger.Warnf("warm-up: failed to set max_connections on %s: %v", host, err)
+			} else {
+				app.warmupEvent(host, "max_connections raised from %d to %d", current, target)
+			}
+		}
+	}
+
+	bufferPool := app.cfg().PromotionWarmupBufferPool
+	var bufferPoolBefore string
+	if bufferPool {
+		var err error
+		bufferPoolBefore, err = node.GetBufferPoolLoadStatus()
+		if err == nil {
+			err = node.StartBufferPoolLoad()
+		}
+		if err != nil {
+			app.logger.Warnf("warm-up: failed to start buffer pool load on %s: %v", host, err)
+			bufferPool = false
+		}
+	}
+
+	queries := app.cfg().PromotionWarmupQueries
+	for i, query := range queries {
+		deadline, _ := ctx.Deadline()
+		err := node.RunQuery(query, time.Until(deadline))
+		if ctx.Err() != nil {
+			app.warmupEvent(host, "budget exhausted after %d of %d queries", i, len(queries))
+			return
+		}
+		if err != nil {
+			app.logger.Warnf("warm-up: query %d on %s failed: %v", i+1, host, err)
+			continue
+		}
+		app.warmupEvent(host, "query %d of %d done", i+1, len(queries))
+		app.liveness.tickPhase(time.Now())
+	}
+
+	if bufferPool {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			status, err := node.GetBufferPoolLoadStatus()
+			if err != nil {
+				app.logger.Warnf("warm-up: failed to get buffer pool load status on %s: %v", host, err)
+				break
+			}
+			done, err := bufferPoolLoaded(bufferPoolBefore, status)
+			if err != nil {
+				app.logger.Warnf("warm-up: buffer pool load on %s failed: %v", host, err)
+				break
+			}
+			if done {
+				app.warmupEvent(host, "buffer pool loaded")
+				break
+			}
+			app.liveness.tickPhase(time.Now())
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				app.warmupEvent(host, "budget exhausted, buffer pool load: %s", status)
+				return
+			}
+		}
+	}
+	app.warmupEvent(host, "finished in %v", time.Since(start).Round(time.Millisecond))
+}
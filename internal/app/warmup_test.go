@@ -0,0 +1,31 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferPoolLoaded(t *testing.T) {
+	done, err := bufferPoolLoaded("", "Loaded 1024/8192 pages")
+	require.NoError(t, err)
+	require.False(t, done)
+
+	done, err = bufferPoolLoaded("", "Buffer pool(s) load completed at 241015 10:00:00")
+	require.NoError(t, err)
+	require.True(t, done)
+
+	done, err = bufferPoolLoaded("", "Buffer pool(s) load aborted on request")
+	require.Error(t, err)
+	require.True(t, done)
+
+	// status of load made on startup is not mistaken for the new one
+	before := "Buffer pool(s) load completed at 241015 09:00:00"
+	done, err = bufferPoolLoaded(before, before)
+	require.NoError(t, err)
+	require.False(t, done)
+
+	done, err = bufferPoolLoaded(before, "Buffer pool(s) load completed at 241015 10:00:00")
+	require.NoError(t, err)
+	require.True(t, done)
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/internal/util"
+)
+
+// getHostZones returns zone labels published by mysync agents in their health nodes
+// hosts without zone label are omitted
+func (app *App) getHostZones(hosts []string) map[string]string {
+	zones := make(map[string]string)
+	for _, host := range hosts {
+		nodeState := new(NodeState)
+		err := app.dcs.Get(dcs.JoinPath(pathHealthPrefix, host), nodeState)
+		if err != nil {
+			if err != dcs.ErrNotFound {
+				app.logger.Warnf("zone: failed to get health of %s: %v", host, err)
+			}
+			continue
+		}
+		if nodeState.Zone != "" {
+			zones[host] = nodeState.Zone
+		}
+	}
+	return zones
+}
+
+// checkZoneAllowed returns error if host is located in zone where promotion is forbidden
+// or is not reachable from enough zones
+func (app *App) checkZoneAllowed(host string) error {
+	if len(app.cfg().ZonePolicy.ForbiddenZones) > 0 {
+		zone := app.getHostZones([]string{host})[host]
+		if util.ContainsString(app.cfg().ZonePolicy.ForbiddenZones, zone) {
+			return fmt.Errorf("host %s is located in zone %s, where promotion is forbidden", host, zone)
+		}
+	}
+	if app.cfg().ZonePolicy.MinReachableZones > 0 {
+		positions, err := app.applyReachabilityPolicy([]nodePosition{{host: host}})
+		if err != nil {
+			return err
+		}
+		if len(positions) == 0 {
+			return fmt.Errorf("host %s is seen alive from less than %d zones", host, app.cfg().ZonePolicy.MinReachableZones)
+		}
+	}
+	return nil
+}
+
+// applyReachabilityPolicy keeps candidates, which agents from at least zone_policy.min_reachable_zones zones see alive.
+// Candidate reachable only from its own zone may be cut off from clients of other zones
+func (app *App) applyReachabilityPolicy(positions []nodePosition) ([]nodePosition, error) {
+	minZones := app.cfg().ZonePolicy.MinReachableZones
+	if minZones == 0 {
+		return positions, nil
+	}
+	observations, err := app.getLivenessObservations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get liveness observations: %v", err)
+	}
+	observers := make([]string, 0, len(observations))
+	for observer := range observations {
+		observers = append(observers, observer)
+	}
+	zones := app.getHostZones(observers)
+	maxAge := 3 * app.cfg().LivenessCheckInterval
+	var reachable []nodePosition
+	for _, pos := range positions {
+		seenFrom := reachableFromZones(observations, zones, pos.host, maxAge)
+		if seenFrom < minZones {
+			app.logger.Infof("zone: %s is seen alive from %d zones, while %d is required", pos.host, seenFrom, minZones)
+			continue
+		}
+		reachable = append(reachable, pos)
+	}
+	return reachable, nil
+}
+
+// reachableFromZones counts distinct zones of agents, which fresh observations report host alive.
+// Agents without zone label are not counted
+func reachableFromZones(observations map[string]*LivenessObservation, zones map[string]string, host string, maxAge time.Duration) int {
+	seen := make(map[string]bool)
+	for observer, observation := range observations {
+		if observation == nil || zones[observer] == "" || time.Since(observation.SeenAt) > maxAge {
+			continue
+		}
+		if observation.Alive[host] {
+			seen[zones[observer]] = true
+		}
+	}
+	return len(seen)
+}
+
+// applyZonePolicy filters candidate positions according to zone policy.
+// failedHost is the master being switched from, its zone is preferred if configured
+func (app *App) applyZonePolicy(positions []nodePosition, failedHost string) ([]nodePosition, error) {
+	policy := app.cfg().ZonePolicy
+	positions, err := app.applyReachabilityPolicy(positions)
+	if err != nil {
+		return nil, err
+	}
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no candidates are seen alive from %d zones", policy.MinReachableZones)
+	}
+	if !policy.PreferSameZone && len(policy.ForbiddenZones) == 0 {
+		return positions, nil
+	}
+	hosts := []string{failedHost}
+	for _, pos := range positions {
+		hosts = append(hosts, pos.host)
+	}
+	zones := app.getHostZones(hosts)
+	filtered := filterPositionsByZone(positions, zones, zones[failedHost], policy)
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no candidates left after applying zone policy")
+	}
+	if len(filtered) != len(positions) {
+		app.logger.Infof("zone: candidates after applying zone policy (failed zone %q): %v", zones[failedHost], positionHosts(filtered))
+	}
+	return filtered, nil
+}
+
+// filterPositionsByZone drops candidates from forbidden zones and,
+// if same zone preferred, keeps only candidates from failedZone (when there are any)
+func filterPositionsByZone(positions []nodePosition, zones map[string]string, failedZone string, policy config.ZonePolicyConfig) []nodePosition {
+	var allowed []nodePosition
+	for _, pos := range positions {
+		if util.ContainsString(policy.ForbiddenZones, zones[pos.host]) {
+			continue
+		}
+		allowed = append(allowed, pos)
+	}
+	if !policy.PreferSameZone || failedZone == "" {
+		return allowed
+	}
+	var sameZone []nodePosition
+	for _, pos := range allowed {
+		if zones[pos.host] == failedZone {
+			sameZone = append(sameZone, pos)
+		}
+	}
+	if len(sameZone) > 0 {
+		return sameZone
+	}
+	return allowed
+}
+
+func positionHosts(positions []nodePosition) []string {
+	var hosts []string
+	for _, pos := range positions {
+		hosts = append(hosts, pos.host)
+	}
+	return hosts
+}
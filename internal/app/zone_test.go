@@ -0,0 +1,68 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestFilterPositionsByZone(t *testing.T) {
+	positions := []nodePosition{
+		{"A", nil, 0, 0},
+		{"B", nil, 0, 0},
+		{"C", nil, 0, 0},
+	}
+	zones := map[string]string{
+		"A": "dc1",
+		"B": "dc2",
+		"C": "dc3",
+		"M": "dc2",
+	}
+
+	// no policy - nothing filtered
+	filtered := filterPositionsByZone(positions, zones, "dc2", config.ZonePolicyConfig{})
+	require.Equal(t, []string{"A", "B", "C"}, positionHosts(filtered))
+
+	// same zone preferred
+	filtered = filterPositionsByZone(positions, zones, "dc2", config.ZonePolicyConfig{PreferSameZone: true})
+	require.Equal(t, []string{"B"}, positionHosts(filtered))
+
+	// same zone preferred, but there are no candidates in it
+	filtered = filterPositionsByZone(positions, zones, "dc4", config.ZonePolicyConfig{PreferSameZone: true})
+	require.Equal(t, []string{"A", "B", "C"}, positionHosts(filtered))
+
+	// forbidden zones are never chosen, even if preferred
+	filtered = filterPositionsByZone(positions, zones, "dc2", config.ZonePolicyConfig{
+		PreferSameZone: true,
+		ForbiddenZones: []string{"dc2", "dc3"},
+	})
+	require.Equal(t, []string{"A"}, positionHosts(filtered))
+
+	// all zones forbidden
+	filtered = filterPositionsByZone(positions, zones, "", config.ZonePolicyConfig{
+		ForbiddenZones: []string{"dc1", "dc2", "dc3"},
+	})
+	require.Empty(t, filtered)
+}
+
+func TestReachableFromZones(t *testing.T) {
+	now := time.Now()
+	observations := map[string]*LivenessObservation{
+		"A": {SeenAt: now, Alive: map[string]bool{"C": true}},
+		"B": {SeenAt: now, Alive: map[string]bool{"C": true}},
+		"D": {SeenAt: now, Alive: map[string]bool{"C": true}},
+		"E": {SeenAt: now.Add(-time.Hour), Alive: map[string]bool{"C": true}},
+		"F": {SeenAt: now, Alive: map[string]bool{"C": true}},
+	}
+	zones := map[string]string{"A": "dc1", "B": "dc1", "D": "dc2", "E": "dc3"}
+
+	// agents of the same zone count once, stale observations and agents without zone are ignored
+	require.Equal(t, 2, reachableFromZones(observations, zones, "C", time.Minute))
+
+	observations["D"].Alive["C"] = false
+	require.Equal(t, 1, reachableFromZones(observations, zones, "C", time.Minute))
+	require.Equal(t, 0, reachableFromZones(observations, zones, "X", time.Minute))
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// HostAddressConfig contains addresses of host, when networks of cluster are split and its name
+// does not resolve to them. Empty address means host name is used.
+type HostAddressConfig struct {
+	// address mysync connects to MySQL by
+	Management string `config:"management" yaml:"management"`
+	// address replicas connect to MySQL by, it is used in CHANGE MASTER and CLONE
+	Replication string `config:"replication" yaml:"replication"`
+}
+
+// ManagementAddress returns address mysync uses to connect to MySQL on host
+func (cfg *Config) ManagementAddress(host string) string {
+	if addr := cfg.HostAddresses[host].Management; addr != "" {
+		return addr
+	}
+	return host
+}
+
+// ReplicationAddress returns address replicas use to connect to MySQL on host
+func (cfg *Config) ReplicationAddress(host string) string {
+	if addr := cfg.HostAddresses[host].Replication; addr != "" {
+		return addr
+	}
+	return host
+}
+
+// HostByReplicationAddress returns host name, which replication address is addr, or addr itself if it is unknown
+func (cfg *Config) HostByReplicationAddress(addr string) string {
+	ip := net.ParseIP(addr)
+	for host, addrs := range cfg.HostAddresses {
+		if addrs.Replication == addr || (ip != nil && ip.Equal(net.ParseIP(addrs.Replication))) {
+			return host
+		}
+	}
+	return addr
+}
+
+// validateAddress checks that address is host name or IP address without port and brackets
+func validateAddress(addr string) error {
+	if addr == "" || net.ParseIP(addr) != nil {
+		return nil
+	}
+	if strings.ContainsAny(addr, ":[]/ ") {
+		return fmt.Errorf("%q should be host name or IP address without port", addr)
+	}
+	return nil
+}
+
+func (cfg *Config) validateHostAddresses() error {
+	replication := make(map[string]string)
+	for host, addrs := range cfg.HostAddresses {
+		if err := validateAddress(addrs.Management); err != nil {
+			return fmt.Errorf("host_addresses.%s.management: %v", host, err)
+		}
+		if err := validateAddress(addrs.Replication); err != nil {
+			return fmt.Errorf("host_addresses.%s.replication: %v", host, err)
+		}
+		if addrs.Replication == "" {
+			continue
+		}
+		if other, ok := replication[addrs.Replication]; ok {
+			return fmt.Errorf("host_addresses: %s and %s have the same replication address %s", other, host, addrs.Replication)
+		}
+		replication[addrs.Replication] = host
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostAddresses(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	cfg.HostAddresses = map[string]HostAddressConfig{
+		"mysql1": {Management: "10.0.0.1", Replication: "fd00::1"},
+		"mysql2": {Replication: "mysql2.repl.net"},
+	}
+	require.Equal(t, "10.0.0.1", cfg.ManagementAddress("mysql1"))
+	require.Equal(t, "mysql2", cfg.ManagementAddress("mysql2"))
+	require.Equal(t, "mysql3", cfg.ManagementAddress("mysql3"))
+	require.Equal(t, "fd00::1", cfg.ReplicationAddress("mysql1"))
+	require.Equal(t, "mysql3", cfg.ReplicationAddress("mysql3"))
+
+	require.Equal(t, "mysql1", cfg.HostByReplicationAddress("fd00:0:0:0:0:0:0:1"))
+	require.Equal(t, "mysql2", cfg.HostByReplicationAddress("mysql2.repl.net"))
+	require.Equal(t, "mysql3", cfg.HostByReplicationAddress("mysql3"))
+	require.NoError(t, cfg.validateHostAddresses())
+
+	cfg.HostAddresses["mysql3"] = HostAddressConfig{Replication: "[fd00::3]:3306"}
+	require.Error(t, cfg.validateHostAddresses())
+	cfg.HostAddresses["mysql3"] = HostAddressConfig{Management: "mysql3:3306"}
+	require.Error(t, cfg.validateHostAddresses())
+	cfg.HostAddresses["mysql3"] = HostAddressConfig{Replication: "mysql2.repl.net"}
+	require.Error(t, cfg.validateHostAddresses())
+}
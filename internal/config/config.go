@@ -3,7 +3,9 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
+	"path"
 	"time"
 
 	"github.com/heetch/confita"
@@ -11,12 +13,14 @@ import (
 
 	"github.com/yandex/mysync/internal/dcs"
 	"github.com/yandex/mysync/internal/util"
+	"github.com/yandex/mysync/internal/vault"
 )
 
 // MySQLConfig contains MySQL cluster connection info
 type MySQLConfig struct {
 	User                       string `config:"user,required"`
 	Password                   string `config:"password,required"`
+	PreviousPassword           string `config:"previous_password" yaml:"previous_password"` // accepted during password rotation
 	Port                       int    `config:"port" yaml:"port"`
 	SslCA                      string `config:"ssl_ca" yaml:"ssl_ca"`
 	ReplicationUser            string `config:"replication_user,required" yaml:"replication_user"`
@@ -32,14 +36,321 @@ type MySQLConfig struct {
 	ErrorLog                   string `config:"error_log" yaml:"error_log"`
 }
 
+// ZonePolicyConfig contains rules for zone-aware choice of the new master
+type ZonePolicyConfig struct {
+	PreferSameZone bool     `config:"prefer_same_zone" yaml:"prefer_same_zone"`
+	ForbiddenZones []string `config:"forbidden_zones" yaml:"forbidden_zones"`
+	// candidate should be seen alive by agents from at least this number of zones, 0 means no check
+	MinReachableZones int `config:"min_reachable_zones" yaml:"min_reachable_zones"`
+}
+
+// DonorPolicyConfig contains rules for choice of resetup donor, when it is not set explicitly
+type DonorPolicyConfig struct {
+	PreferSameZone bool `config:"prefer_same_zone" yaml:"prefer_same_zone"`
+	AllowMaster    bool `config:"allow_master" yaml:"allow_master"`
+	// hosts lagging or loaded more are not used as donors, 0 means no limit
+	MaxLag            time.Duration `config:"max_lag" yaml:"max_lag"`
+	MaxThreadsRunning int           `config:"max_threads_running" yaml:"max_threads_running"`
+	// how many resetups may copy data from one donor at once, 0 means no limit
+	MaxConcurrent int `config:"max_concurrent" yaml:"max_concurrent"`
+}
+
+// AdaptivePollingConfig bounds intervals of main loop and health checks, which are shortened
+// while cluster is unhealthy or operation is pending and prolonged when cluster is stable
+type AdaptivePollingConfig struct {
+	Enabled     bool          `config:"enabled" yaml:"enabled"`
+	MinInterval time.Duration `config:"min_interval" yaml:"min_interval"`
+	MaxInterval time.Duration `config:"max_interval" yaml:"max_interval"`
+	// how long cluster should be stable before polling slows down
+	StablePeriod time.Duration `config:"stable_period" yaml:"stable_period"`
+}
+
+// KubernetesConfig describes running as a sidecar container of MySQL StatefulSet pod
+type KubernetesConfig struct {
+	Enabled bool `config:"enabled" yaml:"enabled"`
+	// namespace, name and IP of own pod, taken from POD_NAMESPACE, POD_NAME and POD_IP if empty
+	Namespace string `config:"namespace" yaml:"namespace"`
+	PodName   string `config:"pod_name" yaml:"pod_name"`
+	PodIP     string `config:"pod_ip" yaml:"pod_ip"`
+	// headless service of StatefulSet, if set hostname becomes stable DNS name of the pod:
+	// <pod>.<service>.<namespace>.svc.<cluster_domain>, so pod IP changes do not affect host identity
+	Service       string `config:"service" yaml:"service"`
+	ClusterDomain string `config:"cluster_domain" yaml:"cluster_domain"`
+	// label of own pod set to "primary" or "replica"
+	RoleLabel string `config:"role_label" yaml:"role_label"`
+	// pod condition used in readinessGates of pod spec, it follows readiness of mysync
+	ReadinessGate string `config:"readiness_gate" yaml:"readiness_gate"`
+	// Endpoints object (of a selector-less Service) pointed to the primary pod
+	PrimaryEndpoints string        `config:"primary_endpoints" yaml:"primary_endpoints"`
+	APIServer        string        `config:"api_server" yaml:"api_server"`
+	TokenFile        string        `config:"token_file" yaml:"token_file"`
+	CAFile           string        `config:"ca_file" yaml:"ca_file"`
+	SyncInterval     time.Duration `config:"sync_interval" yaml:"sync_interval"`
+}
+
+// Roles of agent API tokens, each role is allowed to do everything lower roles do
+const (
+	// APIRoleViewer may only read cluster status
+	APIRoleViewer = "viewer"
+	// APIRoleOperator may also run switchovers and toggle maintenance
+	APIRoleOperator = "operator"
+	// APIRoleAdmin may also promote hosts forcibly and change cluster hosts
+	APIRoleAdmin = "admin"
+)
+
+// APITokenConfig is a named token granting access to agent API.
+// Clients presenting certificate signed by api_tls_ca_file are matched by CertCN instead of token
+type APITokenConfig struct {
+	Name  string `config:"name" yaml:"name"`
+	Token string `config:"token" yaml:"token"`
+	// glob pattern of client certificate common name, e.g. "*.db.example.net"
+	CertCN string `config:"cert_cn" yaml:"cert_cn"`
+	// one of viewer, operator or admin, tokens without role are admins
+	Role string `config:"role" yaml:"role"`
+}
+
+// HealthCheckConfig describes user-defined SQL health check
+type HealthCheckConfig struct {
+	Name     string        `config:"name" yaml:"name"`
+	Query    string        `config:"query" yaml:"query"`
+	Expected string        `config:"expected" yaml:"expected"`
+	Timeout  time.Duration `config:"timeout" yaml:"timeout"`
+	// Critical check failure marks host as dead, otherwise it is only reported
+	Critical bool `config:"critical" yaml:"critical"`
+}
+
+// Notifier types
+const (
+	NotifierWebhook   = "webhook"
+	NotifierSlack     = "slack"
+	NotifierPagerDuty = "pagerduty"
+)
+
+// NotifierConfig describes destination of cluster event notifications
+type NotifierConfig struct {
+	Name string `config:"name" yaml:"name"`
+	// one of webhook, slack, pagerduty
+	Type string `config:"type" yaml:"type"`
+	URL  string `config:"url" yaml:"url"`
+	// PagerDuty Events API v2 integration key
+	RoutingKey string `config:"routing_key" yaml:"routing_key"`
+	// event types to send, all events are sent if empty
+	Events  []string      `config:"events" yaml:"events"`
+	Timeout time.Duration `config:"timeout" yaml:"timeout"`
+}
+
+// Topology publisher types
+const (
+	TopologyPublisherEtcd      = "etcd"
+	TopologyPublisherConsul    = "consul"
+	TopologyPublisherConfigMap = "configmap"
+	TopologyPublisherFile      = "file"
+)
+
+// TopologyPublisherConfig describes application-facing store, which receives current master and replicas
+// on every topology change
+type TopologyPublisherConfig struct {
+	Name string `config:"name" yaml:"name"`
+	// one of etcd, consul, configmap, file
+	Type string `config:"type" yaml:"type"`
+	// etcd v3 JSON gateway or Consul API url, kubernetes API is configured in kubernetes section
+	Endpoint string `config:"endpoint" yaml:"endpoint"`
+	// etcd or Consul KV key, ConfigMap name or file path
+	Key string `config:"key" yaml:"key"`
+	// Consul ACL token
+	Token string `config:"token" yaml:"token"`
+	// text/template of file content with .Master, .Replicas and .Epoch, JSON if empty
+	Template string `config:"template" yaml:"template"`
+	// shell command run after file is changed, e.g. to reload application
+	ReloadCommand string        `config:"reload_command" yaml:"reload_command"`
+	Timeout       time.Duration `config:"timeout" yaml:"timeout"`
+}
+
+// DNS providers
+const (
+	DNSProviderRoute53  = "route53"
+	DNSProviderEtcd     = "etcd"
+	DNSProviderPowerDNS = "powerdns"
+	DNSProviderRFC2136  = "rfc2136"
+)
+
+// DNSConfig describes writer DNS record pointed to the new master on promotion
+type DNSConfig struct {
+	// one of route53, etcd (CoreDNS etcd plugin), powerdns, rfc2136, empty disables updates
+	Provider string `config:"provider" yaml:"provider"`
+	// FQDN of writer record
+	Record string `config:"record" yaml:"record"`
+	// CNAME to master hostname, or A/AAAA with its addresses
+	RecordType string `config:"record_type" yaml:"record_type"`
+	TTL        int    `config:"ttl" yaml:"ttl"`
+	// Route53 API, etcd v3 JSON gateway, PowerDNS API url or host:port of rfc2136 primary server
+	Endpoint string `config:"endpoint" yaml:"endpoint"`
+	// Route53 hosted zone id or zone name for powerdns and rfc2136
+	Zone string `config:"zone" yaml:"zone"`
+	// Route53 access key id or TSIG key name
+	Key string `config:"key" yaml:"key"`
+	// Route53 secret access key, PowerDNS API key or TSIG secret
+	Secret        string `config:"secret" yaml:"secret"`
+	Region        string `config:"region" yaml:"region"`
+	TSIGAlgorithm string `config:"tsig_algorithm" yaml:"tsig_algorithm"`
+	// key prefix of CoreDNS etcd plugin
+	EtcdPrefix string `config:"etcd_prefix" yaml:"etcd_prefix"`
+	// host:port of DNS server queried until record is propagated, empty disables the check
+	CheckServer        string        `config:"check_server" yaml:"check_server"`
+	PropagationTimeout time.Duration `config:"propagation_timeout" yaml:"propagation_timeout"`
+	Timeout            time.Duration `config:"timeout" yaml:"timeout"`
+}
+
+// VIPConfig describes floating IP moved to the current master.
+// Address is managed by vip_add, vip_del and vip_check commands, which may be overridden to use cloud API
+type VIPConfig struct {
+	// address with prefix length, e.g. 10.0.0.10/24, empty disables VIP management
+	Address        string        `config:"address" yaml:"address"`
+	Interface      string        `config:"interface" yaml:"interface"`
+	CheckInterval  time.Duration `config:"check_interval" yaml:"check_interval"`
+	CommandTimeout time.Duration `config:"command_timeout" yaml:"command_timeout"`
+}
+
+// ReadOnlyProbeConfig describes canary write, which verifies that read-only host really rejects writes.
+// Probe user should have only INSERT privilege on the probe table, which is created by mysync on master.
+// Probe write is always rolled back.
+type ReadOnlyProbeConfig struct {
+	// empty user disables probe
+	User     string `config:"user" yaml:"user"`
+	Password string `config:"password" yaml:"password"`
+	// port to connect, e.g. of proxy in front of MySQL, mysql port if 0
+	Port     int           `config:"port" yaml:"port"`
+	Schema   string        `config:"schema" yaml:"schema"`
+	Table    string        `config:"table" yaml:"table"`
+	Interval time.Duration `config:"interval" yaml:"interval"`
+	Timeout  time.Duration `config:"timeout" yaml:"timeout"`
+}
+
+// LoadSheddingConfig describes actions applied on master while its writes are at risk of loss:
+// semi-sync durability is degraded or all replicas lag behind. Actions are reverted after replication recovers.
+type LoadSheddingConfig struct {
+	// shed load when no active replica lags less, 0 - lag is not considered
+	Lag time.Duration `config:"lag" yaml:"lag"`
+	// shed load when master waits for fewer semi-sync replicas than rpl_semi_sync_master_wait_for_slave_count
+	SemiSync bool `config:"semi_sync" yaml:"semi_sync"`
+	// accounts limited to max_user_connections, e.g. app@% or batch@10.0.%, host is % if omitted
+	Users              []string `config:"users" yaml:"users"`
+	MaxUserConnections int      `config:"max_user_connections" yaml:"max_user_connections"`
+	// raise throttle flag served at /throttle, which applications watch
+	Throttle bool `config:"throttle" yaml:"throttle"`
+	// replication should stay healthy that long before actions are reverted
+	RecoverAfter time.Duration `config:"recover_after" yaml:"recover_after"`
+}
+
+// RecoveryMarksConfig describes cluster-wide GTID positions recorded in dcs for point-in-time recovery.
+// Backup tooling associates backups with marks, hosts restored from them are validated against marks before rejoin.
+type RecoveryMarksConfig struct {
+	// cron expression of marks recorded by manager, empty - marks are recorded by `mysync recovery mark` only
+	Schedule string `config:"schedule" yaml:"schedule"`
+	// how many latest marks are kept. With validate_rejoin host on recovery, which has not reached
+	// the oldest kept mark (e.g. restored from older backup), is sent to resetup, so keep should cover backup retention
+	Keep int `config:"keep" yaml:"keep"`
+	// host on recovery rejoins only if it has reached one of recorded marks, otherwise resetup file is written
+	ValidateRejoin bool `config:"validate_rejoin" yaml:"validate_rejoin"`
+}
+
+// Service catalogs
+const (
+	DiscoveryConsul = "consul"
+	DiscoveryEtcd   = "etcd"
+)
+
+// DiscoveryConfig describes registration of host role in service catalog
+type DiscoveryConfig struct {
+	// consul or etcd, empty disables registration
+	Type string `config:"type" yaml:"type"`
+	// Consul agent or etcd v3 JSON gateway url
+	Endpoint string `config:"endpoint" yaml:"endpoint"`
+	Service  string `config:"service" yaml:"service"`
+	// address registered for the host, hostname is used if empty
+	Address string `config:"address" yaml:"address"`
+	// Consul ACL token
+	Token      string `config:"token" yaml:"token"`
+	EtcdPrefix string `config:"etcd_prefix" yaml:"etcd_prefix"`
+	// registration is refreshed every interval and expires after ttl without refresh
+	Interval time.Duration `config:"interval" yaml:"interval"`
+	TTL      time.Duration `config:"ttl" yaml:"ttl"`
+	Timeout  time.Duration `config:"timeout" yaml:"timeout"`
+}
+
+// ResetupCompressionZstd compresses data stream of resetup with zstd
+const ResetupCompressionZstd = "zstd"
+
+// Replication lag calculators
+const (
+	// LagCalculatorSecondsBehindMaster takes Seconds_Behind_Master of replica status
+	LagCalculatorSecondsBehindMaster = "seconds_behind_master"
+	// LagCalculatorHeartbeat compares timestamp in repl_mon table with current time
+	LagCalculatorHeartbeat = "heartbeat"
+	// LagCalculatorApplier uses original commit timestamps of transactions being applied
+	LagCalculatorApplier = "applier"
+	// LagCalculatorQuery runs replication_lag query
+	LagCalculatorQuery = "query"
+)
+
+// Policies of combining lags reported by several calculators
+const (
+	LagPolicyMax    = "max"
+	LagPolicyMedian = "median"
+)
+
+// Manager anti-affinity policies, keeping manager lock off the master host,
+// so failure of master host does not take manager with it
+const (
+	ManagerAntiAffinityOff = "off"
+	// ManagerAntiAffinityPrefer passes the lock to another agent, master agent takes it only if nobody else can
+	ManagerAntiAffinityPrefer = "prefer"
+	// ManagerAntiAffinityRequire never lets master agent be manager, unless cluster has single node
+	ManagerAntiAffinityRequire = "require"
+)
+
+// Mitigations of master blocked waiting for semi sync ACKs
+const (
+	// SemiSyncStallReport only reports stall
+	SemiSyncStallReport = "report"
+	// SemiSyncStallRelax lowers wait_for_slave_count to connected replicas, until stall is over
+	SemiSyncStallRelax = "relax"
+	// SemiSyncStallFailover switches over to healthy replica
+	SemiSyncStallFailover = "failover"
+)
+
+// Strategies of choosing new master, when it is not given explicitly
+const (
+	// PromotionStrategyPriority promotes host of the highest priority, letting it catch up from the most advanced host
+	PromotionStrategyPriority = "priority"
+	// PromotionStrategyMostAdvanced promotes the most advanced host immediately, priority only breaks ties
+	PromotionStrategyMostAdvanced = "most_advanced"
+)
+
+// Failure detectors of master
+const (
+	// FailureDetectorFixed declares master dead after it is seen failed for failover_delay
+	FailureDetectorFixed = "fixed"
+	// FailureDetectorPhiAccrual declares master dead when suspicion level, computed from history
+	// of its heartbeat intervals, reaches phi_threshold, but not before failover_delay
+	FailureDetectorPhiAccrual = "phi_accrual"
+)
+
 // Config contains all mysync configuration
 type Config struct {
 	DevMode                                 bool                         `config:"dev_mode" yaml:"dev_mode"`
+	ObserveOnly                             bool                         `config:"observe_only" yaml:"observe_only"` // make decisions, but never change MySQL and topology in dcs
 	SemiSync                                bool                         `config:"semi_sync" yaml:"semi_sync"`
 	SemiSyncEnableLag                       int64                        `config:"semi_sync_enable_lag" yaml:"semi_sync_enable_lag"`
+	SemiSyncStallTimeout                    time.Duration                `config:"semi_sync_stall_timeout" yaml:"semi_sync_stall_timeout"` // 0 - stall is not detected
+	SemiSyncStallMitigation                 string                       `config:"semi_sync_stall_mitigation" yaml:"semi_sync_stall_mitigation"`
 	Failover                                bool                         `config:"failover" yaml:"failover"`
 	FailoverCooldown                        time.Duration                `config:"failover_cooldown" yaml:"failover_cooldown"`
 	FailoverDelay                           time.Duration                `config:"failover_delay" yaml:"failover_delay"`
+	FailureDetector                         string                       `config:"failure_detector" yaml:"failure_detector"`
+	PhiThreshold                            float64                      `config:"phi_threshold" yaml:"phi_threshold"`
+	PhiMinStdDeviation                      time.Duration                `config:"phi_min_std_deviation" yaml:"phi_min_std_deviation"`
+	PhiWindowSize                           int                          `config:"phi_window_size" yaml:"phi_window_size"` // number of heartbeat intervals kept
 	InactivationDelay                       time.Duration                `config:"inactivation_delay" yaml:"inactivation_delay"`
 	CriticalDiskUsage                       float64                      `config:"critical_disk_usage" yaml:"critical_disk_usage"`
 	NotCriticalDiskUsage                    float64                      `config:"not_critical_disk_usage" yaml:"not_critical_disk_usage"`
@@ -57,10 +368,17 @@ type Config struct {
 	Zookeeper                               dcs.ZookeeperConfig          `config:"zookeeper"`
 	DcsWaitTimeout                          time.Duration                `config:"dcs_wait_timeout" yaml:"dcs_wait_timeout"`
 	DBTimeout                               time.Duration                `config:"db_timeout" yaml:"db_timeout"`
+	HostStatusTimeout                       time.Duration                `config:"host_status_timeout" yaml:"host_status_timeout"`
+	HostStatusConcurrency                   int                          `config:"host_status_concurrency" yaml:"host_status_concurrency"`
 	DBLostCheckTimeout                      time.Duration                `config:"db_lost_check_timeout" yaml:"db_lost_check_timeout"`
 	DBSetRoTimeout                          time.Duration                `config:"db_set_ro_timeout" yaml:"db_set_ro_timeout"`
 	DBSetRoForceTimeout                     time.Duration                `config:"db_set_ro_force_timeout" yaml:"db_set_ro_force_timeout"`
 	DBStopSlaveSQLThreadTimeout             time.Duration                `config:"db_stop_slave_sql_thread_timeout" yaml:"db_stop_slave_sql_thread_timeout"`
+	ReadQueryTimeout                        time.Duration                `config:"read_query_timeout" yaml:"read_query_timeout"` // timeout of status reads, db_timeout if 0
+	ReadQueryRetries                        int                          `config:"read_query_retries" yaml:"read_query_retries"` // extra attempts of status reads after network errors and timeouts
+	ReadQueryRetryInterval                  time.Duration                `config:"read_query_retry_interval" yaml:"read_query_retry_interval"`
+	WriteQueryTimeout                       time.Duration                `config:"write_query_timeout" yaml:"write_query_timeout"` // timeout of topology-changing statements, db_timeout if 0; they are never retried
+	QueryTimeouts                           map[string]time.Duration     `config:"query_timeouts" yaml:"query_timeouts"`           // per query name, overrides timeout of query class
 	TickInterval                            time.Duration                `config:"tick_interval" yaml:"tick_interval"`
 	HealthCheckInterval                     time.Duration                `config:"healthcheck_interval" yaml:"healthcheck_interval"`
 	InfoFileHandlerInterval                 time.Duration                `config:"info_file_handler_interval" yaml:"info_file_handler_interval"`
@@ -68,28 +386,55 @@ type Config struct {
 	ExternalCAFileCheckInterval             time.Duration                `config:"external_ca_file_check_interval" yaml:"external_ca_file_check_interval"`
 	ManagerElectionDelayAfterQuorumLoss     time.Duration                `config:"manager_election_delay_after_quorum_loss" yaml:"manager_election_delay_after_quorum_loss"`
 	ManagerLockAcquireDelayAfterQuorumLoss  time.Duration                `config:"manager_lock_acquire_delay_after_quorum_loss" yaml:"manager_lock_acquire_delay_after_quorum_loss"`
+	ManagerHandoffTimeout                   time.Duration                `config:"manager_handoff_timeout" yaml:"manager_handoff_timeout"`
+	QuarantineTimeout                       time.Duration                `config:"quarantine_timeout" yaml:"quarantine_timeout"` // default duration of `mysync host quarantine`
+	ManagerAntiAffinity                     string                       `config:"manager_anti_affinity" yaml:"manager_anti_affinity"`
+	ClockSkewThreshold                      time.Duration                `config:"clock_skew_threshold" yaml:"clock_skew_threshold"` // 0 - clocks are not checked
 	MaxAcceptableLag                        float64                      `config:"max_acceptable_lag" yaml:"max_acceptable_lag"`
 	SlaveCatchUpTimeout                     time.Duration                `config:"slave_catch_up_timeout" yaml:"slave_catch_up_timeout"`
+	SlaveCatchUpTimeoutMax                  time.Duration                `config:"slave_catch_up_timeout_max" yaml:"slave_catch_up_timeout_max"` // 0 - catch up timeout is not extended by apply backlog
+	MaxApplyBacklog                         int64                        `config:"max_apply_backlog" yaml:"max_apply_backlog"`                   // 0 - apply backlog does not affect candidate selection
+	ThrottleLag                             time.Duration                `config:"throttle_lag" yaml:"throttle_lag"`                             // 0 - throttle flag is not published
 	DisableSemiSyncReplicationOnMaintenance bool                         `config:"disable_semi_sync_replication_on_maintenance" yaml:"disable_semi_sync_replication_on_maintenance"`
 	KeepSuperWritableOnCriticalDiskUsage    bool                         `config:"keep_super_writable_on_critical_disk_usage" yaml:"keep_super_writable_on_critical_disk_usage"`
 	ExcludeUsers                            []string                     `config:"exclude_users" yaml:"exclude_users"`
 	OfflineModeEnableInterval               time.Duration                `config:"offline_mode_enable_interval" yaml:"offline_mode_enable_interval"`
 	OfflineModeEnableLag                    time.Duration                `config:"offline_mode_enable_lag" yaml:"offline_mode_enable_lag"`
 	OfflineModeDisableLag                   time.Duration                `config:"offline_mode_disable_lag" yaml:"offline_mode_disable_lag"`
+	OfflineModeIgnoreMasterReadOnly         bool                         `config:"offline_mode_ignore_master_read_only" yaml:"offline_mode_ignore_master_read_only"`
+	CascadeOfflineModeEnableLag             time.Duration                `config:"cascade_offline_mode_enable_lag" yaml:"cascade_offline_mode_enable_lag"`
+	CascadeOfflineModeDisableLag            time.Duration                `config:"cascade_offline_mode_disable_lag" yaml:"cascade_offline_mode_disable_lag"`
+	OfflineModeEnableThreadsRunning         int                          `config:"offline_mode_enable_threads_running" yaml:"offline_mode_enable_threads_running"` // 0 - load is not considered
+	OfflineModeDisableThreadsRunning        int                          `config:"offline_mode_disable_threads_running" yaml:"offline_mode_disable_threads_running"`
+	OfflineModeEnableCPULoad                float64                      `config:"offline_mode_enable_cpu_load" yaml:"offline_mode_enable_cpu_load"` // load average per CPU, 0 - not considered
+	OfflineModeDisableCPULoad               float64                      `config:"offline_mode_disable_cpu_load" yaml:"offline_mode_disable_cpu_load"`
+	OfflineModeLoadHoldTime                 time.Duration                `config:"offline_mode_load_hold_time" yaml:"offline_mode_load_hold_time"`           // minimal time replica overloaded stays offline
+	OfflineModeMinOnlineReplicas            int                          `config:"offline_mode_min_online_replicas" yaml:"offline_mode_min_online_replicas"` // load never takes more replicas offline
 	DisableSetReadonlyOnLost                bool                         `config:"disable_set_readonly_on_lost" yaml:"disable_set_readonly_on_lost"`
 	ResetupCrashedHosts                     bool                         `config:"resetup_crashed_hosts" yaml:"resetup_crashed_hosts"`
 	StreamFromReasonableLag                 time.Duration                `config:"stream_from_reasonable_lag" yaml:"stream_from_reasonable_lag"`
 	PriorityChoiceMaxLag                    time.Duration                `config:"priority_choice_max_lag" yaml:"priority_choice_max_lag"`
+	PromotionStrategy                       string                       `config:"promotion_strategy" yaml:"promotion_strategy"`
+	PriorityCatchUpTimeout                  time.Duration                `config:"priority_catch_up_timeout" yaml:"priority_catch_up_timeout"` // 0 - wait up to slave_catch_up_timeout, then fail
 	TestDiskUsageFile                       string                       `config:"test_disk_usage_file" yaml:"test_disk_usage_file"`
 	RplSemiSyncMasterWaitForSlaveCount      int                          `config:"rpl_semi_sync_master_wait_for_slave_count" yaml:"rpl_semi_sync_master_wait_for_slave_count"`
 	WaitReplicationStartTimeout             time.Duration                `config:"wait_start_replication_timeout" yaml:"wait_start_replication_timeout"`
 	ReplicationRepairAggressiveMode         bool                         `config:"replication_repair_aggressive_mode" yaml:"replication_repair_aggressive_mode"`
 	ReplicationRepairCooldown               time.Duration                `config:"replication_repair_cooldown" yaml:"replication_repair_cooldown"`
 	ReplicationRepairMaxAttempts            int                          `config:"replication_repair_max_attempts" yaml:"replication_repair_max_attempts"`
+	RepairBudgetAttempts                    int                          `config:"repair_budget_attempts" yaml:"repair_budget_attempts"` // max attempts of repair action per host within repair_budget_window, 0 means unlimited
+	RepairBudgetWindow                      time.Duration                `config:"repair_budget_window" yaml:"repair_budget_window"`
+	RepairCooldown                          time.Duration                `config:"repair_cooldown" yaml:"repair_cooldown"` // doubled after each consecutive attempt up to repair_cooldown_max
+	RepairCooldownMax                       time.Duration                `config:"repair_cooldown_max" yaml:"repair_cooldown_max"`
+	EnforceSettings                         bool                         `config:"enforce_settings" yaml:"enforce_settings"` // revert super_read_only drift on replicas and audit every reverted out-of-band change
+	LagHistoryWindow                        time.Duration                `config:"lag_history_window" yaml:"lag_history_window"`
+	LagQuantile                             float64                      `config:"lag_quantile" yaml:"lag_quantile"` // offline mode and candidates use this quantile of lag over lag_history_window, 0 means current lag
 	TestFilesystemReadonlyFile              string                       `config:"test_filesystem_readonly_file" yaml:"test_filesystem_readonly_file"`
 	ReplicationChannel                      string                       `config:"replication_channel" yaml:"replication_channel"`
 	ExternalReplicationChannel              string                       `config:"external_replication_channel" yaml:"external_replication_channel"`
 	ExternalReplicationType                 util.ExternalReplicationType `config:"external_replication_type" yaml:"external_replication_type"`
+	ExternalReplicationSources              []string                     `config:"external_replication_sources" yaml:"external_replication_sources"` // upstream hosts to repoint external channel to, when its source is lost
+	ExternalReplicationFailoverTimeout      time.Duration                `config:"external_replication_failover_timeout" yaml:"external_replication_failover_timeout"`
 	ASync                                   bool                         `config:"async" yaml:"async"`
 	AsyncAllowedLag                         time.Duration                `config:"async_allowed_lag" yaml:"async_allowed_lag"`
 	ReplMon                                 bool                         `config:"repl_mon" yaml:"repl_mon"`
@@ -98,9 +443,96 @@ type Config struct {
 	ReplMonWriteInterval                    time.Duration                `config:"repl_mon_write_interval" yaml:"repl_mon_write_interval"`
 	ReplMonErrorWaitInterval                time.Duration                `config:"repl_mon_error_wait_interval" yaml:"repl_mon_error_wait_interval"`
 	ReplMonSlaveWaitInterval                time.Duration                `config:"repl_mon_slave_wait_interval" yaml:"repl_mon_slave_wait_interval"`
+	ReplicationLagCalculators               []string                     `config:"replication_lag_calculators" yaml:"replication_lag_calculators"` // if empty, replication_lag query or seconds_behind_master
+	ReplicationLagPolicy                    string                       `config:"replication_lag_policy" yaml:"replication_lag_policy"`
 	ShowOnlyGTIDDiff                        bool                         `config:"show_only_gtid_diff" yaml:"show_only_gtid_diff"`
+	SQLLog                                  bool                         `config:"sql_log" yaml:"sql_log"` // log every query with its latency at info level
 	ManagerSwitchover                       bool                         `config:"manager_switchover" yaml:"manager_switchover"`
 	ForceSwitchover                         bool                         `config:"force_switchover" yaml:"force_switchover"` // TODO: Remove when we will be sure it's right way to do switchover
+	Zone                                    string                       `config:"zone" yaml:"zone"`
+	ZonePolicy                              ZonePolicyConfig             `config:"zone_policy" yaml:"zone_policy"`
+	AdaptivePolling                         AdaptivePollingConfig        `config:"adaptive_polling" yaml:"adaptive_polling"`
+	Kubernetes                              KubernetesConfig             `config:"kubernetes" yaml:"kubernetes"`
+	DNS                                     DNSConfig                    `config:"dns" yaml:"dns"`
+	VIP                                     VIPConfig                    `config:"vip" yaml:"vip"`
+	ReadOnlyProbe                           ReadOnlyProbeConfig          `config:"read_only_probe" yaml:"read_only_probe"`
+	LoadShedding                            LoadSheddingConfig           `config:"load_shedding" yaml:"load_shedding"`
+	RecoveryMarks                           RecoveryMarksConfig          `config:"recovery_marks" yaml:"recovery_marks"`
+	Discovery                               DiscoveryConfig              `config:"discovery" yaml:"discovery"`
+	LivenessQuorum                          int                          `config:"liveness_quorum" yaml:"liveness_quorum"`
+	LivenessCheckInterval                   time.Duration                `config:"liveness_check_interval" yaml:"liveness_check_interval"`
+	SwitchoverDrainTimeout                  time.Duration                `config:"switchover_drain_timeout" yaml:"switchover_drain_timeout"`
+	SwitchoverDrainKill                     bool                         `config:"switchover_drain_kill" yaml:"switchover_drain_kill"`
+	SwitchoverRequireSameProtocol           bool                         `config:"switchover_require_same_protocol" yaml:"switchover_require_same_protocol"`
+	SwitchoverTimeout                       time.Duration                `config:"switchover_timeout" yaml:"switchover_timeout"`
+	PromotionWarmupTimeout                  time.Duration                `config:"promotion_warmup_timeout" yaml:"promotion_warmup_timeout"` // time budget of warm-up, 0 - no warm-up
+	PromotionWarmupBufferPool               bool                         `config:"promotion_warmup_buffer_pool" yaml:"promotion_warmup_buffer_pool"`
+	PromotionWarmupQueries                  []string                     `config:"promotion_warmup_queries" yaml:"promotion_warmup_queries"`
+	PromotionWarmupMaxConnections           int                          `config:"promotion_warmup_max_connections" yaml:"promotion_warmup_max_connections"` // raise max_connections up to, 0 - keep
+	AutoResetup                             bool                         `config:"auto_resetup" yaml:"auto_resetup"`
+	AutoResetupDelay                        time.Duration                `config:"auto_resetup_delay" yaml:"auto_resetup_delay"`
+	AutoResetupConcurrency                  int                          `config:"auto_resetup_concurrency" yaml:"auto_resetup_concurrency"`
+	ResetupTimeout                          time.Duration                `config:"resetup_timeout" yaml:"resetup_timeout"`
+	ResetupProgressInterval                 time.Duration                `config:"resetup_progress_interval" yaml:"resetup_progress_interval"`
+	ResetupCloneRestartTimeout              time.Duration                `config:"resetup_clone_restart_timeout" yaml:"resetup_clone_restart_timeout"` // wait for mysqld restarted by CLONE INSTANCE
+	ResetupProgressFile                     string                       `config:"resetup_progress_file" yaml:"resetup_progress_file"`                 // written by resetup command as JSON
+	ResetupBandwidth                        int64                        `config:"resetup_bandwidth" yaml:"resetup_bandwidth"`                         // bytes per second of single resetup, 0 means unlimited
+	ResetupClusterBandwidth                 int64                        `config:"resetup_cluster_bandwidth" yaml:"resetup_cluster_bandwidth"`         // shared by concurrent resetups in cluster
+	ResetupCompression                      string                       `config:"resetup_compression" yaml:"resetup_compression"`                     // "zstd" or empty
+	ResetupDonorPolicy                      DonorPolicyConfig            `config:"resetup_donor_policy" yaml:"resetup_donor_policy"`
+	APIListen                               string                       `config:"api_listen" yaml:"api_listen"`
+	GRPCListen                              string                       `config:"grpc_listen" yaml:"grpc_listen"`
+	APITokens                               []APITokenConfig             `config:"api_tokens" yaml:"api_tokens"`
+	APITLSCertFile                          string                       `config:"api_tls_cert_file" yaml:"api_tls_cert_file"`
+	APITLSKeyFile                           string                       `config:"api_tls_key_file" yaml:"api_tls_key_file"`
+	APITLSCAFile                            string                       `config:"api_tls_ca_file" yaml:"api_tls_ca_file"`
+	APITLSRequireClientCert                 bool                         `config:"api_tls_require_client_cert" yaml:"api_tls_require_client_cert"`
+	HealthChecks                            []HealthCheckConfig          `config:"health_checks" yaml:"health_checks"`
+	Notifiers                               []NotifierConfig             `config:"notifiers" yaml:"notifiers"`
+	TopologyPublishers                      []TopologyPublisherConfig    `config:"topology_publishers" yaml:"topology_publishers"`
+	FailoverWindows                         []FailoverWindowConfig       `config:"failover_windows" yaml:"failover_windows"`
+	NotifyRetries                           int                          `config:"notify_retries" yaml:"notify_retries"`
+	NotifyRetryBackoff                      time.Duration                `config:"notify_retry_backoff" yaml:"notify_retry_backoff"`
+	StatsdAddress                           string                       `config:"statsd_address" yaml:"statsd_address"`
+	StatsdPrefix                            string                       `config:"statsd_prefix" yaml:"statsd_prefix"`
+	StatsdTags                              []string                     `config:"statsd_tags" yaml:"statsd_tags"`
+	StatsdDogStatsD                         bool                         `config:"statsd_dogstatsd" yaml:"statsd_dogstatsd"`
+	OTLPEndpoint                            string                       `config:"otlp_endpoint" yaml:"otlp_endpoint"`
+	OTLPInsecure                            bool                         `config:"otlp_insecure" yaml:"otlp_insecure"`
+	DebugListen                             string                       `config:"debug_listen" yaml:"debug_listen"`
+	HealthListen                            string                       `config:"health_listen" yaml:"health_listen"`
+	HealthStaleTimeout                      time.Duration                `config:"health_stale_timeout" yaml:"health_stale_timeout"`
+	DiskExtraPaths                          []string                     `config:"disk_extra_paths" yaml:"disk_extra_paths"`
+	DiskExhaustionHorizon                   time.Duration                `config:"disk_exhaustion_horizon" yaml:"disk_exhaustion_horizon"`
+	SwitchoverOnDiskExhaustion              bool                         `config:"switchover_on_disk_exhaustion" yaml:"switchover_on_disk_exhaustion"`
+	StorageHealthFile                       string                       `config:"storage_health_file" yaml:"storage_health_file"`
+	SwitchoverOnStorageDegradation          bool                         `config:"switchover_on_storage_degradation" yaml:"switchover_on_storage_degradation"`
+	BackupAwareSwitchover                   bool                         `config:"backup_aware_switchover" yaml:"backup_aware_switchover"`
+	OnlineDDLAwareSwitchover                bool                         `config:"online_ddl_aware_switchover" yaml:"online_ddl_aware_switchover"`
+	VersionAwareSwitchover                  bool                         `config:"version_aware_switchover" yaml:"version_aware_switchover"` // never promote host, which can't replicate to the rest of cluster
+	EventHistorySize                        int                          `config:"event_history_size" yaml:"event_history_size"`
+	PolicyFile                              string                       `config:"policy_file" yaml:"policy_file"`
+	PolicyTimeout                           time.Duration                `config:"policy_timeout" yaml:"policy_timeout"`
+	LocalStateFile                          string                       `config:"local_state_file" yaml:"local_state_file"` // last known cluster view, checked on restart; empty - not saved
+	Vault                                   VaultConfig                  `config:"vault" yaml:"vault"`
+	// key for values encrypted with `mysync config encrypt`, either file or command printing it (e.g. KMS client)
+	SecretsKeyFile    string `config:"secrets_key_file" yaml:"secrets_key_file"`
+	SecretsKeyCommand string `config:"secrets_key_command" yaml:"secrets_key_command"`
+	// config fragments merged on top of this file, e.g. /etc/mysync.d/*.yaml
+	Include []string `config:"include" yaml:"include"`
+	// management and replication addresses of hosts: hostname -> addresses,
+	// agents in other network segments may override it in host_overrides
+	HostAddresses map[string]HostAddressConfig `config:"host_addresses" yaml:"host_addresses"`
+	// config keys overridden for specific hosts: hostname -> key -> value
+	HostOverrides map[string]map[string]interface{} `config:"host_overrides" yaml:"host_overrides"`
+	// labels of cluster, `mysync fleet --selector` chooses clusters by them, e.g. env: prod
+	Labels map[string]string `config:"labels" yaml:"labels"`
+	// leases of credentials issued by Vault database secret engine
+	MySQLCredentialsLease       *vault.Lease `config:"-" yaml:"-"`
+	ReplicationCredentialsLease *vault.Lease `config:"-" yaml:"-"`
+	ZookeeperCredentialsLease   *vault.Lease `config:"-" yaml:"-"`
+	// hash of cluster-wide settings of config file, agents report it to detect config skew
+	ClusterHash string `config:"-" yaml:"-"`
 }
 
 // DefaultConfig returns default configuration for MySync
@@ -115,6 +547,7 @@ func DefaultConfig() (Config, error) {
 	}
 	config := Config{
 		DevMode:           false,
+		ObserveOnly:       false,
 		SemiSync:          false,
 		SemiSyncEnableLag: 100 * 1024 * 1024, // 100Mb
 		Failover:          false,
@@ -146,39 +579,76 @@ func DefaultConfig() (Config, error) {
 		Zookeeper:                               zkConfig,
 		DcsWaitTimeout:                          10 * time.Second,
 		DBTimeout:                               5 * time.Second,
+		HostStatusTimeout:                       10 * time.Second,
+		HostStatusConcurrency:                   8,
 		DBLostCheckTimeout:                      5 * time.Second,
 		DBSetRoTimeout:                          30 * time.Second,
 		DBSetRoForceTimeout:                     30 * time.Second,
+		ReadQueryRetryInterval:                  100 * time.Millisecond,
+		QueryTimeouts:                           map[string]time.Duration{},
 		DisableSetReadonlyOnLost:                false,
 		ResetupCrashedHosts:                     false,
 		DBStopSlaveSQLThreadTimeout:             30 * time.Second,
 		TickInterval:                            5 * time.Second,
+		FailureDetector:                         FailureDetectorFixed,
+		PhiThreshold:                            8,
+		PhiMinStdDeviation:                      500 * time.Millisecond,
+		PhiWindowSize:                           100,
 		HealthCheckInterval:                     5 * time.Second,
 		InfoFileHandlerInterval:                 30 * time.Second,
 		RecoveryCheckInterval:                   5 * time.Second,
 		ExternalCAFileCheckInterval:             5 * time.Second,
 		ManagerElectionDelayAfterQuorumLoss:     30 * time.Second, // need more than 15 sec
 		ManagerLockAcquireDelayAfterQuorumLoss:  45 * time.Second,
+		ManagerHandoffTimeout:                   time.Minute,
+		QuarantineTimeout:                       4 * time.Hour,
+		ManagerAntiAffinity:                     ManagerAntiAffinityOff,
+		ClockSkewThreshold:                      0,
+		SemiSyncStallTimeout:                    0,
+		SemiSyncStallMitigation:                 SemiSyncStallReport,
 		MaxAcceptableLag:                        60.0,
 		SlaveCatchUpTimeout:                     30 * time.Minute,
+		SlaveCatchUpTimeoutMax:                  0,
+		MaxApplyBacklog:                         0,
+		ThrottleLag:                             0,
 		DisableSemiSyncReplicationOnMaintenance: true,
 		KeepSuperWritableOnCriticalDiskUsage:    false,
 		ExcludeUsers:                            []string{},
 		OfflineModeEnableInterval:               15 * time.Minute,
 		OfflineModeEnableLag:                    24 * time.Hour,
 		OfflineModeDisableLag:                   30 * time.Second,
+		OfflineModeIgnoreMasterReadOnly:         false,
+		CascadeOfflineModeEnableLag:             0,
+		CascadeOfflineModeDisableLag:            0,
+		OfflineModeEnableThreadsRunning:         0,
+		OfflineModeDisableThreadsRunning:        0,
+		OfflineModeEnableCPULoad:                0,
+		OfflineModeDisableCPULoad:               0,
+		OfflineModeLoadHoldTime:                 5 * time.Minute,
+		OfflineModeMinOnlineReplicas:            1,
 		StreamFromReasonableLag:                 5 * time.Minute,
 		PriorityChoiceMaxLag:                    60 * time.Second,
+		PromotionStrategy:                       PromotionStrategyPriority,
+		PriorityCatchUpTimeout:                  0,
 		TestDiskUsageFile:                       "", // fake disk usage, only for docker tests
 		RplSemiSyncMasterWaitForSlaveCount:      1,
 		WaitReplicationStartTimeout:             10 * time.Second,
 		ReplicationRepairAggressiveMode:         false,
 		ReplicationRepairCooldown:               1 * time.Minute,
 		ReplicationRepairMaxAttempts:            3,
+		RepairBudgetAttempts:                    5,
+		RepairBudgetWindow:                      10 * time.Minute,
+		RepairCooldown:                          5 * time.Second,
+		RepairCooldownMax:                       2 * time.Minute,
+		EnforceSettings:                         false,
+		LagHistoryWindow:                        5 * time.Minute,
+		LagQuantile:                             0,
 		TestFilesystemReadonlyFile:              "", // fake readonly status, only for docker tests
 		ReplicationChannel:                      "",
 		ExternalReplicationChannel:              "external",
 		ExternalReplicationType:                 util.Disabled,
+		ExternalReplicationSources:              nil,
+		ExternalReplicationFailoverTimeout:      30 * time.Second,
 		ASync:                                   false,
 		AsyncAllowedLag:                         0 * time.Second,
 		ReplMon:                                 false,
@@ -187,9 +657,119 @@ func DefaultConfig() (Config, error) {
 		ReplMonWriteInterval:                    1 * time.Second,
 		ReplMonErrorWaitInterval:                10 * time.Second,
 		ReplMonSlaveWaitInterval:                10 * time.Second,
+		ReplicationLagPolicy:                    LagPolicyMax,
 		ShowOnlyGTIDDiff:                        false,
+		SQLLog:                                  false,
 		ManagerSwitchover:                       false,
 		ForceSwitchover:                         false,
+		Zone:                                    "",
+		ZonePolicy: ZonePolicyConfig{
+			PreferSameZone:    false,
+			ForbiddenZones:    []string{},
+			MinReachableZones: 0,
+		},
+		AdaptivePolling: AdaptivePollingConfig{
+			Enabled:      false,
+			MinInterval:  500 * time.Millisecond,
+			MaxInterval:  15 * time.Second,
+			StablePeriod: 5 * time.Minute,
+		},
+		Kubernetes: KubernetesConfig{
+			Enabled:       false,
+			ClusterDomain: "cluster.local",
+			RoleLabel:     "mysync/role",
+			APIServer:     "https://kubernetes.default.svc",
+			TokenFile:     "/var/run/secrets/kubernetes.io/serviceaccount/token",
+			CAFile:        "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+			SyncInterval:  10 * time.Second,
+		},
+		DNS: DNSConfig{
+			RecordType:         "CNAME",
+			TTL:                30,
+			Region:             "us-east-1",
+			TSIGAlgorithm:      "hmac-sha256.",
+			EtcdPrefix:         "/skydns",
+			PropagationTimeout: 30 * time.Second,
+			Timeout:            10 * time.Second,
+		},
+		VIP: VIPConfig{
+			CheckInterval:  time.Second,
+			CommandTimeout: 10 * time.Second,
+		},
+		ReadOnlyProbe: ReadOnlyProbeConfig{
+			Schema:   "mysql",
+			Table:    "mysync_ro_probe",
+			Interval: time.Minute,
+			Timeout:  5 * time.Second,
+		},
+		LoadShedding: LoadSheddingConfig{
+			RecoverAfter: time.Minute,
+		},
+		RecoveryMarks: RecoveryMarksConfig{
+			Keep: 100,
+		},
+		Discovery: DiscoveryConfig{
+			Service:    "mysql",
+			EtcdPrefix: "/mysync/services",
+			Interval:   10 * time.Second,
+			TTL:        30 * time.Second,
+			Timeout:    5 * time.Second,
+		},
+		LivenessQuorum:                 0,
+		LivenessCheckInterval:          5 * time.Second,
+		SwitchoverDrainTimeout:         0,
+		SwitchoverDrainKill:            false,
+		SwitchoverRequireSameProtocol:  false,
+		SwitchoverTimeout:              0,
+		PromotionWarmupTimeout:         0,
+		PromotionWarmupBufferPool:      false,
+		PromotionWarmupQueries:         []string{},
+		PromotionWarmupMaxConnections:  0,
+		AutoResetup:                    false,
+		AutoResetupDelay:               10 * time.Minute,
+		AutoResetupConcurrency:         1,
+		ResetupTimeout:                 12 * time.Hour,
+		ResetupProgressInterval:        10 * time.Second,
+		ResetupCloneRestartTimeout:     10 * time.Minute,
+		ResetupProgressFile:            "/var/run/mysync/mysync.resetup.progress",
+		ResetupBandwidth:               0,
+		ResetupClusterBandwidth:        0,
+		ResetupCompression:             "",
+		APIListen:                      "",
+		GRPCListen:                     "",
+		APITokens:                      []APITokenConfig{},
+		HealthChecks:                   []HealthCheckConfig{},
+		Notifiers:                      []NotifierConfig{},
+		TopologyPublishers:             []TopologyPublisherConfig{},
+		FailoverWindows:                []FailoverWindowConfig{},
+		NotifyRetries:                  3,
+		NotifyRetryBackoff:             time.Second,
+		StatsdAddress:                  "",
+		StatsdPrefix:                   "mysync",
+		StatsdTags:                     []string{},
+		StatsdDogStatsD:                false,
+		HealthListen:                   "",
+		DebugListen:                    "",
+		HealthStaleTimeout:             time.Minute,
+		DiskExtraPaths:                 []string{},
+		DiskExhaustionHorizon:          0,
+		SwitchoverOnDiskExhaustion:     false,
+		StorageHealthFile:              "",
+		SwitchoverOnStorageDegradation: false,
+		BackupAwareSwitchover:          false,
+		OnlineDDLAwareSwitchover:       false,
+		VersionAwareSwitchover:         true,
+		EventHistorySize:               100,
+		LocalStateFile:                 "",
+		PolicyFile:                     "",
+		PolicyTimeout:                  time.Second,
+		Vault:                          defaultVaultConfig(),
+		ResetupDonorPolicy: DonorPolicyConfig{
+			PreferSameZone: true,
+			AllowMaster:    false,
+			MaxLag:         time.Minute,
+			MaxConcurrent:  1,
+		},
 	}
 	return config, nil
 }
@@ -200,7 +780,10 @@ func ReadFromFile(configFile string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	loader := confita.NewLoader(file.NewBackend(configFile))
+	if err = checkStrict(configFile); err != nil {
+		return nil, err
+	}
+	loader := confita.NewLoader(file.NewBackend(configFile), includeBackend{}, envBackend{}, secretsBackend{}, vaultBackend{})
 	if err = loader.Load(context.Background(), &config); err != nil {
 		err = fmt.Errorf("failed to load config from %s: %s", configFile, err.Error())
 		return nil, err
@@ -213,6 +796,11 @@ func ReadFromFile(configFile string) (*Config, error) {
 		fmt.Printf("MYSYNC_EMULATE_ERROR='%s'", mee)
 		fmt.Printf("\n\n")
 	}
+	config.ClusterHash = config.hashClusterSettings()
+	err = config.applyHostOverrides()
+	if err != nil {
+		return nil, err
+	}
 	config.SetDynamicDefaults()
 	err = config.Validate()
 	if err != nil {
@@ -225,17 +813,440 @@ func (cfg *Config) SetDynamicDefaults() {
 	if cfg.NotCriticalDiskUsage == 0.0 {
 		cfg.NotCriticalDiskUsage = cfg.CriticalDiskUsage
 	}
+	if cfg.Kubernetes.Enabled {
+		k8s := &cfg.Kubernetes
+		if k8s.Namespace == "" {
+			k8s.Namespace = os.Getenv("POD_NAMESPACE")
+		}
+		if k8s.PodName == "" {
+			k8s.PodName = os.Getenv("POD_NAME")
+		}
+		if k8s.PodIP == "" {
+			k8s.PodIP = os.Getenv("POD_IP")
+		}
+		if k8s.Service != "" && k8s.PodName != "" && k8s.Namespace != "" {
+			cfg.Hostname = fmt.Sprintf("%s.%s.%s.svc.%s", k8s.PodName, k8s.Service, k8s.Namespace, k8s.ClusterDomain)
+		}
+	}
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.SecretsKeyFile != "" && cfg.SecretsKeyCommand != "" {
+		return fmt.Errorf("secrets_key_file and secrets_key_command are mutually exclusive")
+	}
+	if cfg.CriticalDiskUsage < 0 || cfg.CriticalDiskUsage > 100 {
+		return fmt.Errorf("critical_disk_usage should be within [0, 100]")
+	}
+	if cfg.NotCriticalDiskUsage < 0 || cfg.NotCriticalDiskUsage > 100 {
+		return fmt.Errorf("not_critical_disk_usage should be within [0, 100]")
+	}
+	if cfg.MySQL.Port <= 0 || cfg.MySQL.Port > 65535 {
+		return fmt.Errorf("mysql.port should be within [1, 65535]")
+	}
+	if cfg.MaxAcceptableLag < 0 {
+		return fmt.Errorf("max_acceptable_lag should be >= 0")
+	}
+	if cfg.ResetupBandwidth < 0 || cfg.ResetupClusterBandwidth < 0 {
+		return fmt.Errorf("resetup_bandwidth and resetup_cluster_bandwidth should be >= 0")
+	}
+	if cfg.ResetupCompression != "" && cfg.ResetupCompression != ResetupCompressionZstd {
+		return fmt.Errorf("resetup_compression should be empty or %q", ResetupCompressionZstd)
+	}
+	if cfg.ResetupDonorPolicy.MaxLag < 0 || cfg.ResetupDonorPolicy.MaxThreadsRunning < 0 || cfg.ResetupDonorPolicy.MaxConcurrent < 0 {
+		return fmt.Errorf("resetup_donor_policy limits should be >= 0")
+	}
+	for _, calculator := range cfg.ReplicationLagCalculators {
+		switch calculator {
+		case LagCalculatorSecondsBehindMaster, LagCalculatorApplier:
+		case LagCalculatorHeartbeat:
+			if !cfg.ReplMon {
+				return fmt.Errorf("repl mon must be enabled to use %s replication lag calculator", calculator)
+			}
+		case LagCalculatorQuery:
+			if cfg.Queries["replication_lag"] == "" {
+				return fmt.Errorf("queries.replication_lag must be set to use %s replication lag calculator", calculator)
+			}
+		default:
+			return fmt.Errorf("unknown replication lag calculator %q", calculator)
+		}
+	}
+	switch cfg.ManagerAntiAffinity {
+	case ManagerAntiAffinityOff, ManagerAntiAffinityPrefer, ManagerAntiAffinityRequire:
+	default:
+		return fmt.Errorf("manager_anti_affinity should be one of %s, %s, %s", ManagerAntiAffinityOff, ManagerAntiAffinityPrefer, ManagerAntiAffinityRequire)
+	}
+	if cfg.ReplicationLagPolicy != LagPolicyMax && cfg.ReplicationLagPolicy != LagPolicyMedian {
+		return fmt.Errorf("replication_lag_policy should be %q or %q", LagPolicyMax, LagPolicyMedian)
+	}
+	timeouts := map[string]time.Duration{
+		"db_timeout":              cfg.DBTimeout,
+		"db_lost_check_timeout":   cfg.DBLostCheckTimeout,
+		"db_set_ro_timeout":       cfg.DBSetRoTimeout,
+		"db_set_ro_force_timeout": cfg.DBSetRoForceTimeout,
+		"read_query_timeout":      cfg.ReadQueryTimeout,
+		"write_query_timeout":     cfg.WriteQueryTimeout,
+		"dcs_wait_timeout":        cfg.DcsWaitTimeout,
+		"failover_cooldown":       cfg.FailoverCooldown,
+		"failover_delay":          cfg.FailoverDelay,
+		"inactivation_delay":      cfg.InactivationDelay,
+	}
+	for name, timeout := range timeouts {
+		if timeout < 0 {
+			return fmt.Errorf("%s should be >= 0", name)
+		}
+	}
+	if cfg.ClockSkewThreshold < 0 {
+		return fmt.Errorf("clock_skew_threshold should be >= 0")
+	}
+	if cfg.PromotionWarmupTimeout < 0 || cfg.PromotionWarmupMaxConnections < 0 {
+		return fmt.Errorf("promotion_warmup_timeout and promotion_warmup_max_connections should be >= 0")
+	}
+	if cfg.LagQuantile < 0 || cfg.LagQuantile > 1 {
+		return fmt.Errorf("lag_quantile should be within [0, 1]")
+	}
+	if cfg.RepairBudgetAttempts < 0 {
+		return fmt.Errorf("repair_budget_attempts should be >= 0")
+	}
+	if cfg.RepairCooldown > cfg.RepairCooldownMax {
+		return fmt.Errorf("repair_cooldown should not be greater than repair_cooldown_max")
+	}
+	if cfg.ReadQueryRetries < 0 || cfg.ReadQueryRetryInterval < 0 {
+		return fmt.Errorf("read_query_retries and read_query_retry_interval should be >= 0")
+	}
+	for name, timeout := range cfg.QueryTimeouts {
+		if timeout <= 0 {
+			return fmt.Errorf("query_timeouts.%s should be > 0", name)
+		}
+	}
 	if cfg.NotCriticalDiskUsage > cfg.CriticalDiskUsage {
 		return fmt.Errorf("not_critical_disk_usage should be <= critical_disk_usage")
 	}
+	switch cfg.SemiSyncStallMitigation {
+	case SemiSyncStallReport, SemiSyncStallRelax, SemiSyncStallFailover:
+	default:
+		return fmt.Errorf("semi_sync_stall_mitigation should be one of %s, %s, %s", SemiSyncStallReport, SemiSyncStallRelax, SemiSyncStallFailover)
+	}
+	if cfg.SemiSyncStallTimeout < 0 {
+		return fmt.Errorf("semi_sync_stall_timeout should be >= 0")
+	}
+	switch cfg.PromotionStrategy {
+	case PromotionStrategyPriority, PromotionStrategyMostAdvanced:
+	default:
+		return fmt.Errorf("promotion_strategy should be one of %s, %s", PromotionStrategyPriority, PromotionStrategyMostAdvanced)
+	}
+	if cfg.PriorityCatchUpTimeout < 0 {
+		return fmt.Errorf("priority_catch_up_timeout should be >= 0")
+	}
+	switch cfg.FailureDetector {
+	case FailureDetectorFixed:
+	case FailureDetectorPhiAccrual:
+		if cfg.PhiThreshold <= 0 {
+			return fmt.Errorf("phi_threshold should be > 0")
+		}
+		if cfg.PhiMinStdDeviation <= 0 {
+			return fmt.Errorf("phi_min_std_deviation should be > 0")
+		}
+		if cfg.PhiWindowSize < 2 {
+			return fmt.Errorf("phi_window_size should be >= 2")
+		}
+	default:
+		return fmt.Errorf("failure_detector should be one of %s, %s", FailureDetectorFixed, FailureDetectorPhiAccrual)
+	}
+	if cfg.ThrottleLag < 0 {
+		return fmt.Errorf("throttle_lag should be >= 0")
+	}
+	if cfg.PolicyFile != "" && cfg.PolicyTimeout <= 0 {
+		return fmt.Errorf("policy_timeout should be > 0")
+	}
+	if cfg.MaxApplyBacklog < 0 {
+		return fmt.Errorf("max_apply_backlog should be >= 0")
+	}
+	if cfg.SlaveCatchUpTimeoutMax != 0 && cfg.SlaveCatchUpTimeoutMax < cfg.SlaveCatchUpTimeout {
+		return fmt.Errorf("slave_catch_up_timeout_max should be 0 or >= slave_catch_up_timeout")
+	}
 	if cfg.SemiSync && cfg.ASync {
 		return fmt.Errorf("can't run in both semisync and async mode")
 	}
 	if cfg.ASync && !cfg.ReplMon {
 		return fmt.Errorf("repl mon must be enabled to run mysync in async mode")
 	}
+	if cfg.LivenessQuorum < 0 {
+		return fmt.Errorf("liveness_quorum should be >= 0")
+	}
+	if cfg.ZonePolicy.MinReachableZones < 0 {
+		return fmt.Errorf("zone_policy.min_reachable_zones should be >= 0")
+	}
+	if cfg.OfflineModeDisableLag > cfg.OfflineModeEnableLag {
+		return fmt.Errorf("offline_mode_disable_lag should not be greater than offline_mode_enable_lag")
+	}
+	if cfg.CascadeOfflineModeEnableLag > 0 && cfg.CascadeOfflineModeDisableLag > cfg.CascadeOfflineModeEnableLag {
+		return fmt.Errorf("cascade_offline_mode_disable_lag should not be greater than cascade_offline_mode_enable_lag")
+	}
+	if cfg.OfflineModeEnableThreadsRunning < 0 || cfg.OfflineModeDisableThreadsRunning < 0 || cfg.OfflineModeEnableCPULoad < 0 || cfg.OfflineModeDisableCPULoad < 0 {
+		return fmt.Errorf("offline mode load thresholds should be >= 0")
+	}
+	if cfg.OfflineModeEnableThreadsRunning > 0 && cfg.OfflineModeDisableThreadsRunning > cfg.OfflineModeEnableThreadsRunning {
+		return fmt.Errorf("offline_mode_disable_threads_running should not be greater than offline_mode_enable_threads_running")
+	}
+	if cfg.OfflineModeEnableCPULoad > 0 && cfg.OfflineModeDisableCPULoad > cfg.OfflineModeEnableCPULoad {
+		return fmt.Errorf("offline_mode_disable_cpu_load should not be greater than offline_mode_enable_cpu_load")
+	}
+	if cfg.OfflineModeLoadHoldTime < 0 || cfg.OfflineModeMinOnlineReplicas < 0 {
+		return fmt.Errorf("offline_mode_load_hold_time and offline_mode_min_online_replicas should be >= 0")
+	}
+	for _, check := range cfg.HealthChecks {
+		if check.Name == "" || check.Query == "" {
+			return fmt.Errorf("health check should have name and query")
+		}
+	}
+	if cfg.EventHistorySize < 0 {
+		return fmt.Errorf("event_history_size should be >= 0")
+	}
+	if cfg.HostStatusConcurrency < 1 {
+		return fmt.Errorf("host_status_concurrency should be >= 1")
+	}
+	if cfg.AutoResetupConcurrency < 1 {
+		return fmt.Errorf("auto_resetup_concurrency should be >= 1")
+	}
+	if cfg.APIListen != "" && len(cfg.APITokens) == 0 {
+		return fmt.Errorf("api_tokens should be set when api_listen is enabled")
+	}
+	for _, token := range cfg.APITokens {
+		if token.Name == "" || (token.Token == "" && token.CertCN == "") {
+			return fmt.Errorf("api token should have name and token or cert_cn")
+		}
+		if _, err := path.Match(token.CertCN, ""); err != nil {
+			return fmt.Errorf("api token %q: malformed cert_cn: %v", token.Name, err)
+		}
+		switch token.Role {
+		case "", APIRoleViewer, APIRoleOperator, APIRoleAdmin:
+		default:
+			return fmt.Errorf("api token %q: unknown role %q", token.Name, token.Role)
+		}
+	}
+	for _, notifier := range cfg.Notifiers {
+		switch notifier.Type {
+		case NotifierWebhook, NotifierSlack:
+			if notifier.URL == "" {
+				return fmt.Errorf("notifier %q: url should be set", notifier.Name)
+			}
+		case NotifierPagerDuty:
+			if notifier.RoutingKey == "" {
+				return fmt.Errorf("notifier %q: routing_key should be set", notifier.Name)
+			}
+		default:
+			return fmt.Errorf("notifier %q: unknown type %q, expected one of webhook, slack, pagerduty", notifier.Name, notifier.Type)
+		}
+	}
+	if err := cfg.validateHostAddresses(); err != nil {
+		return err
+	}
+	if err := cfg.validateFailoverWindows(); err != nil {
+		return err
+	}
+	if cfg.ReadOnlyProbe.User != "" && (cfg.ReadOnlyProbe.Interval <= 0 || cfg.ReadOnlyProbe.Timeout <= 0) {
+		return fmt.Errorf("read_only_probe interval and timeout should be > 0")
+	}
+	if err := cfg.validateLoadShedding(); err != nil {
+		return err
+	}
+	if cfg.RecoveryMarks.Keep < 1 {
+		return fmt.Errorf("recovery_marks keep should be >= 1")
+	}
+	if cfg.RecoveryMarks.Schedule != "" {
+		if _, err := util.ParseCron(cfg.RecoveryMarks.Schedule); err != nil {
+			return fmt.Errorf("recovery_marks schedule: %v", err)
+		}
+	}
+	for _, publisher := range cfg.TopologyPublishers {
+		switch publisher.Type {
+		case TopologyPublisherEtcd, TopologyPublisherConsul:
+			if publisher.Endpoint == "" || publisher.Key == "" {
+				return fmt.Errorf("topology publisher %q: endpoint and key should be set", publisher.Name)
+			}
+		case TopologyPublisherConfigMap, TopologyPublisherFile:
+			if publisher.Key == "" {
+				return fmt.Errorf("topology publisher %q: key should be set", publisher.Name)
+			}
+		default:
+			return fmt.Errorf("topology publisher %q: unknown type %q, expected one of etcd, consul, configmap, file", publisher.Name, publisher.Type)
+		}
+	}
+	if cfg.NotifyRetries < 0 {
+		return fmt.Errorf("notify_retries should be >= 0")
+	}
+	if cfg.DebugListen != "" {
+		host, _, err := net.SplitHostPort(cfg.DebugListen)
+		if err != nil {
+			return fmt.Errorf("malformed debug_listen: %v", err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return fmt.Errorf("debug_listen should be loopback address, got %s", host)
+		}
+	}
+	if (cfg.APITLSCertFile == "") != (cfg.APITLSKeyFile == "") {
+		return fmt.Errorf("api_tls_cert_file and api_tls_key_file should be set together")
+	}
+	if cfg.Kubernetes.Enabled {
+		if cfg.Kubernetes.Namespace == "" || cfg.Kubernetes.PodName == "" {
+			return fmt.Errorf("kubernetes namespace and pod_name should be set or passed via POD_NAMESPACE and POD_NAME")
+		}
+		if cfg.Kubernetes.SyncInterval <= 0 {
+			return fmt.Errorf("kubernetes sync_interval should be > 0")
+		}
+	}
+	if err := cfg.DNS.validate(); err != nil {
+		return err
+	}
+	switch cfg.Discovery.Type {
+	case "":
+	case DiscoveryConsul, DiscoveryEtcd:
+		if cfg.Discovery.Endpoint == "" || cfg.Discovery.Service == "" {
+			return fmt.Errorf("discovery endpoint and service should be set")
+		}
+		if cfg.Discovery.Interval <= 0 || cfg.Discovery.Timeout <= 0 || cfg.Discovery.TTL <= cfg.Discovery.Interval {
+			return fmt.Errorf("discovery interval and timeout should be > 0, ttl should be greater than interval")
+		}
+	default:
+		return fmt.Errorf("unknown discovery type %q", cfg.Discovery.Type)
+	}
+	if cfg.VIP.Address != "" {
+		if _, _, err := net.ParseCIDR(cfg.VIP.Address); err != nil {
+			return fmt.Errorf("vip address should be an address with prefix length: %s", err)
+		}
+		if cfg.VIP.CheckInterval <= 0 || cfg.VIP.CommandTimeout <= 0 {
+			return fmt.Errorf("vip check_interval and command_timeout should be > 0")
+		}
+	}
+	if cfg.AdaptivePolling.Enabled {
+		if cfg.AdaptivePolling.MinInterval <= 0 || cfg.AdaptivePolling.StablePeriod <= 0 {
+			return fmt.Errorf("adaptive_polling min_interval and stable_period should be > 0")
+		}
+		if cfg.AdaptivePolling.MaxInterval < cfg.AdaptivePolling.MinInterval {
+			return fmt.Errorf("adaptive_polling max_interval should be >= min_interval")
+		}
+	}
+	if cfg.APITLSCAFile != "" && cfg.APITLSCertFile == "" {
+		return fmt.Errorf("api_tls_ca_file requires api_tls_cert_file and api_tls_key_file")
+	}
+	if cfg.APITLSRequireClientCert && cfg.APITLSCAFile == "" {
+		return fmt.Errorf("api_tls_require_client_cert requires api_tls_ca_file")
+	}
+	if len(cfg.ExternalReplicationSources) > 0 {
+		if cfg.ExternalReplicationType == util.Disabled {
+			return fmt.Errorf("external_replication_sources requires external_replication_type")
+		}
+		if cfg.ExternalReplicationFailoverTimeout <= 0 {
+			return fmt.Errorf("external_replication_failover_timeout should be > 0")
+		}
+	}
+	intervals := map[string]time.Duration{
+		"tick_interval":                   cfg.TickInterval,
+		"healthcheck_interval":            cfg.HealthCheckInterval,
+		"recoverycheck_interval":          cfg.RecoveryCheckInterval,
+		"health_stale_timeout":            cfg.HealthStaleTimeout,
+		"host_status_timeout":             cfg.HostStatusTimeout,
+		"info_file_handler_interval":      cfg.InfoFileHandlerInterval,
+		"external_ca_file_check_interval": cfg.ExternalCAFileCheckInterval,
+		"liveness_check_interval":         cfg.LivenessCheckInterval,
+		"notify_retry_backoff":            cfg.NotifyRetryBackoff,
+		"resetup_progress_interval":       cfg.ResetupProgressInterval,
+		"resetup_clone_restart_timeout":   cfg.ResetupCloneRestartTimeout,
+		"manager_handoff_timeout":         cfg.ManagerHandoffTimeout,
+		"quarantine_timeout":              cfg.QuarantineTimeout,
+		"repair_budget_window":            cfg.RepairBudgetWindow,
+		"repair_cooldown":                 cfg.RepairCooldown,
+		"repair_cooldown_max":             cfg.RepairCooldownMax,
+		"lag_history_window":              cfg.LagHistoryWindow,
+	}
+	for name, interval := range intervals {
+		if interval <= 0 {
+			return fmt.Errorf("%s should be > 0", name)
+		}
+	}
+	return nil
+}
+
+// Redacted returns copy of config with secrets hidden, suitable for printing
+func (cfg *Config) Redacted() *Config {
+	redacted := *cfg
+	for _, secret := range []*string{&redacted.MySQL.Password, &redacted.MySQL.PreviousPassword, &redacted.MySQL.ReplicationPassword, &redacted.Zookeeper.Password} {
+		if *secret != "" {
+			*secret = "********"
+		}
+	}
+	redacted.MySQLCredentialsLease = nil
+	redacted.ReplicationCredentialsLease = nil
+	redacted.ZookeeperCredentialsLease = nil
+	// overrides may contain secrets, effective values are shown instead
+	redacted.HostOverrides = nil
+	redacted.APITokens = make([]APITokenConfig, len(cfg.APITokens))
+	for i, token := range cfg.APITokens {
+		redacted.APITokens[i] = token
+		if token.Token != "" {
+			redacted.APITokens[i].Token = "********"
+		}
+	}
+	// webhook urls usually embed credentials
+	redacted.Notifiers = make([]NotifierConfig, len(cfg.Notifiers))
+	for i, notifier := range cfg.Notifiers {
+		redacted.Notifiers[i] = notifier
+		if notifier.URL != "" {
+			redacted.Notifiers[i].URL = "********"
+		}
+		if notifier.RoutingKey != "" {
+			redacted.Notifiers[i].RoutingKey = "********"
+		}
+	}
+	redacted.TopologyPublishers = make([]TopologyPublisherConfig, len(cfg.TopologyPublishers))
+	for i, publisher := range cfg.TopologyPublishers {
+		redacted.TopologyPublishers[i] = publisher
+		if publisher.Token != "" {
+			redacted.TopologyPublishers[i].Token = "********"
+		}
+	}
+	if redacted.ReadOnlyProbe.Password != "" {
+		redacted.ReadOnlyProbe.Password = "********"
+	}
+	if redacted.DNS.Secret != "" {
+		redacted.DNS.Secret = "********"
+	}
+	if redacted.Discovery.Token != "" {
+		redacted.Discovery.Token = "********"
+	}
+	return &redacted
+}
+
+func (dns DNSConfig) validate() error {
+	if dns.Provider == "" {
+		return nil
+	}
+	var required []string
+	switch dns.Provider {
+	case DNSProviderRoute53:
+		required = []string{"zone", dns.Zone, "key", dns.Key, "secret", dns.Secret}
+	case DNSProviderEtcd:
+		required = []string{"endpoint", dns.Endpoint}
+	case DNSProviderPowerDNS:
+		required = []string{"endpoint", dns.Endpoint, "zone", dns.Zone, "secret", dns.Secret}
+	case DNSProviderRFC2136:
+		required = []string{"endpoint", dns.Endpoint, "zone", dns.Zone}
+	default:
+		return fmt.Errorf("unknown dns provider %q", dns.Provider)
+	}
+	required = append(required, "record", dns.Record)
+	for i := 0; i < len(required); i += 2 {
+		if required[i+1] == "" {
+			return fmt.Errorf("dns %s should be set for provider %s", required[i], dns.Provider)
+		}
+	}
+	switch dns.RecordType {
+	case "CNAME", "A", "AAAA":
+	default:
+		return fmt.Errorf("dns record_type should be one of CNAME, A, AAAA")
+	}
+	if dns.TTL <= 0 || dns.Timeout <= 0 {
+		return fmt.Errorf("dns ttl and timeout should be > 0")
+	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/heetch/confita"
+	"github.com/heetch/confita/backend"
+)
+
+// envPrefix is a prefix of environment variables overriding config keys,
+// e.g. MYSYNC_MYSQL_PASSWORD overrides mysql.password
+const envPrefix = "MYSYNC"
+
+// envBackend overrides config keys from environment. Unlike confita env backend,
+// it takes nesting into account, so mysql.password and zookeeper.password differ
+type envBackend struct{}
+
+func (b envBackend) Name() string {
+	return "env"
+}
+
+func (b envBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (b envBackend) Unmarshal(ctx context.Context, to interface{}) error {
+	return loadEnv(reflect.ValueOf(to).Elem(), envPrefix)
+}
+
+// envName returns environment variable name for a field with given prefix
+func envName(prefix string, field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("config"), ",")[0]
+	if name == "" || name == "-" {
+		return ""
+	}
+	return prefix + "_" + strings.ToUpper(name)
+}
+
+func loadEnv(value reflect.Value, prefix string) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := envName(prefix, field)
+		if name == "" || field.PkgPath != "" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		switch fieldValue.Kind() {
+		case reflect.Struct:
+			err := loadEnv(fieldValue, name)
+			if err != nil {
+				return err
+			}
+			continue
+		case reflect.Map:
+			continue
+		case reflect.Slice:
+			if fieldValue.Type().Elem().Kind() == reflect.Struct {
+				continue
+			}
+		}
+		data, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		err := (&confita.FieldConfig{Value: fieldValue}).Set(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysync.yaml")
+	content := `
+hostname: db1
+mysql:
+  user: mysync
+  replication_user: repl
+  replication_password: repl
+zookeeper:
+  namespace: /mysync/test
+  hosts: [zk1:2181]
+failover_delay: 10s
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	t.Setenv("MYSYNC_MYSQL_PASSWORD", "secret")
+	t.Setenv("MYSYNC_ZOOKEEPER_HOSTS", "zk2:2181,zk3:2181")
+	t.Setenv("MYSYNC_FAILOVER_DELAY", "1m")
+
+	cfg, err := ReadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "secret", cfg.MySQL.Password)
+	require.Equal(t, "", cfg.Zookeeper.Password)
+	require.Equal(t, []string{"zk2:2181", "zk3:2181"}, cfg.Zookeeper.Hosts)
+	require.Equal(t, time.Minute, cfg.FailoverDelay)
+	require.Equal(t, "mysync", cfg.MySQL.User)
+}
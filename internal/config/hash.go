@@ -0,0 +1,32 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"gopkg.in/yaml.v2"
+)
+
+// hashClusterSettings hashes settings shared by all hosts of cluster. It is called before
+// host overrides are applied, host identity and credentials issued per host are excluded,
+// so hosts with the same config file have the same hash
+func (cfg *Config) hashClusterSettings() string {
+	shared := cfg.Redacted()
+	shared.Hostname = ""
+	shared.Zone = ""
+	shared.Kubernetes.PodName = ""
+	shared.Kubernetes.PodIP = ""
+	shared.Discovery.Address = ""
+	// users may be issued by Vault for each host
+	shared.MySQL.User = ""
+	shared.MySQL.ReplicationUser = ""
+	shared.Zookeeper.Username = ""
+	// overrides of all hosts are part of cluster config as written in the file
+	shared.HostOverrides = cfg.HostOverrides
+	data, err := yaml.Marshal(shared)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Holder keeps config, which is read by concurrent goroutines of agent.
+// Config is never changed in place: updates are applied to a copy, which then
+// replaces current config as a whole, so readers never see partially applied changes
+type Holder struct {
+	current atomic.Pointer[Config]
+	updates sync.Mutex
+}
+
+// NewHolder returns holder of cfg, cfg should not be changed after that
+func NewHolder(cfg *Config) *Holder {
+	h := new(Holder)
+	h.current.Store(cfg)
+	return h
+}
+
+// Get returns current config, it should be treated as read-only
+func (h *Holder) Get() *Config {
+	return h.current.Load()
+}
+
+// Update applies change to a copy of current config and publishes it
+func (h *Holder) Update(change func(cfg *Config)) {
+	h.updates.Lock()
+	defer h.updates.Unlock()
+	updated := *h.current.Load()
+	change(&updated)
+	h.current.Store(&updated)
+}
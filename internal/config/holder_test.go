@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolderUpdate(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	holder := NewHolder(&cfg)
+	current := holder.Get()
+
+	holder.Update(func(cfg *Config) {
+		cfg.FailoverDelay = time.Minute
+	})
+	require.Equal(t, time.Minute, holder.Get().FailoverDelay)
+	require.NotEqual(t, time.Minute, current.FailoverDelay)
+}
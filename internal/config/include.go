@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/heetch/confita/backend"
+	"gopkg.in/yaml.v2"
+)
+
+// includeBackend merges config fragments listed in `include` on top of config file.
+// Every pattern is expanded and its matches are applied in lexical order,
+// so later fragments override earlier ones
+type includeBackend struct{}
+
+func (b includeBackend) Name() string {
+	return "include"
+}
+
+func (b includeBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (b includeBackend) Unmarshal(ctx context.Context, to interface{}) error {
+	cfg := to.(*Config)
+	fragments, err := expandIncludes(cfg.Include)
+	if err != nil {
+		return err
+	}
+	for _, fragment := range fragments {
+		err = checkStrict(fragment)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(fragment)
+		if err != nil {
+			return err
+		}
+		include := cfg.Include
+		cfg.Include = nil
+		err = yaml.Unmarshal(data, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fragment, err)
+		}
+		if cfg.Include != nil {
+			return fmt.Errorf("%s: nested include is not supported", fragment)
+		}
+		cfg.Include = include
+	}
+	return nil
+}
+
+// expandIncludes returns files matching include patterns in order of application
+func expandIncludes(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("malformed include pattern %q: %v", pattern, err)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
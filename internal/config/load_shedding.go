@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Account is MySQL account, which is 'user'@'host'
+type Account struct {
+	User string
+	Host string
+}
+
+// ParseAccount parses account written as user@host, host is % if omitted
+func ParseAccount(account string) Account {
+	i := strings.LastIndex(account, "@")
+	if i < 0 {
+		return Account{User: account, Host: "%"}
+	}
+	return Account{User: account[:i], Host: account[i+1:]}
+}
+
+func (a Account) String() string {
+	return a.User + "@" + a.Host
+}
+
+// Enabled checks that load shedding is triggered by anything
+func (c *LoadSheddingConfig) Enabled() bool {
+	return c.Lag > 0 || c.SemiSync
+}
+
+func (cfg *Config) validateLoadShedding() error {
+	ls := cfg.LoadShedding
+	if ls.Lag < 0 || ls.RecoverAfter < 0 || ls.MaxUserConnections < 0 {
+		return fmt.Errorf("load_shedding lag, recover_after and max_user_connections should be >= 0")
+	}
+	for _, user := range ls.Users {
+		if ParseAccount(user).User == "" {
+			return fmt.Errorf("load_shedding: malformed account %q, expected user@host", user)
+		}
+	}
+	if len(ls.Users) > 0 && ls.MaxUserConnections == 0 {
+		return fmt.Errorf("load_shedding: max_user_connections should be set to limit users")
+	}
+	if ls.Enabled() && len(ls.Users) == 0 && !ls.Throttle {
+		return fmt.Errorf("load_shedding: neither users nor throttle is set, nothing to apply")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// keys which identify host and therefore can't be overridden per host
+var notOverridableKeys = []string{"hostname", "host_overrides"}
+
+// ApplyOverrides sets config keys from overrides, which has the same structure as config file
+func (cfg *Config) ApplyOverrides(overrides map[string]interface{}) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	for _, key := range notOverridableKeys {
+		if _, ok := overrides[key]; ok {
+			return fmt.Errorf("%s can't be overridden per host", key)
+		}
+	}
+	data, err := yaml.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	updated := *cfg
+	err = yaml.UnmarshalStrict(data, &updated)
+	if err != nil {
+		return err
+	}
+	*cfg = updated
+	return nil
+}
+
+// applyHostOverrides applies host_overrides section of config file for local host
+func (cfg *Config) applyHostOverrides() error {
+	err := cfg.ApplyOverrides(cfg.HostOverrides[cfg.Hostname])
+	if err != nil {
+		return fmt.Errorf("invalid host_overrides for %s: %v", cfg.Hostname, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadableFields are config fields, which are read on every use and may be changed without restart
+var reloadableFields = map[string]bool{
+	"LogLevel":                     true,
+	"SQLLog":                       true,
+	"Failover":                     true,
+	"FailoverCooldown":             true,
+	"FailoverDelay":                true,
+	"FailoverWindows":              true,
+	"FailureDetector":              true,
+	"PhiThreshold":                 true,
+	"PhiMinStdDeviation":           true,
+	"PhiWindowSize":                true,
+	"InactivationDelay":            true,
+	"CriticalDiskUsage":            true,
+	"NotCriticalDiskUsage":         true,
+	"SemiSyncEnableLag":            true,
+	"SemiSyncStallTimeout":         true,
+	"SemiSyncStallMitigation":      true,
+	"DBTimeout":                    true,
+	"HostStatusTimeout":            true,
+	"DBLostCheckTimeout":           true,
+	"DBSetRoTimeout":               true,
+	"DBSetRoForceTimeout":          true,
+	"DBStopSlaveSQLThreadTimeout":  true,
+	"ReplicationLagCalculators":    true,
+	"ReplicationLagPolicy":         true,
+	"ReadQueryTimeout":             true,
+	"ReadQueryRetries":             true,
+	"ReadQueryRetryInterval":       true,
+	"WriteQueryTimeout":            true,
+	"QueryTimeouts":                true,
+	"MaxAcceptableLag":             true,
+	"SlaveCatchUpTimeout":          true,
+	"SlaveCatchUpTimeoutMax":       true,
+	"MaxApplyBacklog":              true,
+	"ThrottleLag":                  true,
+	"PolicyFile":                   true,
+	"PolicyTimeout":                true,
+	"ExcludeUsers":                 true,
+	"OfflineModeEnableInterval":    true,
+	"OfflineModeEnableLag":         true,
+	"OfflineModeDisableLag":        true,
+	"CascadeOfflineModeEnableLag":  true,
+	"CascadeOfflineModeDisableLag": true,
+	"StreamFromReasonableLag":      true,
+	"PriorityChoiceMaxLag":         true,
+	"PromotionStrategy":            true,
+	"PriorityCatchUpTimeout":       true,
+	"WaitReplicationStartTimeout":  true,
+	"ReplicationRepairCooldown":    true,
+	"ReplicationRepairMaxAttempts": true,
+	"RepairBudgetAttempts":         true,
+	"RepairBudgetWindow":           true,
+	"RepairCooldown":               true,
+	"RepairCooldownMax":            true,
+	"EnforceSettings":              true,
+	"LagHistoryWindow":             true,
+	"LagQuantile":                  true,
+	"ManagerAntiAffinity":          true,
+	"ClockSkewThreshold":           true,
+	"AsyncAllowedLag":              true,
+	"ExternalReplicationSources":   true,
+	"SwitchoverDrainTimeout":       true,
+	"SwitchoverDrainKill":          true,
+	"SwitchoverTimeout":            true,
+	"PromotionWarmupTimeout":       true,
+	"PromotionWarmupBufferPool":    true,
+	"PromotionWarmupQueries":       true,
+	"AutoResetupDelay":             true,
+	"AutoResetupConcurrency":       true,
+	"ResetupTimeout":               true,
+	"ResetupProgressInterval":      true,
+	"ResetupCloneRestartTimeout":   true,
+	"ResetupBandwidth":             true,
+	"ResetupClusterBandwidth":      true,
+	"ResetupCompression":           true,
+	"ResetupDonorPolicy":           true,
+	"DiskExhaustionHorizon":        true,
+	"EventHistorySize":             true,
+	"HealthStaleTimeout":           true,
+	"ManagerHandoffTimeout":        true,
+	"QuarantineTimeout":            true,
+	"AdaptivePolling":              true,
+	"VersionAwareSwitchover":       true,
+
+	"SwitchoverRequireSameProtocol":      true,
+	"ExternalReplicationFailoverTimeout": true,
+	"OfflineModeEnableThreadsRunning":    true,
+	"OfflineModeDisableThreadsRunning":   true,
+	"OfflineModeEnableCPULoad":           true,
+	"OfflineModeDisableCPULoad":          true,
+	"OfflineModeLoadHoldTime":            true,
+	"OfflineModeMinOnlineReplicas":       true,
+	"PromotionWarmupMaxConnections":      true,
+}
+
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("config"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Reload copies reloadable fields of newCfg to cfg. It returns names of applied fields
+// and names of changed fields, which take effect only after restart
+func (cfg *Config) Reload(newCfg *Config) (applied, ignored []string) {
+	current := reflect.ValueOf(cfg).Elem()
+	updated := reflect.ValueOf(newCfg).Elem()
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		if field.Tag.Get("config") == "-" {
+			continue
+		}
+		if reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			continue
+		}
+		if reloadableFields[field.Name] {
+			current.Field(i).Set(updated.Field(i))
+			applied = append(applied, fieldName(field))
+		} else {
+			ignored = append(ignored, fieldName(field))
+		}
+	}
+	return applied, ignored
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReload(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	newCfg := cfg
+	newCfg.FailoverDelay = time.Minute
+	newCfg.ExcludeUsers = []string{"monitor"}
+	newCfg.Hostname = "other"
+
+	applied, ignored := cfg.Reload(&newCfg)
+	require.Equal(t, []string{"failover_delay", "exclude_users"}, applied)
+	require.Equal(t, []string{"hostname"}, ignored)
+	require.Equal(t, time.Minute, cfg.FailoverDelay)
+	require.Equal(t, []string{"monitor"}, cfg.ExcludeUsers)
+	require.NotEqual(t, "other", cfg.Hostname)
+}
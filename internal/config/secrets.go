@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/heetch/confita/backend"
+)
+
+const (
+	encryptedPrefix = "ENC[aes256gcm:"
+	encryptedSuffix = "]"
+	secretsKeySize  = 32
+)
+
+// GenerateSecretsKey returns new random key for config secrets encryption, encoded in base64
+func GenerateSecretsKey() (string, error) {
+	key := make([]byte, secretsKeySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseSecretsKey decodes base64 encoded key, as produced by GenerateSecretsKey
+func ParseSecretsKey(data []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("malformed secrets key: %v", err)
+	}
+	if len(key) != secretsKeySize {
+		return nil, fmt.Errorf("secrets key should be %d bytes, got %d", secretsKeySize, len(key))
+	}
+	return key, nil
+}
+
+// ReadSecretsKeyFile reads key for config secrets encryption from file
+func ReadSecretsKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key: %v", err)
+	}
+	return ParseSecretsKey(data)
+}
+
+// readSecretsKey returns key from key file or output of key command (e.g. KMS client)
+func (cfg *Config) readSecretsKey() ([]byte, error) {
+	if cfg.SecretsKeyFile != "" {
+		return ReadSecretsKeyFile(cfg.SecretsKeyFile)
+	}
+	if cfg.SecretsKeyCommand != "" {
+		output, err := exec.Command("/bin/sh", "-c", cfg.SecretsKeyCommand).Output()
+		if err != nil {
+			return nil, fmt.Errorf("secrets key command failed: %v", err)
+		}
+		return ParseSecretsKey(output)
+	}
+	return nil, fmt.Errorf("config contains encrypted values, but neither secrets_key_file nor secrets_key_command is set")
+}
+
+// EncryptSecret encrypts value with AES-256-GCM, result may be used in config instead of plaintext
+func EncryptSecret(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// IsEncrypted checks whether config value was produced by EncryptSecret
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+func decryptSecret(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext is too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretsBackend decrypts encrypted values loaded from config file and environment
+type secretsBackend struct{}
+
+func (b secretsBackend) Name() string {
+	return "secrets"
+}
+
+func (b secretsBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (b secretsBackend) Unmarshal(ctx context.Context, to interface{}) error {
+	cfg := to.(*Config)
+	var key []byte
+	return decryptFields(reflect.ValueOf(cfg).Elem(), "", func() ([]byte, error) {
+		if key != nil {
+			return key, nil
+		}
+		var err error
+		key, err = cfg.readSecretsKey()
+		return key, err
+	})
+}
+
+// decryptFields replaces encrypted strings in struct, key is read only if such strings are present
+func decryptFields(value reflect.Value, path string, getKey func() ([]byte, error)) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := strings.Split(field.Tag.Get("config"), ",")[0]
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		fieldValue := value.Field(i)
+		switch fieldValue.Kind() {
+		case reflect.Struct:
+			err := decryptFields(fieldValue, name, getKey)
+			if err != nil {
+				return err
+			}
+		case reflect.String:
+			if !IsEncrypted(fieldValue.String()) {
+				continue
+			}
+			key, err := getKey()
+			if err != nil {
+				return err
+			}
+			plaintext, err := decryptSecret(key, fieldValue.String())
+			if err != nil {
+				return fmt.Errorf("failed to decrypt %s: %v", name, err)
+			}
+			fieldValue.SetString(plaintext)
+		}
+	}
+	return nil
+}
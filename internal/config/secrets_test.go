@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	encoded, err := GenerateSecretsKey()
+	require.NoError(t, err)
+	key, err := ParseSecretsKey([]byte(encoded + "\n"))
+	require.NoError(t, err)
+
+	encrypted, err := EncryptSecret(key, "secret")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(encrypted))
+	require.NotContains(t, encrypted, "secret")
+
+	plaintext, err := decryptSecret(key, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "secret", plaintext)
+
+	otherKey := make([]byte, secretsKeySize)
+	_, err = decryptSecret(otherKey, encrypted)
+	require.Error(t, err)
+
+	_, err = ParseSecretsKey([]byte("c2hvcnQ="))
+	require.Error(t, err)
+}
+
+func TestEncryptedConfig(t *testing.T) {
+	dir := t.TempDir()
+	encoded, err := GenerateSecretsKey()
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(encoded), 0600))
+	key, err := ParseSecretsKey([]byte(encoded))
+	require.NoError(t, err)
+	password, err := EncryptSecret(key, "mysql-secret")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "mysync.yaml")
+	content := `hostname: db1
+secrets_key_file: ` + keyFile + `
+mysql:
+  user: mysync
+  password: "` + password + `"
+  replication_user: repl
+  replication_password: repl
+zookeeper:
+  namespace: /mysync/test
+  hosts: [zk1:2181]
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	cfg, err := ReadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "mysql-secret", cfg.MySQL.Password)
+
+	require.NoError(t, os.Remove(keyFile))
+	_, err = ReadFromFile(path)
+	require.ErrorContains(t, err, "failed to read secrets key")
+}
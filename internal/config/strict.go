@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	unknownFieldRegex   = regexp.MustCompile(`field (.+) not found in type [\w.]+$`)
+	duplicateFieldRegex = regexp.MustCompile(`field (.+) already set in type [\w.]+$`)
+)
+
+// checkStrict rejects unknown and duplicate keys and values of wrong type,
+// reporting file and line of every problem
+func checkStrict(configFile string) error {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	var cfg Config
+	err = yaml.UnmarshalStrict(data, &cfg)
+	if err == nil {
+		for host, overrides := range cfg.HostOverrides {
+			var hostCfg Config
+			if err := hostCfg.ApplyOverrides(overrides); err != nil {
+				return fmt.Errorf("%s: invalid host_overrides for %s: %v", configFile, host, err)
+			}
+		}
+		return nil
+	}
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return fmt.Errorf("%s: %v", configFile, err)
+	}
+	problems := make([]string, 0, len(typeErr.Errors))
+	for _, problem := range typeErr.Errors {
+		problem = unknownFieldRegex.ReplaceAllString(problem, "unknown key \"$1\"")
+		problem = duplicateFieldRegex.ReplaceAllString(problem, "duplicate key \"$1\"")
+		problems = append(problems, fmt.Sprintf("%s: %s", configFile, problem))
+	}
+	return fmt.Errorf("invalid config:\n%s", strings.Join(problems, "\n"))
+}
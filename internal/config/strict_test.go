@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysync.yaml")
+	content := `hostname: db1
+critical_disk_usag: 90
+failover: true
+failover: false
+mysql:
+  user: mysync
+  prot: 3306
+failover_delay: soon
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	err := checkStrict(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), path+`: line 2: unknown key "critical_disk_usag"`)
+	require.Contains(t, err.Error(), path+`: line 4: duplicate key "failover"`)
+	require.Contains(t, err.Error(), path+`: line 7: unknown key "prot"`)
+	require.Contains(t, err.Error(), path+": line 8: cannot unmarshal !!str `soon` into time.Duration")
+}
+
+func TestHostOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mysync.yaml")
+	content := `hostname: db1
+mysql:
+  user: mysync
+  password: secret
+  replication_user: repl
+  replication_password: repl
+zookeeper:
+  namespace: /mysync/test
+  hosts: [zk1:2181]
+offline_mode_enable_lag: 1m
+host_overrides:
+  db1:
+    offline_mode_enable_lag: 5m
+    mysql:
+      port: 3307
+  db2:
+    offline_mode_enable_lag: 10m
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	cfg, err := ReadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, cfg.OfflineModeEnableLag)
+	require.Equal(t, 3307, cfg.MySQL.Port)
+	require.Equal(t, "secret", cfg.MySQL.Password)
+
+	// overridden host has the same cluster settings as others
+	other := strings.Replace(content, "hostname: db1", "hostname: db2", 1)
+	otherPath := filepath.Join(t.TempDir(), "mysync.yaml")
+	require.NoError(t, os.WriteFile(otherPath, []byte(other), 0644))
+	otherCfg, err := ReadFromFile(otherPath)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, otherCfg.OfflineModeEnableLag)
+	require.NotEmpty(t, cfg.ClusterHash)
+	require.Equal(t, cfg.ClusterHash, otherCfg.ClusterHash)
+	other = strings.Replace(other, "offline_mode_enable_lag: 1m", "offline_mode_enable_lag: 2m", 1)
+	require.NoError(t, os.WriteFile(otherPath, []byte(other), 0644))
+	otherCfg, err = ReadFromFile(otherPath)
+	require.NoError(t, err)
+	require.NotEqual(t, cfg.ClusterHash, otherCfg.ClusterHash)
+
+	content += "  db3:\n    offline_mode_enable_lagg: 1m\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	_, err = ReadFromFile(path)
+	require.ErrorContains(t, err, "invalid host_overrides for db3")
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mysync.yaml")
+	content := `hostname: db1
+mysql:
+  user: mysync
+  replication_user: repl
+  replication_password: repl
+zookeeper:
+  namespace: /mysync/test
+  hosts: [zk1:2181]
+failover_delay: 10s
+include: ["` + filepath.Join(dir, "mysync.d", "*.yaml") + `"]
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "mysync.d"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mysync.d", "10-cluster.yaml"), []byte("failover_delay: 20s\nmysql:\n  password: secret\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mysync.d", "20-role.yaml"), []byte("failover_delay: 30s\n"), 0644))
+
+	cfg, err := ReadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, cfg.FailoverDelay)
+	require.Equal(t, "secret", cfg.MySQL.Password)
+	require.Equal(t, "mysync", cfg.MySQL.User)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mysync.d", "30-typo.yaml"), []byte("failover_dealy: 30s\n"), 0644))
+	_, err = ReadFromFile(path)
+	require.ErrorContains(t, err, `30-typo.yaml: line 1: unknown key "failover_dealy"`)
+}
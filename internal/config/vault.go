@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/heetch/confita/backend"
+
+	"github.com/yandex/mysync/internal/vault"
+)
+
+// VaultConfig describes where to fetch credentials from HashiCorp Vault
+type VaultConfig struct {
+	Address     string            `config:"address" yaml:"address"`
+	TokenFile   string            `config:"token_file" yaml:"token_file"`
+	CACert      string            `config:"ca_cert" yaml:"ca_cert"`
+	Timeout     time.Duration     `config:"timeout" yaml:"timeout"`
+	MySQL       VaultSecretConfig `config:"mysql" yaml:"mysql"`
+	Replication VaultSecretConfig `config:"replication" yaml:"replication"`
+	Zookeeper   VaultSecretConfig `config:"zookeeper" yaml:"zookeeper"`
+}
+
+// VaultSecretConfig points either to KV secret or to database secret engine role
+type VaultSecretConfig struct {
+	// KV secret path, e.g. secret/data/mysync
+	KVPath      string `config:"kv_path" yaml:"kv_path"`
+	UsernameKey string `config:"username_key" yaml:"username_key"`
+	PasswordKey string `config:"password_key" yaml:"password_key"`
+	// database secret engine credentials path, e.g. database/creds/mysync
+	DatabaseCredsPath string `config:"database_creds_path" yaml:"database_creds_path"`
+}
+
+func defaultVaultConfig() VaultConfig {
+	secret := VaultSecretConfig{UsernameKey: "username", PasswordKey: "password"}
+	return VaultConfig{
+		Timeout:     10 * time.Second,
+		MySQL:       secret,
+		Replication: secret,
+		Zookeeper:   secret,
+	}
+}
+
+// NewVaultClient creates client for configured Vault
+func (cfg *Config) NewVaultClient() (*vault.Client, error) {
+	return vault.NewClient(cfg.Vault.Address, cfg.Vault.TokenFile, cfg.Vault.CACert, cfg.Vault.Timeout)
+}
+
+// FetchVaultCredentials reads credentials described by secret config
+func FetchVaultCredentials(client *vault.Client, secret VaultSecretConfig) (*vault.Credentials, error) {
+	if secret.DatabaseCredsPath != "" {
+		return client.ReadDatabaseCreds(secret.DatabaseCredsPath)
+	}
+	if secret.KVPath != "" {
+		return client.ReadKV(secret.KVPath, secret.UsernameKey, secret.PasswordKey)
+	}
+	return nil, nil
+}
+
+// vaultBackend fills credentials from Vault after config file was loaded
+type vaultBackend struct{}
+
+func (b vaultBackend) Name() string {
+	return "vault"
+}
+
+func (b vaultBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, backend.ErrNotFound
+}
+
+func (b vaultBackend) Unmarshal(ctx context.Context, to interface{}) error {
+	cfg := to.(*Config)
+	if cfg.Vault.Address == "" {
+		return nil
+	}
+	client, err := cfg.NewVaultClient()
+	if err != nil {
+		return err
+	}
+	// dynamic mysql credentials are issued by running agent only, see IssueVaultDatabaseCredentials,
+	// so CLI commands do not issue and revoke them on every invocation
+	if cfg.Vault.MySQL.DatabaseCredsPath == "" {
+		creds, err := FetchVaultCredentials(client, cfg.Vault.MySQL)
+		if err != nil {
+			return fmt.Errorf("failed to get mysql credentials from vault: %v", err)
+		}
+		if creds != nil {
+			cfg.MySQL.User, cfg.MySQL.Password = creds.Username, creds.Password
+		}
+	}
+	if cfg.Vault.Replication.DatabaseCredsPath == "" {
+		creds, err := FetchVaultCredentials(client, cfg.Vault.Replication)
+		if err != nil {
+			return fmt.Errorf("failed to get replication credentials from vault: %v", err)
+		}
+		if creds != nil {
+			cfg.MySQL.ReplicationUser, cfg.MySQL.ReplicationPassword = creds.Username, creds.Password
+		}
+	}
+	creds, err := FetchVaultCredentials(client, cfg.Vault.Zookeeper)
+	if err != nil {
+		return fmt.Errorf("failed to get zookeeper credentials from vault: %v", err)
+	}
+	if creds != nil {
+		cfg.Zookeeper.Username, cfg.Zookeeper.Password = creds.Username, creds.Password
+		cfg.ZookeeperCredentialsLease = creds.Lease
+	}
+	return nil
+}
+
+// IssueVaultDatabaseCredentials issues MySQL and replication credentials configured
+// with Vault database secret engine. Leases are kept in config to be renewed and revoked
+func (cfg *Config) IssueVaultDatabaseCredentials() error {
+	if cfg.Vault.Address == "" || (cfg.Vault.MySQL.DatabaseCredsPath == "" && cfg.Vault.Replication.DatabaseCredsPath == "") {
+		return nil
+	}
+	client, err := cfg.NewVaultClient()
+	if err != nil {
+		return err
+	}
+	if cfg.Vault.MySQL.DatabaseCredsPath != "" {
+		creds, err := client.ReadDatabaseCreds(cfg.Vault.MySQL.DatabaseCredsPath)
+		if err != nil {
+			return fmt.Errorf("failed to get mysql credentials from vault: %v", err)
+		}
+		cfg.MySQL.User, cfg.MySQL.Password = creds.Username, creds.Password
+		cfg.MySQLCredentialsLease = creds.Lease
+	}
+	if cfg.Vault.Replication.DatabaseCredsPath != "" {
+		creds, err := client.ReadDatabaseCreds(cfg.Vault.Replication.DatabaseCredsPath)
+		if err != nil {
+			return fmt.Errorf("failed to get replication credentials from vault: %v", err)
+		}
+		cfg.MySQL.ReplicationUser, cfg.MySQL.ReplicationPassword = creds.Username, creds.Password
+		cfg.ReplicationCredentialsLease = creds.Lease
+	}
+	return nil
+}
+
+// RevokeVaultLeases revokes dynamic credentials issued by Vault for this config,
+// so they do not pile up until their TTL expires
+func (cfg *Config) RevokeVaultLeases() error {
+	var leases []*vault.Lease
+	for _, lease := range []*vault.Lease{cfg.MySQLCredentialsLease, cfg.ReplicationCredentialsLease, cfg.ZookeeperCredentialsLease} {
+		if lease != nil && lease.ID != "" {
+			leases = append(leases, lease)
+		}
+	}
+	if len(leases) == 0 {
+		return nil
+	}
+	client, err := cfg.NewVaultClient()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, lease := range leases {
+		err = client.RevokeLease(lease)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke lease %s: %v", lease.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MySQLCredentials returns MySQL user and password, which may be rotated at runtime
+func (cfg *Config) MySQLCredentials() (string, string) {
+	return cfg.MySQL.User, cfg.MySQL.Password
+}
+
+// SetMySQLCredentials replaces MySQL credentials used for new connections.
+// Config shared with running agent is changed only via Holder.Update
+func (cfg *Config) SetMySQLCredentials(user, password string) {
+	cfg.MySQL.User, cfg.MySQL.Password = user, password
+}
+
+// MySQLPreviousPassword returns password, which is tried when current one is rejected
+func (cfg *Config) MySQLPreviousPassword() string {
+	return cfg.MySQL.PreviousPassword
+}
+
+// SetMySQLPreviousPassword replaces password, which is tried when current one is rejected
+func (cfg *Config) SetMySQLPreviousPassword(password string) {
+	cfg.MySQL.PreviousPassword = password
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultDatabaseCredentialsIssuedOnRequestOnly(t *testing.T) {
+	var issued atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/database/creds/mysync":
+			issued.Add(1)
+			_, _ = w.Write([]byte(`{"lease_id": "database/creds/mysync/1", "lease_duration": 3600, "renewable": true,
+				"data": {"username": "v-mysync-1", "password": "dynamic"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "token")
+	path := filepath.Join(t.TempDir(), "mysync.yaml")
+	content := `
+hostname: db1
+mysql:
+  user: mysync
+  password: static
+  replication_user: repl
+  replication_password: repl
+zookeeper:
+  namespace: /mysync/test
+  hosts: [zk1:2181]
+vault:
+  address: ` + server.URL + `
+  mysql:
+    database_creds_path: database/creds/mysync
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	// CLI commands read config, but do not need dynamic credentials
+	cfg, err := ReadFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), issued.Load())
+	require.Nil(t, cfg.MySQLCredentialsLease)
+
+	require.NoError(t, cfg.IssueVaultDatabaseCredentials())
+	require.Equal(t, int32(1), issued.Load())
+	user, password := cfg.MySQLCredentials()
+	require.Equal(t, "v-mysync-1", user)
+	require.Equal(t, "dynamic", password)
+	require.Equal(t, "database/creds/mysync/1", cfg.MySQLCredentialsLease.ID)
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yandex/mysync/internal/util"
+)
+
+// Modes of failover window
+const (
+	// FailoverWindowAllow lets automatic failover happen, it carves exception out of windows listed after it
+	FailoverWindowAllow = "allow"
+	// FailoverWindowAlert detects master failure and alerts about it, but does not perform failover
+	FailoverWindowAlert = "alert"
+	// FailoverWindowPause neither performs failover nor alerts about master failure
+	FailoverWindowPause = "pause"
+)
+
+// maxFailoverWindowDuration limits lookup of window start
+const maxFailoverWindowDuration = 7 * 24 * time.Hour
+
+// FailoverWindowConfig describes recurring time window, when automatic failover is restricted.
+// The first active window of failover_windows wins, failover is allowed outside of windows.
+type FailoverWindowConfig struct {
+	Name string `config:"name" yaml:"name"`
+	// cron expression of window start: minute, hour, day of month, month, day of week
+	Schedule string        `config:"schedule" yaml:"schedule"`
+	Duration time.Duration `config:"duration" yaml:"duration"`
+	// IANA time zone of schedule, local time of host if empty
+	Timezone string `config:"timezone" yaml:"timezone"`
+	// one of allow, alert, pause
+	Mode string `config:"mode" yaml:"mode"`
+	// failover is performed anyway, when master is down longer than that, 0 means never
+	HardDownAfter time.Duration `config:"hard_down_after" yaml:"hard_down_after"`
+}
+
+// ActiveSince returns start of window, which is active at now, or zero time if window is not active
+func (w *FailoverWindowConfig) ActiveSince(now time.Time) (time.Time, error) {
+	schedule, err := util.ParseCron(w.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	location, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.LastFireWithin(now.In(location), w.Duration), nil
+}
+
+// ActiveFailoverWindow returns the first window of failover_windows active at now, or nil
+func (cfg *Config) ActiveFailoverWindow(now time.Time) (*FailoverWindowConfig, error) {
+	for i := range cfg.FailoverWindows {
+		window := &cfg.FailoverWindows[i]
+		since, err := window.ActiveSince(now)
+		if err != nil {
+			return nil, fmt.Errorf("failover window %q: %v", window.Name, err)
+		}
+		if !since.IsZero() {
+			return window, nil
+		}
+	}
+	return nil, nil
+}
+
+func (cfg *Config) validateFailoverWindows() error {
+	for _, window := range cfg.FailoverWindows {
+		switch window.Mode {
+		case FailoverWindowAllow, FailoverWindowAlert, FailoverWindowPause:
+		default:
+			return fmt.Errorf("failover window %q: unknown mode %q, expected one of allow, alert, pause", window.Name, window.Mode)
+		}
+		if window.Duration <= 0 || window.Duration > maxFailoverWindowDuration {
+			return fmt.Errorf("failover window %q: duration should be within (0, %s]", window.Name, maxFailoverWindowDuration)
+		}
+		if window.HardDownAfter < 0 {
+			return fmt.Errorf("failover window %q: hard_down_after should be >= 0", window.Name)
+		}
+		if _, err := window.ActiveSince(time.Now()); err != nil {
+			return fmt.Errorf("failover window %q: %v", window.Name, err)
+		}
+	}
+	return nil
+}
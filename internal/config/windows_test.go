@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverWindows(t *testing.T) {
+	cfg, err := DefaultConfig()
+	require.NoError(t, err)
+	cfg.FailoverWindows = []FailoverWindowConfig{
+		{Name: "weekend", Schedule: "0 0 * * 6,0", Duration: 24 * time.Hour, Timezone: "UTC", Mode: FailoverWindowAllow},
+		{Name: "backup", Schedule: "30 1 * * *", Duration: 2 * time.Hour, Timezone: "UTC", Mode: FailoverWindowAlert},
+		{Name: "release", Schedule: "*/20 9-17 1-7 * 1", Duration: 10 * time.Minute, Timezone: "Europe/Moscow", Mode: FailoverWindowPause},
+	}
+	require.NoError(t, cfg.validateFailoverWindows())
+	active := func(now string) string {
+		ts, err := time.Parse(time.RFC3339, now)
+		require.NoError(t, err)
+		window, err := cfg.ActiveFailoverWindow(ts)
+		require.NoError(t, err)
+		if window == nil {
+			return ""
+		}
+		return window.Name
+	}
+	// Wednesday
+	require.Equal(t, "", active("2026-10-14T01:29:59Z"))
+	require.Equal(t, "backup", active("2026-10-14T01:30:00Z"))
+	require.Equal(t, "backup", active("2026-10-14T03:29:59Z"))
+	require.Equal(t, "", active("2026-10-14T03:30:00Z"))
+	// Saturday, allow window is listed first
+	require.Equal(t, "weekend", active("2026-10-17T02:00:00Z"))
+	// Monday 5th, 12:45 in Moscow; both day of month and day of week are restricted, either matches
+	require.Equal(t, "release", active("2026-10-05T09:45:00Z"))
+	require.Equal(t, "", active("2026-10-05T09:50:00Z"))
+	require.Equal(t, "release", active("2026-10-12T09:45:00Z"))
+	require.Equal(t, "release", active("2026-10-02T09:45:00Z"))
+	require.Equal(t, "", active("2026-10-13T09:45:00Z"))
+
+	for _, window := range []FailoverWindowConfig{
+		{Schedule: "0 0 * *", Duration: time.Hour, Mode: FailoverWindowPause},
+		{Schedule: "60 0 * * *", Duration: time.Hour, Mode: FailoverWindowPause},
+		{Schedule: "0 0 * * */0", Duration: time.Hour, Mode: FailoverWindowPause},
+		{Schedule: "0 0 * * *", Duration: 0, Mode: FailoverWindowPause},
+		{Schedule: "0 0 * * *", Duration: time.Hour, Mode: "deny"},
+		{Schedule: "0 0 * * *", Duration: time.Hour, Mode: FailoverWindowPause, Timezone: "Mars/Olympus"},
+	} {
+		cfg.FailoverWindows = []FailoverWindowConfig{window}
+		require.Error(t, cfg.validateFailoverWindows(), window)
+	}
+}
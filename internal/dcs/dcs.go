@@ -27,9 +27,18 @@ type DCS interface {
 	Delete(path string) error
 	GetTree(path string) (interface{}, error)
 	GetChildren(path string) ([]string, error)
+	SessionInfo() SessionInfo
 	Close()
 }
 
+// SessionInfo describes connection to DCS server for diagnostics
+type SessionInfo struct {
+	Connected bool   `json:"connected"`
+	State     string `json:"state"`
+	SessionID int64  `json:"session_id"`
+	Server    string `json:"server"`
+}
+
 var (
 	// ErrExists means that node being created already exists
 	ErrExists = errors.New("key already exists")
@@ -244,6 +244,15 @@ func (z *zkDCS) IsConnected() bool {
 	return z.isConnected
 }
 
+func (z *zkDCS) SessionInfo() SessionInfo {
+	return SessionInfo{
+		Connected: z.IsConnected(),
+		State:     z.conn.State().String(),
+		SessionID: z.conn.SessionID(),
+		Server:    z.conn.Server(),
+	}
+}
+
 func (z *zkDCS) WaitConnected(timeout time.Duration) bool {
 	z.connectedLock.Lock()
 	if z.isConnected {
@@ -492,6 +501,9 @@ func (z *zkDCS) Get(path string, dest interface{}) error {
 func (z *zkDCS) GetTree(path string) (interface{}, error) {
 	fullPath := z.buildFullPath(path)
 	children, _, err := z.retryChildren(fullPath)
+	if err == zk.ErrNoNode {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		z.logger.Errorf("failed to get children of %s: %v", fullPath, err)
 		return nil, err
@@ -499,6 +511,9 @@ func (z *zkDCS) GetTree(path string) (interface{}, error) {
 	if len(children) == 0 {
 		var data []byte
 		data, _, err = z.retryGet(fullPath)
+		if err == zk.ErrNoNode {
+			return nil, ErrNotFound
+		}
 		if err != nil {
 			z.logger.Errorf("failed to get data of %s: %v", fullPath, err)
 			return nil, err
@@ -0,0 +1,63 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// consul registers service in local Consul agent with TTL check updated by mysync
+type consul struct {
+	cfg    config.DiscoveryConfig
+	client *client
+}
+
+func (c *consul) serviceID(host string) string {
+	return fmt.Sprintf("%s-%s", c.cfg.Service, host)
+}
+
+func (c *consul) Register(ctx context.Context, reg Registration) error {
+	id := c.serviceID(reg.Host)
+	tags := []string{reg.Role}
+	if reg.Maintenance {
+		tags = append(tags, "maintenance")
+	}
+	service := map[string]interface{}{
+		"ID":      id,
+		"Name":    c.cfg.Service,
+		"Tags":    tags,
+		"Address": reg.Address,
+		"Port":    reg.Port,
+		"Meta":    map[string]string{"role": reg.Role, "host": reg.Host, "maintenance": strconv.FormatBool(reg.Maintenance)},
+		"Check": map[string]interface{}{
+			"CheckID":                        "service:" + id,
+			"Name":                           "mysync",
+			"TTL":                            c.cfg.TTL.String(),
+			"DeregisterCriticalServiceAfter": (10 * c.cfg.TTL).String(),
+		},
+	}
+	err := c.client.call(ctx, http.MethodPut, "/v1/agent/service/register", service, nil)
+	if err != nil {
+		return err
+	}
+	status := "passing"
+	if !reg.Healthy {
+		status = "critical"
+	}
+	output := fmt.Sprintf("%s is %s", reg.Host, reg.Role)
+	if reg.Maintenance {
+		output += " in maintenance"
+	}
+	if reg.Note != "" {
+		output += ": " + reg.Note
+	}
+	check := map[string]string{"Status": status, "Output": output}
+	return c.client.call(ctx, http.MethodPut, "/v1/agent/check/update/service:"+id, check, nil)
+}
+
+func (c *consul) Deregister(ctx context.Context, host string) error {
+	return c.client.call(ctx, http.MethodPut, "/v1/agent/service/deregister/"+c.serviceID(host), nil, nil)
+}
@@ -0,0 +1,99 @@
+// Package discovery registers MySQL hosts with their roles in service catalogs
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// Roles of registered hosts
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Registration describes host as seen by mysync
+type Registration struct {
+	Host    string `json:"host"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	Role    string `json:"role"`
+	Healthy bool   `json:"healthy"`
+	// cluster is in maintenance, role is the one host had when maintenance started,
+	// so clients still find primary while mysync does not manage it
+	Maintenance bool `json:"maintenance,omitempty"`
+	// reason of host being unhealthy
+	Note string `json:"note,omitempty"`
+}
+
+// Registrar publishes registration of the local host to service catalog
+type Registrar interface {
+	// Register creates or refreshes registration, it should be called more often than ttl
+	Register(ctx context.Context, reg Registration) error
+	Deregister(ctx context.Context, host string) error
+}
+
+// New creates registrar for catalog configured by cfg
+func New(cfg config.DiscoveryConfig) (Registrar, error) {
+	client := &client{endpoint: strings.TrimSuffix(cfg.Endpoint, "/"), http: &http.Client{Timeout: cfg.Timeout}}
+	switch cfg.Type {
+	case config.DiscoveryConsul:
+		client.headers = map[string]string{}
+		if cfg.Token != "" {
+			client.headers["X-Consul-Token"] = cfg.Token
+		}
+		return &consul{cfg: cfg, client: client}, nil
+	case config.DiscoveryEtcd:
+		return &etcd{cfg: cfg, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown discovery type %q", cfg.Type)
+}
+
+type client struct {
+	endpoint string
+	headers  map[string]string
+	http     *http.Client
+}
+
+// call sends request with JSON body and decodes JSON response into result, if it is not nil
+func (c *client) call(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if result != nil {
+		return json.Unmarshal(data, result)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func catalog(t *testing.T, handler func(path string, body map[string]interface{}) interface{}) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		_ = json.NewEncoder(w).Encode(handler(r.URL.Path, body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func testConfig(kind, endpoint string) config.DiscoveryConfig {
+	return config.DiscoveryConfig{
+		Type:       kind,
+		Endpoint:   endpoint,
+		Service:    "mysql",
+		EtcdPrefix: "/mysync/services",
+		TTL:        30 * time.Second,
+		Timeout:    time.Second,
+	}
+}
+
+func TestConsul(t *testing.T) {
+	requests := map[string]map[string]interface{}{}
+	endpoint := catalog(t, func(path string, body map[string]interface{}) interface{} {
+		requests[path] = body
+		return nil
+	})
+	registrar, err := New(testConfig(config.DiscoveryConsul, endpoint))
+	require.NoError(t, err)
+	reg := Registration{Host: "mysql1", Address: "10.0.0.1", Port: 3306, Role: RoleReplica, Note: "host is not active"}
+	require.NoError(t, registrar.Register(context.Background(), reg))
+
+	service := requests["/v1/agent/service/register"]
+	require.Equal(t, "mysql-mysql1", service["ID"])
+	require.Equal(t, []interface{}{"replica"}, service["Tags"])
+	require.Equal(t, "30s", service["Check"].(map[string]interface{})["TTL"])
+	require.Equal(t, map[string]interface{}{"Status": "critical", "Output": "mysql1 is replica: host is not active"},
+		requests["/v1/agent/check/update/service:mysql-mysql1"])
+
+	// primary in maintenance is still passing
+	reg = Registration{Host: "mysql1", Address: "10.0.0.1", Port: 3306, Role: RolePrimary, Healthy: true, Maintenance: true}
+	require.NoError(t, registrar.Register(context.Background(), reg))
+	require.Equal(t, []interface{}{"primary", "maintenance"}, requests["/v1/agent/service/register"]["Tags"])
+	require.Equal(t, map[string]interface{}{"Status": "passing", "Output": "mysql1 is primary in maintenance"},
+		requests["/v1/agent/check/update/service:mysql-mysql1"])
+
+	require.NoError(t, registrar.Deregister(context.Background(), "mysql1"))
+	require.Contains(t, requests, "/v1/agent/service/deregister/mysql-mysql1")
+}
+
+func TestEtcd(t *testing.T) {
+	grants := 0
+	var put map[string]interface{}
+	endpoint := catalog(t, func(path string, body map[string]interface{}) interface{} {
+		switch path {
+		case "/v3/lease/grant":
+			grants++
+			return map[string]string{"ID": "42", "TTL": "30"}
+		case "/v3/lease/keepalive":
+			return map[string]interface{}{"result": map[string]string{"ID": "42", "TTL": "30"}}
+		case "/v3/kv/put":
+			put = body
+		}
+		return map[string]string{}
+	})
+	registrar, err := New(testConfig(config.DiscoveryEtcd, endpoint))
+	require.NoError(t, err)
+	reg := Registration{Host: "mysql1", Address: "10.0.0.1", Port: 3306, Role: RolePrimary, Healthy: true}
+	require.NoError(t, registrar.Register(context.Background(), reg))
+	require.NoError(t, registrar.Register(context.Background(), reg))
+	require.Equal(t, 1, grants)
+
+	key, _ := base64.StdEncoding.DecodeString(put["key"].(string))
+	require.Equal(t, "/mysync/services/mysql/mysql1", string(key))
+	require.Equal(t, "42", put["lease"])
+	value, _ := base64.StdEncoding.DecodeString(put["value"].(string))
+	var record etcdRecord
+	require.NoError(t, json.Unmarshal(value, &record))
+	require.Equal(t, reg, record.Registration)
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// etcd keeps registration under <prefix>/<service>/<host> bound to a lease,
+// so it disappears when mysync stops refreshing it
+type etcd struct {
+	cfg     config.DiscoveryConfig
+	client  *client
+	leaseID string
+}
+
+// etcdRecord is a value of registration key
+type etcdRecord struct {
+	Registration
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func (e *etcd) key(host string) string {
+	return strings.TrimSuffix(e.cfg.EtcdPrefix, "/") + "/" + e.cfg.Service + "/" + host
+}
+
+// keepLease refreshes current lease or grants new one if it expired
+func (e *etcd) keepLease(ctx context.Context) error {
+	if e.leaseID != "" {
+		var resp struct {
+			Result struct {
+				TTL string `json:"TTL"`
+			} `json:"result"`
+		}
+		err := e.client.call(ctx, http.MethodPost, "/v3/lease/keepalive", map[string]string{"ID": e.leaseID}, &resp)
+		if err == nil && resp.Result.TTL != "" && resp.Result.TTL != "0" {
+			return nil
+		}
+	}
+	var resp struct {
+		ID string `json:"ID"`
+	}
+	ttl := strconv.Itoa(int(e.cfg.TTL.Seconds()))
+	err := e.client.call(ctx, http.MethodPost, "/v3/lease/grant", map[string]string{"TTL": ttl}, &resp)
+	if err != nil {
+		return err
+	}
+	e.leaseID = resp.ID
+	return nil
+}
+
+func (e *etcd) Register(ctx context.Context, reg Registration) error {
+	if err := e.keepLease(ctx); err != nil {
+		return err
+	}
+	value, err := json.Marshal(etcdRecord{Registration: reg, UpdatedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	put := map[string]string{"key": b64(e.key(reg.Host)), "value": b64(string(value)), "lease": e.leaseID}
+	return e.client.call(ctx, http.MethodPost, "/v3/kv/put", put, nil)
+}
+
+func (e *etcd) Deregister(ctx context.Context, host string) error {
+	return e.client.call(ctx, http.MethodPost, "/v3/kv/deleterange", map[string]string{"key": b64(e.key(host))}, nil)
+}
@@ -0,0 +1,109 @@
+// Package dnsupdate points DNS records to a host using one of supported DNS providers
+package dnsupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// Provider replaces all values of record with given ones
+type Provider interface {
+	Update(ctx context.Context, record, recordType string, values []string, ttl int) error
+}
+
+// New creates provider configured by cfg
+func New(cfg config.DNSConfig) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Provider {
+	case config.DNSProviderRoute53:
+		return &route53{cfg: cfg, client: client}, nil
+	case config.DNSProviderEtcd:
+		return &etcd{cfg: cfg, client: client}, nil
+	case config.DNSProviderPowerDNS:
+		return &powerDNS{cfg: cfg, client: client}, nil
+	case config.DNSProviderRFC2136:
+		return &rfc2136{cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown dns provider %q", cfg.Provider)
+}
+
+// Lookup queries record of given type directly from server, it returns sorted values of answer
+func Lookup(ctx context.Context, server, record, recordType string) ([]string, error) {
+	qtype, ok := dns.StringToType[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unknown record type %s", recordType)
+	}
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(record), qtype)
+	// avoid cached answers of recursive resolvers
+	msg.RecursionDesired = false
+	resp, _, err := new(dns.Client).ExchangeContext(ctx, msg, server)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("query %s %s: %s", record, recordType, dns.RcodeToString[resp.Rcode])
+	}
+	var values []string
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qtype {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.CNAME:
+			values = append(values, rr.Target)
+		case *dns.A:
+			values = append(values, rr.A.String())
+		case *dns.AAAA:
+			values = append(values, rr.AAAA.String())
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// Normalize makes values comparable with result of Lookup
+func Normalize(recordType string, values []string) []string {
+	normalized := make([]string, len(values))
+	for i, value := range values {
+		if recordType == "CNAME" {
+			value = dns.Fqdn(value)
+		}
+		normalized[i] = value
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return body, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+}
+
+// now is replaced in tests
+var now = time.Now
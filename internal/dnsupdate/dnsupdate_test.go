@@ -0,0 +1,178 @@
+package dnsupdate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func recordingServer(t *testing.T) (*httptest.Server, *[]recordedRequest) {
+	var mu sync.Mutex
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{r.Method, r.URL.Path, r.Header, string(body)})
+		mu.Unlock()
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testConfig(provider, endpoint string) config.DNSConfig {
+	return config.DNSConfig{
+		Provider:      provider,
+		Record:        "writer.db.example.com",
+		RecordType:    "CNAME",
+		TTL:           30,
+		Endpoint:      endpoint,
+		Zone:          "example.com",
+		Key:           "key",
+		Secret:        "secret",
+		Region:        "us-east-1",
+		TSIGAlgorithm: dns.HmacSHA256,
+		EtcdPrefix:    "/skydns",
+		Timeout:       time.Second,
+	}
+}
+
+func TestPowerDNS(t *testing.T) {
+	server, requests := recordingServer(t)
+	provider, err := New(testConfig(config.DNSProviderPowerDNS, server.URL))
+	require.NoError(t, err)
+	err = provider.Update(context.Background(), "writer.db.example.com", "CNAME", []string{"mysql2.example.com"}, 30)
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	require.Equal(t, http.MethodPatch, req.method)
+	require.Equal(t, "/api/v1/servers/localhost/zones/example.com.", req.path)
+	require.Equal(t, "secret", req.header.Get("X-API-Key"))
+	require.JSONEq(t, `{"rrsets":[{"name":"writer.db.example.com.","type":"CNAME","ttl":30,"changetype":"REPLACE",
+		"records":[{"content":"mysql2.example.com.","disabled":false}]}]}`, req.body)
+}
+
+func TestEtcd(t *testing.T) {
+	require.Equal(t, "/skydns/com/example/db/writer", etcdPath("/skydns/", "writer.db.example.com."))
+
+	server, requests := recordingServer(t)
+	provider, err := New(testConfig(config.DNSProviderEtcd, server.URL))
+	require.NoError(t, err)
+	err = provider.Update(context.Background(), "writer.db.example.com", "A", []string{"10.0.0.2", "10.0.0.1"}, 30)
+	require.NoError(t, err)
+
+	var puts []string
+	for _, req := range *requests {
+		var body map[string]string
+		require.NoError(t, json.Unmarshal([]byte(req.body), &body))
+		key, _ := base64.StdEncoding.DecodeString(body["key"])
+		value, _ := base64.StdEncoding.DecodeString(body["value"])
+		if req.path == "/v3/kv/put" {
+			puts = append(puts, string(key)+"="+string(value))
+		}
+	}
+	require.Equal(t, "/v3/kv/deleterange", (*requests)[0].path)
+	require.Equal(t, []string{
+		`/skydns/com/example/db/writer/x1={"host":"10.0.0.1","ttl":30}`,
+		`/skydns/com/example/db/writer/x2={"host":"10.0.0.2","ttl":30}`,
+	}, puts)
+}
+
+func TestRoute53(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	server, requests := recordingServer(t)
+	cfg := testConfig(config.DNSProviderRoute53, server.URL)
+	cfg.Zone = "/hostedzone/Z123"
+	provider, err := New(cfg)
+	require.NoError(t, err)
+	err = provider.Update(context.Background(), "writer.db.example.com", "CNAME", []string{"mysql2.example.com"}, 30)
+	require.NoError(t, err)
+
+	req := (*requests)[0]
+	require.Equal(t, "/2013-04-01/hostedzone/Z123/rrset/", req.path)
+	require.Equal(t, "20240102T030405Z", req.header.Get("X-Amz-Date"))
+	require.True(t, strings.HasPrefix(req.header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=key/20240102/us-east-1/route53/aws4_request, SignedHeaders=host;x-amz-date, Signature="))
+	require.Contains(t, req.body, "<Change><Action>UPSERT</Action><ResourceRecordSet><Name>writer.db.example.com.</Name>"+
+		"<Type>CNAME</Type><TTL>30</TTL><ResourceRecords><ResourceRecord><Value>mysql2.example.com.</Value>")
+}
+
+func TestRFC2136AndLookup(t *testing.T) {
+	var mu sync.Mutex
+	records := map[string][]dns.RR{}
+	secret := base64.StdEncoding.EncodeToString([]byte("secret"))
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		mu.Lock()
+		defer mu.Unlock()
+		if req.Opcode == dns.OpcodeUpdate {
+			if req.IsTsig() == nil || w.TsigStatus() != nil {
+				resp.Rcode = dns.RcodeNotAuth
+			} else {
+				for _, rr := range req.Ns {
+					if rr.Header().Class == dns.ClassANY {
+						delete(records, rr.Header().Name)
+					} else {
+						records[rr.Header().Name] = append(records[rr.Header().Name], rr)
+					}
+				}
+			}
+			resp.SetTsig("key.", dns.HmacSHA256, 300, time.Now().Unix())
+		} else {
+			resp.Answer = records[req.Question[0].Name]
+		}
+		_ = w.WriteMsg(resp)
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	acceptUpdates := func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept }
+	tcp := &dns.Server{Listener: listener, Handler: handler, TsigSecret: map[string]string{"key.": secret}, MsgAcceptFunc: acceptUpdates}
+	go func() { _ = tcp.ActivateAndServe() }()
+	defer func() { _ = tcp.Shutdown() }()
+	packet, err := net.ListenPacket("udp", listener.Addr().String())
+	require.NoError(t, err)
+	udp := &dns.Server{PacketConn: packet, Handler: handler}
+	go func() { _ = udp.ActivateAndServe() }()
+	defer func() { _ = udp.Shutdown() }()
+
+	cfg := testConfig(config.DNSProviderRFC2136, listener.Addr().String())
+	cfg.Secret = base64.StdEncoding.EncodeToString([]byte("wrong"))
+	provider, err := New(cfg)
+	require.NoError(t, err)
+	err = provider.Update(context.Background(), "writer.db.example.com", "CNAME", []string{"mysql1.example.com"}, 30)
+	require.Error(t, err)
+
+	cfg.Secret = secret
+	provider, err = New(cfg)
+	require.NoError(t, err)
+	err = provider.Update(context.Background(), "writer.db.example.com", "CNAME", []string{"mysql2.example.com"}, 30)
+	require.NoError(t, err)
+
+	values, err := Lookup(context.Background(), listener.Addr().String(), "writer.db.example.com", "CNAME")
+	require.NoError(t, err)
+	require.Equal(t, Normalize("CNAME", []string{"mysql2.example.com"}), values)
+}
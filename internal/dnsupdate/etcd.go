@@ -0,0 +1,68 @@
+package dnsupdate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// etcd updates records served by CoreDNS etcd plugin using etcd v3 JSON gateway
+type etcd struct {
+	cfg    config.DNSConfig
+	client *http.Client
+}
+
+// etcdPath converts record name to CoreDNS key: writer.db.example.com -> /skydns/com/example/db/writer
+func etcdPath(prefix, record string) string {
+	labels := dns.SplitDomainName(record)
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.Join(labels, "/")
+}
+
+func (e *etcd) call(ctx context.Context, method string, request map[string]string) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := newRequest(ctx, http.MethodPost, strings.TrimSuffix(e.cfg.Endpoint, "/")+"/v3/kv/"+method, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = doRequest(e.client, req)
+	return err
+}
+
+func (e *etcd) Update(ctx context.Context, record, recordType string, values []string, ttl int) error {
+	key := etcdPath(e.cfg.EtcdPrefix, record)
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	// CoreDNS serves all values stored under the record key, so stale ones are removed first
+	err := e.call(ctx, "deleterange", map[string]string{"key": b64(key + "/"), "range_end": b64(key + "0")})
+	if err != nil {
+		return err
+	}
+	err = e.call(ctx, "deleterange", map[string]string{"key": b64(key)})
+	if err != nil {
+		return err
+	}
+	for i, value := range Normalize(recordType, values) {
+		service, err := json.Marshal(map[string]interface{}{"host": strings.TrimSuffix(value, "."), "ttl": ttl})
+		if err != nil {
+			return err
+		}
+		err = e.call(ctx, "put", map[string]string{"key": b64(fmt.Sprintf("%s/x%d", key, i+1)), "value": b64(string(service))})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package dnsupdate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// powerDNS updates records via PowerDNS authoritative server HTTP API
+type powerDNS struct {
+	cfg    config.DNSConfig
+	client *http.Client
+}
+
+type powerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type powerDNSRRSet struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	TTL        int              `json:"ttl"`
+	ChangeType string           `json:"changetype"`
+	Records    []powerDNSRecord `json:"records"`
+}
+
+func (p *powerDNS) Update(ctx context.Context, record, recordType string, values []string, ttl int) error {
+	rrset := powerDNSRRSet{Name: dns.Fqdn(record), Type: recordType, TTL: ttl, ChangeType: "REPLACE"}
+	for _, value := range Normalize(recordType, values) {
+		rrset.Records = append(rrset.Records, powerDNSRecord{Content: value})
+	}
+	body, err := json.Marshal(map[string][]powerDNSRRSet{"rrsets": {rrset}})
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/v1/servers/localhost/zones/%s", strings.TrimSuffix(p.cfg.Endpoint, "/"), dns.Fqdn(p.cfg.Zone))
+	req, err := newRequest(ctx, http.MethodPatch, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", p.cfg.Secret)
+	_, err = doRequest(p.client, req)
+	return err
+}
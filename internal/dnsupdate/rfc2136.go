@@ -0,0 +1,52 @@
+package dnsupdate
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// rfc2136 updates records with DNS UPDATE messages, optionally signed with TSIG
+type rfc2136 struct {
+	cfg config.DNSConfig
+}
+
+func (r *rfc2136) Update(ctx context.Context, record, recordType string, values []string, ttl int) error {
+	name := dns.Fqdn(record)
+	qtype := dns.StringToType[recordType]
+	msg := new(dns.Msg)
+	msg.SetUpdate(dns.Fqdn(r.cfg.Zone))
+	msg.RemoveRRset([]dns.RR{&dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: qtype, Class: dns.ClassINET}}})
+	var rrs []dns.RR
+	for _, value := range Normalize(recordType, values) {
+		hdr := dns.RR_Header{Name: name, Rrtype: qtype, Class: dns.ClassINET, Ttl: uint32(ttl)}
+		switch recordType {
+		case "CNAME":
+			rrs = append(rrs, &dns.CNAME{Hdr: hdr, Target: value})
+		case "A":
+			rrs = append(rrs, &dns.A{Hdr: hdr, A: net.ParseIP(value)})
+		case "AAAA":
+			rrs = append(rrs, &dns.AAAA{Hdr: hdr, AAAA: net.ParseIP(value)})
+		}
+	}
+	msg.Insert(rrs)
+
+	client := &dns.Client{Net: "tcp", Timeout: r.cfg.Timeout}
+	if r.cfg.Key != "" {
+		key := dns.Fqdn(r.cfg.Key)
+		client.TsigSecret = map[string]string{key: r.cfg.Secret}
+		msg.SetTsig(key, dns.Fqdn(r.cfg.TSIGAlgorithm), 300, now().Unix())
+	}
+	resp, _, err := client.ExchangeContext(ctx, msg, r.cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("update of %s rejected by %s: %s", name, r.cfg.Endpoint, dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
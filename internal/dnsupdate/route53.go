@@ -0,0 +1,118 @@
+package dnsupdate
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+const (
+	route53DefaultEndpoint = "https://route53.amazonaws.com"
+	route53Namespace       = "https://route53.amazonaws.com/doc/2013-04-01/"
+)
+
+// route53 updates records via AWS Route53 API, requests are signed with AWS Signature Version 4
+type route53 struct {
+	cfg    config.DNSConfig
+	client *http.Client
+}
+
+type route53Value struct {
+	Value string `xml:"Value"`
+}
+
+type route53RecordChange struct {
+	Action string         `xml:"Action"`
+	Name   string         `xml:"ResourceRecordSet>Name"`
+	Type   string         `xml:"ResourceRecordSet>Type"`
+	TTL    int            `xml:"ResourceRecordSet>TTL"`
+	Values []route53Value `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord"`
+}
+
+type route53ChangeRequest struct {
+	XMLName xml.Name              `xml:"ChangeResourceRecordSetsRequest"`
+	Xmlns   string                `xml:"xmlns,attr"`
+	Comment string                `xml:"ChangeBatch>Comment"`
+	Changes []route53RecordChange `xml:"ChangeBatch>Changes>Change"`
+}
+
+func (r *route53) Update(ctx context.Context, record, recordType string, values []string, ttl int) error {
+	upsert := route53RecordChange{Action: "UPSERT", Name: dns.Fqdn(record), Type: recordType, TTL: ttl}
+	for _, value := range Normalize(recordType, values) {
+		upsert.Values = append(upsert.Values, route53Value{value})
+	}
+	change := route53ChangeRequest{Xmlns: route53Namespace, Comment: "updated by mysync", Changes: []route53RecordChange{upsert}}
+	body, err := xml.Marshal(change)
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+
+	endpoint := r.cfg.Endpoint
+	if endpoint == "" {
+		endpoint = route53DefaultEndpoint
+	}
+	zone := strings.TrimPrefix(r.cfg.Zone, "/hostedzone/")
+	url := fmt.Sprintf("%s/2013-04-01/hostedzone/%s/rrset/", strings.TrimSuffix(endpoint, "/"), zone)
+	req, err := newRequest(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	signV4(req, body, r.cfg.Key, r.cfg.Secret, r.cfg.Region, "route53")
+	_, err = doRequest(r.client, req)
+	return err
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signV4 adds AWS Signature Version 4 of request without query parameters
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region, service string) {
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	signedHeaders := "host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
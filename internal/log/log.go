@@ -114,14 +114,26 @@ func (l *Logger) ReOpenOnSignal(sig syscall.Signal) {
 	}()
 }
 
+// SetLevel changes logging level of running logger
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.m.Lock()
+	l.lvl = lvl
+	l.m.Unlock()
+	return nil
+}
+
 func (l *Logger) printf(lvl Level, msg string, args ...interface{}) {
+	l.m.Lock()
+	defer l.m.Unlock()
 	if lvl < l.lvl {
 		return
 	}
 	data := fmt.Sprintf("%s %s: ", time.Now().Format(timeFormat), lvl) + fmt.Sprintf(msg, args...) + "\n"
-	l.m.Lock()
 	_, _ = l.fh.Write([]byte(data))
-	l.m.Unlock()
 }
 
 func (l *Logger) Debug(msg string) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/yandex/mysync/internal/config"
 	"github.com/yandex/mysync/internal/dcs"
@@ -15,12 +16,13 @@ import (
 // Cluster is a simple collection, containing set of MySQL ha_nodes
 type Cluster struct {
 	sync.Mutex
-	config       *config.Config
+	config       *config.Holder
 	logger       *log.Logger
 	local        *Node
 	dcs          dcs.DCS
 	haNodes      map[string]*Node
 	cascadeNodes map[string]*Node
+	queryDelay   func(host string) time.Duration
 }
 
 func (c *Cluster) IsHAHost(hostname string) bool {
@@ -98,7 +100,7 @@ func (c *Cluster) GetClusterCascadeHostsFromDcs() (map[string]string, error) {
 
 func (c *Cluster) registerLocalNode() error {
 	if c.local == nil {
-		node, err := NewNode(c.config, c.logger, c.config.Hostname)
+		node, err := c.newNode(c.config.Get().Hostname)
 		if err != nil {
 			c.Close()
 			return fmt.Errorf("failed to configure local node due (%v)", err)
@@ -109,8 +111,34 @@ func (c *Cluster) registerLocalNode() error {
 	return nil
 }
 
+func (c *Cluster) newNode(host string) (*Node, error) {
+	node, err := NewNode(c.config, c.logger, host)
+	if err != nil {
+		return nil, err
+	}
+	node.queryDelay = c.queryDelay
+	return node, nil
+}
+
+// SetQueryDelay makes queries to host wait for duration returned by delay before execution.
+// It is intended for fault injection in tests and should be called before any query is run
+func (c *Cluster) SetQueryDelay(delay func(host string) time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.queryDelay = delay
+	if c.local != nil {
+		c.local.queryDelay = delay
+	}
+	for _, node := range c.haNodes {
+		node.queryDelay = delay
+	}
+	for _, node := range c.cascadeNodes {
+		node.queryDelay = delay
+	}
+}
+
 // NewCluster connects (lazy) to MySQL ha_nodes and returns new Cluster
-func NewCluster(config *config.Config, logger *log.Logger, dcs dcs.DCS) (*Cluster, error) {
+func NewCluster(config *config.Holder, logger *log.Logger, dcs dcs.DCS) (*Cluster, error) {
 	c := &Cluster{
 		config:       config,
 		logger:       logger,
@@ -119,7 +147,7 @@ func NewCluster(config *config.Config, logger *log.Logger, dcs dcs.DCS) (*Cluste
 		local:        nil,
 		dcs:          dcs,
 	}
-	err := RegisterTLSConfig(config)
+	err := RegisterTLSConfig(config.Get())
 	if err != nil {
 		return nil, err
 	}
@@ -189,10 +217,11 @@ func (c *Cluster) updateHAHostsInfo() error {
 			var node *Node
 			if c.local.host == host {
 				node = c.local
-			} else if node, err = NewNode(c.config, c.logger, host); err != nil {
+			} else if node, err = c.newNode(host); err != nil {
 				return err
 			}
 			c.haNodes[node.Host()] = node
+			c.logger.Infof("HA node %s joined the cluster", host)
 		}
 	}
 	// we delete hosts which are no longer in dcs
@@ -204,6 +233,7 @@ func (c *Cluster) updateHAHostsInfo() error {
 				}
 			}
 			delete(c.haNodes, hostname)
+			c.logger.Infof("HA node %s left the cluster", hostname)
 		}
 	}
 	return nil
@@ -226,10 +256,11 @@ func (c *Cluster) updateCascadeHostsInfo() error {
 			var node *Node
 			if c.local.host == host {
 				node = c.local
-			} else if node, err = NewNode(c.config, c.logger, host); err != nil {
+			} else if node, err = c.newNode(host); err != nil {
 				return err
 			}
 			c.cascadeNodes[node.Host()] = node
+			c.logger.Infof("cascade node %s joined the cluster", host)
 		}
 	}
 	// we delete hosts which are no longer in dcs
@@ -241,6 +272,7 @@ func (c *Cluster) updateCascadeHostsInfo() error {
 				}
 			}
 			delete(c.cascadeNodes, hostname)
+			c.logger.Infof("cascade node %s left the cluster", hostname)
 		}
 	}
 	return nil
@@ -289,7 +321,7 @@ func (c *Cluster) AllNodeHosts() []string {
 
 // PingNode checks connection to host without adding it to cluster
 func (c *Cluster) PingNode(host string) (bool, error) {
-	node, err := NewNode(c.config, c.logger, host)
+	node, err := c.newNode(host)
 	if err != nil {
 		return false, err
 	}
@@ -2,8 +2,18 @@ package mysql
 
 const (
 	commandStatus = "status"
+	// resetup has no default, it should be configured explicitly to use xtrabackup or script resetup methods
+	commandResetup  = "resetup"
+	commandVIPAdd   = "vip_add"
+	commandVIPDel   = "vip_del"
+	commandVIPCheck = "vip_check"
 )
 
 var defaultCommands = map[string]string{
 	commandStatus: `service mysql status`,
+	// add address and announce it with gratuitous ARP
+	commandVIPAdd: `ip addr add "$MYSYNC_VIP" dev "$MYSYNC_VIP_INTERFACE" && arping -q -U -c 3 -I "$MYSYNC_VIP_INTERFACE" "$MYSYNC_VIP_ADDRESS"`,
+	commandVIPDel: `ip addr del "$MYSYNC_VIP" dev "$MYSYNC_VIP_INTERFACE"`,
+	// succeeds only if nobody answers for the address (duplicate address detection)
+	commandVIPCheck: `arping -q -D -c 2 -I "$MYSYNC_VIP_INTERFACE" "$MYSYNC_VIP_ADDRESS"`,
 }
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+// erAccessDenied is returned by MySQL when password is wrong
+const erAccessDenied = 1045
+
+// rotatingConnector resolves credentials on every connect, as they may be rotated at runtime.
+// When current password is rejected, previous one is tried, so agents keep connecting
+// while new password is being rolled out to hosts
+type rotatingConnector struct {
+	dsn    *mysql.Config
+	config *config.Holder
+	logger *log.Logger
+	host   string
+}
+
+func (c *rotatingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	user, password := c.config.Get().MySQLCredentials()
+	conn, err := c.connect(ctx, user, password)
+	previous := c.config.Get().MySQLPreviousPassword()
+	if err == nil || previous == "" || previous == password || !IsAccessDenied(err) {
+		return conn, err
+	}
+	conn, err = c.connect(ctx, user, previous)
+	if err == nil {
+		c.logger.Infof("node %s accepted only previous password of %s, password rotation is not finished", c.host, user)
+	}
+	return conn, err
+}
+
+func (c *rotatingConnector) connect(ctx context.Context, user, password string) (driver.Conn, error) {
+	dsn := c.dsn.Clone()
+	dsn.User, dsn.Passwd = user, password
+	connector, err := mysql.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *rotatingConnector) Driver() driver.Driver {
+	return &mysql.MySQLDriver{}
+}
+
+// IsAccessDenied checks that MySQL rejected credentials
+func IsAccessDenied(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erAccessDenied
+}
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"cmp"
 	"database/sql"
 	"fmt"
 	"time"
@@ -36,12 +37,18 @@ type ServerUUIDResult struct {
 type CascadeNodeConfiguration struct {
 	// StreamFrom - is a host to stream from. Can be changed from CLI.
 	StreamFrom string `json:"stream_from"`
+	// Relay - host only fans out replication to cascade replicas streaming from it
+	// (binlog server or blackhole replica), so it holds no data to serve or copy
+	Relay bool `json:"relay,omitempty"`
 }
 
 // NodeConfiguration is a dcs node configuration for HA mysql replica
 type NodeConfiguration struct {
 	// Priority - is a host priority to become master. Can be changed from CLI.
 	Priority int64 `json:"priority"`
+	// NoSemiSync - host replicates asynchronously, so it never acknowledges commits on master
+	// and stays out of HA group. Can be changed from CLI.
+	NoSemiSync bool `json:"no_semi_sync,omitempty"`
 }
 
 type ResetupStatus struct {
@@ -74,6 +81,7 @@ type SlaveStatusStruct struct {
 	LastIOErrno      int             `db:"Last_IO_Errno"`
 	LastIOError      string          `db:"Last_IO_Error"`
 	LastSQLErrno     int             `db:"Last_SQL_Errno"`
+	RelayLogSpace    int64           `db:"Relay_Log_Space"`
 	Lag              sql.NullFloat64 `db:"Seconds_Behind_Master"`
 }
 
@@ -91,6 +99,7 @@ type ReplicaStatusStruct struct {
 	LastIOErrno       int             `db:"Last_IO_Errno"`
 	LastIOError       string          `db:"Last_IO_Error"`
 	LastSQLErrno      int             `db:"Last_SQL_Errno"`
+	RelayLogSpace     int64           `db:"Relay_Log_Space"`
 	Lag               sql.NullFloat64 `db:"Seconds_Behind_Source"`
 }
 
@@ -108,6 +117,7 @@ type ReplicaStatus interface {
 	GetLastIOErrno() int
 	GetLastIOError() string
 	GetLastSQLErrno() int
+	GetRelayLogSpace() int64
 	GetReplicationLag() sql.NullFloat64
 }
 
@@ -118,6 +128,16 @@ type SemiSyncStatus struct {
 	WaitSlaveCount int `db:"WaitSlaveCount"`
 }
 
+// SemiSyncWaitStatus shows whether master is blocked waiting for semi sync ACKs
+type SemiSyncWaitStatus struct {
+	// sessions waiting for ACK of their commits
+	WaitSessions int `db:"WaitSessions"`
+	// semi sync replicas connected to master
+	Clients int `db:"Clients"`
+	// commits acknowledged by replicas, grows while ACKs make progress
+	YesTx int64 `db:"YesTx"`
+}
+
 func (sett *replicationSettings) ShouldBeRunning() bool {
 	replStatus, _ := sett.ReplicationStatus.Value()
 	if replStatus != nil {
@@ -162,6 +182,10 @@ func (ss *SlaveStatusStruct) GetLastSQLErrno() int {
 	return ss.LastSQLErrno
 }
 
+func (ss *SlaveStatusStruct) GetRelayLogSpace() int64 {
+	return ss.RelayLogSpace
+}
+
 func (ss *ReplicaStatusStruct) GetMasterHost() string {
 	return ss.SourceHost
 }
@@ -190,6 +214,10 @@ func (ss *ReplicaStatusStruct) GetLastSQLErrno() int {
 	return ss.LastSQLErrno
 }
 
+func (ss *ReplicaStatusStruct) GetRelayLogSpace() int64 {
+	return ss.RelayLogSpace
+}
+
 // ReplicationIORunning ...
 func (ss *SlaveStatusStruct) ReplicationIORunning() bool {
 	return ss.SlaveIORunning == yes
@@ -271,6 +299,10 @@ type replicationLag struct {
 	Lag sql.NullFloat64 `db:"Seconds_Behind_Master"`
 }
 
+type lagResult struct {
+	Lag sql.NullFloat64 `db:"Lag"`
+}
+
 type Event struct {
 	Schema  string `db:"EVENT_SCHEMA"`
 	Name    string `db:"EVENT_NAME"`
@@ -282,14 +314,50 @@ type offlineModeStatus struct {
 	OfflineMode int `db:"OfflineMode"`
 }
 
+// backupStatus shows whether backup lock is held by someone
+type backupStatus struct {
+	IsRunning int `db:"IsRunning"`
+}
+
+// CloneStage is progress of one stage of CLONE INSTANCE
+type CloneStage struct {
+	Stage    string `db:"Stage"`
+	State    string `db:"State"`
+	Estimate int64  `db:"Estimate"`
+	Data     int64  `db:"Data"`
+}
+
+// CloneStateCompleted is the state of successfully finished CLONE INSTANCE
+const CloneStateCompleted = "Completed"
+
+// CloneStatus is the state of the last CLONE INSTANCE, it survives restart of mysqld
+type CloneStatus struct {
+	State        string `db:"State"`
+	ErrorNo      int    `db:"ErrorNo"`
+	ErrorMessage string `db:"ErrorMessage"`
+}
+
 func (ev Event) String() string {
 	return fmt.Sprintf("`%s`.`%s`", ev.Schema, ev.Name)
 }
 
 type Version struct {
-	MajorVersion int `db:"MajorVersion"`
-	MinorVersion int `db:"MinorVersion"`
-	PatchVersion int `db:"PatchVersion"`
+	MajorVersion int `db:"MajorVersion" json:"major"`
+	MinorVersion int `db:"MinorVersion" json:"minor"`
+	PatchVersion int `db:"PatchVersion" json:"patch"`
+}
+
+func (v *Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.MajorVersion, v.MinorVersion, v.PatchVersion)
+}
+
+// CompareSeries compares release series (major.minor) of versions, patch releases of one series
+// replicate to each other. It returns -1, 0 or 1 if v is older, the same or newer than other.
+func (v *Version) CompareSeries(other *Version) int {
+	if v.MajorVersion != other.MajorVersion {
+		return cmp.Compare(v.MajorVersion, other.MajorVersion)
+	}
+	return cmp.Compare(v.MinorVersion, other.MinorVersion)
 }
 
 type ReplMonTS struct {
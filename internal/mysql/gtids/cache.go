@@ -0,0 +1,83 @@
+package gtids
+
+import (
+	"sync"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// maxCachedSets bounds the number of parsed sets kept between cycles,
+// cluster state contains only a few distinct gtid_executed values per host
+const maxCachedSets = 1024
+
+// setCache keeps parsed GTID sets by their textual form and results of comparisons between them.
+// gtid_executed of a quiet host does not change between cycles, so it is parsed once,
+// and only changed sets are parsed and compared again
+type setCache struct {
+	mu       sync.Mutex
+	sets     map[string]*mysql.MysqlGTIDSet
+	cached   map[*mysql.MysqlGTIDSet]bool
+	contains map[[2]*mysql.MysqlGTIDSet]bool
+}
+
+var cache = newSetCache()
+
+func newSetCache() *setCache {
+	return &setCache{
+		sets:     make(map[string]*mysql.MysqlGTIDSet),
+		cached:   make(map[*mysql.MysqlGTIDSet]bool),
+		contains: make(map[[2]*mysql.MysqlGTIDSet]bool),
+	}
+}
+
+func (c *setCache) parse(gtidset string) (*mysql.MysqlGTIDSet, error) {
+	c.mu.Lock()
+	set, ok := c.sets[gtidset]
+	c.mu.Unlock()
+	if ok {
+		return set, nil
+	}
+	parsed, err := mysql.ParseMysqlGTIDSet(gtidset)
+	if err != nil {
+		return nil, err
+	}
+	set = parsed.(*mysql.MysqlGTIDSet)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sets) >= maxCachedSets {
+		c.reset()
+	}
+	c.sets[gtidset] = set
+	c.cached[set] = true
+	return set, nil
+}
+
+// contain reports whether outer contains inner, result is memoized only for cached sets
+func (c *setCache) contain(outer, inner GTIDSet) bool {
+	o, ok1 := outer.(*mysql.MysqlGTIDSet)
+	i, ok2 := inner.(*mysql.MysqlGTIDSet)
+	if !ok1 || !ok2 {
+		return outer.Contain(inner)
+	}
+	key := [2]*mysql.MysqlGTIDSet{o, i}
+	c.mu.Lock()
+	res, ok := c.contains[key]
+	c.mu.Unlock()
+	if ok {
+		return res
+	}
+	res = o.Contain(i)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// sets parsed outside of cache may be mutated by caller
+	if c.cached[o] && c.cached[i] {
+		c.contains[key] = res
+	}
+	return res
+}
+
+func (c *setCache) reset() {
+	c.sets = make(map[string]*mysql.MysqlGTIDSet)
+	c.cached = make(map[*mysql.MysqlGTIDSet]bool)
+	c.contains = make(map[[2]*mysql.MysqlGTIDSet]bool)
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func IsSlaveBehindOrEqual(slaveGtidSet, masterGtidSet GTIDSet) bool {
-	return masterGtidSet.Contain(slaveGtidSet) || masterGtidSet.Equal(slaveGtidSet)
+	return cache.contain(masterGtidSet, slaveGtidSet) || masterGtidSet.Equal(slaveGtidSet)
 }
 
 func IsSlaveAhead(slaveGtidSet, masterGtidSet GTIDSet) bool {
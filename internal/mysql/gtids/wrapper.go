@@ -8,8 +8,10 @@ import (
 
 type GTIDSet = mysql.GTIDSet
 
+// ParseGtidSet parses gtid set using cache of previously parsed sets,
+// returned set is shared and must not be modified, use Clone() before changing it
 func ParseGtidSet(gtidset string) GTIDSet {
-	parsed, err := mysql.ParseGTIDSet(mysql.MySQLFlavor, gtidset)
+	parsed, err := cache.parse(gtidset)
 	if err != nil {
 		panic(err)
 	}
@@ -51,3 +53,24 @@ func GTIDDiff(replicaGTIDSet, sourceGTIDSet mysql.GTIDSet) (string, error) {
 
 	return "", fmt.Errorf("an indefinite case was obtained")
 }
+
+// MissingTransactions returns transactions of source absent in replica and their count
+func MissingTransactions(replicaGTIDSet, sourceGTIDSet GTIDSet) (string, int64, error) {
+	missing := sourceGTIDSet.(*mysql.MysqlGTIDSet).Clone().(*mysql.MysqlGTIDSet)
+	err := missing.Minus(*replicaGTIDSet.(*mysql.MysqlGTIDSet))
+	if err != nil {
+		return "", 0, err
+	}
+	return missing.String(), CountTransactions(missing), nil
+}
+
+// CountTransactions returns number of transactions in gtid set
+func CountTransactions(gtidset GTIDSet) int64 {
+	var count int64
+	for _, set := range gtidset.(*mysql.MysqlGTIDSet).Sets {
+		for _, interval := range set.Intervals {
+			count += interval.Stop - interval.Start
+		}
+	}
+	return count
+}
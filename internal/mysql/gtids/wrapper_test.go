@@ -48,3 +48,37 @@ func TestGTIDDiff(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "split brain! source ahead on: 11111111-1111-1111-1111-111111111111:1-100; replica ahead on: 22222222-2222-2222-2222-222222222222:1-110", diff)
 }
+
+func TestMissingTransactions(t *testing.T) {
+	source := ParseGtidSet("00000000-0000-0000-0000-000000000000:1-100,11111111-1111-1111-1111-111111111111:1-10")
+	replica := ParseGtidSet("00000000-0000-0000-0000-000000000000:1-90,11111111-1111-1111-1111-111111111111:1-10:12")
+
+	missing, count, err := MissingTransactions(replica, source)
+	require.NoError(t, err)
+	require.Equal(t, "00000000-0000-0000-0000-000000000000:91-100", missing)
+	require.Equal(t, int64(10), count)
+	require.Equal(t, int64(110), CountTransactions(source))
+
+	missing, count, err = MissingTransactions(source, source)
+	require.NoError(t, err)
+	require.Equal(t, "", missing)
+	require.Equal(t, int64(0), count)
+}
+
+func TestParseGtidSetCache(t *testing.T) {
+	first := ParseGtidSet("00000000-0000-0000-0000-000000000000:1-100")
+	second := ParseGtidSet("00000000-0000-0000-0000-000000000000:1-100")
+	require.Same(t, first, second)
+
+	ahead := ParseGtidSet("00000000-0000-0000-0000-000000000000:1-110")
+	require.True(t, IsSlaveBehindOrEqual(first, ahead))
+	require.True(t, IsSlaveAhead(ahead, first))
+	// memoized result is the same
+	require.True(t, IsSlaveAhead(ahead, first))
+
+	// modifying a clone does not affect cached set
+	clone := first.Clone()
+	require.NoError(t, clone.Update("00000000-0000-0000-0000-000000000000:101-120"))
+	require.Equal(t, "00000000-0000-0000-0000-000000000000:1-100", ParseGtidSet("00000000-0000-0000-0000-000000000000:1-100").String())
+	require.True(t, IsSlaveAhead(clone, ahead))
+}
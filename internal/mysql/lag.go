@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+// LagCalculator computes replication lag of replica
+type LagCalculator interface {
+	// Lag returns lag in seconds or nil if it is unknown.
+	// Status is nil if node is not a replica.
+	Lag(n *Node, status ReplicaStatus) (*float64, error)
+}
+
+// NewLagCalculator returns calculator by name, see config.LagCalculator*
+func NewLagCalculator(name string) (LagCalculator, error) {
+	switch name {
+	case config.LagCalculatorSecondsBehindMaster:
+		return &secondsBehindMasterLag{}, nil
+	case config.LagCalculatorHeartbeat:
+		return &heartbeatLag{}, nil
+	case config.LagCalculatorApplier:
+		return &applierLag{}, nil
+	case config.LagCalculatorQuery:
+		return &queryLag{}, nil
+	default:
+		return nil, fmt.Errorf("unknown replication lag calculator %q", name)
+	}
+}
+
+type secondsBehindMasterLag struct{}
+
+func (c *secondsBehindMasterLag) Lag(n *Node, status ReplicaStatus) (*float64, error) {
+	if status == nil {
+		return nil, nil
+	}
+	l := status.GetReplicationLag()
+	if l.Valid {
+		return &l.Float64, nil
+	}
+	return nil, nil
+}
+
+// heartbeatLag relies on timestamp written to repl_mon table by master every repl_mon_write_interval
+type heartbeatLag struct{}
+
+func (c *heartbeatLag) Lag(n *Node, status ReplicaStatus) (*float64, error) {
+	if status == nil {
+		return nil, nil
+	}
+	lag := new(lagResult)
+	err := n.queryRowMogrify(queryHeartbeatLag, map[string]interface{}{
+		"replMonSchemeName": schemaname(n.config.Get().ReplMonSchemeName),
+		"replMonTable":      schemaname(n.config.Get().ReplMonTableName),
+	}, lag)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || !lag.Lag.Valid {
+		return nil, err
+	}
+	return &lag.Lag.Float64, nil
+}
+
+// applierLag is age of the oldest transaction being applied. It is 0 when applier is idle,
+// so lag is unknown if replication is not running.
+type applierLag struct{}
+
+func (c *applierLag) Lag(n *Node, status ReplicaStatus) (*float64, error) {
+	if status == nil || !status.ReplicationRunning() {
+		return nil, nil
+	}
+	lag := new(lagResult)
+	err := n.queryRowMogrify(queryApplierLag, map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
+	}, lag)
+	if err != nil || !lag.Lag.Valid {
+		return nil, err
+	}
+	return &lag.Lag.Float64, nil
+}
+
+// queryLag runs custom replication_lag query returning Seconds_Behind_Master column
+type queryLag struct{}
+
+func (c *queryLag) Lag(n *Node, status ReplicaStatus) (*float64, error) {
+	lag := new(replicationLag)
+	err := n.queryRow(queryReplicationLag, nil, lag)
+	if err == sql.ErrNoRows {
+		// looks like master
+		return new(float64), nil
+	}
+	if err != nil || !lag.Lag.Valid {
+		return nil, err
+	}
+	return &lag.Lag.Float64, nil
+}
+
+// combineLags applies replication_lag_policy to lags reported by calculators.
+// With median policy unknown lags are ignored unless all of them are unknown,
+// otherwise unknown lag of any calculator makes result unknown.
+func combineLags(lags []*float64, policy string) *float64 {
+	var known []float64
+	for _, lag := range lags {
+		if lag == nil {
+			if policy != config.LagPolicyMedian {
+				return nil
+			}
+			continue
+		}
+		known = append(known, *lag)
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	sort.Float64s(known)
+	var result float64
+	switch {
+	case policy != config.LagPolicyMedian:
+		result = known[len(known)-1]
+	case len(known)%2 == 1:
+		result = known[len(known)/2]
+	default:
+		result = (known[len(known)/2-1] + known[len(known)/2]) / 2
+	}
+	return &result
+}
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+)
+
+func TestCombineLags(t *testing.T) {
+	lag := func(v float64) *float64 { return &v }
+	lags := []*float64{lag(3), nil, lag(1), lag(10)}
+
+	require.Nil(t, combineLags(lags, config.LagPolicyMax))
+	require.Equal(t, 3.0, *combineLags(lags, config.LagPolicyMedian))
+	require.Equal(t, 10.0, *combineLags([]*float64{lag(3), lag(10)}, config.LagPolicyMax))
+	require.Equal(t, 6.5, *combineLags([]*float64{lag(3), lag(10)}, config.LagPolicyMedian))
+	require.Nil(t, combineLags([]*float64{nil}, config.LagPolicyMedian))
+	require.Nil(t, combineLags(nil, config.LagPolicyMax))
+}
+
+func TestReplicationLagCalculators(t *testing.T) {
+	n := newPolicyTestNode(t)
+	status := &ReplicaStatusStruct{Lag: sql.NullFloat64{Float64: 42, Valid: true}}
+
+	lag, err := n.ReplicationLag(status)
+	require.NoError(t, err)
+	require.Equal(t, 42.0, *lag)
+
+	lag, err = n.ReplicationLag(nil)
+	require.NoError(t, err)
+	require.Nil(t, lag)
+
+	n.config.Get().ReplicationLagCalculators = []string{"unknown"}
+	_, err = n.ReplicationLag(status)
+	require.Error(t, err)
+}
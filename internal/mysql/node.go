@@ -3,6 +3,7 @@ package mysql
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
@@ -29,12 +30,17 @@ import (
 
 // Node represents API to query/manipulate single MySQL node
 type Node struct {
-	config  *config.Config
+	config  *config.Holder
 	logger  *log.Logger
 	db      *sqlx.DB
 	version *Version
 	host    string
 	uuid    uuid.UUID
+
+	// semisync is provided by "source/replica" plugins, detected on demand
+	semiSyncSource *bool
+	// emulates slow MySQL for fault injection, see Cluster.SetQueryDelay
+	queryDelay func(host string) time.Duration
 }
 
 var (
@@ -49,24 +55,28 @@ const (
 )
 
 // NewNode returns new Node
-func NewNode(config *config.Config, logger *log.Logger, host string) (*Node, error) {
-	addr := util.JoinHostPort(host, config.MySQL.Port)
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/mysql?autocommit=1", config.MySQL.User, config.MySQL.Password, addr)
+func NewNode(holder *config.Holder, logger *log.Logger, host string) (*Node, error) {
+	config := holder.Get()
+	addr := util.JoinHostPort(config.ManagementAddress(host), config.MySQL.Port)
+	dsn := fmt.Sprintf("tcp(%s)/mysql?autocommit=1", addr)
+	// without TLS, driver requests RSA public key of caching_sha2_password from server itself
 	if config.MySQL.SslCA != "" {
-		dsn += "&tls=custom"
+		dsn += "&tls=" + tlsConfigName(config)
 	}
-	db, err := sqlx.Open("mysql", dsn)
+	dsnConfig, err := mysql.ParseDSN(dsn)
 	if err != nil {
 		return nil, err
 	}
+	connector := &rotatingConnector{dsn: dsnConfig, config: holder, logger: logger, host: host}
+	db := sqlx.NewDb(sql.OpenDB(connector), "mysql")
 	// Unsafe option allow us to use queries containing fields missing in structs
 	// eg. when we running "SHOW SLAVE STATUS", but need only few columns
 	db = db.Unsafe()
 	db.SetMaxIdleConns(1)
 	db.SetMaxOpenConns(3)
-	db.SetConnMaxLifetime(3 * config.TickInterval)
+	db.SetConnMaxLifetime(ConnMaxLifetime(config))
 	return &Node{
-		config:  config,
+		config:  holder,
 		logger:  logger,
 		db:      db,
 		host:    host,
@@ -74,6 +84,12 @@ func NewNode(config *config.Config, logger *log.Logger, host string) (*Node, err
 	}, nil
 }
 
+// ConnMaxLifetime returns how long pooled connection is reused before it is reopened,
+// so changed credentials are applied to all connections within this time
+func ConnMaxLifetime(config *config.Config) time.Duration {
+	return 3 * config.TickInterval
+}
+
 // RegisterTLSConfig loads and register CA file for TLS encryption
 func RegisterTLSConfig(config *config.Config) error {
 	if config.MySQL.SslCA != "" {
@@ -86,11 +102,17 @@ func RegisterTLSConfig(config *config.Config) error {
 		if ok := rootCertPool.AppendCertsFromPEM(pem); !ok {
 			return fmt.Errorf("failed to parse PEM certificate")
 		}
-		return mysql.RegisterTLSConfig("custom", &tls.Config{RootCAs: rootCertPool})
+		return mysql.RegisterTLSConfig(tlsConfigName(config), &tls.Config{RootCAs: rootCertPool})
 	}
 	return nil
 }
 
+// tlsConfigName returns name of registered TLS config for CA file,
+// clusters managed by one process may use different CAs
+func tlsConfigName(config *config.Config) string {
+	return fmt.Sprintf("custom-%x", sha256.Sum256([]byte(config.MySQL.SslCA)))[:23]
+}
+
 // Host returns Node host name
 func (n *Node) Host() string {
 	return n.host
@@ -98,7 +120,7 @@ func (n *Node) Host() string {
 
 // IsLocal returns true if MySQL Node running on the same host as calling mysync process
 func (n *Node) IsLocal() bool {
-	return n.host == n.config.Hostname
+	return n.host == n.config.Get().Hostname
 }
 
 func (n *Node) String() string {
@@ -111,7 +133,7 @@ func (n *Node) Close() error {
 }
 
 func (n *Node) getCommand(name string) string {
-	command, ok := n.config.Commands[name]
+	command, ok := n.config.Get().Commands[name]
 	if !ok {
 		command, ok = defaultCommands[name]
 	}
@@ -134,8 +156,50 @@ func (n *Node) runCommand(name string) (int, error) {
 	return ret, err
 }
 
+func (n *Node) runCommandWithEnv(name string, env map[string]string, timeout time.Duration) (int, error) {
+	command := n.getCommand(name)
+	if !n.IsLocal() {
+		panic(fmt.Sprintf("Remote command execution is not supported (%s on %s)", command, n.host))
+	}
+	if n.config.Get().ObserveOnly {
+		n.logger.Infof("observe-only: node %s would run command '%s' with env %v", n.host, command, env)
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shell := util.GetEnvVariable("SHELL", "sh")
+	cmd := exec.CommandContext(ctx, shell, "-c", command)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+	err := cmd.Run()
+	ret := -1
+	if cmd.ProcessState != nil {
+		ret = cmd.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+	}
+	n.logger.Debugf("running command '%s', retcode: %d, error %s", command, ret, err)
+	return ret, err
+}
+
+func (n *Node) waitQueryDelay(ctx context.Context) error {
+	if n.queryDelay == nil {
+		return nil
+	}
+	delay := n.queryDelay(n.host)
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (n *Node) getQuery(name string) string {
-	query, ok := n.config.Queries[name]
+	query, ok := n.config.Get().Queries[name]
 	if !ok {
 		query, ok = DefaultQueries[name]
 	}
@@ -145,16 +209,40 @@ func (n *Node) getQuery(name string) string {
 	return query
 }
 
-func (n *Node) traceQuery(query string, arg interface{}, result interface{}, err error) {
+func (n *Node) traceQuery(start time.Time, query string, arg interface{}, result interface{}, err error) {
 	query = queryOnliner.ReplaceAllString(query, " ")
-	if n.config.ShowOnlyGTIDDiff && IsGtidQuery(query) {
+	if n.config.Get().SQLLog {
+		// result is omitted, as it may be huge or contain user data
+		msg := fmt.Sprintf("sql: node %s query '%s' with args %#v took %v, error: %v", n.host, RedactQuery(query), redactArgs(arg), time.Since(start).Round(time.Microsecond), err)
+		n.logger.Info(n.hidePasswords(msg))
+		return
+	}
+	if n.config.Get().ShowOnlyGTIDDiff && IsGtidQuery(query) {
 		n.logger.Debug("<gtid query was ignored>")
 		return
 	}
-	msg := fmt.Sprintf("node %s running query '%s' with args %#v, result: %#v, error: %v", n.host, query, arg, result, err)
-	msg = strings.ReplaceAll(msg, n.config.MySQL.Password, "********")
-	msg = strings.ReplaceAll(msg, n.config.MySQL.ReplicationPassword, "********")
-	n.logger.Debug(msg)
+	msg := fmt.Sprintf("node %s running query '%s' with args %#v, result: %#v, error: %v, took %v", n.host, RedactQuery(query), redactArgs(arg), result, err, time.Since(start).Round(time.Microsecond))
+	n.logger.Debug(n.hidePasswords(msg))
+}
+
+func (n *Node) hidePasswords(msg string) string {
+	_, password := n.config.Get().MySQLCredentials()
+	for _, p := range []string{password, n.config.Get().MySQLPreviousPassword(), n.config.Get().MySQL.ReplicationPassword} {
+		if p != "" {
+			msg = strings.ReplaceAll(msg, p, "********")
+		}
+	}
+	return msg
+}
+
+// skipWrite reports that modifying query should not be run, as mysync is in observe-only mode
+func (n *Node) skipWrite(query string, arg interface{}) bool {
+	if !n.config.Get().ObserveOnly {
+		return false
+	}
+	query = queryOnliner.ReplaceAllString(query, " ")
+	n.logger.Info(n.hidePasswords(fmt.Sprintf("observe-only: node %s would run query '%s' with args %#v", n.host, RedactQuery(query), redactArgs(arg))))
+	return true
 }
 
 func IsGtidQuery(query string) bool {
@@ -175,7 +263,7 @@ var GtidQueries = []string{
 
 //nolint:unparam
 func (n *Node) queryRow(queryName string, arg interface{}, result interface{}) error {
-	return n.queryRowWithTimeout(queryName, arg, result, n.config.DBTimeout)
+	return n.queryRowWithTimeout(queryName, arg, result, n.queryTimeout(queryName, queryClassRead))
 }
 
 func (n *Node) queryRowWithTimeout(queryName string, arg interface{}, result interface{}, timeout time.Duration) error {
@@ -183,8 +271,19 @@ func (n *Node) queryRowWithTimeout(queryName string, arg interface{}, result int
 		arg = struct{}{}
 	}
 	query := n.getQuery(queryName)
+	return n.retryRead(queryName, func() error {
+		return n.queryRowOnce(query, arg, result, timeout)
+	})
+}
+
+func (n *Node) queryRowOnce(query string, arg interface{}, result interface{}, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	start := time.Now()
+	if err := n.waitQueryDelay(ctx); err != nil {
+		n.traceQuery(start, query, arg, result, err)
+		return err
+	}
 	rows, err := n.db.NamedQueryContext(ctx, query, arg)
 	if err == nil {
 		defer func() { _ = rows.Close() }()
@@ -197,7 +296,7 @@ func (n *Node) queryRowWithTimeout(queryName string, arg interface{}, result int
 			}
 		}
 	}
-	n.traceQuery(query, arg, result, err)
+	n.traceQuery(start, query, arg, result, err)
 	return err
 }
 
@@ -216,27 +315,41 @@ func (n *Node) queryRows(queryName string, arg interface{}, scanner func(*sqlx.R
 		}
 
 		return err
-	}, n.config.DBTimeout)
+	}, n.queryTimeout(queryName, queryClassRead))
 }
 
 func (n *Node) processQuery(queryName string, arg interface{}, rowsProcessor func(*sqlx.Rows) error, timeout time.Duration) error {
 	if arg == nil {
 		arg = struct{}{}
 	}
+	query := n.getQuery(queryName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	// only failed queries are retried: rows may be partially processed already
+	var processErr error
+	err := n.retryRead(queryName, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
 
-	query := n.getQuery(queryName)
-	rows, err := n.db.NamedQueryContext(ctx, query, arg)
-	n.traceQuery(query, arg, rows, err)
+		if err := n.waitQueryDelay(ctx); err != nil {
+			n.traceQuery(start, query, arg, nil, err)
+			return err
+		}
+		rows, err := n.db.NamedQueryContext(ctx, query, arg)
+		n.traceQuery(start, query, arg, rows, err)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = rows.Close() }()
+
+		processErr = rowsProcessor(rows)
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-
-	defer func() { _ = rows.Close() }()
-
-	return rowsProcessor(rows)
+	return processErr
 }
 
 // nolint: unparam
@@ -245,39 +358,52 @@ func (n *Node) execWithTimeout(queryName string, arg map[string]interface{}, tim
 		arg = map[string]interface{}{}
 	}
 	query := n.getQuery(queryName)
+	if n.skipWrite(query, arg) {
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	start := time.Now()
+	if err := n.waitQueryDelay(ctx); err != nil {
+		n.traceQuery(start, query, arg, nil, err)
+		return err
+	}
 	// avoid connection leak on long lock timeouts
 	lockTimeout := int64(math.Floor(0.8 * float64(timeout/time.Second)))
 	if _, err := n.db.ExecContext(ctx, n.getQuery(querySetLockTimeout), lockTimeout); err != nil {
-		n.traceQuery(query, arg, nil, err)
+		n.traceQuery(start, query, arg, nil, err)
 		return err
 	}
 
 	_, err := n.db.NamedExecContext(ctx, query, arg)
-	n.traceQuery(query, arg, nil, err)
+	n.traceQuery(start, query, arg, nil, err)
 	return err
 }
 
 // nolint: unparam
 func (n *Node) exec(queryName string, arg map[string]interface{}) error {
-	return n.execWithTimeout(queryName, arg, n.config.DBTimeout)
+	return n.execWithTimeout(queryName, arg, n.queryTimeout(queryName, queryClassWrite))
 }
 
 func (n *Node) getRunningQueryIDs(excludeUsers []string, timeout time.Duration) ([]int, error) {
+	return n.getProcessIDs(queryGetProcessIDs, excludeUsers, timeout)
+}
+
+func (n *Node) getProcessIDs(queryName string, excludeUsers []string, timeout time.Duration) ([]int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	start := time.Now()
 
-	query := DefaultQueries[queryGetProcessIDs]
+	query := DefaultQueries[queryName]
 
 	bquery, args, err := sqlx.In(query, excludeUsers)
 	if err != nil {
-		n.traceQuery(bquery, args, nil, err)
+		n.traceQuery(start, bquery, args, nil, err)
 		return nil, err
 	}
 	rows, err := n.db.QueryxContext(ctx, bquery, args...)
 	if err != nil {
-		n.traceQuery(bquery, args, nil, err)
+		n.traceQuery(start, bquery, args, nil, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -287,13 +413,13 @@ func (n *Node) getRunningQueryIDs(excludeUsers []string, timeout time.Duration)
 		var currid int
 		err := rows.Scan(&currid)
 		if err != nil {
-			n.traceQuery(bquery, nil, ret, err)
+			n.traceQuery(start, bquery, nil, ret, err)
 			return nil, err
 		}
 		ret = append(ret, currid)
 	}
 
-	n.traceQuery(bquery, args, ret, nil)
+	n.traceQuery(start, bquery, args, ret, nil)
 
 	return ret, nil
 }
@@ -332,40 +458,47 @@ func Mogrify(query string, arg map[string]interface{}) string {
 func (n *Node) execMogrifyWithTimeout(queryName string, arg map[string]interface{}, timeout time.Duration) error {
 	query := n.getQuery(queryName)
 	query = Mogrify(query, arg)
+	if n.skipWrite(query, nil) {
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	start := time.Now()
 	_, err := n.db.ExecContext(ctx, query)
-	n.traceQuery(query, nil, nil, err)
+	n.traceQuery(start, query, nil, nil, err)
 	return err
 }
 
 func (n *Node) execMogrify(queryName string, arg map[string]interface{}) error {
-	return n.execMogrifyWithTimeout(queryName, arg, n.config.DBTimeout)
+	return n.execMogrifyWithTimeout(queryName, arg, n.queryTimeout(queryName, queryClassWrite))
 }
 
 func (n *Node) queryRowMogrifyWithTimeout(queryName string, arg map[string]interface{}, result interface{}, timeout time.Duration) error {
 	query := n.getQuery(queryName)
 	query = Mogrify(query, arg)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	rows, err := n.db.NamedQueryContext(ctx, query, arg)
-	if err == nil {
-		defer func() { _ = rows.Close() }()
-		if rows.Next() {
-			err = rows.StructScan(result)
-		} else {
-			err = rows.Err()
-			if err == nil {
-				err = sql.ErrNoRows
+	return n.retryRead(queryName, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		rows, err := n.db.NamedQueryContext(ctx, query, arg)
+		if err == nil {
+			defer func() { _ = rows.Close() }()
+			if rows.Next() {
+				err = rows.StructScan(result)
+			} else {
+				err = rows.Err()
+				if err == nil {
+					err = sql.ErrNoRows
+				}
 			}
 		}
-	}
-	n.traceQuery(query, arg, result, err)
-	return err
+		n.traceQuery(start, query, arg, result, err)
+		return err
+	})
 }
 
 func (n *Node) queryRowMogrify(queryName string, arg map[string]interface{}, result interface{}) error {
-	return n.queryRowMogrifyWithTimeout(queryName, arg, result, n.config.DBTimeout)
+	return n.queryRowMogrifyWithTimeout(queryName, arg, result, n.queryTimeout(queryName, queryClassRead))
 }
 
 // IsRunning checks if daemon process is running
@@ -392,15 +525,15 @@ func (n *Node) getTestDiskUsage(f string) (used uint64, total uint64, err error)
 
 // GetDiskUsage returns datadir usage statistics
 func (n *Node) GetDiskUsage() (used uint64, total uint64, err error) {
-	if n.config.TestDiskUsageFile != "" {
-		return n.getTestDiskUsage(n.config.TestDiskUsageFile)
+	if n.config.Get().TestDiskUsageFile != "" {
+		return n.getTestDiskUsage(n.config.Get().TestDiskUsageFile)
 	}
 	if !n.IsLocal() {
 		err = ErrNotLocalNode
 		return
 	}
 	var stat syscall.Statfs_t
-	err = syscall.Statfs(n.config.MySQL.DataDir, &stat)
+	err = syscall.Statfs(n.config.Get().MySQL.DataDir, &stat)
 	total = uint64(stat.Bsize) * stat.Blocks
 	// on FreeBSD stat.Bavail may be negative
 	bavail := stat.Bavail
@@ -412,6 +545,29 @@ func (n *Node) GetDiskUsage() (used uint64, total uint64, err error) {
 	return
 }
 
+// GetFsUsage returns space and inodes usage statistics of filesystem containing path
+func (n *Node) GetFsUsage(path string) (used, total, inodesUsed, inodesTotal uint64, err error) {
+	if !n.IsLocal() {
+		err = ErrNotLocalNode
+		return
+	}
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(path, &stat)
+	if err != nil {
+		return
+	}
+	total = uint64(stat.Bsize) * stat.Blocks
+	bavail := stat.Bavail
+	// nolint: staticcheck
+	if bavail < 0 {
+		bavail = 0
+	}
+	used = total - uint64(stat.Bsize)*uint64(bavail) // nolint: unconvert
+	inodesTotal = stat.Files
+	inodesUsed = stat.Files - stat.Ffree
+	return
+}
+
 func getFlagsFromProcMounts(file, filesystem string) (string, error) {
 	for _, line := range strings.Split(file, "\n") {
 		components := strings.Split(line, " ")
@@ -434,8 +590,8 @@ func getFlagsFromProcMounts(file, filesystem string) (string, error) {
 }
 
 func (n *Node) IsFileSystemReadonly() (bool, error) {
-	if n.config.TestFilesystemReadonlyFile != "" {
-		return isTestFileSystemReadonly(n.config.TestFilesystemReadonlyFile)
+	if n.config.Get().TestFilesystemReadonlyFile != "" {
+		return isTestFileSystemReadonly(n.config.Get().TestFilesystemReadonlyFile)
 	}
 	if !n.IsLocal() {
 		return false, ErrNotLocalNode
@@ -447,7 +603,7 @@ func (n *Node) IsFileSystemReadonly() (bool, error) {
 	}
 	file := string(data)
 
-	flag, err := getFlagsFromProcMounts(file, n.config.MySQL.DataDir)
+	flag, err := getFlagsFromProcMounts(file, n.config.Get().MySQL.DataDir)
 	if err != nil {
 		return false, err
 	}
@@ -475,7 +631,7 @@ func (n *Node) GetDaemonStartTime() (time.Time, error) {
 	if !n.IsLocal() {
 		return time.Time{}, ErrNotLocalNode
 	}
-	pidB, err := os.ReadFile(n.config.MySQL.PidFile)
+	pidB, err := os.ReadFile(n.config.Get().MySQL.PidFile)
 	if err != nil {
 		if os.IsNotExist(err) {
 			err = nil
@@ -501,7 +657,7 @@ func (n *Node) GetCrashRecoveryTime() (time.Time, error) {
 	if !n.IsLocal() {
 		return time.Time{}, ErrNotLocalNode
 	}
-	fh, err := os.Open(n.config.MySQL.ErrorLog)
+	fh, err := os.Open(n.config.Get().MySQL.ErrorLog)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -530,7 +686,11 @@ func (n *Node) Ping() (bool, error) {
 
 // GetReplicaStatus returns slave/replica status or nil if node is master
 func (n *Node) GetReplicaStatus() (ReplicaStatus, error) {
-	return n.ReplicaStatusWithTimeout(n.config.DBTimeout, n.config.ReplicationChannel)
+	query, _, err := n.GetVersionSlaveStatusQuery()
+	if err != nil {
+		return nil, err
+	}
+	return n.ReplicaStatusWithTimeout(n.queryTimeout(query, queryClassRead), n.config.Get().ReplicationChannel)
 }
 
 func (n *Node) ReplicaStatusWithTimeout(timeout time.Duration, channel string) (ReplicaStatus, error) {
@@ -544,9 +704,22 @@ func (n *Node) ReplicaStatusWithTimeout(timeout time.Duration, channel string) (
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	if err == nil {
+		n.resolveMasterHost(status)
+	}
 	return status, err
 }
 
+// resolveMasterHost replaces replication address of master in replica status with its host name
+func (n *Node) resolveMasterHost(status ReplicaStatus) {
+	switch s := status.(type) {
+	case *SlaveStatusStruct:
+		s.MasterHost = n.config.Get().HostByReplicationAddress(s.MasterHost)
+	case *ReplicaStatusStruct:
+		s.SourceHost = n.config.Get().HostByReplicationAddress(s.SourceHost)
+	}
+}
+
 func (n *Node) GetVersionSlaveStatusQuery() (string, ReplicaStatus, error) {
 	version, err := n.GetVersion()
 	if err != nil {
@@ -568,28 +741,42 @@ func (n *Node) GetVersion() (*Version, error) {
 	return n.version, nil
 }
 
+// RefreshVersion re-reads version of MySQL, which may be upgraded in place
+func (n *Node) RefreshVersion() (*Version, error) {
+	v := new(Version)
+	err := n.queryRow(queryGetVersion, nil, v)
+	if err != nil {
+		return nil, err
+	}
+	n.version = v
+	// semisync plugins are replaced during upgrade
+	n.semiSyncSource = nil
+	return v, nil
+}
+
 // ReplicationLag returns slave replication lag in seconds
 // ReplicationLag may return nil without error if lag is unknown (replication not running)
 func (n *Node) ReplicationLag(sstatus ReplicaStatus) (*float64, error) {
-	var err error
-	if n.getQuery(queryReplicationLag) != "" {
-		lag := new(replicationLag)
-		err = n.queryRow(queryReplicationLag, nil, lag)
-		if err == sql.ErrNoRows {
-			// looks like master
-			return new(float64), nil
+	names := n.config.Get().ReplicationLagCalculators
+	if len(names) == 0 {
+		names = []string{config.LagCalculatorSecondsBehindMaster}
+		if n.getQuery(queryReplicationLag) != "" {
+			names = []string{config.LagCalculatorQuery}
 		}
-		if lag.Lag.Valid {
-			return &lag.Lag.Float64, nil
+	}
+	lags := make([]*float64, 0, len(names))
+	for _, name := range names {
+		calculator, err := NewLagCalculator(name)
+		if err != nil {
+			return nil, err
 		}
-	} else if sstatus != nil {
-		l := sstatus.GetReplicationLag()
-		if l.Valid {
-			return &l.Float64, nil
+		lag, err := calculator.Lag(n, sstatus)
+		if err != nil {
+			return nil, fmt.Errorf("%s replication lag: %w", name, err)
 		}
+		lags = append(lags, lag)
 	}
-	// replication not running, assume lag is huge
-	return nil, err
+	return combineLags(lags, n.config.Get().ReplicationLagPolicy), nil
 }
 
 // GTIDExecuted returns global transaction id executed
@@ -657,7 +844,7 @@ func (n *Node) IsReadOnly() (bool, bool, error) {
 // Setting server read-only may take a while
 // as server waits all running commits (not transactions) to be finished
 func (n *Node) SetReadOnly(superReadOnly bool) error {
-	return n.setReadonlyWithTimeout(superReadOnly, n.config.DBSetRoTimeout)
+	return n.setReadonlyWithTimeout(superReadOnly, n.config.Get().DBSetRoTimeout)
 }
 
 func (n *Node) setReadonlyWithTimeout(superReadOnly bool, timeout time.Duration) error {
@@ -667,7 +854,7 @@ func (n *Node) setReadonlyWithTimeout(superReadOnly bool, timeout time.Duration)
 	}
 
 	err := n.execWithTimeout(query, nil, timeout)
-	if err != nil {
+	if err != nil || n.config.Get().ObserveOnly {
 		return err
 	}
 
@@ -724,7 +911,40 @@ func (n *Node) SetReadOnlyWithForce(excludeUsers []string, superReadOnly bool) e
 
 	defer func() { quit <- true }()
 
-	return n.setReadonlyWithTimeout(superReadOnly, n.config.DBSetRoForceTimeout)
+	return n.setReadonlyWithTimeout(superReadOnly, n.config.Get().DBSetRoForceTimeout)
+}
+
+// QueryValue runs arbitrary query and returns first column of its first row as string
+func (n *Node) QueryValue(query string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	var value sql.NullString
+	err := n.db.QueryRowContext(ctx, query).Scan(&value)
+	n.traceQuery(start, query, nil, value.String, err)
+	return value.String, err
+}
+
+// GetTransactionProcessIDs returns ids of connections having open transactions
+func (n *Node) GetTransactionProcessIDs(excludeUsers []string) ([]int, error) {
+	return n.getProcessIDs(queryGetTransactionProcessIDs, excludeUsers, n.config.Get().DBTimeout)
+}
+
+// GetClientProcessIDs returns ids of connections of all users but excluded ones
+func (n *Node) GetClientProcessIDs(excludeUsers []string) ([]int, error) {
+	return n.getRunningQueryIDs(excludeUsers, n.config.Get().DBTimeout)
+}
+
+// KillProcesses kills connections with given ids
+func (n *Node) KillProcesses(ids []int) error {
+	var lastErr error
+	for _, id := range ids {
+		err := n.exec(queryKillQuery, map[string]interface{}{"kill_id": strconv.Itoa(id)})
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
 }
 
 // SetWritable sets MySQL Node to be writable, eg. disables read-only
@@ -734,15 +954,15 @@ func (n *Node) SetWritable() error {
 
 // StopSlave stops replication (both IO and SQL threads)
 func (n *Node) StopSlave() error {
-	return n.execMogrifyWithTimeout(queryStopSlave, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
-	}, n.config.DBStopSlaveSQLThreadTimeout)
+	return n.execMogrifyWithTimeout(n.replicationQuery(queryStopSlave), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
+	}, n.config.Get().DBStopSlaveSQLThreadTimeout)
 }
 
 // StartSlave starts replication (both IO and SQL threads)
 func (n *Node) StartSlave() error {
-	return n.execMogrify(queryStartSlave, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
+	return n.execMogrify(n.replicationQuery(queryStartSlave), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
 	})
 }
 
@@ -757,15 +977,15 @@ func (n *Node) RestartReplica() error {
 
 // StopSlaveIOThread stops IO replication thread
 func (n *Node) StopSlaveIOThread() error {
-	return n.execMogrify(queryStopSlaveIOThread, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
+	return n.execMogrify(n.replicationQuery(queryStopSlaveIOThread), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
 	})
 }
 
 // StartSlaveIOThread starts IO replication thread
 func (n *Node) StartSlaveIOThread() error {
-	return n.execMogrify(queryStartSlaveIOThread, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
+	return n.execMogrify(n.replicationQuery(queryStartSlaveIOThread), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
 	})
 }
 
@@ -780,29 +1000,29 @@ func (n *Node) RestartSlaveIOThread() error {
 
 // StopSlaveSQLThread stops SQL replication thread
 func (n *Node) StopSlaveSQLThread() error {
-	return n.execMogrifyWithTimeout(queryStopSlaveSQLThread, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
-	}, n.config.DBStopSlaveSQLThreadTimeout)
+	return n.execMogrifyWithTimeout(n.replicationQuery(queryStopSlaveSQLThread), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
+	}, n.config.Get().DBStopSlaveSQLThreadTimeout)
 }
 
 // StartSlaveSQLThread starts SQL replication thread
 func (n *Node) StartSlaveSQLThread() error {
-	return n.execMogrify(queryStartSlaveSQLThread, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
+	return n.execMogrify(n.replicationQuery(queryStartSlaveSQLThread), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
 	})
 }
 
 // ResetSlaveAll promotes MySQL Node to be master
 func (n *Node) ResetSlaveAll() error {
-	return n.execMogrify(queryResetSlaveAll, map[string]interface{}{
-		"channel": n.config.ReplicationChannel,
+	return n.execMogrify(n.replicationQuery(queryResetSlaveAll), map[string]interface{}{
+		"channel": n.config.Get().ReplicationChannel,
 	})
 }
 
 // SemiSyncStatus returns semi sync status
 func (n *Node) SemiSyncStatus() (*SemiSyncStatus, error) {
 	status := new(SemiSyncStatus)
-	err := n.queryRow(querySemiSyncStatus, nil, status)
+	err := n.queryRow(n.semiSyncQuery(querySemiSyncStatus), nil, status)
 	if err != nil {
 		if err2, ok := err.(*mysql.MySQLError); ok && err2.Number == 1193 {
 			// Error: Unknown system variable
@@ -815,22 +1035,29 @@ func (n *Node) SemiSyncStatus() (*SemiSyncStatus, error) {
 
 // SemiSyncSetMaster set host as semisync master
 func (n *Node) SemiSyncSetMaster() error {
-	return n.exec(querySemiSyncSetMaster, nil)
+	return n.exec(n.semiSyncQuery(querySemiSyncSetMaster), nil)
 }
 
 // SemiSyncSetSlave set host as semisync master
 func (n *Node) SemiSyncSetSlave() error {
-	return n.exec(querySemiSyncSetSlave, nil)
+	return n.exec(n.semiSyncQuery(querySemiSyncSetSlave), nil)
 }
 
 // SemiSyncDisable disables semi_sync_master and semi_sync_slave
 func (n *Node) SemiSyncDisable() error {
-	return n.exec(querySemiSyncDisable, nil)
+	return n.exec(n.semiSyncQuery(querySemiSyncDisable), nil)
+}
+
+// SemiSyncWaitStatus returns number of sessions waiting for semi sync ACK and connected semi sync replicas
+func (n *Node) SemiSyncWaitStatus() (*SemiSyncWaitStatus, error) {
+	status := new(SemiSyncWaitStatus)
+	err := n.queryRow(n.semiSyncQuery(querySemiSyncWaitStatus), nil, status)
+	return status, err
 }
 
 // SemiSyncSetWaitSlaveCount changes rpl_semi_sync_master_wait_for_slave_count
 func (n *Node) SetSemiSyncWaitSlaveCount(c int) error {
-	return n.exec(querySetSemiSyncWaitSlaveCount, map[string]interface{}{"wait_slave_count": c})
+	return n.exec(n.semiSyncQuery(querySetSemiSyncWaitSlaveCount), map[string]interface{}{"wait_slave_count": c})
 }
 
 // IsOffline returns current 'offline_mode' variable value
@@ -843,6 +1070,153 @@ func (n *Node) IsOffline() (bool, error) {
 	return status.OfflineMode == 1, err
 }
 
+// IsBackupRunning checks if backup lock is acquired (e.g. by xtrabackup or clone)
+func (n *Node) IsBackupRunning() (bool, error) {
+	status := new(backupStatus)
+	err := n.queryRow(queryIsBackupRunning, nil, status)
+	if err != nil {
+		return false, err
+	}
+	return status.IsRunning == 1, nil
+}
+
+// GetThreadsRunning returns number of threads executing queries now, a measure of host load
+func (n *Node) GetThreadsRunning() (int, error) {
+	var result struct {
+		ThreadsRunning int `db:"ThreadsRunning"`
+	}
+	err := n.queryRow(queryGetThreadsRunning, nil, &result)
+	return result.ThreadsRunning, err
+}
+
+// StartBufferPoolLoad starts asynchronous load of buffer pool pages, dumped on last shutdown
+func (n *Node) StartBufferPoolLoad() error {
+	return n.exec(queryStartBufferPoolLoad, nil)
+}
+
+// GetBufferPoolLoadStatus returns progress of buffer pool load, e.g. "Loaded 100/500 pages"
+func (n *Node) GetBufferPoolLoadStatus() (string, error) {
+	var result struct {
+		Status string `db:"Status"`
+	}
+	err := n.queryRow(queryBufferPoolLoadStatus, nil, &result)
+	return result.Status, err
+}
+
+// GetMaxConnections returns max_connections of host
+func (n *Node) GetMaxConnections() (int, error) {
+	var result struct {
+		MaxConnections int `db:"MaxConnections"`
+	}
+	err := n.queryRow(queryGetMaxConnections, nil, &result)
+	return result.MaxConnections, err
+}
+
+// SetMaxConnections sets max_connections of host
+func (n *Node) SetMaxConnections(maxConnections int) error {
+	return n.exec(querySetMaxConnections, map[string]interface{}{"max_connections": maxConnections})
+}
+
+// GetMaxUserConnections returns connections limit of account, 0 means no limit
+func (n *Node) GetMaxUserConnections(user, host string) (int, error) {
+	var result struct {
+		MaxUserConnections int `db:"MaxUserConnections"`
+	}
+	err := n.queryRow(queryGetMaxUserConnections, map[string]interface{}{"user": user, "host": host}, &result)
+	return result.MaxUserConnections, err
+}
+
+// SetMaxUserConnections changes connections limit of account, it affects new connections only.
+// Statement is replicated, so the limit is kept by replicas as well
+func (n *Node) SetMaxUserConnections(user, host string, limit int) error {
+	return n.execMogrify(querySetMaxUserConnections, map[string]interface{}{
+		"user":                 user,
+		"host":                 host,
+		"max_user_connections": limit,
+	})
+}
+
+// RunQuery runs arbitrary query discarding its result
+func (n *Node) RunQuery(query string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	start := time.Now()
+	_, err := n.db.ExecContext(ctx, query)
+	n.traceQuery(start, query, nil, nil, err)
+	return err
+}
+
+// GetOnlineDDLObjects returns ghost tables and triggers, left by gh-ost or pt-online-schema-change
+func (n *Node) GetOnlineDDLObjects() ([]string, error) {
+	var objects []string
+	err := n.queryRows(queryGetOnlineDDLObjects, nil, func(rows *sqlx.Rows) error {
+		var object struct {
+			Name string `db:"Name"`
+		}
+		err := rows.StructScan(&object)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object.Name)
+		return nil
+	})
+	return objects, err
+}
+
+// GetServerID returns server_id
+func (n *Node) GetServerID() (int64, error) {
+	var result struct {
+		ServerID int64 `db:"ServerID"`
+	}
+	err := n.queryRow(queryGetServerID, nil, &result)
+	return result.ServerID, err
+}
+
+// GetCurrentTime returns current time of MySQL server
+func (n *Node) GetCurrentTime() (time.Time, error) {
+	var result struct {
+		Now float64 `db:"Now"`
+	}
+	err := n.queryRow(queryGetCurrentTime, nil, &result)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, frac := math.Modf(result.Now)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+}
+
+// GetActivePlugins returns names of active plugins
+func (n *Node) GetActivePlugins() ([]string, error) {
+	var plugins []string
+	err := n.queryRows(queryGetActivePlugins, nil, func(rows *sqlx.Rows) error {
+		var plugin struct {
+			Name string `db:"Name"`
+		}
+		err := rows.StructScan(&plugin)
+		if err != nil {
+			return err
+		}
+		plugins = append(plugins, plugin.Name)
+		return nil
+	})
+	return plugins, err
+}
+
+// GetGrants returns grants of current user
+func (n *Node) GetGrants() ([]string, error) {
+	var grants []string
+	err := n.queryRows(queryShowGrants, nil, func(rows *sqlx.Rows) error {
+		var grant string
+		err := rows.Scan(&grant)
+		if err != nil {
+			return err
+		}
+		grants = append(grants, grant)
+		return nil
+	})
+	return grants, err
+}
+
 // SetOffline turns on 'offline_mode'
 func (n *Node) SetOffline() error {
 	return n.exec(queryEnableOfflineMode, nil)
@@ -864,20 +1238,21 @@ func (n *Node) SetOfflineForce() error {
 // ChangeMaster changes master of MySQL Node, demoting it to slave
 func (n *Node) ChangeMaster(host string) error {
 	useSsl := 0
-	if n.config.MySQL.ReplicationSslCA != "" {
+	if n.config.Get().MySQL.ReplicationSslCA != "" {
 		useSsl = 1
 	}
-	return n.execMogrify(queryChangeMaster, map[string]interface{}{
-		"host":            host,
-		"port":            n.config.MySQL.ReplicationPort,
-		"user":            n.config.MySQL.ReplicationUser,
-		"password":        n.config.MySQL.ReplicationPassword,
+	return n.execMogrify(n.replicationQuery(queryChangeMaster), map[string]interface{}{
+		"host":            n.config.Get().ReplicationAddress(host),
+		"port":            n.config.Get().MySQL.ReplicationPort,
+		"user":            n.config.Get().MySQL.ReplicationUser,
+		"password":        n.config.Get().MySQL.ReplicationPassword,
 		"ssl":             useSsl,
-		"sslCa":           n.config.MySQL.ReplicationSslCA,
-		"retryCount":      n.config.MySQL.ReplicationRetryCount,
-		"connectRetry":    n.config.MySQL.ReplicationConnectRetry,
-		"heartbeatPeriod": n.config.MySQL.ReplicationHeartbeatPeriod,
-		"channel":         n.config.ReplicationChannel,
+		"sslCa":           n.config.Get().MySQL.ReplicationSslCA,
+		"retryCount":      n.config.Get().MySQL.ReplicationRetryCount,
+		"connectRetry":    n.config.Get().MySQL.ReplicationConnectRetry,
+		"heartbeatPeriod": n.config.Get().MySQL.ReplicationHeartbeatPeriod,
+		"getPublicKey":    1 - useSsl, // caching_sha2_password requires RSA key exchange without TLS
+		"channel":         n.config.Get().ReplicationChannel,
 	})
 }
 
@@ -897,7 +1272,7 @@ func (n *Node) ReenableEventsRetry() ([]Event, error) {
 
 func (n *Node) ReenableEvents() ([]Event, error) {
 	var events []Event
-	err := n.queryRows(queryListSlavesideDisabledEvents, nil, func(rows *sqlx.Rows) error {
+	err := n.queryRows(n.replicationQuery(queryListSlavesideDisabledEvents), nil, func(rows *sqlx.Rows) error {
 		var event Event
 		err := rows.StructScan(&event)
 		if err != nil {
@@ -940,7 +1315,7 @@ func (n *Node) IsWaitingSemiSyncAck() (bool, error) {
 		IsWaiting bool `db:"IsWaiting"`
 	}
 	var status waitingSemiSyncStatus
-	err := n.queryRow(queryHasWaitingSemiSyncAck, nil, &status)
+	err := n.queryRow(n.semiSyncQuery(queryHasWaitingSemiSyncAck), nil, &status)
 	return status.IsWaiting, err
 }
 
@@ -963,7 +1338,7 @@ func (n *Node) UpdateExternalCAFile() error {
 		return nil
 	}
 	data := replSettings.SourceSslCa
-	fileName := n.config.MySQL.ExternalReplicationSslCA
+	fileName := n.config.Get().MySQL.ExternalReplicationSslCA
 	if data != "" && fileName != "" {
 		err = util.TouchFile(fileName)
 		if err != nil {
@@ -1028,6 +1403,16 @@ type ReplicationSettings struct {
 	SyncBinlog                int `db:"SyncBinlog"`
 }
 
+// GetReplicationSettings returns durability settings of node
+func (n *Node) GetReplicationSettings() (*ReplicationSettings, error) {
+	rs := new(ReplicationSettings)
+	err := n.queryRow(queryGetReplicationSettings, nil, rs)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
 // SetDefaultReplicationSettings sets default values for replication based on the value on the master
 func (n *Node) SetDefaultReplicationSettings(masterNode *Node) error {
 	var rs ReplicationSettings
@@ -1082,3 +1467,123 @@ func (n *Node) UpdateReplMonTable(replMonSchemeName string, replMonTable string)
 		"replMonTable":      schemaname(replMonTable),
 	})
 }
+
+// CloneInstance replaces local data with a snapshot of donor using CLONE plugin.
+// MySQL restarts after successful clone, so connection error may be returned here
+func (n *Node) CloneInstance(donor string, timeout time.Duration) error {
+	err := n.execMogrify(querySetCloneValidDonorList, map[string]interface{}{
+		"donor": util.JoinHostPort(n.config.Get().ReplicationAddress(donor), n.config.Get().MySQL.Port),
+	})
+	if err != nil {
+		return err
+	}
+	user, password := n.config.Get().MySQLCredentials()
+	return n.execMogrifyWithTimeout(queryCloneInstance, map[string]interface{}{
+		"user":     user,
+		"host":     n.config.Get().ReplicationAddress(donor),
+		"port":     n.config.Get().MySQL.Port,
+		"password": password,
+	}, timeout)
+}
+
+// SetCloneThrottling limits bandwidth (bytes per second, 0 means unlimited)
+// and enables network compression of next CLONE INSTANCE
+func (n *Node) SetCloneThrottling(bandwidth int64, compression bool) error {
+	// clone bandwidth is set in MiB per second
+	mib := int((bandwidth + 1<<20 - 1) >> 20)
+	compress := 0
+	if compression {
+		compress = 1
+	}
+	return n.execMogrify(querySetCloneThrottling, map[string]interface{}{
+		"bandwidth":   mib,
+		"compression": compress,
+	})
+}
+
+// GetCloneProgress returns stages of running or last CLONE INSTANCE
+func (n *Node) GetCloneProgress() ([]CloneStage, error) {
+	var stages []CloneStage
+	err := n.queryRows(queryGetCloneProgress, nil, func(rows *sqlx.Rows) error {
+		var stage CloneStage
+		err := rows.StructScan(&stage)
+		if err != nil {
+			return err
+		}
+		stages = append(stages, stage)
+		return nil
+	})
+	return stages, err
+}
+
+// GetCloneStatus returns state of the last CLONE INSTANCE or nil, if nothing was cloned
+func (n *Node) GetCloneStatus() (*CloneStatus, error) {
+	status := new(CloneStatus)
+	err := n.queryRow(queryGetCloneStatus, nil, status)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// RunResetupCommand runs external resetup command with given method and donor.
+// Command should limit data stream to bandwidth (bytes per second, 0 means unlimited),
+// compress it with given compression and may report its progress to resetup_progress_file
+func (n *Node) RunResetupCommand(method, donor string, bandwidth int64, compression string, timeout time.Duration) error {
+	if _, ok := n.config.Get().Commands[commandResetup]; !ok {
+		return fmt.Errorf("resetup command is not configured, set commands.%s", commandResetup)
+	}
+	ret, err := n.runCommandWithEnv(commandResetup, map[string]string{
+		"MYSYNC_RESETUP_METHOD":        method,
+		"MYSYNC_RESETUP_DONOR":         donor,
+		"MYSYNC_RESETUP_PROGRESS_FILE": n.config.Get().ResetupProgressFile,
+		"MYSYNC_RESETUP_BANDWIDTH":     strconv.FormatInt(bandwidth, 10),
+		"MYSYNC_RESETUP_COMPRESSION":   compression,
+	}, timeout)
+	if err != nil {
+		return err
+	}
+	if ret != 0 {
+		return fmt.Errorf("resetup command exited with code %d", ret)
+	}
+	return nil
+}
+
+func (n *Node) runVIPCommand(name, vip, iface string, timeout time.Duration) (int, error) {
+	address, _, _ := strings.Cut(vip, "/")
+	return n.runCommandWithEnv(name, map[string]string{
+		"MYSYNC_VIP":           vip,
+		"MYSYNC_VIP_ADDRESS":   address,
+		"MYSYNC_VIP_INTERFACE": iface,
+	}, timeout)
+}
+
+// AddVIP assigns virtual IP to the local host
+func (n *Node) AddVIP(vip, iface string, timeout time.Duration) error {
+	ret, err := n.runVIPCommand(commandVIPAdd, vip, iface, timeout)
+	if err == nil && ret != 0 {
+		err = fmt.Errorf("vip_add command exited with code %d", ret)
+	}
+	return err
+}
+
+// DeleteVIP removes virtual IP from the local host
+func (n *Node) DeleteVIP(vip, iface string, timeout time.Duration) error {
+	ret, err := n.runVIPCommand(commandVIPDel, vip, iface, timeout)
+	if err == nil && ret != 0 {
+		err = fmt.Errorf("vip_del command exited with code %d", ret)
+	}
+	return err
+}
+
+// IsVIPFree checks that no other host answers for virtual IP
+func (n *Node) IsVIPFree(vip, iface string, timeout time.Duration) (bool, error) {
+	ret, err := n.runVIPCommand(commandVIPCheck, vip, iface, timeout)
+	if ret > 0 {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// queryClass defines timeout and retry policy of query
+type queryClass int
+
+const (
+	// status reads are idempotent, so they are retried after network errors and timeouts
+	queryClassRead queryClass = iota
+	// topology-changing statements are never retried: after an error their outcome is unknown
+	queryClassWrite
+)
+
+// queryTimeout returns timeout of query: per query override, then timeout of query class, then db_timeout
+func (n *Node) queryTimeout(queryName string, class queryClass) time.Duration {
+	if timeout, ok := n.config.Get().QueryTimeouts[queryName]; ok && timeout > 0 {
+		return timeout
+	}
+	classTimeout := n.config.Get().ReadQueryTimeout
+	if class == queryClassWrite {
+		classTimeout = n.config.Get().WriteQueryTimeout
+	}
+	if classTimeout > 0 {
+		return classTimeout
+	}
+	return n.config.Get().DBTimeout
+}
+
+// isRetryableReadError checks that read may succeed on next attempt.
+// Errors returned by server (syntax, privileges, etc) and empty results are final.
+func isRetryableReadError(err error) bool {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	var serverErr *mysql.MySQLError
+	return !errors.As(err, &serverErr)
+}
+
+// retryRead runs attempt of status read according to read_query_retries.
+// Each attempt should use its own context, so hung one does not consume time of others.
+func (n *Node) retryRead(queryName string, attempt func() error) error {
+	err := attempt()
+	for i := 0; i < n.config.Get().ReadQueryRetries && isRetryableReadError(err); i++ {
+		n.logger.Warnf("node %s: query %s failed, retrying (%d/%d): %v", n.host, queryName, i+1, n.config.Get().ReadQueryRetries, err)
+		time.Sleep(n.config.Get().ReadQueryRetryInterval)
+		err = attempt()
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+)
+
+func newPolicyTestNode(t *testing.T) *Node {
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	cfg.ReadQueryRetryInterval = time.Millisecond
+	logger, err := log.Open("", "Error")
+	require.NoError(t, err)
+	return &Node{config: config.NewHolder(&cfg), logger: logger, host: "db1"}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	n := newPolicyTestNode(t)
+	require.Equal(t, n.config.Get().DBTimeout, n.queryTimeout(querySlaveStatus, queryClassRead))
+	require.Equal(t, n.config.Get().DBTimeout, n.queryTimeout(queryStopSlave, queryClassWrite))
+
+	n.config.Get().ReadQueryTimeout = time.Second
+	n.config.Get().WriteQueryTimeout = time.Minute
+	n.config.Get().QueryTimeouts[queryStopSlave] = 10 * time.Second
+	require.Equal(t, time.Second, n.queryTimeout(querySlaveStatus, queryClassRead))
+	require.Equal(t, time.Minute, n.queryTimeout(queryStartSlave, queryClassWrite))
+	require.Equal(t, 10*time.Second, n.queryTimeout(queryStopSlave, queryClassWrite))
+}
+
+func TestRetryRead(t *testing.T) {
+	n := newPolicyTestNode(t)
+	n.config.Get().ReadQueryRetries = 2
+
+	attempts := 0
+	err := n.retryRead(queryPing, func() error {
+		attempts++
+		return context.DeadlineExceeded
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = n.retryRead(queryPing, func() error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+
+	for _, final := range []error{sql.ErrNoRows, fmt.Errorf("query: %w", &mysql.MySQLError{Number: 1227})} {
+		attempts = 0
+		err = n.retryRead(queryPing, func() error {
+			attempts++
+			return final
+		})
+		require.Equal(t, final, err)
+		require.Equal(t, 1, attempts)
+	}
+}
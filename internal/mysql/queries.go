@@ -27,17 +27,34 @@ const (
 	querySemiSyncSetSlave               = "semisync_set_slave"
 	querySemiSyncDisable                = "semisync_disable"
 	querySetSemiSyncWaitSlaveCount      = "set_semisync_wait_slave_count"
+	querySemiSyncWaitStatus             = "semisync_wait_status"
 	queryListSlavesideDisabledEvents    = "list_slaveside_disabled_events"
 	queryEnableEvent                    = "enable_event"
 	querySetLockTimeout                 = "set_lock_timeout"
 	queryKillQuery                      = "kill_query"
 	queryGetProcessIDs                  = "get_process_ids"
+	queryGetTransactionProcessIDs       = "get_transaction_process_ids"
+	queryIsBackupRunning                = "is_backup_running"
+	queryGetOnlineDDLObjects            = "get_online_ddl_objects"
+	queryGetServerID                    = "get_server_id"
+	queryGetCurrentTime                 = "get_current_time"
+	queryGetActivePlugins               = "get_active_plugins"
+	queryShowGrants                     = "show_grants"
+	querySetCloneValidDonorList         = "set_clone_valid_donor_list"
+	queryCloneInstance                  = "clone_instance"
+	queryGetCloneProgress               = "get_clone_progress"
+	queryGetCloneStatus                 = "get_clone_status"
+	querySetCloneThrottling             = "set_clone_throttling"
+	queryHeartbeatLag                   = "heartbeat_lag"
+	queryApplierLag                     = "applier_lag"
 	queryEnableOfflineMode              = "enable_offline_mode"
 	queryDisableOfflineMode             = "disable_offline_mode"
 	queryGetOfflineMode                 = "get_offline_mode"
 	queryHasWaitingSemiSyncAck          = "has_waiting_semi_sync_ack"
 	queryGetLastStartupTime             = "get_last_startup_time"
+	queryGetThreadsRunning              = "get_threads_running"
 	queryGetExternalReplicationSettings = "get_external_replication_settings"
+	queryUpdateExternalSourceHost       = "update_external_source_host"
 	queryChangeSource                   = "change_source"
 	queryResetReplicaAll                = "reset_replica_all"
 	queryStopReplica                    = "stop_replica"
@@ -50,6 +67,29 @@ const (
 	queryCalcReplMonTSDelay             = "calc_repl_mon_ts_delay"
 	queryCreateReplMonTable             = "create_repl_mon_table"
 	queryUpdateReplMon                  = "update_repl_mon"
+	queryCreateReadOnlyProbeTable       = "create_read_only_probe_table"
+	queryReadOnlyProbe                  = "read_only_probe"
+	queryStartBufferPoolLoad            = "start_buffer_pool_load"
+	queryBufferPoolLoadStatus           = "buffer_pool_load_status"
+	queryGetMaxConnections              = "get_max_connections"
+	querySetMaxConnections              = "set_max_connections"
+	queryGetMaxUserConnections          = "get_max_user_connections"
+	querySetMaxUserConnections          = "set_max_user_connections"
+
+	// "source/replica" syntax, the only one since MySQL 8.4
+	queryStopReplicaIOThread           = "stop_replica_io_thread"
+	queryStartReplicaIOThread          = "start_replica_io_thread"
+	queryStopReplicaSQLThread          = "stop_replica_sql_thread"
+	queryStartReplicaSQLThread         = "start_replica_sql_thread"
+	queryChangeReplicationSource       = "change_replication_source"
+	queryListReplicaSideDisabledEvents = "list_replica_side_disabled_events"
+	querySemiSyncSourceStatus          = "semisync_source_status"
+	querySemiSyncSetSource             = "semisync_set_source"
+	querySemiSyncSetReplica            = "semisync_set_replica"
+	querySemiSyncDisableSource         = "semisync_disable_source"
+	querySetSemiSyncWaitReplicaCount   = "set_semisync_wait_replica_count"
+	queryHasWaitingSemiSyncAckReplica  = "has_waiting_semi_sync_ack_replica"
+	querySemiSyncSourceWaitStatus      = "semisync_source_wait_status"
 )
 
 var DefaultQueries = map[string]string{
@@ -96,19 +136,55 @@ var DefaultQueries = map[string]string{
 	querySemiSyncSetSlave:          `SET GLOBAL rpl_semi_sync_slave_enabled = 1, rpl_semi_sync_master_enabled = 0`,
 	querySemiSyncDisable:           `SET GLOBAL rpl_semi_sync_slave_enabled = 0, rpl_semi_sync_master_enabled = 0`,
 	querySetSemiSyncWaitSlaveCount: `SET GLOBAL rpl_semi_sync_master_wait_for_slave_count = :wait_slave_count`,
+	querySemiSyncWaitStatus: `SELECT IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_master_wait_sessions', variable_value, 0)), 0) AS WaitSessions,
+								 IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_master_clients', variable_value, 0)), 0) AS Clients,
+								 IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_master_yes_tx', variable_value, 0)), 0) AS YesTx
+								 FROM performance_schema.global_status
+								 WHERE variable_name IN ('Rpl_semi_sync_master_wait_sessions', 'Rpl_semi_sync_master_clients', 'Rpl_semi_sync_master_yes_tx')`,
 	queryListSlavesideDisabledEvents: `SELECT EVENT_SCHEMA, EVENT_NAME, DEFINER
 										FROM information_schema.EVENTS
 										WHERE STATUS = 'SLAVESIDE_DISABLED'`,
 
-	queryEnableEvent:           `ALTER DEFINER = :user@:host EVENT :schema.:name ENABLE`,
-	querySetLockTimeout:        `SET SESSION lock_wait_timeout = ?`,
-	queryKillQuery:             `KILL :kill_id`,
-	queryGetProcessIDs:         `SELECT ID FROM information_schema.PROCESSLIST p WHERE USER NOT IN (?) AND COMMAND != 'Killed'`,
-	queryEnableOfflineMode:     `SET GLOBAL offline_mode = ON`,
-	queryDisableOfflineMode:    `SET GLOBAL offline_mode = OFF`,
-	queryGetOfflineMode:        `SELECT @@GLOBAL.offline_mode AS OfflineMode`,
-	queryHasWaitingSemiSyncAck: `SELECT count(*) <> 0 AS IsWaiting FROM information_schema.PROCESSLIST WHERE state = 'Waiting for semi-sync ACK from slave'`,
-	queryGetLastStartupTime:    `SELECT UNIX_TIMESTAMP(DATE_SUB(now(), INTERVAL variable_value SECOND)) AS LastStartup FROM performance_schema.global_status WHERE variable_name='Uptime'`,
+	queryEnableEvent:    `ALTER DEFINER = :user@:host EVENT :schema.:name ENABLE`,
+	querySetLockTimeout: `SET SESSION lock_wait_timeout = ?`,
+	queryKillQuery:      `KILL :kill_id`,
+	queryGetProcessIDs:  `SELECT ID FROM information_schema.PROCESSLIST p WHERE USER NOT IN (?) AND COMMAND != 'Killed'`,
+	queryGetTransactionProcessIDs: `SELECT p.ID FROM information_schema.INNODB_TRX t
+										JOIN information_schema.PROCESSLIST p ON t.trx_mysql_thread_id = p.ID
+										WHERE p.USER NOT IN (?) AND p.COMMAND != 'Killed'`,
+	queryIsBackupRunning: `SELECT COUNT(*) > 0 AS IsRunning
+										FROM performance_schema.metadata_locks
+										WHERE OBJECT_TYPE = 'BACKUP LOCK'`,
+	queryGetOnlineDDLObjects: `SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) AS Name
+										FROM information_schema.TABLES
+										WHERE TABLE_NAME LIKE '|_%|_gho' ESCAPE '|' OR TABLE_NAME LIKE '|_%|_ghc' ESCAPE '|' OR TABLE_NAME LIKE '|_%|_new' ESCAPE '|'
+										UNION ALL
+										SELECT CONCAT(TRIGGER_SCHEMA, '.', TRIGGER_NAME) AS Name
+										FROM information_schema.TRIGGERS
+										WHERE TRIGGER_NAME LIKE 'pt|_osc|_%' ESCAPE '|'`,
+	queryGetServerID:            `SELECT @@server_id AS ServerID`,
+	queryGetCurrentTime:         `SELECT UNIX_TIMESTAMP(NOW(6)) AS Now`,
+	queryGetActivePlugins:       `SELECT PLUGIN_NAME AS Name FROM information_schema.PLUGINS WHERE PLUGIN_STATUS = 'ACTIVE'`,
+	queryShowGrants:             `SHOW GRANTS`,
+	querySetCloneValidDonorList: `SET GLOBAL clone_valid_donor_list = :donor`,
+	queryCloneInstance:          `CLONE INSTANCE FROM :user@:host::port IDENTIFIED BY :password`,
+	querySetCloneThrottling:     `SET GLOBAL clone_max_data_bandwidth = :bandwidth, GLOBAL clone_max_network_bandwidth = :bandwidth, GLOBAL clone_enable_compression = :compression`,
+	queryGetCloneProgress:       `SELECT STAGE AS Stage, STATE AS State, IFNULL(ESTIMATE, 0) AS Estimate, IFNULL(DATA, 0) AS Data FROM performance_schema.clone_progress ORDER BY ID`,
+	queryGetCloneStatus:         `SELECT STATE AS State, ERROR_NO AS ErrorNo, IFNULL(ERROR_MESSAGE, '') AS ErrorMessage FROM performance_schema.clone_status`,
+	queryHeartbeatLag:           `SELECT GREATEST(0, UNIX_TIMESTAMP(CURRENT_TIMESTAMP(3)) - UNIX_TIMESTAMP(ts)) AS Lag FROM :replMonSchemeName.:replMonTable`,
+	queryApplierLag:             `SELECT IFNULL(TIMESTAMPDIFF(MICROSECOND, MIN(NULLIF(APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP, '0000-00-00 00:00:00.000000')), NOW(6)) / 1000000, 0) AS Lag FROM performance_schema.replication_applier_status_by_worker WHERE CHANNEL_NAME = :channel`,
+	queryEnableOfflineMode:      `SET GLOBAL offline_mode = ON`,
+	queryDisableOfflineMode:     `SET GLOBAL offline_mode = OFF`,
+	queryGetOfflineMode:         `SELECT @@GLOBAL.offline_mode AS OfflineMode`,
+	queryHasWaitingSemiSyncAck:  `SELECT count(*) <> 0 AS IsWaiting FROM information_schema.PROCESSLIST WHERE state = 'Waiting for semi-sync ACK from slave'`,
+	queryGetThreadsRunning:      `SELECT CAST(variable_value AS UNSIGNED) AS ThreadsRunning FROM performance_schema.global_status WHERE variable_name='Threads_running'`,
+	queryGetLastStartupTime:     `SELECT UNIX_TIMESTAMP(DATE_SUB(now(), INTERVAL variable_value SECOND)) AS LastStartup FROM performance_schema.global_status WHERE variable_name='Uptime'`,
+	queryStartBufferPoolLoad:    `SET GLOBAL innodb_buffer_pool_load_now = ON`,
+	queryBufferPoolLoadStatus:   `SELECT variable_value AS Status FROM performance_schema.global_status WHERE variable_name='Innodb_buffer_pool_load_status'`,
+	queryGetMaxConnections:      `SELECT @@GLOBAL.max_connections AS MaxConnections`,
+	querySetMaxConnections:      `SET GLOBAL max_connections = :max_connections`,
+	queryGetMaxUserConnections:  `SELECT max_user_connections AS MaxUserConnections FROM mysql.user WHERE user = :user AND host = :host`,
+	querySetMaxUserConnections:  `ALTER USER :user@:host WITH MAX_USER_CONNECTIONS :max_user_connections`,
 	queryGetExternalReplicationSettings: `SELECT channel_name AS ChannelName, source_host AS SourceHost, source_user AS SourceUser, source_port AS SourcePort,
 											source_password AS SourcePassword, source_ssl_ca AS SourceSslCa, source_delay AS SourceDelay, replication_status AS ReplicationStatus
 											FROM mysql.replication_settings WHERE channel_name = 'external'`,
@@ -126,6 +202,7 @@ var DefaultQueries = map[string]string{
 								SOURCE_DELAY = :sourceDelay
 						FOR CHANNEL :channel`,
 	queryIgnoreDB:                     `CHANGE REPLICATION FILTER REPLICATE_IGNORE_DB = (:ignoreList) FOR CHANNEl :channel`,
+	queryUpdateExternalSourceHost:     `UPDATE mysql.replication_settings SET source_host = :host WHERE channel_name = 'external'`,
 	querySetInnodbFlushLogAtTrxCommit: `SET GLOBAL innodb_flush_log_at_trx_commit = :level`,
 	queryGetReplicationSettings:       `SELECT @@innodb_flush_log_at_trx_commit as InnodbFlushLogAtTrxCommit, @@sync_binlog as SyncBinlog`,
 	querySetSyncBinlog:                `SET GLOBAL sync_binlog = :sync_binlog`,
@@ -136,10 +213,51 @@ var DefaultQueries = map[string]string{
 												ts TIMESTAMP(3)
 										)
 										ENGINE=INNODB`,
+	queryStopReplicaIOThread:   `STOP REPLICA IO_THREAD FOR CHANNEL :channel`,
+	queryStartReplicaIOThread:  `START REPLICA IO_THREAD FOR CHANNEL :channel`,
+	queryStopReplicaSQLThread:  `STOP REPLICA SQL_THREAD FOR CHANNEL :channel`,
+	queryStartReplicaSQLThread: `START REPLICA SQL_THREAD FOR CHANNEL :channel`,
+	queryChangeReplicationSource: `CHANGE REPLICATION SOURCE TO
+								SOURCE_HOST = :host ,
+								SOURCE_PORT = :port ,
+								SOURCE_USER = :user ,
+								SOURCE_PASSWORD = :password ,
+								SOURCE_SSL = :ssl ,
+								SOURCE_SSL_CA = :sslCa ,
+								SOURCE_SSL_VERIFY_SERVER_CERT = 1,
+								GET_SOURCE_PUBLIC_KEY = :getPublicKey,
+								SOURCE_AUTO_POSITION = 1,
+								SOURCE_CONNECT_RETRY = :connectRetry,
+								SOURCE_RETRY_COUNT = :retryCount,
+								SOURCE_HEARTBEAT_PERIOD = :heartbeatPeriod
+						FOR CHANNEL :channel`,
+	queryListReplicaSideDisabledEvents: `SELECT EVENT_SCHEMA, EVENT_NAME, DEFINER
+										FROM information_schema.EVENTS
+										WHERE STATUS = 'REPLICA_SIDE_DISABLED'`,
+	querySemiSyncSourceStatus: `SELECT @@rpl_semi_sync_source_enabled AS MasterEnabled,
+								 @@rpl_semi_sync_replica_enabled AS SlaveEnabled,
+								 @@rpl_semi_sync_source_wait_for_replica_count as WaitSlaveCount`,
+	querySemiSyncSetSource:            `SET GLOBAL rpl_semi_sync_source_enabled = 1, rpl_semi_sync_replica_enabled = 0`,
+	querySemiSyncSetReplica:           `SET GLOBAL rpl_semi_sync_replica_enabled = 1, rpl_semi_sync_source_enabled = 0`,
+	querySemiSyncDisableSource:        `SET GLOBAL rpl_semi_sync_replica_enabled = 0, rpl_semi_sync_source_enabled = 0`,
+	querySetSemiSyncWaitReplicaCount:  `SET GLOBAL rpl_semi_sync_source_wait_for_replica_count = :wait_slave_count`,
+	queryHasWaitingSemiSyncAckReplica: `SELECT count(*) <> 0 AS IsWaiting FROM information_schema.PROCESSLIST WHERE state = 'Waiting for semi-sync ACK from replica'`,
+	querySemiSyncSourceWaitStatus: `SELECT IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_source_wait_sessions', variable_value, 0)), 0) AS WaitSessions,
+								 IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_source_clients', variable_value, 0)), 0) AS Clients,
+								 IFNULL(SUM(IF(variable_name = 'Rpl_semi_sync_source_yes_tx', variable_value, 0)), 0) AS YesTx
+								 FROM performance_schema.global_status
+								 WHERE variable_name IN ('Rpl_semi_sync_source_wait_sessions', 'Rpl_semi_sync_source_clients', 'Rpl_semi_sync_source_yes_tx')`,
 	queryUpdateReplMon: `INSERT INTO :replMonSchemeName.:replMonTable(id, ts)
 										(
 											SELECT 1, CURRENT_TIMESTAMP(3)
 											WHERE @@read_only = 0
 										)
 									ON DUPLICATE KEY UPDATE ts = CURRENT_TIMESTAMP(3)`,
+	queryCreateReadOnlyProbeTable: `CREATE TABLE IF NOT EXISTS :schema.:table(
+												host VARCHAR(255) NOT NULL PRIMARY KEY,
+												ts TIMESTAMP(3)
+										)
+										ENGINE=INNODB`,
+	queryReadOnlyProbe: `INSERT INTO :schema.:table(host, ts) VALUES (:host, CURRENT_TIMESTAMP(3))
+									ON DUPLICATE KEY UPDATE ts = CURRENT_TIMESTAMP(3)`,
 }
@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/yandex/mysync/internal/util"
+)
+
+// CreateReadOnlyProbeTable creates table, which probe user writes to
+func (n *Node) CreateReadOnlyProbeTable() error {
+	return n.execMogrify(queryCreateReadOnlyProbeTable, map[string]interface{}{
+		"schema": schemaname(n.config.Get().ReadOnlyProbe.Schema),
+		"table":  schemaname(n.config.Get().ReadOnlyProbe.Table),
+	})
+}
+
+// ProbeReadOnly attempts canary write as low-privilege probe user and rolls it back.
+// Read-only node rejects it, while accepted write means that read_only is bypassed, e.g. by proxy or grants.
+// Error means that probe is inconclusive.
+func (n *Node) ProbeReadOnly() (accepted bool, err error) {
+	probe := n.config.Get().ReadOnlyProbe
+	port := probe.Port
+	if port == 0 {
+		port = n.config.Get().MySQL.Port
+	}
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = util.JoinHostPort(n.config.Get().ManagementAddress(n.host), port)
+	cfg.User = probe.User
+	cfg.Passwd = probe.Password
+	cfg.Timeout = probe.Timeout
+	cfg.ReadTimeout = probe.Timeout
+	cfg.WriteTimeout = probe.Timeout
+	if n.config.Get().MySQL.SslCA != "" {
+		cfg.TLSConfig = tlsConfigName(n.config.Get())
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return false, err
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), probe.Timeout)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	query := Mogrify(n.getQuery(queryReadOnlyProbe), map[string]interface{}{
+		"schema": schemaname(probe.Schema),
+		"table":  schemaname(probe.Table),
+		"host":   n.host,
+	})
+	start := time.Now()
+	_, err = tx.ExecContext(ctx, query)
+	n.traceQuery(start, query, nil, nil, err)
+	if err == nil {
+		return true, nil
+	}
+	if IsErrorReadOnly(err) {
+		return false, nil
+	}
+	return false, err
+}
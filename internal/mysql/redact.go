@@ -0,0 +1,42 @@
+package mysql
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redacted = "********"
+
+// quoted values following password clauses of CHANGE MASTER/REPLICATION SOURCE, CREATE/ALTER USER,
+// SET PASSWORD and CLONE INSTANCE
+var passwordClauseRegex = regexp.MustCompile(`(?i)((?:MASTER_PASSWORD|SOURCE_PASSWORD|PASSWORD(?:\s+FOR\s+\S+)?)\s*=\s*|IDENTIFIED\s+(?:WITH\s+\S+\s+)?BY\s+|PASSWORD\s*\(\s*)'(?:[^'\\]|\\.)*'`)
+
+// RedactQuery hides passwords in query text, so it may be logged
+func RedactQuery(query string) string {
+	return passwordClauseRegex.ReplaceAllString(query, "${1}'"+redacted+"'")
+}
+
+// redactArgs hides values of named query arguments, which look like passwords
+func redactArgs(arg interface{}) interface{} {
+	args, ok := arg.(map[string]interface{})
+	if !ok {
+		return arg
+	}
+	var result map[string]interface{}
+	for key := range args {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "password") || strings.Contains(lower, "passwd") {
+			if result == nil {
+				result = make(map[string]interface{}, len(args))
+				for k, v := range args {
+					result[k] = v
+				}
+			}
+			result[key] = redacted
+		}
+	}
+	if result == nil {
+		return arg
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactQuery(t *testing.T) {
+	cases := map[string]string{
+		`CHANGE MASTER TO MASTER_HOST = 'db1', MASTER_USER = 'repl', MASTER_PASSWORD = 'se\'cret', MASTER_PORT = 3306`: `CHANGE MASTER TO MASTER_HOST = 'db1', MASTER_USER = 'repl', MASTER_PASSWORD = '********', MASTER_PORT = 3306`,
+		`CHANGE REPLICATION SOURCE TO SOURCE_PASSWORD='secret' FOR CHANNEL 'external'`:                                 `CHANGE REPLICATION SOURCE TO SOURCE_PASSWORD='********' FOR CHANNEL 'external'`,
+		`CLONE INSTANCE FROM 'admin'@'db2':3306 IDENTIFIED BY 'secret'`:                                                `CLONE INSTANCE FROM 'admin'@'db2':3306 IDENTIFIED BY '********'`,
+		`ALTER USER 'admin'@'%' IDENTIFIED WITH caching_sha2_password BY 'secret'`:                                     `ALTER USER 'admin'@'%' IDENTIFIED WITH caching_sha2_password BY '********'`,
+		`SET PASSWORD FOR 'admin'@'%' = 'secret'`:                                                                      `SET PASSWORD FOR 'admin'@'%' = '********'`,
+		`SELECT @@read_only AS ReadOnly`:                                                                               `SELECT @@read_only AS ReadOnly`,
+	}
+	for query, expected := range cases {
+		require.Equal(t, expected, RedactQuery(query))
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	args := map[string]interface{}{"user": "repl", "password": "secret"}
+	require.Equal(t, map[string]interface{}{"user": "repl", "password": "********"}, redactArgs(args))
+	require.Equal(t, "secret", args["password"])
+	require.Equal(t, []int{1}, redactArgs([]int{1}))
+}
@@ -1,7 +1,16 @@
 package mysql
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
 
 	"github.com/yandex/mysync/internal/log"
 	"github.com/yandex/mysync/internal/util"
@@ -15,6 +24,8 @@ type IExternalReplication interface {
 	GetReplicaStatus(*Node) (ReplicaStatus, error)
 	Stop(*Node) error
 	IsRunningByUser(*Node) bool
+	FindWritableSource(n *Node, hosts []string, timeout time.Duration) (string, error)
+	Repoint(n *Node, host string) error
 }
 
 type UnimplementedExternalReplication struct{}
@@ -47,6 +58,14 @@ func (d *UnimplementedExternalReplication) Stop(*Node) error {
 	return nil
 }
 
+func (d *UnimplementedExternalReplication) FindWritableSource(*Node, []string, time.Duration) (string, error) {
+	return "", nil
+}
+
+func (d *UnimplementedExternalReplication) Repoint(*Node, string) error {
+	return nil
+}
+
 type ExternalReplication struct {
 	logger *log.Logger
 }
@@ -89,9 +108,9 @@ func (er *ExternalReplication) Set(n *Node) error {
 	}
 	useSsl := 0
 	sslCa := ""
-	if replSettings.SourceSslCa != "" && n.config.MySQL.ExternalReplicationSslCA != "" {
+	if replSettings.SourceSslCa != "" && n.config.Get().MySQL.ExternalReplicationSslCA != "" {
 		useSsl = 1
-		sslCa = n.config.MySQL.ExternalReplicationSslCA
+		sslCa = n.config.Get().MySQL.ExternalReplicationSslCA
 	}
 	err = er.Stop(n)
 	if err != nil {
@@ -109,9 +128,9 @@ func (er *ExternalReplication) Set(n *Node) error {
 		"ssl":             useSsl,
 		"sslCa":           sslCa,
 		"sourceDelay":     replSettings.SourceDelay,
-		"retryCount":      n.config.MySQL.ReplicationRetryCount,
-		"connectRetry":    n.config.MySQL.ReplicationConnectRetry,
-		"heartbeatPeriod": n.config.MySQL.ReplicationHeartbeatPeriod,
+		"retryCount":      n.config.Get().MySQL.ReplicationRetryCount,
+		"connectRetry":    n.config.Get().MySQL.ReplicationConnectRetry,
+		"heartbeatPeriod": n.config.Get().MySQL.ReplicationHeartbeatPeriod,
 		"channel":         "external",
 	})
 	if err != nil {
@@ -152,7 +171,7 @@ func (er *ExternalReplication) GetReplicaStatus(n *Node) (ReplicaStatus, error)
 		return nil, nil
 	}
 
-	return n.ReplicaStatusWithTimeout(n.config.DBTimeout, n.config.ExternalReplicationChannel)
+	return n.ReplicaStatusWithTimeout(n.config.Get().DBTimeout, n.config.Get().ExternalReplicationChannel)
 }
 
 // StartExternalReplication starts external replication
@@ -163,7 +182,7 @@ func (er *ExternalReplication) Start(n *Node) error {
 	}
 	if checked {
 		err := n.execMogrify(queryStartReplica, map[string]interface{}{
-			"channel": n.config.ExternalReplicationChannel,
+			"channel": n.config.Get().ExternalReplicationChannel,
 		})
 		if err != nil {
 			return err
@@ -180,7 +199,7 @@ func (er *ExternalReplication) Stop(n *Node) error {
 	}
 	if checked {
 		err := n.execMogrify(queryStopReplica, map[string]interface{}{
-			"channel": n.config.ExternalReplicationChannel,
+			"channel": n.config.Get().ExternalReplicationChannel,
 		})
 		if err != nil && !IsErrorChannelDoesNotExists(err) {
 			return err
@@ -197,7 +216,7 @@ func (er *ExternalReplication) Reset(n *Node) error {
 	}
 	if checked {
 		err := n.execMogrify(queryResetReplicaAll, map[string]interface{}{
-			"channel": n.config.ExternalReplicationChannel,
+			"channel": n.config.Get().ExternalReplicationChannel,
 		})
 		if err != nil && !IsErrorChannelDoesNotExists(err) {
 			return err
@@ -205,3 +224,86 @@ func (er *ExternalReplication) Reset(n *Node) error {
 	}
 	return nil
 }
+
+// FindWritableSource returns the first of upstream hosts, which is writable now.
+// Hosts are checked with credentials of external channel, read from node.
+func (er *ExternalReplication) FindWritableSource(n *Node, hosts []string, timeout time.Duration) (string, error) {
+	var replSettings replicationSettings
+	err := n.queryRow(queryGetExternalReplicationSettings, nil, &replSettings)
+	if err != nil {
+		return "", err
+	}
+	var lastErr error
+	for _, host := range hosts {
+		writable, err := er.isSourceWritable(n, replSettings, host, timeout)
+		if err != nil {
+			er.logger.Warnf("external replication: failed to check source %s: %v", host, err)
+			lastErr = err
+			continue
+		}
+		if writable {
+			return host, nil
+		}
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("no writable source found, last error: %v", lastErr)
+	}
+	return "", nil
+}
+
+func (er *ExternalReplication) isSourceWritable(n *Node, replSettings replicationSettings, host string, timeout time.Duration) (bool, error) {
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = util.JoinHostPort(host, replSettings.SourcePort)
+	cfg.User = replSettings.SourceUser
+	cfg.Passwd = replSettings.SourcePassword
+	cfg.Timeout = timeout
+	cfg.ReadTimeout = timeout
+	if replSettings.SourceSslCa != "" {
+		name, err := registerSourceTLSConfig(replSettings.SourceSslCa)
+		if err != nil {
+			return false, err
+		}
+		cfg.TLSConfig = name
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return false, err
+	}
+	db := sqlx.NewDb(sql.OpenDB(connector), "mysql").Unsafe()
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var ror readOnlyResult
+	err = db.GetContext(ctx, &ror, n.getQuery(queryIsReadOnly))
+	if err != nil {
+		return false, err
+	}
+	return ror.ReadOnly == 0 && ror.SuperReadOnly == 0, nil
+}
+
+// registerSourceTLSConfig registers TLS config for CA of external source stored in replication settings
+func registerSourceTLSConfig(ca string) (string, error) {
+	name := fmt.Sprintf("external-%x", sha256.Sum256([]byte(ca)))[:23]
+	rootCertPool := x509.NewCertPool()
+	if ok := rootCertPool.AppendCertsFromPEM([]byte(ca)); !ok {
+		return "", fmt.Errorf("failed to parse PEM certificate of external source")
+	}
+	err := mysql.RegisterTLSConfig(name, &tls.Config{RootCAs: rootCertPool})
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Repoint saves new source host of external channel and sets replication up from it.
+// Settings are stored on node, so replicas get them and keep the source after switchover.
+func (er *ExternalReplication) Repoint(n *Node, host string) error {
+	err := n.execMogrify(queryUpdateExternalSourceHost, map[string]interface{}{
+		"host": host,
+	})
+	if err != nil {
+		return err
+	}
+	return er.Set(n)
+}
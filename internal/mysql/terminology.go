@@ -0,0 +1,87 @@
+package mysql
+
+import "github.com/yandex/mysync/internal/util"
+
+const (
+	Version84Minor               = 4
+	Version80PatchSemiSyncSource = 26
+)
+
+// RequiresReplicaTerminology is true for MySQL 8.4 and newer, where "master/slave" replication syntax is removed
+func (v *Version) RequiresReplicaTerminology() bool {
+	return v.MajorVersion > Version80Major || (v.MajorVersion == Version80Major && v.MinorVersion >= Version84Minor)
+}
+
+// SupportsSemiSyncSourcePlugin is true if "source/replica" semisync plugins may be installed instead of legacy ones
+func (v *Version) SupportsSemiSyncSourcePlugin() bool {
+	if v.MajorVersion != Version80Major {
+		return v.MajorVersion > Version80Major
+	}
+	return v.MinorVersion > Version80Minor || v.PatchVersion >= Version80PatchSemiSyncSource
+}
+
+// replicaQueries maps queries using "master/slave" syntax to their "source/replica" counterparts
+var replicaQueries = map[string]string{
+	queryStopSlave:                   queryStopReplica,
+	queryStartSlave:                  queryStartReplica,
+	queryStopSlaveIOThread:           queryStopReplicaIOThread,
+	queryStartSlaveIOThread:          queryStartReplicaIOThread,
+	queryStopSlaveSQLThread:          queryStopReplicaSQLThread,
+	queryStartSlaveSQLThread:         queryStartReplicaSQLThread,
+	queryResetSlaveAll:               queryResetReplicaAll,
+	queryChangeMaster:                queryChangeReplicationSource,
+	queryListSlavesideDisabledEvents: queryListReplicaSideDisabledEvents,
+}
+
+// semiSyncSourceQueries maps queries to variables of legacy semisync plugins
+// to ones of "source/replica" plugins
+var semiSyncSourceQueries = map[string]string{
+	querySemiSyncStatus:            querySemiSyncSourceStatus,
+	querySemiSyncSetMaster:         querySemiSyncSetSource,
+	querySemiSyncSetSlave:          querySemiSyncSetReplica,
+	querySemiSyncDisable:           querySemiSyncDisableSource,
+	querySetSemiSyncWaitSlaveCount: querySetSemiSyncWaitReplicaCount,
+	queryHasWaitingSemiSyncAck:     queryHasWaitingSemiSyncAckReplica,
+	querySemiSyncWaitStatus:        querySemiSyncSourceWaitStatus,
+}
+
+// replicationQuery returns name of query, matching replication syntax of host version.
+// Legacy syntax is used if version is unknown, it works on all versions before 8.4.
+func (n *Node) replicationQuery(name string) string {
+	if replica, ok := replicaQueries[name]; ok {
+		if version, err := n.GetVersion(); err == nil && version.RequiresReplicaTerminology() {
+			return replica
+		}
+	}
+	return name
+}
+
+// semiSyncQuery returns name of query, matching semisync plugins loaded on host
+func (n *Node) semiSyncQuery(name string) string {
+	if source, ok := semiSyncSourceQueries[name]; ok && n.hasSemiSyncSourcePlugin() {
+		return source
+	}
+	return name
+}
+
+// hasSemiSyncSourcePlugin checks that semisync is provided by "source/replica" plugins,
+// they are the only ones since 8.4 and may replace legacy plugins since 8.0.26
+func (n *Node) hasSemiSyncSourcePlugin() bool {
+	if n.semiSyncSource != nil {
+		return *n.semiSyncSource
+	}
+	version, err := n.GetVersion()
+	if err != nil {
+		return false
+	}
+	source := version.RequiresReplicaTerminology()
+	if !source && version.SupportsSemiSyncSourcePlugin() {
+		plugins, err := n.GetActivePlugins()
+		if err != nil {
+			return false
+		}
+		source = util.ContainsString(plugins, "rpl_semi_sync_source") || util.ContainsString(plugins, "rpl_semi_sync_replica")
+	}
+	n.semiSyncSource = &source
+	return source
+}
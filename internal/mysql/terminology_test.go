@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaTerminology(t *testing.T) {
+	require.False(t, (&Version{5, 7, 44}).RequiresReplicaTerminology())
+	require.False(t, (&Version{8, 0, 39}).RequiresReplicaTerminology())
+	require.True(t, (&Version{8, 4, 0}).RequiresReplicaTerminology())
+	require.True(t, (&Version{9, 1, 0}).RequiresReplicaTerminology())
+
+	require.False(t, (&Version{5, 7, 44}).SupportsSemiSyncSourcePlugin())
+	require.False(t, (&Version{8, 0, 25}).SupportsSemiSyncSourcePlugin())
+	require.True(t, (&Version{8, 0, 26}).SupportsSemiSyncSourcePlugin())
+	require.True(t, (&Version{9, 0, 0}).SupportsSemiSyncSourcePlugin())
+}
+
+func TestReplicationQueryByVersion(t *testing.T) {
+	n := newPolicyTestNode(t)
+	n.version = &Version{8, 0, 39}
+	require.Equal(t, queryChangeMaster, n.replicationQuery(queryChangeMaster))
+	require.Equal(t, queryStopSlave, n.replicationQuery(queryStopSlave))
+
+	n.version = &Version{8, 4, 2}
+	require.Equal(t, queryChangeReplicationSource, n.replicationQuery(queryChangeMaster))
+	require.Equal(t, queryStopReplica, n.replicationQuery(queryStopSlave))
+	require.Equal(t, queryListReplicaSideDisabledEvents, n.replicationQuery(queryListSlavesideDisabledEvents))
+	require.Equal(t, queryGetVersion, n.replicationQuery(queryGetVersion))
+	require.Equal(t, querySemiSyncSourceStatus, n.semiSyncQuery(querySemiSyncStatus))
+
+	n = newPolicyTestNode(t)
+	n.version = &Version{5, 7, 44}
+	require.Equal(t, querySemiSyncSetMaster, n.semiSyncQuery(querySemiSyncSetMaster))
+	require.Equal(t, queryStartSlave, n.replicationQuery(queryStartSlave))
+}
+
+func TestReplicaQueriesDefined(t *testing.T) {
+	for legacy, replica := range replicaQueries {
+		require.NotEmpty(t, DefaultQueries[legacy], legacy)
+		require.NotEmpty(t, DefaultQueries[replica], replica)
+		require.NotContains(t, DefaultQueries[replica], "SLAVE", replica)
+		require.NotContains(t, DefaultQueries[replica], "MASTER", replica)
+	}
+	for legacy, source := range semiSyncSourceQueries {
+		require.NotEmpty(t, DefaultQueries[legacy], legacy)
+		require.NotContains(t, DefaultQueries[source], "rpl_semi_sync_master", source)
+		require.NotContains(t, DefaultQueries[source], "rpl_semi_sync_slave", source)
+		require.NotContains(t, DefaultQueries[source], "from slave", source)
+	}
+}
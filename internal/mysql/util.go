@@ -1,6 +1,11 @@
 package mysql
 
-import "github.com/go-sql-driver/mysql"
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
 
 var dubiousErrorNumbers = []uint16{
 	1040, // Symbol: ER_CON_COUNT_ERROR; SQLSTATE: 08004
@@ -16,8 +21,15 @@ var dubiousErrorNumbers = []uint16{
 const (
 	channelDoesNotExists = 3074 // Symbol: ER_REPLICA_CHANNEL_DOES_NOT_EXIST; SQLSTATE: HY000
 	tableDoesNotExists   = 1146 // Symbol: ER_NO_SUCH_TABLE; SQLSTATE: 42S02
+	cloneRestartFailed   = 3707 // Symbol: ER_CLONE_RESTART_FAILED; SQLSTATE: HY000
 )
 
+var readOnlyErrorNumbers = []uint16{
+	1290, // Symbol: ER_OPTION_PREVENTS_STATEMENT; SQLSTATE: HY000
+	1792, // Symbol: ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION; SQLSTATE: 25006
+	1836, // Symbol: ER_READ_ONLY_MODE; SQLSTATE: HY000
+}
+
 // IsErrorDubious check that error may be caused by misconfiguration, mysync/scripts bugs
 // and not related to MySQL/network failure
 func IsErrorDubious(err error) bool {
@@ -63,3 +75,35 @@ func IsErrorTableDoesNotExists(err error) bool {
 	}
 	return false
 }
+
+// IsErrorCloneRestartFailed checks that data was cloned, but mysqld is not managed
+// by supervisor and should be restarted manually
+func IsErrorCloneRestartFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return false
+	}
+	return mysqlErr.Number == cloneRestartFailed
+}
+
+// IsErrorConnectionLost checks that connection to mysqld was dropped, e.g. by its restart
+func IsErrorConnectionLost(err error) bool {
+	return errors.Is(err, mysql.ErrInvalidConn) || errors.Is(err, driver.ErrBadConn)
+}
+
+// IsErrorReadOnly checks that write was rejected, because server or transaction is read-only
+func IsErrorReadOnly(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	for _, errno := range readOnlyErrorNumbers {
+		if mysqlErr.Number == errno {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Package statsd implements push of metrics in StatsD and DogStatsD formats
+package statsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPacketSize keeps packets below common MTU, so they are not fragmented
+const maxPacketSize = 1432
+
+// Client buffers metrics and sends them over UDP on Flush
+type Client struct {
+	conn      net.Conn
+	prefix    string
+	tags      []string
+	dogStatsD bool
+	mu        sync.Mutex
+	buf       bytes.Buffer
+}
+
+// NewClient creates client sending metrics to address. Tags are sent only in DogStatsD format,
+// plain StatsD has no tags, so values of metric tags (e.g. host) are appended to metric name instead,
+// otherwise metrics of different hosts would overwrite each other
+func NewClient(address, prefix string, tags []string, dogStatsD bool) (*Client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd %s: %v", address, err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &Client{conn: conn, prefix: prefix, tags: tags, dogStatsD: dogStatsD}, nil
+}
+
+// Gauge sets value of metric
+func (c *Client) Gauge(name string, value float64, tags ...string) {
+	c.add(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// Count increments counter
+func (c *Client) Count(name string, value int64, tags ...string) {
+	c.add(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+// Timing reports duration in milliseconds
+func (c *Client) Timing(name string, ms float64, tags ...string) {
+	c.add(name, strconv.FormatFloat(ms, 'f', 3, 64), "ms", tags)
+}
+
+func (c *Client) format(name, value, kind string, tags []string) string {
+	if !c.dogStatsD {
+		for _, tag := range tags {
+			name += "." + nameComponent(tag)
+		}
+	}
+	line := c.prefix + name + ":" + value + "|" + kind
+	if c.dogStatsD {
+		all := append(append([]string{}, c.tags...), tags...)
+		if len(all) > 0 {
+			line += "|#" + strings.Join(all, ",")
+		}
+	}
+	return line
+}
+
+// nameComponent turns value of tag key:value into single component of metric name,
+// e.g. host:db1.example.net becomes db1_example_net
+func nameComponent(tag string) string {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		tag = tag[i+1:]
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', '|', '@', '#', ' ':
+			return '_'
+		}
+		return r
+	}, tag)
+}
+
+func (c *Client) add(name, value, kind string, tags []string) {
+	line := c.format(name, value, kind, tags)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.buf.Len() > 0 && c.buf.Len()+len(line)+1 > maxPacketSize {
+		c.flushLocked()
+	}
+	if c.buf.Len() > 0 {
+		c.buf.WriteByte('\n')
+	}
+	c.buf.WriteString(line)
+}
+
+// Flush sends buffered metrics
+func (c *Client) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flushLocked()
+}
+
+func (c *Client) flushLocked() error {
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	_, err := c.conn.Write(c.buf.Bytes())
+	c.buf.Reset()
+	return err
+}
+
+// Close flushes pending metrics and closes connection
+func (c *Client) Close() error {
+	err := c.Flush()
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
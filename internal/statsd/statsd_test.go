@@ -0,0 +1,63 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func listen(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func receive(t *testing.T, conn *net.UDPConn) string {
+	buf := make([]byte, 65536)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestStatsD(t *testing.T) {
+	server := listen(t)
+	client, err := NewClient(server.LocalAddr().String(), "mysync", []string{"cluster:c1"}, false)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	client.Gauge("replication.lag", 1.5, "host:db1.example.net")
+	client.Count("events", 1)
+	client.Timing("switchover.duration", 250)
+	require.NoError(t, client.Flush())
+	// without tags host is distinguished by metric name
+	require.Equal(t, "mysync.replication.lag.db1_example_net:1.5|g\nmysync.events:1|c\nmysync.switchover.duration:250.000|ms", receive(t, server))
+}
+
+func TestDogStatsD(t *testing.T) {
+	server := listen(t)
+	client, err := NewClient(server.LocalAddr().String(), "mysync.", []string{"cluster:c1"}, true)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	client.Gauge("mysql.alive", 1, "host:h1")
+	client.Gauge("mysql.is_master", 0)
+	require.NoError(t, client.Flush())
+	require.Equal(t, "mysync.mysql.alive:1|g|#cluster:c1,host:h1\nmysync.mysql.is_master:0|g|#cluster:c1", receive(t, server))
+}
+
+func TestPacketSplit(t *testing.T) {
+	server := listen(t)
+	client, err := NewClient(server.LocalAddr().String(), "", nil, false)
+	require.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	name := strings.Repeat("m", 1000)
+	client.Gauge(name, 1)
+	client.Gauge(name, 2)
+	require.NoError(t, client.Flush())
+	require.Equal(t, name+":1|g", receive(t, server))
+	require.Equal(t, name+":2|g", receive(t, server))
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+)
+
+// GetCPULoad is not supported on this platform
+func GetCPULoad() (float64, error) {
+	return 0, errors.New("cpu load is not supported on this platform")
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package util
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// MakeTerminalRaw disables input echo and line buffering on terminal
+// and returns function restoring previous terminal state
+func MakeTerminalRaw(fd int) (func() error, error) {
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return nil, err
+	}
+	raw := *termios
+	raw.Lflag &^= unix.ECHO | unix.ICANON
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+	}, nil
+}
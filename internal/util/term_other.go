@@ -0,0 +1,12 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+)
+
+// MakeTerminalRaw is not supported on this platform
+func MakeTerminalRaw(fd int) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
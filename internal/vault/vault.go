@@ -0,0 +1,182 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client is a minimal client of HashiCorp Vault HTTP API
+type Client struct {
+	address string
+	token   string
+	http    *http.Client
+}
+
+// Lease describes dynamic secret, which should be renewed to stay valid
+type Lease struct {
+	ID        string
+	Duration  time.Duration
+	Renewable bool
+}
+
+// Credentials is a user-password pair read from Vault
+type Credentials struct {
+	Username string
+	Password string
+	Lease    *Lease
+}
+
+type secretResponse struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int64                  `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Errors        []string               `json:"errors"`
+}
+
+// NewClient creates Vault client. Token is read from tokenFile or VAULT_TOKEN environment variable
+func NewClient(address, tokenFile, caCert string, timeout time.Duration) (*Client, error) {
+	token := os.Getenv("VAULT_TOKEN")
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token: %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault token is not set")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse vault CA")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &Client{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		http:    &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+func (c *Client) request(method, path string, body interface{}) (*secretResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", c.address, strings.TrimPrefix(path, "/")), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	secret := new(secretResponse)
+	err = json.NewDecoder(resp.Body).Decode(secret)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse vault response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("vault %s %s: %s %v", method, path, resp.Status, secret.Errors)
+	}
+	return secret, nil
+}
+
+func stringField(data map[string]interface{}, key string) (string, error) {
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret", key)
+	}
+	return value, nil
+}
+
+// ReadKV reads credentials from KV secret engine. Both v1 and v2 (with data/ in path) are supported
+func (c *Client) ReadKV(path, usernameKey, passwordKey string) (*Credentials, error) {
+	secret, err := c.request(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	data := secret.Data
+	// KV v2 wraps secret into data with metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	creds := new(Credentials)
+	if usernameKey != "" {
+		creds.Username, err = stringField(data, usernameKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	creds.Password, err = stringField(data, passwordKey)
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// ReadDatabaseCreds issues short-lived credentials from database secret engine, e.g. database/creds/mysync
+func (c *Client) ReadDatabaseCreds(path string) (*Credentials, error) {
+	secret, err := c.request(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	creds := &Credentials{
+		Lease: &Lease{
+			ID:        secret.LeaseID,
+			Duration:  time.Duration(secret.LeaseDuration) * time.Second,
+			Renewable: secret.Renewable,
+		},
+	}
+	creds.Username, err = stringField(secret.Data, "username")
+	if err != nil {
+		return nil, err
+	}
+	creds.Password, err = stringField(secret.Data, "password")
+	if err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// RenewLease extends lease and returns its new duration
+func (c *Client) RenewLease(lease *Lease) (time.Duration, error) {
+	secret, err := c.request(http.MethodPut, "sys/leases/renew", map[string]interface{}{
+		"lease_id":  lease.ID,
+		"increment": int64(lease.Duration.Seconds()),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secret.LeaseDuration) * time.Second, nil
+}
+
+// RevokeLease revokes lease, so credentials issued with it stop working immediately
+func (c *Client) RevokeLease(lease *Lease) error {
+	_, err := c.request(http.MethodPut, "sys/leases/revoke", map[string]interface{}{
+		"lease_id": lease.ID,
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/mysync":
+			_, _ = w.Write([]byte(`{"data": {"data": {"user": "mysync", "pass": "secret"}, "metadata": {}}}`))
+		case "/v1/sys/leases/revoke":
+			require.Equal(t, http.MethodPut, r.Method)
+			w.WriteHeader(http.StatusNoContent)
+		case "/v1/database/creds/mysync":
+			_, _ = w.Write([]byte(`{"lease_id": "database/creds/mysync/1", "lease_duration": 3600, "renewable": true,
+				"data": {"username": "v-mysync-1", "password": "dynamic"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "token")
+	client, err := NewClient(server.URL, "", "", time.Second)
+	require.NoError(t, err)
+
+	creds, err := client.ReadKV("secret/data/mysync", "user", "pass")
+	require.NoError(t, err)
+	require.Equal(t, "mysync", creds.Username)
+	require.Equal(t, "secret", creds.Password)
+	require.Nil(t, creds.Lease)
+
+	creds, err = client.ReadDatabaseCreds("database/creds/mysync")
+	require.NoError(t, err)
+	require.Equal(t, "v-mysync-1", creds.Username)
+	require.Equal(t, &Lease{ID: "database/creds/mysync/1", Duration: time.Hour, Renewable: true}, creds.Lease)
+
+	require.NoError(t, client.RevokeLease(creds.Lease))
+
+	_, err = client.ReadKV("secret/data/missing", "user", "pass")
+	require.Error(t, err)
+}
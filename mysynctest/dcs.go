@@ -0,0 +1,355 @@
+// Package mysynctest provides test doubles of mysync dependencies: in-memory DCS and fake MySQL server.
+// They allow to unit-test orchestration logic without ZooKeeper and MySQL
+package mysynctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yandex/mysync/internal/dcs"
+)
+
+type memNode struct {
+	data []byte
+	// session which owns ephemeral node
+	owner *MemDCS
+}
+
+// MemStore is in-memory storage shared by DCS sessions of several emulated hosts
+type MemStore struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode
+}
+
+// NewMemStore creates empty storage
+func NewMemStore() *MemStore {
+	return &MemStore{nodes: make(map[string]*memNode)}
+}
+
+func cleanPath(path string) string {
+	parts := strings.Split(path, "/")
+	clean := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			clean = append(clean, part)
+		}
+	}
+	return strings.Join(clean, "/")
+}
+
+// Session creates DCS client of host, locks acquired by it are owned by hostname
+func (s *MemStore) Session(hostname string) *MemDCS {
+	return &MemDCS{store: s, owner: dcs.LockOwner{Hostname: hostname, Pid: os.Getpid()}, connected: true}
+}
+
+// Dump returns raw JSON values of all nodes, it is useful for assertions and debugging
+func (s *MemStore) Dump() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]string, len(s.nodes))
+	for path, node := range s.nodes {
+		result[path] = string(node.data)
+	}
+	return result
+}
+
+// children returns names of immediate children of path, caller should hold lock
+func (s *MemStore) children(path string) []string {
+	prefix := ""
+	if path != "" {
+		prefix = path + "/"
+	}
+	names := make(map[string]bool)
+	for p := range s.nodes {
+		if p == path || !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		names[strings.SplitN(strings.TrimPrefix(p, prefix), "/", 2)[0]] = true
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// exists checks node or any of its descendants exists, caller should hold lock
+func (s *MemStore) exists(path string) bool {
+	if _, ok := s.nodes[path]; ok || path == "" {
+		return true
+	}
+	return len(s.children(path)) > 0
+}
+
+// MemDCS is in-memory implementation of dcs.DCS. Values are stored as JSON, like in ZooKeeper
+type MemDCS struct {
+	store        *MemStore
+	owner        dcs.LockOwner
+	mu           sync.Mutex
+	connected    bool
+	onDisconnect func() error
+}
+
+var _ dcs.DCS = &MemDCS{}
+
+// NewMemDCS creates DCS of single host with its own storage
+func NewMemDCS(hostname string) *MemDCS {
+	return NewMemStore().Session(hostname)
+}
+
+// SetConnected emulates loss and restoration of connection.
+// Loss of connection expires session: ephemeral nodes and locks of host are removed
+func (m *MemDCS) SetConnected(connected bool) error {
+	m.mu.Lock()
+	wasConnected := m.connected
+	m.connected = connected
+	callback := m.onDisconnect
+	m.mu.Unlock()
+	if !wasConnected || connected {
+		return nil
+	}
+	m.store.mu.Lock()
+	for path, node := range m.store.nodes {
+		if node.owner == m {
+			delete(m.store.nodes, path)
+		}
+	}
+	m.store.mu.Unlock()
+	if callback != nil {
+		return callback()
+	}
+	return nil
+}
+
+func (m *MemDCS) checkConnected() error {
+	if !m.IsConnected() {
+		return fmt.Errorf("memdcs: %s is disconnected", m.owner.Hostname)
+	}
+	return nil
+}
+
+func (m *MemDCS) IsConnected() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected
+}
+
+func (m *MemDCS) WaitConnected(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !m.IsConnected() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func (m *MemDCS) Initialize() {}
+
+func (m *MemDCS) SetDisconnectCallback(callback func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onDisconnect = callback
+}
+
+func (m *MemDCS) AcquireLock(path string) bool {
+	if m.checkConnected() != nil {
+		return false
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	node, ok := m.store.nodes[path]
+	if !ok {
+		data, _ := json.Marshal(m.owner)
+		m.store.nodes[path] = &memNode{data: data, owner: m}
+		return true
+	}
+	var owner dcs.LockOwner
+	if err := json.Unmarshal(node.data, &owner); err != nil {
+		return false
+	}
+	return owner == m.owner
+}
+
+func (m *MemDCS) ReleaseLock(path string) {
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	node, ok := m.store.nodes[path]
+	if !ok {
+		return
+	}
+	var owner dcs.LockOwner
+	if err := json.Unmarshal(node.data, &owner); err == nil && owner == m.owner {
+		delete(m.store.nodes, path)
+	}
+}
+
+func (m *MemDCS) create(path string, value interface{}, ephemeral bool) error {
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if _, ok := m.store.nodes[path]; ok {
+		return dcs.ErrExists
+	}
+	node := &memNode{data: data}
+	if ephemeral {
+		node.owner = m
+	}
+	m.store.nodes[path] = node
+	return nil
+}
+
+func (m *MemDCS) Create(path string, value interface{}) error {
+	return m.create(path, value, false)
+}
+
+func (m *MemDCS) CreateEphemeral(path string, value interface{}) error {
+	return m.create(path, value, true)
+}
+
+func (m *MemDCS) set(path string, value interface{}, ephemeral bool) error {
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	node, ok := m.store.nodes[path]
+	if !ok {
+		node = &memNode{}
+		if ephemeral {
+			node.owner = m
+		}
+		m.store.nodes[path] = node
+	} else if ephemeral && node.owner == nil {
+		return fmt.Errorf("node %s exists, but not ephemeral, can't make it ephemeral", path)
+	}
+	node.data = data
+	return nil
+}
+
+func (m *MemDCS) Set(path string, value interface{}) error {
+	return m.set(path, value, false)
+}
+
+func (m *MemDCS) SetEphemeral(path string, value interface{}) error {
+	return m.set(path, value, true)
+}
+
+func (m *MemDCS) Get(path string, dest interface{}) error {
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	node, ok := m.store.nodes[path]
+	var data []byte
+	if ok {
+		data = node.data
+	}
+	m.store.mu.Unlock()
+	if !ok {
+		return dcs.ErrNotFound
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return dcs.ErrMalformed
+	}
+	return nil
+}
+
+func (m *MemDCS) Delete(path string) error {
+	if err := m.checkConnected(); err != nil {
+		return err
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if len(m.store.children(path)) > 0 {
+		return fmt.Errorf("node %s has children", path)
+	}
+	delete(m.store.nodes, path)
+	return nil
+}
+
+func (m *MemDCS) GetTree(path string) (interface{}, error) {
+	if err := m.checkConnected(); err != nil {
+		return nil, err
+	}
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	return m.store.tree(cleanPath(path))
+}
+
+func (s *MemStore) tree(path string) (interface{}, error) {
+	if !s.exists(path) {
+		return nil, dcs.ErrNotFound
+	}
+	children := s.children(path)
+	if len(children) == 0 {
+		node := s.nodes[path]
+		if node == nil || len(node.data) == 0 {
+			return nil, nil
+		}
+		var ret interface{}
+		err := json.Unmarshal(node.data, &ret)
+		return ret, err
+	}
+	ret := make(map[string]interface{}, len(children))
+	for _, name := range children {
+		child := name
+		if path != "" {
+			child = path + "/" + name
+		}
+		var err error
+		ret[name], err = s.tree(child)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (m *MemDCS) GetChildren(path string) ([]string, error) {
+	if err := m.checkConnected(); err != nil {
+		return nil, err
+	}
+	path = cleanPath(path)
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+	if !m.store.exists(path) {
+		return nil, dcs.ErrNotFound
+	}
+	return m.store.children(path), nil
+}
+
+func (m *MemDCS) SessionInfo() dcs.SessionInfo {
+	state := "disconnected"
+	if m.IsConnected() {
+		state = "connected"
+	}
+	return dcs.SessionInfo{Connected: m.IsConnected(), State: state, Server: "memory"}
+}
+
+func (m *MemDCS) Close() {
+	_ = m.SetConnected(false)
+}
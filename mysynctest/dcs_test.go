@@ -0,0 +1,55 @@
+package mysynctest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/dcs"
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestMemDCS(t *testing.T) {
+	store := mysynctest.NewMemStore()
+	first := store.Session("mysql1")
+	second := store.Session("mysql2")
+
+	require.NoError(t, first.Create("ha_nodes/mysql1", map[string]string{"role": "master"}))
+	require.Equal(t, dcs.ErrExists, second.Create("ha_nodes/mysql1", nil))
+	require.NoError(t, second.SetEphemeral("health/mysql2", 42))
+
+	var value map[string]string
+	require.NoError(t, second.Get("/ha_nodes/mysql1", &value))
+	require.Equal(t, "master", value["role"])
+	require.Equal(t, dcs.ErrNotFound, second.Get("ha_nodes/mysql3", &value))
+
+	children, err := first.GetChildren("ha_nodes")
+	require.NoError(t, err)
+	require.Equal(t, []string{"mysql1"}, children)
+	tree, err := first.GetTree("")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"ha_nodes": map[string]interface{}{"mysql1": map[string]interface{}{"role": "master"}},
+		"health":   map[string]interface{}{"mysql2": float64(42)},
+	}, tree)
+
+	require.True(t, first.AcquireLock("manager"))
+	require.True(t, first.AcquireLock("manager"))
+	require.False(t, second.AcquireLock("manager"))
+	second.ReleaseLock("manager")
+	require.False(t, second.AcquireLock("manager"))
+
+	// losing session drops ephemeral nodes and locks
+	require.NoError(t, first.SetConnected(false))
+	require.False(t, first.SessionInfo().Connected)
+	require.True(t, second.AcquireLock("manager"))
+	require.Error(t, first.Get("ha_nodes/mysql1", &value))
+	require.NoError(t, second.Get("ha_nodes/mysql1", &value))
+
+	second.Close()
+	_, err = first.GetChildren("health")
+	require.Error(t, err)
+	require.NoError(t, first.SetConnected(true))
+	_, err = first.GetChildren("health")
+	require.Equal(t, dcs.ErrNotFound, err)
+}
@@ -0,0 +1,377 @@
+package mysynctest
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/server"
+
+	"github.com/yandex/mysync/internal/mysql"
+)
+
+const (
+	FakeMySQLUser     = "mysync"
+	FakeMySQLPassword = "mysync"
+)
+
+var (
+	namedParamRegex   = regexp.MustCompile(`:[a-zA-Z_]+`)
+	quotedStringRegex = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	numberRegex       = regexp.MustCompile(`\b\d+\b`)
+	spacesRegex       = regexp.MustCompile(`\s+`)
+)
+
+// normalizeQuery replaces literals and placeholders, so query text
+// may be matched regardless of parameter values and the way they were passed
+func normalizeQuery(query string) string {
+	query = namedParamRegex.ReplaceAllString(query, "?")
+	query = quotedStringRegex.ReplaceAllString(query, "?")
+	query = numberRegex.ReplaceAllString(query, "?")
+	query = spacesRegex.ReplaceAllString(query, " ")
+	return strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";")))
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// ReplicaState describes replication reported by fake server in SHOW REPLICA STATUS
+type ReplicaState struct {
+	SourceHost string
+	SourcePort int
+	IORunning  bool
+	SQLRunning bool
+	// Lag is nil when lag is unknown, eg. replication is stopped
+	Lag            *float64
+	LastIOErrno    int
+	LastIOError    string
+	LastSQLErrno   int
+	LastError      string
+	RetrievedGTIDs string
+}
+
+// FakeMySQL is scriptable MySQL server speaking real protocol.
+// It recognizes mysync queries by their text in mysql.DefaultQueries,
+// so mysql.Node may be used against it without modifications
+type FakeMySQL struct {
+	listener net.Listener
+	queries  map[string]string
+
+	mu            sync.Mutex
+	uuid          string
+	gtidExecuted  string
+	readOnly      bool
+	superReadOnly bool
+	replica       *ReplicaState
+	errors        map[string]error
+	results       map[string]fakeResult
+	executed      []string
+}
+
+// NewFakeMySQL starts fake server on random port of loopback interface
+func NewFakeMySQL(uuid string) (*FakeMySQL, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	f := &FakeMySQL{
+		listener: listener,
+		queries:  make(map[string]string, len(mysql.DefaultQueries)),
+		uuid:     uuid,
+		errors:   make(map[string]error),
+		results:  make(map[string]fakeResult),
+	}
+	for name, query := range mysql.DefaultQueries {
+		if query != "" {
+			f.queries[normalizeQuery(query)] = name
+		}
+	}
+	go f.serve()
+	return f, nil
+}
+
+func (f *FakeMySQL) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			c, err := server.NewConn(conn, FakeMySQLUser, FakeMySQLPassword, &fakeHandler{f})
+			if err != nil {
+				return
+			}
+			for !c.Closed() {
+				if err := c.HandleCommand(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Close stops accepting new connections
+func (f *FakeMySQL) Close() error {
+	return f.listener.Close()
+}
+
+// Host returns address fake server listens on
+func (f *FakeMySQL) Host() string {
+	return "127.0.0.1"
+}
+
+// Port returns port fake server listens on
+func (f *FakeMySQL) Port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Credentials returns user and password accepted by fake server
+func (f *FakeMySQL) Credentials() (string, string) {
+	return FakeMySQLUser, FakeMySQLPassword
+}
+
+// SetGTIDExecuted sets @@gtid_executed reported by server
+func (f *FakeMySQL) SetGTIDExecuted(gtids string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gtidExecuted = gtids
+}
+
+// SetReadOnly sets @@read_only and @@super_read_only reported by server
+func (f *FakeMySQL) SetReadOnly(readOnly, superReadOnly bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readOnly = readOnly || superReadOnly
+	f.superReadOnly = superReadOnly
+}
+
+// ReadOnly returns current @@read_only and @@super_read_only
+func (f *FakeMySQL) ReadOnly() (bool, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readOnly, f.superReadOnly
+}
+
+// SetReplicaState sets replication status, nil means that server is not a replica
+func (f *FakeMySQL) SetReplicaState(state *ReplicaState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replica = state
+}
+
+// SetReplicationLag changes lag of running replication
+func (f *FakeMySQL) SetReplicationLag(lag float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.replica != nil {
+		f.replica.Lag = &lag
+	}
+}
+
+// SetError makes server to fail query with given name (see mysql.DefaultQueries), nil error clears failure
+func (f *FakeMySQL) SetError(queryName string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errors, queryName)
+		return
+	}
+	f.errors[queryName] = err
+}
+
+// SetResult overrides response to query with given name
+func (f *FakeMySQL) SetResult(queryName string, columns []string, rows [][]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results[queryName] = fakeResult{columns: columns, rows: rows}
+}
+
+// Executed returns names of queries executed by server in order of execution.
+// Queries missing in mysql.DefaultQueries are reported with their text
+func (f *FakeMySQL) Executed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.executed...)
+}
+
+func boolToInt(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Yes"
+	}
+	return "No"
+}
+
+func (f *FakeMySQL) replicaStatus(columns map[string]string) fakeResult {
+	if f.replica == nil {
+		return fakeResult{columns: []string{columns["host"]}}
+	}
+	r := f.replica
+	var lag interface{}
+	if r.Lag != nil {
+		lag = *r.Lag
+	}
+	return fakeResult{
+		columns: []string{
+			columns["host"], columns["port"], columns["file"], columns["pos"], columns["io"], columns["sql"],
+			"Last_Error", "Retrieved_Gtid_Set", "Executed_Gtid_Set",
+			"Last_IO_Errno", "Last_IO_Error", "Last_SQL_Errno", columns["lag"],
+		},
+		rows: [][]interface{}{{
+			r.SourceHost, int64(r.SourcePort), "mysql-bin.000001", int64(4), yesNo(r.IORunning), yesNo(r.SQLRunning),
+			r.LastError, r.RetrievedGTIDs, f.gtidExecuted,
+			int64(r.LastIOErrno), r.LastIOError, int64(r.LastSQLErrno), lag,
+		}},
+	}
+}
+
+var (
+	replicaColumns = map[string]string{
+		"host": "Source_Host", "port": "Source_Port", "file": "Source_Log_File", "pos": "Read_Source_Log_Pos",
+		"io": "Replica_IO_Running", "sql": "Replica_SQL_Running", "lag": "Seconds_Behind_Source",
+	}
+	slaveColumns = map[string]string{
+		"host": "Master_Host", "port": "Master_Port", "file": "Master_Log_File", "pos": "Read_Master_Log_Pos",
+		"io": "Slave_IO_Running", "sql": "Slave_SQL_Running", "lag": "Seconds_Behind_Master",
+	}
+)
+
+// execute emulates query and returns its result, nil result means OK packet
+func (f *FakeMySQL) execute(query string) (*fakeResult, error) {
+	name, known := f.queries[normalizeQuery(query)]
+	if !known {
+		name = query
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.executed = append(f.executed, name)
+	if err, ok := f.errors[name]; ok {
+		return nil, err
+	}
+	if res, ok := f.results[name]; ok {
+		return &res, nil
+	}
+	if !known {
+		return nil, nil
+	}
+	var res fakeResult
+	switch name {
+	case "ping":
+		res = fakeResult{[]string{"Ok"}, [][]interface{}{{int64(1)}}}
+	case "get_version":
+		res = fakeResult{[]string{"MajorVersion", "MinorVersion", "PatchVersion"}, [][]interface{}{{int64(8), int64(0), int64(35)}}}
+	case "gtid_executed":
+		res = fakeResult{[]string{"Executed_Gtid_Set"}, [][]interface{}{{f.gtidExecuted}}}
+	case "get_uuid":
+		res = fakeResult{[]string{"server_uuid"}, [][]interface{}{{f.uuid}}}
+	case "is_readonly":
+		res = fakeResult{[]string{"ReadOnly", "SuperReadOnly"}, [][]interface{}{{boolToInt(f.readOnly), boolToInt(f.superReadOnly)}}}
+	case "set_readonly":
+		f.readOnly, f.superReadOnly = true, true
+		return nil, nil
+	case "set_readonly_no_super":
+		f.readOnly, f.superReadOnly = true, false
+		return nil, nil
+	case "set_writable":
+		f.readOnly, f.superReadOnly = false, false
+		return nil, nil
+	case "replica_status":
+		res = f.replicaStatus(replicaColumns)
+	case "slave_status":
+		res = f.replicaStatus(slaveColumns)
+	case "stop_replica", "stop_slave":
+		if f.replica != nil {
+			f.replica.IORunning, f.replica.SQLRunning, f.replica.Lag = false, false, nil
+		}
+		return nil, nil
+	case "start_replica", "start_slave":
+		if f.replica != nil {
+			f.replica.IORunning, f.replica.SQLRunning = true, true
+		}
+		return nil, nil
+	case "reset_replica_all", "reset_slave_all":
+		f.replica = nil
+		return nil, nil
+	default:
+		return nil, nil
+	}
+	return &res, nil
+}
+
+func (f *FakeMySQL) handle(query string, binary bool) (*gomysql.Result, error) {
+	res, err := f.execute(query)
+	if err != nil {
+		if _, ok := err.(*gomysql.MyError); ok {
+			return nil, err
+		}
+		return nil, gomysql.NewError(gomysql.ER_UNKNOWN_ERROR, err.Error())
+	}
+	if res == nil {
+		return nil, nil
+	}
+	rows := make([][]interface{}, 0, len(res.rows))
+	for _, row := range res.rows {
+		values := make([]interface{}, 0, len(row))
+		for _, value := range row {
+			// go-mysql encodes empty strings as NULL, while empty byte slices are sent as is
+			if s, ok := value.(string); ok {
+				value = append([]byte{}, s...)
+			}
+			values = append(values, value)
+		}
+		rows = append(rows, values)
+	}
+	rs, err := gomysql.BuildSimpleResultset(res.columns, rows, binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resultset for %q: %v", query, err)
+	}
+	return gomysql.NewResult(rs), nil
+}
+
+type fakeHandler struct {
+	f *FakeMySQL
+}
+
+func (h *fakeHandler) UseDB(dbName string) error {
+	return nil
+}
+
+func (h *fakeHandler) HandleQuery(query string) (*gomysql.Result, error) {
+	return h.f.handle(query, false)
+}
+
+func (h *fakeHandler) HandleFieldList(table string, fieldWildcard string) ([]*gomysql.Field, error) {
+	return nil, gomysql.NewError(gomysql.ER_UNKNOWN_ERROR, "field list is not supported")
+}
+
+func (h *fakeHandler) HandleStmtPrepare(query string) (int, int, interface{}, error) {
+	return strings.Count(query, "?"), 0, nil, nil
+}
+
+func (h *fakeHandler) HandleStmtExecute(context interface{}, query string, args []interface{}) (*gomysql.Result, error) {
+	// arguments do not affect emulated queries, inline them only to keep text of unknown queries readable
+	for _, arg := range args {
+		query = strings.Replace(query, "?", strconv.Quote(fmt.Sprint(arg)), 1)
+	}
+	return h.f.handle(query, true)
+}
+
+func (h *fakeHandler) HandleStmtClose(context interface{}) error {
+	return nil
+}
+
+func (h *fakeHandler) HandleOtherCommand(cmd byte, data []byte) error {
+	return gomysql.NewError(gomysql.ER_UNKNOWN_ERROR, fmt.Sprintf("command %d is not supported", cmd))
+}
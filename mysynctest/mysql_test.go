@@ -0,0 +1,118 @@
+package mysynctest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yandex/mysync/internal/config"
+	"github.com/yandex/mysync/internal/log"
+	"github.com/yandex/mysync/internal/mysql"
+	"github.com/yandex/mysync/mysynctest"
+)
+
+func TestFakeMySQL(t *testing.T) {
+	fake, err := mysynctest.NewFakeMySQL("00000000-0000-0000-0000-000000000001")
+	require.NoError(t, err)
+	defer func() { _ = fake.Close() }()
+
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	configureFakeMySQL(&cfg, fake)
+	logger, err := log.Open("/dev/null", "fatal")
+	require.NoError(t, err)
+	node, err := mysql.NewNode(config.NewHolder(&cfg), logger, fake.Host())
+	require.NoError(t, err)
+	defer func() { _ = node.Close() }()
+
+	ok, err := node.Ping()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	fake.SetGTIDExecuted("00000000-0000-0000-0000-000000000001:1-100")
+	gtid, err := node.GTIDExecuted()
+	require.NoError(t, err)
+	require.Equal(t, "00000000-0000-0000-0000-000000000001:1-100", gtid.ExecutedGtidSet)
+
+	require.NoError(t, node.SetReadOnly(true))
+	ro, superRo, err := node.IsReadOnly()
+	require.NoError(t, err)
+	require.True(t, ro)
+	require.True(t, superRo)
+	require.NoError(t, node.SetWritable())
+	ro, _ = fake.ReadOnly()
+	require.False(t, ro)
+
+	status, err := node.GetReplicaStatus()
+	require.NoError(t, err)
+	require.Nil(t, status)
+
+	lag := 3.5
+	fake.SetReplicaState(&mysynctest.ReplicaState{SourceHost: "mysql1", SourcePort: 3306, IORunning: true, SQLRunning: true, Lag: &lag})
+	status, err = node.GetReplicaStatus()
+	require.NoError(t, err)
+	require.Equal(t, "mysql1", status.GetMasterHost())
+	require.True(t, status.ReplicationRunning())
+	require.Equal(t, 3.5, status.GetReplicationLag().Float64)
+
+	fake.SetError("gtid_executed", errors.New("disk is full"))
+	_, err = node.GTIDExecuted()
+	require.ErrorContains(t, err, "disk is full")
+}
+
+func TestObserveOnly(t *testing.T) {
+	fake, err := mysynctest.NewFakeMySQL("00000000-0000-0000-0000-000000000001")
+	require.NoError(t, err)
+	defer func() { _ = fake.Close() }()
+
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	configureFakeMySQL(&cfg, fake)
+	cfg.ObserveOnly = true
+	logger, err := log.Open("/dev/null", "fatal")
+	require.NoError(t, err)
+	node, err := mysql.NewNode(config.NewHolder(&cfg), logger, fake.Host())
+	require.NoError(t, err)
+	defer func() { _ = node.Close() }()
+
+	require.NoError(t, node.SetReadOnly(true))
+	require.NoError(t, node.ResetSlaveAll())
+	ro, superRo, err := node.IsReadOnly()
+	require.NoError(t, err)
+	require.False(t, ro)
+	require.False(t, superRo)
+	require.NotContains(t, fake.Executed(), "set_readonly")
+	require.NotContains(t, fake.Executed(), "reset_slave_all")
+}
+
+func TestPasswordRotation(t *testing.T) {
+	fake, err := mysynctest.NewFakeMySQL("00000000-0000-0000-0000-000000000001")
+	require.NoError(t, err)
+	defer func() { _ = fake.Close() }()
+
+	cfg, err := config.DefaultConfig()
+	require.NoError(t, err)
+	configureFakeMySQL(&cfg, fake)
+	// new password is not rolled out to server yet
+	cfg.MySQL.Password = "rotated"
+	logger, err := log.Open("/dev/null", "fatal")
+	require.NoError(t, err)
+	node, err := mysql.NewNode(config.NewHolder(&cfg), logger, fake.Host())
+	require.NoError(t, err)
+	defer func() { _ = node.Close() }()
+
+	_, err = node.Ping()
+	require.True(t, mysql.IsAccessDenied(err))
+
+	cfg.SetMySQLPreviousPassword(mysynctest.FakeMySQLPassword)
+	ok, err := node.Ping()
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+// configureFakeMySQL points MySQL connection settings of config to fake server
+func configureFakeMySQL(cfg *config.Config, fake *mysynctest.FakeMySQL) {
+	cfg.MySQL.Port = fake.Port()
+	cfg.MySQL.User, cfg.MySQL.Password = fake.Credentials()
+}